@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/meter"
+)
+
+// runCalibrate fits the power polynomial to the calibration points stored in
+// the configuration, added in the GUI, and saves the coefficients.
+func runCalibrate(args []string) error {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	configPath := fs.String("config", "", "Configuration file path (default: golpm/config.yaml in the user configuration directory)")
+	profile := fs.String("profile", "", "Device profile to calibrate (default: the profile last in use)")
+	dryRun := fs.Bool("dry-run", false, "Print the fit without saving it")
+	fs.Parse(args)
+
+	path, err := config.ResolvePath(*configPath)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadProfile(path, *profile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	coeffs, rSquared, err := meter.FitCalibration(cfg.Calibration.Points)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "c0 = %.6f\nc1 = %.6f\nc2 = %.6f\nc3 = %.6f\nR² = %.6f\n",
+		coeffs[0], coeffs[1], coeffs[2], coeffs[3], rSquared)
+	if *dryRun {
+		return nil
+	}
+
+	cfg.Measurement.PowerPolynomial = coeffs
+	cfg.Calibration.Date = time.Now()
+	if _, ok := cfg.FindProfile(cfg.Profile); ok {
+		cfg.SetProfile(cfg.CurrentProfile(cfg.Profile))
+	}
+	if err := cfg.Save(path); err != nil {
+		return fmt.Errorf("failed to save calibration: %w", err)
+	}
+	log.Printf("Saved calibration from %d points to %s", len(cfg.Calibration.Points), path)
+	return nil
+}
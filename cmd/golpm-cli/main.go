@@ -0,0 +1,144 @@
+// Command golpm-cli drives the laser power meter from the command line, for
+// scripting and automation alongside the GUI. It shares the device, sample
+// and meter packages with the GUI and reads the same configuration file.
+//
+// Usage:
+//
+//	golpm-cli <command> [flags]
+//
+// Commands:
+//
+//	ports      list connected meters
+//	record     capture raw samples to a file
+//	replay     measure the pulses in a raw recording
+//	calibrate  fit the power polynomial to the stored calibration points
+//	measure    print pulses and readings from a live device
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+)
+
+// command is a golpm-cli subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"ports", "List connected meters", runPorts},
+	{"record", "Capture raw samples to a file", runRecord},
+	{"replay", "Measure the pulses in a raw recording", runReplay},
+	{"calibrate", "Fit the power polynomial to the stored calibration points", runCalibrate},
+	{"measure", "Print pulses and readings from a live device", runMeasure},
+}
+
+func main() {
+	// Data goes to stdout, status messages to stderr
+	log.SetOutput(os.Stderr)
+	log.SetFlags(log.Ltime)
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			return
+		}
+	}
+
+	if name != "help" && name != "-h" && name != "-help" && name != "--help" {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	}
+	usage()
+	os.Exit(2)
+}
+
+// usage prints the list of commands.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: golpm-cli <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'golpm-cli <command> -h' for the flags of a command.\n")
+}
+
+// deviceFlags are the flags of the commands that load the configuration and
+// connect to a device.
+type deviceFlags struct {
+	config  string
+	profile string
+	port    string
+	mock    bool
+}
+
+// register adds the flags to fs.
+func (f *deviceFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.config, "config", "", "Configuration file path (default: golpm/config.yaml in the user configuration directory)")
+	fs.StringVar(&f.profile, "profile", "", "Device profile to use (default: the profile last in use)")
+	fs.StringVar(&f.port, "p", "", "Serial port override (e.g., COM3 or /dev/ttyACM0)")
+	fs.BoolVar(&f.mock, "mock", false, "Use mocked device instead of serial port")
+}
+
+// load loads the configuration with the profile and port override applied.
+func (f *deviceFlags) load() (*config.Config, error) {
+	path, err := config.ResolvePath(f.config)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.LoadProfile(path, f.profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if f.port != "" {
+		cfg.Serial.Port = f.port
+	}
+	return cfg, nil
+}
+
+// device creates the mocked or serial device selected by the flags.
+func (f *deviceFlags) device(cfg *config.Config) (lpm.Device, error) {
+	if f.mock {
+		return lpm.NewMock(&cfg.Mock), nil
+	}
+	device, err := lpm.NewFromConfig(&cfg.Serial, lpm.DefaultBufferSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid serial settings: %w", err)
+	}
+	return device, nil
+}
+
+// connect connects device, puts a known head's profile in use and
+// negotiates its sample rate.
+func (f *deviceFlags) connect(ctx context.Context, cfg *config.Config, device lpm.Device) error {
+	if err := device.ConnectContext(ctx); err != nil {
+		if f.mock {
+			return fmt.Errorf("failed to connect to mocked device: %w", err)
+		}
+		return fmt.Errorf("failed to connect to %s: %w", cfg.Serial.Port, err)
+	}
+	if f.mock {
+		log.Printf("Connected to mocked device")
+	} else {
+		log.Printf("Connected to serial port: %s", cfg.Serial.Port)
+		if name, ok := cfg.UseDeviceProfile(device.Info().Serial); ok {
+			log.Printf("Using profile %s of head %s", name, device.Info().Serial)
+		}
+	}
+	lpm.Negotiate(device, &cfg.Serial, f.mock)
+	return nil
+}
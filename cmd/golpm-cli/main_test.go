@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	json, err := parseFormat("json")
+	require.NoError(t, err)
+	assert.True(t, json)
+	json, err = parseFormat("text")
+	require.NoError(t, err)
+	assert.False(t, json)
+	_, err = parseFormat("csv")
+	assert.Error(t, err)
+}
+
+func TestRunCalibrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := config.Default()
+	cfg.Calibration.Points = []config.CalibrationPoint{
+		{Slope: 0.001, Power: 10},
+		{Slope: 0.003, Power: 30},
+	}
+	require.NoError(t, cfg.Save(path))
+
+	// A dry run leaves the configuration alone
+	require.NoError(t, runCalibrate([]string{"-config", path, "-dry-run"}))
+	loaded, err := config.Load(path)
+	require.NoError(t, err)
+	assert.True(t, loaded.Calibration.Date.IsZero())
+
+	require.NoError(t, runCalibrate([]string{"-config", path}))
+	loaded, err = config.Load(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Measurement.PowerPolynomial, 4)
+	assert.InDelta(t, 10000.0, loaded.Measurement.PowerPolynomial[1], 1e-3)
+	assert.False(t, loaded.Calibration.Date.IsZero())
+
+	// A profile is calibrated in its own entry
+	loaded.SetProfile(loaded.CurrentProfile("Head A"))
+	loaded.Measurement.PowerPolynomial = []float64{0, 1, 0, 0}
+	loaded.SetProfile(loaded.CurrentProfile("Head B"))
+	loaded.Profile = "Head B"
+	require.NoError(t, loaded.Save(path))
+	require.NoError(t, runCalibrate([]string{"-config", path, "-profile", "Head A"}))
+	loaded, err = config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Head A", loaded.Profile)
+	headA, _ := loaded.FindProfile("Head A")
+	assert.InDelta(t, 10000.0, headA.PowerPolynomial[1], 1e-3)
+	headB, _ := loaded.FindProfile("Head B")
+	assert.Equal(t, []float64{0, 1, 0, 0}, headB.PowerPolynomial, "other profiles keep their calibration")
+
+	// Too few points
+	loaded.Calibration.Points = loaded.Calibration.Points[:1]
+	require.NoError(t, loaded.Save(path))
+	assert.ErrorIs(t, runCalibrate([]string{"-config", path}), meter.ErrTooFewCalibrationPoints)
+}
+
+func TestReplay(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	raw := make([]lpm.RawSample, 100)
+	for i := range raw {
+		raw[i] = lpm.RawSample{Timestamp: t0.Add(time.Duration(i) * 20 * time.Millisecond), Voltage: 50000}
+	}
+
+	var out bytes.Buffer
+	cfg := config.Default()
+	replay(cfg, raw, meter.NewReporter(&out, false, time.Second))
+
+	// The recorded rate drives the meter, and readings follow recording time
+	assert.InDelta(t, 50.0, cfg.Serial.NegotiatedRate, 1e-9)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[1], "14:03:08.980 reading"), lines[1])
+}
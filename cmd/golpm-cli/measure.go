@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// runMeasure runs the device, sample pipeline and meter, printing measured
+// pulses and periodic readings until interrupted or for the given duration.
+func runMeasure(args []string) error {
+	fs := flag.NewFlagSet("measure", flag.ExitOnError)
+	var dev deviceFlags
+	dev.register(fs)
+	format := fs.String("format", "text", "Output format: text or json")
+	interval := fs.Duration("interval", time.Second, "Time between readings (0 prints pulses only)")
+	duration := fs.Duration("duration", 0, "Time to measure (0 measures until interrupted)")
+	statistics := fs.Bool("statistics", false, "Calculate and log statistics (variability, stddev, optimal EMA parameters)")
+	fs.Parse(args)
+
+	json, err := parseFormat(*format)
+	if err != nil {
+		return err
+	}
+	cfg, err := dev.load()
+	if err != nil {
+		return err
+	}
+	device, err := dev.device(cfg)
+	if err != nil {
+		return err
+	}
+	device = lpm.NewWatchdog(device, cfg.Serial.StaleTimeout, func(healthy bool) {
+		if !healthy {
+			log.Printf("Device stopped sending samples")
+		}
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+	if err := dev.connect(ctx, cfg, device); err != nil {
+		return err
+	}
+
+	// The meter is created after negotiating, as its windows may be in samples
+	powerMeter := meter.New(cfg)
+	powerMeter.OnUpdate(meter.NewReporter(os.Stdout, json, *interval).Update)
+
+	meterDone := make(chan struct{})
+	go func() {
+		defer close(meterDone)
+		powerMeter.ProcessSamples(sample.NewPipeline(cfg, *statistics, device.Samples()))
+	}()
+
+	select {
+	case <-ctx.Done():
+	case <-meterDone:
+		log.Printf("Device stream ended")
+	}
+	device.Close()
+	<-meterDone
+	return nil
+}
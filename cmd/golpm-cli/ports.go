@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/itohio/golpm/pkg/lpm"
+)
+
+// runPorts lists the serial ports with a meter attached, one per line.
+func runPorts(args []string) error {
+	fs := flag.NewFlagSet("ports", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 10*time.Second, "Time allowed for probing the ports")
+	all := fs.Bool("all", false, "Probe every serial port, not only those of the supported boards; may reset other boards")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	found, err := lpm.Discover(ctx, lpm.DiscoverOptions{AllPorts: *all})
+	if err != nil {
+		return err
+	}
+
+	if len(found) == 0 {
+		log.Printf("No devices found")
+		return nil
+	}
+	for _, d := range found {
+		fmt.Fprintln(os.Stdout, d)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/itohio/golpm/pkg/lpm"
+)
+
+// runRecord captures raw samples from the device to a file in the format the
+// GUI records, for replay or the mock device. Runs until interrupted or for
+// the given duration.
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	var dev deviceFlags
+	dev.register(fs)
+	output := fs.String("o", "", "Recording file to write (required)")
+	duration := fs.Duration("duration", 0, "Time to record (0 records until interrupted)")
+	fs.Parse(args)
+
+	if *output == "" {
+		return errors.New("no recording file given, use -o")
+	}
+	cfg, err := dev.load()
+	if err != nil {
+		return err
+	}
+	device, err := dev.device(cfg)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create recording: %w", err)
+	}
+	defer f.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	recorder := lpm.NewRecorder(device, nil)
+	if err := dev.connect(ctx, cfg, recorder); err != nil {
+		return err
+	}
+	// Set after connecting, so the recording starts with the device info
+	recorder.SetWriter(f)
+	log.Printf("Recording to %s", *output)
+
+	start := time.Now()
+	count := 0
+	samples := recorder.Samples()
+loop:
+	for {
+		select {
+		case _, ok := <-samples:
+			if !ok {
+				log.Printf("Device stream ended")
+				break loop
+			}
+			count++
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		log.Printf("Failed to close device: %v", err)
+	}
+
+	log.Printf("Recorded %d samples in %s", count, time.Since(start).Round(time.Millisecond))
+	return nil
+}
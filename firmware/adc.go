@@ -0,0 +1,14 @@
+//go:build !atsamd21
+
+package main
+
+import "machine"
+
+// Only the SAMD21 has a programmable ADC gain and reference selection; other
+// boards accept the defaults only.
+
+func validADCGain(gain int) bool { return gain == 1 }
+
+func applyADCReference(mv int) bool { return mv == ADC_REFERENCE_MV }
+
+func readADCWithGain(adc machine.ADC, gain int) uint16 { return adc.Get() }
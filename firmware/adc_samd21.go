@@ -0,0 +1,65 @@
+//go:build atsamd21
+
+package main
+
+import (
+	"device/sam"
+	"machine"
+)
+
+// The SAMD21 ADC runs at TinyGo's default of half gain against VDDANA/2,
+// i.e. a 0..VDDANA input range. Gains are given relative to that range, so
+// gain 2 is the hardware's 1X and gain 32 its 16X.
+var adcGainCodes = map[int]uint32{
+	1:  sam.ADC_INPUTCTRL_GAIN_DIV2,
+	2:  sam.ADC_INPUTCTRL_GAIN_1X,
+	4:  sam.ADC_INPUTCTRL_GAIN_2X,
+	8:  sam.ADC_INPUTCTRL_GAIN_4X,
+	16: sam.ADC_INPUTCTRL_GAIN_8X,
+	32: sam.ADC_INPUTCTRL_GAIN_16X,
+}
+
+// INTERNAL_REFERENCE_MV is the input range with the internal 1V bandgap
+// reference at half gain, independent of the supply.
+const INTERNAL_REFERENCE_MV = 2000
+
+// validADCGain reports whether the hardware supports gain.
+func validADCGain(gain int) bool {
+	_, ok := adcGainCodes[gain]
+	return ok
+}
+
+// applyADCReference selects the reference for an input range of mv
+// millivolts: ADC_REFERENCE_MV (VDDANA/2) or INTERNAL_REFERENCE_MV (bandgap).
+// It applies to all channels.
+func applyADCReference(mv int) bool {
+	switch mv {
+	case ADC_REFERENCE_MV:
+		sam.ADC.REFCTRL.ReplaceBits(sam.ADC_REFCTRL_REFSEL_INTVCC1, sam.ADC_REFCTRL_REFSEL_Msk, 0)
+	case INTERNAL_REFERENCE_MV:
+		sam.SYSCTRL.VREF.SetBits(sam.SYSCTRL_VREF_BGOUTEN)
+		sam.ADC.REFCTRL.ReplaceBits(sam.ADC_REFCTRL_REFSEL_INT1V, sam.ADC_REFCTRL_REFSEL_Msk, 0)
+	default:
+		return false
+	}
+	return true
+}
+
+// readADCWithGain reads adc with the input gain set to gain, then restores
+// unity gain for the other channels.
+func readADCWithGain(adc machine.ADC, gain int) uint16 {
+	if gain == 1 {
+		return adc.Get()
+	}
+
+	setADCGain(adcGainCodes[gain])
+	value := adc.Get()
+	setADCGain(sam.ADC_INPUTCTRL_GAIN_DIV2)
+	return value
+}
+
+func setADCGain(code uint32) {
+	sam.ADC.INPUTCTRL.ReplaceBits(code, sam.ADC_INPUTCTRL_GAIN_Msk>>sam.ADC_INPUTCTRL_GAIN_Pos, sam.ADC_INPUTCTRL_GAIN_Pos)
+	for sam.ADC.STATUS.HasBits(sam.ADC_STATUS_SYNCBUSY) {
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !esp32c3
+
+package main
+
+import "machine"
+
+// deviceID returns the MCU's unique ID, reported as the serial number that
+// tells heads apart.
+func deviceID() []byte {
+	return machine.DeviceID()
+}
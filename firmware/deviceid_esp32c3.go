@@ -0,0 +1,9 @@
+//go:build esp32c3
+
+package main
+
+// TinyGo has no unique ID for the ESP32-C3 yet, so no serial number is
+// reported on this board: the host falls back to the USB serial number,
+// which the ESP32-C3's USB serial derives from its MAC address.
+
+func deviceID() []byte { return nil }
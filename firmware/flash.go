@@ -0,0 +1,26 @@
+//go:build !esp32c3
+
+package main
+
+import "machine"
+
+// readSettings reads the settings block from the start of the flash area
+// that follows the program.
+func readSettings(p []byte) error {
+	_, err := machine.Flash.ReadAt(p, 0)
+	return err
+}
+
+// writeSettings erases the first flash block and writes p to it, padded to
+// the flash write block size.
+func writeSettings(p []byte) error {
+	size := int(machine.Flash.WriteBlockSize())
+	padded := make([]byte, (len(p)+size-1)/size*size)
+	copy(padded, p)
+
+	if err := machine.Flash.EraseBlocks(0, 1); err != nil {
+		return err
+	}
+	_, err := machine.Flash.WriteAt(padded, 0)
+	return err
+}
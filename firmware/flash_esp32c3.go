@@ -0,0 +1,10 @@
+//go:build esp32c3
+
+package main
+
+// TinyGo has no flash block device for the ESP32-C3 yet, so settings are
+// not persisted on this board: "S" fails and the defaults apply at boot.
+
+func readSettings(p []byte) error { return errNoFlash }
+
+func writeSettings(p []byte) error { return errNoFlash }
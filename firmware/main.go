@@ -1,48 +1,141 @@
-//go:generate tinygo flash -target=pico
+// Board pins and sampling constants are selected by build tag, see pins_*.go.
+//go:generate tinygo build -target=xiao -o build/lpm-xiao.uf2 .
+//go:generate tinygo build -target=pico -o build/lpm-pico.uf2 .
+//go:generate tinygo build -target=xiao-esp32c3 -o build/lpm-esp32c3.bin .
 
 package main
 
 import (
 	"machine"
+	"runtime/interrupt"
 	"time"
 )
 
-var (
-	adcAbsorber machine.ADC
-	adcVoltage  machine.ADC
+// PROTOCOL_VERSION is reported in the handshake response so the host can adapt.
+// Version 2 acknowledges heater commands with "#OK <states>".
+// Version 3 accepts "R<hz>" to change the output sample rate.
+// Version 4 appends a sequence number to every sample line.
+// Version 5 accepts "B1"/"B0" to switch between binary frames and text lines.
+// Version 6 accepts "N<n>" to set the averaging count and reports it as "avg=<n>".
+// Version 7 accepts "D<d1>,<d2>,<d3>" to drive the heaters with PWM duty cycles.
+// Version 8 announces every boot with a "#BOOT" line.
+// Version 9 accepts "T<ms>" to set the ADC read interval and reports it as "interval=<ms>".
+// Version 10 appends the ambient temperature reading to every sample.
+// Version 11 stores calibration points ("K") and saves settings to flash ("S").
+// Version 12 answers every command line, rejecting unknown and overlong ones.
+// Version 13 accepts "P1"/"P0" to pause and resume the sample output.
+// Version 14 runs a self-test on "X" and reports it as "#TEST".
+// Version 15 accepts "G<gain>" and "A<mv>" to set the absorber gain and the ADC reference, reported as "gain=<g> ref=<mv>".
+// Version 16 appends the second absorber reading to every sample on boards that have one.
+// Version 17 reports its number of heaters as "heaters=<n>" and takes one digit or duty per heater.
+// Version 18 reports the MCU's unique ID as "serial=<hex>" in the identity response.
+// Version 19 stores signed calibration points with powers in µW instead of nW.
+const PROTOCOL_VERSION = 19
+
+// FIRMWARE_VERSION is reported in the identity response.
+const FIRMWARE_VERSION = "0.2.0"
+
+// NUM_CHANNELS is the number of ADC channels reported in each sample,
+// not counting the second absorber.
+const NUM_CHANNELS = 3
+
+// HAS_ABSORBER2 is set on boards with a second absorber input, for dual-head
+// or differential setups. Its reading is appended to every sample.
+const HAS_ABSORBER2 = PIN_ABSORBER2_ADC != machine.NoPin
+
+// Binary frame layout, matching pkg/lpm (little-endian):
+// sync, payload length, payload (unix_micros u64, reading u16, voltage u16,
+// heater bits u8, sequence u32, ambient u16, and reading2 u16 with a second
+// absorber), CRC16-CCITT over length and payload.
+const (
+	FRAME_SYNC          = 0xA5
+	FRAME_PAYLOAD_SIZE  = 19
+	FRAME_READING2_SIZE = FRAME_PAYLOAD_SIZE + 2
+	FRAME_SIZE          = FRAME_READING2_SIZE + 4
+)
+
+// Heater PWM: duty cycles are given in per mille of PWM_PERIOD_MS. The heaters
+// are thermally slow, so a software PWM from the main loop is smooth enough
+// and works the same on every board.
+const (
+	PWM_PERIOD_MS = 100
+	MAX_DUTY      = 1000
+)
 
-	// Heater states
-	heaterStates    [3]bool
-	previousStates  [3]bool
+// WATCHDOG_TIMEOUT_MS resets the MCU if the main loop stalls this long, on
+// boards with a watchdog driver (see watchdog*.go).
+// The heaters are switched off by the reset, so a hung loop cannot leave them on.
+const WATCHDOG_TIMEOUT_MS = 1000
+
+// MAX_SAMPLE_INTERVAL_MS bounds the ADC read interval set by the "T<ms>" command.
+const MAX_SAMPLE_INTERVAL_MS = 100
+
+var (
+	adcAbsorber  machine.ADC
+	adcVoltage   machine.ADC
+	adcAmbient   machine.ADC
+	adcAbsorber2 machine.ADC
+
+	// Heater states: a heater is reported on while its duty is above zero
+	heaterStates    [len(heaterPins)]bool
+	heaterDuty      [len(heaterPins)]int // PWM duty per heater in per mille
 	ignoreCountdown int
 
-	// ADC averaging - running sums and counts
-	absorberSum uint32
-	voltageSum  uint32
-	adcCount    int // Current count of samples (resets after N samples)
+	// ADC averaging - running sums and counts, updated by sampleTick
+	absorberSum  uint32
+	voltageSum   uint32
+	ambientSum   uint32
+	absorber2Sum uint32
+	adcCount     int               // Current count of samples (resets after N samples)
+	numSamples   int = NUM_SAMPLES // Samples averaged per output, changed by the "R<hz>" and "N<n>" commands
+
+	// ADC read interval, changed by the "T<ms>" command
+	sampleIntervalMs int = SAMPLE_INTERVAL_MS
+
+	// Absorber channel gain relative to the default input range, changed by
+	// the "G<gain>" command, and the input range in mV, changed by "A<mv>"
+	absorberGain   int = 1
+	adcReferenceMv int = ADC_REFERENCE_MV
+
+	// Sequence number of the last sample line, starts at 1 and skips 0 on
+	// wrap so the host can tell it apart from firmware without sequence numbers
+	sequence uint32
+
+	// Output binary frames instead of text lines, changed by the "B" command
+	binaryOutput bool
+
+	// Sample output paused by the "P" command; sampling and commands continue
+	outputPaused bool
 
 	// Timing
-	lastADCRead time.Time
+	bootTime time.Time
 
 	// Serial buffer for reading lines
-	serialBuffer [16]byte
-	serialPos    int
+	serialBuffer   [32]byte
+	serialPos      int
+	serialOverflow bool // The current line is longer than serialBuffer
 )
 
 func main() {
 	machine.InitADC()
 
 	// Configure heater pins as outputs
-	PIN_HEATER1.Configure(machine.PinConfig{Mode: machine.PinOutput})
-	PIN_HEATER2.Configure(machine.PinConfig{Mode: machine.PinOutput})
-	PIN_HEATER3.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	for _, pin := range heaterPins {
+		pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	}
 
 	// Configure ADC pins and set up ADCs with highest resolution
 	PIN_ADC.Configure(machine.PinConfig{Mode: machine.PinInput})
 	PIN_VOLTAGE_ADC.Configure(machine.PinConfig{Mode: machine.PinInput})
+	PIN_AMBIENT_ADC.Configure(machine.PinConfig{Mode: machine.PinInput})
+	if HAS_ABSORBER2 {
+		PIN_ABSORBER2_ADC.Configure(machine.PinConfig{Mode: machine.PinInput})
+	}
 
 	adcAbsorber = machine.ADC{Pin: PIN_ADC}
 	adcVoltage = machine.ADC{Pin: PIN_VOLTAGE_ADC}
+	adcAmbient = machine.ADC{Pin: PIN_AMBIENT_ADC}
+	adcAbsorber2 = machine.ADC{Pin: PIN_ABSORBER2_ADC}
 
 	adcConfig := machine.ADCConfig{
 		// Reference:  ADC_REFERENCE_MV,
@@ -52,6 +145,10 @@ func main() {
 
 	adcAbsorber.Configure(adcConfig)
 	adcVoltage.Configure(adcConfig)
+	adcAmbient.Configure(adcConfig)
+	if HAS_ABSORBER2 {
+		adcAbsorber2.Configure(adcConfig)
+	}
 
 	// Configure UART for heater control
 	// uart.Configure(machine.UARTConfig{
@@ -61,33 +158,37 @@ func main() {
 	// })
 
 	// Initialize timing
-	lastADCRead = time.Now()
+	bootTime = time.Now()
+
+	// Restore the averaging, ADC interval and calibration saved with "S"
+	loadSettings()
+
+	reportBoot()
+
+	startSampling()
+
+	startWatchdog(WATCHDOG_TIMEOUT_MS)
 
 	// Main loop
 	for {
+		feedWatchdog()
+
 		now := time.Now()
 
 		// Check for serial input (non-blocking)
 		processSerial()
 
-		// Read both ADCs at the same time and rate (every 1ms)
-		if now.Sub(lastADCRead) >= time.Duration(SAMPLE_INTERVAL_MS)*time.Millisecond {
-			readAbsorberADC()
-			readVoltageADC()
-			lastADCRead = now
-			adcCount++
-		}
+		updateHeaterPWM(now)
+
+		// Readings are taken by the sample timer, see sampling.go
+		pollSampleTimer(now)
 
-		// Check if we've collected N samples for either ADC and output
-		if adcCount >= NUM_SAMPLES {
-			outputAveragedValues()
-			// Reset and start accumulating again
-			absorberSum = 0
-			voltageSum = 0
-			adcCount = 0
+		if s, ok := takeSample(); ok {
+			outputSample(s)
 		}
+		drainOutput()
 
-		// Small delay to prevent tight loop (but still allow precise timing)
+		// Small delay to prevent tight loop
 		time.Sleep(100 * time.Microsecond)
 	}
 }
@@ -99,8 +200,14 @@ func readAbsorberADC() {
 		return
 	}
 
-	value := adcAbsorber.Get()
+	value := readADCWithGain(adcAbsorber, absorberGain)
 	absorberSum += uint32(value)
+
+	// The second absorber is the same kind of head, so it shares the gain
+	if HAS_ABSORBER2 {
+		value = readADCWithGain(adcAbsorber2, absorberGain)
+		absorber2Sum += uint32(value)
+	}
 }
 
 func readVoltageADC() {
@@ -114,44 +221,68 @@ func readVoltageADC() {
 	voltageSum += uint32(value)
 }
 
-func outputAveragedValues() {
-	if adcCount <= 0 {
-		return
-	}
-	absorberAvg := uint16(absorberSum / uint32(adcCount))
+// readAmbientADC reads the ambient temperature sensor. Heater switching does
+// not disturb it, so it is never ignored.
+func readAmbientADC() {
+	value := adcAmbient.Get()
+	ambientSum += uint32(value)
+}
 
-	// Calculate average for voltage (use actual count, up to NUM_SAMPLES)
-	voltageAvg := uint16(voltageSum / uint32(adcCount))
+// resetAveraging discards the readings accumulated so far.
+func resetAveraging() {
+	state := interrupt.Disable()
+	defer interrupt.Restore(state)
 
-	// Get timestamp in unix microseconds
-	now := time.Now()
-	timestampMicros := now.UnixNano() / 1000 // Convert nanoseconds to microseconds
+	absorberSum = 0
+	voltageSum = 0
+	ambientSum = 0
+	absorber2Sum = 0
+	adcCount = 0
+}
 
-	// Output format: "unix_micros,reading,voltage,heater1heater2heater3\n"
-	// Example: "1234567890123,2048,1024,101\n"
-	print(timestampMicros)
-	print(",")
-	print(absorberAvg)
-	print(",")
-	print(voltageAvg)
-	print(",")
-	// Output heater states as 3 digits
-	if heaterStates[0] {
-		print("1")
-	} else {
-		print("0")
+// outputSample queues an averaged sample as a text line or binary frame,
+// see output.go. A sample that finds the queue full is dropped.
+func outputSample(s averagedSample) {
+	slot := queueSlot()
+	if slot == nil {
+		return
 	}
-	if heaterStates[1] {
-		print("1")
+
+	if binaryOutput {
+		writeFrame(slot, s)
 	} else {
-		print("0")
+		writeLine(slot, s)
 	}
-	if heaterStates[2] {
-		print("1")
-	} else {
-		print("0")
+	commitSlot()
+}
+
+// writeLine formats a sample as a text line.
+func writeLine(slot *txSlot, s averagedSample) {
+	// Output format: "unix_micros,reading,voltage,heater1heater2...heaterN,sequence,ambient[,reading2]\n"
+	// Example: "1234567890123,2048,1024,101,42,30000\n"
+	slot.appendUint(uint64(s.timestampMicros))
+	slot.appendString(",")
+	slot.appendUint(uint64(s.reading))
+	slot.appendString(",")
+	slot.appendUint(uint64(s.voltage))
+	slot.appendString(",")
+	// Output heater states as one digit per heater
+	for i := range heaterStates {
+		if heaterStates[i] {
+			slot.appendString("1")
+		} else {
+			slot.appendString("0")
+		}
 	}
-	print("\n")
+	slot.appendString(",")
+	slot.appendUint(uint64(s.sequence))
+	slot.appendString(",")
+	slot.appendUint(uint64(s.ambient))
+	if HAS_ABSORBER2 {
+		slot.appendString(",")
+		slot.appendUint(uint64(s.reading2))
+	}
+	slot.appendString("\n")
 }
 
 func processSerial() {
@@ -167,12 +298,14 @@ func processSerial() {
 		}
 		// Check for newline (end of line)
 		if data == '\n' || data == '\r' {
-			if serialPos == 3 {
-				// We have exactly 3 characters, process heater states
-				updateHeaterStates()
+			if serialOverflow {
+				print("#ERR length\n")
+			} else if serialPos > 0 {
+				handleCommand(serialBuffer[:serialPos])
 			}
 			// Reset buffer regardless of length
 			serialPos = 0
+			serialOverflow = false
 			continue
 		}
 
@@ -181,57 +314,501 @@ func processSerial() {
 			continue
 		}
 
-		// Only accept '0' or '1', and only up to 3 characters
-		if data == '0' || data == '1' {
-			if serialPos < 3 {
+		// Accept printable characters up to the buffer size; a longer
+		// line is rejected as a whole at its newline
+		if data > ' ' && data < 0x7F {
+			if serialPos < len(serialBuffer) {
 				serialBuffer[serialPos] = data
 				serialPos++
+			} else {
+				serialOverflow = true
 			}
-			// If we already have 3 characters, ignore additional ones until newline
 		} else {
 			// Invalid character - reset buffer
 			serialPos = 0
+			serialOverflow = false
 		}
 	}
 }
 
-func updateHeaterStates() {
-	// Parse three characters from buffer
-	var stateChanged bool
+// handleCommand dispatches a complete command line received from the host.
+// Commands:
+//
+//	"000".."111" - set heater states, one digit per heater, acknowledged with "#OK <states>"
+//	"D<d1>,<d2>,..." - set heater PWM duties in per mille, one per heater, acknowledged with "#OK duty=<d1>,<d2>,..."
+//	"V?"         - query protocol version and capabilities
+//	"I?"         - query firmware version, board name and uptime
+//	"R<hz>"      - set output sample rate, acknowledged with "#OK rate=<hz> avg=<n> interval=<ms>"
+//	"N<n>"       - set readings averaged per output, acknowledged with "#OK rate=<hz> avg=<n> interval=<ms>"
+//	"T<ms>"      - set the ADC read interval, acknowledged with "#OK rate=<hz> avg=<n> interval=<ms>"
+//	"B1", "B0"   - switch to binary frames or text lines, acknowledged with "#OK binary=<0|1>"
+//	"G<gain>"    - set the absorber gain (1..32, board permitting), acknowledged with "#OK gain=<g> ref=<mv>"
+//	"A<mv>"      - set the ADC input range (board references only), acknowledged with "#OK gain=<g> ref=<mv>"
+//	"P1", "P0"   - pause or resume the sample output, acknowledged with "#OK paused=<0|1>"
+//	"K..."       - read or change the calibration points, see handleCalibration
+//	"X"          - run the self-test, answered with "#TEST ...", see runSelfTest
+//	"S"          - save averaging, ADC interval and calibration to flash, acknowledged with "#OK saved"
+//
+// Every command is answered. Invalid arguments are rejected with
+// "#ERR <reason>", unknown commands with "#ERR unknown" and lines longer
+// than serialBuffer with "#ERR length".
+// Whitespace is ignored, so "R 50" and "R50" are the same command.
+// Responses are always text lines, also while binary frames are output.
+func handleCommand(cmd []byte) {
+	if len(cmd) == len(heaterPins) && isHeaterCommand(cmd) {
+		var duty [len(heaterPins)]int
+		for i := range duty {
+			if cmd[i] == '1' {
+				duty[i] = MAX_DUTY
+			}
+		}
+		setHeaterDuty(duty)
+		reportHeaterAck()
+		return
+	}
 
-	for i := range 3 {
-		newState := serialBuffer[i] == '1'
-		if heaterStates[i] != newState {
-			stateChanged = true
+	if len(cmd) > 1 && cmd[0] == 'D' {
+		setHeaterDutyCommand(cmd[1:])
+		return
+	}
+
+	if len(cmd) > 1 && cmd[0] == 'R' {
+		setSampleRate(cmd[1:])
+		return
+	}
+
+	if len(cmd) > 1 && cmd[0] == 'N' {
+		setAveraging(cmd[1:])
+		return
+	}
+
+	if len(cmd) > 1 && cmd[0] == 'T' {
+		setSampleInterval(cmd[1:])
+		return
+	}
+
+	if len(cmd) > 1 && cmd[0] == 'K' {
+		handleCalibration(cmd[1:])
+		return
+	}
+
+	if len(cmd) == 1 && cmd[0] == 'X' {
+		runSelfTest()
+		return
+	}
+
+	if len(cmd) == 1 && cmd[0] == 'S' {
+		saveSettings()
+		return
+	}
+
+	if len(cmd) > 1 && cmd[0] == 'G' {
+		setAbsorberGain(cmd[1:])
+		return
+	}
+
+	if len(cmd) > 1 && cmd[0] == 'A' {
+		setADCReference(cmd[1:])
+		return
+	}
+
+	if len(cmd) == 2 && cmd[0] == 'P' && (cmd[1] == '0' || cmd[1] == '1') {
+		outputPaused = cmd[1] == '1'
+		print("#OK paused=")
+		print(cmd[1] - '0')
+		print("\n")
+		return
+	}
+
+	if len(cmd) == 2 && cmd[0] == 'B' && (cmd[1] == '0' || cmd[1] == '1') {
+		binaryOutput = cmd[1] == '1'
+		print("#OK binary=")
+		print(cmd[1] - '0')
+		print("\n")
+		return
+	}
+
+	if len(cmd) == 2 && cmd[1] == '?' {
+		switch cmd[0] {
+		case 'V':
+			reportInfo()
+			return
+		case 'I':
+			reportIdentity()
+			return
 		}
-		previousStates[i] = heaterStates[i]
-		heaterStates[i] = newState
 	}
 
-	// Update heater pins
-	if heaterStates[0] {
-		PIN_HEATER1.High()
-	} else {
-		PIN_HEATER1.Low()
+	print("#ERR unknown\n")
+}
+
+// writeFrame formats a sample as a binary frame, about half the size of a
+// text line and protected by a CRC16 so the host detects corruption.
+func writeFrame(slot *txSlot, s averagedSample) {
+	n := FRAME_PAYLOAD_SIZE
+	if HAS_ABSORBER2 {
+		n = FRAME_READING2_SIZE
 	}
 
-	if heaterStates[1] {
-		PIN_HEATER2.High()
-	} else {
-		PIN_HEATER2.Low()
+	frame := slot.data[:FRAME_SIZE]
+	frame[0] = FRAME_SYNC
+	frame[1] = byte(n)
+
+	payload := frame[2 : 2+n]
+	putUint(payload[0:8], uint64(s.timestampMicros))
+	putUint(payload[8:10], uint64(s.reading))
+	putUint(payload[10:12], uint64(s.voltage))
+	payload[12] = 0
+	for i := range heaterStates {
+		if heaterStates[i] {
+			payload[12] |= 1 << i
+		}
+	}
+	putUint(payload[13:17], uint64(s.sequence))
+	putUint(payload[17:19], uint64(s.ambient))
+	if HAS_ABSORBER2 {
+		putUint(payload[19:21], uint64(s.reading2))
+	}
+
+	putUint(frame[2+n:4+n], uint64(crc16(frame[1:2+n])))
+	slot.n = 4 + n
+}
+
+// putUint stores the low len(b) bytes of v in b, little-endian.
+func putUint(b []byte, v uint64) {
+	for i := range b {
+		b[i] = byte(v >> (8 * i))
+	}
+}
+
+// crc16 computes CRC16-CCITT (poly 0x1021, init 0xFFFF) over data.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// isHeaterCommand checks that the command consists only of '0' and '1' characters.
+func isHeaterCommand(cmd []byte) bool {
+	for _, c := range cmd {
+		if c != '0' && c != '1' {
+			return false
+		}
 	}
+	return true
+}
 
-	if heaterStates[2] {
-		PIN_HEATER3.High()
+// reportHeaterAck acknowledges a heater command with the applied heater states,
+// so the host can verify the MCU actually switched the heaters.
+// Format: "#OK <heater1><heater2>...<heaterN>\n"
+func reportHeaterAck() {
+	print("#OK ")
+	printHeaterStates()
+	print("\n")
+}
+
+// printHeaterStates outputs heater states as one digit per heater.
+func printHeaterStates() {
+	for i := range heaterStates {
+		if heaterStates[i] {
+			print("1")
+		} else {
+			print("0")
+		}
+	}
+}
+
+// setSampleRate parses the requested rate in Hz and adjusts the number of
+// averaged ADC readings per output. The achievable rate is quantized to
+// whole readings, so the applied rate is reported back.
+func setSampleRate(arg []byte) {
+	hz, ok := parseNumber(arg, 1000)
+	if !ok || hz <= 0 {
+		print("#ERR rate\n")
+		return
+	}
+
+	n := 1000 / (sampleIntervalMs * hz)
+	if n < 1 {
+		n = 1
+	}
+	applyNumSamples(n)
+}
+
+// setAveraging parses the requested number of ADC readings averaged per
+// output. More readings lower the noise at the cost of a lower output rate.
+func setAveraging(arg []byte) {
+	n, ok := parseNumber(arg, maxNumSamples())
+	if !ok || n <= 0 {
+		print("#ERR avg\n")
+		return
+	}
+	applyNumSamples(n)
+}
+
+// setSampleInterval parses the requested ADC read interval in milliseconds.
+// The averaging count is kept, so the output rate changes with the interval,
+// unless the count exceeds the limit for the new interval.
+func setSampleInterval(arg []byte) {
+	ms, ok := parseNumber(arg, MAX_SAMPLE_INTERVAL_MS)
+	if !ok || ms <= 0 {
+		print("#ERR interval\n")
+		return
+	}
+
+	sampleIntervalMs = ms
+	updateSampleTimer()
+	applyNumSamples(numSamples)
+}
+
+// setAbsorberGain parses the requested absorber channel gain. Higher gains
+// resolve small thermopile signals; the other channels keep the full range.
+func setAbsorberGain(arg []byte) {
+	gain, ok := parseNumber(arg, 32)
+	if !ok || !validADCGain(gain) {
+		print("#ERR gain\n")
+		return
+	}
+
+	absorberGain = gain
+	resetAveraging()
+	reportADCAck()
+}
+
+// setADCReference parses the requested ADC input range in mV, which must
+// match one of the board's references.
+func setADCReference(arg []byte) {
+	mv, ok := parseNumber(arg, 10000)
+	if !ok || !applyADCReference(mv) {
+		print("#ERR ref\n")
+		return
+	}
+
+	adcReferenceMv = mv
+	resetAveraging()
+	reportADCAck()
+}
+
+// reportADCAck acknowledges an ADC setting with the applied gain and reference.
+func reportADCAck() {
+	print("#OK ")
+	printADC()
+	print("\n")
+}
+
+// printADC outputs the ADC settings as "gain=<g> ref=<mv>".
+func printADC() {
+	print("gain=")
+	print(absorberGain)
+	print(" ref=")
+	print(adcReferenceMv)
+}
+
+// maxNumSamples bounds averaging so the lowest selectable rate is 1 Hz.
+func maxNumSamples() int {
+	return 1000 / sampleIntervalMs
+}
+
+// applyNumSamples changes the averaging count and acknowledges the
+// resulting sampling settings.
+func applyNumSamples(n int) {
+	if n > maxNumSamples() {
+		n = maxNumSamples()
+	}
+
+	// Restart averaging so the next output uses the new count only
+	numSamples = n
+	resetAveraging()
+
+	print("#OK ")
+	printSampling()
+	print("\n")
+}
+
+// parseNumber parses a decimal number, failing on other characters or
+// values above max. Checked before each digit is added, so long inputs
+// cannot overflow.
+func parseNumber(arg []byte, max int) (int, bool) {
+	v := 0
+	for _, c := range arg {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		d := int(c - '0')
+		if v > (max-d)/10 {
+			return 0, false
+		}
+		v = v*10 + d
+	}
+	return v, len(arg) > 0
+}
+
+// parseSignedNumber parses a decimal number with an optional '-' sign,
+// failing on other characters or magnitudes above max.
+func parseSignedNumber(arg []byte, max int) (int, bool) {
+	if len(arg) > 0 && arg[0] == '-' {
+		v, ok := parseNumber(arg[1:], max)
+		return -v, ok
+	}
+	return parseNumber(arg, max)
+}
+
+// printSampling outputs the sampling settings as "rate=<hz> avg=<n> interval=<ms>".
+func printSampling() {
+	print("rate=")
+	printSampleRate()
+	print(" avg=")
+	print(numSamples)
+	print(" interval=")
+	print(sampleIntervalMs)
+}
+
+// printSampleRate outputs the current output sample rate in Hz with one decimal.
+func printSampleRate() {
+	deciHz := 10000 / (sampleIntervalMs * numSamples)
+	print(deciHz / 10)
+	print(".")
+	print(deciHz % 10)
+}
+
+// reportInfo outputs the handshake response line.
+// Lines starting with '#' are responses, never samples.
+// Format: "#INFO proto=<version> channels=<n> heaters=<n> rate=<hz> avg=<n> interval=<ms> gain=<g> ref=<mv>\n"
+func reportInfo() {
+	print("#INFO proto=")
+	print(PROTOCOL_VERSION)
+	print(" channels=")
+	if HAS_ABSORBER2 {
+		print(NUM_CHANNELS + 1)
 	} else {
-		PIN_HEATER3.Low()
+		print(NUM_CHANNELS)
+	}
+	print(" heaters=")
+	print(len(heaterPins))
+	print(" ")
+	printSampling()
+	print(" ")
+	printADC()
+	print("\n")
+}
+
+// reportBoot announces a (re)start, so the host can tell that the MCU reset
+// mid-session, e.g. by the watchdog, and that its settings are back to defaults.
+// Format: "#BOOT proto=<version> fw=<version> board=<name>\n"
+func reportBoot() {
+	print("#BOOT proto=")
+	print(PROTOCOL_VERSION)
+	print(" fw=")
+	print(FIRMWARE_VERSION)
+	print(" board=")
+	print(BOARD_NAME)
+	print("\n")
+}
+
+// reportIdentity outputs the identity response line. The serial number is
+// left out on boards without a unique ID.
+// Format: "#INFO fw=<version> board=<name> serial=<hex> uptime=<ms>\n"
+func reportIdentity() {
+	print("#INFO fw=")
+	print(FIRMWARE_VERSION)
+	print(" board=")
+	print(BOARD_NAME)
+	if id := deviceID(); len(id) > 0 {
+		print(" serial=")
+		printHex(id)
+	}
+	print(" uptime=")
+	print(time.Since(bootTime).Milliseconds())
+	print("\n")
+}
+
+// printHex outputs b as lowercase hex digits.
+func printHex(b []byte) {
+	const digits = "0123456789abcdef"
+	for _, c := range b {
+		print(digits[c>>4 : c>>4+1])
+		print(digits[c&0x0F : c&0x0F+1])
+	}
+}
+
+// setHeaterDutyCommand parses "<d1>,<d2>,..." duties in per mille, one per heater.
+func setHeaterDutyCommand(arg []byte) {
+	var duty [len(heaterPins)]int
+	field := 0
+	begin := 0
+	for i := 0; i <= len(arg); i++ {
+		if i < len(arg) && arg[i] != ',' {
+			continue
+		}
+		if field >= len(duty) {
+			print("#ERR duty\n")
+			return
+		}
+		d, ok := parseNumber(arg[begin:i], MAX_DUTY)
+		if !ok {
+			print("#ERR duty\n")
+			return
+		}
+		duty[field] = d
+		field++
+		begin = i + 1
+	}
+	if field != len(duty) {
+		print("#ERR duty\n")
+		return
+	}
+
+	setHeaterDuty(duty)
+
+	print("#OK duty=")
+	for i := range heaterDuty {
+		if i > 0 {
+			print(",")
+		}
+		print(heaterDuty[i])
+	}
+	print("\n")
+}
+
+// setHeaterDuty applies new heater duties. The pins follow on the next
+// updateHeaterPWM call.
+func setHeaterDuty(duty [len(heaterPins)]int) {
+	var stateChanged bool
+
+	for i := range duty {
+		if heaterDuty[i] != duty[i] {
+			stateChanged = true
+		}
+		heaterDuty[i] = duty[i]
+		heaterStates[i] = duty[i] > 0
 	}
 
-	// If any heater state changed, reset ADC averaging and start ignoring samples
+	// If any heater changed, reset ADC averaging and start ignoring samples
 	if stateChanged {
 		ignoreCountdown = IGNORE_SAMPLES_AFTER_CHANGE
-		absorberSum = 0
-		voltageSum = 0
-		adcCount = 0
+		resetAveraging()
+	}
+}
+
+// updateHeaterPWM drives the heater pins for the current position in the
+// PWM period. Full and zero duty keep the pin steady.
+func updateHeaterPWM(now time.Time) {
+	phase := int(now.Sub(bootTime).Microseconds()%(PWM_PERIOD_MS*1000)) / PWM_PERIOD_MS
+
+	for i, pin := range heaterPins {
+		if phase < heaterDuty[i] {
+			pin.High()
+		} else {
+			pin.Low()
+		}
 	}
 }
@@ -0,0 +1,80 @@
+package main
+
+import "machine"
+
+// Sample output is queued instead of printed, and the main loop hands one
+// queued line or frame at a time to the serial driver. A slow or congested
+// link then costs at most one slot per loop iteration, and never delays
+// heater PWM, command handling or the polled sample clock.
+//
+// Samples that find the queue full are dropped; the host sees them as a
+// sequence gap. Responses are still printed directly: slots always hold
+// whole lines or frames, so a response can never land inside a sample.
+
+const (
+	// TX_SLOTS is the number of queued samples, about 0.3s at 50Hz.
+	TX_SLOTS = 16
+
+	// TX_SLOT_SIZE fits the longest text line,
+	// "<u64>,65535,65535,111,<u32>,65535,65535\n", and a binary frame.
+	TX_SLOT_SIZE = 64
+)
+
+// txSlot is one queued sample line or frame.
+type txSlot struct {
+	data [TX_SLOT_SIZE]byte
+	n    int
+}
+
+var (
+	txQueue [TX_SLOTS]txSlot
+	txHead  int // Next slot to transmit
+	txCount int // Queued slots
+)
+
+// queueSlot returns the next free slot, or nil if the queue is full.
+// The slot is only sent once commitSlot is called.
+func queueSlot() *txSlot {
+	if txCount == len(txQueue) {
+		return nil
+	}
+	slot := &txQueue[(txHead+txCount)%len(txQueue)]
+	slot.n = 0
+	return slot
+}
+
+// commitSlot queues the slot returned by the last queueSlot call.
+func commitSlot() {
+	txCount++
+}
+
+// drainOutput transmits the oldest queued slot, if any.
+func drainOutput() {
+	if txCount == 0 {
+		return
+	}
+	slot := &txQueue[txHead]
+	machine.Serial.Write(slot.data[:slot.n])
+	txHead = (txHead + 1) % len(txQueue)
+	txCount--
+}
+
+// appendString appends s to the slot, truncating at the slot size.
+func (s *txSlot) appendString(str string) {
+	s.n += copy(s.data[s.n:], str)
+}
+
+// appendUint appends v in decimal.
+func (s *txSlot) appendUint(v uint64) {
+	var digits [20]byte
+	i := len(digits)
+	for {
+		i--
+		digits[i] = byte('0' + v%10)
+		v /= 10
+		if v == 0 {
+			break
+		}
+	}
+	s.n += copy(s.data[s.n:], digits[i:])
+}
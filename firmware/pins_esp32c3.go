@@ -0,0 +1,42 @@
+//go:build esp32c3
+
+package main
+
+import "machine"
+
+const (
+	// Board name reported in the identity response
+	BOARD_NAME = "esp32c3"
+
+	// Sampling configuration
+	SAMPLE_INTERVAL_MS          = 1  // Default ADC read interval in milliseconds (same for both ADCs)
+	NUM_SAMPLES                 = 20 // Default number of samples to average
+	IGNORE_SAMPLES_AFTER_CHANGE = 10 // Ignore this many samples after heater state change
+
+	// ADC configuration
+	ADC_REFERENCE_MV = 3300 // Reference voltage in millivolts (3.3V)
+	ADC_RESOLUTION   = 12   // ADC resolution in bits (12-bit = 0-4095)
+
+	// Heater pins (D3..D5 on a XIAO ESP32C3)
+	PIN_HEATER1 = machine.GPIO5
+	PIN_HEATER2 = machine.GPIO6
+	PIN_HEATER3 = machine.GPIO7
+
+	// ADC pins, on ADC1 (GPIO0..GPIO4); ADC2 is unreliable on the ESP32-C3
+	PIN_ADC           = machine.GPIO3
+	PIN_VOLTAGE_ADC   = machine.GPIO4
+	PIN_AMBIENT_ADC   = machine.GPIO2 // Ambient NTC divider
+	PIN_ABSORBER2_ADC = machine.GPIO1 // Second absorber, optional
+
+	// Serial configuration
+	// Same line format and rate as the other boards, see pins_xiao.go.
+	UART_BAUD_RATE = 115200
+)
+
+var (
+	// heaterPins lists the heater outputs, heater 1 first. Their number is
+	// reported to the host as heaters=<n>, so heaters are added here only.
+	heaterPins = [...]machine.Pin{PIN_HEATER1, PIN_HEATER2, PIN_HEATER3}
+
+	uart = machine.DefaultUART
+)
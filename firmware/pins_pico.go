@@ -5,9 +5,12 @@ package main
 import "machine"
 
 const (
+	// Board name reported in the identity response
+	BOARD_NAME = "pico"
+
 	// Sampling configuration
-	SAMPLE_INTERVAL_MS          = 1  // ADC read interval in milliseconds (same for both ADCs)
-	NUM_SAMPLES                 = 20 // Number of samples to average
+	SAMPLE_INTERVAL_MS          = 1  // Default ADC read interval in milliseconds (same for both ADCs)
+	NUM_SAMPLES                 = 20 // Default number of samples to average
 	IGNORE_SAMPLES_AFTER_CHANGE = 10 // Ignore this many samples after heater state change
 
 	// ADC configuration
@@ -20,8 +23,10 @@ const (
 	PIN_HEATER3 = machine.GPIO8
 
 	// ADC pins
-	PIN_ADC         = machine.ADC0
-	PIN_VOLTAGE_ADC = machine.ADC1
+	PIN_ADC           = machine.ADC0
+	PIN_VOLTAGE_ADC   = machine.ADC1
+	PIN_AMBIENT_ADC   = machine.ADC2  // Ambient NTC divider
+	PIN_ABSORBER2_ADC = machine.NoPin // No free ADC input; ADC3 measures VSYS
 
 	// Serial configuration
 	// Baud rate calculation: Format "unix_micros,reading,voltage,heater1heater2heater3\n"
@@ -33,5 +38,9 @@ const (
 )
 
 var (
+	// heaterPins lists the heater outputs, heater 1 first. Their number is
+	// reported to the host as heaters=<n>, so heaters are added here only.
+	heaterPins = [...]machine.Pin{PIN_HEATER1, PIN_HEATER2, PIN_HEATER3}
+
 	uart = machine.USBCDC
 )
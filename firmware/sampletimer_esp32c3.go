@@ -0,0 +1,26 @@
+//go:build esp32c3
+
+package main
+
+import "time"
+
+// TinyGo has no timer interrupt support for the ESP32-C3 yet, so the main
+// loop polls for due readings. They follow a fixed schedule, so the sample
+// clock keeps pace with real time even when a poll comes late.
+var nextSampleRead time.Time
+
+func startSampleTimer() {
+	nextSampleRead = time.Now().Add(time.Duration(sampleIntervalMs) * time.Millisecond)
+}
+
+func updateSampleTimer() {
+	startSampleTimer()
+}
+
+// pollSampleTimer takes the readings that are due at now.
+func pollSampleTimer(now time.Time) {
+	for !now.Before(nextSampleRead) {
+		sampleTick()
+		nextSampleRead = nextSampleRead.Add(time.Duration(sampleIntervalMs) * time.Millisecond)
+	}
+}
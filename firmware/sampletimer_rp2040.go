@@ -0,0 +1,39 @@
+//go:build rp2040
+
+package main
+
+import (
+	"device/rp"
+	"runtime/interrupt"
+	"time"
+)
+
+// The RP2040 reads the ADCs from TIMER alarm 1; the runtime uses alarm 0
+// for sleeping. Each alarm is scheduled one interval after the previous
+// target rather than after the interrupt, so latency does not accumulate.
+var nextSampleAlarm uint32
+
+// startSampleTimer arms alarm 1 one sampleIntervalMs from now.
+func startSampleTimer() {
+	rp.TIMER.INTE.SetBits(rp.TIMER_INTE_ALARM_1)
+	interrupt.New(rp.IRQ_TIMER_IRQ_1, handleSampleTimer).Enable()
+
+	nextSampleAlarm = rp.TIMER.TIMERAWL.Get() + uint32(sampleIntervalMs)*1000
+	rp.TIMER.ALARM1.Set(nextSampleAlarm)
+}
+
+// updateSampleTimer is a no-op: every alarm is scheduled with the current
+// sampleIntervalMs.
+func updateSampleTimer() {}
+
+// pollSampleTimer is a no-op: readings are taken by the alarm interrupt.
+func pollSampleTimer(now time.Time) {}
+
+func handleSampleTimer(interrupt.Interrupt) {
+	rp.TIMER.INTR.Set(rp.TIMER_INTR_ALARM_1)
+
+	nextSampleAlarm += uint32(sampleIntervalMs) * 1000
+	rp.TIMER.ALARM1.Set(nextSampleAlarm)
+
+	sampleTick()
+}
@@ -0,0 +1,63 @@
+//go:build atsamd21
+
+package main
+
+import (
+	"device/sam"
+	"runtime/interrupt"
+	"time"
+)
+
+// TC3 runs from the 48MHz GCLK0 divided by 64 and interrupts on every
+// match of CC0, one ADC read interval.
+const tcCountsPerMs = 48000 / 64
+
+// startSampleTimer starts TC3 interrupting every sampleIntervalMs.
+func startSampleTimer() {
+	sam.PM.APBCMASK.SetBits(sam.PM_APBCMASK_TC3_)
+	sam.GCLK.CLKCTRL.Set((sam.GCLK_CLKCTRL_ID_TCC2_TC3 << sam.GCLK_CLKCTRL_ID_Pos) |
+		(sam.GCLK_CLKCTRL_GEN_GCLK0 << sam.GCLK_CLKCTRL_GEN_Pos) |
+		sam.GCLK_CLKCTRL_CLKEN)
+	for sam.GCLK.STATUS.HasBits(sam.GCLK_STATUS_SYNCBUSY) {
+	}
+
+	tc := sam.TC3_COUNT16
+	tc.CTRLA.Set((sam.TC_COUNT16_CTRLA_MODE_COUNT16 << sam.TC_COUNT16_CTRLA_MODE_Pos) |
+		(sam.TC_COUNT16_CTRLA_WAVEGEN_MFRQ << sam.TC_COUNT16_CTRLA_WAVEGEN_Pos) |
+		(sam.TC_COUNT16_CTRLA_PRESCALER_DIV64 << sam.TC_COUNT16_CTRLA_PRESCALER_Pos))
+	waitTC3()
+	tc.CC0.Set(uint16(sampleIntervalMs*tcCountsPerMs - 1))
+	waitTC3()
+	tc.INTENSET.Set(sam.TC_COUNT16_INTENSET_MC0)
+
+	interrupt.New(sam.IRQ_TC3, handleSampleTimer).Enable()
+
+	tc.CTRLA.SetBits(sam.TC_COUNT16_CTRLA_ENABLE)
+	waitTC3()
+}
+
+// updateSampleTimer applies a changed sampleIntervalMs. The counter
+// restarts, so the next reading is one full interval away.
+func updateSampleTimer() {
+	tc := sam.TC3_COUNT16
+	tc.CTRLA.ClearBits(sam.TC_COUNT16_CTRLA_ENABLE)
+	waitTC3()
+	tc.COUNT.Set(0)
+	tc.CC0.Set(uint16(sampleIntervalMs*tcCountsPerMs - 1))
+	waitTC3()
+	tc.CTRLA.SetBits(sam.TC_COUNT16_CTRLA_ENABLE)
+	waitTC3()
+}
+
+// pollSampleTimer is a no-op: readings are taken by the TC3 interrupt.
+func pollSampleTimer(now time.Time) {}
+
+func handleSampleTimer(interrupt.Interrupt) {
+	sam.TC3_COUNT16.INTFLAG.Set(sam.TC_COUNT16_INTFLAG_MC0)
+	sampleTick()
+}
+
+func waitTC3() {
+	for sam.TC3_COUNT16.STATUS.HasBits(sam.TC_COUNT16_STATUS_SYNCBUSY) {
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"runtime/interrupt"
+	"runtime/volatile"
+	"time"
+)
+
+// ADC readings are taken by sampleTick, called from a hardware timer
+// interrupt on boards that have one (see sampletimer*.go). Sample timestamps
+// advance by exactly one read interval per reading instead of following the
+// main loop, so loop jitter under serial load does not bias the host's
+// temperature slopes.
+
+// averagedSample is an averaged output sample latched by sampleTick.
+type averagedSample struct {
+	timestampMicros int64
+	reading         uint16
+	voltage         uint16
+	ambient         uint16
+	reading2        uint16 // Second absorber, if HAS_ABSORBER2
+	sequence        uint32
+}
+
+var (
+	sampleMicros int64 // Timestamp of the latest ADC reading in unix microseconds
+
+	latched     averagedSample
+	latchedFlag volatile.Register8
+)
+
+// startSampling starts the sample clock at the current time and the ADC
+// read timer.
+func startSampling() {
+	sampleMicros = time.Now().UnixNano() / 1000
+	startSampleTimer()
+}
+
+// sampleTick reads all ADCs once and latches the averaged sample every
+// numSamples readings. Runs in interrupt context on boards with a sample timer.
+func sampleTick() {
+	sampleMicros += int64(sampleIntervalMs) * 1000
+
+	// The self-test owns the ADC; the clock keeps running
+	if selfTesting.Get() != 0 {
+		return
+	}
+
+	readAbsorberADC()
+	readVoltageADC()
+	readAmbientADC()
+	adcCount++
+
+	if adcCount < numSamples {
+		return
+	}
+
+	// While paused, samples are dropped unnumbered, so the host does not
+	// count them as lost on resume
+	if outputPaused {
+		resetAveraging()
+		return
+	}
+
+	// Numbered here rather than on output, so a sample the main loop did
+	// not take in time shows up as a sequence gap on the host
+	sequence++
+	if sequence == 0 {
+		sequence = 1
+	}
+	latched = averagedSample{
+		timestampMicros: sampleMicros,
+		reading:         uint16(absorberSum / uint32(adcCount)),
+		voltage:         uint16(voltageSum / uint32(adcCount)),
+		ambient:         uint16(ambientSum / uint32(adcCount)),
+		reading2:        uint16(absorber2Sum / uint32(adcCount)),
+		sequence:        sequence,
+	}
+	latchedFlag.Set(1)
+	resetAveraging()
+}
+
+// takeSample returns the latched sample, if a new one is available.
+func takeSample() (averagedSample, bool) {
+	state := interrupt.Disable()
+	defer interrupt.Restore(state)
+
+	if latchedFlag.Get() == 0 {
+		return averagedSample{}, false
+	}
+	latchedFlag.Set(0)
+	return latched, true
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"machine"
+	"runtime/volatile"
+	"time"
+)
+
+// Self-test thresholds. ADC readings are 16-bit regardless of the hardware
+// resolution (see ADC_RESOLUTION).
+const (
+	ADC_FULL_SCALE     = 65535
+	SELFTEST_SETTLE_MS = 200 // Time for the supply to settle after switching a heater
+	SELFTEST_READINGS  = 32  // Readings averaged per measurement
+	SELFTEST_MAX_SAG   = 10  // Largest supply drop with a heater on, in percent
+)
+
+// selfTesting suspends sampleTick's ADC readings while the self-test owns the ADC.
+var selfTesting volatile.Register8
+
+// runSelfTest handles the "X" command. It checks that no ADC input is stuck
+// at either rail, then switches each heater on alone and measures how far
+// the heater supply sags. A heater that pulls the supply down by more than
+// SELFTEST_MAX_SAG percent is reported as failed, e.g. a short.
+// Format: "#TEST supply=<adc>,<ok|fail> absorber=<adc>,<ok|fail> ambient=<adc>,<ok|fail> heater1=<drop>,<ok|fail> ...\n"
+//
+// Samples are not output during the test, which takes about 0.8s, and the
+// heaters are back at their duties afterwards.
+func runSelfTest() {
+	selfTesting.Set(1)
+
+	for _, pin := range heaterPins {
+		pin.Low()
+	}
+	selfTestSleep(SELFTEST_SETTLE_MS)
+
+	supply := selfTestMeasure(adcVoltage)
+	supplyOK := supply > ADC_FULL_SCALE/5 && supply < ADC_FULL_SCALE*95/100
+
+	print("#TEST supply=")
+	print(supply)
+	printResult(supplyOK)
+	print(" absorber=")
+	absorber := selfTestMeasure(adcAbsorber)
+	print(absorber)
+	printResult(notRailed(absorber))
+	print(" ambient=")
+	ambient := selfTestMeasure(adcAmbient)
+	print(ambient)
+	printResult(notRailed(ambient))
+
+	for i, pin := range heaterPins {
+		pin.High()
+		selfTestSleep(SELFTEST_SETTLE_MS)
+		loaded := selfTestMeasure(adcVoltage)
+		pin.Low()
+
+		print(" heater")
+		print(i + 1)
+		print("=")
+		print(int(supply) - int(loaded))
+		printResult(supplyOK && loaded*100 >= supply*(100-SELFTEST_MAX_SAG))
+	}
+	print("\n")
+
+	// Readings right after the heaters switched are disturbed
+	ignoreCountdown = IGNORE_SAMPLES_AFTER_CHANGE
+	resetAveraging()
+	selfTesting.Set(0)
+}
+
+// selfTestMeasure averages SELFTEST_READINGS readings of adc.
+func selfTestMeasure(adc machine.ADC) uint32 {
+	var sum uint32
+	for i := 0; i < SELFTEST_READINGS; i++ {
+		sum += uint32(adc.Get())
+	}
+	return sum / SELFTEST_READINGS
+}
+
+// selfTestSleep waits ms milliseconds, keeping the watchdog fed.
+func selfTestSleep(ms int) {
+	for ; ms > 0; ms -= 100 {
+		feedWatchdog()
+		time.Sleep(time.Duration(min(ms, 100)) * time.Millisecond)
+	}
+	feedWatchdog()
+}
+
+// notRailed reports whether an ADC reading is off both rails.
+func notRailed(v uint32) bool {
+	return v > ADC_FULL_SCALE/100 && v < ADC_FULL_SCALE*99/100
+}
+
+func printResult(ok bool) {
+	if ok {
+		print(",ok")
+	} else {
+		print(",fail")
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import "errors"
+
+// Settings persisted in flash, loaded at boot and written by the "S" command.
+// Layout (little-endian):
+//
+//	[0:4]   SETTINGS_MAGIC
+//	[4]     SETTINGS_VERSION
+//	[5:7]   numSamples u16
+//	[7]     sampleIntervalMs u8
+//	[8]     number of calibration points u8
+//	[9:73]  calibration points: slope i32 scaled by 1e9, power i32 in µW
+//	[73:75] CRC16-CCITT over [0:73]
+const (
+	SETTINGS_MAGIC   = 0x534D504C // "LPMS"
+	SETTINGS_VERSION = 2          // 1 stored powers in nW
+	SETTINGS_SIZE    = 75
+
+	// MAX_CAL_POINTS bounds the calibration points stored on the MCU.
+	MAX_CAL_POINTS = 8
+)
+
+// calPoint is a calibration point in integer units, so the firmware stores
+// the host's values without floating point parsing.
+type calPoint struct {
+	slope int32 // Temperature slope, scaled by 1e9 (±2.147)
+	power int32 // Absorbed power in µW (±2147 W)
+}
+
+var (
+	calPoints [MAX_CAL_POINTS]calPoint
+	calCount  int
+
+	settingsBuf [SETTINGS_SIZE]byte
+
+	errNoFlash = errors.New("no flash storage")
+)
+
+// encodeSettings serializes the current settings into settingsBuf.
+func encodeSettings() []byte {
+	b := settingsBuf[:]
+	putUint(b[0:4], SETTINGS_MAGIC)
+	b[4] = SETTINGS_VERSION
+	putUint(b[5:7], uint64(numSamples))
+	b[7] = byte(sampleIntervalMs)
+	b[8] = byte(calCount)
+	for i, p := range calPoints {
+		off := 9 + i*8
+		putUint(b[off:off+4], uint64(uint32(p.slope)))
+		putUint(b[off+4:off+8], uint64(uint32(p.power)))
+	}
+	putUint(b[73:75], uint64(crc16(b[:73])))
+	return b
+}
+
+// decodeSettings applies settings read from flash. Erased flash, other
+// versions and corrupted blocks are ignored, keeping the defaults.
+func decodeSettings(b []byte) bool {
+	if len(b) < SETTINGS_SIZE || getUint(b[0:4]) != SETTINGS_MAGIC || b[4] != SETTINGS_VERSION {
+		return false
+	}
+	if uint16(getUint(b[73:75])) != crc16(b[:73]) {
+		return false
+	}
+
+	n, interval, count := int(getUint(b[5:7])), int(b[7]), int(b[8])
+	if interval <= 0 || interval > MAX_SAMPLE_INTERVAL_MS || n <= 0 || n > 1000/interval || count > MAX_CAL_POINTS {
+		return false
+	}
+
+	sampleIntervalMs = interval
+	numSamples = n
+	calCount = count
+	for i := range calPoints {
+		off := 9 + i*8
+		calPoints[i] = calPoint{
+			slope: int32(uint32(getUint(b[off : off+4]))),
+			power: int32(uint32(getUint(b[off+4 : off+8]))),
+		}
+	}
+	return true
+}
+
+// loadSettings restores the settings saved in flash, if any.
+func loadSettings() bool {
+	if err := readSettings(settingsBuf[:]); err != nil {
+		return false
+	}
+	return decodeSettings(settingsBuf[:])
+}
+
+// saveSettings handles the "S" command.
+func saveSettings() {
+	if err := writeSettings(encodeSettings()); err != nil {
+		print("#ERR flash\n")
+		return
+	}
+	print("#OK saved\n")
+}
+
+// handleCalibration handles the calibration commands:
+//
+//	"K?"             - list points as "#CAL <slope>,<power> ..."
+//	"K-"             - clear all points, acknowledged with "#OK cal=0"
+//	"K<i>,<s>,<p>"   - set point i (at most one past the last), acknowledged with "#OK cal=<count>"
+//
+// Slopes are scaled by 1e9 and powers are in µW, both signed 32-bit. Points
+// only survive a reset once saved with "S".
+func handleCalibration(arg []byte) {
+	switch {
+	case len(arg) == 1 && arg[0] == '?':
+		print("#CAL")
+		for i := 0; i < calCount; i++ {
+			print(" ")
+			print(calPoints[i].slope)
+			print(",")
+			print(calPoints[i].power)
+		}
+		print("\n")
+		return
+	case len(arg) == 1 && arg[0] == '-':
+		calCount = 0
+		calPoints = [MAX_CAL_POINTS]calPoint{}
+		print("#OK cal=0\n")
+		return
+	}
+
+	var fields [3]int
+	field, begin := 0, 0
+	for i := 0; i <= len(arg); i++ {
+		if i < len(arg) && arg[i] != ',' {
+			continue
+		}
+		v, ok := parseSignedNumber(arg[begin:i], 0x7FFFFFFF)
+		if !ok || field >= len(fields) {
+			print("#ERR cal\n")
+			return
+		}
+		fields[field] = v
+		field++
+		begin = i + 1
+	}
+
+	index := fields[0]
+	if field != len(fields) || index < 0 || index >= MAX_CAL_POINTS || index > calCount {
+		print("#ERR cal\n")
+		return
+	}
+
+	calPoints[index] = calPoint{slope: int32(fields[1]), power: int32(fields[2])}
+	if index == calCount {
+		calCount++
+	}
+	print("#OK cal=")
+	print(calCount)
+	print("\n")
+}
+
+// getUint reads a little-endian unsigned integer of len(b) bytes.
+func getUint(b []byte) uint64 {
+	var v uint64
+	for i := range b {
+		v |= uint64(b[i]) << (8 * i)
+	}
+	return v
+}
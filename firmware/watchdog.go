@@ -0,0 +1,16 @@
+//go:build !esp32c3
+
+package main
+
+import "machine"
+
+// startWatchdog resets the MCU if feedWatchdog is not called within timeoutMs.
+func startWatchdog(timeoutMs uint32) {
+	machine.Watchdog.Configure(machine.WatchdogConfig{TimeoutMillis: timeoutMs})
+	machine.Watchdog.Start()
+}
+
+// feedWatchdog restarts the watchdog timeout.
+func feedWatchdog() {
+	machine.Watchdog.Update()
+}
@@ -0,0 +1,10 @@
+//go:build esp32c3
+
+package main
+
+// TinyGo has no watchdog driver for the ESP32-C3 yet, so a stalled main loop
+// is not reset on this board.
+
+func startWatchdog(timeoutMs uint32) {}
+
+func feedWatchdog() {}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// version is the application version, set at build time with
+// -ldflags "-X main.version=v1.2.3". Builds without it report the module
+// version, or "(devel)" for a source checkout.
+var version string
+
+// buildInfo describes the running binary.
+type buildInfo struct {
+	Version   string
+	Revision  string // VCS commit, empty if not built from a checkout
+	Time      string // VCS commit time
+	Modified  bool   // Built from a checkout with local changes
+	GoVersion string
+}
+
+// readBuildInfo returns the version and the build settings embedded in the
+// binary by the Go toolchain.
+func readBuildInfo() buildInfo {
+	bi := buildInfo{Version: version, GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return bi
+	}
+	if bi.Version == "" {
+		bi.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			bi.Revision = s.Value
+		case "vcs.time":
+			bi.Time = s.Value
+		case "vcs.modified":
+			bi.Modified = s.Value == "true"
+		}
+	}
+	return bi
+}
+
+// aboutRows returns the label and value rows of the About dialog: the
+// application's version and build, the connected device and the
+// calibration.
+func aboutRows(state *appState, bi buildInfo) [][2]string {
+	appVersion := bi.Version
+	if appVersion == "" {
+		appVersion = i18n.T("unknown")
+	}
+	build := i18n.T("unknown")
+	if bi.Revision != "" {
+		build = bi.Revision[:min(len(bi.Revision), 12)]
+		if bi.Modified {
+			build += "+dirty"
+		}
+		if bi.Time != "" {
+			build += ", " + bi.Time
+		}
+	}
+
+	device := i18n.T("not connected")
+	if state.device != nil && state.device.IsConnected() {
+		info := state.device.Info()
+		board := info.Board
+		if board == "" {
+			board = i18n.T("unknown")
+		}
+		device = i18n.Tf("%s, %s, protocol v%d", board, formatFirmware(info), info.ProtocolVersion)
+	}
+
+	calibration := i18n.T("never fitted")
+	if !state.cfg.Calibration.Date.IsZero() {
+		calibration = state.cfg.Calibration.Date.Format("2006-01-02 15:04")
+	}
+	if state.cfg.Profile != "" {
+		calibration += i18n.Tf(", profile %s", state.cfg.Profile)
+	}
+
+	return [][2]string{
+		{i18n.T("Version"), appVersion},
+		{i18n.T("Build"), build},
+		{"Go", fmt.Sprintf("%s %s/%s", bi.GoVersion, runtime.GOOS, runtime.GOARCH)},
+		{i18n.T("Device"), device},
+		{i18n.T("Calibration"), calibration},
+	}
+}
+
+// showAboutDialog shows the application's version and build, the connected
+// device and the calibration date, with a button copying them for a bug
+// report.
+func showAboutDialog(state *appState) {
+	rows := aboutRows(state, readBuildInfo())
+	form := widget.NewForm()
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		form.Append(row[0], widget.NewLabel(row[1]))
+		lines[i] = row[0] + ": " + row[1]
+	}
+	copyBtn := widget.NewButtonWithIcon(i18n.T("Copy"), theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(strings.Join(lines, "\n"))
+	})
+	title := widget.NewLabelWithStyle(i18n.T("Laser Power Meter"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	content := container.NewVBox(title, form, container.NewHBox(copyBtn))
+	dialog.ShowCustom(i18n.T("About"), i18n.T("Close"), content, state.window)
+}
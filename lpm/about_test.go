@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAboutRows(t *testing.T) {
+	state := &appState{cfg: config.Default()}
+	bi := buildInfo{Version: "v1.2.0", GoVersion: "go1.25.0"}
+
+	rows := aboutRows(state, bi)
+	require.Len(t, rows, 5)
+	assert.Equal(t, [2]string{"Version", "v1.2.0"}, rows[0])
+	assert.Equal(t, [2]string{"Build", "unknown"}, rows[1])
+	assert.Contains(t, rows[2][1], "go1.25.0 ")
+	assert.Equal(t, [2]string{"Device", "not connected"}, rows[3])
+	assert.Equal(t, [2]string{"Calibration", "never fitted"}, rows[4])
+
+	bi.Revision, bi.Time, bi.Modified = "0123456789abcdef", "2024-05-01T12:00:00Z", true
+	state.device = &statusDevice{info: lpm.Info{ProtocolVersion: 5, FirmwareVersion: "0.2.0", Board: "rp2040"}}
+	state.cfg.Calibration.Date = time.Date(2024, 4, 30, 9, 15, 0, 0, time.Local)
+	state.cfg.Profile = "head A"
+	rows = aboutRows(state, bi)
+	assert.Equal(t, "0123456789ab+dirty, 2024-05-01T12:00:00Z", rows[1][1])
+	assert.Equal(t, "rp2040, fw 0.2.0, protocol v5", rows[3][1])
+	assert.Equal(t, "2024-04-30 09:15, profile head A", rows[4][1])
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+)
+
+// alarmInterval is how often the alarms are checked.
+const alarmInterval = time.Second
+
+// runAlarms checks the alarms and the heater cutoff every alarmInterval. It
+// never returns.
+func runAlarms(state *appState) {
+	ticker := time.NewTicker(alarmInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		fyne.Do(func() {
+			now := time.Now()
+			checkAlarms(state, now)
+			checkHeaterCutoff(state, now)
+		})
+	}
+}
+
+// checkAlarms raises the alarms whose conditions held long enough. Call it
+// on the main thread.
+func checkAlarms(state *appState, now time.Time) {
+	for _, a := range state.alarms.Check(state.cfg.Alarms, now, alarmStatus(state)) {
+		raiseAlarm(state, a)
+	}
+}
+
+// alarmStatus returns the state of the measurement the alarms watch.
+func alarmStatus(state *appState) meter.AlarmStatus {
+	if state.device == nil || !state.device.IsConnected() || state.paused || state.sessionOpen {
+		return meter.AlarmStatus{}
+	}
+	status := meter.AlarmStatus{
+		Measuring: true,
+		Received:  state.device.Stats().Parsed,
+		Heaters:   state.heaterState,
+	}
+	if state.powerMeter != nil {
+		if p := state.powerMeter.ActivePulse(); p != nil && p.State == meter.PulseStateUpdating {
+			status.Power = p.AvgPower
+		}
+	}
+	return status
+}
+
+// raiseAlarm reports an alarm in the status bar, as a system notification
+// and in a dialog, and beeps if enabled.
+func raiseAlarm(state *appState, a meter.Alarm) {
+	setStatus(state, "Alarm: %s", a.Message)
+	fyne.CurrentApp().SendNotification(fyne.NewNotification(i18n.T("Laser Power Meter alarm"), a.Message))
+	dialog.ShowInformation(i18n.T("Alarm"), a.Message, state.window)
+	if state.cfg.Alarms.Sound {
+		go beep()
+	}
+}
+
+// beep plays the system alert sound with the platform's command line player.
+func beep() {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", "[System.Media.SystemSounds]::Exclamation.Play(); Start-Sleep -Milliseconds 500")
+	case "darwin":
+		cmd = exec.Command("afplay", "/System/Library/Sounds/Glass.aiff")
+	default:
+		cmd = exec.Command("paplay", "/usr/share/sounds/freedesktop/stereo/alarm-clock-elapsed.oga")
+	}
+	if err := cmd.Run(); err != nil {
+		log.Printf("Failed to play alarm sound: %v", err)
+	}
+}
+
+// createAlarmsTab creates the Alarms configuration tab.
+func createAlarmsTab(state *appState) *container.TabItem {
+	alarms := state.cfg.Alarms
+
+	powerAboveEntry := widget.NewEntry()
+	powerAboveEntry.SetText(fmt.Sprintf("%.3f", alarms.PowerAboveMW))
+
+	powerBelowEntry := widget.NewEntry()
+	powerBelowEntry.SetText(fmt.Sprintf("%.3f", alarms.PowerBelowMW))
+
+	powerDurationEntry := widget.NewEntry()
+	powerDurationEntry.SetText(alarms.PowerDuration.String())
+
+	noSamplesEntry := widget.NewEntry()
+	noSamplesEntry.SetText(alarms.NoSamples.String())
+
+	heaterStuckEntry := widget.NewEntry()
+	heaterStuckEntry.SetText(alarms.HeaterStuckOn.String())
+
+	heaterMaxOnEntry := widget.NewEntry()
+	heaterMaxOnEntry.SetText(alarms.HeaterMaxOn.String())
+
+	soundCheck := widget.NewCheck(i18n.T("Beep when an alarm is raised"), nil)
+	soundCheck.SetChecked(alarms.Sound)
+
+	form := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: i18n.T("Power Above (mW)"), Widget: powerAboveEntry, HintText: i18n.T("0 = off")},
+			{Text: i18n.T("Power Below (mW)"), Widget: powerBelowEntry, HintText: i18n.T("0 = off; no pulse counts as 0 mW")},
+			{Text: i18n.T("Power Limit For"), Widget: powerDurationEntry, HintText: i18n.T("How long the power must stay beyond a limit")},
+			{Text: i18n.T("No Samples For"), Widget: noSamplesEntry, HintText: i18n.T("0s = off")},
+			{Text: i18n.T("Heater On For"), Widget: heaterStuckEntry, HintText: i18n.T("0s = off")},
+			{Text: i18n.T("Heaters Off After"), Widget: heaterMaxOnEntry, HintText: i18n.T("Turn all heaters off when one stays on this long; 0s = never")},
+			{Text: i18n.T("Sound"), Widget: soundCheck},
+		},
+		OnSubmit: func() {
+			if v, err := strconv.ParseFloat(powerAboveEntry.Text, 64); err == nil && v >= 0 {
+				state.cfg.Alarms.PowerAboveMW = v
+			}
+			if v, err := strconv.ParseFloat(powerBelowEntry.Text, 64); err == nil && v >= 0 {
+				state.cfg.Alarms.PowerBelowMW = v
+			}
+			if d, err := time.ParseDuration(powerDurationEntry.Text); err == nil && d >= 0 {
+				state.cfg.Alarms.PowerDuration = d
+			}
+			if d, err := time.ParseDuration(noSamplesEntry.Text); err == nil && d >= 0 {
+				state.cfg.Alarms.NoSamples = d
+			}
+			if d, err := time.ParseDuration(heaterStuckEntry.Text); err == nil && d >= 0 {
+				state.cfg.Alarms.HeaterStuckOn = d
+			}
+			if d, err := time.ParseDuration(heaterMaxOnEntry.Text); err == nil && d >= 0 {
+				state.cfg.Alarms.HeaterMaxOn = d
+			}
+			state.cfg.Alarms.Sound = soundCheck.Checked
+			if err := state.cfg.Save(state.configPath); err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+			}
+		},
+	}
+
+	return container.NewTabItem(i18n.T("Alarms"), form)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlarmStatus(t *testing.T) {
+	state := &appState{cfg: config.Default()}
+	assert.False(t, alarmStatus(state).Measuring, "disconnected")
+
+	state.device = &statusDevice{stats: lpm.Stats{Parsed: 42}}
+	state.heaterState = []bool{false, true, false}
+	status := alarmStatus(state)
+	assert.True(t, status.Measuring)
+	assert.Equal(t, uint64(42), status.Received)
+	assert.Equal(t, []bool{false, true, false}, status.Heaters)
+	assert.Zero(t, status.Power, "no pulse measured")
+
+	state.paused = true
+	assert.False(t, alarmStatus(state).Measuring, "paused")
+}
+
+func TestCheckAlarms(t *testing.T) {
+	a := test.NewTempApp(t)
+	state := &appState{
+		cfg:    config.Default(),
+		device: &statusDevice{stats: lpm.Stats{Parsed: 42}},
+		status: newStatusBar(),
+		window: a.NewWindow("test"),
+	}
+	state.cfg.Alarms.NoSamples = 3 * time.Second
+	now := time.Now()
+
+	checkAlarms(state, now)
+	assert.Empty(t, state.status.message.Text)
+	checkAlarms(state, now.Add(3*time.Second))
+	assert.Equal(t, "Alarm: No samples received for 3s", state.status.message.Text)
+}
+
+func TestHeaterCutoff(t *testing.T) {
+	var c heaterCutoff
+	now := time.Now()
+	on := []bool{false, true, false}
+
+	assert.False(t, c.Check(on, now, time.Minute))
+	assert.False(t, c.Check(on, now.Add(59*time.Second), time.Minute))
+	assert.True(t, c.Check([]bool{true, true, false}, now.Add(time.Minute), time.Minute), "any heater counts")
+
+	assert.False(t, c.Check([]bool{false, false, false}, now.Add(61*time.Second), time.Minute), "all off restarts")
+	assert.False(t, c.Check(on, now.Add(62*time.Second), time.Minute))
+	assert.True(t, c.Check(on, now.Add(122*time.Second), time.Minute))
+
+	assert.False(t, c.Check(on, now.Add(time.Hour), 0), "0 never cuts off")
+}
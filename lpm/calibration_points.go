@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"image/color"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
+	"gopkg.in/yaml.v3"
+)
+
+// calibrationPointsCSVHeader is the header of calibration point CSV files,
+// in the units of the editor.
+var calibrationPointsCSVHeader = []string{"slope_mv_s", "power_mw"}
+
+// calibrationPointsEditor is an editable table of calibration points in the
+// Calibration tab. Rows are edited in mV/s and mW, can be added, removed and
+// imported from other files, and are applied to the configuration with
+// points.
+type calibrationPointsEditor struct {
+	window  fyne.Window
+	rows    []calibrationPointRow
+	list    *fyne.Container
+	content fyne.CanvasObject
+}
+
+// calibrationPointRow holds the entries of one calibration point.
+type calibrationPointRow struct {
+	slope *widget.Entry // mV/s
+	power *widget.Entry // mW
+}
+
+// newCalibrationPointsEditor creates an editor showing points.
+func newCalibrationPointsEditor(points []config.CalibrationPoint, window fyne.Window) *calibrationPointsEditor {
+	e := &calibrationPointsEditor{window: window, list: container.NewVBox()}
+	for _, p := range points {
+		e.addRow(p)
+	}
+	e.refresh()
+
+	// The header reserves the width of the rows' remove buttons
+	removeWidth := canvas.NewRectangle(color.Transparent)
+	removeWidth.SetMinSize(widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), nil).MinSize())
+	header := container.NewBorder(nil, nil, nil, removeWidth,
+		container.NewGridWithColumns(2,
+			widget.NewLabelWithStyle(i18n.T("Slope (mV/s)"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(i18n.T("Heater Power (mW)"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})))
+
+	scroll := container.NewVScroll(e.list)
+	scroll.SetMinSize(fyne.NewSize(0, 160))
+	addBtn := widget.NewButtonWithIcon(i18n.T("Add Point"), theme.ContentAddIcon(), func() {
+		e.addRow(config.CalibrationPoint{})
+		e.refresh()
+	})
+	importBtn := widget.NewButtonWithIcon(i18n.T("Import..."), theme.FolderOpenIcon(), e.showImport)
+	e.content = container.NewBorder(header, container.NewHBox(addBtn, importBtn), nil, nil, scroll)
+	return e
+}
+
+// addRow appends a row showing p.
+func (e *calibrationPointsEditor) addRow(p config.CalibrationPoint) {
+	row := calibrationPointRow{slope: widget.NewEntry(), power: widget.NewEntry()}
+	row.slope.SetText(strconv.FormatFloat(p.Slope*1000, 'g', -1, 64))
+	row.power.SetText(strconv.FormatFloat(p.Power*1000, 'g', -1, 64))
+	e.rows = append(e.rows, row)
+}
+
+// removeRow removes the row holding slope.
+func (e *calibrationPointsEditor) removeRow(slope *widget.Entry) {
+	for i, row := range e.rows {
+		if row.slope == slope {
+			e.rows = append(e.rows[:i], e.rows[i+1:]...)
+			break
+		}
+	}
+	e.refresh()
+}
+
+// refresh lays out the rows.
+func (e *calibrationPointsEditor) refresh() {
+	objects := make([]fyne.CanvasObject, len(e.rows))
+	for i, row := range e.rows {
+		removeBtn := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() { e.removeRow(row.slope) })
+		objects[i] = container.NewBorder(nil, nil, nil, removeBtn, container.NewGridWithColumns(2, row.slope, row.power))
+	}
+	if len(objects) == 0 {
+		objects = append(objects, widget.NewLabel(i18n.T("No calibration points. Add them with 'Add Cal Point' while measuring, with 'Add Point' or by importing.")))
+	}
+	e.list.Objects = objects
+	e.list.Refresh()
+}
+
+// setPoints replaces the rows with points.
+func (e *calibrationPointsEditor) setPoints(points []config.CalibrationPoint) {
+	e.rows = nil
+	for _, p := range points {
+		e.addRow(p)
+	}
+	e.refresh()
+}
+
+// points returns the points of the rows in configuration units, V/s and W.
+func (e *calibrationPointsEditor) points() ([]config.CalibrationPoint, error) {
+	points := make([]config.CalibrationPoint, 0, len(e.rows))
+	for i, row := range e.rows {
+		slope, err := strconv.ParseFloat(strings.TrimSpace(row.slope.Text), 64)
+		if err != nil {
+			return nil, i18n.Errorf("point %d: invalid slope %q", i+1, row.slope.Text)
+		}
+		power, err := strconv.ParseFloat(strings.TrimSpace(row.power.Text), 64)
+		if err != nil {
+			return nil, i18n.Errorf("point %d: invalid power %q", i+1, row.power.Text)
+		}
+		points = append(points, config.CalibrationPoint{Slope: slope / 1000, Power: power / 1000})
+	}
+	return points, nil
+}
+
+// showImport asks for a file and adds its points to the table, so
+// calibrations can be merged.
+func (e *calibrationPointsEditor) showImport() {
+	d := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, e.window)
+			return
+		}
+		if r == nil {
+			return // Cancelled
+		}
+		defer r.Close()
+
+		points, err := readCalibrationPoints(r, r.URI().Name())
+		if err != nil {
+			dialog.ShowError(i18n.Errorf("failed to import calibration points: %w", err), e.window)
+			return
+		}
+		for _, p := range points {
+			e.addRow(p)
+		}
+		e.refresh()
+	}, e.window)
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".yaml", ".yml", ".csv"}))
+	d.Show()
+}
+
+// readCalibrationPoints reads calibration points from a configuration file,
+// or from a CSV file (by name's extension) with the columns
+// calibrationPointsCSVHeader.
+func readCalibrationPoints(r io.Reader, name string) ([]config.CalibrationPoint, error) {
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		return readCalibrationPointsCSV(r)
+	}
+
+	var file struct {
+		Calibration struct {
+			Points []config.CalibrationPoint `yaml:"points"`
+		} `yaml:"calibration"`
+	}
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, i18n.Errorf("failed to parse configuration: %w", err)
+	}
+	if len(file.Calibration.Points) == 0 {
+		return nil, i18n.Errorf("no calibration points in %s", name)
+	}
+	return file.Calibration.Points, nil
+}
+
+// readCalibrationPointsCSV reads calibration points in mV/s and mW from CSV
+// with a header row.
+func readCalibrationPointsCSV(r io.Reader) ([]config.CalibrationPoint, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || len(rows[0]) < 2 || rows[0][0] != calibrationPointsCSVHeader[0] || rows[0][1] != calibrationPointsCSVHeader[1] {
+		return nil, i18n.Errorf("expected a header row %s", strings.Join(calibrationPointsCSVHeader, ","))
+	}
+
+	points := make([]config.CalibrationPoint, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		slope, err := strconv.ParseFloat(strings.TrimSpace(row[0]), 64)
+		if err != nil {
+			return nil, i18n.Errorf("line %d: invalid slope %q", i+2, row[0])
+		}
+		power, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		if err != nil {
+			return nil, i18n.Errorf("line %d: invalid power %q", i+2, row[1])
+		}
+		points = append(points, config.CalibrationPoint{Slope: slope / 1000, Power: power / 1000})
+	}
+	return points, nil
+}
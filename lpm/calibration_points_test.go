@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalibrationPointsEditor(t *testing.T) {
+	a := test.NewTempApp(t)
+	e := newCalibrationPointsEditor([]config.CalibrationPoint{{Slope: 0.002, Power: 0.01}, {Slope: 0.004, Power: 0.02}}, a.NewWindow("test"))
+	require.Len(t, e.rows, 2)
+	assert.Equal(t, "2", e.rows[0].slope.Text, "shown in mV/s")
+	assert.Equal(t, "10", e.rows[0].power.Text, "shown in mW")
+
+	e.rows[1].power.SetText("25")
+	e.addRow(config.CalibrationPoint{})
+	e.removeRow(e.rows[0].slope)
+	points, err := e.points()
+	require.NoError(t, err)
+	assert.Equal(t, []config.CalibrationPoint{{Slope: 0.004, Power: 0.025}, {}}, points)
+
+	e.rows[1].slope.SetText("fast")
+	_, err = e.points()
+	assert.EqualError(t, err, `point 2: invalid slope "fast"`)
+
+	e.setPoints(nil)
+	points, err = e.points()
+	require.NoError(t, err)
+	assert.Empty(t, points)
+}
+
+func TestReadCalibrationPoints(t *testing.T) {
+	points, err := readCalibrationPoints(strings.NewReader("calibration:\n  points:\n    - slope: 0.002\n      power: 0.01\n"), "config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []config.CalibrationPoint{{Slope: 0.002, Power: 0.01}}, points)
+
+	_, err = readCalibrationPoints(strings.NewReader("serial:\n  port: COM3\n"), "other.yaml")
+	assert.Error(t, err, "no points")
+
+	points, err = readCalibrationPoints(strings.NewReader("slope_mv_s,power_mw\n2,10\n4, 20\n"), "points.CSV")
+	require.NoError(t, err)
+	assert.Equal(t, []config.CalibrationPoint{{Slope: 0.002, Power: 0.01}, {Slope: 0.004, Power: 0.02}}, points)
+
+	_, err = readCalibrationPoints(strings.NewReader("2,10\n"), "points.csv")
+	assert.Error(t, err, "no header")
+	_, err = readCalibrationPoints(strings.NewReader("slope_mv_s,power_mw\n2,x\n"), "points.csv")
+	assert.EqualError(t, err, `line 2: invalid power "x"`)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/itohio/golpm/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// watchConfig reloads the configuration file when it changes on disk, e.g.
+// edited by hand or saved by another instance, and applies it. The watcher
+// is kept for the lifetime of the application.
+func watchConfig(state *appState, filename string) {
+	w, err := config.Watch(filename)
+	if err != nil {
+		log.Printf("Configuration changes will not be reloaded: %v", err)
+		return
+	}
+	w.Subscribe(func(cfg *config.Config) {
+		fyne.Do(func() { applyConfigFile(state, cfg) })
+	})
+}
+
+// applyConfigFile applies a reloaded configuration to the running
+// application: the meter, the graph, the units and the theme follow it
+// live. Serial settings apply on the next connect and the language on the
+// next start. Reloads of the application's own saves change nothing. Call
+// it on the main thread.
+func applyConfigFile(state *appState, cfg *config.Config) {
+	current, err := yaml.Marshal(state.cfg)
+	if err == nil {
+		if reloaded, err := yaml.Marshal(cfg); err == nil && bytes.Equal(current, reloaded) {
+			return
+		}
+	}
+
+	skipped := state.cfg.ApplyLive(cfg)
+	reconfigureMeasurement(state)
+	state.powerMeter.UpdateCalibration(state.cfg.Measurement.PowerPolynomial, state.cfg.Measurement.AbsorbanceCoefficient)
+	applyTheme(fyne.CurrentApp(), state.cfg.UI.Theme)
+	applyUnits(state.cfg.UI.PowerUnit, state.cfg.UI.ReadingUnit)
+	if state.scopeWidget != nil {
+		updateHeaterLabels(state)
+		state.profileSelect.SetOptions(profileNames(state.cfg.Profiles))
+		state.profileSelect.Selected = state.cfg.Profile // Without the callback: already in use
+		state.profileSelect.Refresh()
+		state.viewSelect.SetOptions(viewNames(state.cfg.Scope.Views))
+		state.liveReadout.Refresh()
+		state.pulseLog.Refresh()
+		state.sessionStats.Refresh()
+		state.scopeWidget.Rescale()
+		updatePerformanceOverlay(state, time.Now())
+	}
+
+	if len(skipped) > 0 {
+		setStatus(state, "Reloaded configuration; changes to %s apply after reconnecting or restarting", strings.Join(skipped, ", "))
+		return
+	}
+	setStatus(state, "Reloaded configuration")
+}
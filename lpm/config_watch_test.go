@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyConfigFile(t *testing.T) {
+	test.NewTempApp(t)
+	cfg := config.Default()
+	cfg.Serial.NegotiatedRate = 100
+	state := &appState{cfg: cfg, powerMeter: meter.New(cfg), status: newStatusBar()}
+
+	// The application's own save reads back unchanged
+	applyConfigFile(state, config.Default())
+	assert.Empty(t, state.status.message.Text)
+
+	reloaded := config.Default()
+	reloaded.Measurement.WindowSeconds = 30
+	applyConfigFile(state, reloaded)
+	assert.Equal(t, "Reloaded configuration", state.status.message.Text)
+	assert.Equal(t, 30.0, state.cfg.Measurement.WindowSeconds)
+	assert.Equal(t, 100.0, state.cfg.Serial.NegotiatedRate, "the device's rate is kept")
+
+	reloaded = config.Default()
+	reloaded.Measurement.WindowSeconds = 30
+	reloaded.Serial.Port = "/dev/ttyACM1"
+	applyConfigFile(state, reloaded)
+	assert.Equal(t, "Reloaded configuration; changes to serial apply after reconnecting or restarting", state.status.message.Text)
+	assert.Equal(t, "COM3", state.cfg.Serial.Port)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// dataLogMaxSize is the size at which the data log starts a new samples file.
+const dataLogMaxSize = 100 << 20
+
+// handleDataLogToggle starts or stops logging the converted samples and
+// finalized pulses to lpm_log_<timestamp>_NNN.csv and
+// lpm_log_<timestamp>_pulses.csv. Logging goes on across reconnects until
+// stopped, for long unattended runs.
+func handleDataLogToggle(state *appState) {
+	if state.dataLog.Load() != nil {
+		stopDataLog(state)
+		return
+	}
+
+	base := fmt.Sprintf("lpm_log_%s", time.Now().Format("20060102_150405"))
+	l, err := meter.NewDataLog(base, dataLogMaxSize, heaterCount(state))
+	if err != nil {
+		dialog.ShowError(i18n.Errorf("failed to start data log: %w", err), state.window)
+		return
+	}
+	state.dataLog.Store(l)
+	setStatus(state, "Logging samples and pulses to %s_*.csv", base)
+	updateDataLogButton(state)
+	updateDataLogStatus(state)
+}
+
+// stopDataLog stops logging and closes the log files, if logging. Call it on
+// the main thread.
+func stopDataLog(state *appState) {
+	l := state.dataLog.Swap(nil)
+	if l == nil {
+		return
+	}
+	err := l.Close()
+	stats := l.Stats()
+	if err != nil {
+		setStatus(state, "Error closing data log: %v", err)
+	} else {
+		setStatus(state, "Stopped data log after %s: %s, %d samples files",
+			stats.Duration.Round(time.Second), formatSize(stats.Size), stats.Files)
+	}
+	updateDataLogButton(state)
+	updateDataLogStatus(state)
+}
+
+// tapDataLog passes samples through, logging them while the data log runs.
+// A failing log, e.g. a full disk, is stopped without interrupting the
+// measurement.
+func tapDataLog(state *appState, in <-chan sample.Sample) <-chan sample.Sample {
+	out := make(chan sample.Sample, 100)
+	go func() {
+		defer close(out)
+		for s := range in {
+			if l := state.dataLog.Load(); l != nil {
+				if err := l.LogSample(s); err != nil {
+					failDataLog(state, l, err)
+				}
+			}
+			out <- s
+		}
+	}()
+	return out
+}
+
+// updateDataLogHeaters gives the running data log a column for each heater
+// of the connected device, which starts a new samples file when a head with
+// another number of heaters was connected.
+func updateDataLogHeaters(state *appState) {
+	l := state.dataLog.Load()
+	if l == nil {
+		return
+	}
+	before := l.Stats().Files
+	if err := l.SetHeaterCount(heaterCount(state)); err != nil {
+		failDataLog(state, l, err)
+		return
+	}
+	if stats := l.Stats(); stats.Files != before {
+		setStatus(state, "Device has %d heaters, data log continues in %s", heaterCount(state), stats.Path)
+	}
+}
+
+// logPulses logs the finalized pulses of a meter update while the data log
+// runs.
+func logPulses(state *appState, pulses []meter.Pulse) {
+	if l := state.dataLog.Load(); l != nil {
+		if err := l.LogPulses(pulses); err != nil {
+			failDataLog(state, l, err)
+		}
+	}
+}
+
+// failDataLog stops l after a write error. A log stopped by the user in the
+// meantime is left alone. Call it from any goroutine.
+func failDataLog(state *appState, l *meter.DataLog, err error) {
+	if !state.dataLog.CompareAndSwap(l, nil) {
+		return
+	}
+	l.Close()
+	fyne.Do(func() {
+		setStatus(state, "Data log stopped: %v", err)
+		updateDataLogButton(state)
+		updateDataLogStatus(state)
+	})
+}
+
+// updateDataLogButton highlights the data log button while logging.
+func updateDataLogButton(state *appState) {
+	if state.dataLogBtn == nil {
+		return
+	}
+	if state.dataLog.Load() != nil {
+		state.dataLogBtn.Importance = widget.DangerImportance
+	} else {
+		state.dataLogBtn.Importance = widget.MediumImportance
+	}
+	state.dataLogBtn.Refresh()
+}
+
+// updateDataLogStatus shows the size and duration of the running data log
+// in the status bar, and hides them while not logging.
+func updateDataLogStatus(state *appState) {
+	if state.status == nil {
+		return
+	}
+	label := state.status.dataLog
+	l := state.dataLog.Load()
+	if l == nil {
+		label.Hide()
+		return
+	}
+	stats := l.Stats()
+	label.SetText(i18n.Tf("Log %s, %s", formatSize(stats.Size), stats.Duration.Round(time.Second)))
+	label.Show()
+}
+
+// formatSize formats a byte count, e.g. "12.3 MB".
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f kB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
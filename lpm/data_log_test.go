@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 kB", formatSize(1536))
+	assert.Equal(t, "100.0 MB", formatSize(dataLogMaxSize))
+	assert.Equal(t, "2.0 GB", formatSize(2<<30))
+}
+
+func TestTapDataLog(t *testing.T) {
+	state := &appState{}
+	in := make(chan sample.Sample)
+	out := tapDataLog(state, in)
+	t0 := time.Now()
+
+	// Samples pass through whether logging or not
+	in <- sample.Sample{Timestamp: t0}
+	<-out
+
+	base := filepath.Join(t.TempDir(), "log")
+	l, err := meter.NewDataLog(base, 0, 3)
+	require.NoError(t, err)
+	state.dataLog.Store(l)
+	in <- sample.Sample{Timestamp: t0.Add(time.Second)}
+	<-out
+	in <- sample.Sample{Timestamp: t0.Add(2 * time.Second)}
+	<-out
+	close(in)
+	_, ok := <-out
+	assert.False(t, ok, "closed with the input")
+	require.NoError(t, l.Close())
+
+	data, err := os.ReadFile(base + "_001.csv")
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3, "header and the samples logged")
+}
+
+func TestUpdateDataLogHeaters(t *testing.T) {
+	cfg := config.Default()
+	cfg.Heaters = cfg.Heaters[:2]
+	state := &appState{cfg: cfg}
+	updateDataLogHeaters(state) // Not logging
+
+	base := filepath.Join(t.TempDir(), "log")
+	l, err := meter.NewDataLog(base, 0, 3)
+	require.NoError(t, err)
+	state.dataLog.Store(l)
+	defer l.Close()
+
+	updateDataLogHeaters(state)
+	assert.Equal(t, base+"_002.csv", l.Stats().Path, "a new file for the other heaters")
+	updateDataLogHeaters(state)
+	assert.Equal(t, 2, l.Stats().Files)
+
+	data, err := os.ReadFile(base + "_002.csv")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(string(data)), "heater2,ambient_c,reading2_v"), string(data))
+}
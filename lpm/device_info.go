@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// showDeviceInfoDialog displays the connected device's firmware and board information.
+func showDeviceInfoDialog(state *appState) {
+	if state.device == nil || !state.device.IsConnected() {
+		dialog.ShowInformation(i18n.T("Device"), i18n.T("No device connected."), state.window)
+		return
+	}
+
+	info := state.device.Info()
+
+	firmware := info.FirmwareVersion
+	if firmware == "" {
+		firmware = i18n.T("unknown (legacy firmware)")
+	}
+	board := info.Board
+	if board == "" {
+		board = i18n.T("unknown")
+	}
+	serial := info.Serial
+	if serial == "" {
+		serial = i18n.T("unknown")
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem(i18n.T("Firmware"), widget.NewLabel(firmware)),
+		widget.NewFormItem(i18n.T("Board"), widget.NewLabel(board)),
+		widget.NewFormItem(i18n.T("Serial Number"), widget.NewLabel(serial)),
+		widget.NewFormItem(i18n.T("Uptime"), widget.NewLabel(info.Uptime.Truncate(time.Second).String())),
+		widget.NewFormItem(i18n.T("Protocol"), widget.NewLabel(fmt.Sprintf("v%d", info.ProtocolVersion))),
+		widget.NewFormItem(i18n.T("Channels"), widget.NewLabel(fmt.Sprintf("%d", info.Channels))),
+		widget.NewFormItem(i18n.T("Sample Rate"), widget.NewLabel(fmt.Sprintf("%.1f Hz", info.SampleRate))),
+	)
+	if info.Averaging > 0 {
+		oversampling := i18n.Tf("%d readings", info.Averaging)
+		if info.ADCInterval > 0 {
+			oversampling += i18n.Tf(" every %v", info.ADCInterval)
+		}
+		form.Append(i18n.T("Averaging"), widget.NewLabel(oversampling))
+	}
+	if info.ADCGain > 0 {
+		form.Append(i18n.T("ADC"), widget.NewLabel(i18n.Tf("gain %dx, reference %.3f V", info.ADCGain, info.ADCReference)))
+	}
+
+	dialog.ShowCustom(i18n.T("Device"), i18n.T("Close"), form, state.window)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/lpm"
+)
+
+// diagnosticsRefreshInterval is how often the diagnostics dialog re-reads the link counters.
+const diagnosticsRefreshInterval = time.Second
+
+// showDiagnosticsDialog displays live link statistics of the connected device,
+// so flaky USB links (parse errors, drops, lost samples, reconnects) and MCU
+// resets are easy to spot.
+func showDiagnosticsDialog(state *appState) {
+	if state.device == nil || !state.device.IsConnected() {
+		dialog.ShowInformation(i18n.T("Diagnostics"), i18n.T("No device connected."), state.window)
+		return
+	}
+
+	bytesLabel := widget.NewLabel("")
+	parsedLabel := widget.NewLabel("")
+	errorsLabel := widget.NewLabel("")
+	droppedLabel := widget.NewLabel("")
+	lostLabel := widget.NewLabel("")
+	reconnectsLabel := widget.NewLabel("")
+	resetsLabel := widget.NewLabel("")
+	retriesLabel := widget.NewLabel("")
+	failuresLabel := widget.NewLabel("")
+
+	update := func(stats lpm.Stats) {
+		bytesLabel.SetText(fmt.Sprintf("%d", stats.BytesRead))
+		parsedLabel.SetText(fmt.Sprintf("%d", stats.Parsed))
+		errorsLabel.SetText(formatRatio(stats.ParseErrors, stats.Parsed+stats.ParseErrors))
+		droppedLabel.SetText(formatRatio(stats.Dropped, stats.Parsed))
+		lostLabel.SetText(formatRatio(stats.Lost, stats.Parsed+stats.Lost))
+		reconnectsLabel.SetText(fmt.Sprintf("%d", stats.Reconnects))
+		resetsLabel.SetText(fmt.Sprintf("%d", stats.Resets))
+		retriesLabel.SetText(fmt.Sprintf("%d", stats.Retries))
+		failuresLabel.SetText(fmt.Sprintf("%d", stats.CommandFailures))
+	}
+	update(deviceStats(state))
+
+	form := widget.NewForm(
+		widget.NewFormItem(i18n.T("Bytes Read"), bytesLabel),
+		widget.NewFormItem(i18n.T("Samples Parsed"), parsedLabel),
+		widget.NewFormItem(i18n.T("Parse Errors"), errorsLabel),
+		widget.NewFormItem(i18n.T("Dropped Samples"), droppedLabel),
+		widget.NewFormItem(i18n.T("Lost Samples"), lostLabel),
+		widget.NewFormItem(i18n.T("Reconnects"), reconnectsLabel),
+		widget.NewFormItem(i18n.T("MCU Resets"), resetsLabel),
+		widget.NewFormItem(i18n.T("Command Retries"), retriesLabel),
+		widget.NewFormItem(i18n.T("Failed Commands"), failuresLabel),
+	)
+
+	content := fyne.CanvasObject(form)
+	if state.serial != nil {
+		content = container.NewVBox(form, widget.NewSeparator(), newSelfTestPanel(state.serial))
+	}
+
+	d := dialog.NewCustom(i18n.T("Diagnostics"), i18n.T("Close"), content, state.window)
+
+	// Refresh counters until the dialog is closed or the device disconnects
+	done := make(chan struct{})
+	d.SetOnClosed(func() { close(done) })
+	device := state.device
+	reconnects := appReconnects(state)
+	go func() {
+		ticker := time.NewTicker(diagnosticsRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if !device.IsConnected() {
+					return
+				}
+				stats := device.Stats()
+				stats.Reconnects += reconnects
+				fyne.Do(func() { update(stats) })
+			}
+		}
+	}()
+
+	d.Show()
+}
+
+// appReconnects returns how often the app connected a device again this
+// run. Every connect creates a new device, whose own statistics start over.
+func appReconnects(state *appState) uint64 {
+	if state.connects > 1 {
+		return state.connects - 1
+	}
+	return 0
+}
+
+// deviceStats returns the link statistics of the connected device, counting
+// the app's reconnects as well.
+func deviceStats(state *appState) lpm.Stats {
+	stats := state.device.Stats()
+	stats.Reconnects += appReconnects(state)
+	return stats
+}
+
+// newSelfTestPanel creates a button that runs the MCU self-test and a form
+// listing the result of each check.
+func newSelfTestPanel(device *lpm.Serial) fyne.CanvasObject {
+	results := widget.NewForm()
+	status := widget.NewLabel(i18n.T("Toggles each heater briefly and checks the ADC inputs and supply."))
+	status.Wrapping = fyne.TextWrapWord
+
+	var runBtn *widget.Button
+	runBtn = widget.NewButton(i18n.T("Run Self-Test"), func() {
+		runBtn.Disable()
+		status.SetText(i18n.T("Running self-test..."))
+		go func() {
+			report, err := device.SelfTest()
+			fyne.Do(func() {
+				runBtn.Enable()
+				if err != nil {
+					status.SetText(i18n.Tf("Self-test failed to run: %v", err))
+					return
+				}
+				showSelfTestReport(results, report)
+				if report.Passed() {
+					status.SetText(i18n.T("Self-test passed."))
+				} else {
+					status.SetText(i18n.T("Self-test found problems."))
+				}
+			})
+		}()
+	})
+
+	return container.NewVBox(runBtn, status, results)
+}
+
+// showSelfTestReport replaces the form items with one row per check.
+func showSelfTestReport(form *widget.Form, report lpm.SelfTestReport) {
+	form.Items = nil
+	for _, check := range report {
+		verdict := i18n.T("FAIL")
+		if check.Passed {
+			verdict = i18n.T("OK")
+		}
+		value := fmt.Sprintf("%d", check.Value)
+		if strings.HasPrefix(check.Name, "heater") {
+			value = i18n.Tf("supply drop %d", check.Value)
+		}
+		form.Append(check.Name, widget.NewLabel(fmt.Sprintf("%s (%s)", verdict, value)))
+	}
+	form.Refresh()
+}
+
+// formatRatio formats a counter with its percentage of total.
+func formatRatio(count, total uint64) string {
+	if total == 0 {
+		return fmt.Sprintf("%d", count)
+	}
+	return fmt.Sprintf("%d (%.2f%%)", count, 100*float64(count)/float64(total))
+}
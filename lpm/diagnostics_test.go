@@ -0,0 +1,48 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/scope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceStats_Reconnects(t *testing.T) {
+	test.NewTempApp(t)
+	cfg := config.Default()
+	state := &appState{
+		cfg:          cfg,
+		configPath:   filepath.Join(t.TempDir(), "config.yaml"),
+		powerMeter:   meter.New(cfg),
+		window:       test.NewWindow(nil),
+		useMock:      true,
+		scopeWidget:  scope.New(cfg),
+		liveReadout:  scope.NewLiveReadout(),
+		sessionStats: scope.NewSessionStatsPanel(),
+		pulseLog:     scope.NewPulseLog(),
+		status:       newStatusBar(),
+	}
+	createToolbar(state)
+	state.replayBar = newReplayBar(state)
+	t.Cleanup(func() { closeMeasurementChain(state.chain) })
+
+	handleConnect(state)
+	require.NotNil(t, state.device)
+	assert.Zero(t, deviceStats(state).Reconnects)
+
+	// Every connect creates a new device, which counts from zero again
+	handleConnect(state)
+	require.Nil(t, state.device)
+	handleConnect(state)
+	require.NotNil(t, state.device)
+	assert.Equal(t, uint64(1), deviceStats(state).Reconnects)
+
+	reconnect(state)
+	require.NotNil(t, state.device)
+	assert.Equal(t, uint64(2), deviceStats(state).Reconnects)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/lpm"
+)
+
+// discoverTimeout bounds the device scan before the picker is shown.
+const discoverTimeout = 5 * time.Second
+
+// showDevicePicker scans the USB ports of the supported boards for LPM
+// devices and lets the user pick one. The configured port is always offered,
+// so BLE heads and ports that are not probed or do not answer can still be
+// used. The chosen port is saved to the config
+// and onPick is called on the main thread.
+func showDevicePicker(state *appState, onPick func()) {
+	progress := dialog.NewCustomWithoutButtons(i18n.T("Connect"), container.NewVBox(widget.NewLabel(i18n.T("Searching for devices...")), widget.NewProgressBarInfinite()), state.window)
+	progress.Show()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), discoverTimeout)
+		defer cancel()
+
+		found, err := lpm.Discover(ctx, lpm.DiscoverOptions{})
+		if err != nil {
+			log.Printf("Device discovery failed: %v", err)
+		}
+
+		fyne.Do(func() {
+			progress.Hide()
+			pickDevice(state, found, onPick)
+		})
+	}()
+}
+
+// pickDevice shows the discovered devices in a picker.
+func pickDevice(state *appState, found []lpm.Discovered, onPick func()) {
+	options := make([]string, 0, len(found)+1)
+	ports := make(map[string]string, len(found)+1) // Option to port name
+
+	configured := state.cfg.Serial.Port
+	selected := ""
+	for _, d := range found {
+		option := d.String()
+		options = append(options, option)
+		ports[option] = d.Port
+		if d.Port == configured {
+			selected = option
+		}
+	}
+	if configured != "" && selected == "" {
+		selected = i18n.Tf("%s (configured)", configured)
+		options = append(options, selected)
+		ports[selected] = configured
+	}
+	if selected == "" && len(options) > 0 {
+		selected = options[0]
+	}
+
+	if len(options) == 0 {
+		dialog.ShowInformation(i18n.T("Connect"), i18n.T("No devices found. Select a port in Settings."), state.window)
+		return
+	}
+
+	deviceSelect := widget.NewSelect(options, nil)
+	deviceSelect.SetSelected(selected)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(i18n.T("Device"), deviceSelect),
+	}
+	dialog.ShowForm(i18n.T("Connect"), i18n.T("Connect"), i18n.T("Cancel"), items, func(ok bool) {
+		if !ok || deviceSelect.Selected == "" {
+			return
+		}
+
+		port := ports[deviceSelect.Selected]
+		if port != state.cfg.Serial.Port {
+			state.cfg.Serial.Port = port
+			if err := state.cfg.Save(state.configPath); err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+			}
+		}
+		onPick()
+	}, state.window)
+}
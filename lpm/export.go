@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/software"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/itohio/golpm/pkg/scope"
+)
+
+// handleAnnotatedExport saves the graph as it is now, below a header with
+// the date, device, configuration, calibration, the session statistics, the
+// markers and the user's
+// session notes and annotations, as a PNG for lab documentation. The graph is captured before the
+// notes dialog opens so the dialog is not part of the image.
+func handleAnnotatedExport(state *appState) {
+	plot, err := capturePlot(state)
+	if err != nil {
+		dialog.ShowError(i18n.Errorf("failed to capture graph: %w", err), state.window)
+		return
+	}
+	now := time.Now()
+
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetPlaceHolder(i18n.T("Setup, sample, operator..."))
+	notesEntry.SetMinRowsVisible(4)
+	notesEntry.SetText(state.notes)
+
+	items := []*widget.FormItem{widget.NewFormItem(i18n.T("Notes"), notesEntry)}
+	dialog.ShowForm(i18n.T("Annotated Export"), i18n.T("Save"), i18n.T("Cancel"), items, func(save bool) {
+		if !save {
+			return
+		}
+		state.notes = notesEntry.Text
+		img := renderAnnotatedExport(plot, exportHeader(state, now, notesEntry.Text), state.window.Canvas().Scale())
+		filename := fmt.Sprintf("lpm_scope_%s.png", now.Format("20060102_150405"))
+		if err := writePNG(filename, img); err != nil {
+			dialog.ShowError(i18n.Errorf("failed to save export: %w", err), state.window)
+			return
+		}
+		log.Printf("Saved annotated export to %s", filename)
+		dialog.ShowInformation(i18n.T("Annotated Export"), i18n.Tf("Saved %s", filename), state.window)
+	}, state.window)
+}
+
+// capturePlot returns the pixels of the graph area of the window.
+func capturePlot(state *appState) (image.Image, error) {
+	c := state.window.Canvas()
+	full := c.Capture()
+	if full == nil {
+		return nil, i18n.Errorf("window capture not supported")
+	}
+
+	// The capture is in pixels, object positions in canvas units
+	scale := float32(full.Bounds().Dx()) / c.Size().Width
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(state.graphSplit)
+	size := state.graphSplit.Size()
+	rect := image.Rect(
+		int(pos.X*scale), int(pos.Y*scale),
+		int((pos.X+size.Width)*scale), int((pos.Y+size.Height)*scale),
+	).Add(full.Bounds().Min).Intersect(full.Bounds())
+
+	sub, ok := full.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok || rect.Empty() {
+		return full, nil
+	}
+	return sub.SubImage(rect), nil
+}
+
+// exportHeader returns the label and value rows of the export header.
+func exportHeader(state *appState, now time.Time, notes string) [][2]string {
+	device := i18n.T("not connected")
+	switch {
+	case state.useMock:
+		device = i18n.T("mock")
+	case state.device != nil && state.device.IsConnected():
+		info := state.device.Info()
+		device = i18n.Tf("%s, firmware %s, protocol v%d", info.Board, info.FirmwareVersion, info.ProtocolVersion)
+		if state.cfg.Serial.Port != "" {
+			device = state.cfg.Serial.Port + ": " + device
+		}
+	}
+
+	m := state.cfg.Measurement
+	rate := state.cfg.Serial.NegotiatedRate
+	if rate <= 0 {
+		rate = state.cfg.Serial.SampleRate
+	}
+	configSummary := i18n.Tf("%.1f Hz, window %.1fs, threshold %.2f mV/s, absorbance %.2f",
+		rate, m.WindowSeconds, m.PulseThresholdMVS, m.AbsorbanceCoefficient)
+
+	calibration := i18n.T("never fitted")
+	if !state.cfg.Calibration.Date.IsZero() {
+		calibration = state.cfg.Calibration.Date.Format("2006-01-02 15:04")
+	}
+	calibration += i18n.Tf(", %d points, polynomial %v", len(state.cfg.Calibration.Points), m.PowerPolynomial)
+
+	rows := [][2]string{
+		{i18n.T("Date"), now.Format("2006-01-02 15:04:05")},
+		{i18n.T("Device"), device},
+		{i18n.T("Config"), configSummary},
+		{i18n.T("Calibration"), calibration},
+		{i18n.T("Session"), sessionStatsSummary(currentSessionStats(state))},
+	}
+	if state.scopeWidget != nil {
+		if markers := state.scopeWidget.Markers(); len(markers) > 0 {
+			rows = append(rows, [2]string{i18n.T("Markers"), markerSummary(markers)})
+		}
+	}
+	if notes = strings.TrimSpace(notes); notes != "" {
+		rows = append(rows, [2]string{i18n.T("Notes"), notes})
+	}
+	if len(state.annotations) > 0 {
+		rows = append(rows, [2]string{i18n.T("Annotations"), annotationSummary(state.annotations)})
+	}
+	return rows
+}
+
+// sessionStatsSummary returns the session statistics on one line.
+func sessionStatsSummary(stats meter.SessionStats) string {
+	var parts []string
+	for _, row := range scope.SessionStatsRows(stats) {
+		parts = append(parts, row[0]+" "+row[1])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderAnnotatedExport draws the header rows above plot. scale is the
+// pixel density the plot was captured at, so the header text matches it.
+func renderAnnotatedExport(plot image.Image, rows [][2]string, scale float32) image.Image {
+	form := widget.NewForm()
+	for _, row := range rows {
+		form.Append(row[0], widget.NewLabel(row[1]))
+	}
+
+	// Lay the header out at the plot's width
+	header := container.NewPadded(form)
+	c := software.NewCanvas()
+	c.SetPadded(false)
+	c.SetScale(scale)
+	c.SetContent(header)
+	width := max(float32(plot.Bounds().Dx())/scale, header.MinSize().Width)
+	c.Resize(fyne.NewSize(width, header.MinSize().Height))
+	headerImg := c.Capture()
+
+	hb, pb := headerImg.Bounds(), plot.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, max(hb.Dx(), pb.Dx()), hb.Dy()+pb.Dy()))
+	draw.Draw(out, out.Bounds(), image.NewUniform(headerImg.At(hb.Min.X, hb.Min.Y)), image.Point{}, draw.Src)
+	draw.Draw(out, image.Rect(0, 0, hb.Dx(), hb.Dy()), headerImg, hb.Min, draw.Src)
+	draw.Draw(out, image.Rect(0, hb.Dy(), pb.Dx(), hb.Dy()+pb.Dy()), plot, pb.Min, draw.Src)
+	return out
+}
+
+// writePNG encodes img to filename.
+func writePNG(filename string, img image.Image) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// handleCSVExport asks for a folder and saves the meter's current samples
+// with their derivatives, its pulse table and the session statistics there
+// as lpm_samples_<timestamp>.csv, lpm_pulses_<timestamp>.csv and
+// lpm_summary_<timestamp>.csv. While a session is open, its data is saved
+// instead.
+func handleCSVExport(state *appState) {
+	var (
+		samples     []sample.Sample
+		derivatives []float64
+		pulses      []meter.Pulse
+	)
+	switch {
+	case state.sessionOpen:
+		samples, derivatives, pulses = state.scopeWidget.History()
+	case state.powerMeter != nil:
+		samples, derivatives, pulses = state.powerMeter.Samples(), state.powerMeter.Derivatives(), state.powerMeter.Pulses()
+	}
+	if len(samples) == 0 {
+		dialog.ShowInformation(i18n.T("Export CSV"), i18n.T("There is no data to export yet."), state.window)
+		return
+	}
+	stats := currentSessionStats(state)
+	ts := time.Now().Format("20060102_150405")
+
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, state.window)
+			return
+		}
+		if dir == nil {
+			return // Cancelled
+		}
+		samplesName := fmt.Sprintf("lpm_samples_%s.csv", ts)
+		pulsesName := fmt.Sprintf("lpm_pulses_%s.csv", ts)
+		summaryName := fmt.Sprintf("lpm_summary_%s.csv", ts)
+		err = writeCSVFile(dir, samplesName, func(w io.Writer) error {
+			return meter.WriteSamplesCSV(w, samples, derivatives)
+		})
+		if err == nil {
+			err = writeCSVFile(dir, pulsesName, func(w io.Writer) error {
+				return meter.WritePulsesCSV(w, pulses)
+			})
+		}
+		if err == nil {
+			err = writeCSVFile(dir, summaryName, func(w io.Writer) error {
+				return meter.WriteSessionStatsCSV(w, stats)
+			})
+		}
+		if err != nil {
+			dialog.ShowError(i18n.Errorf("failed to export CSV: %w", err), state.window)
+			return
+		}
+		setStatus(state, "Exported %d samples and %d pulses to %s", len(samples), len(pulses), dir.Path())
+		dialog.ShowInformation(i18n.T("Export CSV"), fmt.Sprintf("Saved %s, %s and %s", samplesName, pulsesName, summaryName), state.window)
+	}, state.window)
+}
+
+// writeCSVFile creates name in dir and writes it with write.
+func writeCSVFile(dir fyne.URI, name string, write func(io.Writer) error) error {
+	uri, err := storage.Child(dir, name)
+	if err != nil {
+		return err
+	}
+	w, err := storage.Writer(uri)
+	if err != nil {
+		return err
+	}
+	return errors.Join(write(w), w.Close())
+}
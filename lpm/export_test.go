@@ -0,0 +1,53 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportHeader(t *testing.T) {
+	cfg := config.Default()
+	state := &appState{cfg: cfg, useMock: true}
+	now := time.Date(2024, 5, 1, 14, 3, 7, 0, time.Local)
+
+	rows := exportHeader(state, now, "  ")
+	require.Len(t, rows, 5, "blank notes are left out")
+	assert.Equal(t, [2]string{"Date", "2024-05-01 14:03:07"}, rows[0])
+	assert.Equal(t, [2]string{"Device", "mock"}, rows[1])
+	assert.Contains(t, rows[3][1], "never fitted")
+	assert.Equal(t, "Session", rows[4][0])
+	assert.Contains(t, rows[4][1], "Pulses 0, Mean Power —")
+
+	cfg.Calibration.Date = time.Date(2024, 4, 30, 9, 15, 0, 0, time.Local)
+	rows = exportHeader(state, now, "Sample A, 405 nm\n")
+	require.Len(t, rows, 6)
+	assert.Contains(t, rows[3][1], "2024-04-30 09:15")
+	assert.Equal(t, [2]string{"Notes", "Sample A, 405 nm"}, rows[5])
+
+	state.annotations = []annotation{{Time: now, Text: "beam aligned"}}
+	rows = exportHeader(state, now, "")
+	require.Len(t, rows, 6)
+	assert.Equal(t, [2]string{"Annotations", "2024-05-01 14:03:07  beam aligned"}, rows[5])
+}
+
+func TestRenderAnnotatedExport(t *testing.T) {
+	test.NewTempApp(t)
+	red := color.RGBA{R: 255, A: 255}
+	plot := image.NewRGBA(image.Rect(0, 0, 800, 300))
+	draw.Draw(plot, plot.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	img := renderAnnotatedExport(plot, [][2]string{{"Date", "2024-05-01"}, {"Notes", "test"}}, 1)
+	b := img.Bounds()
+	assert.Equal(t, 800, b.Dx(), "header takes the plot's width")
+	require.Greater(t, b.Dy(), 300, "header is above the plot")
+	assert.Equal(t, red, img.At(0, b.Max.Y-1), "plot at the bottom")
+	assert.NotEqual(t, red, img.At(0, 0), "header at the top")
+}
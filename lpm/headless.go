@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// headlessOptions configure a run without the GUI.
+type headlessOptions struct {
+	useMock       bool
+	useStatistics bool
+	jitter        time.Duration
+	json          bool          // JSON lines instead of text
+	interval      time.Duration // Between power readings, 0 for pulses only
+}
+
+// runHeadless runs the device, converter and meter chain without Fyne,
+// printing measured pulses and periodic readings to stdout until interrupted.
+// Status messages go to the log on stderr, so stdout carries only data.
+func runHeadless(cfg *config.Config, opts headlessOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	device, err := connectHeadless(ctx, cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	// The meter is created after negotiating, as its windows may be in samples
+	powerMeter := meter.New(cfg)
+	reporter := meter.NewReporter(os.Stdout, opts.json, opts.interval)
+	powerMeter.OnUpdate(reporter.Update)
+
+	meterDone := make(chan struct{})
+	go func() {
+		defer close(meterDone)
+		powerMeter.ProcessSamples(sample.NewPipeline(cfg, opts.useStatistics, device.Samples()))
+	}()
+
+	select {
+	case <-ctx.Done():
+		log.Printf("Stopping")
+	case <-meterDone:
+		log.Printf("Device stream ended")
+	}
+	device.Close()
+	<-meterDone
+	return nil
+}
+
+// connectHeadless creates the device selected by opts, with jitter and a
+// watchdog logging stalls, connects it with ctx, puts a known head's
+// profile in use and negotiates its sample rate.
+func connectHeadless(ctx context.Context, cfg *config.Config, opts headlessOptions) (lpm.Device, error) {
+	var device lpm.Device
+	if opts.useMock {
+		device = lpm.NewMock(&cfg.Mock)
+	} else {
+		serialDevice, err := lpm.NewFromConfig(&cfg.Serial, lpm.DefaultBufferSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid serial settings: %w", err)
+		}
+		device = serialDevice
+	}
+	if opts.jitter > 0 {
+		device = lpm.NewJitter(device, lpm.JitterOptions{
+			Delay:         opts.jitter,
+			Jitter:        opts.jitter / 2,
+			DropChance:    0.01,
+			DuplicateRate: 0.01,
+			Seed:          time.Now().UnixNano(),
+		})
+	}
+	device = lpm.NewWatchdog(device, cfg.Serial.StaleTimeout, func(healthy bool) {
+		if !healthy {
+			log.Printf("Device stopped sending samples")
+		}
+	})
+
+	if err := device.ConnectContext(ctx); err != nil {
+		if opts.useMock {
+			return nil, fmt.Errorf("failed to connect to mocked device: %w", err)
+		}
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.Serial.Port, err)
+	}
+	if opts.useMock {
+		log.Printf("Connected to mocked device")
+	} else {
+		log.Printf("Connected to serial port: %s", cfg.Serial.Port)
+		if name, ok := cfg.UseDeviceProfile(device.Info().Serial); ok {
+			log.Printf("Using profile %s of head %s", name, device.Info().Serial)
+		}
+	}
+	lpm.Negotiate(device, &cfg.Serial, opts.useMock)
+	return device, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Automatic reconnects after a stall wait stallReconnectDelay, doubled after
+// each one up to stallReconnectMaxDelay while the device keeps stalling.
+const (
+	stallReconnectDelay    = 2 * time.Second
+	stallReconnectMaxDelay = time.Minute
+)
+
+// handleDeviceHealth reflects the device watchdog state in the UI.
+// While no samples arrive the connect button is highlighted as a warning
+// and the device is reconnected after a delay, backing off while it keeps
+// stalling. A stalled replay is left alone.
+func handleDeviceHealth(state *appState, healthy bool) {
+	state.stalled = !healthy
+	if state.stallRetry != nil {
+		state.stallRetry.Stop()
+		state.stallRetry = nil
+	}
+	if !healthy && state.device != nil && state.replay == nil {
+		// A device that kept up since the last reconnect starts over
+		if time.Since(state.stallReconnectAt) > stallReconnectMaxDelay {
+			state.stallDelay = stallReconnectDelay
+		}
+		var retry *time.Timer
+		retry = time.AfterFunc(state.stallDelay, func() {
+			fyne.Do(func() {
+				if state.stallRetry == retry {
+					state.stallRetry = nil
+					reconnectStalled(state)
+				}
+			})
+		})
+		state.stallRetry = retry
+	}
+	if state.connectBtn == nil {
+		return
+	}
+
+	switch {
+	case healthy:
+		state.connectBtn.Importance = widget.HighImportance
+	case state.stallRetry != nil:
+		setStatus(state, "Device stopped sending samples; reconnecting in %s", state.stallDelay)
+		state.connectBtn.Importance = widget.WarningImportance
+	default:
+		setStatus(state, "Device stopped sending samples; reconnect to recover")
+		state.connectBtn.Importance = widget.WarningImportance
+	}
+	state.connectBtn.Refresh()
+}
+
+// reconnectStalled reconnects to the stalled device like reconnect, but to
+// the port in use without asking, and doubles the delay before the next
+// automatic reconnect.
+func reconnectStalled(state *appState) {
+	if !state.stalled || state.device == nil {
+		return
+	}
+	state.stallReconnectAt = time.Now()
+	state.stallDelay = min(2*state.stallDelay, stallReconnectMaxDelay)
+
+	closeDevice(state)
+	handleDeviceHealth(state, true)
+	connectDevice(state)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/scope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDeviceHealth_Reconnects(t *testing.T) {
+	test.NewTempApp(t)
+	cfg := config.Default()
+	state := &appState{
+		cfg:          cfg,
+		configPath:   filepath.Join(t.TempDir(), "config.yaml"),
+		powerMeter:   meter.New(cfg),
+		window:       test.NewWindow(nil),
+		useMock:      true,
+		scopeWidget:  scope.New(cfg),
+		liveReadout:  scope.NewLiveReadout(),
+		sessionStats: scope.NewSessionStatsPanel(),
+		pulseLog:     scope.NewPulseLog(),
+		status:       newStatusBar(),
+	}
+	createToolbar(state)
+	state.replayBar = newReplayBar(state)
+	t.Cleanup(func() {
+		handleDeviceHealth(state, true)
+		closeMeasurementChain(state.chain)
+	})
+
+	handleConnect(state)
+	require.NotNil(t, state.device)
+
+	// A stream recovering on its own needs no reconnect
+	handleDeviceHealth(state, false)
+	require.NotNil(t, state.stallRetry)
+	assert.Equal(t, stallReconnectDelay, state.stallDelay)
+	handleDeviceHealth(state, true)
+	assert.Nil(t, state.stallRetry)
+
+	handleDeviceHealth(state, false)
+	device := state.device
+	reconnectStalled(state)
+	require.NotNil(t, state.device)
+	assert.NotSame(t, device, state.device)
+	assert.False(t, state.stalled)
+	assert.Equal(t, uint64(2), state.connects)
+
+	// Stalling again soon after backs off
+	handleDeviceHealth(state, false)
+	assert.Equal(t, 2*stallReconnectDelay, state.stallDelay)
+
+	// Disconnecting cancels the reconnect
+	handleConnect(state)
+	assert.Nil(t, state.stallRetry)
+	reconnectStalled(state)
+	assert.Nil(t, state.device)
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/lpm"
+)
+
+// heaterScheduleHeaters returns the heater choices of a schedule step on a
+// device with n heaters.
+func heaterScheduleHeaters(n int) []string {
+	heaters := make([]string, n)
+	for i := range heaters {
+		heaters[i] = fmt.Sprintf("H%d", i+1)
+	}
+	return heaters
+}
+
+// heaterScheduleRun is a heater schedule running on the device.
+type heaterScheduleRun struct {
+	cancel context.CancelFunc
+	done   chan struct{} // Closed when the schedule has finished
+}
+
+// heaterScheduleDialog holds the widgets of the open heater schedule dialog
+// that follow a running schedule.
+type heaterScheduleDialog struct {
+	runBtn   *widget.Button
+	progress *widget.Label
+}
+
+// heaterScheduleRow holds the entries of one schedule step.
+type heaterScheduleRow struct {
+	heater   *widget.Select
+	duty     *widget.Entry // %
+	duration *widget.Entry
+	rest     *widget.Entry
+	repeat   *widget.Entry
+}
+
+// showHeaterScheduleDialog shows the heater schedule editor: steps of a
+// heater at a duty cycle for a time, with a rest after it and a repeat count,
+// run against the device for thermal characterization or custom calibration
+// protocols. The schedule keeps running when the dialog is closed.
+func showHeaterScheduleDialog(state *appState) {
+	var rows []heaterScheduleRow
+	list := container.NewVBox()
+
+	// The header reserves the width of the rows' remove buttons
+	removeWidth := canvas.NewRectangle(color.Transparent)
+	removeWidth.SetMinSize(widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), nil).MinSize())
+	bold := fyne.TextStyle{Bold: true}
+	header := container.NewBorder(nil, nil, nil, removeWidth, container.NewGridWithColumns(5,
+		widget.NewLabelWithStyle(i18n.T("Heater"), fyne.TextAlignLeading, bold),
+		widget.NewLabelWithStyle(i18n.T("Duty (%)"), fyne.TextAlignLeading, bold),
+		widget.NewLabelWithStyle(i18n.T("On"), fyne.TextAlignLeading, bold),
+		widget.NewLabelWithStyle(i18n.T("Rest"), fyne.TextAlignLeading, bold),
+		widget.NewLabelWithStyle(i18n.T("Repeat"), fyne.TextAlignLeading, bold),
+	))
+
+	var refresh func()
+	addRow := func(step config.HeaterStep) {
+		row := heaterScheduleRow{
+			heater:   widget.NewSelect(heaterScheduleHeaters(heaterCount(state)), nil),
+			duty:     widget.NewEntry(),
+			duration: widget.NewEntry(),
+			rest:     widget.NewEntry(),
+			repeat:   widget.NewEntry(),
+		}
+		row.heater.SetSelectedIndex(max(step.Heater-1, 0))
+		row.duty.SetText(strconv.FormatFloat(step.Duty*100, 'g', -1, 64))
+		row.duration.SetText(step.Duration.String())
+		row.rest.SetText(step.Rest.String())
+		row.repeat.SetText(strconv.Itoa(step.Repeat))
+		rows = append(rows, row)
+	}
+	refresh = func() {
+		objects := make([]fyne.CanvasObject, len(rows))
+		for i, row := range rows {
+			removeBtn := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+				for j := range rows {
+					if rows[j].duty == row.duty {
+						rows = append(rows[:j], rows[j+1:]...)
+						break
+					}
+				}
+				refresh()
+			})
+			objects[i] = container.NewBorder(nil, nil, nil, removeBtn,
+				container.NewGridWithColumns(5, row.heater, row.duty, row.duration, row.rest, row.repeat))
+		}
+		list.Objects = objects
+		list.Refresh()
+	}
+
+	steps := state.cfg.Calibration.HeaterSchedule
+	if len(steps) == 0 {
+		steps = []config.HeaterStep{{Heater: 1, Duty: 1, Duration: 10 * time.Second, Rest: 30 * time.Second, Repeat: 1}}
+	}
+	for _, step := range steps {
+		addRow(step)
+	}
+	refresh()
+
+	addBtn := widget.NewButtonWithIcon(i18n.T("Add Step"), theme.ContentAddIcon(), func() {
+		addRow(config.HeaterStep{Heater: 1, Duty: 1, Duration: 10 * time.Second, Rest: 30 * time.Second, Repeat: 1})
+		refresh()
+	})
+
+	d := &heaterScheduleDialog{progress: widget.NewLabel("")}
+	d.runBtn = widget.NewButtonWithIcon(i18n.T("Run"), theme.MediaPlayIcon(), func() {
+		if state.heaterSchedule != nil {
+			stopHeaterSchedule(state)
+			return
+		}
+		steps, err := parseHeaterSchedule(rows)
+		if err == nil {
+			err = lpm.ValidateHeaterSchedule(steps, heaterCount(state))
+		}
+		if err != nil {
+			dialog.ShowError(err, state.window)
+			return
+		}
+		state.cfg.Calibration.HeaterSchedule = steps
+		if err := state.cfg.Save(state.configPath); err != nil {
+			dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+		}
+		startHeaterSchedule(state, steps)
+	})
+	state.scheduleDialog = d
+	updateHeaterScheduleDialog(state)
+
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(0, 200))
+	content := container.NewBorder(header, container.NewVBox(addBtn, widget.NewSeparator(), container.NewBorder(nil, nil, nil, d.runBtn, d.progress)), nil, nil, scroll)
+
+	dlg := dialog.NewCustom(i18n.T("Heater Schedule"), i18n.T("Close"), content, state.window)
+	dlg.SetOnClosed(func() { state.scheduleDialog = nil })
+	dlg.Resize(fyne.NewSize(640, 420))
+	dlg.Show()
+}
+
+// parseHeaterSchedule returns the steps of the rows.
+func parseHeaterSchedule(rows []heaterScheduleRow) ([]config.HeaterStep, error) {
+	steps := make([]config.HeaterStep, 0, len(rows))
+	for i, row := range rows {
+		duty, err := strconv.ParseFloat(strings.TrimSpace(row.duty.Text), 64)
+		if err != nil {
+			return nil, i18n.Errorf("step %d: invalid duty %q", i+1, row.duty.Text)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(row.duration.Text))
+		if err != nil {
+			return nil, i18n.Errorf("step %d: invalid on time %q", i+1, row.duration.Text)
+		}
+		rest, err := time.ParseDuration(strings.TrimSpace(row.rest.Text))
+		if err != nil {
+			return nil, i18n.Errorf("step %d: invalid rest %q", i+1, row.rest.Text)
+		}
+		repeat, err := strconv.Atoi(strings.TrimSpace(row.repeat.Text))
+		if err != nil {
+			return nil, i18n.Errorf("step %d: invalid repeat count %q", i+1, row.repeat.Text)
+		}
+		steps = append(steps, config.HeaterStep{
+			Heater:   row.heater.SelectedIndex() + 1,
+			Duty:     duty / 100,
+			Duration: duration,
+			Rest:     rest,
+			Repeat:   repeat,
+		})
+	}
+	return steps, nil
+}
+
+// startHeaterSchedule runs steps on the connected device in the background.
+func startHeaterSchedule(state *appState, steps []config.HeaterStep) {
+	if state.device == nil || !state.device.IsConnected() {
+		dialog.ShowInformation(i18n.T("Heater Schedule"), i18n.T("Connect a device first."), state.window)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &heaterScheduleRun{cancel: cancel, done: make(chan struct{})}
+	state.heaterSchedule = run
+	device := state.device
+	setStatus(state, "Running heater schedule of %d steps, %s", len(steps), lpm.HeaterScheduleDuration(steps))
+	updateHeaterScheduleDialog(state)
+
+	go func() {
+		defer close(run.done)
+		err := lpm.RunHeaterSchedule(ctx, device, steps, func(p lpm.HeaterPhase) {
+			msg := formatHeaterPhase(steps, p)
+			fyne.Do(func() {
+				if d := state.scheduleDialog; d != nil {
+					d.progress.SetText(msg)
+				}
+			})
+		})
+		fyne.Do(func() {
+			if state.heaterSchedule == run {
+				state.heaterSchedule = nil
+			}
+			switch {
+			case errors.Is(err, context.Canceled):
+				setStatus(state, "Heater schedule stopped")
+			case err != nil:
+				setStatus(state, "Heater schedule failed: %v", err)
+			default:
+				setStatus(state, "Heater schedule finished")
+			}
+			updateHeaterScheduleDialog(state)
+		})
+	}()
+}
+
+// stopHeaterSchedule stops the running heater schedule, if any, and waits
+// for it to turn the heaters off.
+func stopHeaterSchedule(state *appState) {
+	run := state.heaterSchedule
+	if run == nil {
+		return
+	}
+	state.heaterSchedule = nil
+	run.cancel()
+	<-run.done
+	updateHeaterScheduleDialog(state)
+}
+
+// updateHeaterScheduleDialog shows whether a schedule runs in the open
+// dialog, if any.
+func updateHeaterScheduleDialog(state *appState) {
+	d := state.scheduleDialog
+	if d == nil {
+		return
+	}
+	if state.heaterSchedule != nil {
+		d.runBtn.SetText(i18n.T("Stop"))
+		d.runBtn.SetIcon(theme.MediaStopIcon())
+		d.runBtn.Importance = widget.DangerImportance
+	} else {
+		d.runBtn.SetText(i18n.T("Run"))
+		d.runBtn.SetIcon(theme.MediaPlayIcon())
+		d.runBtn.Importance = widget.MediumImportance
+		d.progress.SetText("")
+	}
+	d.runBtn.Refresh()
+}
+
+// formatHeaterPhase describes a phase of steps, e.g.
+// "Step 2/3, run 1/5: H1 at 50% for 10s".
+func formatHeaterPhase(steps []config.HeaterStep, p lpm.HeaterPhase) string {
+	s := steps[p.Step]
+	prefix := i18n.Tf("Step %d/%d, run %d/%d: ", p.Step+1, len(steps), p.Run, s.Repeat)
+	if !p.On {
+		return prefix + i18n.Tf("resting for %s", p.Length)
+	}
+	return prefix + i18n.Tf("H%d at %g%% for %s", s.Heater, s.Duty*100, p.Length)
+}
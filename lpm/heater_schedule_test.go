@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scheduleRow creates a schedule row with the given texts.
+func scheduleRow(heater int, duty, duration, rest, repeat string) heaterScheduleRow {
+	row := heaterScheduleRow{
+		heater:   widget.NewSelect(heaterScheduleHeaters(3), nil),
+		duty:     widget.NewEntry(),
+		duration: widget.NewEntry(),
+		rest:     widget.NewEntry(),
+		repeat:   widget.NewEntry(),
+	}
+	row.heater.SetSelectedIndex(heater - 1)
+	row.duty.SetText(duty)
+	row.duration.SetText(duration)
+	row.rest.SetText(rest)
+	row.repeat.SetText(repeat)
+	return row
+}
+
+func TestParseHeaterSchedule(t *testing.T) {
+	test.NewTempApp(t)
+	steps, err := parseHeaterSchedule([]heaterScheduleRow{
+		scheduleRow(2, "50", "10s", "30s", "3"),
+		scheduleRow(3, "100", "1m", "0s", "1"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []config.HeaterStep{
+		{Heater: 2, Duty: 0.5, Duration: 10 * time.Second, Rest: 30 * time.Second, Repeat: 3},
+		{Heater: 3, Duty: 1, Duration: time.Minute, Repeat: 1},
+	}, steps)
+
+	_, err = parseHeaterSchedule([]heaterScheduleRow{scheduleRow(1, "50", "10", "0s", "1")})
+	assert.EqualError(t, err, `step 1: invalid on time "10"`)
+}
+
+func TestFormatHeaterPhase(t *testing.T) {
+	steps := []config.HeaterStep{
+		{Heater: 1, Duty: 1, Duration: time.Second, Repeat: 1},
+		{Heater: 2, Duty: 0.5, Duration: 10 * time.Second, Rest: 30 * time.Second, Repeat: 5},
+	}
+	assert.Equal(t, "Step 2/2, run 3/5: H2 at 50% for 10s", formatHeaterPhase(steps, lpm.HeaterPhase{Step: 1, Run: 3, On: true, Length: 10 * time.Second}))
+	assert.Equal(t, "Step 2/2, run 3/5: resting for 30s", formatHeaterPhase(steps, lpm.HeaterPhase{Step: 1, Run: 3, Length: 30 * time.Second}))
+}
@@ -2,11 +2,17 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"slices"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
 	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/itohio/golpm/pkg/meter"
 )
 
 // handleHeaterToggle handles heater button click to toggle heater state.
@@ -15,41 +21,42 @@ func handleHeaterToggle(state *appState, heaterIndex int) {
 		return
 	}
 
-	// Toggle heater state
-	state.heaterState[heaterIndex] = !state.heaterState[heaterIndex]
+	// Toggle heater state; the current states may be a received sample's,
+	// which must not change
+	newState := currentHeaterStates(state)
+	newState[heaterIndex] = !newState[heaterIndex]
 
 	// Send command to device
-	err := state.device.SetHeaters(
-		state.heaterState[0],
-		state.heaterState[1],
-		state.heaterState[2],
-	)
+	err := state.device.SetHeaters(newState...)
 	if err != nil {
-		// Revert state on error
-		state.heaterState[heaterIndex] = !state.heaterState[heaterIndex]
-		dialog.ShowError(fmt.Errorf("failed to set heaters: %w", err), state.window)
+		dialog.ShowError(i18n.Errorf("failed to set heaters: %w", err), state.window)
 		return
 	}
 
 	// Update button visual state (optimistic update)
+	state.heaterState = newState
 	updateHeaterButtonStates(state)
 }
 
+// currentHeaterStates returns a copy of the current heater states, one per
+// heater button; heaters not reported yet are off.
+func currentHeaterStates(state *appState) []bool {
+	states := make([]bool, len(state.heaterBtns))
+	copy(states, state.heaterState)
+	return states
+}
+
 // updateHeaterStatesFromSample updates heater button states from incoming sample.
 // Only updates UI when heater state actually changes.
 // Uses fyne.Do() to ensure thread-safe UI updates from goroutine.
 func updateHeaterStatesFromSample(state *appState, sample lpm.RawSample) {
-	// Check if state changed - arrays are directly comparable in Go
-	newState := [3]bool{sample.Heater1, sample.Heater2, sample.Heater3}
-	if state.heaterState == newState {
+	if slices.Equal(state.heaterState, sample.Heaters) {
 		// No change, skip update
 		return
 	}
 
-	// Update state from sample
-	state.heaterState[0] = sample.Heater1
-	state.heaterState[1] = sample.Heater2
-	state.heaterState[2] = sample.Heater3
+	// Update state from sample; its heater states are never modified
+	state.heaterState = sample.Heaters
 
 	// Update UI on main thread using fyne.Do()
 	fyne.Do(func() {
@@ -58,25 +65,75 @@ func updateHeaterStatesFromSample(state *appState, sample lpm.RawSample) {
 }
 
 // updateHeaterButtonStates updates the visual state of heater buttons.
-// Also controls visibility of "Add Cal Point" button and "Heater Off" button - only enabled when at least one heater is on.
+// Also controls visibility of "Add Cal Point" button - only shown when at least one heater is on.
+// The "All Off" button stays enabled while connected, so it works whatever the heaters are reported as.
 func updateHeaterButtonStates(state *appState) {
-	updateHeaterButton(state.heater1Btn, state.heaterState[0])
-	updateHeaterButton(state.heater2Btn, state.heaterState[1])
-	updateHeaterButton(state.heater3Btn, state.heaterState[2])
+	heaters := currentHeaterStates(state)
+	for i, btn := range state.heaterBtns {
+		updateHeaterButton(btn, heaters[i])
+	}
 
-	// Show/enable buttons only when at least one heater is on
-	anyHeaterOn := state.heaterState[0] || state.heaterState[1] || state.heaterState[2]
+	// Show buttons only when at least one heater is on
+	anyHeaterOn := slices.Contains(state.heaterState, true)
 	if anyHeaterOn {
 		state.addCalPointBtn.Show()
-		state.heaterOffBtn.Enable()
 	} else {
 		state.addCalPointBtn.Hide()
-		state.heaterOffBtn.Disable()
 	}
 
 	// Refresh buttons to update visual state
 	state.addCalPointBtn.Refresh()
-	state.heaterOffBtn.Refresh()
+}
+
+// heaterCount returns the number of heaters to show buttons for: the
+// connected device's, or the configured ones while disconnected.
+func heaterCount(state *appState) int {
+	if state.device != nil && state.device.IsConnected() {
+		return state.device.Info().HeaterCount()
+	}
+	return len(state.cfg.Heaters)
+}
+
+// updateHeaterLabels shows a button for each heater, labelled with its
+// configured resistance. The buttons are replaced when the number of heaters
+// changed, enabled like the other heater controls.
+func updateHeaterLabels(state *appState) {
+	if n := heaterCount(state); n != len(state.heaterBtns) {
+		state.heaterBtns = make([]*widget.Button, n)
+		objects := make([]fyne.CanvasObject, n)
+		for i := range state.heaterBtns {
+			// Using radio button checked/unchecked icons to represent heater state
+			btn := widget.NewButtonWithIcon("", theme.RadioButtonIcon(), func() {
+				handleHeaterToggle(state, i)
+			})
+			if state.heaterIncrementBtn.Disabled() {
+				btn.Disable()
+			}
+			state.heaterBtns[i], objects[i] = btn, btn
+		}
+		state.heaterBox.Objects = objects
+		state.heaterBox.Refresh()
+	}
+
+	for i, btn := range state.heaterBtns {
+		if i < len(state.cfg.Heaters) {
+			btn.SetText(fmt.Sprintf("H%d (~%.0fΩ)", i+1, state.cfg.Heaters[i].Resistance))
+		} else {
+			btn.SetText(fmt.Sprintf("H%d", i+1))
+		}
+	}
+	updateHeaterButtonStates(state)
+}
+
+// setHeaterButtonsEnabled enables or disables the heater buttons.
+func setHeaterButtonsEnabled(state *appState, enabled bool) {
+	for _, btn := range state.heaterBtns {
+		if enabled {
+			btn.Enable()
+		} else {
+			btn.Disable()
+		}
+	}
 }
 
 // updateHeaterButton updates a single heater button's visual state.
@@ -90,39 +147,35 @@ func updateHeaterButton(btn *widget.Button, isOn bool) {
 }
 
 // handleHeaterIncrement increments heaters as a binary counter.
-// Binary progression: 000 -> 100 -> 010 -> 110 -> 001 -> 101 -> 011 -> 111 -> 000
-// This is equivalent to treating heaters as bits: [H1=bit0, H2=bit1, H3=bit2]
+// Binary progression for three heaters: 000 -> 100 -> 010 -> 110 -> 001 -> 101 -> 011 -> 111 -> 000
+// This is equivalent to treating heaters as bits: [H1=bit0, H2=bit1, H3=bit2, ...]
 func handleHeaterIncrement(state *appState) {
 	if state.device == nil || !state.device.IsConnected() {
 		return
 	}
 
-	// Convert current state to binary number (H1=bit0, H2=bit1, H3=bit2)
+	// Convert current state to binary number (H1=bit0, H2=bit1, ...)
+	heaters := currentHeaterStates(state)
 	currentValue := 0
-	if state.heaterState[0] {
-		currentValue |= 1 // bit 0
-	}
-	if state.heaterState[1] {
-		currentValue |= 2 // bit 1
-	}
-	if state.heaterState[2] {
-		currentValue |= 4 // bit 2
+	for i, on := range heaters {
+		if on {
+			currentValue |= 1 << i
+		}
 	}
 
-	// Increment and wrap around (0-7)
-	nextValue := (currentValue + 1) % 8
+	// Increment and wrap around once all heaters were on
+	nextValue := (currentValue + 1) % (1 << len(heaters))
 
-	// Convert back to boolean array
-	newState := [3]bool{
-		(nextValue & 1) != 0, // bit 0 -> H1
-		(nextValue & 2) != 0, // bit 1 -> H2
-		(nextValue & 4) != 0, // bit 2 -> H3
+	// Convert back to heater states
+	newState := make([]bool, len(heaters))
+	for i := range newState {
+		newState[i] = nextValue&(1<<i) != 0
 	}
 
 	// Send command to device
-	err := state.device.SetHeaters(newState[0], newState[1], newState[2])
+	err := state.device.SetHeaters(newState...)
 	if err != nil {
-		dialog.ShowError(fmt.Errorf("failed to increment heaters: %w", err), state.window)
+		dialog.ShowError(i18n.Errorf("failed to increment heaters: %w", err), state.window)
 		return
 	}
 
@@ -131,20 +184,72 @@ func handleHeaterIncrement(state *appState) {
 	updateHeaterButtonStates(state)
 }
 
-// handleHeaterOff turns off all heaters immediately.
+// handleHeaterOff turns off all heaters immediately, stopping a running
+// heater schedule first so it does not turn them back on.
 func handleHeaterOff(state *appState) {
+	if err := heatersOff(state); err != nil {
+		dialog.ShowError(err, state.window)
+	}
+}
+
+// heatersOff stops the heater schedule and turns off all heaters.
+func heatersOff(state *appState) error {
 	if state.device == nil || !state.device.IsConnected() {
-		return
+		return nil
 	}
+	stopHeaterSchedule(state)
 
 	// Turn off all heaters
-	err := state.device.SetHeaters(false, false, false)
+	off := make([]bool, state.device.Info().HeaterCount())
+	err := state.device.SetHeaters(off...)
 	if err != nil {
-		dialog.ShowError(fmt.Errorf("failed to turn off heaters: %w", err), state.window)
-		return
+		return i18n.Errorf("failed to turn off heaters: %w", err)
 	}
 
 	// Update state (optimistic update)
-	state.heaterState = [3]bool{false, false, false}
+	state.heaterState = off
 	updateHeaterButtonStates(state)
+	return nil
+}
+
+// heaterCutoff tells when the heaters have been on for too long. The zero
+// value is ready to use.
+type heaterCutoff struct {
+	since time.Time // When a heater was first seen on, zero while all are off
+}
+
+// Check reports whether any of heaters has been on for maxOn by now. A zero
+// maxOn never cuts off. Call it periodically; the time counts from the
+// first check that saw a heater on and restarts once all are off.
+func (c *heaterCutoff) Check(heaters []bool, now time.Time, maxOn time.Duration) bool {
+	if maxOn <= 0 || !slices.Contains(heaters, true) {
+		c.since = time.Time{}
+		return false
+	}
+	if c.since.IsZero() {
+		c.since = now
+	}
+	return now.Sub(c.since) >= maxOn
+}
+
+// checkHeaterCutoff turns all heaters off and warns the user once any has
+// been on for the configured maximum, so a forgotten heater cannot cook the
+// absorber. A replayed recording's heaters are left alone. Call it on the
+// main thread.
+func checkHeaterCutoff(state *appState, now time.Time) {
+	maxOn := state.cfg.Alarms.HeaterMaxOn
+	if state.device == nil || !state.device.IsConnected() || state.replay != nil {
+		maxOn = 0
+	}
+	if !state.heaterCutoff.Check(state.heaterState, now, maxOn) {
+		return
+	}
+	state.heaterCutoff = heaterCutoff{}
+
+	msg := i18n.Tf("Heaters turned off after %s on", maxOn)
+	if err := heatersOff(state); err != nil {
+		log.Printf("Heater cutoff: %v", err)
+		msg = i18n.Tf("Heaters on for %s and could not be turned off: %v", maxOn, err)
+	}
+	raiseAlarm(state, meter.Alarm{Kind: meter.AlarmHeaterCutoff, Message: msg})
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateHeaterLabels(t *testing.T) {
+	test.NewTempApp(t)
+	state := &appState{
+		cfg:                config.Default(),
+		heaterBox:          container.NewHBox(),
+		heaterIncrementBtn: widget.NewButton("", nil),
+		addCalPointBtn:     widget.NewButton("", nil),
+	}
+	state.heaterIncrementBtn.Disable()
+
+	updateHeaterLabels(state)
+	require.Len(t, state.heaterBtns, 3, "one per configured heater")
+	assert.Len(t, state.heaterBox.Objects, 3)
+	assert.Equal(t, "H2 (~511Ω)", state.heaterBtns[1].Text)
+	assert.True(t, state.heaterBtns[0].Disabled(), "disabled like the other heater controls")
+
+	// A connected device shows its own heaters
+	state.heaterIncrementBtn.Enable()
+	state.device = lpm.NewMock(&config.MockConfig{HeaterPower: []float64{10, 20, 30, 40}, SampleRate: time.Second})
+	require.NoError(t, state.device.Connect())
+	defer state.device.Close()
+	updateHeaterLabels(state)
+	require.Len(t, state.heaterBtns, 4)
+	assert.Equal(t, "H4", state.heaterBtns[3].Text, "no resistance configured")
+	assert.False(t, state.heaterBtns[3].Disabled())
+
+	// Toggles switch every heater of the device
+	state.heaterState = []bool{true} // Never modified, e.g. a sample's
+	handleHeaterToggle(state, 3)
+	assert.Equal(t, []bool{true, false, false, true}, state.heaterState)
+	handleHeaterIncrement(state)
+	assert.Equal(t, []bool{false, true, false, true}, state.heaterState)
+	assert.True(t, state.addCalPointBtn.Visible())
+}
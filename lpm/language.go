@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+
+	"fyne.io/fyne/v2/lang"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/units"
+)
+
+// languageChoices are the GUI languages offered in the settings, after the
+// system's language.
+func languageChoices() []choice {
+	choices := []choice{{"System", "system"}}
+	for _, l := range i18n.Languages {
+		choices = append(choices, choice{l.Name, l.Code})
+	}
+	return choices
+}
+
+// applyLanguage selects the GUI language of code, or the OS locale's for
+// "system", falling back to English if it has no translations. Text is
+// translated as widgets are created, so it must be called before the
+// window is built.
+func applyLanguage(code string) {
+	if code == "system" || code == "" {
+		_ = i18n.SetLanguage(lang.SystemLocale().LanguageString())
+		return
+	}
+	if err := i18n.SetLanguage(code); err != nil {
+		log.Printf("Failed to set language: %v", err)
+	}
+}
+
+// unitChoices returns the display units us as choices for the settings.
+func unitChoices[U ~string](us []U) []choice {
+	choices := make([]choice, len(us))
+	for i, u := range us {
+		choices[i] = choice{string(u), string(u)}
+	}
+	return choices
+}
+
+// applyUnits selects the units power and readings are displayed in.
+func applyUnits(power, reading string) {
+	if err := units.Set(power, reading); err != nil {
+		log.Printf("Failed to set display units: %v", err)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+const (
+	// logConsoleLines is the number of newest log messages kept for the
+	// console.
+	logConsoleLines = 2000
+
+	// logConsoleRefreshInterval is how often an open console shows new
+	// messages.
+	logConsoleRefreshInterval = 500 * time.Millisecond
+
+	// logTimeFormat is the timestamp of a log line, as log.LstdFlags.
+	logTimeFormat = "2006/01/02 15:04:05"
+)
+
+// logSeverity is how serious a log message is.
+type logSeverity int
+
+const (
+	logInfo logSeverity = iota
+	logWarning
+	logError
+)
+
+// String returns the tag shown before messages of the severity.
+func (s logSeverity) String() string {
+	switch s {
+	case logWarning:
+		return "WARN"
+	case logError:
+		return "ERROR"
+	default:
+		return "INFO"
+	}
+}
+
+// logSeverityFilters are the choices of the console's filter, from all
+// messages to errors only.
+var logSeverityFilters = []struct {
+	name string
+	min  logSeverity
+}{
+	{"All", logInfo},
+	{"Warnings and errors", logWarning},
+	{"Errors", logError},
+}
+
+// logErrorWords and logWarningWords mark a message as an error or a
+// warning. The packages log with plain log.Printf, so the severity is told
+// from the wording.
+var (
+	logErrorWords   = []string{"error", "failed", "panic", "corrupt"}
+	logWarningWords = []string{"full", "dropping", "lost ", "stale", "stopped sending", "retrying", "invalid",
+		"no handshake", "restarted", "unavailable", "ignoring"}
+)
+
+// classifyLog returns the severity of a log message.
+func classifyLog(msg string) logSeverity {
+	lower := strings.ToLower(msg)
+	for _, w := range logErrorWords {
+		if strings.Contains(lower, w) {
+			return logError
+		}
+	}
+	for _, w := range logWarningWords {
+		if strings.Contains(lower, w) {
+			return logWarning
+		}
+	}
+	return logInfo
+}
+
+// logEntry is a message written to the log.
+type logEntry struct {
+	Time     time.Time
+	Severity logSeverity
+	Message  string
+}
+
+// String formats e as a line of the console.
+func (e logEntry) String() string {
+	return fmt.Sprintf("%s %-5s %s", e.Time.Format("15:04:05"), e.Severity, e.Message)
+}
+
+// logBuffer is the log's writer in the GUI: it keeps the newest messages
+// for the log console and passes them on to out with a timestamp, as the
+// standard logger would. Install it with log.SetFlags(0), so messages
+// arrive without the logger's prefix.
+type logBuffer struct {
+	out io.Writer
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries []logEntry // Newest last, at most size
+	size    int
+	written uint64 // Messages written so far, to tell when the console is stale
+}
+
+// newLogBuffer creates a buffer of the size newest messages writing to out.
+func newLogBuffer(out io.Writer, size int) *logBuffer {
+	return &logBuffer{out: out, now: time.Now, size: size}
+}
+
+// Write keeps each line of p as a message.
+func (b *logBuffer) Write(p []byte) (int, error) {
+	now := b.now()
+	var out strings.Builder
+	b.mu.Lock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		b.entries = append(b.entries, logEntry{Time: now, Severity: classifyLog(line), Message: line})
+		b.written++
+		fmt.Fprintf(&out, "%s %s\n", now.Format(logTimeFormat), line)
+	}
+	if len(b.entries) > b.size {
+		b.entries = append(b.entries[:0], b.entries[len(b.entries)-b.size:]...)
+	}
+	b.mu.Unlock()
+
+	if b.out != nil {
+		if _, err := io.WriteString(b.out, out.String()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Entries returns the kept messages of at least severity min, oldest first,
+// and the number of messages written so far.
+func (b *logBuffer) Entries(min logSeverity) ([]logEntry, uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var entries []logEntry
+	for _, e := range b.entries {
+		if e.Severity >= min {
+			entries = append(entries, e)
+		}
+	}
+	return entries, b.written
+}
+
+// Written returns the number of messages written so far.
+func (b *logBuffer) Written() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.written
+}
+
+// Clear removes the kept messages.
+func (b *logBuffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = nil
+}
+
+// showLogConsole opens the window of the application's log messages, e.g.
+// parse errors and full channel warnings, filtered by severity, to be
+// copied into a bug report. A console already open is brought to the front.
+func showLogConsole(state *appState) {
+	if state.logConsole != nil {
+		state.logConsole.RequestFocus()
+		return
+	}
+
+	var (
+		shown   []logEntry
+		min     logSeverity
+		written uint64
+	)
+	list := widget.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			label.SetText(shown[id].String())
+			switch shown[id].Severity {
+			case logError:
+				label.Importance = widget.DangerImportance
+			case logWarning:
+				label.Importance = widget.WarningImportance
+			default:
+				label.Importance = widget.MediumImportance
+			}
+			label.Refresh()
+		},
+	)
+	count := widget.NewLabel("")
+	reload := func() {
+		shown, written = state.logs.Entries(min)
+		count.SetText(i18n.Tf("%d messages", len(shown)))
+		list.Refresh()
+		list.ScrollToBottom()
+	}
+
+	filters := make([]string, len(logSeverityFilters))
+	for i, f := range logSeverityFilters {
+		filters[i] = i18n.T(f.name)
+	}
+	filterSelect := widget.NewSelect(filters, func(name string) {
+		for _, f := range logSeverityFilters {
+			if i18n.T(f.name) == name {
+				min = f.min
+			}
+		}
+		reload()
+	})
+	filterSelect.SetSelectedIndex(0)
+
+	copyBtn := widget.NewButtonWithIcon(i18n.T("Copy"), theme.ContentCopyIcon(), func() {
+		lines := make([]string, len(shown))
+		for i, e := range shown {
+			lines[i] = e.String()
+		}
+		fyne.CurrentApp().Clipboard().SetContent(strings.Join(lines, "\n"))
+	})
+	clearBtn := widget.NewButtonWithIcon(i18n.T("Clear"), theme.DeleteIcon(), func() {
+		state.logs.Clear()
+		reload()
+	})
+
+	w := fyne.CurrentApp().NewWindow(i18n.T("Log Console"))
+	w.SetContent(container.NewBorder(
+		container.NewBorder(nil, nil, filterSelect, container.NewHBox(copyBtn, clearBtn), count),
+		nil, nil, nil, list))
+	w.Resize(fyne.NewSize(800, 400))
+	state.logConsole = w
+
+	// Show new messages until the window is closed
+	done := make(chan struct{})
+	w.SetOnClosed(func() {
+		close(done)
+		state.logConsole = nil
+	})
+	go func() {
+		ticker := time.NewTicker(logConsoleRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fyne.Do(func() {
+					if state.logs.Written() != written {
+						reload()
+					}
+				})
+			}
+		}
+	}()
+	w.Show()
+}
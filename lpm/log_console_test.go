@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyLog(t *testing.T) {
+	assert.Equal(t, logError, classifyLog("Error reading from serial port: EOF"))
+	assert.Equal(t, logError, classifyLog("Failed to set heaters: timeout"))
+	assert.Equal(t, logWarning, classifyLog("Converter output channel full, dropping sample"))
+	assert.Equal(t, logWarning, classifyLog("Lost 3 samples before sequence 120"))
+	assert.Equal(t, logInfo, classifyLog("Connected to serial port: COM3"))
+}
+
+func TestLogBuffer(t *testing.T) {
+	var out bytes.Buffer
+	b := newLogBuffer(&out, 3)
+	b.now = func() time.Time { return time.Date(2024, 5, 1, 14, 3, 7, 0, time.Local) }
+
+	_, err := b.Write([]byte("Connected to mocked device\n"))
+	require.NoError(t, err)
+	_, err = b.Write([]byte("Samples channel full, dropping sample\nFailed to set heaters: timeout\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "2024/05/01 14:03:07 Connected to mocked device\n"+
+		"2024/05/01 14:03:07 Samples channel full, dropping sample\n"+
+		"2024/05/01 14:03:07 Failed to set heaters: timeout\n", out.String(), "passed on as the standard logger prints")
+
+	entries, written := b.Entries(logWarning)
+	assert.Equal(t, uint64(3), written)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "14:03:07 WARN  Samples channel full, dropping sample", entries[0].String())
+	assert.Equal(t, logError, entries[1].Severity)
+
+	// Only the newest messages are kept
+	b.Write([]byte("Heaters set to 100\n"))
+	entries, _ = b.Entries(logInfo)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "Samples channel full, dropping sample", entries[0].Message)
+	assert.Equal(t, uint64(4), b.Written())
+
+	b.Clear()
+	entries, _ = b.Entries(logInfo)
+	assert.Empty(t, entries)
+}
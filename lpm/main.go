@@ -1,10 +1,11 @@
 package main
 
 import (
+	"errors"
 	"flag"
-	"fmt"
 	"log"
-	"sync"
+	"os"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -14,6 +15,7 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
 	"github.com/itohio/golpm/pkg/lpm"
 	"github.com/itohio/golpm/pkg/meter"
 	"github.com/itohio/golpm/pkg/sample"
@@ -23,28 +25,90 @@ import (
 func main() {
 	var (
 		portFlag       = flag.String("p", "", "Serial port override (e.g., COM3 or /dev/ttyACM0)")
-		configFlag     = flag.String("config", "config.yaml", "Configuration file path")
+		configFlag     = flag.String("config", "", "Configuration file path (default: golpm/config.yaml in the user configuration directory)")
+		profileFlag    = flag.String("profile", "", "Device profile to use (default: the profile last in use)")
 		mockFlag       = flag.Bool("mock", false, "Use mocked device instead of serial port")
 		statisticsFlag = flag.Bool("statistics", false, "Calculate and log statistics (variability, stddev, optimal EMA parameters)")
+		jitterFlag     = flag.Duration("jitter", 0, "Delay samples by this much ±50% and drop/duplicate 1% of them (for demos)")
+		scenarioFlag   = flag.String("scenario", "", "Mock scenario file with scripted laser pulses (implies -mock)")
+		replayFlag     = flag.String("replay", "", "Raw recording to play back through the mock device (implies -mock)")
+		truthFlag      = flag.Bool("truth", false, "Overlay the mock device's true laser power on the graph")
+		headlessFlag   = flag.Bool("headless", false, "Run without the GUI, printing pulses and readings to stdout")
+		tuiFlag        = flag.Bool("tui", false, "Run without the GUI, showing a live dashboard in the terminal")
+		formatFlag     = flag.String("format", "text", "Headless output format: text or json")
+		intervalFlag   = flag.Duration("interval", time.Second, "Headless time between readings (0 prints pulses only)")
 	)
 	flag.Parse()
 
-	// Load configuration
-	cfg, err := config.Load(*configFlag)
+	// Load configuration. The GUI starts with settings out of range to show
+	// them for fixing
+	configPath, err := config.ResolvePath(*configFlag)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	cfg, err := config.LoadProfile(configPath, *profileFlag)
+	var invalid config.ValidationErrors
+	if err != nil && (!errors.As(err, &invalid) || *headlessFlag || *tuiFlag) {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	// Override serial port if provided via command line
 	if *portFlag != "" {
 		cfg.Serial.Port = *portFlag
 	}
+	if *scenarioFlag != "" {
+		cfg.Mock.Scenario = *scenarioFlag
+		*mockFlag = true
+	}
+	if *replayFlag != "" {
+		cfg.Mock.ReplayFile = *replayFlag
+		*mockFlag = true
+	}
+
+	// In a terminal, e.g. over SSH, run the measurement chain with a dashboard
+	if *tuiFlag {
+		err := runTUI(cfg, headlessOptions{
+			useMock:       *mockFlag,
+			useStatistics: *statisticsFlag,
+			jitter:        *jitterFlag,
+		})
+		if err != nil {
+			log.Fatalf("Terminal dashboard failed: %v", err)
+		}
+		return
+	}
+
+	// Without a display, run the measurement chain and print its results
+	if *headlessFlag {
+		if *formatFlag != "text" && *formatFlag != "json" {
+			log.Fatalf("Unknown output format %q, want text or json", *formatFlag)
+		}
+		err := runHeadless(cfg, headlessOptions{
+			useMock:       *mockFlag,
+			useStatistics: *statisticsFlag,
+			jitter:        *jitterFlag,
+			json:          *formatFlag == "json",
+			interval:      *intervalFlag,
+		})
+		if err != nil {
+			log.Fatalf("Headless run failed: %v", err)
+		}
+		return
+	}
+
+	// Keep the log for the log console, still printing it to the terminal
+	logs := newLogBuffer(os.Stderr, logConsoleLines)
+	log.SetFlags(0)
+	log.SetOutput(logs)
 
 	// Create Fyne application
+	applyLanguage(cfg.UI.Language)
+	applyUnits(cfg.UI.PowerUnit, cfg.UI.ReadingUnit)
 	application := app.NewWithID("com.itohio.golpm")
+	applyTheme(application, cfg.UI.Theme)
 
 	// Create main window
-	window := application.NewWindow("Laser Power Meter")
+	window := application.NewWindow(i18n.T("Laser Power Meter"))
 	window.Resize(fyne.NewSize(1200, 800))
 	window.CenterOnScreen()
 
@@ -54,30 +118,75 @@ func main() {
 	// Create application state
 	appState := &appState{
 		cfg:           cfg,
+		configPath:    configPath,
+		logs:          logs,
 		device:        nil,
 		powerMeter:    powerMeter,
 		window:        window,
 		useMock:       *mockFlag,
 		useStatistics: *statisticsFlag,
+		jitter:        *jitterFlag,
+		showTruth:     *truthFlag,
 	}
 
-	// Create toolbar
+	// Create toolbar, with the replay controls below it while a recording plays
 	toolbar := createToolbar(appState)
+	appState.replayBar = newReplayBar(appState)
 
 	// Create scope widget for graph display
 	scopeWidget := scope.New(cfg)
 	appState.scopeWidget = scopeWidget
 
-	// Create border layout with toolbar at top and scope widget as content
+	// Spectrum panel below the scope, hidden until toggled
+	spectrumWidget := scope.NewSpectrum()
+	spectrumWidget.Hide()
+	scopeWidget.OnViewChanged = spectrumWidget.Update
+	appState.spectrumWidget = spectrumWidget
+	// Redraw rate, update latency and sample rate over the graph, if enabled
+	graph := container.NewStack(scopeWidget, newPerformanceOverlay(appState))
+	appState.graphSplit = container.NewVSplit(graph, spectrumWidget)
+	appState.graphSplit.Offset = 0.7
+
+	// Large live numbers beside the graph
+	appState.liveReadout = scope.NewLiveReadout()
+
+	// Summary of the session below the live numbers, hidden until toggled
+	appState.sessionStats = scope.NewSessionStatsPanel()
+	appState.sessionStats.Hide()
+
+	// Table of every pulse beside the graph, hidden until toggled
+	appState.pulseLog = scope.NewPulseLog()
+	appState.pulseLog.Hide()
+	appState.pulseLog.OnExport = func(csv string) {
+		handlePulseLogExport(appState, csv)
+	}
+	pulseSplit := container.NewHSplit(appState.graphSplit, appState.pulseLog)
+	pulseSplit.Offset = 0.7
+
+	// Connection and chain health below the graph
+	appState.status = newStatusBar()
+	updateStatusBar(appState, time.Now())
+	go runStatusBar(appState)
+	go runAlarms(appState)
+	go runPerformanceOverlay(appState)
+	watchConfig(appState, configPath)
+
+	// Create border layout with toolbar at top, scope widget as content,
+	// the live readout and session statistics on the right and the status
+	// bar at the bottom
 	container := container.NewBorder(
-		toolbar,
-		nil,
+		container.NewVBox(toolbar, appState.replayBar.content),
+		appState.status.content,
 		nil,
-		nil,
-		scopeWidget,
+		container.NewVBox(appState.liveReadout, appState.sessionStats),
+		pulseSplit,
 	)
 
 	window.SetContent(container)
+	window.SetMainMenu(createMainMenu(appState))
+	if invalid != nil {
+		showConfigProblems(appState, invalid)
+	}
 	window.ShowAndRun()
 }
 
@@ -86,7 +195,8 @@ type measurementChain struct {
 	device               lpm.Device
 	rawSamples           <-chan lpm.RawSample
 	rawSamplesForTee     <-chan lpm.RawSample
-	heaterStateGoroutine chan struct{} // Closed when heater state goroutine exits
+	heaterStateGoroutine chan struct{}    // Closed when heater state goroutine exits
+	pipeline             *sample.Pipeline // Converts raw samples; reconfigured in place
 	samplesStream        <-chan sample.Sample
 	meterGoroutine       chan struct{} // Closed when meter goroutine exits
 }
@@ -94,25 +204,85 @@ type measurementChain struct {
 // appState holds the application state.
 type appState struct {
 	cfg                *config.Config
+	configPath         string // Configuration file the settings are saved to
 	device             lpm.Device
+	recorder           *lpm.Recorder                 // Wraps the connected device; records raw lines when enabled
+	mock               *lpm.Mock                     // Connected mocked device (nil unless in mock mode)
+	serial             *lpm.Serial                   // Connected serial device (nil in mock mode)
+	replay             *lpm.Replay                   // Recording played back in place of the device (nil if none)
+	recordFile         *os.File                      // Current raw recording file (nil if not recording)
+	dataLog            atomic.Pointer[meter.DataLog] // Running data log (nil if not logging)
 	powerMeter         *meter.Meter
 	scopeWidget        *scope.ScopeWidget
+	spectrumWidget     *scope.SpectrumWidget
+	liveReadout        *scope.LiveReadout
+	pulseLog           *scope.PulseLog
+	sessionStats       *scope.SessionStatsPanel
+	sessionTracker     meter.SessionTracker // Statistics of the live session
+	status             *statusBar
+	replayBar          *replayBar
+	alarms             meter.AlarmMonitor
+	heaterSchedule     *heaterScheduleRun
+	scheduleDialog     *heaterScheduleDialog
+	graphSplit         *container.Split
 	window             fyne.Window
+	logs               *logBuffer  // Log messages for the log console
+	logConsole         fyne.Window // Open log console window (nil if closed)
 	connectBtn         *widget.Button
-	heater1Btn         *widget.Button
-	heater2Btn         *widget.Button
-	heater3Btn         *widget.Button
+	recordBtn          *widget.Button
+	dataLogBtn         *widget.Button
+	pauseBtn           *widget.Button
+	freezeBtn          *widget.Button
+	armBtn             *widget.Button
+	triggerSelect      *widget.Select
+	viewSelect         *widget.Select
+	profileSelect      *widget.Select
+	deviceInfoBtn      *widget.Button
+	diagnosticsBtn     *widget.Button
+	heaterBtns         []*widget.Button // One per heater, see updateHeaterLabels
+	heaterBox          *fyne.Container  // Holds the heater buttons
 	addCalPointBtn     *widget.Button
 	heaterIncrementBtn *widget.Button
 	heaterOffBtn       *widget.Button
+	heaterScheduleBtn  *widget.Button
+	laserBtn           *widget.Button
 	useMock            bool
 	useStatistics      bool
-	heaterState        [3]bool           // Current heater states [heater1, heater2, heater3]
-	chain              *measurementChain // Current measurement chain (nil if not connected)
+	jitter             time.Duration      // Stream impairment for demos (0 = off)
+	showTruth          bool               // Overlay the mock's ground truth on the scope
+	heaterState        []bool             // Current heater states, heater 1 first; a received sample's are never modified
+	heaterCutoff       heaterCutoff       // Turns the heaters off when on for too long
+	paused             bool               // Sample output paused by the user
+	stalled            bool               // No samples from the device within the stale timeout
+	stallRetry         *time.Timer        // Pending automatic reconnect of the stalled device
+	stallDelay         time.Duration      // Delay before the next automatic reconnect
+	stallReconnectAt   time.Time          // Time of the last automatic reconnect
+	sessionOpen        bool               // A saved session is shown instead of live data
+	openedStats        meter.SessionStats // Statistics of the opened session
+	notes              string             // Laser, wavelength and setup of the session
+	annotations        []annotation       // Timestamped notes on the session
+	chain              *measurementChain  // Current measurement chain (nil if not connected)
+	connects           uint64             // Devices connected this run; each one counts its own link statistics
+	perf               performanceMonitor // Update latency and sample rate for the performance overlay
+	perfLabel          *widget.Label      // Performance overlay over the graph
+}
 
-	// Throttling for scope updates
-	lastUpdateTime time.Time
-	updateMu       sync.Mutex
+// createMainMenu creates the application menu.
+func createMainMenu(state *appState) *fyne.MainMenu {
+	return fyne.NewMainMenu(
+		fyne.NewMenu(i18n.T("File"),
+			fyne.NewMenuItem(i18n.T("Open Session..."), func() { handleOpenSession(state) }),
+			fyne.NewMenuItem(i18n.T("Save Session..."), func() { handleSaveSession(state) }),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem(i18n.T("Open Recording..."), func() { handleOpenRecording(state) }),
+		),
+		fyne.NewMenu(i18n.T("View"),
+			fyne.NewMenuItem(i18n.T("Log Console"), func() { showLogConsole(state) }),
+		),
+		fyne.NewMenu(i18n.T("Help"),
+			fyne.NewMenuItem(i18n.T("About"), func() { showAboutDialog(state) }),
+		),
+	)
 }
 
 // createToolbar creates the application toolbar with Connect, Settings, Measure, and Heater buttons.
@@ -124,34 +294,137 @@ func createToolbar(state *appState) fyne.CanvasObject {
 	connectBtn.Importance = widget.HighImportance
 	state.connectBtn = connectBtn
 
+	// Profiles switch between the port, divider, heaters and calibration of
+	// several absorber heads
+	profileSelect := widget.NewSelect(profileNames(state.cfg.Profiles), func(name string) {
+		handleProfileSelected(state, name)
+	})
+	profileSelect.PlaceHolder = i18n.T("Profile")
+	profileSelect.Selected = state.cfg.Profile
+	state.profileSelect = profileSelect
+	saveProfileBtn := widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
+		handleSaveProfile(state)
+	})
+
 	// Settings button with icon
 	settingsBtn := widget.NewButtonWithIcon("", theme.SettingsIcon(), func() {
 		showSettingsDialog(state)
 	})
 
-	// Create heater buttons with better icons
-	// Using radio button checked/unchecked icons to represent heater state
-	heater1Btn := widget.NewButtonWithIcon("", theme.RadioButtonIcon(), func() {
-		handleHeaterToggle(state, 0)
+	// Record button toggles raw stream recording to disk
+	recordBtn := widget.NewButtonWithIcon("", theme.MediaRecordIcon(), func() {
+		handleRecordToggle(state)
+	})
+	recordBtn.Disable()
+	state.recordBtn = recordBtn
+
+	// Log button toggles logging converted samples and pulses to rotating files
+	dataLogBtn := widget.NewButtonWithIcon(i18n.T("Log"), theme.DocumentCreateIcon(), func() {
+		handleDataLogToggle(state)
 	})
-	heater1Btn.Disable()
-	state.heater1Btn = heater1Btn
+	state.dataLogBtn = dataLogBtn
 
-	heater2Btn := widget.NewButtonWithIcon("", theme.RadioButtonIcon(), func() {
-		handleHeaterToggle(state, 1)
+	// Pause button freezes the graph by pausing the device's sample output
+	pauseBtn := widget.NewButtonWithIcon("", theme.MediaPauseIcon(), func() {
+		handlePauseToggle(state)
 	})
-	heater2Btn.Disable()
-	state.heater2Btn = heater2Btn
+	pauseBtn.Disable()
+	state.pauseBtn = pauseBtn
 
-	heater3Btn := widget.NewButtonWithIcon("", theme.RadioButtonIcon(), func() {
-		handleHeaterToggle(state, 2)
+	// Freeze button holds the graph while acquisition and recording continue
+	freezeBtn := widget.NewButtonWithIcon("", theme.VisibilityIcon(), func() {
+		handleFreezeToggle(state)
 	})
-	heater3Btn.Disable()
-	state.heater3Btn = heater3Btn
+	state.freezeBtn = freezeBtn
+
+	// Trigger mode selects rolling or pulse-triggered captures; Arm re-arms
+	// a single capture
+	triggerModes := make([]string, len(scope.TriggerModes))
+	for i, m := range scope.TriggerModes {
+		triggerModes[i] = i18n.T(m.String())
+	}
+	triggerSelect := widget.NewSelect(triggerModes, func(name string) {
+		handleTriggerMode(state, name)
+	})
+	triggerSelect.Selected = i18n.T(scope.TriggerRoll.String())
+	state.triggerSelect = triggerSelect
+	armBtn := widget.NewButtonWithIcon(i18n.T("Arm"), theme.MediaReplayIcon(), func() {
+		state.scopeWidget.Arm()
+	})
+	armBtn.Disable()
+	state.armBtn = armBtn
+
+	// Views switch between saved zoom, trace, axis and trigger setups
+	viewSelect := widget.NewSelect(viewNames(state.cfg.Scope.Views), func(name string) {
+		handleViewSelected(state, name)
+	})
+	viewSelect.PlaceHolder = i18n.T("View")
+	state.viewSelect = viewSelect
+	saveViewBtn := widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
+		handleSaveView(state)
+	})
+
+	// Marker button names the current moment, e.g. an experiment step
+	markerBtn := widget.NewButtonWithIcon(i18n.T("Mark"), theme.ContentAddIcon(), func() {
+		handleAddMarker(state)
+	})
+
+	// Notes button records the laser, wavelength and setup of the session
+	notesBtn := widget.NewButtonWithIcon(i18n.T("Notes"), theme.DocumentCreateIcon(), func() {
+		showNotesDialog(state)
+	})
+
+	// Spectrum button shows the FFT of the readings in view below the graph
+	spectrumBtn := widget.NewButton("FFT", func() {
+		handleSpectrumToggle(state)
+	})
+
+	// Pulses button shows or hides the table of every measured pulse
+	pulseLogBtn := widget.NewButton(i18n.T("Pulses"), func() {
+		handlePulseLogToggle(state)
+	})
+
+	// Stats button shows or hides the summary of the session
+	sessionStatsBtn := widget.NewButton(i18n.T("Stats"), func() {
+		handleSessionStatsToggle(state)
+	})
+
+	// Export button saves the graph with a header of session metadata
+	exportBtn := widget.NewButtonWithIcon("", theme.FileImageIcon(), func() {
+		handleAnnotatedExport(state)
+	})
+
+	// CSV button saves the samples, derivatives and pulses as CSV files
+	csvExportBtn := widget.NewButtonWithIcon("CSV", theme.DocumentSaveIcon(), func() {
+		handleCSVExport(state)
+	})
+
+	// Readout button shows or hides the large live numbers beside the graph
+	readoutBtn := widget.NewButton(i18n.T("Readout"), func() {
+		handleReadoutToggle(state)
+	})
+
+	// Device info button shows firmware/board information of the connected device
+	deviceInfoBtn := widget.NewButtonWithIcon("", theme.InfoIcon(), func() {
+		showDeviceInfoDialog(state)
+	})
+	deviceInfoBtn.Disable()
+	state.deviceInfoBtn = deviceInfoBtn
+
+	// Diagnostics button shows live link statistics of the connected device
+	diagnosticsBtn := widget.NewButtonWithIcon("", theme.ListIcon(), func() {
+		showDiagnosticsDialog(state)
+	})
+	diagnosticsBtn.Disable()
+	state.diagnosticsBtn = diagnosticsBtn
+
+	// Heater buttons, one per heater, are created by updateHeaterLabels
+	// once the other heater controls exist
+	state.heaterBox = container.NewHBox()
 
 	// Add calibration point button
 	// Only visible when at least one heater is on
-	addCalPointBtn := widget.NewButtonWithIcon("Add Cal Point", theme.ContentAddIcon(), func() {
+	addCalPointBtn := widget.NewButtonWithIcon(i18n.T("Add Cal Point"), theme.ContentAddIcon(), func() {
 		handleAddCalibrationPoint(state)
 	})
 	addCalPointBtn.Disable()
@@ -166,32 +439,50 @@ func createToolbar(state *appState) fyne.CanvasObject {
 	heaterIncrementBtn.Disable()
 	state.heaterIncrementBtn = heaterIncrementBtn
 
-	// Heater off button (turn off all heaters)
-	// Only enabled when at least one heater is on
-	heaterOffBtn := widget.NewButtonWithIcon("", theme.MediaStopIcon(), func() {
+	// Heater schedule button opens the editor of timed heater sequences
+	heaterScheduleBtn := widget.NewButtonWithIcon("", theme.HistoryIcon(), func() {
+		showHeaterScheduleDialog(state)
+	})
+	heaterScheduleBtn.Disable()
+	state.heaterScheduleBtn = heaterScheduleBtn
+
+	// Emergency button turns all heaters off and stops a heater schedule
+	// Enabled whenever a device is connected, whatever the heaters are reported as
+	heaterOffBtn := widget.NewButtonWithIcon(i18n.T("All Off"), theme.MediaStopIcon(), func() {
 		handleHeaterOff(state)
 	})
-	heaterOffBtn.Disable() // Start disabled - enabled by updateHeaterButtonStates when heaters turn on
+	heaterOffBtn.Disable() // Start disabled - enabled on connect
 	heaterOffBtn.Importance = widget.DangerImportance
 	state.heaterOffBtn = heaterOffBtn
+	updateHeaterLabels(state)
+
+	// Laser button fires a simulated pulse; only shown in mock mode
+	laserBtn := widget.NewButtonWithIcon(i18n.T("Fire Laser"), theme.MediaPlayIcon(), func() {
+		handleLaserTrigger(state)
+	})
+	laserBtn.Disable()
+	if !state.useMock {
+		laserBtn.Hide()
+	}
+	state.laserBtn = laserBtn
 
 	// Create separators
 	separator1 := widget.NewSeparator()
 	separator2 := widget.NewSeparator()
 
 	// Create toolbar with buttons on left and heater controls aligned to the right
-	// Layout: [Connect] [Settings] ... [Add Cal] | [>>] [H1] [H2] [H3] | [Off]
+	// Layout: [Connect] [Profile] [Save Profile] [Settings] [Rec] [Pause] [Freeze] [Trigger] [Arm] [View] [Save View] [FFT] [Readout] [Pulses] [Stats] [Export] [Info] [Diag] ... [Laser] [Add Cal] | [Schedule] [>>] [H1] [H2] ... | [All Off]
 	return container.NewBorder(
 		nil, // top
 		nil, // bottom
-		container.NewHBox(connectBtn, settingsBtn), // left
+		container.NewHBox(connectBtn, profileSelect, saveProfileBtn, settingsBtn, recordBtn, dataLogBtn, pauseBtn, freezeBtn, triggerSelect, armBtn, viewSelect, saveViewBtn, markerBtn, notesBtn, spectrumBtn, readoutBtn, pulseLogBtn, sessionStatsBtn, exportBtn, csvExportBtn, deviceInfoBtn, diagnosticsBtn), // left
 		container.NewHBox( // right
+			laserBtn,
 			addCalPointBtn,
 			separator1,
+			heaterScheduleBtn,
 			heaterIncrementBtn,
-			heater1Btn,
-			heater2Btn,
-			heater3Btn,
+			state.heaterBox,
 			separator2,
 			heaterOffBtn,
 		),
@@ -224,230 +515,308 @@ func closeMeasurementChain(chain *measurementChain) {
 	}
 }
 
+// reconnect closes the measurement chain and the device and connects again,
+// e.g. with new port or line settings.
+func reconnect(state *appState) {
+	closeDevice(state)
+
+	// Reconnect with new settings
+	handleConnect(state)
+}
+
+// closeDevice closes the measurement chain and the device, to connect again.
+func closeDevice(state *appState) {
+	// Gracefully close old chain
+	closeMeasurementChain(state.chain)
+	state.chain = nil
+
+	// Close old device
+	if state.device != nil {
+		state.device.Close()
+		state.device = nil
+	}
+}
+
+// applySampleRate negotiates the sample rate with the connected device and
+// applies it to the settings that may be given in samples.
+func applySampleRate(state *appState) {
+	if state.replay != nil {
+		// A recording plays at its recorded rate
+		state.cfg.Serial.NegotiatedRate = state.device.Info().SampleRate
+	} else {
+		lpm.Negotiate(state.device, &state.cfg.Serial, state.useMock)
+	}
+	reconfigureMeasurement(state)
+}
+
+// reconfigureMeasurement applies the voltage divider, heater and measurement
+// settings to the running meter and pipeline in place, keeping the measured
+// samples and pulses.
+func reconfigureMeasurement(state *appState) {
+	state.powerMeter.Reconfigure(state.cfg)
+	if state.chain != nil && state.chain.pipeline != nil {
+		state.chain.pipeline.Reconfigure(state.cfg)
+	}
+}
+
+// handleLaserTrigger fires a simulated laser pulse on the mocked device
+// with the configured mock laser power and duration.
+func handleLaserTrigger(state *appState) {
+	if state.mock == nil {
+		return
+	}
+
+	power, duration := state.cfg.Mock.LaserPower, state.cfg.Mock.LaserDuration
+	if err := state.mock.TriggerLaser(power, duration); err != nil {
+		dialog.ShowError(i18n.Errorf("failed to fire laser: %w", err), state.window)
+		return
+	}
+	setStatus(state, "Fired simulated laser pulse: %.1f mW for %v", power, duration)
+}
+
 // handleConnect handles the connect/disconnect button click.
 func handleConnect(state *appState) {
 	if state.device != nil && state.device.IsConnected() {
-		// Disconnect - gracefully close measurement chain
+		// Disconnect - turn the heaters off and gracefully close measurement chain
+		stopHeaterSchedule(state)
 		closeMeasurementChain(state.chain)
 		state.chain = nil
 		state.device = nil
+		handleDeviceHealth(state, true)
+		stopRecording(state)
+		state.recorder = nil
+		state.mock = nil
+		state.serial = nil
+		closeReplay(state)
+		state.laserBtn.Disable()
+		state.cfg.Serial.NegotiatedRate = 0
+		state.cfg.Serial.NegotiatedGain = 0
+		state.cfg.Serial.NegotiatedReference = 0
+		state.recordBtn.Disable()
+		state.paused = false
+		updatePauseButton(state)
+		state.pauseBtn.Disable()
+		state.deviceInfoBtn.Disable()
+		state.diagnosticsBtn.Disable()
 		// Connect button icon doesn't change
-		state.heater1Btn.Disable()
-		state.heater2Btn.Disable()
-		state.heater3Btn.Disable()
+		setHeaterButtonsEnabled(state, false)
 		state.addCalPointBtn.Disable()
 		state.heaterIncrementBtn.Disable()
+		state.heaterScheduleBtn.Disable()
 		state.heaterOffBtn.Disable()
-		// Reset heater states
-		state.heaterState = [3]bool{false, false, false}
-		updateHeaterButtonStates(state)
+		// Reset heater states and show the configured heaters
+		state.heaterState = nil
+		updateHeaterLabels(state)
 		if state.useMock {
-			fmt.Println("Disconnected from mocked device")
+			setStatus(state, "Disconnected from mocked device")
 		} else {
-			fmt.Println("Disconnected from serial port")
+			setStatus(state, "Disconnected from serial port")
 		}
+	} else if state.useMock {
+		connectDevice(state)
 	} else {
-		// Connect
-		var device lpm.Device
-		if state.useMock {
-			device = lpm.NewMock(&state.cfg.Mock)
-			fmt.Println("Using mocked device")
-		} else {
-			device = lpm.New(state.cfg.Serial.Port, lpm.DefaultBaudRate, lpm.DefaultBufferSize)
-		}
+		// Let the user pick one of the discovered devices first
+		showDevicePicker(state, func() { connectDevice(state) })
+	}
+}
 
-		if err := device.Connect(); err != nil {
-			if state.useMock {
-				dialog.ShowError(fmt.Errorf("failed to connect to mocked device: %w", err), state.window)
-			} else {
-				dialog.ShowError(fmt.Errorf("failed to connect to %s: %w", state.cfg.Serial.Port, err), state.window)
-			}
+// connectDevice connects to the configured device and starts the measurement chain.
+func connectDevice(state *appState) {
+	var device lpm.Device
+	state.mock = nil
+	state.serial = nil
+	switch {
+	case state.replay != nil:
+		device = state.replay
+	case state.useMock:
+		state.mock = lpm.NewMock(&state.cfg.Mock)
+		device = state.mock
+		setStatus(state, "Using mocked device")
+	default:
+		serialDevice, err := lpm.NewFromConfig(&state.cfg.Serial, lpm.DefaultBufferSize)
+		if err != nil {
+			dialog.ShowError(i18n.Errorf("invalid serial settings: %w", err), state.window)
 			return
 		}
-		state.device = device
-		if state.useMock {
-			fmt.Printf("Connected to mocked device\n")
-		} else {
-			fmt.Printf("Connected to serial port: %s\n", state.cfg.Serial.Port)
-		}
-
-		// Enable heater buttons
-		state.heater1Btn.Enable()
-		state.heater2Btn.Enable()
-		state.heater3Btn.Enable()
-		state.addCalPointBtn.Enable()
-		state.heaterIncrementBtn.Enable()
-		// heaterOffBtn is controlled by updateHeaterButtonStates - only enabled when heaters are on
-
-		// Reset meter shutdown flag for new chain
-		state.powerMeter.ResetShutdown()
-
-		// Register callback with power meter to update scope widget
-		// This must be done before starting the measurement chain
-		// Throttle updates to ~60 FPS (16.67ms between updates) to ensure smooth UI
-		const updateInterval = 16 * time.Millisecond // ~60 FPS
-		state.powerMeter.OnUpdate(func(samples []sample.Sample, derivatives []float64, pulses []meter.Pulse) {
-			// Throttle updates to prevent UI from being overwhelmed
-			state.updateMu.Lock()
-			now := time.Now()
-			timeSinceLastUpdate := now.Sub(state.lastUpdateTime)
-			state.updateMu.Unlock()
-
-			// Skip update if too soon since last update
-			if timeSinceLastUpdate < updateInterval {
-				return
-			}
-
-			// Calculate current heater power from latest sample
-			var heaterPower float64
-			if len(samples) > 0 {
-				heaterPower = samples[len(samples)-1].HeaterPower
-			}
-
-			// Get active pulse (Fitting or Updating state) for real-time display
-			activePulse := state.powerMeter.ActivePulse()
-
-			// Update timestamp
-			state.updateMu.Lock()
-			state.lastUpdateTime = now
-			state.updateMu.Unlock()
+		state.serial = serialDevice
+		device = serialDevice
+	}
 
-			// Update scope widget on main thread
-			// Scope widget handles downsampling internally, so pass full data
-			fyne.Do(func() {
-				state.scopeWidget.UpdateData(samples, derivatives, pulses, activePulse, heaterPower)
-			})
+	if state.jitter > 0 {
+		device = lpm.NewJitter(device, lpm.JitterOptions{
+			Delay:         state.jitter,
+			Jitter:        state.jitter / 2,
+			DropChance:    0.01,
+			DuplicateRate: 0.01,
+			Seed:          time.Now().UnixNano(),
 		})
+		setStatus(state, "Impairing device stream with %v delay", state.jitter)
+	}
 
-		// Create converter pipeline with chaining support
-		rawSamples := device.Samples()
+	// Watch the stream so a hung MCU does not just freeze the graph
+	device = lpm.NewWatchdog(device, state.cfg.Serial.StaleTimeout, func(healthy bool) {
+		fyne.Do(func() { handleDeviceHealth(state, healthy) })
+	})
 
-		// Tee raw samples: one branch for heater state updates, one for converter chain
-		// We need to tee because we need to read from the channel twice:
-		// 1. For heater state synchronization
-		// 2. For the converter chain
-		rawSamplesForConverter := teeChannel(rawSamples)
+	// Wrap device in a recorder so raw recording can be toggled at any time
+	state.recorder = lpm.NewRecorder(device, nil)
+	device = state.recorder
+
+	if err := device.Connect(); err != nil {
+		state.recorder = nil
+		state.mock = nil
+		state.serial = nil
+		switch {
+		case state.replay != nil:
+			dialog.ShowError(i18n.Errorf("failed to play the recording: %w", err), state.window)
+		case state.useMock:
+			dialog.ShowError(i18n.Errorf("failed to connect to mocked device: %w", err), state.window)
+		default:
+			dialog.ShowError(i18n.Errorf("failed to connect to %s: %w", state.cfg.Serial.Port, err), state.window)
+		}
+		return
+	}
+	state.device = device
+	if state.replay == nil {
+		state.connects++
+	}
+	if state.recordFile != nil {
+		// A recording goes on across reconnects
+		state.recorder.SetWriter(state.recordFile)
+	}
+	closeSession(state)
+	var truth *truthBuffer
+	if state.mock != nil && state.showTruth {
+		truth = &truthBuffer{}
+		go truth.collect(state.mock.Truth())
+	}
+	switch {
+	case state.replay != nil:
+		// handleOpenRecording reports the recording
+	case state.useMock:
+		setStatus(state, "Connected to mocked device")
+	default:
+		setStatus(state, "Connected to serial port: %s", state.cfg.Serial.Port)
+		selectDeviceProfile(state, device.Info().Serial)
+	}
 
-		// Track goroutines for graceful shutdown
-		heaterStateDone := make(chan struct{})
-		meterDone := make(chan struct{})
+	applySampleRate(state)
+
+	// Enable heater buttons
+	state.recordBtn.Enable()
+	state.pauseBtn.Enable()
+	state.deviceInfoBtn.Enable()
+	state.diagnosticsBtn.Enable()
+	setHeaterButtonsEnabled(state, true)
+	state.addCalPointBtn.Enable()
+	state.heaterIncrementBtn.Enable()
+	state.heaterScheduleBtn.Enable()
+	if state.mock != nil {
+		state.laserBtn.Enable()
+	}
+	if state.replay != nil {
+		// The recording's heaters cannot be switched
+		setHeaterButtonsEnabled(state, false)
+		state.addCalPointBtn.Disable()
+		state.heaterIncrementBtn.Disable()
+		state.heaterScheduleBtn.Disable()
+		state.heaterOffBtn.Disable()
+	}
 
-		// Update heater states from raw samples (only when state changes)
-		go func() {
-			defer close(heaterStateDone)
-			for rawSample := range rawSamples {
-				updateHeaterStatesFromSample(state, rawSample)
-			}
-		}()
-
-		// Chain converters:
-		// 1. Base conversion from raw samples
-		// 2. Statistics collection (if enabled) - collects stats on raw converted samples
-		// 3. Median filter on main fields to remove spikes (hardware-induced spikes when heaters turn on)
-		// 4. EMA smoothing on all fields except Change (if smoothing enabled)
-		// 5. Downsampling to target sample rate (if enabled)
-		// 6. Differentiation to calculate Change field from Reading
-		// 7. Filter on Change field (EMA/MA/MM - configurable)
-		// Increase buffer size to prevent channel full errors
-		baseStream := sample.NewConverter(state.cfg, 500)(rawSamplesForConverter)
-
-		// Apply statistics collection (if enabled)
-		// This must be done BEFORE any filtering to capture raw signal characteristics
-		var statsStream <-chan sample.Sample
-		if state.useStatistics {
-			// Use the configured smoothing alpha for EMA comparison
-			log.Printf("Statistics Smoothing alpha: %f", state.cfg.Measurement.SmoothingAlpha)
-			statsStream = sample.NewStatisticsConverter(state.cfg.Measurement.SmoothingAlpha, 500)(baseStream)
-		} else {
-			statsStream = baseStream
+	// Show a button for each of the device's heaters, whose power needs
+	// their resistances
+	updateHeaterLabels(state)
+	if n := device.Info().HeaterCount(); n != len(state.cfg.Heaters) {
+		setStatus(state, "Device has %d heaters, but resistances are configured for %d", n, len(state.cfg.Heaters))
+	}
+	updateDataLogHeaters(state)
+
+	// Reset meter shutdown flag for new chain
+	state.powerMeter.ResetShutdown()
+
+	// Register callback with power meter to update scope widget
+	// This must be done before starting the measurement chain
+	// The scope paces its own redraws; updates are coalesced here so a busy
+	// main thread gets the newest data instead of a queue of stale updates
+	var latest atomic.Pointer[func()]
+	state.powerMeter.OnUpdate(func(samples []sample.Sample, derivatives []float64, pulses []meter.Pulse) {
+		// Calculate current heater power from latest sample
+		var heaterPower float64
+		if len(samples) > 0 {
+			heaterPower = samples[len(samples)-1].HeaterPower
 		}
 
-		// Apply median filter to remove spikes from main signal (Reading, Voltage)
-		// This filters out hardware-induced spikes when heaters turn on
-		// Note: HeaterPower is never filtered - it's only calculated
-		mainFields := sample.FieldReading | sample.FieldVoltage
-		var spikeFilteredStream <-chan sample.Sample
-		if state.cfg.Measurement.SpikeFilterWindowSize > 0 {
-			spikeFilteredStream = sample.NewMMFilter(state.cfg.Measurement.SpikeFilterWindowSize, mainFields, 500)(statsStream)
-		} else {
-			// Spike filtering disabled, use stats stream directly
-			spikeFilteredStream = statsStream
-		}
+		// Get active pulse (Fitting or Updating state) for real-time display
+		activePulse := state.powerMeter.ActivePulse()
 
-		// Apply EMA smoothing on all fields except Change and HeaterPower (if smoothing enabled)
-		// Note: HeaterPower is never filtered - it's only calculated
-		var smoothedStream <-chan sample.Sample
-		if state.cfg.Measurement.SmoothingAlpha > 0 {
-			// Apply EMA to Reading and Voltage (Change will be calculated later, HeaterPower is never filtered)
-			smoothedStream = sample.NewEMAFilter(state.cfg.Measurement.SmoothingAlpha, mainFields, 500)(spikeFilteredStream)
-		} else {
-			// No smoothing, use spike-filtered stream directly
-			smoothedStream = spikeFilteredStream
+		// Ground truth of the samples in view, for the debug overlay
+		var truthPoints []scope.TruthPoint
+		if truth != nil && len(samples) > 0 {
+			truthPoints = truth.since(samples[0].Timestamp)
 		}
 
-		// Apply downsampling to target sample rate (if enabled)
-		var downsampledStream <-chan sample.Sample
-		if state.cfg.Measurement.DownsampleRate != nil && *state.cfg.Measurement.DownsampleRate > 0 {
-			downsampledStream = sample.NewDownsamplingConverter(*state.cfg.Measurement.DownsampleRate, 500)(smoothedStream)
-		} else {
-			// No downsampling, use smoothed stream directly
-			downsampledStream = smoothedStream
+		// Update scope widget on main thread
+		// Scope widget handles downsampling internally, so pass full data
+		queued := time.Now()
+		update := func() {
+			state.perf.recordLatency(time.Since(queued))
+			state.scopeWidget.UpdateTruth(truthPoints)
+			state.scopeWidget.UpdateData(samples, derivatives, pulses, activePulse, heaterPower)
+			state.liveReadout.Update(samples, derivatives, pulses, activePulse)
+			state.pulseLog.Update(pulses)
+			state.sessionStats.Update(state.sessionTracker.Stats())
+		}
+		state.sessionTracker.Update(samples, derivatives, pulses)
+		logPulses(state, pulses)
+		if latest.Swap(&update) != nil {
+			return // Still queued: it runs this newer update instead
 		}
+		fyne.Do(func() {
+			if update := latest.Swap(nil); update != nil {
+				(*update)()
+			}
+		})
+	})
 
-		// Always apply differentiation to calculate Change field from Reading
-		diffStream := sample.NewDifferentiationConverter(500)(downsampledStream)
+	// Create converter pipeline with chaining support
+	rawSamples := device.Samples()
 
-		// Apply filter on Change field (configurable: EMA, MA, or MM)
-		var samplesStream <-chan sample.Sample
-		changeFields := sample.FieldChange
-		filterType := state.cfg.Measurement.ChangeFilterType
-		if filterType == "" {
-			filterType = "ema" // Default
-		}
+	// Tee raw samples: one branch for heater state updates, one for converter chain
+	// We need to tee because we need to read from the channel twice:
+	// 1. For heater state synchronization
+	// 2. For the converter chain
+	rawSamplesForConverter := teeChannel(rawSamples)
 
-		switch filterType {
-		case "ema", "EMA":
-			if state.cfg.Measurement.ChangeFilterAlpha > 0 {
-				samplesStream = sample.NewEMAFilter(state.cfg.Measurement.ChangeFilterAlpha, changeFields, 500)(diffStream)
-			} else {
-				samplesStream = diffStream // No filtering if alpha is 0
-			}
-		case "ma", "MA":
-			windowDuration := state.cfg.Measurement.ChangeFilterWindowSize
-			if windowDuration <= 0 {
-				windowDuration = 200 * time.Millisecond // Default: 200ms
-			}
-			samplesStream = sample.NewMAFilter(windowDuration, changeFields, 500)(diffStream)
-		case "mm", "MM":
-			windowDuration := state.cfg.Measurement.ChangeFilterWindowSize
-			if windowDuration <= 0 {
-				windowDuration = 200 * time.Millisecond // Default: 200ms
-			}
-			samplesStream = sample.NewMMFilter(windowDuration, changeFields, 500)(diffStream)
-		default:
-			// Unknown filter type, use EMA as fallback
-			if state.cfg.Measurement.ChangeFilterAlpha > 0 {
-				samplesStream = sample.NewEMAFilter(state.cfg.Measurement.ChangeFilterAlpha, changeFields, 500)(diffStream)
-			} else {
-				samplesStream = diffStream
-			}
-		}
+	// Track goroutines for graceful shutdown
+	heaterStateDone := make(chan struct{})
+	meterDone := make(chan struct{})
 
-		// Process samples through power meter (starts measurement automatically)
-		go func() {
-			defer close(meterDone)
-			state.powerMeter.ProcessSamples(samplesStream)
-		}()
-
-		// Store chain for graceful shutdown
-		state.chain = &measurementChain{
-			device:               device,
-			rawSamples:           rawSamples,
-			rawSamplesForTee:     rawSamplesForConverter,
-			heaterStateGoroutine: heaterStateDone,
-			samplesStream:        samplesStream,
-			meterGoroutine:       meterDone,
+	// Update heater states from raw samples (only when state changes)
+	go func() {
+		defer close(heaterStateDone)
+		for rawSample := range rawSamples {
+			updateHeaterStatesFromSample(state, rawSample)
 		}
+	}()
+
+	pipeline := sample.RunPipeline(state.cfg, state.useStatistics, rawSamplesForConverter)
+	samplesStream := tapDataLog(state, pipeline.Samples())
+
+	// Process samples through power meter (starts measurement automatically)
+	go func() {
+		defer close(meterDone)
+		state.powerMeter.ProcessSamples(samplesStream)
+	}()
+
+	// Store chain for graceful shutdown
+	state.chain = &measurementChain{
+		device:               device,
+		rawSamples:           rawSamples,
+		rawSamplesForTee:     rawSamplesForConverter,
+		heaterStateGoroutine: heaterStateDone,
+		pipeline:             pipeline,
+		samplesStream:        samplesStream,
+		meterGoroutine:       meterDone,
 	}
 }
 
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/scope"
+)
+
+// handleAddMarker asks for a name and drops a marker on the graph's
+// timeline, at measurement cursor 1 if placed, otherwise at the newest data.
+func handleAddMarker(state *appState) {
+	markers := state.scopeWidget.Markers()
+	nameEntry := widget.NewSelectEntry(markerLabels(markers))
+	nameEntry.SetPlaceHolder(i18n.T("e.g. aligned beam"))
+
+	items := []*widget.FormItem{widget.NewFormItem(i18n.T("Name"), nameEntry)}
+	dialog.ShowForm(i18n.T("Add Marker"), i18n.T("Add"), i18n.T("Cancel"), items, func(add bool) {
+		if !add || strings.TrimSpace(nameEntry.Text) == "" {
+			return
+		}
+		m := state.scopeWidget.AddMarker(nameEntry.Text)
+		setStatus(state, "Marker %q at %s", m.Label, m.Time.Format("15:04:05.000"))
+	}, state.window)
+}
+
+// markerLabels returns the distinct labels of markers, newest first, to be
+// offered again.
+func markerLabels(markers []scope.Marker) []string {
+	var labels []string
+	for _, m := range slices.Backward(markers) {
+		if !slices.Contains(labels, m.Label) {
+			labels = append(labels, m.Label)
+		}
+	}
+	return labels
+}
+
+// markerSummary lists markers one per line with their time of day, for the
+// export header.
+func markerSummary(markers []scope.Marker) string {
+	lines := make([]string, len(markers))
+	for i, m := range markers {
+		lines[i] = m.Time.Format("15:04:05") + "  " + m.Label
+	}
+	return strings.Join(lines, "\n")
+}
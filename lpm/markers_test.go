@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/scope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkerLabels(t *testing.T) {
+	markers := []scope.Marker{{Label: "aligned beam"}, {Label: "changed filter"}, {Label: "aligned beam"}}
+	assert.Equal(t, []string{"aligned beam", "changed filter"}, markerLabels(markers))
+	assert.Empty(t, markerLabels(nil))
+}
+
+func TestExportHeader_Markers(t *testing.T) {
+	test.NewTempApp(t)
+	cfg := config.Default()
+	state := &appState{cfg: cfg, useMock: true, scopeWidget: scope.New(cfg)}
+	now := time.Date(2024, 5, 1, 14, 3, 7, 0, time.Local)
+
+	require.Len(t, exportHeader(state, now, ""), 5, "no markers row without markers")
+
+	state.scopeWidget.AddMarker("aligned beam")
+	state.scopeWidget.AddMarker("changed filter")
+	rows := exportHeader(state, now, "")
+	require.Len(t, rows, 6)
+	assert.Equal(t, "Markers", rows[5][0])
+	assert.Regexp(t, `^\d\d:\d\d:\d\d  aligned beam\n\d\d:\d\d:\d\d  changed filter$`, rows[5][1])
+}
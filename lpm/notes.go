@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// annotation is a timestamped note on the measurement, e.g. a change of
+// laser, wavelength or setup. Unlike markers, annotations are not drawn on
+// the graph.
+type annotation struct {
+	Time time.Time
+	Text string
+}
+
+// addAnnotation appends text as an annotation made at now. Blank text is
+// ignored.
+func addAnnotation(annotations []annotation, text string, now time.Time) []annotation {
+	if text = strings.TrimSpace(text); text == "" {
+		return annotations
+	}
+	return append(annotations, annotation{Time: now, Text: text})
+}
+
+// annotationSummary lists annotations one per line with their date and
+// time, for the export header.
+func annotationSummary(annotations []annotation) string {
+	lines := make([]string, len(annotations))
+	for i, a := range annotations {
+		lines[i] = a.Time.Format("2006-01-02 15:04:05") + "  " + a.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// showNotesDialog edits the session's notes, describing the laser,
+// wavelength and setup measured, and its timestamped annotations. Both are
+// saved with the session and shown in the annotated export.
+func showNotesDialog(state *appState) {
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetPlaceHolder(i18n.T("Laser, wavelength, setup, operator..."))
+	notesEntry.SetMinRowsVisible(4)
+	notesEntry.SetText(state.notes)
+	notesEntry.OnChanged = func(text string) { state.notes = text }
+
+	list := widget.NewList(
+		func() int { return len(state.annotations) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			a := state.annotations[id]
+			o.(*widget.Label).SetText(a.Time.Format("15:04:05") + "  " + a.Text)
+		},
+	)
+	annotationEntry := widget.NewEntry()
+	annotationEntry.SetPlaceHolder(i18n.T("e.g. switched to 405 nm"))
+	add := func() {
+		n := len(state.annotations)
+		state.annotations = addAnnotation(state.annotations, annotationEntry.Text, time.Now())
+		if len(state.annotations) == n {
+			return
+		}
+		annotationEntry.SetText("")
+		list.Refresh()
+		list.ScrollToBottom()
+		setStatus(state, "Annotation: %s", state.annotations[n].Text)
+	}
+	annotationEntry.OnSubmitted = func(string) { add() }
+	addBtn := widget.NewButtonWithIcon(i18n.T("Add"), theme.ContentAddIcon(), add)
+
+	// A dialog sizes to its content, so the list gets a fixed area
+	listArea := container.NewGridWrap(fyne.NewSize(480, 160), list)
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(i18n.T("Notes"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		notesEntry,
+		widget.NewLabelWithStyle(i18n.T("Annotations"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		listArea,
+		container.NewBorder(nil, nil, nil, addBtn, annotationEntry),
+	)
+	dialog.ShowCustom(i18n.T("Session Notes"), i18n.T("Close"), content, state.window)
+}
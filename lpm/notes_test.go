@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAnnotation(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.Local)
+
+	annotations := addAnnotation(nil, "  switched to 405 nm\n", t0)
+	annotations = addAnnotation(annotations, " ", t0.Add(time.Minute))
+	annotations = addAnnotation(annotations, "ND filter in", t0.Add(2*time.Minute))
+
+	require.Len(t, annotations, 2, "blank text is ignored")
+	assert.Equal(t, annotation{Time: t0, Text: "switched to 405 nm"}, annotations[0])
+	assert.Equal(t, "2024-05-01 14:03:07  switched to 405 nm\n2024-05-01 14:05:07  ND filter in", annotationSummary(annotations))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/scope"
+)
+
+// performanceInterval is how often the performance overlay is updated.
+const performanceInterval = 500 * time.Millisecond
+
+// performanceMonitor measures how the GUI keeps up with the measurement:
+// the delay of the meter's updates to the main thread and the rate of
+// samples from the device. Use it on the main thread only.
+type performanceMonitor struct {
+	latency time.Duration // Smoothed delay from a meter update to the widgets showing it
+
+	received   uint64    // Samples received from the device at the last rate update
+	receivedAt time.Time // Time of the last rate update
+	rate       float64   // Samples per second over the last update
+}
+
+// recordLatency adds the delay of a meter update reaching the widgets.
+func (m *performanceMonitor) recordLatency(d time.Duration) {
+	if m.latency == 0 {
+		m.latency = d
+		return
+	}
+	m.latency += (d - m.latency) / 8
+}
+
+// updateRate computes the sample rate from the device's count of received
+// samples at now. A count going back, e.g. after reconnecting, restarts it.
+func (m *performanceMonitor) updateRate(received uint64, now time.Time) {
+	if !m.receivedAt.IsZero() && received >= m.received {
+		if elapsed := now.Sub(m.receivedAt); elapsed > 0 {
+			m.rate = float64(received-m.received) / elapsed.Seconds()
+		}
+	} else {
+		m.rate = 0
+	}
+	m.received, m.receivedAt = received, now
+}
+
+// formatPerformance formats the overlay's figures on one line each.
+func formatPerformance(p scope.Performance, latency time.Duration, rate float64) string {
+	return fmt.Sprintf("%.0f FPS, redraw %.1f ms, every %d ms\nlatency %.1f ms\n%.1f samples/s",
+		p.FPS, float64(p.Redraw)/float64(time.Millisecond), p.Interval.Milliseconds(),
+		float64(latency)/float64(time.Millisecond), rate)
+}
+
+// newPerformanceOverlay returns the overlay label in the top right corner of
+// the graph, hidden unless enabled in the configuration.
+func newPerformanceOverlay(state *appState) fyne.CanvasObject {
+	state.perfLabel = widget.NewLabelWithStyle("", fyne.TextAlignTrailing, fyne.TextStyle{Monospace: true})
+	state.perfLabel.Importance = widget.LowImportance
+	if !state.cfg.Scope.ShowPerformance {
+		state.perfLabel.Hide()
+	}
+	return container.NewVBox(container.NewHBox(layout.NewSpacer(), state.perfLabel))
+}
+
+// runPerformanceOverlay updates the overlay every performanceInterval. It
+// never returns.
+func runPerformanceOverlay(state *appState) {
+	ticker := time.NewTicker(performanceInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		fyne.Do(func() { updatePerformanceOverlay(state, time.Now()) })
+	}
+}
+
+// updatePerformanceOverlay shows or hides the overlay as configured and
+// shows the current figures. Call it on the main thread.
+func updatePerformanceOverlay(state *appState, now time.Time) {
+	var received uint64
+	if state.device != nil && state.device.IsConnected() {
+		received = state.device.Stats().Parsed
+	}
+	state.perf.updateRate(received, now)
+
+	if !state.cfg.Scope.ShowPerformance {
+		state.perfLabel.Hide()
+		return
+	}
+	state.perfLabel.SetText(formatPerformance(state.scopeWidget.Performance(), state.perf.latency, state.perf.rate))
+	state.perfLabel.Show()
+}
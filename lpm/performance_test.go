@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/scope"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerformanceMonitor(t *testing.T) {
+	var m performanceMonitor
+	m.recordLatency(8 * time.Millisecond)
+	assert.Equal(t, 8*time.Millisecond, m.latency)
+	m.recordLatency(16 * time.Millisecond)
+	assert.Equal(t, 9*time.Millisecond, m.latency, "smoothed")
+
+	now := time.Now()
+	m.updateRate(100, now)
+	assert.Zero(t, m.rate, "no rate before a second count")
+	m.updateRate(125, now.Add(500*time.Millisecond))
+	assert.Equal(t, 50.0, m.rate)
+	m.updateRate(0, now.Add(time.Second))
+	assert.Zero(t, m.rate, "reconnected")
+}
+
+func TestFormatPerformance(t *testing.T) {
+	p := scope.Performance{FPS: 59.6, Redraw: 2500 * time.Microsecond, Interval: 16 * time.Millisecond}
+	assert.Equal(t, "60 FPS, redraw 2.5 ms, every 16 ms\nlatency 1.2 ms\n50.0 samples/s",
+		formatPerformance(p, 1200*time.Microsecond, 50))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// profileNames returns the names of the device profiles in order.
+func profileNames(profiles []config.Profile) []string {
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// handleProfileSelected puts the device profile called name in use: its
+// divider, heaters and calibration apply to the running meter, and a
+// connected device on another port is reconnected on the profile's port.
+func handleProfileSelected(state *appState, name string) {
+	if name == state.cfg.Profile {
+		return
+	}
+	port := state.cfg.Serial.Port
+	if err := state.cfg.SwitchProfile(name); err != nil {
+		dialog.ShowError(err, state.window)
+		return
+	}
+	if err := state.cfg.Save(state.configPath); err != nil {
+		dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+	}
+
+	reconfigureMeasurement(state)
+	state.powerMeter.UpdateCalibration(state.cfg.Measurement.PowerPolynomial, state.cfg.Measurement.AbsorbanceCoefficient)
+	updateHeaterLabels(state)
+	setStatus(state, "Using profile %s", name)
+
+	if state.cfg.Serial.Port != port && state.device != nil && state.device.IsConnected() {
+		reconnect(state)
+	}
+}
+
+// selectDeviceProfile puts the profile of the head with serial number serial
+// in use when a head connects, so a known head measures with its own divider,
+// heaters and calibration. The port stays the one the head was found on.
+func selectDeviceProfile(state *appState, serial string) {
+	name, ok := state.cfg.UseDeviceProfile(serial)
+	if !ok {
+		return
+	}
+	if err := state.cfg.Save(state.configPath); err != nil {
+		dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+	}
+
+	reconfigureMeasurement(state)
+	state.powerMeter.UpdateCalibration(state.cfg.Measurement.PowerPolynomial, state.cfg.Measurement.AbsorbanceCoefficient)
+	state.profileSelect.Selected = name // Without the callback: already in use
+	state.profileSelect.Refresh()
+	setStatus(state, "Using profile %s of head %s", name, serial)
+}
+
+// connectedSerial returns the serial number of the connected head, or "" if
+// none is connected or it has none. A replayed recording is not a head.
+func connectedSerial(state *appState) string {
+	if state.device == nil || !state.device.IsConnected() || state.replay != nil {
+		return ""
+	}
+	return state.device.Info().Serial
+}
+
+// handleSaveProfile asks for a name and saves the port, divider, heaters and
+// calibration in use as a profile, replacing a profile of the same name.
+// The connected head's serial number is suggested, so connecting it again
+// selects the profile.
+func handleSaveProfile(state *appState) {
+	nameEntry := widget.NewSelectEntry(profileNames(state.cfg.Profiles))
+	nameEntry.SetPlaceHolder(i18n.T("e.g. Head A"))
+	nameEntry.SetText(state.cfg.Profile)
+
+	serialEntry := widget.NewEntry()
+	serialEntry.SetPlaceHolder(i18n.T("Any head"))
+	if serial := connectedSerial(state); serial != "" {
+		serialEntry.SetText(serial)
+	} else if p, ok := state.cfg.FindProfile(state.cfg.Profile); ok {
+		serialEntry.SetText(p.Serial)
+	}
+
+	items := []*widget.FormItem{
+		{Text: i18n.T("Name"), Widget: nameEntry},
+		{Text: i18n.T("Head Serial Number"), Widget: serialEntry, HintText: i18n.T("Connecting this head selects the profile")},
+	}
+	dialog.ShowForm(i18n.T("Save Profile"), i18n.T("Save"), i18n.T("Cancel"), items, func(save bool) {
+		name := strings.TrimSpace(nameEntry.Text)
+		if !save || name == "" {
+			return
+		}
+		p := state.cfg.CurrentProfile(name)
+		p.Serial = strings.TrimSpace(serialEntry.Text)
+		state.cfg.SetProfile(p)
+		state.cfg.Profile = name
+		if err := state.cfg.Save(state.configPath); err != nil {
+			dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+			return
+		}
+		state.profileSelect.SetOptions(profileNames(state.cfg.Profiles))
+		state.profileSelect.Selected = name // Without the callback: already in use
+		state.profileSelect.Refresh()
+	}, state.window)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectDeviceProfile(t *testing.T) {
+	test.NewTempApp(t)
+	cfg := config.Default()
+	headA := cfg.CurrentProfile("Head A")
+	headA.Serial = "A1"
+	headA.PowerPolynomial = []float64{0, 2, 0, 0}
+	cfg.SetProfile(headA)
+	state := &appState{
+		cfg:           cfg,
+		configPath:    filepath.Join(t.TempDir(), "config.yaml"),
+		powerMeter:    meter.New(cfg),
+		profileSelect: widget.NewSelect(profileNames(cfg.Profiles), nil),
+		status:        newStatusBar(),
+	}
+
+	selectDeviceProfile(state, "B2")
+	assert.Empty(t, state.cfg.Profile, "unknown head")
+	assert.Empty(t, state.status.message.Text)
+
+	selectDeviceProfile(state, "A1")
+	assert.Equal(t, "Head A", state.cfg.Profile)
+	assert.Equal(t, "Head A", state.profileSelect.Selected)
+	assert.Equal(t, "Using profile Head A of head A1", state.status.message.Text)
+
+	saved, err := config.Load(state.configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Head A", saved.Profile)
+	assert.Equal(t, []float64{0, 2, 0, 0}, saved.Measurement.PowerPolynomial)
+}
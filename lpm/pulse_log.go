@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// handlePulseLogExport saves the pulse table as lpm_pulses_<timestamp>.csv.
+func handlePulseLogExport(state *appState, csv string) {
+	filename := fmt.Sprintf("lpm_pulses_%s.csv", time.Now().Format("20060102_150405"))
+	if err := os.WriteFile(filename, []byte(csv), 0644); err != nil {
+		dialog.ShowError(i18n.Errorf("failed to save pulses: %w", err), state.window)
+		return
+	}
+	log.Printf("Saved pulse log to %s", filename)
+	dialog.ShowInformation(i18n.T("Pulse Log"), i18n.Tf("Saved %s", filename), state.window)
+}
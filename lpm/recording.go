@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// recordingExtension is the file extension of raw recordings, which hold
+// text lines or binary frames as the device sent them.
+const recordingExtension = ".lpm"
+
+// handleRecordToggle starts or stops raw stream recording on the connected device.
+// Each recording session appends to a new timestamped file the stream as
+// received from the device; see lpm.Recorder.
+func handleRecordToggle(state *appState) {
+	if state.recorder == nil {
+		return
+	}
+
+	if state.recorder.IsRecording() {
+		stopRecording(state)
+		return
+	}
+
+	filename := fmt.Sprintf("lpm_raw_%s%s", time.Now().Format("20060102_150405"), recordingExtension)
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		dialog.ShowError(i18n.Errorf("failed to open recording file: %w", err), state.window)
+		return
+	}
+
+	state.recordFile = f
+	state.recorder.SetWriter(f)
+	setStatus(state, "Recording raw samples to %s", filename)
+	updateRecordButton(state)
+}
+
+// stopRecording stops recording and closes the recording file, if any.
+func stopRecording(state *appState) {
+	if state.recorder != nil {
+		state.recorder.SetWriter(nil)
+	}
+	if state.recordFile != nil {
+		if err := state.recordFile.Close(); err != nil {
+			log.Printf("Error closing recording file: %v", err)
+		}
+		setStatus(state, "Stopped recording to %s", state.recordFile.Name())
+		state.recordFile = nil
+	}
+	updateRecordButton(state)
+}
+
+// updateRecordButton highlights the record button while recording.
+func updateRecordButton(state *appState) {
+	if state.recordBtn == nil {
+		return
+	}
+	if state.recordFile != nil {
+		state.recordBtn.Importance = widget.DangerImportance
+	} else {
+		state.recordBtn.Importance = widget.MediumImportance
+	}
+	state.recordBtn.Refresh()
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/lpm"
+)
+
+// replayInterval is how often the replay bar follows the playback.
+const replayInterval = 250 * time.Millisecond
+
+// replaySpeeds are the playback speeds offered, as recorded first.
+var replaySpeeds = []float64{1, 0.25, 0.5, 2, 5, 10, 50}
+
+// replayBar is the line below the toolbar controlling the playback of a
+// raw recording: play and pause, the position and the speed.
+type replayBar struct {
+	name     *widget.Label
+	playBtn  *widget.Button
+	slider   *widget.Slider
+	position *widget.Label
+	speed    *widget.Select
+	content  fyne.CanvasObject
+}
+
+// newReplayBar creates the hidden replay bar of state.
+func newReplayBar(state *appState) *replayBar {
+	b := &replayBar{
+		name:     widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		slider:   widget.NewSlider(0, 1),
+		position: widget.NewLabel(""),
+	}
+	b.playBtn = widget.NewButtonWithIcon("", theme.MediaPauseIcon(), func() {
+		handlePauseToggle(state)
+		updateReplayBar(state)
+	})
+	b.slider.Step = 0.1
+	b.slider.OnChangeEnded = func(seconds float64) {
+		if state.replay != nil {
+			state.replay.Seek(time.Duration(seconds * float64(time.Second)))
+		}
+	}
+	speeds := make([]string, len(replaySpeeds))
+	for i, s := range replaySpeeds {
+		speeds[i] = formatReplaySpeed(s)
+	}
+	b.speed = widget.NewSelect(speeds, func(name string) {
+		for _, s := range replaySpeeds {
+			if formatReplaySpeed(s) == name && state.replay != nil {
+				if err := state.replay.SetSpeed(s); err != nil {
+					dialog.ShowError(err, state.window)
+				}
+			}
+		}
+	})
+	stopBtn := widget.NewButtonWithIcon("", theme.MediaStopIcon(), func() {
+		handleConnect(state)
+	})
+	b.content = container.NewBorder(nil, widget.NewSeparator(),
+		container.NewHBox(b.name, b.playBtn),
+		container.NewHBox(b.position, b.speed, stopBtn),
+		b.slider)
+	b.content.Hide()
+	return b
+}
+
+// formatReplaySpeed formats a playback speed, e.g. 0.5×.
+func formatReplaySpeed(speed float64) string {
+	return fmt.Sprintf("%g×", speed)
+}
+
+// formatReplayTime formats a position in a recording as minutes and
+// seconds.
+func formatReplayTime(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// handleOpenRecording asks for a raw recording and plays it back through
+// the measurement chain in place of the device, which is disconnected first.
+// Disconnecting ends the playback.
+func handleOpenRecording(state *appState) {
+	d := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, state.window)
+			return
+		}
+		if r == nil {
+			return // Cancelled
+		}
+		r.Close()
+
+		recording, err := lpm.ReadRecording(r.URI().Path())
+		if err != nil {
+			dialog.ShowError(i18n.Errorf("failed to open recording: %w", err), state.window)
+			return
+		}
+		if state.device != nil && state.device.IsConnected() {
+			handleConnect(state)
+		}
+
+		state.replay = lpm.NewReplay(recording)
+		connectDevice(state)
+		if state.device == nil {
+			state.replay = nil
+			return
+		}
+		bar := state.replayBar
+		bar.name.SetText(r.URI().Name())
+		_, length := state.replay.Position()
+		bar.slider.Max = max(length.Seconds(), bar.slider.Step)
+		bar.speed.SetSelected(formatReplaySpeed(1))
+		updateReplayBar(state)
+		bar.content.Show()
+		setStatus(state, "Replaying %s: %d samples over %s", r.URI().Name(), len(recording), formatReplayTime(length))
+		go runReplayBar(state, state.replay)
+	}, state.window)
+	// Recordings made before binary frames were recorded as received are CSV
+	d.SetFilter(storage.NewExtensionFileFilter([]string{recordingExtension, ".csv"}))
+	d.Show()
+}
+
+// closeReplay ends the playback mode after the replay was disconnected.
+func closeReplay(state *appState) {
+	if state.replay == nil {
+		return
+	}
+	state.replay = nil
+	state.replayBar.content.Hide()
+}
+
+// runReplayBar follows the playback of replay every replayInterval until
+// another device is connected.
+func runReplayBar(state *appState, replay *lpm.Replay) {
+	ticker := time.NewTicker(replayInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		running := true
+		fyne.DoAndWait(func() {
+			if state.replay != replay {
+				running = false
+				return
+			}
+			// The playback pauses by itself at the end of the recording
+			if replay.Paused() != state.paused {
+				handlePauseToggle(state)
+			}
+			updateReplayBar(state)
+		})
+		if !running {
+			return
+		}
+	}
+}
+
+// updateReplayBar shows the position and state of the playback. Call it on
+// the main thread.
+func updateReplayBar(state *appState) {
+	if state.replay == nil {
+		return
+	}
+	bar := state.replayBar
+	position, length := state.replay.Position()
+	bar.slider.Value = position.Seconds()
+	bar.slider.Refresh()
+	bar.position.SetText(formatReplayTime(position) + " / " + formatReplayTime(length))
+	if state.paused {
+		bar.playBtn.SetIcon(theme.MediaPlayIcon())
+	} else {
+		bar.playBtn.SetIcon(theme.MediaPauseIcon())
+	}
+}
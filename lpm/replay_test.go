@@ -0,0 +1,16 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatReplay(t *testing.T) {
+	assert.Equal(t, "0:00", formatReplayTime(0))
+	assert.Equal(t, "1:05", formatReplayTime(64600*time.Millisecond))
+	assert.Equal(t, "75:00", formatReplayTime(75*time.Minute))
+	assert.Equal(t, "0.25×", formatReplaySpeed(0.25))
+	assert.Equal(t, "10×", formatReplaySpeed(10))
+}
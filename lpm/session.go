@@ -0,0 +1,225 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/itohio/golpm/pkg/scope"
+	"gopkg.in/yaml.v3"
+)
+
+// sessionVersion is the version of the session file format written.
+const sessionVersion = 1
+
+// sessionExtension is the file extension of session files.
+const sessionExtension = ".lpms"
+
+// session is a measurement saved to revisit later: the graph's history with
+// its pulses and markers, the session statistics, the configuration it was
+// measured with and the user's notes and annotations. Files are gzip-compressed gob, as samples hold NaN for
+// missing channels.
+type session struct {
+	Version     int
+	Saved       time.Time
+	Notes       string
+	Config      []byte  // YAML snapshot, as in config.yaml
+	SampleRate  float64 // Device output rate in Hz, negotiated or configured
+	Samples     []sample.Sample
+	Derivatives []float64
+	Pulses      []meter.Pulse
+	Markers     []scope.Marker
+	Stats       meter.SessionStats // Zero in files saved before statistics were kept
+	Annotations []annotation
+}
+
+// writeSession writes s to w as a session file.
+func writeSession(w io.Writer, s *session) error {
+	zw := gzip.NewWriter(w)
+	if err := gob.NewEncoder(zw).Encode(s); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// readSession reads a session file written by writeSession.
+func readSession(r io.Reader) (*session, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, i18n.Errorf("not a session file: %w", err)
+	}
+	defer zr.Close()
+
+	var s session
+	if err := gob.NewDecoder(zr).Decode(&s); err != nil {
+		return nil, i18n.Errorf("corrupted session file: %w", err)
+	}
+	if s.Version > sessionVersion {
+		return nil, i18n.Errorf("session file version %d is newer than supported (%d)", s.Version, sessionVersion)
+	}
+	return &s, nil
+}
+
+// newSession captures the graph's history and markers with the session
+// statistics, cfg, notes and annotations.
+func newSession(cfg *config.Config, scopeWidget *scope.ScopeWidget, stats meter.SessionStats, notes string, annotations []annotation, now time.Time) (*session, error) {
+	snapshot, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, i18n.Errorf("failed to snapshot configuration: %w", err)
+	}
+	samples, derivatives, pulses := scopeWidget.History()
+	return &session{
+		Version:     sessionVersion,
+		Saved:       now,
+		Notes:       notes,
+		Config:      snapshot,
+		SampleRate:  cfg.SampleRate(),
+		Samples:     samples,
+		Derivatives: derivatives,
+		Pulses:      pulses,
+		Markers:     scopeWidget.Markers(),
+		Stats:       stats,
+		Annotations: annotations,
+	}, nil
+}
+
+// handleSaveSession asks for notes and a file, and saves the graph's history
+// as a session.
+func handleSaveSession(state *appState) {
+	if samples, _, _ := state.scopeWidget.History(); len(samples) == 0 {
+		dialog.ShowInformation(i18n.T("Save Session"), i18n.T("There is no data to save yet."), state.window)
+		return
+	}
+	now := time.Now()
+	s, err := newSession(state.cfg, state.scopeWidget, currentSessionStats(state), state.notes, state.annotations, now)
+	if err != nil {
+		dialog.ShowError(err, state.window)
+		return
+	}
+
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetPlaceHolder(i18n.T("Setup, sample, operator..."))
+	notesEntry.SetMinRowsVisible(4)
+	notesEntry.SetText(state.notes)
+	items := []*widget.FormItem{widget.NewFormItem(i18n.T("Notes"), notesEntry)}
+	dialog.ShowForm(i18n.T("Save Session"), i18n.T("Save"), i18n.T("Cancel"), items, func(save bool) {
+		if !save {
+			return
+		}
+		s.Notes = notesEntry.Text
+		state.notes = notesEntry.Text
+
+		d := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, state.window)
+				return
+			}
+			if w == nil {
+				return // Cancelled
+			}
+			err = writeSession(w, s)
+			err = errors.Join(err, w.Close())
+			if err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save session: %w", err), state.window)
+				return
+			}
+			setStatus(state, "Saved session of %d samples to %s", len(s.Samples), w.URI().Name())
+		}, state.window)
+		d.SetFileName(fmt.Sprintf("lpm_session_%s%s", now.Format("20060102_150405"), sessionExtension))
+		d.SetFilter(storage.NewExtensionFileFilter([]string{sessionExtension}))
+		d.Show()
+	}, state.window)
+}
+
+// handleOpenSession asks for a session file and shows it on the graph,
+// frozen, and in the pulse log. A connected device is disconnected first, so
+// live data does not mix with the session; connecting again clears it.
+func handleOpenSession(state *appState) {
+	d := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, state.window)
+			return
+		}
+		if r == nil {
+			return // Cancelled
+		}
+		defer r.Close()
+
+		s, err := readSession(r)
+		if err != nil {
+			dialog.ShowError(i18n.Errorf("failed to open session: %w", err), state.window)
+			return
+		}
+		if state.device != nil && state.device.IsConnected() {
+			handleConnect(state)
+		}
+		showSession(state, s)
+		setStatus(state, "Opened session %s", r.URI().Name())
+
+		info := i18n.Tf("Saved %s\n%d samples at %.1f Hz, %d pulses, %d markers, %d annotations",
+			s.Saved.Format("2006-01-02 15:04:05"), len(s.Samples), s.SampleRate, len(s.Pulses), len(s.Markers), len(s.Annotations))
+		if s.Notes != "" {
+			info += "\n\n" + s.Notes
+		}
+		dialog.ShowInformation(i18n.T("Session"), info, state.window)
+	}, state.window)
+	d.SetFilter(storage.NewExtensionFileFilter([]string{sessionExtension}))
+	d.Show()
+}
+
+// showSession loads s into the graph, the pulse log, the session
+// statistics and the session notes.
+func showSession(state *appState, s *session) {
+	state.scopeWidget.LoadHistory(s.Samples, s.Derivatives, s.Pulses, s.Markers)
+	updateFreezeButton(state)
+	state.pulseLog.Clear()
+	state.pulseLog.Restart()
+	state.pulseLog.Update(s.Pulses)
+	state.sessionOpen = true
+	state.notes = s.Notes
+	state.annotations = s.Annotations
+
+	// Sessions saved without statistics get them from their history
+	state.openedStats = s.Stats
+	if s.Stats.Pulses == 0 && s.Stats.Duration == 0 {
+		state.openedStats = meter.ComputeSessionStats(s.Samples, s.Pulses)
+	}
+	state.sessionStats.SetStats(state.openedStats)
+}
+
+// closeSession clears an opened session from the graph before live data
+// arrives, resuming the display.
+func closeSession(state *appState) {
+	if !state.sessionOpen {
+		return
+	}
+	state.sessionOpen = false
+	state.scopeWidget.ClearHistory()
+	state.scopeWidget.SetFrozen(false)
+	updateFreezeButton(state)
+	state.pulseLog.Restart()
+	state.sessionTracker.Reset()
+	state.sessionStats.SetStats(state.sessionTracker.Stats())
+	state.notes = ""
+	state.annotations = nil
+}
+
+// currentSessionStats returns the statistics of the live session, or of the
+// opened one.
+func currentSessionStats(state *appState) meter.SessionStats {
+	if state.sessionOpen {
+		return state.openedStats
+	}
+	return state.sessionTracker.Stats()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/itohio/golpm/pkg/scope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSession_RoundTrip(t *testing.T) {
+	test.NewTempApp(t)
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	samples := []sample.Sample{
+		{Timestamp: t0, Reading: 0.001, Ambient: math.NaN(), Reading2: math.NaN()},
+		{Timestamp: t0.Add(time.Second), Reading: 0.002, Ambient: math.NaN(), Reading2: math.NaN(), Heaters: []bool{true, false, false}},
+	}
+	pulses := []meter.Pulse{{ID: 1, State: meter.PulseStateFinalized, StartTime: t0, EndTime: t0.Add(time.Second), AvgPower: 0.01}}
+
+	cfg := config.Default()
+	cfg.Serial.Port = "COM3"
+	scopeWidget := scope.New(cfg)
+	scopeWidget.UpdateData(samples, []float64{0.001}, pulses, nil, 0)
+	scopeWidget.AddMarker("aligned beam")
+
+	stats := meter.ComputeSessionStats(samples, pulses)
+	annotations := []annotation{{Time: t0, Text: "405 nm diode"}}
+	s, err := newSession(cfg, scopeWidget, stats, "first run", annotations, t0.Add(time.Minute))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, writeSession(&buf, s))
+
+	got, err := readSession(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, sessionVersion, got.Version)
+	assert.Equal(t, "first run", got.Notes)
+	assert.True(t, got.Saved.Equal(t0.Add(time.Minute)))
+	require.Len(t, got.Samples, 2)
+	assert.True(t, math.IsNaN(got.Samples[1].Ambient), "missing channels stay NaN")
+	assert.Equal(t, []bool{true, false, false}, got.Samples[1].Heaters)
+	assert.Equal(t, []float64{0.001}, got.Derivatives)
+	require.Len(t, got.Pulses, 1)
+	assert.Equal(t, 0.01, got.Pulses[0].AvgPower)
+	require.Len(t, got.Markers, 1)
+	assert.Equal(t, "aligned beam", got.Markers[0].Label)
+	assert.Equal(t, 1, got.Stats.Pulses)
+	assert.Equal(t, time.Second, got.Stats.Duration)
+	require.Len(t, got.Annotations, 1)
+	assert.Equal(t, "405 nm diode", got.Annotations[0].Text)
+	assert.True(t, got.Annotations[0].Time.Equal(t0))
+
+	// The configuration snapshot reads back as a config file
+	var snapshot config.Config
+	require.NoError(t, yaml.Unmarshal(got.Config, &snapshot))
+	assert.Equal(t, "COM3", snapshot.Serial.Port)
+}
+
+func TestReadSession_Invalid(t *testing.T) {
+	_, err := readSession(bytes.NewBufferString("not a session"))
+	assert.ErrorContains(t, err, "not a session file")
+
+	var buf bytes.Buffer
+	require.NoError(t, writeSession(&buf, &session{Version: sessionVersion + 1}))
+	_, err = readSession(&buf)
+	assert.ErrorContains(t, err, "newer than supported")
+}
+
+func TestShowSession(t *testing.T) {
+	test.NewTempApp(t)
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	state := &appState{cfg: config.Default(), pulseLog: scope.NewPulseLog(), sessionStats: scope.NewSessionStatsPanel()}
+	state.scopeWidget = scope.New(state.cfg)
+
+	showSession(state, &session{
+		Samples:     []sample.Sample{{Timestamp: t0}, {Timestamp: t0.Add(time.Second)}},
+		Derivatives: []float64{0},
+		Pulses:      []meter.Pulse{{ID: 7, State: meter.PulseStateFinalized, AvgPower: 0.002}},
+	})
+	assert.True(t, state.scopeWidget.Frozen(), "session shown read-only")
+	assert.Contains(t, state.pulseLog.CSV(), "\n1,")
+	stats := currentSessionStats(state)
+	assert.Equal(t, 1, stats.Pulses, "statistics of files without them come from the history")
+	assert.Equal(t, time.Second, stats.Duration)
+
+	// Connecting again resumes live data
+	closeSession(state)
+	assert.False(t, state.scopeWidget.Frozen())
+	samples, _, _ := state.scopeWidget.History()
+	assert.Empty(t, samples)
+	assert.Zero(t, currentSessionStats(state).Pulses)
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -10,8 +12,9 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
 	"github.com/itohio/golpm/pkg/lpm"
-	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/units"
 )
 
 // showSettingsDialog displays a settings dialog with tabs for all configuration options.
@@ -23,6 +26,9 @@ func showSettingsDialog(state *appState) {
 		createHeatersTab(state),
 		createMeasurementTab(state),
 		createCalibrationTab(state),
+		createScopeTab(state),
+		createScopeThemeTab(state),
+		createAlarmsTab(state),
 		createMockTab(state),
 	)
 
@@ -30,7 +36,7 @@ func showSettingsDialog(state *appState) {
 	content := container.NewBorder(nil, nil, nil, nil, tabs)
 	content.Resize(fyne.NewSize(600, 500))
 
-	d := dialog.NewCustom("Settings", "Close", content, state.window)
+	d := dialog.NewCustom(i18n.T("Settings"), i18n.T("Close"), content, state.window)
 	d.Resize(fyne.NewSize(600, 500))
 	d.Show()
 }
@@ -77,11 +83,134 @@ func createSerialTab(state *appState) *container.TabItem {
 		portSelect.SetSelected(currentDisplay)
 	}
 
+	sampleRateEntry := widget.NewEntry()
+	sampleRateEntry.SetPlaceHolder(i18n.T("firmware default"))
+	if state.cfg.Serial.SampleRate > 0 {
+		sampleRateEntry.SetText(fmt.Sprintf("%.0f", state.cfg.Serial.SampleRate))
+	}
+
+	// Oversampling, applied by the firmware on connect
+	averagingEntry := widget.NewEntry()
+	averagingEntry.SetPlaceHolder(i18n.T("firmware default"))
+	if state.cfg.Serial.Averaging > 0 {
+		averagingEntry.SetText(strconv.Itoa(state.cfg.Serial.Averaging))
+	}
+	adcIntervalEntry := widget.NewEntry()
+	adcIntervalEntry.SetPlaceHolder(i18n.T("firmware default"))
+	if state.cfg.Serial.ADCInterval > 0 {
+		adcIntervalEntry.SetText(state.cfg.Serial.ADCInterval.String())
+	}
+
+	staleTimeoutEntry := widget.NewEntry()
+	staleTimeoutEntry.SetText(state.cfg.Serial.StaleTimeout.String())
+
+	// Line settings
+	baudRateEntry := widget.NewEntry()
+	baudRateEntry.SetText(strconv.Itoa(state.cfg.Serial.BaudRate))
+
+	dataBitsSelect := widget.NewSelect([]string{"5", "6", "7", "8"}, nil)
+	dataBitsSelect.SetSelected(strconv.Itoa(state.cfg.Serial.DataBits))
+
+	paritySelect := widget.NewSelect([]string{"none", "odd", "even", "mark", "space"}, nil)
+	paritySelect.SetSelected(state.cfg.Serial.Parity)
+
+	stopBitsSelect := widget.NewSelect([]string{"1", "1.5", "2"}, nil)
+	stopBitsSelect.SetSelected(state.cfg.Serial.StopBits)
+
+	flowControlSelect := widget.NewSelect([]string{lpm.FlowControlNone, lpm.FlowControlRTSCTS}, nil)
+	flowControlSelect.SetSelected(state.cfg.Serial.FlowControl)
+
+	// DTR/RTS default to asserted, matching the driver default
+	dtrCheck := widget.NewCheck(i18n.T("Assert DTR (some boards reset on DTR)"), nil)
+	dtrCheck.SetChecked(state.cfg.Serial.DTR == nil || *state.cfg.Serial.DTR)
+	rtsCheck := widget.NewCheck(i18n.T("Assert RTS"), nil)
+	rtsCheck.SetChecked(state.cfg.Serial.RTS == nil || *state.cfg.Serial.RTS)
+
 	form := &widget.Form{
 		Items: []*widget.FormItem{
-			{Text: "Serial Port", Widget: portSelect},
+			{Text: i18n.T("Serial Port"), Widget: portSelect},
+			{Text: i18n.T("Baud Rate"), Widget: baudRateEntry},
+			{Text: i18n.T("Data Bits"), Widget: dataBitsSelect},
+			{Text: i18n.T("Parity"), Widget: paritySelect},
+			{Text: i18n.T("Stop Bits"), Widget: stopBitsSelect},
+			{Text: i18n.T("Flow Control"), Widget: flowControlSelect},
+			{Text: i18n.T("DTR"), Widget: dtrCheck},
+			{Text: i18n.T("RTS"), Widget: rtsCheck},
+			{Text: i18n.T("Sample Rate (Hz)"), Widget: sampleRateEntry},
+			{Text: i18n.T("Averaging (readings)"), Widget: averagingEntry},
+			{Text: i18n.T("ADC Interval"), Widget: adcIntervalEntry},
+			{Text: i18n.T("Stale Timeout"), Widget: staleTimeoutEntry},
 		},
 		OnSubmit: func() {
+			// Line settings take effect on reconnect
+			line := state.cfg.Serial
+			if br, err := strconv.Atoi(baudRateEntry.Text); err == nil && br > 0 {
+				line.BaudRate = br
+			}
+			if db, err := strconv.Atoi(dataBitsSelect.Selected); err == nil {
+				line.DataBits = db
+			}
+			line.Parity = paritySelect.Selected
+			line.StopBits = stopBitsSelect.Selected
+			line.FlowControl = flowControlSelect.Selected
+			dtr, rts := dtrCheck.Checked, rtsCheck.Checked
+			line.DTR, line.RTS = &dtr, &rts
+
+			// Empty oversampling entries select the firmware default
+			line.Averaging = 0
+			if n, err := strconv.Atoi(averagingEntry.Text); err == nil && n > 0 {
+				line.Averaging = n
+			}
+			line.ADCInterval = 0
+			if iv, err := time.ParseDuration(adcIntervalEntry.Text); err == nil && iv > 0 {
+				line.ADCInterval = iv
+			}
+
+			lineChanged := line.BaudRate != state.cfg.Serial.BaudRate ||
+				line.DataBits != state.cfg.Serial.DataBits ||
+				line.Parity != state.cfg.Serial.Parity ||
+				line.StopBits != state.cfg.Serial.StopBits ||
+				line.FlowControl != state.cfg.Serial.FlowControl ||
+				dtr != (state.cfg.Serial.DTR == nil || *state.cfg.Serial.DTR) ||
+				rts != (state.cfg.Serial.RTS == nil || *state.cfg.Serial.RTS)
+			samplingChanged := line.Averaging != state.cfg.Serial.Averaging ||
+				line.ADCInterval != state.cfg.Serial.ADCInterval
+			if lineChanged || samplingChanged {
+				state.cfg.Serial = line
+				if err := state.cfg.Save(state.configPath); err != nil {
+					dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+				}
+			}
+
+			// Oversampling applies to a connected serial device right away;
+			// the firmware defaults return on reconnect
+			if samplingChanged && !lineChanged && state.serial != nil && state.device != nil && state.device.IsConnected() {
+				applyOversampling(state)
+			}
+
+			// Stale timeout applies on the next connect
+			if st, err := time.ParseDuration(staleTimeoutEntry.Text); err == nil && st > 0 && st != state.cfg.Serial.StaleTimeout {
+				state.cfg.Serial.StaleTimeout = st
+				if err := state.cfg.Save(state.configPath); err != nil {
+					dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+				}
+			}
+
+			// Apply sample rate immediately, along with the sample-based windows
+			if sr, err := strconv.ParseFloat(sampleRateEntry.Text, 64); err == nil && sr > 0 && sr != state.cfg.Serial.SampleRate {
+				state.cfg.Serial.SampleRate = sr
+				if state.device != nil && state.device.IsConnected() {
+					if err := state.device.SetSampleRate(sr); err != nil {
+						dialog.ShowError(i18n.Errorf("failed to set sample rate: %w", err), state.window)
+					}
+					state.cfg.Serial.NegotiatedRate = state.device.Info().SampleRate
+					reconfigureMeasurement(state)
+				}
+				if err := state.cfg.Save(state.configPath); err != nil {
+					dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+				}
+			}
+
 			if portSelect.Selected != "" {
 				selectedPort := portMap[portSelect.Selected]
 				if selectedPort == "" {
@@ -93,31 +222,38 @@ func createSerialTab(state *appState) *container.TabItem {
 				wasConnected := state.device != nil && state.device.IsConnected()
 
 				state.cfg.Serial.Port = selectedPort
-				if err := state.cfg.Save("config.yaml"); err != nil {
-					dialog.ShowError(fmt.Errorf("failed to save config: %w", err), state.window)
+				if err := state.cfg.Save(state.configPath); err != nil {
+					dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
 					return
 				}
 
-				// If port changed and device was connected, restart the measurement chain
-				if portChanged && wasConnected {
-					// Gracefully close old chain
-					closeMeasurementChain(state.chain)
-					state.chain = nil
-
-					// Close old device
-					if state.device != nil {
-						state.device.Close()
-						state.device = nil
-					}
-
-					// Reconnect with new port
-					handleConnect(state)
+				// If port or line settings changed and device was connected, restart the measurement chain
+				if (portChanged || lineChanged) && wasConnected {
+					reconnect(state)
 				}
 			}
 		},
 	}
 
-	return container.NewTabItem("Serial", form)
+	return container.NewTabItem(i18n.T("Serial"), form)
+}
+
+// applyOversampling sends the configured ADC interval and averaging count to
+// the connected serial device, then applies the resulting sample rate.
+func applyOversampling(state *appState) {
+	// The interval first: the firmware keeps the averaging count when it changes
+	if iv := state.cfg.Serial.ADCInterval; iv > 0 {
+		if err := state.serial.SetADCInterval(iv); err != nil {
+			dialog.ShowError(i18n.Errorf("failed to set ADC interval: %w", err), state.window)
+		}
+	}
+	if n := state.cfg.Serial.Averaging; n > 0 {
+		if err := state.serial.SetAveraging(n); err != nil {
+			dialog.ShowError(i18n.Errorf("failed to set averaging: %w", err), state.window)
+		}
+	}
+	applySampleRate(state)
+	setStatus(state, "Sample rate now %.1f Hz", state.cfg.Serial.NegotiatedRate)
 }
 
 // createVoltageDividerTab creates the Voltage Divider configuration tab.
@@ -133,9 +269,9 @@ func createVoltageDividerTab(state *appState) *container.TabItem {
 
 	form := &widget.Form{
 		Items: []*widget.FormItem{
-			{Text: "R1 (Ω)", Widget: r1Entry},
-			{Text: "R2 (Ω)", Widget: r2Entry},
-			{Text: "VRef (V)", Widget: vrefEntry},
+			{Text: i18n.T("R1 (Ω)"), Widget: r1Entry},
+			{Text: i18n.T("R2 (Ω)"), Widget: r2Entry},
+			{Text: i18n.T("VRef (V)"), Widget: vrefEntry},
 		},
 		OnSubmit: func() {
 			if r1, err := strconv.ParseFloat(r1Entry.Text, 64); err == nil {
@@ -147,53 +283,71 @@ func createVoltageDividerTab(state *appState) *container.TabItem {
 			if vref, err := strconv.ParseFloat(vrefEntry.Text, 64); err == nil {
 				state.cfg.VoltageDivider.VRef = vref
 			}
-			if err := state.cfg.Save("config.yaml"); err != nil {
-				dialog.ShowError(fmt.Errorf("failed to save config: %w", err), state.window)
+			if err := state.cfg.Save(state.configPath); err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
 			}
+			reconfigureMeasurement(state)
 		},
 	}
 
-	return container.NewTabItem("Voltage Divider", form)
+	return container.NewTabItem(i18n.T("Voltage Divider"), form)
 }
 
-// createHeatersTab creates the Heaters configuration tab.
+// createHeatersTab creates the Heaters configuration tab. The resistances
+// are listed one per heater, so the list also sets the number of heaters.
 func createHeatersTab(state *appState) *container.TabItem {
-	heater1Entry := widget.NewEntry()
-	heater1Entry.SetText(fmt.Sprintf("%.0f", state.cfg.Heaters[0].Resistance))
-
-	heater2Entry := widget.NewEntry()
-	heater2Entry.SetText(fmt.Sprintf("%.0f", state.cfg.Heaters[1].Resistance))
-
-	heater3Entry := widget.NewEntry()
-	heater3Entry.SetText(fmt.Sprintf("%.0f", state.cfg.Heaters[2].Resistance))
+	resistancesEntry := widget.NewEntry()
+	resistancesEntry.SetText(formatHeaterResistances(state.cfg.Heaters))
+	resistancesEntry.SetPlaceHolder("2694, 511, 241")
 
 	form := &widget.Form{
 		Items: []*widget.FormItem{
-			{Text: "Heater 1 Resistance (Ω)", Widget: heater1Entry},
-			{Text: "Heater 2 Resistance (Ω)", Widget: heater2Entry},
-			{Text: "Heater 3 Resistance (Ω)", Widget: heater3Entry},
+			{Text: i18n.T("Heater Resistances (Ω)"), Widget: resistancesEntry, HintText: i18n.T("One per heater, heater 1 first")},
 		},
 		OnSubmit: func() {
-			if r1, err := strconv.ParseFloat(heater1Entry.Text, 64); err == nil {
-				state.cfg.Heaters[0].Resistance = r1
-			}
-			if r2, err := strconv.ParseFloat(heater2Entry.Text, 64); err == nil {
-				state.cfg.Heaters[1].Resistance = r2
-			}
-			if r3, err := strconv.ParseFloat(heater3Entry.Text, 64); err == nil {
-				state.cfg.Heaters[2].Resistance = r3
+			heaters, err := parseHeaterResistances(resistancesEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, state.window)
+				return
 			}
-			if err := state.cfg.Save("config.yaml"); err != nil {
-				dialog.ShowError(fmt.Errorf("failed to save config: %w", err), state.window)
+			state.cfg.Heaters = heaters
+			if err := state.cfg.Save(state.configPath); err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
 			}
-			// Update heater button labels
-			state.heater1Btn.SetText(fmt.Sprintf("H1 (~%.0fΩ)", state.cfg.Heaters[0].Resistance))
-			state.heater2Btn.SetText(fmt.Sprintf("H2 (~%.0fΩ)", state.cfg.Heaters[1].Resistance))
-			state.heater3Btn.SetText(fmt.Sprintf("H3 (~%.0fΩ)", state.cfg.Heaters[2].Resistance))
+			reconfigureMeasurement(state)
+			updateHeaterLabels(state)
 		},
 	}
 
-	return container.NewTabItem("Heaters", form)
+	return container.NewTabItem(i18n.T("Heaters"), form)
+}
+
+// formatHeaterResistances formats the heater resistances as a
+// comma-separated list, e.g. "2694, 511, 241".
+func formatHeaterResistances(heaters []config.HeaterConfig) string {
+	fields := make([]string, len(heaters))
+	for i, h := range heaters {
+		fields[i] = strconv.FormatFloat(h.Resistance, 'f', -1, 64)
+	}
+	return strings.Join(fields, ", ")
+}
+
+// parseHeaterResistances parses a comma-separated list of heater
+// resistances, one per heater.
+func parseHeaterResistances(text string) ([]config.HeaterConfig, error) {
+	fields := strings.Split(text, ",")
+	if len(fields) > lpm.MaxHeaters {
+		return nil, i18n.Errorf("at most %d heaters are supported", lpm.MaxHeaters)
+	}
+	heaters := make([]config.HeaterConfig, len(fields))
+	for i, field := range fields {
+		r, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || !(r > 0) {
+			return nil, i18n.Errorf("invalid resistance %q of heater %d", strings.TrimSpace(field), i+1)
+		}
+		heaters[i].Resistance = r
+	}
+	return heaters, nil
 }
 
 // createMeasurementTab creates the Measurement configuration tab.
@@ -238,16 +392,16 @@ func createMeasurementTab(state *appState) *container.TabItem {
 
 	form := &widget.Form{
 		Items: []*widget.FormItem{
-			{Text: "Window (seconds)", Widget: windowSecondsEntry},
-			{Text: "Pulse Threshold (mV/s)", Widget: pulseThresholdEntry},
-			{Text: "Pulse Fit Range (mV/s)", Widget: pulseLineFitRangeEntry},
-			{Text: "Min Pulse Duration (s)", Widget: minPulseDurationEntry},
-			{Text: "Smoothing Alpha (0-1, 0=disabled)", Widget: smoothingAlphaEntry},
-			{Text: "Spike Filter Window Size (0=disabled)", Widget: spikeFilterWindowSizeEntry},
-			{Text: "Downsample Rate (e.g., 1s, 0s=disabled)", Widget: downsampleRateEntry},
-			{Text: "Change Filter Type (ema/ma/mm)", Widget: changeFilterTypeSelect},
-			{Text: "Change Filter Alpha (0-1, for EMA)", Widget: changeFilterAlphaEntry},
-			{Text: "Change Filter Window Size (for MA/MM)", Widget: changeFilterWindowSizeEntry},
+			{Text: i18n.T("Window (seconds)"), Widget: windowSecondsEntry},
+			{Text: i18n.T("Pulse Threshold (mV/s)"), Widget: pulseThresholdEntry},
+			{Text: i18n.T("Pulse Fit Range (mV/s)"), Widget: pulseLineFitRangeEntry},
+			{Text: i18n.T("Min Pulse Duration (s)"), Widget: minPulseDurationEntry},
+			{Text: i18n.T("Smoothing Alpha (0-1, 0=disabled)"), Widget: smoothingAlphaEntry},
+			{Text: i18n.T("Spike Filter Window Size (0=disabled)"), Widget: spikeFilterWindowSizeEntry},
+			{Text: i18n.T("Downsample Rate (e.g., 1s, 0s=disabled)"), Widget: downsampleRateEntry},
+			{Text: i18n.T("Change Filter Type (ema/ma/mm)"), Widget: changeFilterTypeSelect},
+			{Text: i18n.T("Change Filter Alpha (0-1, for EMA)"), Widget: changeFilterAlphaEntry},
+			{Text: i18n.T("Change Filter Window Size (for MA/MM)"), Widget: changeFilterWindowSizeEntry},
 		},
 		OnSubmit: func() {
 			if ws, err := strconv.ParseFloat(windowSecondsEntry.Text, 64); err == nil {
@@ -280,23 +434,15 @@ func createMeasurementTab(state *appState) *container.TabItem {
 			if cfws, err := time.ParseDuration(changeFilterWindowSizeEntry.Text); err == nil {
 				state.cfg.Measurement.ChangeFilterWindowSize = cfws
 			}
-			if err := state.cfg.Save("config.yaml"); err != nil {
-				dialog.ShowError(fmt.Errorf("failed to save config: %w", err), state.window)
-			}
-			// Recreate power meter with new config
-			state.powerMeter = meter.New(state.cfg)
-			// Restart measurement chain with new settings
-			if state.chain != nil {
-				closeMeasurementChain(state.chain)
-				state.chain = nil
-				if state.device != nil && state.device.IsConnected() {
-					handleConnect(state)
-				}
+			if err := state.cfg.Save(state.configPath); err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
 			}
+			// Apply to the running meter and pipeline, keeping the measured data
+			reconfigureMeasurement(state)
 		},
 	}
 
-	return container.NewTabItem("Measurement", form)
+	return container.NewTabItem(i18n.T("Measurement"), form)
 }
 
 // createCalibrationTab creates the Calibration configuration tab.
@@ -312,9 +458,9 @@ func createCalibrationTab(state *appState) *container.TabItem {
 
 	form := &widget.Form{
 		Items: []*widget.FormItem{
-			{Text: "Baseline Duration", Widget: baselineDurationEntry},
-			{Text: "Heater Duration", Widget: heaterDurationEntry},
-			{Text: "Cool-off Duration", Widget: cooloffDurationEntry},
+			{Text: i18n.T("Baseline Duration"), Widget: baselineDurationEntry},
+			{Text: i18n.T("Heater Duration"), Widget: heaterDurationEntry},
+			{Text: i18n.T("Cool-off Duration"), Widget: cooloffDurationEntry},
 		},
 		OnSubmit: func() {
 			if bd, err := time.ParseDuration(baselineDurationEntry.Text); err == nil {
@@ -326,59 +472,437 @@ func createCalibrationTab(state *appState) *container.TabItem {
 			if cd, err := time.ParseDuration(cooloffDurationEntry.Text); err == nil {
 				state.cfg.Calibration.CooloffDuration = cd
 			}
-			if err := state.cfg.Save("config.yaml"); err != nil {
-				dialog.ShowError(fmt.Errorf("failed to save config: %w", err), state.window)
+			if err := state.cfg.Save(state.configPath); err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
 			}
 		},
 	}
 
-	// Create calibration points list
-	pointsText := ""
-	for i, point := range state.cfg.Calibration.Points {
-		pointsText += fmt.Sprintf("%d. Heater: %.3f mW, Slope: %.6f V/s (%.3f mV/s)\n",
-			i+1, point.Power, point.Slope, point.Slope*1000)
-	}
-	if pointsText == "" {
-		pointsText = "No calibration points yet. Use 'Add Cal Point' button to add points."
-	}
+	// Editable table of calibration points
+	pointsEditor := newCalibrationPointsEditor(state.cfg.Calibration.Points, state.window)
 
-	pointsLabel := widget.NewLabel(pointsText)
-	pointsLabel.Wrapping = fyne.TextWrapWord
+	// Save points button applies the table to the configuration
+	savePointsBtn := widget.NewButton(i18n.T("Save Points"), func() {
+		if applyCalibrationPoints(state, pointsEditor) {
+			setStatus(state, "Saved %d calibration points", len(state.cfg.Calibration.Points))
+		}
+	})
 
 	// Create calibrate button
-	calibrateBtn := widget.NewButton("Calibrate (Fit Polynomial)", func() {
-		handleCalibrate(state)
+	calibrateBtn := widget.NewButton(i18n.T("Calibrate (Fit Polynomial)"), func() {
+		if applyCalibrationPoints(state, pointsEditor) {
+			handleCalibrate(state)
+		}
 	})
 
 	// Create clear points button
-	clearPointsBtn := widget.NewButton("Clear All Points", func() {
-		dialog.ShowConfirm("Clear Calibration Points",
-			"Are you sure you want to clear all calibration points?",
+	clearPointsBtn := widget.NewButton(i18n.T("Clear All Points"), func() {
+		dialog.ShowConfirm(i18n.T("Clear Calibration Points"),
+			i18n.T("Are you sure you want to clear all calibration points?"),
 			func(confirmed bool) {
 				if confirmed {
-					state.cfg.Calibration.Points = []config.CalibrationPoint{}
-					if err := state.cfg.Save("config.yaml"); err != nil {
-						dialog.ShowError(fmt.Errorf("failed to save config: %w", err), state.window)
-					} else {
-						dialog.ShowInformation("Success", "All calibration points cleared.", state.window)
+					pointsEditor.setPoints(nil)
+					if applyCalibrationPoints(state, pointsEditor) {
+						dialog.ShowInformation(i18n.T("Success"), i18n.T("All calibration points cleared."), state.window)
 					}
 				}
 			}, state.window)
 	})
 
 	// Layout
-	content := container.NewVBox(
-		form,
-		widget.NewSeparator(),
-		widget.NewLabel("Calibration Points:"),
-		pointsLabel,
-		container.NewHBox(calibrateBtn, clearPointsBtn),
+	content := container.NewBorder(
+		container.NewVBox(form, widget.NewSeparator(), widget.NewLabel(i18n.T("Calibration Points:"))),
+		container.NewHBox(savePointsBtn, calibrateBtn, clearPointsBtn),
+		nil, nil,
+		pointsEditor.content,
 	)
 
-	return container.NewTabItem("Calibration", content)
+	return container.NewTabItem(i18n.T("Calibration"), content)
+}
+
+// applyCalibrationPoints stores the points of editor in the configuration
+// and saves it, reporting whether it succeeded.
+func applyCalibrationPoints(state *appState, editor *calibrationPointsEditor) bool {
+	points, err := editor.points()
+	if err != nil {
+		dialog.ShowError(err, state.window)
+		return false
+	}
+	state.cfg.Calibration.Points = points
+	if err := state.cfg.Save(state.configPath); err != nil {
+		dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+		return false
+	}
+	return true
 }
 
 // createMockTab creates the Mock device configuration tab.
+// axisPreset is a named scope axis range offered in the Scope tab.
+type axisPreset struct {
+	name  string
+	value config.AxisRange
+}
+
+const (
+	presetAuto   = "Auto"
+	presetCustom = "Custom"
+)
+
+// readingPresets are fixed reading axis ranges in mV.
+var readingPresets = []axisPreset{
+	{"0 - 100 mV", config.AxisRange{Min: 0, Max: 100}},
+	{"0 - 500 mV", config.AxisRange{Min: 0, Max: 500}},
+	{"0 - 1 V", config.AxisRange{Min: 0, Max: 1000}},
+	{"0 - 3.3 V", config.AxisRange{Min: 0, Max: 3300}},
+}
+
+// derivativePresets are fixed derivative axis ranges in mV/s.
+var derivativePresets = []axisPreset{
+	{"±1 mV/s", config.AxisRange{Min: -1, Max: 1}},
+	{"±10 mV/s", config.AxisRange{Min: -10, Max: 10}},
+	{"±100 mV/s", config.AxisRange{Min: -100, Max: 100}},
+}
+
+// choice is a config value offered in a settings Select by its English name.
+type choice struct {
+	name  string
+	value string
+}
+
+// scopeDisplayModes are the Scope tab's display choices.
+var scopeDisplayModes = []choice{
+	{"Rolling", "roll"},
+	{"Sweep", "sweep"},
+}
+
+// scopeTimeAxes are the Scope tab's time axis choices.
+var scopeTimeAxes = []choice{
+	{"Relative", "relative"},
+	{"Clock", "clock"},
+}
+
+// scopeLayouts are the Scope tab's layout choices.
+var scopeLayouts = []choice{
+	{"Overlay", "overlay"},
+	{"Split", "split"},
+}
+
+// newChoiceSelect creates a Select of the translated names of choices with
+// the choice of value selected, or the first one.
+func newChoiceSelect(choices []choice, value string) *widget.Select {
+	names := make([]string, len(choices))
+	selected := 0
+	for i, c := range choices {
+		names[i] = i18n.T(c.name)
+		if c.value == value {
+			selected = i
+		}
+	}
+	sel := widget.NewSelect(names, nil)
+	sel.SetSelectedIndex(selected)
+	return sel
+}
+
+// choiceValue returns the value of the choice selected in sel.
+func choiceValue(choices []choice, sel *widget.Select) string {
+	return choices[max(sel.SelectedIndex(), 0)].value
+}
+
+// createScopeTab creates the Scope configuration tab, with the display mode,
+// time axis labels, envelope band, heater lane and fixed ranges that lock either Y-axis instead of auto-scaling.
+func createScopeTab(state *appState) *container.TabItem {
+	displaySelect := newChoiceSelect(scopeDisplayModes, state.cfg.Scope.Display)
+	timeAxisSelect := newChoiceSelect(scopeTimeAxes, state.cfg.Scope.TimeAxis)
+	layoutSelect := newChoiceSelect(scopeLayouts, state.cfg.Scope.Layout)
+
+	envelopeCheck := widget.NewCheck(i18n.T("Show min/max band where downsampled"), nil)
+	envelopeCheck.Checked = state.cfg.Scope.Envelope
+
+	heaterLaneCheck := widget.NewCheck(i18n.T("Show heater states below the graph"), nil)
+	heaterLaneCheck.Checked = state.cfg.Scope.HeaterLane
+
+	historyEntry := widget.NewEntry()
+	historyEntry.SetText(state.cfg.Scope.History.String())
+
+	refreshEntry := widget.NewEntry()
+	refreshEntry.SetText(state.cfg.Scope.RefreshInterval.String())
+
+	pointsEntry := widget.NewEntry()
+	pointsEntry.SetText(strconv.Itoa(state.cfg.Scope.MaxDisplayPoints))
+
+	performanceCheck := widget.NewCheck(i18n.T("Show FPS, latency and samples/s over the graph"), nil)
+	performanceCheck.Checked = state.cfg.Scope.ShowPerformance
+
+	readingSelect, readingMin, readingMax := axisRangeWidgets(state.cfg.Scope.ReadingRange, readingPresets)
+	derivSelect, derivMin, derivMax := axisRangeWidgets(state.cfg.Scope.DerivativeRange, derivativePresets)
+
+	form := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: i18n.T("Display"), Widget: displaySelect},
+			{Text: i18n.T("Time Axis"), Widget: timeAxisSelect},
+			{Text: i18n.T("Layout"), Widget: layoutSelect},
+			{Text: i18n.T("Envelope"), Widget: envelopeCheck},
+			{Text: i18n.T("Heater Lane"), Widget: heaterLaneCheck},
+			{Text: i18n.T("History (e.g., 10m, 0s=window only)"), Widget: historyEntry},
+			{Text: i18n.T("Refresh Interval"), Widget: refreshEntry, HintText: i18n.T("Shortest time between redraws, e.g. 16ms (60 FPS) or 100ms on slow machines")},
+			{Text: i18n.T("Display Points"), Widget: pointsEntry, HintText: i18n.T("Points drawn per trace, e.g. 1000; fewer redraw faster (0 = 1000)")},
+			{Text: i18n.T("Performance"), Widget: performanceCheck},
+			{Text: i18n.T("Reading Range"), Widget: readingSelect},
+			{Text: i18n.T("Reading Min (mV)"), Widget: readingMin},
+			{Text: i18n.T("Reading Max (mV)"), Widget: readingMax},
+			{Text: i18n.T("Derivative Range"), Widget: derivSelect},
+			{Text: i18n.T("Derivative Min (mV/s)"), Widget: derivMin},
+			{Text: i18n.T("Derivative Max (mV/s)"), Widget: derivMax},
+		},
+		OnSubmit: func() {
+			reading, err := parseAxisRange(readingSelect.Selected, readingMin.Text, readingMax.Text)
+			if err != nil {
+				dialog.ShowError(i18n.Errorf("reading range: %w", err), state.window)
+				return
+			}
+			derivative, err := parseAxisRange(derivSelect.Selected, derivMin.Text, derivMax.Text)
+			if err != nil {
+				dialog.ShowError(i18n.Errorf("derivative range: %w", err), state.window)
+				return
+			}
+			state.cfg.Scope.Display = choiceValue(scopeDisplayModes, displaySelect)
+			state.cfg.Scope.TimeAxis = choiceValue(scopeTimeAxes, timeAxisSelect)
+			state.cfg.Scope.Layout = choiceValue(scopeLayouts, layoutSelect)
+			state.cfg.Scope.Envelope = envelopeCheck.Checked
+			state.cfg.Scope.HeaterLane = heaterLaneCheck.Checked
+			if h, err := time.ParseDuration(historyEntry.Text); err == nil && h >= 0 {
+				state.cfg.Scope.History = h
+			}
+			if d, err := time.ParseDuration(refreshEntry.Text); err == nil && d >= 0 {
+				state.cfg.Scope.RefreshInterval = d
+			}
+			if n, err := strconv.Atoi(pointsEntry.Text); err == nil && n >= 0 {
+				state.cfg.Scope.MaxDisplayPoints = n
+			}
+			state.cfg.Scope.ShowPerformance = performanceCheck.Checked
+			state.cfg.Scope.ReadingRange = reading
+			state.cfg.Scope.DerivativeRange = derivative
+			if state.scopeWidget != nil {
+				state.scopeWidget.Rescale()
+			}
+			if err := state.cfg.Save(state.configPath); err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+			}
+		},
+	}
+
+	return container.NewTabItem(i18n.T("Scope"), form)
+}
+
+// createScopeThemeTab creates the Scope Theme tab with the application
+// theme and language and the plot background, grid, trace and pulse marker
+// appearance.
+// Colors are "#RRGGBB" or "#RRGGBBAA".
+func createScopeThemeTab(state *appState) *container.TabItem {
+	theme := &state.cfg.Scope
+
+	backgroundEntry := widget.NewEntry()
+	gridColorEntry := widget.NewEntry()
+	labelColorEntry := widget.NewEntry()
+	readingColorEntry := widget.NewEntry()
+	derivativeColorEntry := widget.NewEntry()
+	voltageColorEntry := widget.NewEntry()
+	heaterPowerColorEntry := widget.NewEntry()
+	markerColorEntry := widget.NewEntry()
+	showPalette := func(p config.ScopePalette) {
+		backgroundEntry.SetText(p.Background)
+		gridColorEntry.SetText(p.Grid)
+		labelColorEntry.SetText(p.Label)
+		readingColorEntry.SetText(p.Reading)
+		derivativeColorEntry.SetText(p.Derivative)
+		voltageColorEntry.SetText(p.Voltage)
+		heaterPowerColorEntry.SetText(p.HeaterPower)
+		markerColorEntry.SetText(p.PulseMarkers)
+	}
+	showPalette(theme.Palette())
+
+	// Choosing a theme shows its palette, applied with the other settings
+	themeSelect := newChoiceSelect(themeChoices, state.cfg.UI.Theme)
+	themeSelect.OnChanged = func(string) {
+		showPalette(themePalette(fyne.CurrentApp(), choiceValue(themeChoices, themeSelect)))
+	}
+
+	languages := languageChoices()
+	languageSelect := newChoiceSelect(languages, state.cfg.UI.Language)
+
+	powerUnits, readingUnits := unitChoices(units.PowerUnits), unitChoices(units.VoltageUnits)
+	powerUnitSelect := newChoiceSelect(powerUnits, state.cfg.UI.PowerUnit)
+	readingUnitSelect := newChoiceSelect(readingUnits, state.cfg.UI.ReadingUnit)
+
+	gridWidthEntry := widget.NewEntry()
+	gridWidthEntry.SetText(strconv.FormatFloat(float64(theme.Grid.Width), 'g', -1, 32))
+
+	hDivEntry := widget.NewEntry()
+	hDivEntry.SetText(strconv.Itoa(theme.Grid.HDivisions))
+
+	vDivEntry := widget.NewEntry()
+	vDivEntry.SetText(strconv.Itoa(theme.Grid.VDivisions))
+
+	markersCheck := widget.NewCheck(i18n.T("Show pulse start and end lines"), nil)
+	markersCheck.SetChecked(theme.PulseMarkers.Show)
+
+	markerWidthEntry := widget.NewEntry()
+	markerWidthEntry.SetText(strconv.FormatFloat(float64(theme.PulseMarkers.Width), 'g', -1, 32))
+
+	form := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: i18n.T("Theme"), Widget: themeSelect},
+			{Text: i18n.T("Language"), Widget: languageSelect, HintText: i18n.T("Takes effect after a restart")},
+			{Text: i18n.T("Power Unit"), Widget: powerUnitSelect},
+			{Text: i18n.T("Reading Unit"), Widget: readingUnitSelect, HintText: i18n.T("Slopes are shown per second of it")},
+			{Text: i18n.T("Background"), Widget: backgroundEntry},
+			{Text: i18n.T("Grid Color"), Widget: gridColorEntry},
+			{Text: i18n.T("Grid Width"), Widget: gridWidthEntry},
+			{Text: i18n.T("Value Divisions"), Widget: hDivEntry},
+			{Text: i18n.T("Time Divisions"), Widget: vDivEntry},
+			{Text: i18n.T("Label Color"), Widget: labelColorEntry},
+			{Text: i18n.T("Reading Color"), Widget: readingColorEntry},
+			{Text: i18n.T("Derivative Color"), Widget: derivativeColorEntry},
+			{Text: i18n.T("Voltage Color"), Widget: voltageColorEntry},
+			{Text: i18n.T("Heater Power Color"), Widget: heaterPowerColorEntry},
+			{Text: i18n.T("Pulse Markers"), Widget: markersCheck},
+			{Text: i18n.T("Marker Color"), Widget: markerColorEntry},
+			{Text: i18n.T("Marker Width"), Widget: markerWidthEntry},
+		},
+		OnSubmit: func() {
+			setColor := func(dst *string, text string) {
+				if colorPattern.MatchString(text) {
+					*dst = text
+				}
+			}
+			setColor(&theme.Background, backgroundEntry.Text)
+			setColor(&theme.Grid.Color, gridColorEntry.Text)
+			setColor(&theme.Grid.LabelColor, labelColorEntry.Text)
+			setColor(&theme.Reading.Color, readingColorEntry.Text)
+			setColor(&theme.Derivative.Color, derivativeColorEntry.Text)
+			setColor(&theme.Voltage.Color, voltageColorEntry.Text)
+			setColor(&theme.HeaterPower.Color, heaterPowerColorEntry.Text)
+			setColor(&theme.PulseMarkers.Color, markerColorEntry.Text)
+			if w, err := strconv.ParseFloat(gridWidthEntry.Text, 32); err == nil && w > 0 {
+				theme.Grid.Width = float32(w)
+			}
+			if w, err := strconv.ParseFloat(markerWidthEntry.Text, 32); err == nil && w > 0 {
+				theme.PulseMarkers.Width = float32(w)
+			}
+			if n, err := strconv.Atoi(hDivEntry.Text); err == nil && n > 0 {
+				theme.Grid.HDivisions = n
+			}
+			if n, err := strconv.Atoi(vDivEntry.Text); err == nil && n > 0 {
+				theme.Grid.VDivisions = n
+			}
+			theme.PulseMarkers.Show = markersCheck.Checked
+
+			if name := choiceValue(themeChoices, themeSelect); name != state.cfg.UI.Theme {
+				state.cfg.UI.Theme = name
+				applyTheme(fyne.CurrentApp(), name)
+			}
+			if code := choiceValue(languages, languageSelect); code != state.cfg.UI.Language {
+				state.cfg.UI.Language = code
+				dialog.ShowInformation(i18n.T("Language"), i18n.T("Restart the application to change the language."), state.window)
+			}
+			power, reading := choiceValue(powerUnits, powerUnitSelect), choiceValue(readingUnits, readingUnitSelect)
+			if power != state.cfg.UI.PowerUnit || reading != state.cfg.UI.ReadingUnit {
+				state.cfg.UI.PowerUnit, state.cfg.UI.ReadingUnit = power, reading
+				applyUnits(power, reading)
+				if state.liveReadout != nil {
+					state.liveReadout.Refresh()
+					state.pulseLog.Refresh()
+					state.sessionStats.Refresh()
+				}
+			}
+			if state.scopeWidget != nil {
+				state.scopeWidget.Refresh()
+			}
+			if err := state.cfg.Save(state.configPath); err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+			}
+		},
+	}
+
+	return container.NewTabItem(i18n.T("Scope Theme"), container.NewVScroll(form))
+}
+
+// colorPattern matches the "#RRGGBB" and "#RRGGBBAA" colors of the scope
+// configuration.
+var colorPattern = regexp.MustCompile(`^#([0-9A-Fa-f]{6}|[0-9A-Fa-f]{8})$`)
+
+// axisRangeWidgets creates the preset selector and min/max entries for one
+// scope axis. Picking a preset fills in the entries; editing them switches
+// to Custom.
+func axisRangeWidgets(current config.AxisRange, presets []axisPreset) (*widget.Select, *widget.Entry, *widget.Entry) {
+	minEntry := widget.NewEntry()
+	maxEntry := widget.NewEntry()
+
+	autoName, customName := i18n.T(presetAuto), i18n.T(presetCustom)
+	options := []string{autoName}
+	for _, p := range presets {
+		options = append(options, p.name)
+	}
+	options = append(options, customName)
+
+	var updating bool
+	sel := widget.NewSelect(options, func(name string) {
+		for _, p := range presets {
+			if p.name == name {
+				updating = true
+				minEntry.SetText(strconv.FormatFloat(p.value.Min, 'g', -1, 64))
+				maxEntry.SetText(strconv.FormatFloat(p.value.Max, 'g', -1, 64))
+				updating = false
+			}
+		}
+	})
+	custom := func(string) {
+		if !updating && sel.Selected != customName {
+			sel.SetSelected(customName)
+		}
+	}
+	minEntry.OnChanged = custom
+	maxEntry.OnChanged = custom
+
+	selected := autoName
+	if current.Fixed() {
+		selected = customName
+		for _, p := range presets {
+			if p.value == current {
+				selected = p.name
+			}
+		}
+		minEntry.SetText(strconv.FormatFloat(current.Min, 'g', -1, 64))
+		maxEntry.SetText(strconv.FormatFloat(current.Max, 'g', -1, 64))
+	}
+	updating = true
+	sel.SetSelected(selected)
+	updating = false
+
+	return sel, minEntry, maxEntry
+}
+
+// parseAxisRange returns the range chosen in the Scope tab. Auto returns
+// the zero range, which means auto-scaling.
+func parseAxisRange(selected, minText, maxText string) (config.AxisRange, error) {
+	if selected == i18n.T(presetAuto) || selected == "" {
+		return config.AxisRange{}, nil
+	}
+	lo, err := strconv.ParseFloat(minText, 64)
+	if err != nil {
+		return config.AxisRange{}, i18n.Errorf("invalid minimum %q: %w", minText, err)
+	}
+	hi, err := strconv.ParseFloat(maxText, 64)
+	if err != nil {
+		return config.AxisRange{}, i18n.Errorf("invalid maximum %q: %w", maxText, err)
+	}
+	r := config.AxisRange{Min: lo, Max: hi}
+	if !r.Fixed() {
+		return config.AxisRange{}, i18n.Errorf("maximum %g must be above minimum %g", hi, lo)
+	}
+	return r, nil
+}
+
 func createMockTab(state *appState) *container.TabItem {
 	biasEntry := widget.NewEntry()
 	biasEntry.SetText(fmt.Sprintf("%.3f", state.cfg.Mock.Bias))
@@ -400,12 +924,12 @@ func createMockTab(state *appState) *container.TabItem {
 
 	form := &widget.Form{
 		Items: []*widget.FormItem{
-			{Text: "Bias (V)", Widget: biasEntry},
-			{Text: "Noise Level (V)", Widget: noiseLevelEntry},
-			{Text: "Laser Power (mW)", Widget: laserPowerEntry},
-			{Text: "Laser Duration", Widget: laserDurationEntry},
-			{Text: "Laser Period", Widget: laserPeriodEntry},
-			{Text: "Sample Rate", Widget: sampleRateEntry},
+			{Text: i18n.T("Bias (V)"), Widget: biasEntry},
+			{Text: i18n.T("Noise Level (V)"), Widget: noiseLevelEntry},
+			{Text: i18n.T("Laser Power (mW)"), Widget: laserPowerEntry},
+			{Text: i18n.T("Laser Duration"), Widget: laserDurationEntry},
+			{Text: i18n.T("Laser Period"), Widget: laserPeriodEntry},
+			{Text: i18n.T("Sample Rate"), Widget: sampleRateEntry},
 		},
 		OnSubmit: func() {
 			if bias, err := strconv.ParseFloat(biasEntry.Text, 64); err == nil {
@@ -426,11 +950,11 @@ func createMockTab(state *appState) *container.TabItem {
 			if sr, err := time.ParseDuration(sampleRateEntry.Text); err == nil {
 				state.cfg.Mock.SampleRate = sr
 			}
-			if err := state.cfg.Save("config.yaml"); err != nil {
-				dialog.ShowError(fmt.Errorf("failed to save config: %w", err), state.window)
+			if err := state.cfg.Save(state.configPath); err != nil {
+				dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
 			}
 		},
 	}
 
-	return container.NewTabItem("Mock", form)
+	return container.NewTabItem(i18n.T("Mock"), form)
 }
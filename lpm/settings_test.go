@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAxisRange(t *testing.T) {
+	r, err := parseAxisRange(presetAuto, "1", "2")
+	require.NoError(t, err)
+	assert.False(t, r.Fixed(), "Auto ignores the entries")
+
+	r, err = parseAxisRange(presetCustom, "-5", "20")
+	require.NoError(t, err)
+	assert.Equal(t, config.AxisRange{Min: -5, Max: 20}, r)
+
+	_, err = parseAxisRange(presetCustom, "10", "10")
+	assert.Error(t, err, "empty range")
+
+	_, err = parseAxisRange(presetCustom, "x", "10")
+	assert.Error(t, err)
+}
+
+func TestParseHeaterResistances(t *testing.T) {
+	heaters, err := parseHeaterResistances(" 2694, 511,240.8 ,100")
+	require.NoError(t, err)
+	assert.Equal(t, []config.HeaterConfig{{Resistance: 2694}, {Resistance: 511}, {Resistance: 240.8}, {Resistance: 100}}, heaters)
+	assert.Equal(t, "2694, 511, 240.8, 100", formatHeaterResistances(heaters))
+
+	_, err = parseHeaterResistances("2694, , 240")
+	assert.ErrorContains(t, err, "heater 2")
+	_, err = parseHeaterResistances("2694, -5")
+	assert.Error(t, err)
+	_, err = parseHeaterResistances("1,1,1,1,1,1,1,1,1")
+	assert.Error(t, err, "more than a device can have")
+}
+
+func TestChoiceSelect(t *testing.T) {
+	test.NewTempApp(t)
+	sel := newChoiceSelect(scopeLayouts, "split")
+	assert.Equal(t, []string{"Overlay", "Split"}, sel.Options)
+	assert.Equal(t, "split", choiceValue(scopeLayouts, sel))
+
+	sel = newChoiceSelect(scopeLayouts, "unknown")
+	assert.Equal(t, "overlay", choiceValue(scopeLayouts, sel), "first choice for unknown values")
+
+	languages := languageChoices()
+	assert.Equal(t, choice{"System", "system"}, languages[0])
+	assert.Contains(t, languages, choice{"Deutsch", "de"})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/itohio/golpm/pkg/meter"
+)
+
+// statusInterval is how often the status bar re-reads the link counters.
+const statusInterval = time.Second
+
+// statusBar is the line below the graph showing the connection, the health
+// of the measurement chain and the latest status message.
+type statusBar struct {
+	port     *widget.Label
+	firmware *widget.Label
+	rate     *widget.Label
+	dropped  *widget.Label
+	meter    *widget.Label
+	dataLog  *widget.Label // Size and duration of the data log, hidden while not logging
+	message  *widget.Label
+	content  fyne.CanvasObject
+
+	lastParsed uint64    // Samples parsed at the last update
+	lastTime   time.Time // Time of the last update, zero while disconnected
+}
+
+// newStatusBar creates a status bar showing no device.
+func newStatusBar() *statusBar {
+	b := &statusBar{
+		port:     widget.NewLabel(""),
+		firmware: widget.NewLabel(""),
+		rate:     widget.NewLabel(""),
+		dropped:  widget.NewLabel(""),
+		meter:    widget.NewLabel(""),
+		dataLog:  widget.NewLabel(""),
+		message:  widget.NewLabel(""),
+	}
+	b.dataLog.Importance = widget.DangerImportance
+	b.dataLog.Hide()
+	b.message.Truncation = fyne.TextTruncateEllipsis
+	b.content = container.NewBorder(widget.NewSeparator(), nil,
+		container.NewHBox(b.port, widget.NewSeparator(), b.firmware, widget.NewSeparator(), b.rate,
+			widget.NewSeparator(), b.dropped, widget.NewSeparator(), b.meter, widget.NewSeparator(), b.dataLog),
+		nil, b.message)
+	return b
+}
+
+// setStatus logs a message and shows it in the status bar in the GUI
+// language. Call it on the main thread.
+func setStatus(state *appState, format string, args ...any) {
+	log.Printf(format, args...)
+	if state.status != nil {
+		state.status.message.SetText(i18n.Tf(format, args...))
+	}
+}
+
+// runStatusBar updates the status bar every statusInterval. It never returns.
+func runStatusBar(state *appState) {
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		fyne.Do(func() { updateStatusBar(state, time.Now()) })
+	}
+}
+
+// updateStatusBar shows the current connection and chain state. Call it on
+// the main thread.
+func updateStatusBar(state *appState, now time.Time) {
+	b := state.status
+	updateDataLogStatus(state)
+	if state.device == nil || !state.device.IsConnected() {
+		b.port.SetText(i18n.T("Disconnected"))
+		b.firmware.SetText("-")
+		b.rate.SetText("-")
+		b.dropped.SetText("-")
+		b.meter.SetText(i18n.T("Idle"))
+		b.lastTime = time.Time{}
+		return
+	}
+
+	port := state.cfg.Serial.Port
+	switch {
+	case state.replay != nil:
+		port = i18n.T("Replay")
+	case state.useMock:
+		port = i18n.T("Mock")
+	}
+	b.port.SetText(port)
+	b.firmware.SetText(formatFirmware(state.device.Info()))
+
+	stats := state.device.Stats()
+	if b.lastTime.IsZero() || stats.Parsed < b.lastParsed {
+		b.rate.SetText("- Hz")
+	} else {
+		b.rate.SetText(formatRate(stats.Parsed-b.lastParsed, now.Sub(b.lastTime)))
+	}
+	b.lastParsed, b.lastTime = stats.Parsed, now
+	b.dropped.SetText(i18n.Tf("Dropped %d, lost %d", stats.Dropped, stats.Lost))
+
+	var active *meter.Pulse
+	if state.powerMeter != nil {
+		active = state.powerMeter.ActivePulse()
+	}
+	b.meter.SetText(meterStatus(state.paused, state.stalled, active))
+}
+
+// formatFirmware describes the firmware from the device's handshake.
+func formatFirmware(info lpm.Info) string {
+	switch {
+	case info.FirmwareVersion != "":
+		return "fw " + info.FirmwareVersion
+	case info.ProtocolVersion > 0:
+		return i18n.Tf("protocol v%d", info.ProtocolVersion)
+	default:
+		return i18n.T("legacy firmware")
+	}
+}
+
+// formatRate formats the rate of n samples received over elapsed.
+func formatRate(n uint64, elapsed time.Duration) string {
+	if elapsed <= 0 {
+		return "- Hz"
+	}
+	return fmt.Sprintf("%.1f Hz", float64(n)/elapsed.Seconds())
+}
+
+// meterStatus describes what the measurement chain is doing.
+func meterStatus(paused, stalled bool, active *meter.Pulse) string {
+	switch {
+	case paused:
+		return i18n.T("Paused")
+	case stalled:
+		return i18n.T("No samples")
+	case active == nil:
+		return i18n.T("Waiting for pulse")
+	case active.State == meter.PulseStateFitting:
+		return i18n.T("Fitting pulse")
+	default:
+		return i18n.Tf("Measuring pulse #%d", active.ID)
+	}
+}
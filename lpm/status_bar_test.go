@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/stretchr/testify/assert"
+)
+
+// statusDevice reports fixed info and counters; other Device methods are not used.
+type statusDevice struct {
+	lpm.Device
+	info  lpm.Info
+	stats lpm.Stats
+}
+
+func (d *statusDevice) IsConnected() bool { return true }
+func (d *statusDevice) Info() lpm.Info    { return d.info }
+func (d *statusDevice) Stats() lpm.Stats  { return d.stats }
+
+func TestFormatFirmware(t *testing.T) {
+	assert.Equal(t, "fw 0.2.0", formatFirmware(lpm.Info{ProtocolVersion: 5, FirmwareVersion: "0.2.0"}))
+	assert.Equal(t, "protocol v1", formatFirmware(lpm.Info{ProtocolVersion: 1}))
+	assert.Equal(t, "legacy firmware", formatFirmware(lpm.Info{}))
+}
+
+func TestMeterStatus(t *testing.T) {
+	assert.Equal(t, "Paused", meterStatus(true, true, nil))
+	assert.Equal(t, "No samples", meterStatus(false, true, nil))
+	assert.Equal(t, "Waiting for pulse", meterStatus(false, false, nil))
+	assert.Equal(t, "Fitting pulse", meterStatus(false, false, &meter.Pulse{ID: 4, State: meter.PulseStateFitting}))
+	assert.Equal(t, "Measuring pulse #4", meterStatus(false, false, &meter.Pulse{ID: 4, State: meter.PulseStateUpdating}))
+}
+
+func TestUpdateStatusBar(t *testing.T) {
+	test.NewTempApp(t)
+	state := &appState{cfg: config.Default(), status: newStatusBar()}
+	now := time.Now()
+
+	updateStatusBar(state, now)
+	assert.Equal(t, "Disconnected", state.status.port.Text)
+
+	device := &statusDevice{info: lpm.Info{FirmwareVersion: "0.2.0"}, stats: lpm.Stats{Parsed: 100, Dropped: 2}}
+	state.device = device
+	state.cfg.Serial.Port = "COM3"
+	updateStatusBar(state, now)
+	assert.Equal(t, "COM3", state.status.port.Text)
+	assert.Equal(t, "fw 0.2.0", state.status.firmware.Text)
+	assert.Equal(t, "- Hz", state.status.rate.Text, "no rate before a second reading")
+	assert.Equal(t, "Dropped 2, lost 0", state.status.dropped.Text)
+
+	// The rate follows the parsed counter
+	device.stats.Parsed = 150
+	updateStatusBar(state, now.Add(time.Second))
+	assert.Equal(t, "50.0 Hz", state.status.rate.Text)
+
+	setStatus(state, "Connected to serial port: %s", "COM3")
+	assert.Equal(t, "Connected to serial port: COM3", state.status.message.Text)
+}
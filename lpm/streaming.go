@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/scope"
+)
+
+// handlePauseToggle pauses or resumes the device's sample output. The graph
+// freezes while paused and the link is quiet; heaters stay controllable.
+func handlePauseToggle(state *appState) {
+	if state.device == nil {
+		return
+	}
+
+	paused := !state.paused
+	if err := state.device.SetStreaming(!paused); err != nil {
+		dialog.ShowError(i18n.Errorf("failed to pause the sample output: %w", err), state.window)
+		return
+	}
+
+	state.paused = paused
+	updatePauseButton(state)
+}
+
+// updatePauseButton shows the resume icon while the output is paused.
+func updatePauseButton(state *appState) {
+	if state.pauseBtn == nil {
+		return
+	}
+	if state.paused {
+		state.pauseBtn.SetIcon(theme.MediaPlayIcon())
+	} else {
+		state.pauseBtn.SetIcon(theme.MediaPauseIcon())
+	}
+}
+
+// handleFreezeToggle freezes or resumes the graph. Unlike pausing, the
+// device keeps streaming, so pulses are still measured and recorded.
+func handleFreezeToggle(state *appState) {
+	state.scopeWidget.SetFrozen(!state.scopeWidget.Frozen())
+	updateFreezeButton(state)
+}
+
+// updateFreezeButton shows whether the graph is frozen.
+func updateFreezeButton(state *appState) {
+	if state.freezeBtn == nil {
+		return
+	}
+	if state.scopeWidget.Frozen() {
+		state.freezeBtn.SetIcon(theme.VisibilityOffIcon())
+	} else {
+		state.freezeBtn.SetIcon(theme.VisibilityIcon())
+	}
+}
+
+// handleTriggerMode switches the graph between rolling and pulse-triggered
+// captures. Arm is only needed once a single capture has stopped.
+func handleTriggerMode(state *appState, name string) {
+	for _, m := range scope.TriggerModes {
+		if i18n.T(m.String()) != name {
+			continue
+		}
+		state.scopeWidget.SetTriggerMode(m)
+		updateArmButton(state, m)
+		return
+	}
+}
+
+// updateArmButton enables Arm in single capture mode.
+func updateArmButton(state *appState, m scope.TriggerMode) {
+	if m == scope.TriggerSingle {
+		state.armBtn.Enable()
+	} else {
+		state.armBtn.Disable()
+	}
+}
+
+// handleSpectrumToggle shows or hides the spectrum panel below the graph.
+func handleSpectrumToggle(state *appState) {
+	if state.spectrumWidget.Visible() {
+		state.spectrumWidget.Hide()
+	} else {
+		state.spectrumWidget.Show()
+		state.scopeWidget.Refresh()
+	}
+	state.graphSplit.Refresh()
+}
+
+// handleReadoutToggle shows or hides the live readout beside the graph.
+func handleReadoutToggle(state *appState) {
+	if state.liveReadout.Visible() {
+		state.liveReadout.Hide()
+	} else {
+		state.liveReadout.Show()
+	}
+	state.window.Content().Refresh()
+}
+
+// handlePulseLogToggle shows or hides the pulse table beside the graph.
+func handlePulseLogToggle(state *appState) {
+	if state.pulseLog.Visible() {
+		state.pulseLog.Hide()
+	} else {
+		state.pulseLog.Show()
+	}
+	state.window.Content().Refresh()
+}
+
+// handleSessionStatsToggle shows or hides the session statistics beside the
+// graph.
+func handleSessionStatsToggle(state *appState) {
+	if state.sessionStats.Visible() {
+		state.sessionStats.Hide()
+	} else {
+		state.sessionStats.SetStats(currentSessionStats(state))
+		state.sessionStats.Show()
+	}
+	state.window.Content().Refresh()
+}
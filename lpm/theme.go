@@ -0,0 +1,61 @@
+package main
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"github.com/itohio/golpm/pkg/config"
+)
+
+// themeChoices are the application themes offered in the settings.
+var themeChoices = []choice{
+	{"System", "system"},
+	{"Dark", "dark"},
+	{"Light", "light"},
+}
+
+// variantTheme is the default theme fixed to one variant, ignoring the OS
+// preference.
+type variantTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+}
+
+func (t variantTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	return t.Theme.Color(name, t.variant)
+}
+
+// appTheme returns the theme configured by name: "dark", "light" or
+// "system" (or anything else) to follow the OS.
+func appTheme(name string) fyne.Theme {
+	switch name {
+	case "dark":
+		return variantTheme{Theme: theme.DefaultTheme(), variant: theme.VariantDark}
+	case "light":
+		return variantTheme{Theme: theme.DefaultTheme(), variant: theme.VariantLight}
+	default:
+		return theme.DefaultTheme()
+	}
+}
+
+// applyTheme sets the configured theme of the application.
+func applyTheme(a fyne.App, name string) {
+	a.Settings().SetTheme(appTheme(name))
+}
+
+// themePalette returns the scope palette suiting the theme configured by
+// name; "system" follows the OS variant reported by a.
+func themePalette(a fyne.App, name string) config.ScopePalette {
+	variant := a.Settings().ThemeVariant()
+	switch name {
+	case "dark":
+		variant = theme.VariantDark
+	case "light":
+		variant = theme.VariantLight
+	}
+	if variant == theme.VariantLight {
+		return config.LightScopePalette
+	}
+	return config.DarkScopePalette
+}
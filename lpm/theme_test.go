@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppTheme(t *testing.T) {
+	dark := appTheme("dark")
+	light := appTheme("light")
+	assert.Equal(t, theme.DefaultTheme().Color(theme.ColorNameBackground, theme.VariantDark), dark.Color(theme.ColorNameBackground, theme.VariantLight), "dark ignores the OS variant")
+	assert.Equal(t, theme.DefaultTheme().Color(theme.ColorNameBackground, theme.VariantLight), light.Color(theme.ColorNameBackground, theme.VariantDark), "light ignores the OS variant")
+	assert.Equal(t, theme.DefaultTheme(), appTheme("system"))
+}
+
+func TestThemePalette(t *testing.T) {
+	a := test.NewTempApp(t)
+	assert.Equal(t, config.DarkScopePalette, themePalette(a, "dark"))
+	assert.Equal(t, config.LightScopePalette, themePalette(a, "light"))
+
+	want := config.DarkScopePalette
+	if a.Settings().ThemeVariant() == theme.VariantLight {
+		want = config.LightScopePalette
+	}
+	assert.Equal(t, want, themePalette(a, "system"), "system follows the OS variant")
+}
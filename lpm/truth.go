@@ -0,0 +1,50 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/itohio/golpm/pkg/scope"
+)
+
+// truthBuffer collects the mocked device's ground truth for the scope overlay.
+// Only power changes are kept, plus the latest point to extend the trace.
+type truthBuffer struct {
+	mu     sync.Mutex
+	points []scope.TruthPoint
+}
+
+// collect reads truth until the channel is closed on disconnect.
+func (b *truthBuffer) collect(truth <-chan lpm.TruthSample) {
+	for t := range truth {
+		b.add(scope.TruthPoint{Time: t.Timestamp, Power: t.LaserPower / 1000}) // mW to W
+	}
+}
+
+// add appends p, or moves the latest point forward if the power is unchanged.
+func (b *truthBuffer) add(p scope.TruthPoint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(b.points)
+	if n >= 2 && b.points[n-1].Power == p.Power && b.points[n-2].Power == p.Power {
+		b.points[n-1].Time = p.Time
+		return
+	}
+	b.points = append(b.points, p)
+}
+
+// since drops points that ended before t and returns a copy of the rest.
+// The last point before t is kept, as it still holds at t.
+func (b *truthBuffer) since(t time.Time) []scope.TruthPoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	first := 0
+	for first+1 < len(b.points) && !b.points[first+1].Time.After(t) {
+		first++
+	}
+	b.points = append(b.points[:0], b.points[first:]...)
+	return append([]scope.TruthPoint(nil), b.points...)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// Terminal dashboard layout
+const (
+	tuiWidth   = 72                     // Columns of the sparkline
+	tuiPulses  = 8                      // Newest pulses listed
+	tuiRefresh = 250 * time.Millisecond // Between redraws
+)
+
+// ANSI escape sequences used by the dashboard
+const (
+	ansiAltScreen  = "\x1b[?1049h" // Switch to the alternate screen
+	ansiMainScreen = "\x1b[?1049l" // Back to the main screen and its scroll-back
+	ansiClear      = "\x1b[H\x1b[2J"
+)
+
+// sparkBlocks are the sparkline levels, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// runTUI runs the measurement chain like runHeadless, but draws a live
+// dashboard in the terminal instead of printing lines: a sparkline of the
+// reading, the current power, the newest pulses and the heater states.
+// Commands are typed as a line: 1-3 toggle a heater, 0 turns all off, q quits.
+// Log messages are shown in the dashboard's status line.
+func runTUI(cfg *config.Config, opts headlessOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	device, err := connectHeadless(ctx, cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	dashboard := &tuiDashboard{device: device}
+	log.SetOutput(dashboard)
+	defer log.SetOutput(os.Stderr)
+
+	powerMeter := meter.New(cfg)
+	powerMeter.OnUpdate(dashboard.update)
+
+	meterDone := make(chan struct{})
+	go func() {
+		defer close(meterDone)
+		powerMeter.ProcessSamples(sample.NewPipeline(cfg, opts.useStatistics, device.Samples()))
+	}()
+
+	quit := make(chan struct{})
+	go func() {
+		// Without input, e.g. stdin from /dev/null, the dashboard runs until interrupted
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if dashboard.command(scanner.Text()) {
+				close(quit)
+				return
+			}
+		}
+	}()
+
+	fmt.Print(ansiAltScreen)
+	ticker := time.NewTicker(tuiRefresh)
+	defer ticker.Stop()
+loop:
+	for {
+		var screen strings.Builder
+		screen.WriteString(ansiClear)
+		dashboard.render(&screen)
+		fmt.Print(screen.String())
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			break loop
+		case <-quit:
+			break loop
+		case <-meterDone:
+			log.SetOutput(os.Stderr)
+			log.Printf("Device stream ended")
+			break loop
+		}
+	}
+	fmt.Print(ansiMainScreen)
+
+	device.Close()
+	<-meterDone
+	return nil
+}
+
+// tuiDashboard holds the latest meter update and draws the dashboard.
+// It is also the log's writer, keeping the last message for the status line.
+type tuiDashboard struct {
+	device lpm.Device
+
+	mu          sync.Mutex
+	samples     []sample.Sample
+	derivatives []float64
+	pulses      []meter.Pulse
+	status      string // Last log message or command result
+}
+
+// update is the meter callback. The meter passes copies, so they are kept
+// as they are.
+func (d *tuiDashboard) update(samples []sample.Sample, derivatives []float64, pulses []meter.Pulse) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.samples = samples
+	d.derivatives = derivatives
+	d.pulses = pulses
+}
+
+// Write keeps the last line written to the log.
+func (d *tuiDashboard) Write(p []byte) (int, error) {
+	line := strings.TrimSpace(string(p))
+	if i := strings.LastIndexByte(line, '\n'); i >= 0 {
+		line = line[i+1:]
+	}
+	d.mu.Lock()
+	d.status = line
+	d.mu.Unlock()
+	return len(p), nil
+}
+
+// command executes a command line and reports whether it quits.
+func (d *tuiDashboard) command(line string) bool {
+	heaters := d.heaters()
+	switch cmd := strings.TrimSpace(line); {
+	case cmd == "q" || cmd == "quit":
+		return true
+	case cmd == "0":
+		d.setHeaters(make([]bool, len(heaters)))
+	case len(cmd) == 1 && cmd[0] >= '1' && int(cmd[0]-'1') < len(heaters):
+		i := int(cmd[0] - '1')
+		heaters[i] = !heaters[i]
+		d.setHeaters(heaters)
+	case cmd == "":
+	default:
+		log.Printf("Unknown command %q: 1-%d toggle a heater, 0 turns all off, q quits", cmd, len(heaters))
+	}
+	return false
+}
+
+// heaters returns a copy of the heater states reported with the newest
+// sample, one per heater of the device.
+func (d *tuiDashboard) heaters() []bool {
+	heaters := make([]bool, d.device.Info().HeaterCount())
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.samples) > 0 {
+		copy(heaters, d.samples[len(d.samples)-1].Heaters)
+	}
+	return heaters
+}
+
+// setHeaters switches the heaters, logging the result.
+func (d *tuiDashboard) setHeaters(heaters []bool) {
+	if err := d.device.SetHeaters(heaters...); err != nil {
+		log.Printf("Failed to set heaters: %v", err)
+		return
+	}
+	log.Printf("Heaters set to %s", heaterStates(heaters))
+}
+
+// render draws the dashboard to w.
+func (d *tuiDashboard) render(w io.Writer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.samples) == 0 {
+		fmt.Fprintf(w, "golpm  waiting for samples...\n\n%s\n", d.status)
+		return
+	}
+	last := d.samples[len(d.samples)-1]
+	span := last.Timestamp.Sub(d.samples[0].Timestamp)
+
+	readings := make([]float64, len(d.samples))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, s := range d.samples {
+		readings[i] = s.Reading
+		lo, hi = min(lo, s.Reading), max(hi, s.Reading)
+	}
+	derivative := 0.0
+	if len(d.derivatives) > 0 {
+		derivative = d.derivatives[len(d.derivatives)-1]
+	}
+
+	fmt.Fprintf(w, "golpm  %s  last %s\n\n", last.Timestamp.Format("15:04:05"), span.Round(time.Second))
+	fmt.Fprintf(w, "%.3f mV\n%s\n%.3f mV\n\n", hi*1000, sparkline(readings, tuiWidth), lo*1000)
+	fmt.Fprintf(w, "Reading  %.3f mV   Slope %.3f mV/s   Heater %.3f mW\n", last.Reading*1000, derivative*1000, last.HeaterPower*1000)
+	fmt.Fprintf(w, "Power    %s\n", currentPower(d.pulses))
+	fmt.Fprintf(w, "Heaters  %s\n\n", heaterStates(last.Heaters))
+
+	fmt.Fprintln(w, "Pulses")
+	if len(d.pulses) == 0 {
+		fmt.Fprintln(w, "  none yet")
+	}
+	for i := len(d.pulses) - 1; i >= max(len(d.pulses)-tuiPulses, 0); i-- {
+		p := d.pulses[i]
+		state := ""
+		if p.State != meter.PulseStateFinalized {
+			state = "  measuring"
+		}
+		fmt.Fprintf(w, "  #%-3d %s  %9.3f mW  %6.2fs  R² %.3f%s\n", p.ID, p.StartTime.Format("15:04:05"),
+			p.AvgPower*1000, p.EndTime.Sub(p.StartTime).Seconds(), p.RSquared, state)
+	}
+
+	fmt.Fprintf(w, "\n%s\n1-%d + Enter toggle a heater, 0 all off, q quit\n", d.status, max(len(last.Heaters), 1))
+}
+
+// currentPower describes the power of the pulse being measured, or else of
+// the last pulse.
+func currentPower(pulses []meter.Pulse) string {
+	if len(pulses) == 0 {
+		return "-"
+	}
+	p := pulses[len(pulses)-1]
+	if p.State == meter.PulseStateFinalized {
+		return fmt.Sprintf("%.3f mW (last pulse #%d)", p.AvgPower*1000, p.ID)
+	}
+	return fmt.Sprintf("%.3f mW (measuring pulse #%d)", p.AvgPower*1000, p.ID)
+}
+
+// heaterStates formats heater states, e.g. "[1] on  [2] off  [3] off".
+func heaterStates(heaters []bool) string {
+	states := make([]string, len(heaters))
+	for i, on := range heaters {
+		state := "off"
+		if on {
+			state = "on"
+		}
+		states[i] = fmt.Sprintf("[%d] %s", i+1, state)
+	}
+	return strings.Join(states, "  ")
+}
+
+// sparkline draws values as a line of block characters at most width wide,
+// averaging the values that fall into the same column and scaling the range
+// of the averages to the block heights.
+func sparkline(values []float64, width int) string {
+	columns := min(len(values), width)
+	if columns == 0 {
+		return ""
+	}
+
+	means := make([]float64, columns)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for c := range means {
+		from, to := c*len(values)/columns, (c+1)*len(values)/columns
+		sum := 0.0
+		for _, v := range values[from:to] {
+			sum += v
+		}
+		means[c] = sum / float64(to-from)
+		lo, hi = min(lo, means[c]), max(hi, means[c])
+	}
+
+	line := make([]rune, columns)
+	for c, m := range means {
+		level := 0
+		if hi > lo {
+			level = int(math.Round((m - lo) / (hi - lo) * float64(len(sparkBlocks)-1)))
+		}
+		line[c] = sparkBlocks[level]
+	}
+	return string(line)
+}
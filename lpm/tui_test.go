@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/stretchr/testify/assert"
+)
+
+// heaterDevice records heater commands; other Device methods are not used.
+type heaterDevice struct {
+	lpm.Device
+	count   int // Heaters reported by Info, 0 for the default
+	heaters []bool
+}
+
+func (d *heaterDevice) Info() lpm.Info {
+	return lpm.Info{Heaters: d.count}
+}
+
+func (d *heaterDevice) SetHeaters(states ...bool) error {
+	d.heaters = states
+	return nil
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Empty(t, sparkline(nil, 10))
+	assert.Equal(t, "▁▁▁", sparkline([]float64{2, 2, 2}, 10), "flat")
+	assert.Equal(t, "▁▅█", sparkline([]float64{0, 0.5, 1}, 10))
+
+	// Columns average the values falling into them
+	assert.Equal(t, "▁█", sparkline([]float64{0, 0, 1, 1}, 2))
+}
+
+func TestTUIDashboard_Commands(t *testing.T) {
+	device := &heaterDevice{}
+	d := &tuiDashboard{device: device}
+	log.SetOutput(d)
+	defer log.SetOutput(os.Stderr)
+
+	// Toggles start from the states reported by the device
+	d.update([]sample.Sample{{Heaters: []bool{true, false, false}}}, nil, nil)
+	assert.False(t, d.command("2"))
+	assert.Equal(t, []bool{true, true, false}, device.heaters)
+	assert.Contains(t, d.status, "Heaters set to [1] on  [2] on  [3] off")
+
+	assert.False(t, d.command(" 0 "))
+	assert.Equal(t, []bool{false, false, false}, device.heaters)
+
+	assert.False(t, d.command("x"))
+	assert.Contains(t, d.status, `Unknown command "x"`)
+	assert.False(t, d.command("4"))
+	assert.Contains(t, d.status, `Unknown command "4": 1-3 toggle a heater`)
+	assert.True(t, d.command("q"))
+
+	// The device's heaters, whatever the newest sample reports
+	device.count = 5
+	assert.False(t, d.command("5"))
+	assert.Equal(t, []bool{true, false, false, false, true}, device.heaters)
+}
+
+func TestTUIDashboard_Render(t *testing.T) {
+	d := &tuiDashboard{}
+	var out bytes.Buffer
+	d.render(&out)
+	assert.Contains(t, out.String(), "waiting for samples")
+
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	d.update([]sample.Sample{
+		{Timestamp: t0, Reading: 0.001},
+		{Timestamp: t0.Add(2 * time.Second), Reading: 0.003, HeaterPower: 0.02, Heaters: []bool{false, false, true}},
+	}, []float64{0.001}, []meter.Pulse{
+		{ID: 1, State: meter.PulseStateFinalized, StartTime: t0, EndTime: t0.Add(time.Second), AvgPower: 0.01, RSquared: 0.9},
+		{ID: 2, State: meter.PulseStateUpdating, StartTime: t0.Add(time.Second), EndTime: t0.Add(2 * time.Second), AvgPower: 0.0125},
+	})
+	out.Reset()
+	d.render(&out)
+
+	screen := out.String()
+	assert.Contains(t, screen, "3.000 mV\n▁█\n1.000 mV")
+	assert.Contains(t, screen, "Reading  3.000 mV   Slope 1.000 mV/s   Heater 20.000 mW")
+	assert.Contains(t, screen, "Power    12.500 mW (measuring pulse #2)")
+	assert.Contains(t, screen, "Heaters  [1] off  [2] off  [3] on")
+	assert.Contains(t, screen, "#2   14:03:08     12.500 mW    1.00s  R² 0.000  measuring\n  #1   14:03:07")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// configProblems returns the problems listed by a ValidationErrors in err,
+// one per setting, or err's message if it lists none.
+func configProblems(err error) []string {
+	var errs config.ValidationErrors
+	if !errors.As(err, &errs) {
+		return []string{err.Error()}
+	}
+	problems := make([]string, len(errs))
+	for i, e := range errs {
+		problems[i] = e.Error()
+	}
+	return problems
+}
+
+// showConfigProblems shows the settings out of range in the configuration,
+// e.g. a hand-edited file loaded at start.
+func showConfigProblems(state *appState, err error) {
+	intro := widget.NewLabel(i18n.T("These settings are out of range. Fix them in the settings; the configuration is not saved until then."))
+	intro.Wrapping = fyne.TextWrapWord
+	list := widget.NewLabel(strings.Join(configProblems(err), "\n"))
+	list.TextStyle.Monospace = true
+	content := container.NewVBox(intro, list)
+	d := dialog.NewCustom(i18n.T("Configuration Problems"), i18n.T("Close"), content, state.window)
+	d.Resize(fyne.NewSize(500, 0))
+	d.Show()
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigProblems(t *testing.T) {
+	cfg := config.Default()
+	cfg.VoltageDivider.VRef = 0
+	cfg.Measurement.WindowSeconds = -1
+	assert.Equal(t, []string{
+		"voltage_divider.vref: must be greater than 0",
+		"measurement.window_seconds: must be greater than 0",
+	}, configProblems(cfg.Validate()))
+
+	assert.Equal(t, []string{"disk full"}, configProblems(errors.New("disk full")))
+}
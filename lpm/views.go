@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// viewNames returns the names of the saved scope views in order.
+func viewNames(views []config.ScopeView) []string {
+	names := make([]string, len(views))
+	for i, v := range views {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// handleViewSelected switches the graph to the saved view called name and
+// brings the trigger controls in line with it.
+func handleViewSelected(state *appState, name string) {
+	v, ok := state.cfg.Scope.View(name)
+	if !ok {
+		return
+	}
+	state.scopeWidget.ApplyView(v)
+
+	mode := state.scopeWidget.TriggerMode()
+	state.triggerSelect.Selected = i18n.T(mode.String()) // Without the callback, which would reset the zoom
+	state.triggerSelect.Refresh()
+	updateArmButton(state, mode)
+}
+
+// handleSaveView asks for a name and saves the current zoom, traces, axis
+// locks and trigger mode as a view, replacing a view of the same name.
+func handleSaveView(state *appState) {
+	nameEntry := widget.NewSelectEntry(viewNames(state.cfg.Scope.Views))
+	nameEntry.SetPlaceHolder(i18n.T("e.g. Calibration"))
+	nameEntry.SetText(state.viewSelect.Selected)
+
+	items := []*widget.FormItem{widget.NewFormItem(i18n.T("Name"), nameEntry)}
+	dialog.ShowForm(i18n.T("Save View"), i18n.T("Save"), i18n.T("Cancel"), items, func(save bool) {
+		name := strings.TrimSpace(nameEntry.Text)
+		if !save || name == "" {
+			return
+		}
+		state.cfg.Scope.SetView(state.scopeWidget.CurrentView(name))
+		if err := state.cfg.Save(state.configPath); err != nil {
+			dialog.ShowError(i18n.Errorf("failed to save config: %w", err), state.window)
+			return
+		}
+		state.viewSelect.SetOptions(viewNames(state.cfg.Scope.Views))
+		state.viewSelect.Selected = name
+		state.viewSelect.Refresh()
+	}, state.window)
+}
@@ -3,24 +3,84 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
 	"time"
-
-	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration.
+//
+// A configuration file can include others, e.g. a lab's shared defaults in
+// base.yaml under a bench's site.yaml with "include: [base.yaml]": the
+// included files are read first and the including file overrides their
+// settings.
 type Config struct {
+	Include []string `yaml:"include,omitempty"` // Files read before this one, relative to its directory
+
 	Serial         SerialConfig         `yaml:"serial"`
 	VoltageDivider VoltageDividerConfig `yaml:"voltage_divider"`
+	Ambient        AmbientConfig        `yaml:"ambient"`
 	Heaters        []HeaterConfig       `yaml:"heaters"`
 	Measurement    MeasurementConfig    `yaml:"measurement"`
 	Calibration    CalibrationConfig    `yaml:"calibration"`
+	Scope          ScopeConfig          `yaml:"scope"`
+	UI             UIConfig             `yaml:"ui"`
+	Alarms         AlarmConfig          `yaml:"alarms"`
 	Mock           MockConfig           `yaml:"mock"`
+
+	Profile  string    `yaml:"profile"`  // Name of the profile in use, empty if none
+	Profiles []Profile `yaml:"profiles"` // Named device setups to switch between
+
+	env []envOverride // Settings overridden by the environment, see applyEnv
+}
+
+// Profile is a named device setup, e.g. for one of several absorber heads
+// or dividers: the port it is connected to, its divider, heaters and
+// calibration. A profile with the serial number of a head is put in use
+// whenever that head connects, see UseDeviceProfile.
+type Profile struct {
+	Name                  string               `yaml:"name"`
+	Port                  string               `yaml:"port"`
+	Serial                string               `yaml:"serial,omitempty"` // Serial number of the head, as reported in lpm.Info
+	VoltageDivider        VoltageDividerConfig `yaml:"voltage_divider"`
+	Heaters               []HeaterConfig       `yaml:"heaters"`
+	CalibrationPoints     []CalibrationPoint   `yaml:"calibration_points"`
+	CalibrationDate       time.Time            `yaml:"calibration_date,omitempty"`
+	PowerPolynomial       []float64            `yaml:"power_polynomial"`
+	AbsorbanceCoefficient float64              `yaml:"absorbance_coefficient"`
 }
 
+// DefaultSampleRate is the MCU output rate in Hz assumed until the device reports one.
+const DefaultSampleRate = 50.0
+
 // SerialConfig contains serial port configuration.
 type SerialConfig struct {
-	Port string `yaml:"port"`
+	Port         string        `yaml:"port"`
+	SampleRate   float64       `yaml:"sample_rate"`   // Requested MCU output rate in Hz (0 = firmware default)
+	StaleTimeout time.Duration `yaml:"stale_timeout"` // Flag the device as unhealthy when no sample arrives within this time
+	Binary       bool          `yaml:"binary"`        // Ask protocol v5 firmware for binary frames instead of text lines
+	Averaging    int           `yaml:"averaging"`     // ADC readings averaged per sample (0 = firmware default), protocol v6; takes precedence over sample_rate
+	ADCInterval  time.Duration `yaml:"adc_interval"`  // Time between ADC readings in whole ms (0 = firmware default), protocol v9
+	ADCGain      int           `yaml:"adc_gain"`      // Absorber channel gain relative to the default input range (0 = firmware default), protocol v15
+	ADCReference float64       `yaml:"adc_reference"` // ADC input range in V, selecting the ADC reference (0 = firmware default), protocol v15
+
+	// Line settings (default: 115200 8N1, no flow control)
+	BaudRate    int    `yaml:"baud_rate"`
+	DataBits    int    `yaml:"data_bits"`    // 5-8
+	Parity      string `yaml:"parity"`       // "none", "odd", "even", "mark" or "space"
+	StopBits    string `yaml:"stop_bits"`    // "1", "1.5" or "2"
+	FlowControl string `yaml:"flow_control"` // "none" or "rtscts"
+	DTR         *bool  `yaml:"dtr"`          // DTR level on open (nil = driver default, asserted); some boards reset on DTR
+	RTS         *bool  `yaml:"rts"`          // RTS level on open (nil = driver default, asserted)
+
+	// NegotiatedRate is the output rate reported by the connected device.
+	// It is set at connect time and never saved.
+	NegotiatedRate float64 `yaml:"-"`
+
+	// NegotiatedGain and NegotiatedReference are the absorber gain and ADC
+	// input range (V) reported by the connected device, used to convert ADC
+	// counts to volts. They are set at connect time and never saved.
+	NegotiatedGain      int     `yaml:"-"`
+	NegotiatedReference float64 `yaml:"-"`
 }
 
 // VoltageDividerConfig contains voltage divider configuration.
@@ -30,7 +90,16 @@ type VoltageDividerConfig struct {
 	VRef float64 `yaml:"vref"`
 }
 
-// HeaterConfig contains heater resistance configuration.
+// AmbientConfig describes the ambient temperature sensor: an NTC from the
+// ADC input to ground, fed from VRef through a series resistor.
+type AmbientConfig struct {
+	R25     float64 `yaml:"r25"`      // NTC resistance at 25 °C (Ω)
+	Beta    float64 `yaml:"beta"`     // NTC beta coefficient (K)
+	RSeries float64 `yaml:"r_series"` // Series resistor between VRef and the NTC (Ω)
+}
+
+// HeaterConfig contains heater resistance configuration. Config.Heaters
+// lists one per heater of the device, heater 1 first.
 type HeaterConfig struct {
 	Resistance float64 `yaml:"resistance"`
 }
@@ -40,13 +109,16 @@ type MeasurementConfig struct {
 	WindowSeconds         float64        `yaml:"window_seconds"`
 	PulseThresholdMVS     float64        `yaml:"pulse_threshold_mvs"`      // Threshold for pulse detection in mV/s (default: 0.5 mV/s)
 	MinPulseDuration      float64        `yaml:"min_pulse_duration"`       // Minimum pulse duration in seconds (filters noise)
+	MinPulseSamples       int            `yaml:"min_pulse_samples"`        // Minimum pulse duration in samples at the negotiated rate (overrides min_pulse_duration when > 0)
 	SmoothingAlpha        float64        `yaml:"smoothing_alpha"`          // EMA smoothing factor for main fields (0.0-1.0, 0 = disabled, default 0.25)
 	SpikeFilterWindowSize time.Duration  `yaml:"spike_filter_window_size"` // Median filter time window to remove hardware-induced spikes (default: 60ms, 0 = disabled)
+	SpikeFilterSamples    int            `yaml:"spike_filter_samples"`     // Median filter window in samples at the negotiated rate (overrides spike_filter_window_size when > 0)
 	DownsampleRate        *time.Duration `yaml:"downsample_rate"`          // Target sample rate for downsampling (e.g., "1s" = 1 sample per second, nil = use default, 0 = disabled)
 	// Change field filtering (separate from main smoothing)
 	ChangeFilterType       string        `yaml:"change_filter_type"`        // Filter type for Change field: "ema", "ma", or "mm" (default: "ema")
 	ChangeFilterAlpha      float64       `yaml:"change_filter_alpha"`       // EMA smoothing factor for Change field (0.0-1.0, used when change_filter_type="ema", default 0.25)
 	ChangeFilterWindowSize time.Duration `yaml:"change_filter_window_size"` // Time window for MA/MM filters on Change field (used when change_filter_type="ma" or "mm", default: 200ms)
+	ChangeFilterSamples    int           `yaml:"change_filter_samples"`     // MA/MM window in samples at the negotiated rate (overrides change_filter_window_size when > 0)
 	// Pulse detection using horizontal line fitting
 	PulseLineFitMinDuration float64 `yaml:"pulse_line_fit_min_duration"` // Not used in simplified algorithm (kept for compatibility)
 	PulseLineFitRangeMVS    float64 `yaml:"pulse_line_fit_range_mvs"`    // Display threshold for stdDev in mV/s (for reference, not used for rejection)
@@ -62,6 +134,18 @@ type CalibrationConfig struct {
 	CooloffDuration  time.Duration      `yaml:"cooloff_duration"`
 	HeaterSequence   []int              `yaml:"heater_sequence"`
 	Points           []CalibrationPoint `yaml:"points"`
+	Date             time.Time          `yaml:"date,omitempty"`  // When the power polynomial was last fitted
+	HeaterSchedule   []HeaterStep       `yaml:"heater_schedule"` // Last sequence run by the heater scheduler
+}
+
+// HeaterStep is a step of a heater schedule: one heater driven at a duty
+// cycle for Duration, then all heaters off for Rest, run Repeat times.
+type HeaterStep struct {
+	Heater   int           `yaml:"heater"`   // 1 to the number of heaters
+	Duty     float64       `yaml:"duty"`     // PWM duty cycle, above 0 up to 1 (fully on)
+	Duration time.Duration `yaml:"duration"` // Time on
+	Rest     time.Duration `yaml:"rest"`     // Time off after each run
+	Repeat   int           `yaml:"repeat"`   // Runs of the step, at least 1
 }
 
 // CalibrationPoint represents a single calibration point.
@@ -70,6 +154,306 @@ type CalibrationPoint struct {
 	Power float64 `yaml:"power"`
 }
 
+// ScopeConfig contains the scope display settings.
+type ScopeConfig struct {
+	Display  string `yaml:"display"`   // "roll" scrolls with the newest data, "sweep" redraws left to right
+	Renderer string `yaml:"renderer"`  // "raster" draws anti-aliased traces into an image, "lines" uses a line object per segment
+	TimeAxis string `yaml:"time_axis"` // "relative" labels seconds from the left edge, "clock" the time of day
+	Layout   string `yaml:"layout"`    // "overlay" draws the derivative over the reading, "split" in a sub-plot below it
+
+	Reading     TraceConfig `yaml:"reading"`      // Absorber reading, on the left axis
+	Derivative  TraceConfig `yaml:"derivative"`   // Reading slope, on the right axis
+	Voltage     TraceConfig `yaml:"voltage"`      // Heater supply voltage, scaled to its own range
+	HeaterPower TraceConfig `yaml:"heater_power"` // Total heater power, scaled to its own range
+	Envelope    bool        `yaml:"envelope"`     // Min/max band behind the reading and derivative where downsampled
+	HeaterLane  bool        `yaml:"heater_lane"`  // On/off timeline of each heater below the time axis
+
+	// History is how far back the graph can be scrolled, kept separately
+	// from the meter's analysis window (0 = the analysis window only)
+	History time.Duration `yaml:"history"`
+
+	// RefreshInterval is the shortest time between redraws for new data
+	// (0 = 16ms, about 60 FPS); slow machines back off further by themselves
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	ShowPerformance bool          `yaml:"show_performance"` // Overlay the redraw rate, update latency and sample rate
+
+	// MaxDisplayPoints is how many points the traces are downsampled to for
+	// drawing, keeping pulse edges (0 = 1000); more shows finer detail at
+	// the cost of slower redraws
+	MaxDisplayPoints int `yaml:"max_display_points"`
+
+	ReadingRange    AxisRange `yaml:"reading_range"`    // Fixed reading axis (mV), auto-scaled if unset
+	DerivativeRange AxisRange `yaml:"derivative_range"` // Fixed derivative axis (mV/s), auto-scaled if unset
+
+	Background   string      `yaml:"background"`    // Plot background, "#RRGGBB" or "#RRGGBBAA"
+	Grid         GridConfig  `yaml:"grid"`          // Grid lines and axis labels
+	PulseMarkers TraceConfig `yaml:"pulse_markers"` // Start and end lines of measured pulses
+
+	Views []ScopeView `yaml:"views"` // Named views to switch between
+}
+
+// ScopeView is a named scope setup, e.g. a "calibration" or "CW" view.
+type ScopeView struct {
+	Name            string          `yaml:"name"`
+	Span            time.Duration   `yaml:"span"`             // Zoomed time span, 0 for the live window
+	Traces          TraceVisibility `yaml:"traces"`           // Traces shown
+	ReadingRange    AxisRange       `yaml:"reading_range"`    // Fixed reading axis (mV), auto-scaled if unset
+	DerivativeRange AxisRange       `yaml:"derivative_range"` // Fixed derivative axis (mV/s), auto-scaled if unset
+	Trigger         string          `yaml:"trigger"`          // "roll", "auto", "normal" or "single"
+}
+
+// TraceVisibility selects the scope traces shown by a view.
+type TraceVisibility struct {
+	Reading     bool `yaml:"reading"`
+	Derivative  bool `yaml:"derivative"`
+	Voltage     bool `yaml:"voltage"`
+	HeaterPower bool `yaml:"heater_power"`
+}
+
+// View returns the view called name, or false if there is none.
+func (c ScopeConfig) View(name string) (ScopeView, bool) {
+	for _, v := range c.Views {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return ScopeView{}, false
+}
+
+// SetView adds v, replacing the view of the same name.
+func (c *ScopeConfig) SetView(v ScopeView) {
+	for i := range c.Views {
+		if c.Views[i].Name == v.Name {
+			c.Views[i] = v
+			return
+		}
+	}
+	c.Views = append(c.Views, v)
+}
+
+// CurrentProfile returns the settings in use as a profile called name. The
+// serial number is kept from the profile called name, if there is one.
+// Settings overridden by the environment are taken from the file.
+func (c *Config) CurrentProfile(name string) Profile {
+	return c.fileConfig().currentProfile(name)
+}
+
+// currentProfile returns the settings of c as a profile called name.
+func (c *Config) currentProfile(name string) Profile {
+	existing, _ := c.FindProfile(name)
+	return Profile{
+		Name:                  name,
+		Port:                  c.Serial.Port,
+		Serial:                existing.Serial,
+		VoltageDivider:        c.VoltageDivider,
+		Heaters:               slices.Clone(c.Heaters),
+		CalibrationPoints:     slices.Clone(c.Calibration.Points),
+		CalibrationDate:       c.Calibration.Date,
+		PowerPolynomial:       slices.Clone(c.Measurement.PowerPolynomial),
+		AbsorbanceCoefficient: c.Measurement.AbsorbanceCoefficient,
+	}
+}
+
+// FindProfile returns the profile called name, or false if there is none.
+func (c *Config) FindProfile(name string) (Profile, bool) {
+	for _, p := range c.Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// DeviceProfile returns the profile of the head with serial number serial,
+// the first one if several have it, or false if there is none or serial is
+// empty.
+func (c *Config) DeviceProfile(serial string) (Profile, bool) {
+	if serial == "" {
+		return Profile{}, false
+	}
+	for _, p := range c.Profiles {
+		if p.Serial == serial {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// SetProfile adds p, replacing the profile of the same name.
+func (c *Config) SetProfile(p Profile) {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == p.Name {
+			c.Profiles[i] = p
+			return
+		}
+	}
+	c.Profiles = append(c.Profiles, p)
+}
+
+// SwitchProfile puts the profile called name in use. The settings in use
+// are kept in the profile in use before, so edits made since switching to
+// it are not lost. Settings overridden by the environment stay overridden.
+// Returns an error if there is no profile called name.
+func (c *Config) SwitchProfile(name string) error {
+	p, ok := c.FindProfile(name)
+	if !ok {
+		return fmt.Errorf("no profile %q", name)
+	}
+	c.useFileValues()
+	defer c.useEnvValues()
+	if _, ok := c.FindProfile(c.Profile); ok {
+		c.SetProfile(c.currentProfile(c.Profile))
+	}
+
+	// A port, divider, heaters or absorbance left out of a hand-written
+	// profile keeps the setting in use
+	c.Profile = p.Name
+	if p.Port != "" {
+		c.Serial.Port = p.Port
+	}
+	if p.VoltageDivider.R2 > 0 {
+		c.VoltageDivider = p.VoltageDivider
+	}
+	if len(p.Heaters) > 0 {
+		c.Heaters = slices.Clone(p.Heaters)
+	}
+	c.Calibration.Points = slices.Clone(p.CalibrationPoints)
+	c.Calibration.Date = p.CalibrationDate
+	c.Measurement.PowerPolynomial = slices.Clone(p.PowerPolynomial)
+	if p.AbsorbanceCoefficient > 0 {
+		c.Measurement.AbsorbanceCoefficient = p.AbsorbanceCoefficient
+	}
+	return nil
+}
+
+// UseDeviceProfile switches to the profile of the head with serial number
+// serial like SwitchProfile, but keeps the port in use: the head may be
+// connected to another port than the one it was saved with. Returns the
+// profile's name, or false if the head has no profile or it is in use.
+func (c *Config) UseDeviceProfile(serial string) (string, bool) {
+	p, ok := c.DeviceProfile(serial)
+	if !ok || p.Name == c.Profile {
+		return "", false
+	}
+	port := c.Serial.Port
+	if err := c.SwitchProfile(p.Name); err != nil {
+		return "", false
+	}
+	c.Serial.Port = port
+	return p.Name, true
+}
+
+// Scope palettes suiting the dark and light application themes.
+var (
+	DarkScopePalette = ScopePalette{
+		Background:   "#141414",
+		Grid:         "#282828",
+		Label:        "#969696",
+		Reading:      "#FFA500",
+		Derivative:   "#64C8FF",
+		Voltage:      "#B4B4C8",
+		HeaterPower:  "#FF5050",
+		PulseMarkers: "#0064C8",
+	}
+	LightScopePalette = ScopePalette{
+		Background:   "#FFFFFF",
+		Grid:         "#DCDCDC",
+		Label:        "#505050",
+		Reading:      "#D26400",
+		Derivative:   "#0078C8",
+		Voltage:      "#64647D",
+		HeaterPower:  "#C82828",
+		PulseMarkers: "#0050A0",
+	}
+)
+
+// ScopePalette is the set of scope colors, "#RRGGBB" or "#RRGGBBAA".
+type ScopePalette struct {
+	Background   string
+	Grid         string
+	Label        string
+	Reading      string
+	Derivative   string
+	Voltage      string
+	HeaterPower  string
+	PulseMarkers string
+}
+
+// Palette returns the colors of the scope.
+func (c ScopeConfig) Palette() ScopePalette {
+	return ScopePalette{
+		Background:   c.Background,
+		Grid:         c.Grid.Color,
+		Label:        c.Grid.LabelColor,
+		Reading:      c.Reading.Color,
+		Derivative:   c.Derivative.Color,
+		Voltage:      c.Voltage.Color,
+		HeaterPower:  c.HeaterPower.Color,
+		PulseMarkers: c.PulseMarkers.Color,
+	}
+}
+
+// SetPalette sets the colors of the scope, keeping line widths and
+// visibility.
+func (c *ScopeConfig) SetPalette(p ScopePalette) {
+	c.Background = p.Background
+	c.Grid.Color = p.Grid
+	c.Grid.LabelColor = p.Label
+	c.Reading.Color = p.Reading
+	c.Derivative.Color = p.Derivative
+	c.Voltage.Color = p.Voltage
+	c.HeaterPower.Color = p.HeaterPower
+	c.PulseMarkers.Color = p.PulseMarkers
+}
+
+// GridConfig configures the scope grid.
+type GridConfig struct {
+	HDivisions int     `yaml:"h_divisions"` // Divisions of the value axes
+	VDivisions int     `yaml:"v_divisions"` // Divisions of the time axis
+	Color      string  `yaml:"color"`       // "#RRGGBB" or "#RRGGBBAA"
+	Width      float32 `yaml:"width"`       // Line width
+	LabelColor string  `yaml:"label_color"` // Time axis and status labels
+}
+
+// AxisRange is a fixed scope axis range in display units.
+type AxisRange struct {
+	Min float64 `yaml:"min"`
+	Max float64 `yaml:"max"`
+}
+
+// Fixed reports whether the range is set, i.e. Max is above Min.
+func (r AxisRange) Fixed() bool {
+	return r.Max > r.Min
+}
+
+// TraceConfig configures one scope trace.
+type TraceConfig struct {
+	Show  bool    `yaml:"show"`
+	Color string  `yaml:"color"` // "#RRGGBB" or "#RRGGBBAA"
+	Width float32 `yaml:"width"` // Line width
+}
+
+// UIConfig contains the application appearance.
+type UIConfig struct {
+	Theme    string `yaml:"theme"`    // "system" follows the OS, "dark" or "light"
+	Language string `yaml:"language"` // GUI language code, e.g. "de", or "system" for the OS locale
+
+	PowerUnit   string `yaml:"power_unit"`   // Power display unit: "mW", "W" or "dBm"
+	ReadingUnit string `yaml:"reading_unit"` // Reading display unit: "mV" or "V"
+}
+
+// AlarmConfig contains the alarms raised while measuring. A zero limit or
+// duration disables its alarm.
+type AlarmConfig struct {
+	PowerAboveMW  float64       `yaml:"power_above_mw"`  // Alarm when the measured power stays above this (mW)
+	PowerBelowMW  float64       `yaml:"power_below_mw"`  // Alarm when the measured power stays below this (mW); no pulse counts as 0 mW
+	PowerDuration time.Duration `yaml:"power_duration"`  // How long the power must stay beyond a limit
+	NoSamples     time.Duration `yaml:"no_samples"`      // Alarm when no sample arrives for this long
+	HeaterStuckOn time.Duration `yaml:"heater_stuck_on"` // Alarm when a heater stays on for this long
+	HeaterMaxOn   time.Duration `yaml:"heater_max_on"`   // Turn all heaters off and alarm when one stays on for this long
+	Sound         bool          `yaml:"sound"`           // Beep when an alarm is raised
+}
+
 // MockConfig contains mock device configuration.
 type MockConfig struct {
 	Bias          float64       `yaml:"bias"`           // Bias voltage (V)
@@ -78,19 +462,70 @@ type MockConfig struct {
 	LaserDuration time.Duration `yaml:"laser_duration"` // Laser pulse duration
 	LaserPeriod   time.Duration `yaml:"laser_period"`   // Time between laser pulses
 	SampleRate    time.Duration `yaml:"sample_rate"`    // Sample rate
+	LaserPattern  []MockPulse   `yaml:"laser_pattern"`  // Repeating pulses of varying power; replaces the periodic laser when set
+	Scenario      string        `yaml:"scenario"`       // Scenario file; replaces the periodic laser and pattern when set
+	ReplayFile    string        `yaml:"replay_file"`    // Raw recording played back as the baseline; replaces laser and scenario
+	HeaterPower   []float64     `yaml:"heater_power"`   // Power of each simulated heater when fully on (mW), one per heater (default 10, 50, 100)
+
+	// Thermal model: the absorber body heats up with the absorbed power and
+	// loses heat to ambient; the sensor follows the body with its own lag.
+	Responsivity       float64       `yaml:"responsivity"`         // Steady-state body rise per absorbed power (V/mW)
+	BodyTimeConstant   time.Duration `yaml:"body_time_constant"`   // Body heat loss to ambient (thermal R*C)
+	SensorTimeConstant time.Duration `yaml:"sensor_time_constant"` // Sensor lag behind the body (0 = no lag)
+
+	// Ambient drift of the baseline: a slow sine plus a random walk.
+	DriftAmplitude float64       `yaml:"drift_amplitude"` // Sinusoidal drift amplitude (V)
+	DriftPeriod    time.Duration `yaml:"drift_period"`    // Sinusoidal drift period
+	RandomWalk     float64       `yaml:"random_walk"`     // Random walk intensity (V per √s)
+	DriftSeed      int64         `yaml:"drift_seed"`      // Random seed, so drifting runs are reproducible
+
+	// Fault injection for stress testing; rates are per-sample probabilities (0-1).
+	MalformedRate  float64       `yaml:"malformed_rate"`  // Garble the sample's line so the parser rejects it
+	SaturationRate float64       `yaml:"saturation_rate"` // Report a saturated ADC reading (0 or 65535)
+	DuplicateRate  float64       `yaml:"duplicate_rate"`  // Send the sample twice
+	DropRate       float64       `yaml:"drop_rate"`       // Lose the sample on the link, leaving a sequence gap
+	FreezeRate     float64       `yaml:"freeze_rate"`     // Start a period of frozen readings
+	FreezeDuration time.Duration `yaml:"freeze_duration"` // How long readings stay frozen
+	FaultSeed      int64         `yaml:"fault_seed"`      // Random seed, so faulty runs are reproducible
+
+	// Timing: ADC sampling jitters and USB serial delivers samples in packets.
+	RateJitter float64 `yaml:"rate_jitter"` // Random deviation of each sample interval, as a fraction (0-1) of it
+	BurstRate  float64 `yaml:"burst_rate"`  // Probability (0-1) per sample that delivery stalls into a burst
+	BurstSize  int     `yaml:"burst_size"`  // Samples held back and delivered at once per burst
+	TimingSeed int64   `yaml:"timing_seed"` // Random seed, so timing is reproducible
+}
+
+// MockPulse is one step of a repeating mock laser pattern, e.g. a
+// 10/20/40/80 mW staircase. Zero durations fall back to the mock's
+// LaserDuration and LaserPeriod.
+type MockPulse struct {
+	Power    float64       `yaml:"power"`    // Laser power (mW)
+	Duration time.Duration `yaml:"duration"` // Pulse duration
+	Period   time.Duration `yaml:"period"`   // Time from this pulse to the next
 }
 
 // Default returns a default configuration with sensible values.
 func Default() *Config {
 	return &Config{
 		Serial: SerialConfig{
-			Port: "COM3", // Default for Windows, should be "/dev/ttyACM0" on Linux/Mac
+			Port:         "COM3", // Default for Windows, should be "/dev/ttyACM0" on Linux/Mac
+			StaleTimeout: 2 * time.Second,
+			BaudRate:     115200,
+			DataBits:     8,
+			Parity:       "none",
+			StopBits:     "1",
+			FlowControl:  "none",
 		},
 		VoltageDivider: VoltageDividerConfig{
 			R1:   20000,
 			R2:   20000,
 			VRef: 3.3,
 		},
+		Ambient: AmbientConfig{
+			R25:     10000,
+			Beta:    3950,
+			RSeries: 10000,
+		},
 		Heaters: []HeaterConfig{
 			{Resistance: 2694},
 			{Resistance: 511},
@@ -120,6 +555,39 @@ func Default() *Config {
 				{Slope: 0.0, Power: 0.0},
 			},
 		},
+		Scope: ScopeConfig{
+			Display:          "roll",
+			Renderer:         "raster",
+			TimeAxis:         "relative",
+			Layout:           "overlay",
+			Reading:          TraceConfig{Show: true, Color: "#FFA500", Width: 1.5},
+			Derivative:       TraceConfig{Show: true, Color: "#64C8FF", Width: 1.0},
+			Voltage:          TraceConfig{Color: "#B4B4C8", Width: 1.0},
+			HeaterPower:      TraceConfig{Color: "#FF5050", Width: 1.0},
+			Envelope:         true,
+			HeaterLane:       true,
+			History:          10 * time.Minute,
+			RefreshInterval:  16 * time.Millisecond,
+			MaxDisplayPoints: 1000,
+			Background:       "#141414",
+			Grid: GridConfig{
+				HDivisions: 8,
+				VDivisions: 10,
+				Color:      "#282828",
+				Width:      1.0,
+				LabelColor: "#969696",
+			},
+			PulseMarkers: TraceConfig{Show: true, Color: "#0064C8", Width: 1.0},
+		},
+		UI: UIConfig{
+			Theme:       "system",
+			Language:    "system",
+			PowerUnit:   "mW",
+			ReadingUnit: "mV",
+		},
+		Alarms: AlarmConfig{
+			HeaterMaxOn: 5 * time.Minute, // Longer than any calibration point; protects a forgotten heater
+		},
 		Mock: MockConfig{
 			Bias:          0.0,
 			NoiseLevel:    0.001,
@@ -127,26 +595,56 @@ func Default() *Config {
 			LaserDuration: 2 * time.Second,
 			LaserPeriod:   20 * time.Second,
 			SampleRate:    20 * time.Millisecond, // 50 samples per second // 10 Hz
+
+			Responsivity:       0.001,
+			BodyTimeConstant:   10 * time.Second,
+			SensorTimeConstant: 300 * time.Millisecond,
 		},
 	}
 }
 
-// Load loads configuration from a YAML file. If the file doesn't exist or
-// fields are missing, it uses default values.
+// Load loads configuration from a YAML, JSON or TOML file, told by its
+// extension (see FileFormat), over the files it includes. If the file
+// doesn't exist or fields are missing, it uses default values. Environment variables starting with
+// EnvPrefix override the file, e.g. GOLPM_SERIAL_PORT. A configuration
+// failing Validate is returned along with its ValidationErrors, so it can be
+// shown and fixed.
 func Load(filename string) (*Config, error) {
-	cfg := Default()
+	return LoadProfile(filename, "")
+}
 
-	data, err := os.ReadFile(filename)
+// LoadProfile loads the configuration file like Load and puts the profile
+// called profile in use, as if switched to in the GUI. An empty profile keeps
+// the profile last in use, the file's default. The environment overrides the
+// profile like the file. Returns an error if the file has no profile called
+// profile.
+func LoadProfile(filename, profile string) (*Config, error) {
+	// A missing file reads as defaults
+	layers, err := readLayers(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parse(layers)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist, return defaults
-			return cfg, nil
+		return nil, err
+	}
+	if profile != "" && profile != cfg.Profile {
+		if err := cfg.SwitchProfile(profile); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
+	return cfg, cfg.Validate()
+}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+// parse reads the layers of a configuration file over the defaults, and
+// the environment over all of them.
+func parse(layers []layer) (*Config, error) {
+	cfg := Default()
+	if err := cfg.overlay(layers); err != nil {
+		return nil, err
+	}
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("failed to apply environment: %w", err)
 	}
 
 	// Ensure minimum required fields are set (use defaults if missing)
@@ -155,9 +653,24 @@ func Load(filename string) (*Config, error) {
 	return cfg, nil
 }
 
-// Save saves the configuration to a YAML file.
+// Save saves the configuration to a YAML, JSON or TOML file, told by its
+// extension (see FileFormat). A configuration that includes files is saved
+// as the settings differing from theirs, so later changes to the included
+// files still apply. Settings overridden by the environment are saved with
+// the values of the file. A configuration failing Validate is not saved.
 func (c *Config) Save(filename string) error {
-	data, err := yaml.Marshal(c)
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	file := c.fileConfig()
+	var base *Config
+	if len(c.Include) > 0 {
+		var err error
+		if base, err = includedBase(filename, c.Include); err != nil {
+			return err
+		}
+	}
+	data, err := file.marshal(FileFormat(filename), base)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -169,6 +682,67 @@ func (c *Config) Save(filename string) error {
 	return nil
 }
 
+// SampleRate returns the device output rate in Hz: the rate negotiated with the
+// connected device, else the requested rate, else DefaultSampleRate.
+func (c *Config) SampleRate() float64 {
+	switch {
+	case c.Serial.NegotiatedRate > 0:
+		return c.Serial.NegotiatedRate
+	case c.Serial.SampleRate > 0:
+		return c.Serial.SampleRate
+	default:
+		return DefaultSampleRate
+	}
+}
+
+// ReadingGain returns the absorber channel gain negotiated with the
+// connected device, else 1: firmware without gain control reads at the
+// default input range.
+func (c *Config) ReadingGain() float64 {
+	if c.Serial.NegotiatedGain > 0 {
+		return float64(c.Serial.NegotiatedGain)
+	}
+	return 1
+}
+
+// ADCReference returns the ADC input range in V: the range negotiated with
+// the connected device, else the configured VoltageDivider.VRef.
+func (c *Config) ADCReference() float64 {
+	if c.Serial.NegotiatedReference > 0 {
+		return c.Serial.NegotiatedReference
+	}
+	return c.VoltageDivider.VRef
+}
+
+// SamplesDuration converts a number of samples to a duration at SampleRate.
+func (c *Config) SamplesDuration(n int) time.Duration {
+	return time.Duration(float64(n) / c.SampleRate() * float64(time.Second))
+}
+
+// SpikeFilterWindow returns the spike filter window, preferring SpikeFilterSamples.
+func (c *Config) SpikeFilterWindow() time.Duration {
+	if c.Measurement.SpikeFilterSamples > 0 {
+		return c.SamplesDuration(c.Measurement.SpikeFilterSamples)
+	}
+	return c.Measurement.SpikeFilterWindowSize
+}
+
+// ChangeFilterWindow returns the MA/MM window for the Change field, preferring ChangeFilterSamples.
+func (c *Config) ChangeFilterWindow() time.Duration {
+	if c.Measurement.ChangeFilterSamples > 0 {
+		return c.SamplesDuration(c.Measurement.ChangeFilterSamples)
+	}
+	return c.Measurement.ChangeFilterWindowSize
+}
+
+// MinPulseWindow returns the minimum pulse duration, preferring MinPulseSamples.
+func (c *Config) MinPulseWindow() time.Duration {
+	if c.Measurement.MinPulseSamples > 0 {
+		return c.SamplesDuration(c.Measurement.MinPulseSamples)
+	}
+	return time.Duration(c.Measurement.MinPulseDuration * float64(time.Second))
+}
+
 // ensureDefaults ensures that all required fields have default values if missing.
 func (c *Config) ensureDefaults() {
 	def := Default()
@@ -176,6 +750,25 @@ func (c *Config) ensureDefaults() {
 	if c.Serial.Port == "" {
 		c.Serial.Port = def.Serial.Port
 	}
+	if c.Serial.StaleTimeout <= 0 {
+		c.Serial.StaleTimeout = def.Serial.StaleTimeout
+	}
+	if c.Serial.BaudRate == 0 {
+		c.Serial.BaudRate = def.Serial.BaudRate
+	}
+	if c.Serial.DataBits == 0 {
+		c.Serial.DataBits = def.Serial.DataBits
+	}
+	if c.Serial.Parity == "" {
+		c.Serial.Parity = def.Serial.Parity
+	}
+	if c.Serial.StopBits == "" {
+		c.Serial.StopBits = def.Serial.StopBits
+	}
+	if c.Serial.FlowControl == "" {
+		c.Serial.FlowControl = def.Serial.FlowControl
+	}
+	// DTR/RTS: nil keeps the driver default, so there is nothing to fill in
 
 	if c.VoltageDivider.R1 == 0 {
 		c.VoltageDivider.R1 = def.VoltageDivider.R1
@@ -187,6 +780,16 @@ func (c *Config) ensureDefaults() {
 		c.VoltageDivider.VRef = def.VoltageDivider.VRef
 	}
 
+	if c.Ambient.R25 == 0 {
+		c.Ambient.R25 = def.Ambient.R25
+	}
+	if c.Ambient.Beta == 0 {
+		c.Ambient.Beta = def.Ambient.Beta
+	}
+	if c.Ambient.RSeries == 0 {
+		c.Ambient.RSeries = def.Ambient.RSeries
+	}
+
 	if len(c.Heaters) == 0 {
 		c.Heaters = def.Heaters
 	}
@@ -234,6 +837,59 @@ func (c *Config) ensureDefaults() {
 		c.Calibration.Points = def.Calibration.Points
 	}
 
+	if c.Scope.Display == "" {
+		c.Scope.Display = def.Scope.Display
+	}
+	if c.Scope.Renderer == "" {
+		c.Scope.Renderer = def.Scope.Renderer
+	}
+	if c.Scope.TimeAxis == "" {
+		c.Scope.TimeAxis = def.Scope.TimeAxis
+	}
+	if c.Scope.Layout == "" {
+		c.Scope.Layout = def.Scope.Layout
+	}
+	defTraces := []*TraceConfig{&def.Scope.Reading, &def.Scope.Derivative, &def.Scope.Voltage, &def.Scope.HeaterPower, &def.Scope.PulseMarkers}
+	for i, t := range []*TraceConfig{&c.Scope.Reading, &c.Scope.Derivative, &c.Scope.Voltage, &c.Scope.HeaterPower, &c.Scope.PulseMarkers} {
+		if t.Color == "" {
+			t.Color = defTraces[i].Color
+		}
+		if t.Width <= 0 {
+			t.Width = defTraces[i].Width
+		}
+	}
+	if c.Scope.Background == "" {
+		c.Scope.Background = def.Scope.Background
+	}
+	if c.Scope.Grid.HDivisions <= 0 {
+		c.Scope.Grid.HDivisions = def.Scope.Grid.HDivisions
+	}
+	if c.Scope.Grid.VDivisions <= 0 {
+		c.Scope.Grid.VDivisions = def.Scope.Grid.VDivisions
+	}
+	if c.Scope.Grid.Color == "" {
+		c.Scope.Grid.Color = def.Scope.Grid.Color
+	}
+	if c.Scope.Grid.Width <= 0 {
+		c.Scope.Grid.Width = def.Scope.Grid.Width
+	}
+	if c.Scope.Grid.LabelColor == "" {
+		c.Scope.Grid.LabelColor = def.Scope.Grid.LabelColor
+	}
+
+	if c.UI.Theme == "" {
+		c.UI.Theme = def.UI.Theme
+	}
+	if c.UI.Language == "" {
+		c.UI.Language = def.UI.Language
+	}
+	if c.UI.PowerUnit == "" {
+		c.UI.PowerUnit = def.UI.PowerUnit
+	}
+	if c.UI.ReadingUnit == "" {
+		c.UI.ReadingUnit = def.UI.ReadingUnit
+	}
+
 	if c.Mock.SampleRate == 0 {
 		c.Mock.SampleRate = def.Mock.SampleRate
 	}
@@ -243,4 +899,10 @@ func (c *Config) ensureDefaults() {
 	if c.Mock.LaserDuration == 0 {
 		c.Mock.LaserDuration = def.Mock.LaserDuration
 	}
+	if c.Mock.Responsivity == 0 {
+		c.Mock.Responsivity = def.Mock.Responsivity
+	}
+	if c.Mock.BodyTimeConstant == 0 {
+		c.Mock.BodyTimeConstant = def.Mock.BodyTimeConstant
+	}
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -25,6 +26,7 @@ func TestDefault(t *testing.T) {
 	assert.Equal(t, 20*time.Second, cfg.Calibration.CooloffDuration)
 	assert.Equal(t, []int{1, 2, 3}, cfg.Calibration.HeaterSequence)
 	assert.Len(t, cfg.Calibration.Points, 1)
+	assert.Equal(t, 5*time.Minute, cfg.Alarms.HeaterMaxOn)
 }
 
 func TestLoad_FileNotExists(t *testing.T) {
@@ -127,6 +129,91 @@ serial:
 	assert.Equal(t, float64(10), cfg.Measurement.WindowSeconds) // default
 }
 
+func TestLoad_ScopeTraces(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_config_*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+
+	yamlContent := `
+scope:
+  derivative:
+    show: false
+  voltage:
+    show: true
+    color: "#00FF00"
+    width: 2
+`
+
+	_, err = tmpfile.WriteString(yamlContent)
+	require.NoError(t, err)
+	require.NoError(t, tmpfile.Close())
+
+	cfg, err := Load(tmpfile.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, Default().Scope.Reading, cfg.Scope.Reading, "untouched trace keeps its defaults")
+	assert.False(t, cfg.Scope.Derivative.Show)
+	assert.Equal(t, "#64C8FF", cfg.Scope.Derivative.Color, "default color")
+	assert.Equal(t, TraceConfig{Show: true, Color: "#00FF00", Width: 2}, cfg.Scope.Voltage)
+}
+
+func TestEnsureDefaults_ScopeTheme(t *testing.T) {
+	cfg := &Config{}
+	cfg.Scope.Grid.HDivisions = 5
+	cfg.ensureDefaults()
+
+	assert.Equal(t, 5, cfg.Scope.Grid.HDivisions)
+	assert.Equal(t, 10, cfg.Scope.Grid.VDivisions)
+	assert.Equal(t, "#141414", cfg.Scope.Background)
+	assert.Equal(t, "#0064C8", cfg.Scope.PulseMarkers.Color)
+	assert.Equal(t, "system", cfg.UI.Theme)
+	assert.Equal(t, "system", cfg.UI.Language)
+	assert.Equal(t, "mW", cfg.UI.PowerUnit)
+	assert.Equal(t, "mV", cfg.UI.ReadingUnit)
+}
+
+func TestScopeConfig_Palette(t *testing.T) {
+	cfg := Default()
+	assert.Equal(t, DarkScopePalette, cfg.Scope.Palette(), "defaults are the dark palette")
+
+	cfg.Scope.SetPalette(LightScopePalette)
+	assert.Equal(t, LightScopePalette, cfg.Scope.Palette())
+	assert.Equal(t, "#FFFFFF", cfg.Scope.Background)
+	assert.Equal(t, float32(1.5), cfg.Scope.Reading.Width, "widths kept")
+	assert.True(t, cfg.Scope.PulseMarkers.Show, "visibility kept")
+}
+
+func TestLoad_ScopeRanges(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_config_*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+
+	yamlContent := `
+scope:
+  reading_range:
+    min: -10
+    max: 10
+`
+
+	_, err = tmpfile.WriteString(yamlContent)
+	require.NoError(t, err)
+	require.NoError(t, tmpfile.Close())
+
+	cfg, err := Load(tmpfile.Name())
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Scope.ReadingRange.Fixed())
+	assert.Equal(t, AxisRange{Min: -10, Max: 10}, cfg.Scope.ReadingRange)
+	assert.False(t, cfg.Scope.DerivativeRange.Fixed(), "auto-scaled by default")
+	assert.Equal(t, "roll", cfg.Scope.Display, "default display mode")
+	assert.Equal(t, "relative", cfg.Scope.TimeAxis, "default time axis")
+	assert.Equal(t, "overlay", cfg.Scope.Layout, "default layout")
+	assert.True(t, cfg.Scope.Envelope, "envelope band on by default")
+	assert.True(t, cfg.Scope.HeaterLane, "heater lane on by default")
+	assert.Equal(t, 10*time.Minute, cfg.Scope.History, "default history")
+	assert.Equal(t, Default().Scope.Grid, cfg.Scope.Grid, "default grid")
+}
+
 func TestSave(t *testing.T) {
 	cfg := Default()
 	cfg.Serial.Port = "/dev/ttyUSB0"
@@ -155,3 +242,182 @@ func TestConfig_FieldAccess(t *testing.T) {
 	assert.Equal(t, float64(511), cfg.Heaters[1].Resistance)
 	assert.Equal(t, float64(240.8), cfg.Heaters[2].Resistance)
 }
+
+func TestConfig_SampleRate(t *testing.T) {
+	cfg := Default()
+	assert.Equal(t, DefaultSampleRate, cfg.SampleRate())
+
+	cfg.Serial.SampleRate = 25
+	assert.Equal(t, float64(25), cfg.SampleRate())
+
+	cfg.Serial.NegotiatedRate = 20
+	assert.Equal(t, float64(20), cfg.SampleRate())
+	assert.Equal(t, 150*time.Millisecond, cfg.SamplesDuration(3))
+}
+
+func TestConfig_SampleBasedWindows(t *testing.T) {
+	cfg := Default()
+	cfg.Serial.NegotiatedRate = 10
+
+	// Durations apply until a sample count is given
+	assert.Equal(t, cfg.Measurement.SpikeFilterWindowSize, cfg.SpikeFilterWindow())
+	assert.Equal(t, cfg.Measurement.ChangeFilterWindowSize, cfg.ChangeFilterWindow())
+	assert.Equal(t, time.Second, cfg.MinPulseWindow())
+
+	cfg.Measurement.SpikeFilterSamples = 3
+	cfg.Measurement.ChangeFilterSamples = 5
+	cfg.Measurement.MinPulseSamples = 20
+	assert.Equal(t, 300*time.Millisecond, cfg.SpikeFilterWindow())
+	assert.Equal(t, 500*time.Millisecond, cfg.ChangeFilterWindow())
+	assert.Equal(t, 2*time.Second, cfg.MinPulseWindow())
+}
+
+func TestConfig_NegotiatedRateNotSaved(t *testing.T) {
+	cfg := Default()
+	cfg.Serial.SampleRate = 25
+	cfg.Serial.NegotiatedRate = 24.4
+
+	tmpfile, err := os.CreateTemp("", "config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	require.NoError(t, cfg.Save(tmpfile.Name()))
+	loaded, err := Load(tmpfile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, float64(25), loaded.Serial.SampleRate)
+	assert.Zero(t, loaded.Serial.NegotiatedRate)
+}
+
+func TestScopeConfig_Views(t *testing.T) {
+	cfg := Default()
+	_, ok := cfg.Scope.View("CW")
+	assert.False(t, ok, "no views by default")
+
+	cw := ScopeView{
+		Name:         "CW",
+		Span:         30 * time.Second,
+		Traces:       TraceVisibility{Reading: true},
+		ReadingRange: AxisRange{Min: 0, Max: 500},
+		Trigger:      "roll",
+	}
+	cfg.Scope.SetView(ScopeView{Name: "Calibration", Trigger: "single"})
+	cfg.Scope.SetView(ScopeView{Name: "CW"})
+	cfg.Scope.SetView(cw)
+	require.Len(t, cfg.Scope.Views, 2, "saving a view again replaces it")
+
+	tmpfile, err := os.CreateTemp("", "config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	require.NoError(t, cfg.Save(tmpfile.Name()))
+	loaded, err := Load(tmpfile.Name())
+	require.NoError(t, err)
+	got, ok := loaded.Scope.View("CW")
+	require.True(t, ok)
+	assert.Equal(t, cw, got)
+	assert.Equal(t, "Calibration", loaded.Scope.Views[0].Name, "views keep their order")
+}
+
+func TestConfig_SwitchProfile(t *testing.T) {
+	cfg := Default()
+	assert.Error(t, cfg.SwitchProfile("Head A"), "no profiles by default")
+
+	cfg.SetProfile(cfg.CurrentProfile("Head A"))
+	headB := cfg.CurrentProfile("Head B")
+	headB.Port = "/dev/ttyUSB1"
+	headB.VoltageDivider.R1 = 47000
+	headB.Heaters = []HeaterConfig{{Resistance: 50}, {Resistance: 60}, {Resistance: 70}}
+	headB.PowerPolynomial = []float64{0, 2, 0, 0}
+	cfg.SetProfile(headB)
+	cfg.SetProfile(cfg.CurrentProfile("Head B"))
+	require.Len(t, cfg.Profiles, 2, "saving a profile again replaces it")
+	cfg.SetProfile(headB)
+
+	require.NoError(t, cfg.SwitchProfile("Head A"))
+	cfg.Heaters[0].Resistance = 33 // Edited while Head A is in use
+	require.NoError(t, cfg.SwitchProfile("Head B"))
+	assert.Equal(t, "Head B", cfg.Profile)
+	assert.Equal(t, "/dev/ttyUSB1", cfg.Serial.Port)
+	assert.Equal(t, 47000.0, cfg.VoltageDivider.R1)
+	assert.Equal(t, 50.0, cfg.Heaters[0].Resistance)
+	assert.Equal(t, []float64{0, 2, 0, 0}, cfg.Measurement.PowerPolynomial)
+
+	headA, ok := cfg.FindProfile("Head A")
+	require.True(t, ok)
+	assert.Equal(t, 33.0, headA.Heaters[0].Resistance, "edits are kept in the profile switched from")
+
+	tmpfile, err := os.CreateTemp("", "config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	require.NoError(t, cfg.Save(tmpfile.Name()))
+	loaded, err := Load(tmpfile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "Head B", loaded.Profile)
+	assert.Equal(t, cfg.Profiles, loaded.Profiles)
+}
+
+func TestConfig_UseDeviceProfile(t *testing.T) {
+	cfg := Default()
+	cfg.Serial.Port = "COM7"
+	headA := cfg.CurrentProfile("Head A")
+	headA.Serial = "A1"
+	headA.Port = "COM3"
+	headA.VoltageDivider.R1 = 47000
+	headA.PowerPolynomial = []float64{0, 2, 0, 0}
+	cfg.SetProfile(headA)
+	cfg.SetProfile(cfg.CurrentProfile("Head B"))
+
+	_, ok := cfg.UseDeviceProfile("")
+	assert.False(t, ok, "no serial number")
+	_, ok = cfg.UseDeviceProfile("B2")
+	assert.False(t, ok, "unknown head")
+	assert.Empty(t, cfg.Profile)
+
+	name, ok := cfg.UseDeviceProfile("A1")
+	require.True(t, ok)
+	assert.Equal(t, "Head A", name)
+	assert.Equal(t, "Head A", cfg.Profile)
+	assert.Equal(t, "COM7", cfg.Serial.Port, "the head is where it was found")
+	assert.Equal(t, 47000.0, cfg.VoltageDivider.R1)
+	assert.Equal(t, []float64{0, 2, 0, 0}, cfg.Measurement.PowerPolynomial)
+
+	_, ok = cfg.UseDeviceProfile("A1")
+	assert.False(t, ok, "already in use")
+
+	// Switching away keeps the head's serial number in its profile
+	require.NoError(t, cfg.SwitchProfile("Head B"))
+	headA, ok = cfg.FindProfile("Head A")
+	require.True(t, ok)
+	assert.Equal(t, "A1", headA.Serial)
+	assert.Equal(t, "COM7", headA.Port)
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := Default()
+	cfg.SetProfile(cfg.CurrentProfile("Head A"))
+	headB := cfg.CurrentProfile("Head B")
+	headB.Port = "/dev/ttyACM1"
+	headB.PowerPolynomial = []float64{0, 2, 0, 0}
+	cfg.SetProfile(headB)
+	cfg.Profile = "Head A"
+	require.NoError(t, cfg.Save(path))
+
+	loaded, err := LoadProfile(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Head A", loaded.Profile, "the profile last in use is the default")
+	assert.Equal(t, "COM3", loaded.Serial.Port)
+
+	loaded, err = LoadProfile(path, "Head B")
+	require.NoError(t, err)
+	assert.Equal(t, "Head B", loaded.Profile)
+	assert.Equal(t, "/dev/ttyACM1", loaded.Serial.Port)
+	assert.Equal(t, []float64{0, 2, 0, 0}, loaded.Measurement.PowerPolynomial)
+
+	_, err = LoadProfile(path, "Head C")
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the names of the environment variables overriding
+// settings of the configuration file.
+const EnvPrefix = "GOLPM_"
+
+// envOverride is a setting overridden by an environment variable.
+type envOverride struct {
+	index []int         // Index of the field in Config, see reflect.Value.FieldByIndex
+	env   reflect.Value // Value of the variable
+	file  reflect.Value // Value overridden, which Save writes
+}
+
+// applyEnv overrides settings with the environment variables named after
+// their YAML keys, upper-cased and joined by underscores after EnvPrefix,
+// e.g. GOLPM_SERIAL_PORT for serial.port or
+// GOLPM_MEASUREMENT_WINDOW_SECONDS for measurement.window_seconds. Values
+// are YAML, so durations read as "1s" and lists as "[0, 1, 0, 0]". lookup
+// is os.LookupEnv outside tests.
+//
+// The overridden values are kept, so the environment is not saved with the
+// configuration and switching profiles keeps it in effect.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	overrides, err := envOverrides(reflect.TypeFor[Config](), nil, strings.TrimSuffix(EnvPrefix, "_"), lookup)
+	if err != nil {
+		return err
+	}
+	c.env = overrides
+	c.useEnvValues()
+	return nil
+}
+
+// envOverrides returns the overrides of the fields of the struct type t,
+// at index within Config, from the variables named prefix followed by
+// their keys.
+func envOverrides(t reflect.Type, index []int, prefix string, lookup func(string) (string, bool)) ([]envOverride, error) {
+	var overrides []envOverride
+	for i := range t.NumField() {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(key)
+		fieldIndex := append(slices.Clone(index), i)
+
+		if f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeFor[time.Time]() {
+			nested, err := envOverrides(f.Type, fieldIndex, name, lookup)
+			if err != nil {
+				return nil, err
+			}
+			overrides = append(overrides, nested...)
+			continue
+		}
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		env := reflect.New(f.Type)
+		if err := yaml.Unmarshal([]byte(value), env.Interface()); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		overrides = append(overrides, envOverride{index: fieldIndex, env: env.Elem()})
+	}
+	return overrides, nil
+}
+
+// useEnvValues keeps the values of the overridden settings as the file's
+// and sets them to the environment's.
+func (c *Config) useEnvValues() {
+	v := reflect.ValueOf(c).Elem()
+	for i, o := range c.env {
+		field := v.FieldByIndex(o.index)
+		c.env[i].file = reflect.New(field.Type()).Elem()
+		c.env[i].file.Set(field)
+		field.Set(o.env)
+	}
+}
+
+// useFileValues sets the overridden settings back to the file's values.
+func (c *Config) useFileValues() {
+	v := reflect.ValueOf(c).Elem()
+	for _, o := range c.env {
+		v.FieldByIndex(o.index).Set(o.file)
+	}
+}
+
+// fileConfig returns the configuration as the file has it, without the
+// environment. It shares lists with c.
+func (c *Config) fileConfig() *Config {
+	file := *c
+	file.useFileValues()
+	file.env = nil
+	return &file
+}
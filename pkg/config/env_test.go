@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"GOLPM_SERIAL_PORT":                   "/dev/ttyUSB0",
+		"GOLPM_SERIAL_STALE_TIMEOUT":          "3s",
+		"GOLPM_SERIAL_DTR":                    "false",
+		"GOLPM_MEASUREMENT_WINDOW_SECONDS":    "20",
+		"GOLPM_MEASUREMENT_POWER_POLYNOMIAL":  "[0, 2, 0, 0]",
+		"GOLPM_SCOPE_GRID_H_DIVISIONS":        "12",
+		"GOLPM_CALIBRATION_DATE":              "2024-05-01T12:00:00Z",
+		"GOLPM_SERIAL_NEGOTIATEDRATE":         "100", // Not a setting
+		"GOLPM_MEASUREMENT_DOWNSAMPLE_RATE":   "0s",
+		"GOLPM_UNKNOWN_SETTING":               "1",
+		"GOLPM_ALARMS_HEATER_MAX_ON":          "1m",
+		"GOLPM_HEATERS":                       "[{resistance: 100}, {resistance: 200}, {resistance: 300}]",
+		"GOLPM_UI_POWER_UNIT":                 "dBm",
+		"GOLPM_MOCK_LASER_POWER":              "12.5",
+		"GOLPM_SCOPE_READING_SHOW":            "false",
+		"GOLPM_MEASUREMENT_MIN_PULSE_SAMPLES": "7",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	cfg := Default()
+	require.NoError(t, cfg.applyEnv(lookup))
+	assert.Equal(t, "/dev/ttyUSB0", cfg.Serial.Port)
+	assert.Equal(t, 3*time.Second, cfg.Serial.StaleTimeout)
+	require.NotNil(t, cfg.Serial.DTR)
+	assert.False(t, *cfg.Serial.DTR)
+	assert.Equal(t, 20.0, cfg.Measurement.WindowSeconds)
+	assert.Equal(t, []float64{0, 2, 0, 0}, cfg.Measurement.PowerPolynomial)
+	assert.Equal(t, 12, cfg.Scope.Grid.HDivisions)
+	assert.True(t, cfg.Calibration.Date.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Zero(t, cfg.Serial.NegotiatedRate)
+	require.NotNil(t, cfg.Measurement.DownsampleRate)
+	assert.Zero(t, *cfg.Measurement.DownsampleRate)
+	assert.Equal(t, time.Minute, cfg.Alarms.HeaterMaxOn)
+	assert.Equal(t, []HeaterConfig{{100}, {200}, {300}}, cfg.Heaters)
+	assert.Equal(t, "dBm", cfg.UI.PowerUnit)
+	assert.Equal(t, 12.5, cfg.Mock.LaserPower)
+	assert.False(t, cfg.Scope.Reading.Show)
+	assert.Equal(t, 7, cfg.Measurement.MinPulseSamples)
+
+	env = map[string]string{"GOLPM_MEASUREMENT_WINDOW_SECONDS": "ten"}
+	err := Default().applyEnv(lookup)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GOLPM_MEASUREMENT_WINDOW_SECONDS")
+}
+
+func TestLoad_Env(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := Default()
+	cfg.Serial.Port = "COM7"
+	cfg.Measurement.WindowSeconds = 5
+	require.NoError(t, cfg.Save(path))
+
+	t.Setenv("GOLPM_SERIAL_PORT", "/dev/ttyACM0")
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "/dev/ttyACM0", loaded.Serial.Port, "the environment overrides the file")
+	assert.Equal(t, 5.0, loaded.Measurement.WindowSeconds)
+
+	loaded, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "/dev/ttyACM0", loaded.Serial.Port, "and the defaults")
+
+	t.Setenv("GOLPM_SERIAL_BAUD_RATE", "fast")
+	_, err = Load(path)
+	assert.Error(t, err)
+}
+
+func TestLoad_EnvNotSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := Default()
+	cfg.Serial.Port = "COM7"
+	cfg.Heaters = []HeaterConfig{{100}, {200}}
+	cfg.SetProfile(cfg.CurrentProfile("bench"))
+	cfg.Profile = "bench"
+	require.NoError(t, cfg.Save(path))
+
+	t.Setenv("GOLPM_SERIAL_PORT", "/dev/ttyACM0")
+	t.Setenv("GOLPM_HEATERS", "[{resistance: 300}]")
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "/dev/ttyACM0", loaded.Serial.Port)
+	loaded.Measurement.WindowSeconds = 7
+	loaded.SetProfile(loaded.CurrentProfile("bench"))
+	require.NoError(t, loaded.Save(path))
+
+	os.Unsetenv("GOLPM_SERIAL_PORT")
+	os.Unsetenv("GOLPM_HEATERS")
+	loaded, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "COM7", loaded.Serial.Port, "the file's value is saved")
+	assert.Equal(t, []HeaterConfig{{100}, {200}}, loaded.Heaters)
+	assert.Equal(t, 7.0, loaded.Measurement.WindowSeconds, "and the edits")
+	p, ok := loaded.FindProfile("bench")
+	require.True(t, ok)
+	assert.Equal(t, "COM7", p.Port, "profiles keep the file's values")
+	assert.Equal(t, []HeaterConfig{{100}, {200}}, p.Heaters)
+}
+
+func TestLoadProfile_Env(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := Default()
+	cfg.Serial.Port = "COM7"
+	cfg.SetProfile(cfg.CurrentProfile("bench"))
+	cfg.SetProfile(Profile{
+		Name:            "head",
+		Port:            "COM9",
+		VoltageDivider:  VoltageDividerConfig{R1: 10000, R2: 4700, VRef: 3.3},
+		PowerPolynomial: []float64{0, 3, 0, 0},
+	})
+	cfg.Profile = "bench"
+	require.NoError(t, cfg.Save(path))
+
+	t.Setenv("GOLPM_SERIAL_PORT", "/dev/ttyACM0")
+	t.Setenv("GOLPM_MEASUREMENT_POWER_POLYNOMIAL", "[0, 2, 0, 0]")
+	loaded, err := LoadProfile(path, "head")
+	require.NoError(t, err)
+	assert.Equal(t, "head", loaded.Profile)
+	assert.Equal(t, "/dev/ttyACM0", loaded.Serial.Port, "the environment overrides the profile")
+	assert.Equal(t, []float64{0, 2, 0, 0}, loaded.Measurement.PowerPolynomial)
+	assert.Equal(t, 4700.0, loaded.VoltageDivider.R2, "where it sets something")
+
+	require.NoError(t, loaded.SwitchProfile("bench"))
+	assert.Equal(t, "/dev/ttyACM0", loaded.Serial.Port)
+	p, ok := loaded.FindProfile("head")
+	require.True(t, ok)
+	assert.Equal(t, "COM9", p.Port, "the profile switched from keeps its own values")
+	assert.Equal(t, []float64{0, 3, 0, 0}, p.PowerPolynomial)
+
+	require.NoError(t, loaded.Save(path))
+	os.Unsetenv("GOLPM_SERIAL_PORT")
+	os.Unsetenv("GOLPM_MEASUREMENT_POWER_POLYNOMIAL")
+	loaded, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "bench", loaded.Profile)
+	assert.Equal(t, "COM7", loaded.Serial.Port)
+}
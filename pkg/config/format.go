@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration file formats, told by the file extension.
+const (
+	FormatYAML = "yaml" // .yaml, .yml and any other extension
+	FormatJSON = "json" // .json
+	FormatTOML = "toml" // .toml
+)
+
+// FileFormat returns the format of the configuration file filename by its
+// extension.
+func FileFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatYAML
+	}
+}
+
+// toYAML converts a configuration file's contents in format to YAML. JSON
+// and TOML files use the same keys as YAML, so all formats read into Config
+// through its YAML tags.
+func toYAML(format string, data []byte) ([]byte, error) {
+	var doc map[string]any
+	switch format {
+	case FormatJSON:
+		if len(bytes.TrimSpace(data)) == 0 {
+			return nil, nil
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		if _, err := toml.Decode(string(data), &doc); err != nil {
+			return nil, err
+		}
+	default:
+		return data, nil
+	}
+	return yaml.Marshal(doc)
+}
+
+// marshal encodes c in format. Given the base c includes, only the
+// settings differing from it are encoded.
+func (c *Config) marshal(format string, base *Config) ([]byte, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil || (format == FormatYAML && base == nil) {
+		return data, err
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if base != nil {
+		if doc, err = dropIncluded(doc, base); err != nil {
+			return nil, err
+		}
+		doc["include"] = c.Include
+	}
+	switch format {
+	case FormatYAML:
+		// The includes first, as they are read first
+		delete(doc, "include")
+		head, err := yaml.Marshal(map[string]any{"include": c.Include})
+		if err != nil || len(doc) == 0 {
+			return head, err
+		}
+		data, err := yaml.Marshal(doc)
+		return append(head, data...), err
+	case FormatJSON:
+		data, err = json.MarshalIndent(doc, "", "  ")
+		return append(data, '\n'), err
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(dropNil(doc)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+}
+
+// dropNil removes the nil values of doc, which TOML cannot express; unset
+// settings read back as their defaults.
+func dropNil(doc map[string]any) map[string]any {
+	for k, v := range doc {
+		switch v := v.(type) {
+		case nil:
+			delete(doc, k)
+		case map[string]any:
+			dropNil(v)
+		case []any:
+			for _, e := range v {
+				if m, ok := e.(map[string]any); ok {
+					dropNil(m)
+				}
+			}
+		}
+	}
+	return doc
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFormat(t *testing.T) {
+	assert.Equal(t, FormatYAML, FileFormat("config.yaml"))
+	assert.Equal(t, FormatYAML, FileFormat("config.yml"))
+	assert.Equal(t, FormatYAML, FileFormat("config"))
+	assert.Equal(t, FormatJSON, FileFormat("/etc/golpm/config.JSON"))
+	assert.Equal(t, FormatTOML, FileFormat("config.toml"))
+}
+
+func TestSaveLoad_Formats(t *testing.T) {
+	dtr := false
+	cfg := Default()
+	cfg.Serial.Port = "/dev/ttyACM0"
+	cfg.Serial.DTR = &dtr
+	cfg.Measurement.WindowSeconds = 12.5
+	cfg.Measurement.PowerPolynomial = []float64{0, 2, 0.5, 0}
+	cfg.Calibration.Date = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cfg.Calibration.Points = []CalibrationPoint{{Slope: 0.001, Power: 10}}
+	cfg.SetProfile(cfg.CurrentProfile("Head A"))
+
+	// Every format reads back the same as YAML does
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, cfg.Save(path))
+	want, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Head A", want.Profiles[0].Name)
+
+	for _, ext := range []string{".json", ".toml"} {
+		path := filepath.Join(t.TempDir(), "config"+ext)
+		require.NoError(t, cfg.Save(path), ext)
+		loaded, err := Load(path)
+		require.NoError(t, err, ext)
+		assert.Equal(t, want, loaded, ext)
+	}
+}
+
+func TestLoad_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "serial": {"port": "COM5", "stale_timeout": "3s"},
+  "measurement": {"window_seconds": 20}
+}`), 0644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "COM5", cfg.Serial.Port)
+	assert.Equal(t, 3*time.Second, cfg.Serial.StaleTimeout)
+	assert.Equal(t, 20.0, cfg.Measurement.WindowSeconds)
+	assert.Equal(t, 115200, cfg.Serial.BaudRate, "defaults fill in")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"serial": `), 0644))
+	_, err = Load(path)
+	assert.Error(t, err)
+}
+
+func TestLoad_TOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[serial]
+port = "/dev/ttyUSB0"
+stale_timeout = "3s"
+
+[measurement]
+window_seconds = 20
+power_polynomial = [0.0, 1.5, 0.0, 0.0]
+
+[[heaters]]
+resistance = 100.0
+
+[[heaters]]
+resistance = 200.0
+
+[[heaters]]
+resistance = 300.0
+`), 0644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "/dev/ttyUSB0", cfg.Serial.Port)
+	assert.Equal(t, 3*time.Second, cfg.Serial.StaleTimeout)
+	assert.Equal(t, 20.0, cfg.Measurement.WindowSeconds)
+	assert.Equal(t, []float64{0, 1.5, 0, 0}, cfg.Measurement.PowerPolynomial)
+	assert.Equal(t, []HeaterConfig{{100}, {200}, {300}}, cfg.Heaters)
+}
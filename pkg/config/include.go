@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// layer is the contents of one configuration file, converted to YAML.
+type layer struct {
+	filename string // Absolute path
+	data     []byte
+}
+
+// readLayers reads filename and the files it includes (see Config.Include).
+// Included files come first, in the order listed and each after the files
+// it includes in turn, so every layer overrides the ones before it. A
+// missing filename has no layers and reads as defaults, while a missing
+// included file is an error.
+func readLayers(filename string) ([]layer, error) {
+	return readLayer(filename, nil, true)
+}
+
+// readLayer reads filename and its includes for readLayers. including lists
+// the files that led to filename, to catch files including themselves.
+func readLayer(filename string, including []string, optional bool) ([]layer, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if slices.Contains(including, abs) {
+		return nil, fmt.Errorf("config file %s includes itself", filename)
+	}
+
+	data, err := os.ReadFile(abs)
+	if optional && os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	data, err = toYAML(FileFormat(abs), data)
+	var head struct {
+		Include []string `yaml:"include"`
+	}
+	if err == nil {
+		err = yaml.Unmarshal(data, &head)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+	}
+
+	var layers []layer
+	for _, name := range head.Include {
+		included, err := readLayer(includePath(abs, name), append(slices.Clip(including), abs), false)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, included...)
+	}
+	return append(layers, layer{filename: abs, data: data}), nil
+}
+
+// includePath resolves the included file name relative to the directory of
+// the file including it.
+func includePath(from, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(filepath.Dir(from), name)
+}
+
+// overlay reads the layers over c in order.
+func (c *Config) overlay(layers []layer) error {
+	for _, l := range layers {
+		if err := yaml.Unmarshal(l.data, c); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", l.filename, err)
+		}
+	}
+	return nil
+}
+
+// includedBase returns the configuration that the files included by the
+// file filename amount to, without the environment. Save writes only the
+// settings differing from it, so the included files keep providing the rest.
+func includedBase(filename string, include []string) (*Config, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	base := Default()
+	for _, name := range include {
+		layers, err := readLayer(includePath(abs, name), []string{abs}, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := base.overlay(layers); err != nil {
+			return nil, err
+		}
+	}
+	base.ensureDefaults()
+	return base, nil
+}
+
+// dropIncluded removes the settings of doc, an encoded configuration, that
+// equal those of base, leaving the overrides. Sections left without an
+// override are removed as a whole; lists are overridden as a whole.
+func dropIncluded(doc map[string]any, base *Config) (map[string]any, error) {
+	data, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	var baseDoc map[string]any
+	if err := yaml.Unmarshal(data, &baseDoc); err != nil {
+		return nil, err
+	}
+	return dropBase(doc, baseDoc), nil
+}
+
+// dropBase removes the settings of doc that equal those of base for
+// dropIncluded.
+func dropBase(doc, base map[string]any) map[string]any {
+	for k, v := range doc {
+		b, ok := base[k]
+		if !ok {
+			continue
+		}
+		if m, ok := v.(map[string]any); ok {
+			if bm, ok := b.(map[string]any); ok {
+				if len(dropBase(m, bm)) == 0 {
+					delete(doc, k)
+				}
+				continue
+			}
+		}
+		if reflect.DeepEqual(v, b) {
+			delete(doc, k)
+		}
+	}
+	return doc
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLab writes a lab's shared defaults, a TOML file they include, and a
+// bench's overrides including them, returning the bench's file.
+func writeLab(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "shared"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared", "heads.toml"), []byte(`
+[voltage_divider]
+r1 = 47000.0
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared", "base.yaml"), []byte(`include: [heads.toml]
+measurement:
+  window_seconds: 20
+heaters:
+  - resistance: 100
+  - resistance: 200
+`), 0644))
+	site := filepath.Join(dir, "site.yaml")
+	require.NoError(t, os.WriteFile(site, []byte(`include: [shared/base.yaml]
+serial:
+  port: /dev/ttyACM1
+measurement:
+  window_seconds: 30
+`), 0644))
+	return site
+}
+
+func TestLoad_Include(t *testing.T) {
+	site := writeLab(t)
+
+	cfg, err := Load(site)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shared/base.yaml"}, cfg.Include)
+	assert.Equal(t, "/dev/ttyACM1", cfg.Serial.Port)
+	assert.Equal(t, 30.0, cfg.Measurement.WindowSeconds, "the bench overrides the lab")
+	assert.Equal(t, []HeaterConfig{{Resistance: 100}, {Resistance: 200}}, cfg.Heaters)
+	assert.Equal(t, 47000.0, cfg.VoltageDivider.R1, "includes of includes are read")
+	assert.Equal(t, Default().VoltageDivider.R2, cfg.VoltageDivider.R2, "defaults fill in")
+
+	// A missing included file is a mistake, not defaults
+	dir := filepath.Dir(site)
+	require.NoError(t, os.WriteFile(site, []byte("include: [missing.yaml]\n"), 0644))
+	_, err = Load(site)
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("include: [site.yaml]\n"), 0644))
+	require.NoError(t, os.WriteFile(site, []byte("include: [a.yaml]\n"), 0644))
+	_, err = Load(site)
+	assert.ErrorContains(t, err, "includes itself")
+}
+
+func TestSave_Include(t *testing.T) {
+	site := writeLab(t)
+	cfg, err := Load(site)
+	require.NoError(t, err)
+
+	cfg.Measurement.PulseThresholdMVS = 0.8
+	require.NoError(t, cfg.Save(site))
+
+	data, err := os.ReadFile(site)
+	require.NoError(t, err)
+	assert.Equal(t, `include:
+    - shared/base.yaml
+measurement:
+    pulse_threshold_mvs: 0.8
+    window_seconds: 30
+serial:
+    port: /dev/ttyACM1
+`, string(data), "only the overrides are saved")
+
+	loaded, err := Load(site)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, loaded)
+
+	// The lab's later changes still apply to the bench
+	base := filepath.Join(filepath.Dir(site), "shared", "base.yaml")
+	require.NoError(t, os.WriteFile(base, []byte("heaters:\n  - resistance: 300\n"), 0644))
+	loaded, err = Load(site)
+	require.NoError(t, err)
+	assert.Equal(t, []HeaterConfig{{Resistance: 300}}, loaded.Heaters)
+	assert.Equal(t, 30.0, loaded.Measurement.WindowSeconds)
+}
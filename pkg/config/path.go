@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// LegacyFile is the configuration file in the working directory used before
+// the per-user location. It is copied there on first use.
+const LegacyFile = "config.yaml"
+
+// DefaultPath returns the per-user configuration file: golpm/config.yaml in
+// os.UserConfigDir, e.g. ~/.config on Linux, %AppData% on Windows and
+// ~/Library/Application Support on macOS.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user configuration directory: %w", err)
+	}
+	return filepath.Join(dir, "golpm", "config.yaml"), nil
+}
+
+// ResolvePath returns the configuration file to use: path if given, e.g. by
+// a -config flag, else DefaultPath. The default's directory is created, and
+// a LegacyFile in the working directory is copied to it if it has none yet.
+func ResolvePath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	def, err := DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	if err := prepareDefault(def, LegacyFile); err != nil {
+		return "", err
+	}
+	return def, nil
+}
+
+// prepareDefault creates the directory of the default configuration file
+// def and copies legacy to def unless def exists.
+func prepareDefault(def, legacy string) error {
+	if err := os.MkdirAll(filepath.Dir(def), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if _, err := os.Stat(def); !os.IsNotExist(err) {
+		return nil // Present, or unreadable and reported when loading
+	}
+	data, err := os.ReadFile(legacy)
+	if os.IsNotExist(err) {
+		return nil // Nothing to migrate; defaults are used until saved
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate config file: %w", err)
+	}
+	if err := os.WriteFile(def, data, 0644); err != nil {
+		return fmt.Errorf("failed to migrate config file: %w", err)
+	}
+	log.Printf("Copied %s to %s, which is used from now on", legacy, def)
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePath_Override(t *testing.T) {
+	path, err := ResolvePath("other.toml")
+	require.NoError(t, err)
+	assert.Equal(t, "other.toml", path)
+}
+
+func TestPrepareDefault(t *testing.T) {
+	dir := t.TempDir()
+	def := filepath.Join(dir, "user", "golpm", "config.yaml")
+	legacy := filepath.Join(dir, "config.yaml")
+
+	// Without a legacy file only the directory is created
+	require.NoError(t, prepareDefault(def, legacy))
+	assert.DirExists(t, filepath.Dir(def))
+	assert.NoFileExists(t, def)
+
+	// A legacy file is copied once
+	require.NoError(t, os.WriteFile(legacy, []byte("serial:\n  port: COM7\n"), 0644))
+	require.NoError(t, prepareDefault(def, legacy))
+	cfg, err := Load(def)
+	require.NoError(t, err)
+	assert.Equal(t, "COM7", cfg.Serial.Port)
+	assert.FileExists(t, legacy, "the legacy file is kept")
+
+	// and never overwrites the file in use
+	require.NoError(t, os.WriteFile(legacy, []byte("serial:\n  port: COM9\n"), 0644))
+	require.NoError(t, prepareDefault(def, legacy))
+	cfg, err = Load(def)
+	require.NoError(t, err)
+	assert.Equal(t, "COM7", cfg.Serial.Port)
+}
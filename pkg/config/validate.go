@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxHeaters is the most heaters a device can have, as lpm.MaxHeaters:
+// binary frames carry the heater states in one byte.
+const maxHeaters = 8
+
+// ValidationError is a setting out of its valid range.
+type ValidationError struct {
+	Field   string // YAML path of the setting, e.g. "voltage_divider.vref"
+	Message string // What is wrong with it, e.g. "must be greater than 0"
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors lists every setting out of its valid range.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, v := range e {
+		lines[i] = v.Error()
+	}
+	return "invalid configuration: " + strings.Join(lines, "; ")
+}
+
+// Validate checks the settings' ranges and returns ValidationErrors listing
+// every setting out of range, or nil if all are valid.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	positive := func(field string, v float64) {
+		if !(v > 0) {
+			errs = append(errs, ValidationError{field, "must be greater than 0"})
+		}
+	}
+	notNegative := func(field string, v float64) {
+		if !(v >= 0) {
+			errs = append(errs, ValidationError{field, "must not be negative"})
+		}
+	}
+	fraction := func(field string, v float64) {
+		if !(v >= 0 && v <= 1) {
+			errs = append(errs, ValidationError{field, "must be between 0 and 1"})
+		}
+	}
+
+	notNegative("serial.sample_rate", c.Serial.SampleRate)
+	notNegative("serial.averaging", float64(c.Serial.Averaging))
+
+	positive("voltage_divider.r1", c.VoltageDivider.R1)
+	positive("voltage_divider.r2", c.VoltageDivider.R2)
+	positive("voltage_divider.vref", c.VoltageDivider.VRef)
+
+	positive("ambient.r25", c.Ambient.R25)
+	positive("ambient.r_series", c.Ambient.RSeries)
+
+	if len(c.Heaters) > maxHeaters {
+		errs = append(errs, ValidationError{"heaters", fmt.Sprintf("must list at most %d heaters", maxHeaters)})
+	}
+	for i, h := range c.Heaters {
+		positive(fmt.Sprintf("heaters[%d].resistance", i), h.Resistance)
+	}
+
+	positive("measurement.window_seconds", c.Measurement.WindowSeconds)
+	positive("measurement.pulse_threshold_mvs", c.Measurement.PulseThresholdMVS)
+	fraction("measurement.smoothing_alpha", c.Measurement.SmoothingAlpha)
+	fraction("measurement.change_filter_alpha", c.Measurement.ChangeFilterAlpha)
+
+	notNegative("scope.max_display_points", float64(c.Scope.MaxDisplayPoints))
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, Default().Validate())
+
+	cfg := Default()
+	cfg.VoltageDivider.VRef = 0
+	cfg.Heaters[1].Resistance = -5
+	cfg.Measurement.WindowSeconds = -1
+	cfg.Measurement.PulseThresholdMVS = 0
+	cfg.Measurement.SmoothingAlpha = 1.5
+	cfg.Serial.Averaging = -2
+
+	err := cfg.Validate()
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.Equal(t, ValidationErrors{
+		{"serial.averaging", "must not be negative"},
+		{"voltage_divider.vref", "must be greater than 0"},
+		{"heaters[1].resistance", "must be greater than 0"},
+		{"measurement.window_seconds", "must be greater than 0"},
+		{"measurement.pulse_threshold_mvs", "must be greater than 0"},
+		{"measurement.smoothing_alpha", "must be between 0 and 1"},
+	}, errs)
+	assert.Contains(t, err.Error(), "voltage_divider.vref: must be greater than 0; heaters[1].resistance")
+
+	cfg = Default()
+	for len(cfg.Heaters) < 9 {
+		cfg.Heaters = append(cfg.Heaters, HeaterConfig{Resistance: 100})
+	}
+	assert.EqualError(t, cfg.Validate(), "invalid configuration: heaters: must list at most 8 heaters")
+	cfg.Heaters = cfg.Heaters[:8]
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("voltage_divider:\n  vref: -3.3\nmeasurement:\n  window_seconds: -10\n"), 0644))
+
+	// The invalid configuration is returned to be fixed
+	cfg, err := Load(path)
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 2)
+	require.NotNil(t, cfg)
+	assert.Equal(t, -3.3, cfg.VoltageDivider.VRef)
+
+	// and cannot be saved until it is
+	require.Error(t, cfg.Save(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "vref: -3.3")
+
+	cfg.VoltageDivider.VRef = 3.3
+	cfg.Measurement.WindowSeconds = 10
+	require.NoError(t, cfg.Save(path))
+	_, err = Load(path)
+	assert.NoError(t, err)
+}
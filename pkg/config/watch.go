@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long a configuration file must stay unchanged before
+// it is reloaded, so an editor's several writes reload it once.
+const watchDebounce = 100 * time.Millisecond
+
+// Watcher reloads a configuration file when it or a file it includes
+// changes on disk, e.g. edited by hand or saved by another instance, and
+// passes the new configuration to its subscribers. Writes that leave the
+// contents unchanged and files that do not parse or validate are ignored; a
+// broken file is reloaded once it is fixed.
+type Watcher struct {
+	filename string
+	fs       *fsnotify.Watcher
+	done     chan struct{} // Closed when the watcher has stopped
+
+	mu     sync.Mutex
+	subs   map[int]func(*Config)
+	next   int
+	layers []layer // Contents last loaded, of the file and its includes
+}
+
+// Watch starts watching filename. The directory is watched rather than the
+// file, so files replaced by editors on saving are followed.
+func Watch(filename string) (*Watcher, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+	if err := fs.Add(filepath.Dir(abs)); err != nil {
+		fs.Close()
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	layers, _ := readLayers(abs) // A missing file is watched for creation
+	w := &Watcher{
+		filename: abs,
+		fs:       fs,
+		done:     make(chan struct{}),
+		subs:     make(map[int]func(*Config)),
+		layers:   layers,
+	}
+	w.watchIncludes(layers)
+	go w.run()
+	return w, nil
+}
+
+// Subscribe calls fn with every configuration reloaded until the returned
+// function is called. fn is called from the watcher's goroutine, one reload
+// at a time; a GUI must move to its main thread to apply it.
+func (w *Watcher) Subscribe(fn func(*Config)) (unsubscribe func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := w.next
+	w.next++
+	w.subs[id] = fn
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subs, id)
+	}
+}
+
+// Close stops watching and waits until no subscriber is called anymore.
+func (w *Watcher) Close() error {
+	err := w.fs.Close()
+	<-w.done
+	return err
+}
+
+// run reloads the file once the events on it have settled, until the
+// watcher is closed.
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case ev, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if w.watched(filepath.Clean(ev.Name)) && ev.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				timer.Reset(watchDebounce)
+			}
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Config watcher error: %v", err)
+		case <-timer.C:
+			w.reload()
+		}
+	}
+}
+
+// watched reports whether filename is the file or one of its includes.
+func (w *Watcher) watched(filename string) bool {
+	if filename == w.filename {
+		return true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.ContainsFunc(w.layers, func(l layer) bool { return l.filename == filename })
+}
+
+// watchIncludes watches the directories of included files outside the
+// file's own directory.
+func (w *Watcher) watchIncludes(layers []layer) {
+	for _, l := range layers {
+		if dir := filepath.Dir(l.filename); dir != filepath.Dir(w.filename) {
+			if err := w.fs.Add(dir); err != nil {
+				log.Printf("Failed to watch included config file %s: %v", l.filename, err)
+			}
+		}
+	}
+}
+
+// reload reads the file and its includes and notifies the subscribers if
+// their contents changed and are valid.
+func (w *Watcher) reload() {
+	layers, err := readLayers(w.filename)
+	if err == nil && len(layers) == 0 {
+		return // Removed, or replaced and not written yet
+	}
+
+	w.mu.Lock()
+	if err == nil && slices.EqualFunc(layers, w.layers, sameLayer) {
+		w.mu.Unlock()
+		return
+	}
+	var cfg *Config
+	if err == nil {
+		cfg, err = parse(layers)
+	}
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		w.mu.Unlock()
+		log.Printf("Ignoring invalid config file %s: %v", w.filename, err)
+		return
+	}
+	w.layers = layers
+	subs := make([]func(*Config), 0, len(w.subs))
+	for _, fn := range w.subs {
+		subs = append(subs, fn)
+	}
+	w.mu.Unlock()
+
+	w.watchIncludes(layers)
+	for _, fn := range subs {
+		fn(cfg)
+	}
+}
+
+// sameLayer reports whether a and b are the same file with the same contents.
+func sameLayer(a, b layer) bool {
+	return a.filename == b.filename && bytes.Equal(a.data, b.data)
+}
+
+// ApplyLive takes the settings of n that can change while measuring:
+// everything but the serial connection, which needs a reconnect, and the
+// language, which needs a restart. It returns the names of the settings
+// that differ but were not taken. The negotiated device settings are kept.
+func (c *Config) ApplyLive(n *Config) (skipped []string) {
+	serial := c.Serial
+	serial.NegotiatedRate, serial.NegotiatedGain, serial.NegotiatedReference = 0, 0, 0
+	if !reflect.DeepEqual(serial, n.Serial) {
+		skipped = append(skipped, "serial")
+	}
+	if c.UI.Language != n.UI.Language {
+		skipped = append(skipped, "ui.language")
+	}
+
+	serial, language := c.Serial, c.UI.Language
+	*c = *n
+	c.Serial, c.UI.Language = serial, language
+	return skipped
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("measurement:\n  window_seconds: 5\n"), 0644))
+
+	w, err := Watch(filename)
+	require.NoError(t, err)
+	defer w.Close()
+
+	reloaded := make(chan *Config, 10)
+	unsubscribe := w.Subscribe(func(cfg *Config) { reloaded <- cfg })
+
+	// Rewriting the same contents changes nothing
+	require.NoError(t, os.WriteFile(filename, []byte("measurement:\n  window_seconds: 5\n"), 0644))
+	select {
+	case <-reloaded:
+		t.Fatal("reloaded unchanged contents")
+	case <-time.After(3 * watchDebounce):
+	}
+
+	// A broken file is ignored until fixed
+	require.NoError(t, os.WriteFile(filename, []byte("measurement: [\n"), 0644))
+	select {
+	case <-reloaded:
+		t.Fatal("reloaded a broken file")
+	case <-time.After(3 * watchDebounce):
+	}
+
+	require.NoError(t, os.WriteFile(filename, []byte("measurement:\n  window_seconds: 7\n"), 0644))
+	select {
+	case cfg := <-reloaded:
+		assert.Equal(t, 7.0, cfg.Measurement.WindowSeconds)
+		assert.Equal(t, "COM3", cfg.Serial.Port, "defaults fill in")
+	case <-time.After(2 * time.Second):
+		t.Fatal("not reloaded")
+	}
+
+	// Files replaced by renaming are followed
+	tmp := filename + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, []byte("measurement:\n  window_seconds: 9\n"), 0644))
+	require.NoError(t, os.Rename(tmp, filename))
+	select {
+	case cfg := <-reloaded:
+		assert.Equal(t, 9.0, cfg.Measurement.WindowSeconds)
+	case <-time.After(2 * time.Second):
+		t.Fatal("not reloaded after rename")
+	}
+
+	unsubscribe()
+	require.NoError(t, os.WriteFile(filename, []byte("measurement:\n  window_seconds: 11\n"), 0644))
+	select {
+	case <-reloaded:
+		t.Fatal("notified after unsubscribing")
+	case <-time.After(3 * watchDebounce):
+	}
+}
+
+func TestWatcher_ReloadInclude(t *testing.T) {
+	site := writeLab(t)
+	w, err := Watch(site)
+	require.NoError(t, err)
+	defer w.Close()
+
+	reloaded := make(chan *Config, 10)
+	defer w.Subscribe(func(cfg *Config) { reloaded <- cfg })()
+
+	// A change to a file included from another directory reloads the bench
+	heads := filepath.Join(filepath.Dir(site), "shared", "heads.toml")
+	require.NoError(t, os.WriteFile(heads, []byte("[voltage_divider]\nr1 = 10000.0\n"), 0644))
+	select {
+	case cfg := <-reloaded:
+		assert.Equal(t, 10000.0, cfg.VoltageDivider.R1)
+		assert.Equal(t, 30.0, cfg.Measurement.WindowSeconds)
+	case <-time.After(2 * time.Second):
+		t.Fatal("not reloaded after an included file changed")
+	}
+}
+
+func TestConfig_ApplyLive(t *testing.T) {
+	cfg := Default()
+	cfg.Serial.NegotiatedRate = 100
+
+	n := Default()
+	n.Measurement.WindowSeconds = 30
+	n.Scope.Background = "#000000"
+	assert.Empty(t, cfg.ApplyLive(n), "negotiated settings do not count as changes")
+	assert.Equal(t, 30.0, cfg.Measurement.WindowSeconds)
+	assert.Equal(t, "#000000", cfg.Scope.Background)
+	assert.Equal(t, 100.0, cfg.Serial.NegotiatedRate)
+
+	n = Default()
+	n.Serial.Port = "/dev/ttyACM1"
+	n.UI.Language = "de"
+	n.UI.PowerUnit = "W"
+	assert.Equal(t, []string{"serial", "ui.language"}, cfg.ApplyLive(n))
+	assert.Equal(t, "COM3", cfg.Serial.Port)
+	assert.Equal(t, "system", cfg.UI.Language)
+	assert.Equal(t, "W", cfg.UI.PowerUnit)
+}
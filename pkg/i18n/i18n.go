@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// locales holds the translations of each non-English language as
+// locales/<code>.yaml, a map of the English GUI text to its translation.
+//
+//go:embed locales/*.yaml
+var locales embed.FS
+
+// Language is a GUI language.
+type Language struct {
+	Code string // ISO 639-1 code, e.g. "de"
+	Name string // Name in the language itself
+}
+
+// Languages are the available GUI languages, English first.
+var Languages = []Language{
+	{Code: "en", Name: "English"},
+	{Code: "de", Name: "Deutsch"},
+}
+
+// catalog holds the translations of the selected language, nil for English.
+var catalog atomic.Pointer[map[string]string]
+
+// SetLanguage selects the language of code, e.g. "de" or "de-DE", for T and
+// Tf. English, and any language without translations, shows the text as
+// written in the code; the latter is reported as an error.
+func SetLanguage(code string) error {
+	code, _, _ = strings.Cut(strings.ToLower(code), "-")
+	code, _, _ = strings.Cut(code, "_")
+	if code == "en" || code == "" {
+		catalog.Store(nil)
+		return nil
+	}
+
+	translations, err := Catalog(code)
+	if err != nil {
+		catalog.Store(nil)
+		return err
+	}
+	catalog.Store(&translations)
+	return nil
+}
+
+// Catalog returns the translations of the language of code.
+func Catalog(code string) (map[string]string, error) {
+	data, err := locales.ReadFile("locales/" + code + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("no translations for language %q", code)
+	}
+	var translations map[string]string
+	if err := yaml.Unmarshal(data, &translations); err != nil {
+		return nil, fmt.Errorf("failed to parse %s translations: %w", code, err)
+	}
+	return translations, nil
+}
+
+// T returns text in the selected language, or text itself if it has no
+// translation.
+func T(text string) string {
+	if c := catalog.Load(); c != nil {
+		if t, ok := (*c)[text]; ok && t != "" {
+			return t
+		}
+	}
+	return text
+}
+
+// Tf formats args by the translation of format, which keeps the verbs of
+// format in the same order.
+func Tf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Errorf is fmt.Errorf with the translation of format, for errors shown to
+// the user.
+func Errorf(format string, args ...any) error {
+	return fmt.Errorf(T(format), args...)
+}
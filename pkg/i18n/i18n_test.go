@@ -0,0 +1,103 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLanguage(t *testing.T) {
+	t.Cleanup(func() { SetLanguage("en") })
+
+	require.NoError(t, SetLanguage("de-DE"))
+	assert.Equal(t, "Einstellungen", T("Settings"))
+	assert.Equal(t, "Not translated", T("Not translated"))
+	assert.Equal(t, "Puls #3 wird gemessen", Tf("Measuring pulse #%d", 3))
+	assert.EqualError(t, Errorf("failed to save config: %w", assert.AnError), "Konfiguration konnte nicht gespeichert werden: "+assert.AnError.Error())
+
+	require.NoError(t, SetLanguage("en"))
+	assert.Equal(t, "Settings", T("Settings"))
+
+	assert.Error(t, SetLanguage("xx"))
+	assert.Equal(t, "Settings", T("Settings"), "unknown languages show English")
+}
+
+// verbPattern matches the fmt verbs of a format.
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9.]*[a-zA-Z%]`)
+
+func TestCatalogs(t *testing.T) {
+	for _, l := range Languages[1:] {
+		translations, err := Catalog(l.Code)
+		require.NoError(t, err, l.Code)
+		for text, translation := range translations {
+			assert.Equal(t, verbPattern.FindAllString(text, -1), verbPattern.FindAllString(translation, -1), "%s: verbs of %q", l.Code, text)
+		}
+	}
+}
+
+// TestCatalogs_Complete checks that every literal text translated in the
+// GUI code, or shown with setStatus, has a translation in each language.
+func TestCatalogs_Complete(t *testing.T) {
+	texts := map[string]string{} // Text to its position
+	fset := token.NewFileSet()
+	for _, dir := range []string{"../../lpm", ".."} {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			f, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			ast.Inspect(f, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				arg := 0
+				switch fun := call.Fun.(type) {
+				case *ast.SelectorExpr:
+					if pkg, ok := fun.X.(*ast.Ident); !ok || pkg.Name != "i18n" {
+						return true
+					}
+				case *ast.Ident:
+					if fun.Name != "setStatus" {
+						return true
+					}
+					arg = 1
+				default:
+					return true
+				}
+				if len(call.Args) <= arg {
+					return true
+				}
+				if lit, ok := call.Args[arg].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					text, err := strconv.Unquote(lit.Value)
+					require.NoError(t, err)
+					texts[text] = fset.Position(lit.Pos()).String()
+				}
+				return true
+			})
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	require.NotEmpty(t, texts)
+
+	for _, l := range Languages[1:] {
+		translations, err := Catalog(l.Code)
+		require.NoError(t, err)
+		for text, pos := range texts {
+			assert.Contains(t, translations, text, "%s: no translation of %q at %s", l.Code, text, pos)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package lpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// Nordic UART service (NUS) UUIDs. The peripheral streams the same text lines
+// or binary frames as the USB serial link as notifications on the TX
+// characteristic and accepts commands written to the RX characteristic.
+const (
+	NUSServiceUUID = "6e400001-b5a3-f393-e0a9-e50e24dcca9e"
+	NUSRXCharUUID  = "6e400002-b5a3-f393-e0a9-e50e24dcca9e" // Host -> device (write)
+	NUSTXCharUUID  = "6e400003-b5a3-f393-e0a9-e50e24dcca9e" // Device -> host (notify)
+)
+
+const (
+	// BLEPortPrefix selects the BLE transport in SerialConfig.Port,
+	// e.g. "ble:C8:2B:96:A1:02:3F".
+	BLEPortPrefix = "ble:"
+
+	// DefaultBLEConnectTimeout bounds connecting to the peripheral and
+	// discovering the UART service.
+	DefaultBLEConnectTimeout = 10 * time.Second
+
+	// bleWriteChunk is the largest write that fits the default ATT MTU (23 - 3 bytes).
+	bleWriteChunk = 20
+
+	// bleNotifyQueue is the number of notifications buffered for the reader.
+	bleNotifyQueue = 256
+)
+
+// ErrBLEUnsupported is returned when connecting to a BLE address without a BLE dialer.
+var ErrBLEUnsupported = errors.New("BLE support not available: build with -tags ble on Linux")
+
+// BLEConn is a connection to the Nordic UART service of a peripheral.
+type BLEConn interface {
+	// Subscribe registers fn for notifications on the TX characteristic.
+	// fn is called from the BLE stack and must not block.
+	Subscribe(fn func(data []byte)) error
+	// Write writes data to the RX characteristic without response.
+	// data is never longer than bleWriteChunk.
+	Write(data []byte) (int, error)
+	// Close disconnects from the peripheral.
+	Close() error
+}
+
+// BLEDialer connects to the peripheral with the given address and discovers
+// its Nordic UART service.
+type BLEDialer func(ctx context.Context, address string) (BLEConn, error)
+
+// DefaultBLEDialer is the platform BLE dialer used for BLEPortPrefix ports.
+// Builds with the ble tag on Linux set it to DialBlueZ; otherwise it is nil
+// unless a program embedding another BLE stack sets it.
+var DefaultBLEDialer BLEDialer
+
+// NewBLE creates a Device that talks to the LPM over BLE using dial.
+// Apart from the transport it behaves exactly like a serial connection:
+// same handshake, commands, Decoder and RawSample stream.
+// A nil dial uses DefaultBLEDialer.
+func NewBLE(address string, dial BLEDialer, bufSize int) *Serial {
+	if dial == nil {
+		dial = DefaultBLEDialer
+	}
+
+	d := New(BLEPortPrefix+address, 0, bufSize)
+	d.openPort = func(string, *serial.Mode) (serial.Port, error) {
+		if dial == nil {
+			return nil, ErrBLEUnsupported
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultBLEConnectTimeout)
+		defer cancel()
+
+		conn, err := dial(ctx, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to BLE device %s: %w", address, err)
+		}
+		return newBLEPort(conn)
+	}
+	return d
+}
+
+// blePort adapts a BLEConn to serial.Port so Serial can drive it.
+// Notifications are queued and read as a byte stream; line settings and
+// modem bits do not apply to BLE and are ignored.
+type blePort struct {
+	conn    BLEConn
+	notify  chan []byte
+	pending []byte // Unread rest of the current notification
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Ensure blePort implements serial.Port.
+var _ serial.Port = (*blePort)(nil)
+
+func newBLEPort(conn BLEConn) (*blePort, error) {
+	p := &blePort{
+		conn:   conn,
+		notify: make(chan []byte, bleNotifyQueue),
+		closed: make(chan struct{}),
+	}
+
+	if err := conn.Subscribe(p.onNotify); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe to UART TX: %w", err)
+	}
+	return p, nil
+}
+
+// onNotify queues a notification. The BLE stack may reuse data, so it is copied.
+// When the reader falls behind the notification is dropped; sequence numbers
+// report the lost samples.
+func (p *blePort) onNotify(data []byte) {
+	select {
+	case p.notify <- append([]byte(nil), data...):
+	case <-p.closed:
+	default:
+		log.Printf("BLE notification queue full, dropping %d bytes", len(data))
+	}
+}
+
+// Read blocks until a notification arrives or the port is closed.
+func (p *blePort) Read(b []byte) (int, error) {
+	if len(p.pending) == 0 {
+		select {
+		case data := <-p.notify:
+			p.pending = data
+		case <-p.closed:
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(b, p.pending)
+	p.pending = p.pending[n:]
+	return n, nil
+}
+
+// Write splits b into chunks that fit a single ATT write.
+func (p *blePort) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		chunk := b[:min(len(b), bleWriteChunk)]
+		n, err := p.conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[len(chunk):]
+	}
+	return written, nil
+}
+
+// Close disconnects from the peripheral and unblocks Read.
+func (p *blePort) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		err = p.conn.Close()
+	})
+	return err
+}
+
+func (p *blePort) SetMode(*serial.Mode) error         { return nil }
+func (p *blePort) Drain() error                       { return nil }
+func (p *blePort) ResetInputBuffer() error            { return nil }
+func (p *blePort) ResetOutputBuffer() error           { return nil }
+func (p *blePort) SetDTR(bool) error                  { return nil }
+func (p *blePort) SetRTS(bool) error                  { return nil }
+func (p *blePort) SetReadTimeout(time.Duration) error { return nil }
+func (p *blePort) Break(time.Duration) error          { return nil }
+func (p *blePort) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	// BLE has its own flow control; report the link as always ready
+	return &serial.ModemStatusBits{CTS: true, DSR: true}, nil
+}
+
+// isBLEPort reports whether port selects the BLE transport and returns the address.
+func isBLEPort(port string) (string, bool) {
+	if !strings.HasPrefix(port, BLEPortPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(port, BLEPortPrefix), true
+}
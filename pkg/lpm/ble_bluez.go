@@ -0,0 +1,286 @@
+//go:build linux && ble
+
+package lpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// BlueZ D-Bus names used by DialBlueZ.
+const (
+	bluezService          = "org.bluez"
+	bluezAdapter          = "org.bluez.Adapter1"
+	bluezDevice           = "org.bluez.Device1"
+	bluezCharacteristic   = "org.bluez.GattCharacteristic1"
+	dbusProperties        = "org.freedesktop.DBus.Properties"
+	dbusPropertiesChanged = dbusProperties + ".PropertiesChanged"
+	dbusManagedObjects    = "org.freedesktop.DBus.ObjectManager.GetManagedObjects"
+
+	// bluezPollInterval is how often DialBlueZ checks whether the
+	// peripheral was found and its services resolved.
+	bluezPollInterval = 100 * time.Millisecond
+)
+
+func init() {
+	DefaultBLEDialer = DialBlueZ
+}
+
+// managedObjects maps object paths to their interfaces and properties, as
+// listed by the BlueZ object manager.
+type managedObjects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+// DialBlueZ connects to the peripheral with the given MAC address through
+// the BlueZ daemon on the system bus and finds its Nordic UART service.
+// A peripheral BlueZ does not know yet is scanned for on the first adapter
+// until it shows up or ctx ends.
+func DialBlueZ(ctx context.Context, address string) (BLEConn, error) {
+	// Not bound to ctx, which only bounds connecting
+	bus, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+
+	conn, err := dialBlueZ(ctx, bus, address)
+	if err != nil {
+		bus.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// dialBlueZ connects to the peripheral over bus for DialBlueZ.
+func dialBlueZ(ctx context.Context, bus *dbus.Conn, address string) (*bluezConn, error) {
+	path, err := findBlueZDevice(ctx, bus, address)
+	if err != nil {
+		return nil, err
+	}
+
+	device := bus.Object(bluezService, path)
+	if err := device.CallWithContext(ctx, bluezDevice+".Connect", 0).Err; err != nil {
+		return nil, err
+	}
+	conn := &bluezConn{bus: bus, device: device, done: make(chan struct{})}
+
+	objects, err := resolveBlueZServices(ctx, bus, device)
+	if err == nil {
+		conn.rx, conn.tx, err = nusCharacteristics(objects, path)
+	}
+	if err != nil {
+		device.Call(bluezDevice+".Disconnect", 0)
+		return nil, err
+	}
+	return conn, nil
+}
+
+// findBlueZDevice returns the object path of the peripheral with address,
+// scanning for it if BlueZ does not know it.
+func findBlueZDevice(ctx context.Context, bus *dbus.Conn, address string) (dbus.ObjectPath, error) {
+	var adapter dbus.BusObject
+	defer func() {
+		if adapter != nil {
+			adapter.Call(bluezAdapter+".StopDiscovery", 0)
+		}
+	}()
+
+	for {
+		objects, err := blueZObjects(ctx, bus)
+		if err != nil {
+			return "", err
+		}
+		if path, ok := deviceByAddress(objects, address); ok {
+			return path, nil
+		}
+
+		if adapter == nil {
+			path, ok := firstAdapter(objects)
+			if !ok {
+				return "", errors.New("no Bluetooth adapter")
+			}
+			adapter = bus.Object(bluezService, path)
+			if err := adapter.CallWithContext(ctx, bluezAdapter+".StartDiscovery", 0).Err; err != nil {
+				adapter = nil
+				return "", fmt.Errorf("failed to scan: %w", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("device not found: %w", ctx.Err())
+		case <-time.After(bluezPollInterval):
+		}
+	}
+}
+
+// resolveBlueZServices waits until BlueZ has discovered the services of the
+// connected device and returns the objects, which then list them.
+func resolveBlueZServices(ctx context.Context, bus *dbus.Conn, device dbus.BusObject) (managedObjects, error) {
+	for {
+		resolved, err := device.GetProperty(bluezDevice + ".ServicesResolved")
+		if err != nil {
+			return nil, err
+		}
+		if ok, _ := resolved.Value().(bool); ok {
+			return blueZObjects(ctx, bus)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("services not resolved: %w", ctx.Err())
+		case <-time.After(bluezPollInterval):
+		}
+	}
+}
+
+// blueZObjects lists the adapters, devices and GATT objects BlueZ knows.
+func blueZObjects(ctx context.Context, bus *dbus.Conn) (managedObjects, error) {
+	var objects managedObjects
+	err := bus.Object(bluezService, "/").CallWithContext(ctx, dbusManagedObjects, 0).Store(&objects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list BlueZ objects: %w", err)
+	}
+	return objects, nil
+}
+
+// deviceByAddress returns the path of the device with the MAC address.
+func deviceByAddress(objects managedObjects, address string) (dbus.ObjectPath, bool) {
+	for path, ifaces := range objects {
+		props, ok := ifaces[bluezDevice]
+		if !ok {
+			continue
+		}
+		if addr, _ := props["Address"].Value().(string); strings.EqualFold(addr, address) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// firstAdapter returns the path of the first Bluetooth adapter, e.g.
+// /org/bluez/hci0.
+func firstAdapter(objects managedObjects) (dbus.ObjectPath, bool) {
+	var adapters []dbus.ObjectPath
+	for path, ifaces := range objects {
+		if _, ok := ifaces[bluezAdapter]; ok {
+			adapters = append(adapters, path)
+		}
+	}
+	if len(adapters) == 0 {
+		return "", false
+	}
+	return slices.Min(adapters), true
+}
+
+// nusCharacteristics returns the paths of the Nordic UART RX and TX
+// characteristics of the device at path.
+func nusCharacteristics(objects managedObjects, device dbus.ObjectPath) (rx, tx dbus.ObjectPath, err error) {
+	for path, ifaces := range objects {
+		props, ok := ifaces[bluezCharacteristic]
+		if !ok || !strings.HasPrefix(string(path), string(device)+"/") {
+			continue
+		}
+		uuid, _ := props["UUID"].Value().(string)
+		switch strings.ToLower(uuid) {
+		case NUSRXCharUUID:
+			rx = path
+		case NUSTXCharUUID:
+			tx = path
+		}
+	}
+	if rx == "" || tx == "" {
+		return "", "", errors.New("no Nordic UART service")
+	}
+	return rx, tx, nil
+}
+
+// notifiedValue returns the value a PropertiesChanged signal reports for
+// the characteristic at path.
+func notifiedValue(signal *dbus.Signal, path dbus.ObjectPath) ([]byte, bool) {
+	if signal.Path != path || signal.Name != dbusPropertiesChanged || len(signal.Body) < 2 {
+		return nil, false
+	}
+	if iface, _ := signal.Body[0].(string); iface != bluezCharacteristic {
+		return nil, false
+	}
+	changed, _ := signal.Body[1].(map[string]dbus.Variant)
+	value, ok := changed["Value"].Value().([]byte)
+	return value, ok
+}
+
+// bluezConn is a BLEConn to a peripheral connected through BlueZ.
+type bluezConn struct {
+	bus     *dbus.Conn // Private connection, closed with the peripheral
+	device  dbus.BusObject
+	rx, tx  dbus.ObjectPath
+	signals chan *dbus.Signal // Nil until subscribed
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Subscribe starts notifications on the TX characteristic, which BlueZ
+// reports as changes of its value.
+func (c *bluezConn) Subscribe(fn func(data []byte)) error {
+	if err := c.bus.AddMatchSignal(c.txMatch()...); err != nil {
+		return err
+	}
+	c.signals = make(chan *dbus.Signal, bleNotifyQueue)
+	c.bus.Signal(c.signals)
+	go c.notify(fn)
+
+	return c.bus.Object(bluezService, c.tx).Call(bluezCharacteristic+".StartNotify", 0).Err
+}
+
+// txMatch matches the value changes of the TX characteristic.
+func (c *bluezConn) txMatch() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(c.tx),
+		dbus.WithMatchInterface(dbusProperties),
+		dbus.WithMatchMember("PropertiesChanged"),
+	}
+}
+
+// notify passes the notified values to fn until the connection is closed.
+func (c *bluezConn) notify(fn func(data []byte)) {
+	for {
+		select {
+		case signal := <-c.signals:
+			if value, ok := notifiedValue(signal, c.tx); ok {
+				fn(value)
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Write writes data to the RX characteristic without response.
+func (c *bluezConn) Write(data []byte) (int, error) {
+	options := map[string]dbus.Variant{"type": dbus.MakeVariant("command")}
+	if err := c.bus.Object(bluezService, c.rx).Call(bluezCharacteristic+".WriteValue", 0, data, options).Err; err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Close stops the notifications and disconnects from the peripheral.
+func (c *bluezConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.signals != nil {
+			c.bus.Object(bluezService, c.tx).Call(bluezCharacteristic+".StopNotify", 0)
+			c.bus.RemoveSignal(c.signals)
+			c.bus.RemoveMatchSignal(c.txMatch()...)
+		}
+		err = errors.Join(c.device.Call(bluezDevice+".Disconnect", 0).Err, c.bus.Close())
+	})
+	return err
+}
@@ -0,0 +1,81 @@
+//go:build linux && ble
+
+package lpm
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blueZHead lists the objects of BlueZ with two adapters and a connected
+// head offering the Nordic UART service.
+func blueZHead() managedObjects {
+	props := func(kv ...any) map[string]dbus.Variant {
+		m := map[string]dbus.Variant{}
+		for i := 0; i < len(kv); i += 2 {
+			m[kv[i].(string)] = dbus.MakeVariant(kv[i+1])
+		}
+		return m
+	}
+	return managedObjects{
+		"/org/bluez/hci1":                       {bluezAdapter: props("Address", "00:11:22:33:44:55")},
+		"/org/bluez/hci0":                       {bluezAdapter: props("Address", "00:11:22:33:44:66")},
+		"/org/bluez/hci0/dev_AA_BB_CC_00_00_01": {bluezDevice: props("Address", "AA:BB:CC:00:00:01")},
+		"/org/bluez/hci0/dev_C8_2B_96_A1_02_3F": {bluezDevice: props("Address", "C8:2B:96:A1:02:3F")},
+		"/org/bluez/hci0/dev_C8_2B_96_A1_02_3F/service000a": {
+			"org.bluez.GattService1": props("UUID", NUSServiceUUID),
+		},
+		"/org/bluez/hci0/dev_C8_2B_96_A1_02_3F/service000a/char000b": {
+			bluezCharacteristic: props("UUID", NUSRXCharUUID),
+		},
+		"/org/bluez/hci0/dev_C8_2B_96_A1_02_3F/service000a/char000d": {
+			bluezCharacteristic: props("UUID", "6E400003-B5A3-F393-E0A9-E50E24DCCA9E"),
+		},
+	}
+}
+
+func TestBlueZ_FindHead(t *testing.T) {
+	objects := blueZHead()
+
+	adapter, ok := firstAdapter(objects)
+	require.True(t, ok)
+	assert.Equal(t, dbus.ObjectPath("/org/bluez/hci0"), adapter)
+
+	path, ok := deviceByAddress(objects, "c8:2b:96:a1:02:3f")
+	require.True(t, ok)
+	assert.Equal(t, dbus.ObjectPath("/org/bluez/hci0/dev_C8_2B_96_A1_02_3F"), path)
+	_, ok = deviceByAddress(objects, "C8:2B:96:A1:02:40")
+	assert.False(t, ok)
+
+	rx, tx, err := nusCharacteristics(objects, path)
+	require.NoError(t, err)
+	assert.Equal(t, dbus.ObjectPath("/org/bluez/hci0/dev_C8_2B_96_A1_02_3F/service000a/char000b"), rx)
+	assert.Equal(t, dbus.ObjectPath("/org/bluez/hci0/dev_C8_2B_96_A1_02_3F/service000a/char000d"), tx)
+
+	_, _, err = nusCharacteristics(objects, "/org/bluez/hci0/dev_AA_BB_CC_00_00_01")
+	assert.ErrorContains(t, err, "no Nordic UART service")
+	_, ok = firstAdapter(managedObjects{})
+	assert.False(t, ok)
+}
+
+func TestBlueZ_NotifiedValue(t *testing.T) {
+	tx := dbus.ObjectPath("/org/bluez/hci0/dev_C8_2B_96_A1_02_3F/service000a/char000d")
+	signal := func(path dbus.ObjectPath, iface string, changed map[string]dbus.Variant) *dbus.Signal {
+		return &dbus.Signal{Path: path, Name: dbusPropertiesChanged, Body: []any{iface, changed, []string{}}}
+	}
+	value := map[string]dbus.Variant{"Value": dbus.MakeVariant([]byte("1000,2048,1024,101\n"))}
+
+	data, ok := notifiedValue(signal(tx, bluezCharacteristic, value), tx)
+	require.True(t, ok)
+	assert.Equal(t, []byte("1000,2048,1024,101\n"), data)
+
+	_, ok = notifiedValue(signal(tx+"x", bluezCharacteristic, value), tx)
+	assert.False(t, ok, "another characteristic")
+	_, ok = notifiedValue(signal(tx, bluezDevice, value), tx)
+	assert.False(t, ok, "another interface")
+	_, ok = notifiedValue(signal(tx, bluezCharacteristic, map[string]dbus.Variant{"Notifying": dbus.MakeVariant(true)}), tx)
+	assert.False(t, ok, "no value")
+}
@@ -0,0 +1,131 @@
+package lpm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBLEConn emulates the MCU behind a Nordic UART service. Responses and
+// samples are delivered as notifications of at most bleWriteChunk bytes.
+type fakeBLEConn struct {
+	mu      sync.Mutex
+	notify  func(data []byte)
+	writes  [][]byte
+	pending string
+	respond func(cmd string) string
+	closed  bool
+}
+
+func (c *fakeBLEConn) Subscribe(fn func(data []byte)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notify = fn
+	return nil
+}
+
+func (c *fakeBLEConn) Write(data []byte) (int, error) {
+	c.mu.Lock()
+	c.writes = append(c.writes, append([]byte(nil), data...))
+	c.pending += string(data)
+	var cmds []string
+	for {
+		i := strings.IndexByte(c.pending, '\n')
+		if i < 0 {
+			break
+		}
+		cmds = append(cmds, c.pending[:i+1])
+		c.pending = c.pending[i+1:]
+	}
+	c.mu.Unlock()
+
+	for _, cmd := range cmds {
+		if resp := c.respond(cmd); resp != "" {
+			c.emit(resp)
+		}
+	}
+	return len(data), nil
+}
+
+func (c *fakeBLEConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+// emit sends s as a series of MTU-sized notifications.
+func (c *fakeBLEConn) emit(s string) {
+	c.mu.Lock()
+	notify := c.notify
+	c.mu.Unlock()
+
+	for len(s) > 0 {
+		n := min(len(s), bleWriteChunk)
+		notify([]byte(s[:n]))
+		s = s[n:]
+	}
+}
+
+func TestBLE_StreamsSamples(t *testing.T) {
+	conn := &fakeBLEConn{respond: firmwareV3}
+	dev := NewBLE("C8:2B:96:A1:02:3F", func(ctx context.Context, address string) (BLEConn, error) {
+		assert.Equal(t, "C8:2B:96:A1:02:3F", address)
+		return conn, nil
+	}, 0)
+
+	require.NoError(t, dev.Connect())
+	assert.Equal(t, 3, dev.Info().ProtocolVersion)
+	require.NoError(t, dev.SetHeaters(true, false, true))
+
+	// Lines are split across notifications; the first 100 samples are skipped
+	for i := range 101 {
+		conn.emit(fmt.Sprintf("1234567890123,2048,1024,101,%d\n", i+1))
+	}
+
+	select {
+	case sample := <-dev.Samples():
+		assert.Equal(t, uint16(2048), sample.Reading)
+		assert.Equal(t, []bool{true, false, true}, sample.Heaters)
+	case <-time.After(time.Second):
+		t.Fatal("no sample received over BLE")
+	}
+
+	require.NoError(t, dev.Close())
+	assert.False(t, dev.IsConnected())
+	assert.True(t, conn.closed)
+}
+
+func TestBLEPort_WriteChunks(t *testing.T) {
+	conn := &fakeBLEConn{respond: func(string) string { return "" }}
+	port, err := newBLEPort(conn)
+	require.NoError(t, err)
+
+	cmd := strings.Repeat("x", 2*bleWriteChunk+5)
+	n, err := port.Write([]byte(cmd))
+	require.NoError(t, err)
+	assert.Equal(t, len(cmd), n)
+
+	require.Len(t, conn.writes, 3)
+	assert.Len(t, conn.writes[0], bleWriteChunk)
+	assert.Len(t, conn.writes[2], 5)
+}
+
+func TestNewBLE_Unsupported(t *testing.T) {
+	if DefaultBLEDialer != nil {
+		t.Skip("built with BLE support")
+	}
+
+	err := NewBLE("C8:2B:96:A1:02:3F", nil, 0).Connect()
+	assert.ErrorIs(t, err, ErrBLEUnsupported)
+
+	_, err = NewFromConfig(&config.SerialConfig{Port: BLEPortPrefix + "C8:2B:96:A1:02:3F"}, 0)
+	assert.ErrorIs(t, err, ErrBLEUnsupported)
+}
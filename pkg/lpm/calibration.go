@@ -0,0 +1,124 @@
+package lpm
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/itohio/golpm/pkg/config"
+)
+
+// ReadCalibration returns the calibration points stored on the MCU, so a
+// meter carries its calibration from one host to the next.
+// Requires protocol version 19 or later; earlier firmware stored powers in nW.
+func (d *Serial) ReadCalibration() ([]config.CalibrationPoint, error) {
+	if v := d.Info().ProtocolVersion; v < calibrationProtocolVersion {
+		return nil, fmt.Errorf("stored calibration requires protocol v%d, device reports v%d", calibrationProtocolVersion, v)
+	}
+
+	reply, err := d.submit(&command{line: calibrationQuery, timeout: AckTimeout, retries: CommandRetries, match: matchCalibration})
+	if err != nil {
+		return nil, fmt.Errorf("read calibration: %w", err)
+	}
+
+	points, err := parseCalibration(reply)
+	if err != nil {
+		return nil, fmt.Errorf("read calibration: %w", err)
+	}
+	return points, nil
+}
+
+// WriteCalibration replaces the calibration points stored on the MCU.
+// The points are lost on reset unless SaveSettings is called afterwards.
+// Slopes must be within ±2.147 and powers within ±2147 W.
+// Requires protocol version 19 or later.
+func (d *Serial) WriteCalibration(points []config.CalibrationPoint) error {
+	if v := d.Info().ProtocolVersion; v < calibrationProtocolVersion {
+		return fmt.Errorf("stored calibration requires protocol v%d, device reports v%d", calibrationProtocolVersion, v)
+	}
+	if len(points) > maxCalibrationPoints {
+		return fmt.Errorf("%d calibration points, the MCU stores at most %d", len(points), maxCalibrationPoints)
+	}
+
+	cmds := []string{calibrationClear}
+	for i, p := range points {
+		slope, err := calibrationValue(p.Slope, calibrationSlopeScale)
+		if err != nil {
+			return fmt.Errorf("calibration point %d slope: %w", i, err)
+		}
+		power, err := calibrationValue(p.Power, calibrationPowerScale)
+		if err != nil {
+			return fmt.Errorf("calibration point %d power: %w", i, err)
+		}
+		cmds = append(cmds, fmt.Sprintf(calibrationCommand, i, slope, power))
+	}
+
+	for _, cmd := range cmds {
+		if _, err := d.request(cmd, AckTimeout); err != nil {
+			return fmt.Errorf("write calibration %q: %w", strings.TrimSpace(cmd), err)
+		}
+	}
+
+	log.Printf("Wrote %d calibration points to %s", len(points), d.port)
+
+	return nil
+}
+
+// SaveSettings saves the MCU's averaging count, ADC interval and calibration
+// points to its flash, from where they are restored at boot.
+// Requires protocol version 11 or later.
+func (d *Serial) SaveSettings() error {
+	if v := d.Info().ProtocolVersion; v < settingsProtocolVersion {
+		return fmt.Errorf("saving settings requires protocol v%d, device reports v%d", settingsProtocolVersion, v)
+	}
+
+	if _, err := d.request(saveCommand, AckTimeout); err != nil {
+		return fmt.Errorf("save settings: %w", err)
+	}
+
+	log.Printf("Saved settings to flash on %s", d.port)
+
+	return nil
+}
+
+// calibrationValue converts a calibration slope or power to the MCU's signed
+// 32-bit integer representation, multiplying it by scale.
+func calibrationValue(v, scale float64) (int64, error) {
+	scaled := math.Round(v * scale)
+	if math.IsNaN(scaled) || scaled < -math.MaxInt32 || scaled > math.MaxInt32 {
+		return 0, fmt.Errorf("%g out of range [%g, %g]", v, -math.MaxInt32/scale, math.MaxInt32/scale)
+	}
+	return int64(scaled), nil
+}
+
+// parseCalibration parses a "#CAL <slope>,<power> ..." reply, with slopes
+// scaled by calibrationSlopeScale and powers by calibrationPowerScale.
+func parseCalibration(line string) ([]config.CalibrationPoint, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != calibrationPrefix {
+		return nil, fmt.Errorf("not a calibration response: %q", line)
+	}
+
+	points := make([]config.CalibrationPoint, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		slope, power, ok := strings.Cut(field, ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid calibration point %q", field)
+		}
+		s, err := strconv.ParseInt(slope, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calibration slope %q: %w", slope, err)
+		}
+		p, err := strconv.ParseInt(power, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calibration power %q: %w", power, err)
+		}
+		points = append(points, config.CalibrationPoint{
+			Slope: float64(s) / calibrationSlopeScale,
+			Power: float64(p) / calibrationPowerScale,
+		})
+	}
+	return points, nil
+}
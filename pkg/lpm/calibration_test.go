@@ -0,0 +1,125 @@
+package lpm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// firmwareV19 answers like protocol version 19 firmware, storing calibration
+// points and counting saves.
+func firmwareV19() (respond func(cmd string) string, saves func() int) {
+	var mu sync.Mutex
+	var points []string
+	saved := 0
+	v9 := firmwareV9()
+	respond = func(cmd string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case cmd == "V?\n":
+			return strings.Replace(v9(cmd), "proto=9", "proto=19", 1)
+		case cmd == "K?\n":
+			return strings.TrimSpace("#CAL "+strings.Join(points, " ")) + "\n"
+		case cmd == "K-\n":
+			points = nil
+			return "#OK cal=0\n"
+		case cmd == "S\n":
+			saved++
+			return "#OK saved\n"
+		case cmd[0] == 'K':
+			var i, s, p int
+			if _, err := fmt.Sscanf(cmd, "K%d,%d,%d\n", &i, &s, &p); err != nil || i != len(points) || i >= 8 {
+				return "#ERR cal\n"
+			}
+			points = append(points, fmt.Sprintf("%d,%d", s, p))
+			return fmt.Sprintf("#OK cal=%d\n", len(points))
+		}
+		return v9(cmd)
+	}
+	saves = func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return saved
+	}
+	return respond, saves
+}
+
+func TestSerial_Calibration_RoundTrip(t *testing.T) {
+	respond, saves := firmwareV19()
+	port := newFakePort(respond)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	points, err := dev.ReadCalibration()
+	require.NoError(t, err)
+	assert.Empty(t, points)
+
+	want := []config.CalibrationPoint{
+		{Slope: 0, Power: 0},
+		{Slope: 0.010815559, Power: 0.049979824},
+		{Slope: 0.0325, Power: 0.16},
+		{Slope: -0.0004, Power: 12.5},
+	}
+	require.NoError(t, dev.WriteCalibration(want))
+	assert.Contains(t, port.commands(), "K-\n")
+	assert.Contains(t, port.commands(), "K1,10815559,49980\n")
+	assert.Contains(t, port.commands(), "K3,-400000,12500000\n")
+
+	points, err = dev.ReadCalibration()
+	require.NoError(t, err)
+	require.Len(t, points, len(want))
+	for i := range want {
+		assert.InDelta(t, want[i].Slope, points[i].Slope, 1e-9)
+		assert.InDelta(t, want[i].Power, points[i].Power, 1e-6)
+	}
+
+	require.NoError(t, dev.SaveSettings())
+	assert.Equal(t, 1, saves())
+}
+
+func TestSerial_WriteCalibration_Invalid(t *testing.T) {
+	respond, _ := firmwareV19()
+	dev, err := connectFake(newFakePort(respond))
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.ErrorContains(t, dev.WriteCalibration(make([]config.CalibrationPoint, 9)), "at most 8")
+	assert.ErrorContains(t, dev.WriteCalibration([]config.CalibrationPoint{{Slope: -3, Power: 0.1}}), "out of range")
+	assert.ErrorContains(t, dev.WriteCalibration([]config.CalibrationPoint{{Slope: 0.01, Power: 3000}}), "out of range")
+}
+
+func TestSerial_Calibration_Unsupported(t *testing.T) {
+	port := newFakePort(firmwareV9())
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	_, err = dev.ReadCalibration()
+	assert.ErrorContains(t, err, "requires protocol")
+	assert.ErrorContains(t, dev.WriteCalibration(nil), "requires protocol")
+	assert.ErrorContains(t, dev.SaveSettings(), "requires protocol")
+	assert.NotContains(t, port.commands(), "S\n")
+}
+
+func TestParseCalibration(t *testing.T) {
+	points, err := parseCalibration("#CAL 0,0 10000000,50000 -2000000,-1500000")
+	require.NoError(t, err)
+	assert.Equal(t, []config.CalibrationPoint{{Slope: 0, Power: 0}, {Slope: 0.01, Power: 0.05}, {Slope: -0.002, Power: -1.5}}, points)
+
+	points, err = parseCalibration("#CAL")
+	require.NoError(t, err)
+	assert.Empty(t, points)
+
+	for _, line := range []string{"#OK cal=0", "#CAL 1", "#CAL a,1", "#CAL 1,b"} {
+		_, err := parseCalibration(line)
+		assert.Error(t, err, line)
+	}
+}
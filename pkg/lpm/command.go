@@ -0,0 +1,192 @@
+package lpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// CommandRetries is how many times a command is resent after a timeout or
+// write error. Commands set absolute state, so resending is safe.
+const CommandRetries = 2
+
+var (
+	// ErrCommandFailed is returned when a command still fails after all retries.
+	ErrCommandFailed = errors.New("command failed")
+
+	// errRejected marks an explicit "#ERR" reply, which is not retried.
+	errRejected = errors.New("rejected by MCU")
+)
+
+// command is a command line queued for the MCU.
+type command struct {
+	line    string
+	timeout time.Duration // How long to wait for a reply per attempt
+	retries int
+
+	// match inspects response lines until it reports the reply complete.
+	// A nil match sends the command without waiting for a reply.
+	match func(line string) (bool, error)
+
+	reply chan commandResult
+}
+
+type commandResult struct {
+	line string
+	err  error
+}
+
+// matchAck accepts "#OK" replies and turns "#ERR" replies into errors.
+func matchAck(line string) (bool, error) {
+	switch {
+	case strings.HasPrefix(line, ackPrefix):
+		return true, nil
+	case strings.HasPrefix(line, nakPrefix):
+		return false, fmt.Errorf("%w: %s", errRejected, strings.TrimSpace(strings.TrimPrefix(line, nakPrefix)))
+	}
+	return false, nil
+}
+
+// matchInfo accepts "#INFO" replies and turns "#ERR" replies into errors,
+// so a query the firmware rejects does not wait for its timeout.
+func matchInfo(line string) (bool, error) {
+	switch {
+	case strings.HasPrefix(line, infoPrefix):
+		return true, nil
+	case strings.HasPrefix(line, nakPrefix):
+		return matchAck(line)
+	}
+	return false, nil
+}
+
+// matchCalibration accepts "#CAL" replies and turns "#ERR" replies into errors.
+func matchCalibration(line string) (bool, error) {
+	switch {
+	case strings.HasPrefix(line, calibrationPrefix):
+		return true, nil
+	case strings.HasPrefix(line, nakPrefix):
+		return matchAck(line)
+	}
+	return false, nil
+}
+
+// request queues a command and waits for its acknowledgement.
+// Returns the "#OK" reply line, or an error for "#ERR" replies and
+// commands that still time out after CommandRetries.
+func (d *Serial) request(cmd string, timeout time.Duration) (string, error) {
+	return d.submit(&command{line: cmd, timeout: timeout, retries: CommandRetries, match: matchAck})
+}
+
+// submit queues c on the current connection and waits for its result.
+func (d *Serial) submit(c *command) (string, error) {
+	d.mu.RLock()
+	queue, done, connected := d.commands, d.done, d.connected
+	d.mu.RUnlock()
+
+	if !connected {
+		return "", fmt.Errorf("not connected")
+	}
+
+	c.reply = make(chan commandResult, 1)
+	select {
+	case queue <- c:
+	case <-done:
+		return "", fmt.Errorf("not connected")
+	}
+
+	select {
+	case r := <-c.reply:
+		return r.line, r.err
+	case <-done:
+		return "", fmt.Errorf("disconnected while waiting for reply")
+	}
+}
+
+// runCommands executes queued commands one at a time until ctx is cancelled,
+// so writes from different goroutines never interleave on the wire and each
+// reply is matched to its command.
+func (d *Serial) runCommands(ctx context.Context, queue <-chan *command) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-queue:
+			line, err := d.execute(ctx, c)
+			c.reply <- commandResult{line: line, err: err}
+		}
+	}
+}
+
+// execute sends c, resending it after timeouts and write errors.
+func (d *Serial) execute(ctx context.Context, c *command) (string, error) {
+	cmd := strings.TrimSpace(c.line)
+
+	var err error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			d.stats.retries.Add(1)
+			log.Printf("Retrying command %q on %s (%d/%d): %v", cmd, d.port, attempt, c.retries, err)
+		}
+
+		var line string
+		line, err = d.attempt(ctx, c)
+		if err == nil || errors.Is(err, errRejected) {
+			return line, err
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+	}
+
+	if c.retries > 0 {
+		d.stats.commandFailures.Add(1)
+		log.Printf("Command %q on %s failed after %d attempts: %v", cmd, d.port, c.retries+1, err)
+		return "", fmt.Errorf("%w after %d attempts: %w", ErrCommandFailed, c.retries+1, err)
+	}
+	return "", err
+}
+
+// attempt writes c once and waits for its reply.
+func (d *Serial) attempt(ctx context.Context, c *command) (string, error) {
+	d.drainResponses()
+
+	if err := d.writeCommand(c.line); err != nil {
+		return "", fmt.Errorf("failed to send command: %w", err)
+	}
+	if c.match == nil {
+		return "", nil
+	}
+
+	deadline := time.After(c.timeout)
+	for {
+		select {
+		case line := <-d.responses:
+			ok, err := c.match(line)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return line, nil
+			}
+		case <-deadline:
+			return "", fmt.Errorf("no acknowledgement within %v", c.timeout)
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// drainResponses discards stale responses so they are not mistaken for
+// the reply to the next command.
+func (d *Serial) drainResponses() {
+	for {
+		select {
+		case <-d.responses:
+		default:
+			return
+		}
+	}
+}
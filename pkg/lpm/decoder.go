@@ -0,0 +1,312 @@
+package lpm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// maxLineLength bounds a text line; longer runs without a newline are
+// treated as garbage. A sample line is ~30 bytes, responses are shorter than 128.
+const maxLineLength = 256
+
+// ParseStats counts what a Decoder has seen on the stream.
+type ParseStats struct {
+	Lines        uint64 // Text lines decoded into samples
+	Frames       uint64 // Binary frames decoded into samples
+	Responses    uint64 // '#' response lines
+	BadLines     uint64 // Text lines that failed to parse
+	BadFrames    uint64 // Binary frames with a bad CRC or length
+	Resyncs      uint64 // Times the decoder dropped garbage to realign on a message boundary
+	SkippedBytes uint64 // Bytes discarded while resynchronizing
+	Gaps         uint64 // Sequence gaps, i.e. places where samples were lost
+	Lost         uint64 // Samples lost in sequence gaps
+	Restarts     uint64 // Sequence restarts, e.g. after an MCU reset
+	LastError    string // Most recent parse error, empty if none
+}
+
+// Errors returns the number of malformed messages.
+func (s ParseStats) Errors() uint64 {
+	return s.BadLines + s.BadFrames
+}
+
+// String formats the stats as a one-line summary.
+func (s ParseStats) String() string {
+	return fmt.Sprintf("%d lines, %d frames, %d responses, %d bad lines, %d bad frames, %d resyncs (%d bytes skipped), %d gaps (%d samples lost)",
+		s.Lines, s.Frames, s.Responses, s.BadLines, s.BadFrames, s.Resyncs, s.SkippedBytes, s.Gaps, s.Lost)
+}
+
+// Decoder reads samples from a byte stream carrying the CSV text protocol,
+// binary frames, or both. It works byte by byte: any byte that cannot occur
+// in a text line (control characters, FrameSync) ends the current partial
+// line, so after a partial read or corruption the decoder resynchronizes on
+// the next newline or frame instead of mis-framing lines. CRLF line endings
+// and garbage before a valid line are tolerated.
+//
+// Malformed messages are skipped and counted in ParseStats rather than logged.
+// When the firmware numbers its samples, a skipped sequence number is reported
+// in the Lost field of the next decoded sample.
+type Decoder struct {
+	r          *bufio.Reader
+	line       []byte
+	skipping   bool   // Inside a run of garbage, counted as a single resync
+	sequence   uint32 // Sequence number of the last decoded sample, 0 if none
+	onResponse func(line string)
+	onRaw      func(data []byte)
+	raw        []byte // Bytes of the current message, for onRaw
+
+	mu       sync.Mutex
+	protocol Protocol
+	heaters  int // Heaters of the device, unpacked from binary frames
+	stats    ParseStats
+}
+
+// NewDecoder creates a new Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r:       bufio.NewReader(r),
+		line:    make([]byte, 0, maxLineLength),
+		heaters: DefaultHeaters,
+	}
+}
+
+// SetHeaterCount sets the number of heaters of the device, whose states
+// binary frames carry (DefaultHeaters until set). Text lines carry their
+// own. Safe to call while decoding.
+func (d *Decoder) SetHeaterCount(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.heaters = n
+}
+
+// OnResponse sets the handler for '#' response lines. Without a handler,
+// responses are counted and dropped. Must be set before decoding starts.
+func (d *Decoder) OnResponse(fn func(line string)) {
+	d.onResponse = fn
+}
+
+// OnRaw sets the handler for the bytes read, before they are decoded. It is
+// called once per message with its bytes: each line, response and frame as
+// received, valid or not, and any garbage before it, so the link can be
+// recorded as it was. data is only valid during the call. Must be set before
+// decoding starts.
+func (d *Decoder) OnRaw(fn func(data []byte)) {
+	d.onRaw = fn
+}
+
+// Protocol returns the wire protocol detected from the stream.
+// Returns ProtocolUnknown until the first sample has been decoded.
+func (d *Decoder) Protocol() Protocol {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.protocol
+}
+
+// Stats returns a snapshot of the parse statistics. Safe to call while decoding.
+func (d *Decoder) Stats() ParseStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats
+}
+
+// Decode returns the next sample from the stream, skipping responses and
+// malformed messages. Any returned error is a read error from the underlying
+// reader (io.EOF at the end of the stream); a trailing partial line is dropped.
+func (d *Decoder) Decode() (RawSample, error) {
+	for {
+		b, err := d.r.ReadByte()
+		if err != nil {
+			d.resync(len(d.line))
+			d.flushRaw()
+			return RawSample{}, err
+		}
+		if b != FrameSync {
+			d.consume(b)
+		}
+
+		switch {
+		case b == FrameSync:
+			// FrameSync never occurs in text: a partial line before it is garbage
+			d.resync(len(d.line))
+			if err := d.r.UnreadByte(); err != nil {
+				return RawSample{}, err
+			}
+			sample, ok, err := d.decodeFrame()
+			if err != nil {
+				d.flushRaw()
+				return RawSample{}, err
+			}
+			if ok {
+				d.checkSequence(&sample)
+				return sample, nil
+			}
+
+		case b == '\n':
+			line := strings.TrimSpace(string(d.line))
+			d.line = d.line[:0]
+			d.flushRaw()
+			if sample, ok := d.decodeLine(line); ok {
+				d.checkSequence(&sample)
+				return sample, nil
+			}
+
+		case b == '\r':
+			// CRLF: the '\n' completes the line
+
+		case b >= ' ' && b < 0x7F || b == '\t':
+			if len(d.line) == maxLineLength {
+				d.resync(len(d.line))
+			}
+			d.line = append(d.line, b)
+			d.skipping = false
+
+		default:
+			// Control or non-ASCII byte: drop it and the partial line before it.
+			// Once binary framing is established this is just noise between frames.
+			d.resync(len(d.line) + 1)
+		}
+	}
+}
+
+// decodeFrame decodes the binary frame at the reader position.
+// On a corrupted frame only the sync byte is discarded, so the decoder
+// resynchronizes on the next FrameSync. ok is false for corrupted frames.
+func (d *Decoder) decodeFrame() (sample RawSample, ok bool, err error) {
+	header, err := d.r.Peek(2)
+	if err != nil {
+		return RawSample{}, false, err
+	}
+
+	buf, err := d.r.Peek(frameOverhead + int(header[1]))
+	if err != nil {
+		return RawSample{}, false, err
+	}
+
+	d.mu.Lock()
+	heaters := d.heaters
+	d.mu.Unlock()
+	sample, n, err := decodeFrame(buf, heaters)
+	if err != nil {
+		d.consume(buf[0])
+		d.r.Discard(1)
+		d.mu.Lock()
+		d.stats.BadFrames++
+		d.stats.LastError = err.Error()
+		d.mu.Unlock()
+		return RawSample{}, false, nil
+	}
+
+	d.consume(buf[:n]...)
+	d.flushRaw()
+	d.r.Discard(n)
+	d.skipping = false
+	d.mu.Lock()
+	d.stats.Frames++
+	if d.protocol == ProtocolUnknown {
+		d.protocol = ProtocolBinary
+	}
+	d.mu.Unlock()
+	return sample, true, nil
+}
+
+// decodeLine decodes a complete text line. ok is false for blank lines,
+// responses and malformed lines.
+func (d *Decoder) decodeLine(line string) (RawSample, bool) {
+	if line == "" {
+		return RawSample{}, false
+	}
+
+	if strings.HasPrefix(line, responsePrefix) {
+		d.mu.Lock()
+		d.stats.Responses++
+		d.mu.Unlock()
+		if d.onResponse != nil {
+			d.onResponse(line)
+		}
+		return RawSample{}, false
+	}
+
+	sample, err := parseLine(line)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		d.stats.BadLines++
+		d.stats.LastError = fmt.Sprintf("line %q: %v", line, err)
+		return RawSample{}, false
+	}
+	d.stats.Lines++
+	if d.protocol == ProtocolUnknown {
+		d.protocol = ProtocolText
+	}
+	return sample, true
+}
+
+// consume keeps the bytes read for onRaw, if set.
+func (d *Decoder) consume(data ...byte) {
+	if d.onRaw != nil {
+		d.raw = append(d.raw, data...)
+	}
+}
+
+// flushRaw passes the bytes kept since the last message to onRaw.
+func (d *Decoder) flushRaw() {
+	if len(d.raw) == 0 {
+		return
+	}
+	d.onRaw(d.raw)
+	d.raw = d.raw[:0]
+}
+
+// resync drops the current partial line and counts n skipped bytes.
+// A run of consecutive garbage counts as a single resync.
+func (d *Decoder) resync(n int) {
+	d.line = d.line[:0]
+	if n == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	if !d.skipping {
+		d.stats.Resyncs++
+	}
+	d.stats.SkippedBytes += uint64(n)
+	d.mu.Unlock()
+	d.skipping = true
+}
+
+// checkSequence compares the sample's sequence number with the previous one
+// and sets sample.Lost to the number of samples missing in between.
+// A sequence number that does not increase means the MCU restarted numbering
+// and is not reported as loss. Samples without a sequence number are ignored.
+func (d *Decoder) checkSequence(sample *RawSample) {
+	if sample.Sequence == 0 {
+		return
+	}
+
+	last := d.sequence
+	d.sequence = sample.Sequence
+	if last == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	expected := last + 1
+	if expected == 0 {
+		// The firmware skips 0 on wrap
+		expected = 1
+	}
+	if sample.Sequence < expected {
+		d.stats.Restarts++
+		return
+	}
+	if lost := sample.Sequence - expected; lost > 0 {
+		sample.Lost = lost
+		d.stats.Gaps++
+		d.stats.Lost += uint64(lost)
+	}
+}
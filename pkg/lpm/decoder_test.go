@@ -0,0 +1,208 @@
+package lpm
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeAll decodes samples from input until the end of the stream.
+func decodeAll(t *testing.T, dec *Decoder) []RawSample {
+	t.Helper()
+	var samples []RawSample
+	for {
+		sample, err := dec.Decode()
+		if err == io.EOF {
+			return samples
+		}
+		require.NoError(t, err)
+		samples = append(samples, sample)
+	}
+}
+
+func TestDecoder_AutoDetect(t *testing.T) {
+	frame := encodeFrame(RawSample{Timestamp: time.UnixMicro(42), Reading: 7, Voltage: 8, Heaters: []bool{true, false, false}})
+
+	tests := []struct {
+		name     string
+		input    []byte
+		protocol Protocol
+		reading  uint16
+	}{
+		{"text line", []byte("1234567890123,2048,1024,101\n"), ProtocolText, 2048},
+		{"text line with CRLF", []byte("1234567890123,2048,1024,101\r\n"), ProtocolText, 2048},
+		{"binary frame", frame, ProtocolBinary, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(bytes.NewReader(tt.input))
+			sample, err := dec.Decode()
+			require.NoError(t, err)
+			assert.Equal(t, tt.protocol, dec.Protocol())
+			assert.Equal(t, tt.reading, sample.Reading)
+		})
+	}
+}
+
+func TestDecoder_ResyncAfterCorruptFrame(t *testing.T) {
+	good := encodeFrame(RawSample{Timestamp: time.UnixMicro(42), Reading: 7, Voltage: 8})
+	bad := bytes.Clone(good)
+	bad[len(bad)-1] ^= 0xFF
+
+	dec := NewDecoder(bytes.NewReader(append(append(bad, 0x00, 0x01), good...)))
+	samples := decodeAll(t, dec)
+
+	require.Len(t, samples, 1)
+	assert.Equal(t, uint16(7), samples[0].Reading)
+	stats := dec.Stats()
+	assert.Equal(t, uint64(1), stats.Frames)
+	assert.Equal(t, uint64(1), stats.BadFrames)
+	assert.NotEmpty(t, stats.LastError)
+}
+
+func TestDecoder_LeadingGarbage(t *testing.T) {
+	// Power-up noise and the tail of a line cut off when the port was opened
+	input := "\x00\xff\x13,1024,101\n1000,2048,1024,101\n"
+
+	dec := NewDecoder(strings.NewReader(input))
+	samples := decodeAll(t, dec)
+
+	require.Len(t, samples, 1)
+	assert.Equal(t, uint16(2048), samples[0].Reading)
+	stats := dec.Stats()
+	assert.Equal(t, uint64(1), stats.Lines)
+	assert.Equal(t, uint64(1), stats.BadLines)
+	assert.Equal(t, uint64(1), stats.Resyncs)
+	assert.Equal(t, uint64(3), stats.SkippedBytes)
+}
+
+func TestDecoder_PartialLineBeforeFrame(t *testing.T) {
+	// The firmware switched to binary mid-line
+	frame := encodeFrame(RawSample{Timestamp: time.UnixMicro(42), Reading: 7})
+	input := append([]byte("1000,20"), frame...)
+
+	dec := NewDecoder(bytes.NewReader(input))
+	samples := decodeAll(t, dec)
+
+	require.Len(t, samples, 1)
+	assert.Equal(t, uint16(7), samples[0].Reading)
+	assert.Equal(t, ProtocolBinary, dec.Protocol())
+	stats := dec.Stats()
+	assert.Equal(t, uint64(1), stats.Resyncs)
+	assert.Equal(t, uint64(7), stats.SkippedBytes)
+	assert.Zero(t, stats.Errors())
+}
+
+func TestDecoder_OverlongLine(t *testing.T) {
+	input := strings.Repeat("9", 2*maxLineLength) + "\n1000,2048,1024,101\n"
+
+	dec := NewDecoder(strings.NewReader(input))
+	samples := decodeAll(t, dec)
+
+	require.Len(t, samples, 1)
+	assert.Equal(t, uint16(2048), samples[0].Reading)
+	stats := dec.Stats()
+	assert.Equal(t, uint64(1), stats.BadLines, "the tail of the overlong line is still a bad line")
+	assert.Equal(t, uint64(maxLineLength), stats.SkippedBytes)
+}
+
+func TestDecoder_Responses(t *testing.T) {
+	var responses []string
+	dec := NewDecoder(strings.NewReader("#INFO proto=1 channels=2 rate=50\r\n1000,2048,1024,101\n#OK 101\n"))
+	dec.OnResponse(func(line string) { responses = append(responses, line) })
+
+	samples := decodeAll(t, dec)
+
+	assert.Len(t, samples, 1)
+	assert.Equal(t, []string{"#INFO proto=1 channels=2 rate=50", "#OK 101"}, responses)
+	assert.Equal(t, uint64(2), dec.Stats().Responses)
+}
+
+func TestDecoder_OnRaw(t *testing.T) {
+	good := encodeFrame(RawSample{Timestamp: time.UnixMicro(42), Reading: 7, Voltage: 8})
+	bad := bytes.Clone(good)
+	bad[len(bad)-1] ^= 0xFF
+	messages := [][]byte{
+		[]byte("\x00\xff13,1024,101\n"),
+		[]byte("1000,2048,1024,101\r\n"),
+		[]byte("#OK 101\n"),
+		append(bad, good...),
+		[]byte("not,a,sample\n"),
+		[]byte("1000,20"),
+	}
+
+	var raw [][]byte
+	dec := NewDecoder(bytes.NewReader(bytes.Join(messages, nil)))
+	dec.OnRaw(func(data []byte) { raw = append(raw, bytes.Clone(data)) })
+	samples := decodeAll(t, dec)
+
+	assert.Len(t, samples, 2)
+	assert.Equal(t, messages, raw, "every byte is passed on, one message at a time")
+}
+
+func TestDecoder_SequenceGaps(t *testing.T) {
+	input := "1000,1,0,000,1\n1001,2,0,000,2\n1004,5,0,000,5\n1005,6,0,000,6\n1009,10,0,000,10\n"
+
+	dec := NewDecoder(strings.NewReader(input))
+	samples := decodeAll(t, dec)
+
+	require.Len(t, samples, 5)
+	lost := make([]uint32, len(samples))
+	for i, s := range samples {
+		lost[i] = s.Lost
+	}
+	assert.Equal(t, []uint32{0, 0, 2, 0, 3}, lost)
+	stats := dec.Stats()
+	assert.Equal(t, uint64(2), stats.Gaps)
+	assert.Equal(t, uint64(5), stats.Lost)
+	assert.Zero(t, stats.Errors(), "lost samples are not parse errors")
+}
+
+func TestDecoder_SequenceRestartAndWrap(t *testing.T) {
+	frames := [][]byte{
+		encodeFrame(RawSample{Sequence: 7}),
+		encodeFrame(RawSample{Sequence: 1}), // MCU reset
+		encodeFrame(RawSample{Sequence: 2}),
+		encodeFrame(RawSample{Sequence: 0xFFFFFFFF}),
+		encodeFrame(RawSample{Sequence: 1}), // Wrap skips 0
+		encodeFrame(RawSample{}),            // Unnumbered
+	}
+
+	dec := NewDecoder(bytes.NewReader(bytes.Join(frames, nil)))
+	samples := decodeAll(t, dec)
+
+	require.Len(t, samples, 6)
+	assert.Equal(t, uint32(0xFFFFFFFF-3), samples[3].Lost)
+	assert.Zero(t, samples[4].Lost, "wrap is not a gap")
+	stats := dec.Stats()
+	assert.Equal(t, uint64(1), stats.Restarts)
+	assert.Equal(t, uint64(1), stats.Gaps)
+}
+
+func TestSerial_handleResponse_QueuesResponses(t *testing.T) {
+	dev := New("COM3", 0, 0)
+	dec := NewDecoder(strings.NewReader("#INFO proto=1 channels=2 rate=50\n"))
+	dec.OnResponse(dev.handleResponse)
+
+	_, err := dec.Decode()
+	assert.ErrorIs(t, err, io.EOF)
+
+	select {
+	case line := <-dev.responses:
+		assert.Equal(t, "#INFO proto=1 channels=2 rate=50", line)
+	default:
+		t.Fatal("response was not queued")
+	}
+}
+
+func TestParseStats_String(t *testing.T) {
+	stats := ParseStats{Lines: 10, BadLines: 2, BadFrames: 1, Resyncs: 1, SkippedBytes: 5}
+	assert.Equal(t, uint64(3), stats.Errors())
+	assert.Equal(t, "10 lines, 0 frames, 0 responses, 2 bad lines, 1 bad frames, 1 resyncs (5 bytes skipped), 0 gaps (0 samples lost)", stats.String())
+}
@@ -1,14 +1,16 @@
 package lpm
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.bug.st/serial"
@@ -19,16 +21,27 @@ const (
 	DefaultBaudRate = 115200
 	// DefaultBufferSize is the default size for the samples channel buffer.
 	DefaultBufferSize = 100
+	// HandshakeTimeout is how long Connect waits for the firmware's info response.
+	HandshakeTimeout = time.Second
+	// AckTimeout is how long a command waits for the firmware's acknowledgement.
+	AckTimeout = 500 * time.Millisecond
+	// SelfTestTimeout is how long SelfTest waits for the firmware's report.
+	SelfTestTimeout = 3 * time.Second
 )
 
 // RawSample represents a raw measurement sample from the MCU.
 type RawSample struct {
-	Timestamp time.Time
-	Reading   uint16 // 16-bit ADC reading (0-65535) - TinyGo scales to 16-bit
-	Voltage   uint16 // 16-bit ADC reading for voltage (0-65535) - TinyGo scales to 16-bit
-	Heater1   bool   // Heater 1 state
-	Heater2   bool   // Heater 2 state
-	Heater3   bool   // Heater 3 state
+	Timestamp   time.Time
+	Reading     uint16 // 16-bit ADC reading (0-65535) - TinyGo scales to 16-bit
+	Voltage     uint16 // 16-bit ADC reading for voltage (0-65535) - TinyGo scales to 16-bit
+	Heaters     []bool // Heater states, heater 1 first; never modified once received
+	Sequence    uint32 // Sample sequence number from the MCU, 0 if the firmware does not report one
+	Ambient     uint16 // 16-bit ADC reading of the ambient temperature sensor, 0 if the firmware has none
+	Reading2    uint16 // 16-bit ADC reading of the second absorber, valid if HasReading2
+	HasReading2 bool   // The device has a second absorber input (dual-head or differential setups)
+	Lost        uint32 // Samples lost on the link right before this one, detected from a sequence gap
+	Reset       bool   // First sample after the MCU restarted; samples around the restart are missing
+	DeviceID    int    // Index of the source device in a Mux, 0 for a single device
 }
 
 // Port represents a serial port.
@@ -39,16 +52,37 @@ type Port struct {
 
 // Serial represents a connection to the LPM MCU.
 type Serial struct {
-	port     string
-	baudRate int
-	bufSize  int
+	port         string
+	baudRate     int
+	bufSize      int
+	mode         *serial.Mode                                              // Line settings (nil = 8N1 at baudRate)
+	rtsCTS       bool                                                      // Wait for CTS before writing commands
+	binary       bool                                                      // Request binary frames on connect
+	averaging    int                                                       // ADC readings averaged per sample, applied on connect (0 = firmware default)
+	adcInterval  time.Duration                                             // ADC read interval, applied on connect (0 = firmware default)
+	adcGain      int                                                       // Absorber channel gain, applied on connect (0 = firmware default)
+	adcReference float64                                                   // ADC input range in V, applied on connect (0 = firmware default)
+	paused       bool                                                      // Sample output paused by SetStreaming, applied again after an MCU reset
+	sampleRate   float64                                                   // Output rate set by SetSampleRate, applied again after an MCU reset unless averaging is set
+	heaterDuty   []float64                                                 // Heater duties last set, applied again after an MCU reset
+	onRaw        func(data []byte)                                         // Receives the bytes read before decoding (nil = none)
+	openPort     func(name string, mode *serial.Mode) (serial.Port, error) // serial.Open, replaceable in tests
 
 	conn      serial.Port
 	samples   chan RawSample
+	responses chan string   // Command response lines ('#' prefixed) from the MCU
+	commands  chan *command // Command queue of the current connection
 	mu        sync.RWMutex
-	ctx       context.Context
-	cancel    context.CancelFunc
+	cancel    context.CancelFunc // Cancels the current connection
+	done      chan struct{}      // Closed when the current connection has shut down
 	connected bool
+	protocol  Protocol  // Wire protocol detected from incoming data
+	decoder   *Decoder  // Decoder of the current connection
+	info      Info      // Capabilities reported by the firmware handshake
+	infoTime  time.Time // When info was received (to extrapolate uptime)
+	stats     linkStats
+
+	resetPending atomic.Bool // The MCU restarted; flag the next sample
 }
 
 // New creates a new Device instance with the specified port, baud rate, and buffer size.
@@ -60,15 +94,13 @@ func New(port string, baudRate int, bufSize int) *Serial {
 		bufSize = DefaultBufferSize
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-
 	return &Serial{
 		port:      port,
 		baudRate:  baudRate,
 		bufSize:   bufSize,
+		openPort:  serial.Open,
 		samples:   make(chan RawSample, bufSize),
-		ctx:       ctx,
-		cancel:    cancel,
+		responses: make(chan string, 16),
 		connected: false,
 	}
 }
@@ -106,68 +138,604 @@ func Ports() ([]Port, error) {
 	return result, nil
 }
 
-// Connect connects to the serial port and starts reading samples.
+// Connect connects to the serial port without a deadline.
+// It is equivalent to ConnectContext(context.Background()).
 func (d *Serial) Connect() error {
+	return d.ConnectContext(context.Background())
+}
+
+// ConnectContext connects to the serial port, starts reading samples and
+// performs the protocol handshake. Firmware that does not answer the
+// handshake is still usable; its Info reports protocol version 0.
+//
+// The connection lives until ctx is cancelled or Close is called. Either way
+// shutdown follows a single path: the port is closed to unblock the reader,
+// which then marks the device disconnected and closes the samples channel.
+func (d *Serial) ConnectContext(ctx context.Context) error {
+	ctx, err := d.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	d.setup(ctx)
+
+	return nil
+}
+
+// setup queries the firmware and applies the host's settings to it.
+// Runs on connect and again after the MCU restarted.
+func (d *Serial) setup(ctx context.Context) {
+	d.handshake(ctx)
+
+	d.mu.RLock()
+	binary, averaging, adcInterval, paused := d.binary, d.averaging, d.adcInterval, d.paused
+	adcGain, adcReference, sampleRate := d.adcGain, d.adcReference, d.sampleRate
+	d.mu.RUnlock()
+	if binary {
+		if err := d.SetBinary(true); err != nil {
+			log.Printf("Binary frames unavailable on %s, using text lines: %v", d.port, err)
+		}
+	}
+
+	// The interval first: the firmware keeps the averaging count when it changes
+	if adcInterval > 0 {
+		if err := d.SetADCInterval(adcInterval); err != nil {
+			log.Printf("Failed to set ADC interval on %s: %v", d.port, err)
+		}
+	}
+	if averaging > 0 {
+		if err := d.SetAveraging(averaging); err != nil {
+			log.Printf("Failed to set averaging on %s: %v", d.port, err)
+		}
+	} else if sampleRate > 0 {
+		if err := d.SetSampleRate(sampleRate); err != nil {
+			log.Printf("Failed to set sample rate on %s: %v", d.port, err)
+		}
+	}
+
+	if adcReference > 0 {
+		if err := d.SetADCReference(adcReference); err != nil {
+			log.Printf("Failed to set ADC reference on %s: %v", d.port, err)
+		}
+	}
+	if adcGain > 0 {
+		if err := d.SetADCGain(adcGain); err != nil {
+			log.Printf("Failed to set ADC gain on %s: %v", d.port, err)
+		}
+	}
+
+	// Only set after a reset: a new connection starts streaming
+	if paused {
+		if err := d.SetStreaming(false); err != nil {
+			log.Printf("Failed to pause sample output on %s: %v", d.port, err)
+		}
+	}
+}
+
+// open opens the serial port and starts the reading goroutine.
+// Returns the connection context.
+func (d *Serial) open(parent context.Context) (context.Context, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	if d.connected {
-		return fmt.Errorf("already connected")
+		return nil, fmt.Errorf("already connected")
 	}
 
 	mode := &serial.Mode{
 		BaudRate: d.baudRate,
 	}
+	if d.mode != nil {
+		m := *d.mode
+		mode = &m
+	}
 
-	port, err := serial.Open(d.port, mode)
+	port, err := d.openPort(d.port, mode)
 	if err != nil {
-		return fmt.Errorf("failed to open serial port %s: %w", d.port, err)
+		return nil, fmt.Errorf("failed to open serial port %s: %w", d.port, err)
+	}
+
+	// The previous connection closed its samples channel
+	if d.done != nil {
+		d.samples = make(chan RawSample, d.bufSize)
 	}
 
+	ctx, cancel := context.WithCancel(parent)
 	d.conn = port
+	d.cancel = cancel
+	d.done = make(chan struct{})
 	d.connected = true
+	d.stats.connects.Add(1)
+	d.protocol = ProtocolUnknown
+	d.info = Info{}
+	d.paused = false
+
+	// Closing the port is the only way to unblock a pending read
+	portClosed := make(chan struct{})
+	go func() {
+		defer close(portClosed)
+		<-ctx.Done()
+		if err := port.Close(); err != nil {
+			log.Printf("Error closing serial port: %v", err)
+		}
+	}()
+
+	// Commands are executed one at a time by the queue
+	d.commands = make(chan *command)
+	go d.runCommands(ctx, d.commands)
+
+	d.decoder = NewDecoder(countingReader{r: port, n: &d.stats.bytesRead})
+	if d.onRaw != nil {
+		d.decoder.OnRaw(d.onRaw)
+	}
+	decoder := d.decoder
+	d.decoder.OnResponse(func(line string) {
+		if strings.HasPrefix(line, bootPrefix) {
+			d.handleBoot(ctx, decoder, line)
+			return
+		}
+		d.handleResponse(line)
+	})
 
 	// Start reading samples in a goroutine
-	go d.readSamples()
+	go d.readSamples(ctx, d.decoder, d.samples, portClosed, d.done)
 
-	return nil
+	return ctx, nil
 }
 
-// Close closes the connection and stops reading samples.
-func (d *Serial) Close() error {
+// OnRaw sets the handler for the bytes read from the port, message by
+// message before decoding; see Decoder.OnRaw. Takes effect on the next
+// connect.
+func (d *Serial) OnRaw(fn func(data []byte)) bool {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	d.onRaw = fn
+	return true
+}
 
-	if !d.connected {
+// Close cancels the connection and waits until it has shut down.
+func (d *Serial) Close() error {
+	d.mu.RLock()
+	cancel, done := d.cancel, d.done
+	d.mu.RUnlock()
+
+	if cancel == nil {
 		return nil
 	}
 
-	// Cancel context to stop reading goroutine
-	d.cancel()
+	cancel()
+	<-done
 
-	// Close serial port
-	if d.conn != nil {
-		if err := d.conn.Close(); err != nil {
-			log.Printf("Error closing serial port: %v", err)
+	return nil
+}
+
+// Samples returns the channel for reading samples of the current connection.
+// The channel is closed when the connection shuts down.
+func (d *Serial) Samples() <-chan RawSample {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.samples
+}
+
+// ReadSample returns the next sample of the current connection.
+// Returns io.EOF once the connection has shut down and ErrNotConnected
+// if the device was never connected.
+func (d *Serial) ReadSample(ctx context.Context) (RawSample, error) {
+	d.mu.RLock()
+	samples, done := d.samples, d.done
+	d.mu.RUnlock()
+
+	if done == nil {
+		return RawSample{}, ErrNotConnected
+	}
+	return readSample(ctx, samples)
+}
+
+// SetHeaters sets the heater states, one per heater of the device (see
+// Info.HeaterCount), and sends the command to the MCU.
+// Firmware that acknowledges commands (protocol version >= 2) must confirm the
+// applied state within AckTimeout, otherwise an error is returned. Legacy
+// firmware is fire-and-forget.
+func (d *Serial) SetHeaters(states ...bool) error {
+	if n := d.Info().HeaterCount(); len(states) != n {
+		return fmt.Errorf("%w: %d heater states for %d heaters", ErrHeaterCount, len(states), n)
+	}
+
+	// Build command string: "111\n" for all on, "000\n" for all off, etc.
+	digits := formatHeaters(states)
+	cmd := digits + "\n"
+
+	if d.Info().ProtocolVersion < ackProtocolVersion {
+		if _, err := d.submit(&command{line: cmd, retries: CommandRetries}); err != nil {
+			return fmt.Errorf("failed to send heater command: %w", err)
 		}
-		d.conn = nil
+		d.storeHeaterStates(states)
+		return nil
 	}
 
-	d.connected = false
+	reply, err := d.request(cmd, AckTimeout)
+	if err != nil {
+		return fmt.Errorf("heater command %s: %w", digits, err)
+	}
+	if applied := strings.TrimSpace(strings.TrimPrefix(reply, ackPrefix)); applied != digits {
+		return fmt.Errorf("heater command %s: MCU applied %q", digits, applied)
+	}
+	d.storeHeaterStates(states)
 
-	// Close samples channel
-	close(d.samples)
+	return nil
+}
+
+// storeHeaterStates remembers heater states as duties, to apply them again
+// after an MCU reset.
+func (d *Serial) storeHeaterStates(states []bool) {
+	duties := make([]float64, len(states))
+	for i, on := range states {
+		if on {
+			duties[i] = 1
+		}
+	}
+	d.mu.Lock()
+	d.heaterDuty = duties
+	d.mu.Unlock()
+}
+
+// SetHeaterDuty drives the heaters with PWM duty cycles from 0 (off) to 1
+// (fully on), so calibration can use any power between the fixed heater
+// combinations. The firmware resolves duties to per mille. Firmware older
+// than protocol version 7 only supports fully on or off, which is sent as a
+// regular heater command. There is one duty per heater of the device (see
+// Info.HeaterCount).
+func (d *Serial) SetHeaterDuty(duties ...float64) error {
+	if n := d.Info().HeaterCount(); len(duties) != n {
+		return fmt.Errorf("%w: %d heater duties for %d heaters", ErrHeaterCount, len(duties), n)
+	}
+	perMille := make([]int, len(duties))
+	for i, duty := range duties {
+		if duty < 0 || duty > 1 || math.IsNaN(duty) {
+			return fmt.Errorf("invalid duty %v for heater %d", duty, i+1)
+		}
+		perMille[i] = int(math.Round(duty * maxDuty))
+	}
+
+	if v := d.Info().ProtocolVersion; v < dutyProtocolVersion {
+		states := make([]bool, len(perMille))
+		for i, duty := range perMille {
+			if duty != 0 && duty != maxDuty {
+				return fmt.Errorf("heater duty control requires protocol v%d, device reports v%d", dutyProtocolVersion, v)
+			}
+			states[i] = duty == maxDuty
+		}
+		return d.SetHeaters(states...)
+	}
+
+	list := formatDuties(perMille)
+	cmd := fmt.Sprintf(dutyCommand, list)
+	want := "duty=" + list
+	reply, err := d.request(cmd, AckTimeout)
+	if err != nil {
+		return fmt.Errorf("heater duty %s: %w", want, err)
+	}
+	if applied := strings.TrimSpace(strings.TrimPrefix(reply, ackPrefix)); applied != want {
+		return fmt.Errorf("heater duty %s: MCU applied %q", want, applied)
+	}
+	d.mu.Lock()
+	d.heaterDuty = slices.Clone(duties)
+	d.mu.Unlock()
 
 	return nil
 }
 
-// Samples returns the channel for reading samples.
-func (d *Serial) Samples() <-chan RawSample {
-	return d.samples
+// formatDuties formats per mille heater duties as the comma-separated list
+// used on the wire, e.g. "1000,0,250".
+func formatDuties(perMille []int) string {
+	fields := make([]string, len(perMille))
+	for i, duty := range perMille {
+		fields[i] = strconv.Itoa(duty)
+	}
+	return strings.Join(fields, ",")
 }
 
-// SetHeaters sets the heater states and sends the command to the MCU.
-func (d *Serial) SetHeaters(heater1, heater2, heater3 bool) error {
+// SetSampleRate asks the MCU to output samples at hz, trading noise (more
+// averaging per sample) against responsiveness. The firmware quantizes the
+// rate to whole ADC readings; the applied rate is available from Info.
+// Requires protocol version 3 or later.
+func (d *Serial) SetSampleRate(hz float64) error {
+	if v := d.Info().ProtocolVersion; v < rateProtocolVersion {
+		return fmt.Errorf("sample rate control requires protocol v%d, device reports v%d", rateProtocolVersion, v)
+	}
+
+	rate := int(math.Round(hz))
+	if rate <= 0 {
+		return fmt.Errorf("invalid sample rate %v Hz", hz)
+	}
+
+	reply, err := d.request(fmt.Sprintf(rateCommand, rate), AckTimeout)
+	if err != nil {
+		return fmt.Errorf("sample rate %d Hz: %w", rate, err)
+	}
+
+	// The acknowledgement carries the applied rate in info format: "#OK rate=<hz>"
+	var applied Info
+	if err := parseInfo(infoPrefix+strings.TrimPrefix(reply, ackPrefix), &applied); err != nil || applied.SampleRate <= 0 {
+		return fmt.Errorf("sample rate %d Hz: invalid acknowledgement %q", rate, reply)
+	}
+
+	d.applySampling(applied)
+	d.mu.Lock()
+	d.sampleRate = hz
+	d.mu.Unlock()
+
+	log.Printf("Sample rate on %s set to %.1f Hz (requested %d Hz)", d.port, applied.SampleRate, rate)
+
+	return nil
+}
+
+// SetAveraging sets how many ADC readings the MCU averages per sample.
+// More readings lower the noise, but also the output rate, which the MCU
+// reports back and is updated in Info. The count is remembered and applied
+// again on reconnect. Requires protocol version 6 or later.
+func (d *Serial) SetAveraging(n int) error {
+	if v := d.Info().ProtocolVersion; v < averageProtocolVersion {
+		return fmt.Errorf("averaging control requires protocol v%d, device reports v%d", averageProtocolVersion, v)
+	}
+	if n <= 0 {
+		return fmt.Errorf("invalid averaging count %d", n)
+	}
+
+	reply, err := d.request(fmt.Sprintf(averageCommand, n), AckTimeout)
+	if err != nil {
+		return fmt.Errorf("averaging %d: %w", n, err)
+	}
+
+	// The acknowledgement carries the applied settings: "#OK rate=<hz> avg=<n>"
+	var applied Info
+	if err := parseInfo(infoPrefix+strings.TrimPrefix(reply, ackPrefix), &applied); err != nil || applied.Averaging <= 0 {
+		return fmt.Errorf("averaging %d: invalid acknowledgement %q", n, reply)
+	}
+
+	d.applySampling(applied)
+	d.mu.Lock()
+	d.averaging = n
+	d.mu.Unlock()
+
+	log.Printf("Averaging on %s set to %d readings, %.1f Hz (requested %d)", d.port, applied.Averaging, applied.SampleRate, n)
+
+	return nil
+}
+
+// SetADCInterval sets the time between ADC readings on the MCU, in whole
+// milliseconds. The averaging count is kept, so the output rate changes
+// accordingly; the applied settings are updated in Info. Together with
+// SetAveraging this tunes oversampling without rebuilding the firmware.
+// The interval is remembered and applied again on reconnect. Requires protocol version 9 or later.
+func (d *Serial) SetADCInterval(interval time.Duration) error {
+	if v := d.Info().ProtocolVersion; v < intervalProtocolVersion {
+		return fmt.Errorf("ADC interval control requires protocol v%d, device reports v%d", intervalProtocolVersion, v)
+	}
+
+	ms := interval.Milliseconds()
+	if ms <= 0 {
+		return fmt.Errorf("invalid ADC interval %v: must be at least 1ms", interval)
+	}
+
+	reply, err := d.request(fmt.Sprintf(intervalCommand, ms), AckTimeout)
+	if err != nil {
+		return fmt.Errorf("ADC interval %dms: %w", ms, err)
+	}
+
+	// The acknowledgement carries the applied settings: "#OK rate=<hz> avg=<n> interval=<ms>"
+	var applied Info
+	if err := parseInfo(infoPrefix+strings.TrimPrefix(reply, ackPrefix), &applied); err != nil || applied.ADCInterval <= 0 {
+		return fmt.Errorf("ADC interval %dms: invalid acknowledgement %q", ms, reply)
+	}
+
+	d.applySampling(applied)
+	d.mu.Lock()
+	d.adcInterval = interval
+	d.mu.Unlock()
+
+	log.Printf("ADC interval on %s set to %v, %.1f Hz", d.port, applied.ADCInterval, applied.SampleRate)
+
+	return nil
+}
+
+// SetADCGain sets the gain of the absorber ADC channel relative to the
+// default input range, so small thermopile signals use more of the ADC's
+// resolution. The other channels keep the full range. Which gains the
+// hardware supports depends on the board; the SAMD21 supports 1 to 32 in
+// powers of two. The applied gain is updated in Info, and sample conversion
+// must divide by it. Requires protocol version 15 or later.
+func (d *Serial) SetADCGain(gain int) error {
+	if v := d.Info().ProtocolVersion; v < adcProtocolVersion {
+		return fmt.Errorf("ADC gain control requires protocol v%d, device reports v%d", adcProtocolVersion, v)
+	}
+	if gain <= 0 {
+		return fmt.Errorf("invalid ADC gain %d", gain)
+	}
+
+	reply, err := d.request(fmt.Sprintf(gainCommand, gain), AckTimeout)
+	if err != nil {
+		return fmt.Errorf("ADC gain %d: %w", gain, err)
+	}
+
+	// The acknowledgement carries the applied settings: "#OK gain=<g> ref=<mv>"
+	var applied Info
+	if err := parseInfo(infoPrefix+strings.TrimPrefix(reply, ackPrefix), &applied); err != nil || applied.ADCGain != gain {
+		return fmt.Errorf("ADC gain %d: invalid acknowledgement %q", gain, reply)
+	}
+
+	d.applyADC(applied)
+
+	log.Printf("ADC gain on %s set to %d", d.port, applied.ADCGain)
+
+	return nil
+}
+
+// SetADCReference sets the ADC input range in volts, which selects one of
+// the board's references, e.g. 3.3 for the supply or 2.0 for the SAMD21's
+// internal bandgap, which does not follow supply noise. It applies to all
+// channels and is updated in Info. Requires protocol version 15 or later.
+func (d *Serial) SetADCReference(volts float64) error {
+	if v := d.Info().ProtocolVersion; v < adcProtocolVersion {
+		return fmt.Errorf("ADC reference control requires protocol v%d, device reports v%d", adcProtocolVersion, v)
+	}
+
+	mv := int(math.Round(volts * 1000))
+	if mv <= 0 {
+		return fmt.Errorf("invalid ADC reference %vV", volts)
+	}
+
+	reply, err := d.request(fmt.Sprintf(referenceCommand, mv), AckTimeout)
+	if err != nil {
+		return fmt.Errorf("ADC reference %dmV: %w", mv, err)
+	}
+
+	var applied Info
+	if err := parseInfo(infoPrefix+strings.TrimPrefix(reply, ackPrefix), &applied); err != nil || applied.ADCReference <= 0 {
+		return fmt.Errorf("ADC reference %dmV: invalid acknowledgement %q", mv, reply)
+	}
+
+	d.applyADC(applied)
+
+	log.Printf("ADC reference on %s set to %.3fV", d.port, applied.ADCReference)
+
+	return nil
+}
+
+// applyADC stores the ADC settings acknowledged by the MCU.
+func (d *Serial) applyADC(applied Info) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if applied.ADCGain > 0 {
+		d.info.ADCGain = applied.ADCGain
+	}
+	if applied.ADCReference > 0 {
+		d.info.ADCReference = applied.ADCReference
+	}
+}
+
+// applySampling stores the sampling settings acknowledged by the MCU.
+// Older firmware does not report the averaging count or ADC interval, which
+// are kept then.
+func (d *Serial) applySampling(applied Info) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if applied.SampleRate > 0 {
+		d.info.SampleRate = applied.SampleRate
+	}
+	if applied.Averaging > 0 {
+		d.info.Averaging = applied.Averaging
+	}
+	if applied.ADCInterval > 0 {
+		d.info.ADCInterval = applied.ADCInterval
+	}
+}
+
+// SetBinary asks the MCU to output binary frames instead of text lines, or
+// back. Frames are about half the size of a line and carry a CRC16, so
+// corruption is detected rather than parsed. The choice is remembered and
+// applied again on reconnect. Requires protocol version 5 or later.
+func (d *Serial) SetBinary(enabled bool) error {
+	if v := d.Info().ProtocolVersion; v < binaryProtocolVersion {
+		return fmt.Errorf("binary frames require protocol v%d, device reports v%d", binaryProtocolVersion, v)
+	}
+
+	flag := 0
+	if enabled {
+		flag = 1
+	}
+	reply, err := d.request(fmt.Sprintf(binaryCommand, flag), AckTimeout)
+	if err != nil {
+		return fmt.Errorf("binary output %d: %w", flag, err)
+	}
+	if applied := strings.TrimSpace(strings.TrimPrefix(reply, ackPrefix)); applied != fmt.Sprintf("binary=%d", flag) {
+		return fmt.Errorf("binary output %d: MCU applied %q", flag, applied)
+	}
+
+	d.mu.Lock()
+	d.binary = enabled
+	d.mu.Unlock()
+
+	return nil
+}
+
+// SetStreaming pauses or resumes the MCU's sample output, e.g. to reduce
+// link traffic during a long cooloff. The MCU keeps sampling and accepting
+// commands, so heaters stay controllable while paused. Samples taken while
+// paused are not numbered, so resuming does not count them as lost.
+// Requires protocol version 13 or later.
+func (d *Serial) SetStreaming(enabled bool) error {
+	if v := d.Info().ProtocolVersion; v < pauseProtocolVersion {
+		return fmt.Errorf("pausing the sample output requires protocol v%d, device reports v%d", pauseProtocolVersion, v)
+	}
+
+	flag := 1
+	if enabled {
+		flag = 0
+	}
+	reply, err := d.request(fmt.Sprintf(pauseCommand, flag), AckTimeout)
+	if err != nil {
+		return fmt.Errorf("pause %d: %w", flag, err)
+	}
+	if applied := strings.TrimSpace(strings.TrimPrefix(reply, ackPrefix)); applied != fmt.Sprintf("paused=%d", flag) {
+		return fmt.Errorf("pause %d: MCU applied %q", flag, applied)
+	}
+
+	d.mu.Lock()
+	d.paused = !enabled
+	d.mu.Unlock()
+
+	if enabled {
+		log.Printf("Sample output on %s resumed", d.port)
+	} else {
+		log.Printf("Sample output on %s paused", d.port)
+	}
+
+	return nil
+}
+
+// Info returns the device capabilities reported by the firmware handshake.
+// Uptime is extrapolated from the time the handshake response was received.
+func (d *Serial) Info() Info {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	info := d.info
+	if info.Uptime > 0 {
+		info.Uptime += time.Since(d.infoTime)
+	}
+	return info
+}
+
+// Stats returns the link counters.
+func (d *Serial) Stats() Stats {
+	stats := d.stats.snapshot()
+
+	// Parse errors and lost samples of the current connection are counted by its decoder
+	d.mu.RLock()
+	decoder := d.decoder
+	d.mu.RUnlock()
+	if decoder != nil {
+		parseStats := decoder.Stats()
+		stats.ParseErrors += parseStats.Errors()
+		stats.Lost += parseStats.Lost
+	}
+	return stats
+}
+
+// ParseStats returns the parse statistics of the current connection.
+func (d *Serial) ParseStats() ParseStats {
+	d.mu.RLock()
+	decoder := d.decoder
+	d.mu.RUnlock()
+	if decoder == nil {
+		return ParseStats{}
+	}
+	return decoder.Stats()
+}
+
+// writeCommand writes a raw command line to the MCU.
+func (d *Serial) writeCommand(cmd string) error {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
@@ -175,31 +743,106 @@ func (d *Serial) SetHeaters(heater1, heater2, heater3 bool) error {
 		return fmt.Errorf("not connected")
 	}
 
-	// Build command string: "111\n" for all on, "000\n" for all off, etc.
-	var cmd strings.Builder
-	if heater1 {
-		cmd.WriteByte('1')
-	} else {
-		cmd.WriteByte('0')
+	if d.rtsCTS {
+		if err := waitCTS(d.conn, AckTimeout); err != nil {
+			return err
+		}
 	}
-	if heater2 {
-		cmd.WriteByte('1')
-	} else {
-		cmd.WriteByte('0')
+
+	_, err := d.conn.Write([]byte(cmd))
+	return err
+}
+
+// handshake sends the version and identity queries through the command queue
+// and merges the firmware's info responses into the device info.
+// Queries are not retried: no answer means legacy firmware.
+// Without a serial number from the firmware, the USB port's is used.
+func (d *Serial) handshake(ctx context.Context) {
+	var info Info
+	received := 0
+	for _, query := range []string{versionQuery, identityQuery} {
+		reply, err := d.submit(&command{line: query, timeout: HandshakeTimeout, match: matchInfo})
+		if err != nil {
+			break
+		}
+		if err := parseInfo(reply, &info); err != nil {
+			log.Printf("Invalid handshake response '%s': %v", reply, err)
+			continue
+		}
+		received++
 	}
-	if heater3 {
-		cmd.WriteByte('1')
-	} else {
-		cmd.WriteByte('0')
+
+	if received == 0 || ctx.Err() != nil {
+		if ctx.Err() == nil {
+			log.Printf("No handshake response from %s, assuming legacy firmware", d.port)
+			usbSerial := usbSerialNumber(d.port)
+			d.mu.Lock()
+			d.info.Serial = usbSerial
+			d.mu.Unlock()
+		}
+		return
+	}
+	if info.Serial == "" {
+		// Firmware that does not report one is told apart by its USB adapter
+		info.Serial = usbSerialNumber(d.port)
 	}
-	cmd.WriteByte('\n')
 
-	_, err := d.conn.Write([]byte(cmd.String()))
-	if err != nil {
-		return fmt.Errorf("failed to send heater command: %w", err)
+	d.mu.Lock()
+	d.info = info
+	d.infoTime = time.Now()
+	decoder := d.decoder
+	d.mu.Unlock()
+	if decoder != nil {
+		decoder.SetHeaterCount(info.HeaterCount())
 	}
 
-	return nil
+	log.Printf("Device on %s: firmware %s (%s), protocol v%d, %d channels, %d heaters, %.1f Hz",
+		d.port, info.FirmwareVersion, info.Board, info.ProtocolVersion, info.Channels, info.HeaterCount(), info.SampleRate)
+}
+
+// handleResponse queues a command response line for whoever is waiting on it.
+func (d *Serial) handleResponse(line string) {
+	select {
+	case d.responses <- line:
+	default:
+		log.Printf("Response queue full, dropping '%s'", line)
+	}
+}
+
+// handleBoot handles the MCU announcing a start. A boot after samples were
+// received means the MCU reset mid-session: the next sample is flagged as
+// following a gap, and the firmware, now back at its defaults, is set up
+// again with the settings and heater duties last applied.
+func (d *Serial) handleBoot(ctx context.Context, decoder *Decoder, line string) {
+	stats := decoder.Stats()
+	if stats.Lines+stats.Frames == 0 {
+		log.Printf("MCU on %s booted: %s", d.port, line)
+		return
+	}
+
+	log.Printf("MCU on %s restarted mid-session: %s", d.port, line)
+	d.stats.resets.Add(1)
+	d.resetPending.Store(true)
+
+	// Commands are answered through the reader, which is calling us
+	go func() {
+		d.setup(ctx)
+		d.restoreHeaters()
+	}()
+}
+
+// restoreHeaters applies the heater duties last set again after an MCU
+// reset, which switched the heaters off.
+func (d *Serial) restoreHeaters() {
+	d.mu.RLock()
+	duties := d.heaterDuty
+	d.mu.RUnlock()
+	if !slices.ContainsFunc(duties, func(duty float64) bool { return duty > 0 }) {
+		return
+	}
+	if err := d.SetHeaterDuty(duties...); err != nil {
+		log.Printf("Failed to restore heaters on %s after a restart: %v", d.port, err)
+	}
 }
 
 // IsConnected returns whether the device is currently connected.
@@ -209,70 +852,111 @@ func (d *Serial) IsConnected() bool {
 	return d.connected
 }
 
-// readSamples reads lines from the serial port and parses them into RawSample.
-func (d *Serial) readSamples() {
+// Protocol returns the wire protocol detected from the incoming stream.
+// Returns ProtocolUnknown until the first valid sample has been received.
+func (d *Serial) Protocol() Protocol {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.protocol
+}
+
+// setProtocol records the detected wire protocol the first time it is seen.
+func (d *Serial) setProtocol(p Protocol) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.protocol == ProtocolUnknown {
+		d.protocol = p
+		log.Printf("Detected %s protocol on %s", p, d.port)
+	}
+}
+
+// readSamples decodes messages from the serial port into RawSample.
+// Both the CSV text protocol and binary frames are accepted; see Decoder.
+//
+// The reader owns the connection's shutdown: when it stops, whether cancelled
+// or because the port failed, it waits for the port to be closed, marks the
+// device disconnected and closes the samples channel and done.
+func (d *Serial) readSamples(ctx context.Context, decoder *Decoder, samples chan RawSample, portClosed, done chan struct{}) {
+	defer func() {
+		d.mu.RLock()
+		cancel := d.cancel
+		d.mu.RUnlock()
+		cancel()
+		<-portClosed
+
+		parseStats := decoder.Stats()
+		if parseStats.Errors() > 0 || parseStats.Resyncs > 0 || parseStats.Gaps > 0 {
+			log.Printf("Parse summary for %s: %s; last error: %s", d.port, parseStats, parseStats.LastError)
+		}
+
+		d.mu.Lock()
+		d.conn = nil
+		d.connected = false
+		d.decoder = nil
+		d.stats.parseErrors.Add(parseStats.Errors())
+		d.stats.lost.Add(parseStats.Lost)
+		d.mu.Unlock()
+
+		close(samples)
+		close(done)
+	}()
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Panic in readSamples: %v", r)
 		}
 	}()
 
-	scanner := bufio.NewScanner(d.conn)
 	samplesSkipped := 0
 	skipCount := 100
 
 	for {
-		select {
-		case <-d.ctx.Done():
-			return
-		default:
-			if !scanner.Scan() {
-				// Scanner stopped (EOF or error)
-				if err := scanner.Err(); err != nil {
-					if err != io.EOF {
-						log.Printf("Error reading from serial port: %v", err)
-					}
-				}
-				return
-			}
-
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" {
-				continue
-			}
-
-			sample, err := parseLine(line)
-			if err != nil {
-				log.Printf("Failed to parse line '%s': %v", line, err)
-				continue
+		sample, err := decoder.Decode()
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				log.Printf("Error reading from serial port: %v", err)
 			}
+			return
+		}
+		d.setProtocol(decoder.Protocol())
+		d.stats.parsed.Add(1)
+		if d.resetPending.Swap(false) {
+			sample.Reset = true
+		}
+		if sample.Lost > 0 {
+			log.Printf("Lost %d samples before sequence %d", sample.Lost, sample.Sequence)
+		}
 
-			// Skip first 100 samples
-			if samplesSkipped < skipCount {
-				samplesSkipped++
-				continue
-			}
+		// Skip first 100 samples
+		if samplesSkipped < skipCount {
+			samplesSkipped++
+			continue
+		}
 
-			// Send sample to channel (non-blocking)
-			select {
-			case d.samples <- sample:
-			case <-d.ctx.Done():
-				return
-			default:
-				// Channel full, log and skip
-				log.Printf("Samples channel full, dropping sample")
-			}
+		// Send sample to channel (non-blocking)
+		select {
+		case samples <- sample:
+		case <-ctx.Done():
+			return
+		default:
+			// Channel full, log and skip
+			d.stats.dropped.Add(1)
+			log.Printf("Samples channel full, dropping sample")
 		}
 	}
 }
 
 // parseLine parses a line from the MCU into a RawSample.
-// Format: unix_micros,reading,voltage,heater1heater2heater3
-// Example: 1234567890123,2048,1024,101
+// Protocol v4 firmware appends the sequence number as a fifth field,
+// protocol v10 firmware the ambient temperature reading as a sixth, and
+// protocol v16 firmware the second absorber reading as a seventh on boards
+// that have one.
+// Format: unix_micros,reading,voltage,heater1heater2heater3[,sequence[,ambient[,reading2]]]
+// Example: 1234567890123,2048,1024,101,42,30000,2100
+// The heater field has one digit per heater of the device.
 func parseLine(line string) (RawSample, error) {
 	parts := strings.Split(line, ",")
-	if len(parts) != 4 {
-		return RawSample{}, fmt.Errorf("invalid line format: expected 4 comma-separated values, got %d", len(parts))
+	if len(parts) < 4 || len(parts) > 7 {
+		return RawSample{}, fmt.Errorf("invalid line format: expected 4 to 7 comma-separated values, got %d", len(parts))
 	}
 
 	// Parse timestamp (unix microseconds)
@@ -300,22 +984,66 @@ func parseLine(line string) (RawSample, error) {
 		return RawSample{}, fmt.Errorf("voltage out of range: %d (max 65535)", voltage)
 	}
 
-	// Parse heater states (3 digits: heater1, heater2, heater3)
-	heaterStr := parts[3]
-	if len(heaterStr) != 3 {
-		return RawSample{}, fmt.Errorf("invalid heater states: expected 3 digits, got %d", len(heaterStr))
+	// Parse heater states (one digit per heater, heater 1 first)
+	heaters, err := parseHeaters(parts[3])
+	if err != nil {
+		return RawSample{}, err
+	}
+
+	// Parse optional sequence number
+	var sequence uint64
+	if len(parts) >= 5 {
+		sequence, err = strconv.ParseUint(parts[4], 10, 32)
+		if err != nil {
+			return RawSample{}, fmt.Errorf("invalid sequence: %w", err)
+		}
+	}
+
+	// Parse optional ambient temperature reading
+	var ambient uint64
+	if len(parts) >= 6 {
+		ambient, err = strconv.ParseUint(parts[5], 10, 16)
+		if err != nil {
+			return RawSample{}, fmt.Errorf("invalid ambient: %w", err)
+		}
 	}
 
-	heater1 := heaterStr[0] == '1'
-	heater2 := heaterStr[1] == '1'
-	heater3 := heaterStr[2] == '1'
+	// Parse optional second absorber reading
+	var reading2 uint64
+	if len(parts) == 7 {
+		reading2, err = strconv.ParseUint(parts[6], 10, 16)
+		if err != nil {
+			return RawSample{}, fmt.Errorf("invalid second reading: %w", err)
+		}
+	}
 
 	return RawSample{
-		Timestamp: timestamp,
-		Reading:   uint16(reading),
-		Voltage:   uint16(voltage),
-		Heater1:   heater1,
-		Heater2:   heater2,
-		Heater3:   heater3,
+		Timestamp:   timestamp,
+		Reading:     uint16(reading),
+		Voltage:     uint16(voltage),
+		Heaters:     heaters,
+		Sequence:    uint32(sequence),
+		Ambient:     uint16(ambient),
+		Reading2:    uint16(reading2),
+		HasReading2: len(parts) == 7,
 	}, nil
 }
+
+// parseHeaters parses the heater field of a sample line: one '0' or '1' per
+// heater, at most MaxHeaters.
+func parseHeaters(field string) ([]bool, error) {
+	if len(field) == 0 || len(field) > MaxHeaters {
+		return nil, fmt.Errorf("invalid heater states: expected 1 to %d digits, got %d", MaxHeaters, len(field))
+	}
+	states := make([]bool, len(field))
+	for i := range field {
+		switch field[i] {
+		case '0':
+		case '1':
+			states[i] = true
+		default:
+			return nil, fmt.Errorf("invalid heater states %q", field)
+		}
+	}
+	return states, nil
+}
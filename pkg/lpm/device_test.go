@@ -1,12 +1,21 @@
 package lpm
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/itohio/golpm/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
 )
 
 func TestParseLine(t *testing.T) {
@@ -23,9 +32,7 @@ func TestParseLine(t *testing.T) {
 				Timestamp: time.Unix(0, 1234567890123*1000),
 				Reading:   2048,
 				Voltage:   1024,
-				Heater1:   true,
-				Heater2:   true,
-				Heater3:   true,
+				Heaters:   []bool{true, true, true},
 			},
 			wantErr: false,
 		},
@@ -36,9 +43,7 @@ func TestParseLine(t *testing.T) {
 				Timestamp: time.Unix(0, 1234567890123*1000),
 				Reading:   2048,
 				Voltage:   1024,
-				Heater1:   false,
-				Heater2:   false,
-				Heater3:   false,
+				Heaters:   []bool{false, false, false},
 			},
 			wantErr: false,
 		},
@@ -49,9 +54,7 @@ func TestParseLine(t *testing.T) {
 				Timestamp: time.Unix(0, 1234567890123*1000),
 				Reading:   2048,
 				Voltage:   1024,
-				Heater1:   true,
-				Heater2:   false,
-				Heater3:   true,
+				Heaters:   []bool{true, false, true},
 			},
 			wantErr: false,
 		},
@@ -62,9 +65,47 @@ func TestParseLine(t *testing.T) {
 				Timestamp: time.Unix(0, 1234567890123*1000),
 				Reading:   65535,
 				Voltage:   65535,
-				Heater1:   false,
-				Heater2:   true,
-				Heater3:   false,
+				Heaters:   []bool{false, true, false},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid line - with sequence number",
+			line: "1234567890123,2048,1024,100,42",
+			want: RawSample{
+				Timestamp: time.Unix(0, 1234567890123*1000),
+				Reading:   2048,
+				Voltage:   1024,
+				Heaters:   []bool{true, false, false},
+				Sequence:  42,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid line - with ambient reading",
+			line: "1234567890123,2048,1024,001,42,30000",
+			want: RawSample{
+				Timestamp: time.Unix(0, 1234567890123*1000),
+				Reading:   2048,
+				Voltage:   1024,
+				Heaters:   []bool{false, false, true},
+				Sequence:  42,
+				Ambient:   30000,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid line - with second absorber reading",
+			line: "1234567890123,2048,1024,001,42,30000,2100",
+			want: RawSample{
+				Timestamp:   time.Unix(0, 1234567890123*1000),
+				Reading:     2048,
+				Voltage:     1024,
+				Heaters:     []bool{false, false, true},
+				Sequence:    42,
+				Ambient:     30000,
+				Reading2:    2100,
+				HasReading2: true,
 			},
 			wantErr: false,
 		},
@@ -75,6 +116,21 @@ func TestParseLine(t *testing.T) {
 		},
 		{
 			name:    "invalid - too many fields",
+			line:    "1234567890123,2048,1024,101,42,30000,2100,extra",
+			wantErr: true,
+		},
+		{
+			name:    "invalid - non-numeric second reading",
+			line:    "1234567890123,2048,1024,101,42,30000,extra",
+			wantErr: true,
+		},
+		{
+			name:    "invalid - ambient out of range",
+			line:    "1234567890123,2048,1024,101,42,70000",
+			wantErr: true,
+		},
+		{
+			name:    "invalid - non-numeric sequence",
 			line:    "1234567890123,2048,1024,101,extra",
 			wantErr: true,
 		},
@@ -99,13 +155,38 @@ func TestParseLine(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "invalid - heater states wrong length",
-			line:    "1234567890123,2048,1024,11",
+			name: "valid line - two heaters",
+			line: "1234567890123,2048,1024,01",
+			want: RawSample{
+				Timestamp: time.Unix(0, 1234567890123*1000),
+				Reading:   2048,
+				Voltage:   1024,
+				Heaters:   []bool{false, true},
+			},
+		},
+		{
+			name: "valid line - five heaters",
+			line: "1234567890123,2048,1024,10011",
+			want: RawSample{
+				Timestamp: time.Unix(0, 1234567890123*1000),
+				Reading:   2048,
+				Voltage:   1024,
+				Heaters:   []bool{true, false, false, true, true},
+			},
+		},
+		{
+			name:    "invalid - no heater states",
+			line:    "1234567890123,2048,1024,",
 			wantErr: true,
 		},
 		{
-			name:    "invalid - heater states wrong length 2",
-			line:    "1234567890123,2048,1024,1111",
+			name:    "invalid - more heater states than MaxHeaters",
+			line:    "1234567890123,2048,1024,111111111",
+			wantErr: true,
+		},
+		{
+			name:    "invalid - heater state not a digit",
+			line:    "1234567890123,2048,1024,1x1",
 			wantErr: true,
 		},
 	}
@@ -120,9 +201,8 @@ func TestParseLine(t *testing.T) {
 				assert.Equal(t, tt.want.Timestamp.UnixNano(), got.Timestamp.UnixNano())
 				assert.Equal(t, tt.want.Reading, got.Reading)
 				assert.Equal(t, tt.want.Voltage, got.Voltage)
-				assert.Equal(t, tt.want.Heater1, got.Heater1)
-				assert.Equal(t, tt.want.Heater2, got.Heater2)
-				assert.Equal(t, tt.want.Heater3, got.Heater3)
+				assert.Equal(t, tt.want.Heaters, got.Heaters)
+				assert.Equal(t, tt.want.Sequence, got.Sequence)
 			}
 		})
 	}
@@ -152,40 +232,788 @@ func TestDevice_IsConnected(t *testing.T) {
 
 func TestSetHeaters_CommandFormat(t *testing.T) {
 	tests := []struct {
-		name           string
-		heater1, heater2, heater3 bool
-		wantCmd        string
+		name    string
+		states  []bool
+		wantCmd string
 	}{
-		{"all on", true, true, true, "111\n"},
-		{"all off", false, false, false, "000\n"},
-		{"1 and 3 on", true, false, true, "101\n"},
-		{"only 2 on", false, true, false, "010\n"},
-		{"only 1 on", true, false, false, "100\n"},
-		{"only 3 on", false, false, true, "001\n"},
+		{"all on", []bool{true, true, true}, "111\n"},
+		{"all off", []bool{false, false, false}, "000\n"},
+		{"1 and 3 on", []bool{true, false, true}, "101\n"},
+		{"only 2 on", []bool{false, true, false}, "010\n"},
+		{"only 1 on", []bool{true, false, false}, "100\n"},
+		{"only 3 on", []bool{false, false, true}, "001\n"},
+		{"single heater", []bool{true}, "1\n"},
+		{"five heaters", []bool{false, true, false, false, true}, "01001\n"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var cmd strings.Builder
-			if tt.heater1 {
-				cmd.WriteByte('1')
-			} else {
-				cmd.WriteByte('0')
-			}
-			if tt.heater2 {
-				cmd.WriteByte('1')
-			} else {
-				cmd.WriteByte('0')
-			}
-			if tt.heater3 {
-				cmd.WriteByte('1')
-			} else {
-				cmd.WriteByte('0')
-			}
-			cmd.WriteByte('\n')
-
-			assert.Equal(t, tt.wantCmd, cmd.String())
+			assert.Equal(t, tt.wantCmd, formatHeaters(tt.states)+"\n")
 		})
 	}
 }
 
+func TestSerial_Handshake(t *testing.T) {
+	dev, err := connectFake(newFakePort(firmwareV3))
+	require.NoError(t, err)
+	defer dev.Close()
+
+	info := dev.Info()
+	assert.Equal(t, 3, info.ProtocolVersion)
+	assert.Equal(t, 2, info.Channels)
+	assert.Equal(t, float64(50), info.SampleRate)
+	assert.Equal(t, "0.2.0", info.FirmwareVersion)
+	assert.Equal(t, "xiao", info.Board)
+	assert.GreaterOrEqual(t, info.Uptime, time.Second)
+}
+
+func TestSerial_Handshake_QueryRejected(t *testing.T) {
+	port := newFakePort(func(cmd string) string {
+		if cmd == "I?\n" {
+			return "#ERR unknown\n"
+		}
+		return firmwareV3(cmd)
+	})
+
+	start := time.Now()
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.Less(t, time.Since(start), HandshakeTimeout, "A rejected query does not wait for its timeout")
+	assert.Equal(t, 3, dev.Info().ProtocolVersion)
+	assert.Empty(t, dev.Info().FirmwareVersion)
+}
+
+func TestSerial_SetHeaters_Acknowledged(t *testing.T) {
+	port := newFakePort(firmwareV3)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	require.NoError(t, dev.SetHeaters(true, false, true))
+	assert.Contains(t, port.commands(), "101\n")
+}
+
+func TestSerial_SetHeaters_NotApplied(t *testing.T) {
+	port := newFakePort(func(cmd string) string {
+		if len(cmd) == 4 {
+			return "#OK 000\n" // MCU reports heaters still off
+		}
+		return firmwareV3(cmd)
+	})
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	err = dev.SetHeaters(true, true, true)
+	assert.ErrorContains(t, err, "applied")
+}
+
+func TestSerial_SetHeaters_Timeout(t *testing.T) {
+	port := newFakePort(func(cmd string) string {
+		if len(cmd) == 4 {
+			return "" // MCU never answers
+		}
+		return firmwareV3(cmd)
+	})
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	err = dev.SetHeaters(true, false, false)
+	assert.ErrorContains(t, err, "no acknowledgement")
+	assert.ErrorIs(t, err, ErrCommandFailed)
+
+	// Resent CommandRetries times before giving up
+	sent := 0
+	for _, cmd := range port.commands() {
+		if cmd == "100\n" {
+			sent++
+		}
+	}
+	assert.Equal(t, CommandRetries+1, sent)
+	stats := dev.Stats()
+	assert.Equal(t, uint64(CommandRetries), stats.Retries)
+	assert.Equal(t, uint64(1), stats.CommandFailures)
+}
+
+func TestSerial_SetHeaters_RetryAfterLostReply(t *testing.T) {
+	var mu sync.Mutex
+	lost := false
+	port := newFakePort(func(cmd string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(cmd) == 4 && !lost {
+			lost = true
+			return "" // First acknowledgement is lost
+		}
+		return firmwareV3(cmd)
+	})
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	require.NoError(t, dev.SetHeaters(false, false, true))
+	assert.Equal(t, uint64(1), dev.Stats().Retries)
+	assert.Zero(t, dev.Stats().CommandFailures)
+}
+
+func TestSerial_Commands_Serialized(t *testing.T) {
+	port := newFakePort(firmwareV3)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, dev.SetHeaters(i&1 != 0, i&2 != 0, i&4 != 0))
+		}()
+	}
+	wg.Wait()
+
+	// Every command was written whole and acknowledged without retries
+	for _, cmd := range port.commands() {
+		assert.True(t, strings.HasSuffix(cmd, "\n"), "interleaved write %q", cmd)
+	}
+	assert.Zero(t, dev.Stats().Retries)
+}
+
+func TestSerial_SetHeaters_LegacyFirmware(t *testing.T) {
+	port := newFakePort(nil)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.Equal(t, 0, dev.Info().ProtocolVersion)
+	require.NoError(t, dev.SetHeaters(false, true, false))
+	assert.Contains(t, port.commands(), "010\n")
+}
+
+func TestSerial_SetSampleRate(t *testing.T) {
+	port := newFakePort(firmwareV3)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	require.NoError(t, dev.SetSampleRate(30))
+	assert.Contains(t, port.commands(), "R30\n")
+	assert.InDelta(t, 30.3, dev.Info().SampleRate, 0.01, "Applied rate is quantized by the firmware")
+
+	assert.Error(t, dev.SetSampleRate(0))
+}
+
+func TestSerial_SetSampleRate_Rejected(t *testing.T) {
+	port := newFakePort(func(cmd string) string {
+		if strings.HasPrefix(cmd, "R") {
+			return "#ERR rate\n"
+		}
+		return firmwareV3(cmd)
+	})
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.ErrorContains(t, dev.SetSampleRate(5000), "rejected")
+	assert.Equal(t, float64(50), dev.Info().SampleRate)
+	assert.Zero(t, dev.Stats().Retries, "Rejected commands are not retried")
+}
+
+func TestSerial_SetSampleRate_Unsupported(t *testing.T) {
+	port := newFakePort(nil)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.ErrorContains(t, dev.SetSampleRate(25), "requires protocol")
+	assert.NotContains(t, port.commands(), "R25\n")
+}
+
+// firmwareV5 answers like protocol version 5 firmware that can switch to binary frames.
+func firmwareV5(cmd string) string {
+	switch {
+	case cmd == "V?\n":
+		return "#INFO proto=5 channels=2 rate=50.0\n"
+	case cmd == "B0\n" || cmd == "B1\n":
+		return "#OK binary=" + cmd[1:2] + "\n"
+	}
+	return firmwareV3(cmd)
+}
+
+func TestSerial_SetBinary(t *testing.T) {
+	port := newFakePort(firmwareV5)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	require.NoError(t, dev.SetBinary(true))
+	assert.Contains(t, port.commands(), "B1\n")
+
+	// The first 100 samples are skipped
+	go func() {
+		for seq := range uint32(101) {
+			port.emit(string(encodeFrame(RawSample{Timestamp: time.UnixMicro(1234567890123), Reading: 2048, Voltage: 1024, Heaters: []bool{true, false, false}, Sequence: seq + 1})))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sample, err := dev.ReadSample(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(2048), sample.Reading)
+	assert.Equal(t, ProtocolBinary, dev.Protocol())
+
+	require.NoError(t, dev.SetBinary(false))
+	assert.Contains(t, port.commands(), "B0\n")
+}
+
+func TestSerial_SetBinary_Unsupported(t *testing.T) {
+	port := newFakePort(firmwareV3)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.ErrorContains(t, dev.SetBinary(true), "require protocol")
+	assert.NotContains(t, port.commands(), "B1\n")
+}
+
+func TestSerial_BinaryOnConnect(t *testing.T) {
+	port := newFakePort(firmwareV5)
+	dev := New("fake", 0, 0)
+	dev.binary = true
+	dev.openPort = func(string, *serial.Mode) (serial.Port, error) { return port, nil }
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	assert.Equal(t, []string{"V?\n", "I?\n", "B1\n"}, port.commands())
+
+	// Legacy firmware keeps text lines without failing the connect
+	legacy := newFakePort(firmwareV3)
+	old := New("fake", 0, 0)
+	old.binary = true
+	old.openPort = func(string, *serial.Mode) (serial.Port, error) { return legacy, nil }
+	require.NoError(t, old.Connect())
+	defer old.Close()
+	assert.NotContains(t, legacy.commands(), "B1\n")
+}
+
+// firmwareV6 answers like protocol version 6 firmware that reports and sets
+// the averaging count, with 1ms ADC readings.
+func firmwareV6(cmd string) string {
+	switch {
+	case cmd == "V?\n":
+		return "#INFO proto=6 channels=2 rate=50.0 avg=20\n"
+	case strings.HasPrefix(cmd, "N"):
+		n, err := strconv.Atoi(strings.TrimSpace(cmd[1:]))
+		if err != nil || n <= 0 || n > 1000 {
+			return "#ERR avg\n"
+		}
+		return fmt.Sprintf("#OK rate=%.1f avg=%d\n", 1000/float64(n), n)
+	}
+	return firmwareV5(cmd)
+}
+
+func TestSerial_SetAveraging(t *testing.T) {
+	port := newFakePort(firmwareV6)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.Equal(t, 20, dev.Info().Averaging)
+
+	require.NoError(t, dev.SetAveraging(40))
+	assert.Contains(t, port.commands(), "N40\n")
+	assert.Equal(t, 40, dev.Info().Averaging)
+	assert.Equal(t, 25.0, dev.Info().SampleRate, "Rate follows the averaging count")
+	assert.Equal(t, 40, dev.averaging, "Applied again on reconnect")
+
+	assert.ErrorContains(t, dev.SetAveraging(5000), "rejected")
+	assert.Equal(t, 40, dev.Info().Averaging)
+	assert.Error(t, dev.SetAveraging(0))
+}
+
+func TestSerial_SetAveraging_Unsupported(t *testing.T) {
+	port := newFakePort(firmwareV5)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.ErrorContains(t, dev.SetAveraging(10), "requires protocol")
+	assert.NotContains(t, port.commands(), "N10\n")
+}
+
+// firmwareV7 answers like protocol version 7 firmware that drives the
+// heaters with PWM duty cycles.
+func firmwareV7(cmd string) string {
+	switch {
+	case cmd == "V?\n":
+		return "#INFO proto=7 channels=2 rate=50.0 avg=20\n"
+	case strings.HasPrefix(cmd, "D"):
+		return "#OK duty=" + strings.TrimSpace(cmd[1:]) + "\n"
+	}
+	return firmwareV6(cmd)
+}
+
+func TestSerial_SetHeaterDuty(t *testing.T) {
+	port := newFakePort(firmwareV7)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	require.NoError(t, dev.SetHeaterDuty(0.5, 0, 0.1234))
+	assert.Contains(t, port.commands(), "D500,0,123\n")
+
+	assert.Error(t, dev.SetHeaterDuty(1.1, 0, 0))
+	assert.Error(t, dev.SetHeaterDuty(0, -1, 0))
+	assert.NotContains(t, port.commands(), "D1100,0,0\n")
+}
+
+func TestSerial_SetHeaterDuty_NotApplied(t *testing.T) {
+	port := newFakePort(func(cmd string) string {
+		if strings.HasPrefix(cmd, "D") {
+			return "#OK duty=0,0,0\n"
+		}
+		return firmwareV7(cmd)
+	})
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.ErrorContains(t, dev.SetHeaterDuty(0.5, 0, 0), "MCU applied")
+}
+
+func TestSerial_SetHeaterDuty_LegacyFirmware(t *testing.T) {
+	port := newFakePort(firmwareV3)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	// Full on/off duties fall back to a heater command
+	require.NoError(t, dev.SetHeaterDuty(1, 0, 1))
+	assert.Contains(t, port.commands(), "101\n")
+
+	assert.ErrorContains(t, dev.SetHeaterDuty(0.5, 0, 0), "requires protocol")
+}
+
+// firmwareV17 answers like protocol version 17 firmware built for five
+// heaters.
+func firmwareV17(cmd string) string {
+	switch {
+	case cmd == "V?\n":
+		return "#INFO proto=17 channels=2 rate=50.0 avg=20 heaters=5\n"
+	case strings.Trim(cmd, "01") == "\n":
+		return "#OK " + strings.TrimSpace(cmd) + "\n"
+	}
+	return firmwareV7(cmd)
+}
+
+func TestSerial_HeaterCount(t *testing.T) {
+	port := newFakePort(firmwareV17)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.Equal(t, 5, dev.Info().HeaterCount())
+	require.NoError(t, dev.SetHeaters(true, false, false, false, true))
+	assert.Contains(t, port.commands(), "10001\n")
+	require.NoError(t, dev.SetHeaterDuty(0, 0, 0, 0.5, 1))
+	assert.Contains(t, port.commands(), "D0,0,0,500,1000\n")
+
+	// Three heaters were the only option before, so refuse rather than guess
+	assert.ErrorIs(t, dev.SetHeaters(true, false, true), ErrHeaterCount)
+	assert.ErrorIs(t, dev.SetHeaterDuty(1, 0, 0), ErrHeaterCount)
+	assert.NotContains(t, port.commands(), "101\n")
+}
+
+func TestSerial_SerialNumber(t *testing.T) {
+	listPortDetails = func() ([]*enumerator.PortDetails, error) {
+		return []*enumerator.PortDetails{{Name: "fake", IsUSB: true, SerialNumber: "USB123"}}, nil
+	}
+	defer func() { listPortDetails = enumerator.GetDetailedPortsList }()
+
+	// The firmware's serial number identifies the board itself
+	port := newFakePort(func(cmd string) string {
+		if cmd == "I?\n" {
+			return "#INFO fw=0.3.0 board=pico serial=e6614c311b4b2a2f uptime=10\n"
+		}
+		return firmwareV17(cmd)
+	})
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	assert.Equal(t, "e6614c311b4b2a2f", dev.Info().Serial)
+	dev.Close()
+
+	// Older firmware is told apart by its USB adapter
+	port = newFakePort(firmwareV17)
+	dev, err = connectFake(port)
+	require.NoError(t, err)
+	assert.Equal(t, "USB123", dev.Info().Serial)
+	dev.Close()
+
+	// Also legacy firmware that does not answer the handshake
+	port = newFakePort(nil)
+	dev, err = connectFake(port)
+	require.NoError(t, err)
+	assert.Equal(t, "USB123", dev.Info().Serial)
+	dev.Close()
+}
+
+// firmwareV9 answers like protocol version 9 firmware with a configurable
+// ADC read interval, starting at 1ms readings averaged 20 times.
+func firmwareV9() func(cmd string) string {
+	var mu sync.Mutex
+	interval, avg := 1, 20
+	ack := func() string {
+		return fmt.Sprintf("rate=%.1f avg=%d interval=%d", 1000/float64(interval*avg), avg, interval)
+	}
+	return func(cmd string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		n, err := strconv.Atoi(strings.TrimSpace(cmd[1:]))
+		switch {
+		case cmd == "V?\n":
+			return "#INFO proto=9 channels=2 " + ack() + "\n"
+		case cmd[0] == 'T' && (err != nil || n <= 0 || n > 100):
+			return "#ERR interval\n"
+		case cmd[0] == 'T':
+			interval = n
+			avg = min(avg, 1000/interval)
+			return "#OK " + ack() + "\n"
+		case cmd[0] == 'N' && err == nil && n > 0:
+			avg = n
+			return "#OK " + ack() + "\n"
+		}
+		return firmwareV7(cmd)
+	}
+}
+
+func TestSerial_SetADCInterval(t *testing.T) {
+	port := newFakePort(firmwareV9())
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.Equal(t, time.Millisecond, dev.Info().ADCInterval)
+
+	require.NoError(t, dev.SetADCInterval(2*time.Millisecond))
+	assert.Contains(t, port.commands(), "T2\n")
+	assert.Equal(t, 2*time.Millisecond, dev.Info().ADCInterval)
+	assert.Equal(t, 20, dev.Info().Averaging)
+	assert.Equal(t, 25.0, dev.Info().SampleRate)
+	assert.Equal(t, 2*time.Millisecond, dev.adcInterval, "Applied again on reconnect")
+
+	assert.ErrorContains(t, dev.SetADCInterval(time.Second), "rejected")
+	assert.Error(t, dev.SetADCInterval(time.Microsecond))
+	assert.Equal(t, 2*time.Millisecond, dev.Info().ADCInterval)
+}
+
+func TestSerial_SetADCInterval_Unsupported(t *testing.T) {
+	port := newFakePort(firmwareV7)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.ErrorContains(t, dev.SetADCInterval(2*time.Millisecond), "requires protocol")
+	assert.NotContains(t, port.commands(), "T2\n")
+}
+
+func TestSerial_OversamplingOnConnect(t *testing.T) {
+	port := newFakePort(firmwareV9())
+	dev, err := NewFromConfig(&config.SerialConfig{Port: "fake", Averaging: 50, ADCInterval: 4 * time.Millisecond}, 0)
+	require.NoError(t, err)
+	dev.openPort = func(string, *serial.Mode) (serial.Port, error) { return port, nil }
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	assert.Equal(t, []string{"V?\n", "I?\n", "T4\n", "N50\n"}, port.commands())
+	assert.Equal(t, 50, dev.Info().Averaging)
+	assert.Equal(t, 4*time.Millisecond, dev.Info().ADCInterval)
+	assert.Equal(t, 5.0, dev.Info().SampleRate)
+}
+
+func TestSerial_BootMidSession(t *testing.T) {
+	port := newFakePort(firmwareV5)
+	dev := New("fake", 0, 0)
+	dev.binary = true
+	dev.openPort = func(string, *serial.Mode) (serial.Port, error) { return port, nil }
+
+	// A banner before any samples is just the MCU starting up
+	go port.emit("#BOOT proto=8 fw=0.2.0 board=xiao\n")
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	// The first 100 samples are skipped
+	go func() {
+		for seq := range uint32(101) {
+			port.emit(fmt.Sprintf("1234567890123,2048,1024,000,%d\n", seq+1))
+		}
+		port.emit("#BOOT proto=8 fw=0.2.0 board=xiao\n")
+		port.emit("1234567990123,2048,1024,000,1\n")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sample, err := dev.ReadSample(ctx)
+	require.NoError(t, err)
+	assert.False(t, sample.Reset)
+
+	sample, err = dev.ReadSample(ctx)
+	require.NoError(t, err)
+	assert.True(t, sample.Reset)
+	assert.Equal(t, uint64(1), dev.Stats().Resets)
+
+	// The MCU is set up again after the restart
+	assert.Eventually(t, func() bool {
+		return slices.Equal(port.commands(), []string{"V?\n", "I?\n", "B1\n", "V?\n", "I?\n", "B1\n"})
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSerial_RestoredAfterBoot(t *testing.T) {
+	port := newFakePort(firmwareV7)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	require.NoError(t, dev.SetSampleRate(25))
+	require.NoError(t, dev.SetHeaterDuty(0.5, 0, 1))
+	require.Equal(t, []string{"V?\n", "I?\n", "R25\n", "D500,0,1000\n"}, port.commands())
+
+	// The restarted MCU is back at its default rate with the heaters off
+	port.emit("1234567890123,2048,1024,101,1\n")
+	port.emit("#BOOT proto=7 fw=0.2.0 board=xiao\n")
+
+	assert.Eventually(t, func() bool {
+		return slices.Equal(port.commands()[4:], []string{"V?\n", "I?\n", "R25\n", "D500,0,1000\n"})
+	}, time.Second, 10*time.Millisecond)
+}
+
+// firmwareV13 answers like protocol version 13 firmware that pauses and
+// resumes its sample output.
+func firmwareV13(cmd string) string {
+	switch cmd {
+	case "V?\n":
+		return "#INFO proto=13 channels=3 rate=50.0 avg=20 interval=1\n"
+	case "P0\n", "P1\n":
+		return "#OK paused=" + cmd[1:]
+	}
+	return firmwareV7(cmd)
+}
+
+func TestSerial_SetStreaming(t *testing.T) {
+	port := newFakePort(firmwareV13)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	require.NoError(t, dev.SetStreaming(false))
+	require.NoError(t, dev.SetStreaming(true))
+	assert.Equal(t, []string{"P1\n", "P0\n"}, port.commands()[2:])
+}
+
+func TestSerial_SetStreaming_Unsupported(t *testing.T) {
+	port := newFakePort(firmwareV9())
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.ErrorContains(t, dev.SetStreaming(false), "requires protocol")
+	assert.NotContains(t, port.commands(), "P1\n")
+}
+
+func TestSerial_PausedAfterBoot(t *testing.T) {
+	port := newFakePort(firmwareV13)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	require.NoError(t, dev.SetStreaming(false))
+
+	// Samples before the pause make the next banner a mid-session reset
+	port.emit("1234567890123,2048,1024,000,1,30000\n")
+	port.emit("#BOOT proto=13 fw=0.2.0 board=xiao\n")
+
+	// The restarted MCU streams again until it is paused once more
+	assert.Eventually(t, func() bool {
+		return slices.Equal(port.commands(), []string{"V?\n", "I?\n", "P1\n", "V?\n", "I?\n", "P1\n"})
+	}, time.Second, 10*time.Millisecond)
+}
+
+// firmwareV15 answers like protocol version 15 SAMD21 firmware with a
+// programmable absorber gain and a supply or 1V bandgap reference.
+func firmwareV15() func(cmd string) string {
+	var mu sync.Mutex
+	gain, ref := 1, 3300
+	ack := func() string { return fmt.Sprintf("gain=%d ref=%d", gain, ref) }
+	return func(cmd string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		n, err := strconv.Atoi(strings.TrimSpace(cmd[1:]))
+		switch {
+		case cmd == "V?\n":
+			return "#INFO proto=15 channels=3 rate=50.0 avg=20 interval=1 " + ack() + "\n"
+		case cmd[0] == 'G' && (err != nil || n < 1 || n > 32 || n&(n-1) != 0):
+			return "#ERR gain\n"
+		case cmd[0] == 'G':
+			gain = n
+			return "#OK " + ack() + "\n"
+		case cmd[0] == 'A' && (err != nil || (n != 3300 && n != 2000)):
+			return "#ERR ref\n"
+		case cmd[0] == 'A':
+			ref = n
+			return "#OK " + ack() + "\n"
+		}
+		return firmwareV14(cmd)
+	}
+}
+
+func TestSerial_SetADCGain(t *testing.T) {
+	port := newFakePort(firmwareV15())
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.Equal(t, 1, dev.Info().ADCGain)
+	assert.Equal(t, 3.3, dev.Info().ADCReference)
+
+	require.NoError(t, dev.SetADCGain(16))
+	assert.Contains(t, port.commands(), "G16\n")
+	assert.Equal(t, 16, dev.Info().ADCGain)
+
+	assert.ErrorContains(t, dev.SetADCGain(3), "rejected")
+	assert.Error(t, dev.SetADCGain(0))
+	assert.Equal(t, 16, dev.Info().ADCGain)
+}
+
+func TestSerial_SetADCReference(t *testing.T) {
+	port := newFakePort(firmwareV15())
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	require.NoError(t, dev.SetADCReference(2.0))
+	assert.Contains(t, port.commands(), "A2000\n")
+	assert.Equal(t, 2.0, dev.Info().ADCReference)
+
+	assert.ErrorContains(t, dev.SetADCReference(1.1), "rejected")
+	assert.Equal(t, 2.0, dev.Info().ADCReference)
+}
+
+func TestSerial_SetADCGain_Unsupported(t *testing.T) {
+	port := newFakePort(firmwareV13)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	assert.ErrorContains(t, dev.SetADCGain(4), "requires protocol")
+	assert.ErrorContains(t, dev.SetADCReference(2), "requires protocol")
+	assert.NotContains(t, port.commands(), "G4\n")
+}
+
+func TestSerial_ADCOnConnect(t *testing.T) {
+	port := newFakePort(firmwareV15())
+	dev, err := NewFromConfig(&config.SerialConfig{Port: "fake", ADCGain: 8, ADCReference: 2}, 0)
+	require.NoError(t, err)
+	dev.openPort = func(string, *serial.Mode) (serial.Port, error) { return port, nil }
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	assert.Equal(t, []string{"V?\n", "I?\n", "A2000\n", "G8\n"}, port.commands())
+	assert.Equal(t, 8, dev.Info().ADCGain)
+	assert.Equal(t, 2.0, dev.Info().ADCReference)
+}
+
+func TestSerial_Stats(t *testing.T) {
+	port := newFakePort(nil)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	lines := "1234567890123,2048,1024,101,1\n" +
+		"garbage\n" +
+		"1234567890124,2049,1024,101,4\n"
+	port.emit(lines)
+
+	require.Eventually(t, func() bool { return dev.Stats().Parsed == 2 }, time.Second, 5*time.Millisecond)
+	stats := dev.Stats()
+	assert.Equal(t, uint64(1), stats.ParseErrors)
+	assert.Equal(t, uint64(len(lines)), stats.BytesRead)
+	assert.Zero(t, stats.Dropped)
+	assert.Equal(t, uint64(2), stats.Lost)
+	assert.Zero(t, stats.Reconnects)
+}
+
+func TestSerial_ConnectContext_CancelShutsDown(t *testing.T) {
+	port := newFakePort(nil)
+	dev := New("fake", 0, 0)
+	dev.openPort = func(string, *serial.Mode) (serial.Port, error) { return port, nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, dev.ConnectContext(ctx))
+	samples := dev.Samples()
+
+	cancel()
+	select {
+	case _, ok := <-samples:
+		assert.False(t, ok, "Channel should be closed")
+	case <-time.After(time.Second):
+		t.Fatal("samples channel was not closed after cancel")
+	}
+	assert.False(t, dev.IsConnected())
+	assert.NoError(t, dev.Close(), "Close after cancel is a no-op")
+}
+
+func TestSerial_Reconnect(t *testing.T) {
+	dev := New("fake", 0, 0)
+	dev.openPort = func(string, *serial.Mode) (serial.Port, error) { return newFakePort(nil), nil }
+
+	require.NoError(t, dev.Connect())
+	first := dev.Samples()
+	require.NoError(t, dev.Close())
+	_, ok := <-first
+	assert.False(t, ok, "Channel should be closed")
+
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+	assert.True(t, dev.IsConnected())
+	assert.NotEqual(t, first, dev.Samples(), "Reconnect should use a fresh samples channel")
+	assert.Equal(t, uint64(1), dev.Stats().Reconnects)
+}
+
+func TestSerial_ReadSample(t *testing.T) {
+	port := newFakePort(nil)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+
+	// The first 100 samples are skipped
+	go func() {
+		for i := range 101 {
+			port.emit(fmt.Sprintf("1234567890123,%d,1024,101\n", i))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sample, err := dev.ReadSample(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(100), sample.Reading)
+
+	// Nothing more arrives: the context ends the wait
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	_, err = dev.ReadSample(short)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, dev.Close())
+	_, err = dev.ReadSample(ctx)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestSerial_ReadSample_NotConnected(t *testing.T) {
+	_, err := New("COM3", 0, 0).ReadSample(context.Background())
+	assert.ErrorIs(t, err, ErrNotConnected)
+}
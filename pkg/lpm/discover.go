@@ -0,0 +1,182 @@
+package lpm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+// Discovered is an LPM found by Discover.
+type Discovered struct {
+	Port     string   // Port name, usable as SerialConfig.Port
+	Info     Info     // Handshake response, zero for legacy firmware
+	Protocol Protocol // Wire protocol seen while probing, ProtocolUnknown if no data arrived
+}
+
+// String returns a short description for pickers, e.g. "COM3 (xiao, fw 0.2.0)".
+func (d Discovered) String() string {
+	switch {
+	case d.Info.Board != "" || d.Info.FirmwareVersion != "":
+		return fmt.Sprintf("%s (%s, fw %s)", d.Port, d.Info.Board, d.Info.FirmwareVersion)
+	case d.Info.ProtocolVersion > 0:
+		return fmt.Sprintf("%s (protocol v%d)", d.Port, d.Info.ProtocolVersion)
+	default:
+		return fmt.Sprintf("%s (legacy firmware)", d.Port)
+	}
+}
+
+// DiscoverOptions configures which ports Discover probes.
+type DiscoverOptions struct {
+	// AllPorts probes every serial port instead of only the USB ports of
+	// the supported boards, e.g. a head behind a USB-UART adapter. Opening
+	// a port resets some boards, such as Arduinos, so it is opt-in.
+	AllPorts bool
+}
+
+// usbID identifies a USB device by its vendor and product ID, in hex.
+type usbID struct {
+	vid, pid string
+}
+
+// supportedBoards are the USB IDs the boards the firmware runs on
+// enumerate with.
+var supportedBoards = []usbID{
+	{"2886", "802F"}, // Seeed XIAO SAMD21
+	{"2E8A", "000A"}, // Raspberry Pi Pico
+	{"303A", "1001"}, // ESP32-C3 USB serial/JTAG
+}
+
+// Hooks replaced in tests.
+var (
+	listPorts       = serial.GetPortsList
+	probePort       = serial.Open
+	listPortDetails = enumerator.GetDetailedPortsList
+)
+
+// Discover scans the USB ports of the supported boards, or every serial port
+// with opts.AllPorts, and probes each one with the handshake, using the
+// default line settings. A port is identified as an LPM if it answers the
+// handshake, or if legacy firmware streams valid samples.
+// Ports are probed concurrently; the result is sorted by port name.
+// Ports that are busy or do not speak the protocol are skipped. When ctx
+// ends first, the devices found so far are returned without waiting for the
+// other ports, with ctx's error only if none was found.
+//
+// Only serial ports are scanned; BLE heads must be addressed explicitly.
+func Discover(ctx context.Context, opts DiscoverOptions) ([]Discovered, error) {
+	var ports []string
+	var err error
+	if opts.AllPorts {
+		ports, err = listPorts()
+	} else {
+		ports, err = boardPorts()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list serial ports: %w", err)
+	}
+
+	// Probes may outlive the call when ctx ends first
+	open := probePort
+	var (
+		mu    sync.Mutex
+		found []Discovered
+		wg    sync.WaitGroup
+	)
+	for _, port := range ports {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if d, ok := probe(ctx, port, open); ok {
+				mu.Lock()
+				found = append(found, d)
+				mu.Unlock()
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// A port hanging on open must not hide the devices that answered
+	}
+
+	mu.Lock()
+	result := slices.Clone(found)
+	mu.Unlock()
+	if len(result) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Port < result[j].Port })
+	return result, nil
+}
+
+// probe opens port with open and connects to it, which performs the
+// handshake, and disconnects again.
+func probe(ctx context.Context, port string, open func(string, *serial.Mode) (serial.Port, error)) (Discovered, bool) {
+	dev := New(port, 0, 0)
+	dev.openPort = open
+	if err := dev.ConnectContext(ctx); err != nil {
+		return Discovered{}, false
+	}
+	defer dev.Close()
+
+	d := Discovered{
+		Port:     port,
+		Info:     dev.Info(),
+		Protocol: dev.Protocol(),
+	}
+	return d, d.Info.ProtocolVersion > 0 || d.Protocol != ProtocolUnknown
+}
+
+// boardPorts returns the names of the USB ports of the supported boards.
+func boardPorts() ([]string, error) {
+	details, err := listPortDetails()
+	if err != nil {
+		return nil, err
+	}
+	var ports []string
+	for _, d := range details {
+		if d.IsUSB && isSupportedBoard(d.VID, d.PID) {
+			ports = append(ports, d.Name)
+		}
+	}
+	return ports, nil
+}
+
+// isSupportedBoard reports whether the USB IDs are those of a supported
+// board. Systems differ in the case of the hex digits.
+func isSupportedBoard(vid, pid string) bool {
+	return slices.ContainsFunc(supportedBoards, func(id usbID) bool {
+		return strings.EqualFold(id.vid, vid) && strings.EqualFold(id.pid, pid)
+	})
+}
+
+// usbSerialNumber returns the serial number of the USB device behind port,
+// or "" if port is not a USB port or the system does not report one.
+func usbSerialNumber(port string) string {
+	details, err := listPortDetails()
+	if err != nil {
+		log.Printf("Failed to list USB port details: %v", err)
+		return ""
+	}
+	for _, d := range details {
+		if d.Name == port && d.IsUSB {
+			return d.SerialNumber
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package lpm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+func TestDiscover(t *testing.T) {
+	listPorts = func() ([]string, error) { return []string{"COM4", "COM3", "COM2", "COM1"}, nil }
+	probePort = func(name string, _ *serial.Mode) (serial.Port, error) {
+		switch name {
+		case "COM1":
+			return newFakePort(firmwareV3), nil
+		case "COM2":
+			// Legacy firmware: no handshake, but streams samples
+			port := newFakePort(nil)
+			go port.emit("1234567890123,2048,1024,000\n")
+			return port, nil
+		case "COM3":
+			// Some other device that stays silent
+			return newFakePort(nil), nil
+		default:
+			return nil, errors.New("port busy")
+		}
+	}
+	defer func() { listPorts, probePort = serial.GetPortsList, serial.Open }()
+
+	found, err := Discover(context.Background(), DiscoverOptions{AllPorts: true})
+	require.NoError(t, err)
+
+	require.Len(t, found, 2)
+	assert.Equal(t, "COM1", found[0].Port)
+	assert.Equal(t, 3, found[0].Info.ProtocolVersion)
+	assert.Equal(t, "COM1 (xiao, fw 0.2.0)", found[0].String())
+	assert.Equal(t, "COM2", found[1].Port)
+	assert.Equal(t, ProtocolText, found[1].Protocol)
+	assert.Equal(t, "COM2 (legacy firmware)", found[1].String())
+}
+
+func TestDiscover_Cancelled(t *testing.T) {
+	listPorts = func() ([]string, error) { return []string{"COM1"}, nil }
+	probePort = func(string, *serial.Mode) (serial.Port, error) { return newFakePort(nil), nil }
+	defer func() { listPorts, probePort = serial.GetPortsList, serial.Open }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Discover(ctx, DiscoverOptions{AllPorts: true})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDiscover_Timeout(t *testing.T) {
+	hung, released := make(chan struct{}), make(chan struct{})
+	listPorts = func() ([]string, error) { return []string{"COM1", "COM2"}, nil }
+	probePort = func(name string, _ *serial.Mode) (serial.Port, error) {
+		if name == "COM2" {
+			// A driver that never returns from opening the port
+			<-hung
+			defer close(released)
+			return nil, errors.New("port busy")
+		}
+		return newFakePort(firmwareV3), nil
+	}
+	defer func() { listPorts, probePort = serial.GetPortsList, serial.Open }()
+	defer func() {
+		close(hung)
+		<-released
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	found, err := Discover(ctx, DiscoverOptions{AllPorts: true})
+	require.NoError(t, err, "the devices found are returned when the time is up")
+	assert.Less(t, time.Since(start), 2*time.Second)
+	require.Len(t, found, 1)
+	assert.Equal(t, "COM1", found[0].Port)
+	assert.Equal(t, 3, found[0].Info.ProtocolVersion)
+}
+
+func TestDiscover_SupportedBoards(t *testing.T) {
+	listPortDetails = func() ([]*enumerator.PortDetails, error) {
+		return []*enumerator.PortDetails{
+			{Name: "/dev/ttyACM0", IsUSB: true, VID: "2341", PID: "0043"}, // Arduino Uno
+			{Name: "/dev/ttyACM1", IsUSB: true, VID: "2886", PID: "802f"},
+			{Name: "/dev/ttyACM2", IsUSB: true, VID: "2E8A", PID: "000A"},
+			{Name: "/dev/ttyS0"},
+		}, nil
+	}
+	var mu sync.Mutex
+	var probed []string
+	probePort = func(name string, _ *serial.Mode) (serial.Port, error) {
+		mu.Lock()
+		probed = append(probed, name)
+		mu.Unlock()
+		return newFakePort(firmwareV3), nil
+	}
+	defer func() { listPortDetails, probePort = enumerator.GetDetailedPortsList, serial.Open }()
+
+	found, err := Discover(context.Background(), DiscoverOptions{})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "/dev/ttyACM1", found[0].Port)
+	assert.Equal(t, "/dev/ttyACM2", found[1].Port)
+	assert.ElementsMatch(t, []string{"/dev/ttyACM1", "/dev/ttyACM2"}, probed, "other boards are not opened")
+}
@@ -0,0 +1,199 @@
+package lpm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Protocol identifies the wire format used by the MCU.
+type Protocol int
+
+const (
+	ProtocolUnknown Protocol = iota // Not yet detected (no valid data received)
+	ProtocolText                    // CSV text lines: unix_micros,reading,voltage,heaters
+	ProtocolBinary                  // Binary frames: sync, length, payload, CRC16
+)
+
+// String returns a human readable protocol name.
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolText:
+		return "text"
+	case ProtocolBinary:
+		return "binary"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// FrameSync marks the start of a binary frame. It never occurs in the
+	// ASCII text protocol, which allows auto-detection per message.
+	FrameSync = 0xA5
+
+	// framePayloadSize is the size of the sample payload:
+	// timestamp (8, unix micros) + reading (2) + voltage (2) + heater bits (1).
+	framePayloadSize = 13
+
+	// frameSequenceSize is the size of the payload with the optional
+	// sequence number (u32) appended.
+	frameSequenceSize = framePayloadSize + 4
+
+	// frameAmbientSize is the size of the payload with the optional ambient
+	// temperature reading (u16) appended after the sequence number.
+	frameAmbientSize = frameSequenceSize + 2
+
+	// frameReading2Size is the size of the payload with the optional second
+	// absorber reading (u16) appended after the ambient reading.
+	frameReading2Size = frameAmbientSize + 2
+
+	// frameOverhead is sync (1) + length (1) + CRC16 (2).
+	frameOverhead = 4
+
+	// MaxHeaters is the most heaters a device can have: binary frames carry
+	// the heater states in one byte.
+	MaxHeaters = 8
+)
+
+var (
+	// ErrFrameCRC is returned when a binary frame fails the CRC check.
+	ErrFrameCRC = errors.New("frame CRC mismatch")
+	// ErrFrameShort is returned when the buffer does not hold a complete frame.
+	ErrFrameShort = errors.New("incomplete frame")
+)
+
+// Binary frame layout (little-endian):
+//
+//	[0]      FrameSync (0xA5)
+//	[1]      payload length N
+//	[2..2+N) payload: unix_micros u64, reading u16, voltage u16, heaters u8 (bit0 = heater1),
+//	         optionally followed by sequence u32, ambient u16 and then reading2 u16
+//	[2+N..]  CRC16-CCITT over length byte and payload
+//
+// Payloads longer than framePayloadSize are accepted; unknown trailing fields are ignored
+// so the firmware can append fields without breaking older hosts.
+
+// encodeFrame encodes a RawSample into a binary frame.
+// The sequence number, ambient and second absorber readings are only
+// included when set.
+func encodeFrame(s RawSample) []byte {
+	n := framePayloadSize
+	switch {
+	case s.HasReading2:
+		n = frameReading2Size
+	case s.Ambient != 0:
+		n = frameAmbientSize
+	case s.Sequence != 0:
+		n = frameSequenceSize
+	}
+
+	buf := make([]byte, frameOverhead+n)
+	buf[0] = FrameSync
+	buf[1] = byte(n)
+
+	payload := buf[2 : 2+n]
+	binary.LittleEndian.PutUint64(payload[0:8], uint64(s.Timestamp.UnixMicro()))
+	binary.LittleEndian.PutUint16(payload[8:10], s.Reading)
+	binary.LittleEndian.PutUint16(payload[10:12], s.Voltage)
+	payload[12] = heaterBits(s.Heaters)
+	if n >= frameSequenceSize {
+		binary.LittleEndian.PutUint32(payload[13:17], s.Sequence)
+	}
+	if n >= frameAmbientSize {
+		binary.LittleEndian.PutUint16(payload[17:19], s.Ambient)
+	}
+	if n >= frameReading2Size {
+		binary.LittleEndian.PutUint16(payload[19:21], s.Reading2)
+	}
+
+	crc := crc16(buf[1 : 2+n])
+	binary.LittleEndian.PutUint16(buf[2+n:], crc)
+
+	return buf
+}
+
+// decodeFrame decodes a binary frame at the start of buf from a device with
+// the given number of heaters. Returns the sample and the number of bytes the
+// frame occupies. Returns ErrFrameShort if buf does not yet contain the full
+// frame.
+func decodeFrame(buf []byte, heaters int) (RawSample, int, error) {
+	if len(buf) < 2 {
+		return RawSample{}, 0, ErrFrameShort
+	}
+	if buf[0] != FrameSync {
+		return RawSample{}, 0, fmt.Errorf("invalid frame sync byte: 0x%02X", buf[0])
+	}
+
+	n := int(buf[1])
+	size := frameOverhead + n
+	if n < framePayloadSize {
+		return RawSample{}, 0, fmt.Errorf("invalid frame length: %d (min %d)", n, framePayloadSize)
+	}
+	if len(buf) < size {
+		return RawSample{}, 0, ErrFrameShort
+	}
+
+	want := binary.LittleEndian.Uint16(buf[2+n : size])
+	if got := crc16(buf[1 : 2+n]); got != want {
+		return RawSample{}, 0, ErrFrameCRC
+	}
+
+	payload := buf[2 : 2+n]
+	sample := RawSample{
+		Timestamp: time.UnixMicro(int64(binary.LittleEndian.Uint64(payload[0:8]))),
+		Reading:   binary.LittleEndian.Uint16(payload[8:10]),
+		Voltage:   binary.LittleEndian.Uint16(payload[10:12]),
+		Heaters:   heaterStates(payload[12], heaters),
+	}
+	if n >= frameSequenceSize {
+		sample.Sequence = binary.LittleEndian.Uint32(payload[13:17])
+	}
+	if n >= frameAmbientSize {
+		sample.Ambient = binary.LittleEndian.Uint16(payload[17:19])
+	}
+	if n >= frameReading2Size {
+		sample.Reading2 = binary.LittleEndian.Uint16(payload[19:21])
+		sample.HasReading2 = true
+	}
+	return sample, size, nil
+}
+
+// heaterBits packs heater states into a bit field (bit0 = heater1). States
+// beyond MaxHeaters are dropped.
+func heaterBits(states []bool) uint8 {
+	var bits uint8
+	for i, on := range states[:min(len(states), MaxHeaters)] {
+		if on {
+			bits |= 1 << i
+		}
+	}
+	return bits
+}
+
+// heaterStates unpacks the states of n heaters from a bit field (bit0 =
+// heater1).
+func heaterStates(bits uint8, n int) []bool {
+	states := make([]bool, min(n, MaxHeaters))
+	for i := range states {
+		states[i] = bits&(1<<i) != 0
+	}
+	return states
+}
+
+// crc16 computes CRC16-CCITT (poly 0x1021, init 0xFFFF) over data.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,110 @@
+package lpm
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC16(t *testing.T) {
+	// CRC16-CCITT (0xFFFF) check value for "123456789"
+	assert.Equal(t, uint16(0x29B1), crc16([]byte("123456789")))
+}
+
+func TestFrame_RoundTrip(t *testing.T) {
+	in := RawSample{
+		Timestamp: time.UnixMicro(1234567890123),
+		Reading:   65535,
+		Voltage:   1024,
+		Heaters:   []bool{false, true, true},
+	}
+
+	frame := encodeFrame(in)
+	assert.Len(t, frame, frameOverhead+framePayloadSize)
+
+	out, n, err := decodeFrame(frame, DefaultHeaters)
+	require.NoError(t, err)
+	assert.Equal(t, len(frame), n)
+	assert.Equal(t, in.Timestamp.UnixNano(), out.Timestamp.UnixNano())
+	assert.Equal(t, in.Reading, out.Reading)
+	assert.Equal(t, in.Voltage, out.Voltage)
+	assert.Equal(t, in.Heaters, out.Heaters)
+}
+
+func TestFrame_HeaterCount(t *testing.T) {
+	in := RawSample{Timestamp: time.UnixMicro(42), Heaters: []bool{true, false, false, false, true}}
+
+	out, _, err := decodeFrame(encodeFrame(in), 5)
+	require.NoError(t, err)
+	assert.Equal(t, in.Heaters, out.Heaters)
+
+	out, _, err = decodeFrame(encodeFrame(in), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, out.Heaters)
+
+	all := make([]bool, MaxHeaters)
+	for i := range all {
+		all[i] = true
+	}
+	assert.Equal(t, uint8(0xFF), heaterBits(all))
+	assert.Equal(t, all, heaterStates(0xFF, MaxHeaters))
+}
+
+func TestFrame_RoundTripSequence(t *testing.T) {
+	in := RawSample{Timestamp: time.UnixMicro(42), Reading: 7, Sequence: 0xDEADBEEF}
+
+	frame := encodeFrame(in)
+	assert.Len(t, frame, frameOverhead+frameSequenceSize)
+
+	out, n, err := decodeFrame(frame, DefaultHeaters)
+	require.NoError(t, err)
+	assert.Equal(t, len(frame), n)
+	assert.Equal(t, in.Reading, out.Reading)
+	assert.Equal(t, in.Sequence, out.Sequence)
+}
+
+func TestFrame_RoundTripAmbient(t *testing.T) {
+	in := RawSample{Timestamp: time.UnixMicro(42), Reading: 7, Sequence: 9, Ambient: 30000}
+
+	frame := encodeFrame(in)
+	assert.Len(t, frame, frameOverhead+frameAmbientSize)
+
+	out, n, err := decodeFrame(frame, DefaultHeaters)
+	require.NoError(t, err)
+	assert.Equal(t, len(frame), n)
+	assert.Equal(t, in.Sequence, out.Sequence)
+	assert.Equal(t, in.Ambient, out.Ambient)
+}
+
+func TestFrame_RoundTripReading2(t *testing.T) {
+	// A zero second reading is still a reading
+	in := RawSample{Timestamp: time.UnixMicro(42), Reading: 7, Sequence: 9, HasReading2: true, Heaters: []bool{false, false, false}}
+
+	frame := encodeFrame(in)
+	assert.Len(t, frame, frameOverhead+frameReading2Size)
+
+	out, n, err := decodeFrame(frame, DefaultHeaters)
+	require.NoError(t, err)
+	assert.Equal(t, len(frame), n)
+	assert.Equal(t, in, out)
+}
+
+func TestDecodeFrame_Errors(t *testing.T) {
+	frame := encodeFrame(RawSample{Timestamp: time.UnixMicro(1), Reading: 1, Voltage: 2})
+
+	_, _, err := decodeFrame(frame[:5], DefaultHeaters)
+	assert.ErrorIs(t, err, ErrFrameShort)
+
+	corrupted := bytes.Clone(frame)
+	corrupted[5] ^= 0xFF
+	_, _, err = decodeFrame(corrupted, DefaultHeaters)
+	assert.ErrorIs(t, err, ErrFrameCRC)
+
+	badLength := bytes.Clone(frame)
+	badLength[1] = 3
+	_, _, err = decodeFrame(badLength, DefaultHeaters)
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package lpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+)
+
+// HeaterPhase is a phase of a running heater schedule.
+type HeaterPhase struct {
+	Step   int           // Index of the step
+	Run    int           // Run of the step, from 1 to its Repeat
+	On     bool          // The step's heater is on, else resting
+	Length time.Duration // Length of the phase
+}
+
+// ValidateHeaterSchedule checks the steps of a heater schedule for a device
+// with the given number of heaters.
+func ValidateHeaterSchedule(steps []config.HeaterStep, heaters int) error {
+	if len(steps) == 0 {
+		return errors.New("heater schedule has no steps")
+	}
+	for i, s := range steps {
+		switch {
+		case s.Heater < 1 || s.Heater > heaters:
+			return fmt.Errorf("step %d: invalid heater %d", i+1, s.Heater)
+		case s.Duty <= 0 || s.Duty > 1:
+			return fmt.Errorf("step %d: invalid duty %v", i+1, s.Duty)
+		case s.Duration <= 0:
+			return fmt.Errorf("step %d: invalid duration %v", i+1, s.Duration)
+		case s.Rest < 0:
+			return fmt.Errorf("step %d: invalid rest %v", i+1, s.Rest)
+		case s.Repeat < 1:
+			return fmt.Errorf("step %d: invalid repeat count %d", i+1, s.Repeat)
+		}
+	}
+	return nil
+}
+
+// HeaterScheduleDuration returns how long a heater schedule runs.
+func HeaterScheduleDuration(steps []config.HeaterStep) time.Duration {
+	var total time.Duration
+	for _, s := range steps {
+		total += time.Duration(s.Repeat) * (s.Duration + s.Rest)
+	}
+	return total
+}
+
+// RunHeaterSchedule drives the heaters of d through steps, calling phase at
+// the start of every phase. It returns when the schedule is done, ctx is
+// cancelled or a heater command fails, always leaving the heaters off.
+// Firmware without duty control only runs steps with a duty of 1.
+func RunHeaterSchedule(ctx context.Context, d Device, steps []config.HeaterStep, phase func(HeaterPhase)) (err error) {
+	heaters := d.Info().HeaterCount()
+	if err := ValidateHeaterSchedule(steps, heaters); err != nil {
+		return err
+	}
+	off := make([]float64, heaters)
+	defer func() {
+		if offErr := d.SetHeaterDuty(off...); offErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to turn heaters off: %w", offErr))
+		}
+	}()
+
+	for i, s := range steps {
+		for run := 1; run <= s.Repeat; run++ {
+			duties := make([]float64, heaters)
+			duties[s.Heater-1] = s.Duty
+			if err := d.SetHeaterDuty(duties...); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+			phase(HeaterPhase{Step: i, Run: run, On: true, Length: s.Duration})
+			if err := sleepContext(ctx, s.Duration); err != nil {
+				return err
+			}
+
+			if s.Rest <= 0 {
+				continue
+			}
+			if err := d.SetHeaterDuty(off...); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+			phase(HeaterPhase{Step: i, Run: run, Length: s.Rest})
+			if err := sleepContext(ctx, s.Rest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sleepContext waits for d or until ctx is done, returning ctx's error then.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,99 @@
+package lpm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dutyDevice records heater duty commands; other Device methods are not used.
+type dutyDevice struct {
+	Device
+	heaters int // Reported by Info, 0 for the default
+	duties  [][]float64
+	fail    error
+}
+
+func (d *dutyDevice) Info() Info {
+	return Info{Heaters: d.heaters}
+}
+
+func (d *dutyDevice) SetHeaterDuty(duties ...float64) error {
+	if d.fail != nil {
+		return d.fail
+	}
+	d.duties = append(d.duties, duties)
+	return nil
+}
+
+func TestValidateHeaterSchedule(t *testing.T) {
+	valid := config.HeaterStep{Heater: 2, Duty: 0.5, Duration: time.Second, Repeat: 1}
+	assert.NoError(t, ValidateHeaterSchedule([]config.HeaterStep{valid}, 3))
+	assert.Error(t, ValidateHeaterSchedule(nil, 3))
+	assert.Error(t, ValidateHeaterSchedule([]config.HeaterStep{valid}, 1), "heater 2 of one")
+
+	for _, step := range []config.HeaterStep{
+		{Heater: 4, Duty: 0.5, Duration: time.Second, Repeat: 1},
+		{Heater: 1, Duty: 0, Duration: time.Second, Repeat: 1},
+		{Heater: 1, Duty: 1.5, Duration: time.Second, Repeat: 1},
+		{Heater: 1, Duty: 1, Repeat: 1},
+		{Heater: 1, Duty: 1, Duration: time.Second, Rest: -time.Second, Repeat: 1},
+		{Heater: 1, Duty: 1, Duration: time.Second},
+	} {
+		assert.Error(t, ValidateHeaterSchedule([]config.HeaterStep{valid, step}, 3), "%+v", step)
+	}
+
+	assert.Equal(t, 7*time.Second, HeaterScheduleDuration([]config.HeaterStep{
+		{Duration: time.Second, Rest: 2 * time.Second, Repeat: 2},
+		{Duration: time.Second, Repeat: 1},
+	}))
+}
+
+func TestRunHeaterSchedule(t *testing.T) {
+	d := &dutyDevice{}
+	steps := []config.HeaterStep{
+		{Heater: 1, Duty: 1, Duration: time.Millisecond, Rest: time.Millisecond, Repeat: 2},
+		{Heater: 3, Duty: 0.25, Duration: time.Millisecond, Repeat: 1},
+	}
+	var phases []HeaterPhase
+	require.NoError(t, RunHeaterSchedule(context.Background(), d, steps, func(p HeaterPhase) { phases = append(phases, p) }))
+
+	assert.Equal(t, [][]float64{{1, 0, 0}, {0, 0, 0}, {1, 0, 0}, {0, 0, 0}, {0, 0, 0.25}, {0, 0, 0}}, d.duties, "off at the end")
+	assert.Equal(t, []HeaterPhase{
+		{Step: 0, Run: 1, On: true, Length: time.Millisecond},
+		{Step: 0, Run: 1, Length: time.Millisecond},
+		{Step: 0, Run: 2, On: true, Length: time.Millisecond},
+		{Step: 0, Run: 2, Length: time.Millisecond},
+		{Step: 1, Run: 1, On: true, Length: time.Millisecond},
+	}, phases)
+}
+
+func TestRunHeaterSchedule_HeaterCount(t *testing.T) {
+	d := &dutyDevice{heaters: 5}
+	steps := []config.HeaterStep{{Heater: 5, Duty: 0.5, Duration: time.Millisecond, Repeat: 1}}
+	require.NoError(t, RunHeaterSchedule(context.Background(), d, steps, func(HeaterPhase) {}))
+	assert.Equal(t, [][]float64{{0, 0, 0, 0, 0.5}, {0, 0, 0, 0, 0}}, d.duties)
+
+	d = &dutyDevice{}
+	assert.ErrorContains(t, RunHeaterSchedule(context.Background(), d, steps, func(HeaterPhase) {}), "invalid heater 5")
+	assert.Empty(t, d.duties)
+}
+
+func TestRunHeaterSchedule_Cancel(t *testing.T) {
+	d := &dutyDevice{}
+	ctx, cancel := context.WithCancel(context.Background())
+	steps := []config.HeaterStep{{Heater: 2, Duty: 1, Duration: time.Hour, Repeat: 1}}
+	err := RunHeaterSchedule(ctx, d, steps, func(HeaterPhase) { cancel() })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, [][]float64{{0, 1, 0}, {0, 0, 0}}, d.duties, "heaters off when cancelled")
+
+	d.fail = errors.New("no ack")
+	err = RunHeaterSchedule(context.Background(), d, steps, func(HeaterPhase) {})
+	assert.ErrorContains(t, err, "step 1: no ack")
+	assert.ErrorContains(t, err, "failed to turn heaters off")
+}
@@ -0,0 +1,227 @@
+package lpm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// responsePrefix marks lines that are command responses rather than samples.
+	responsePrefix = "#"
+	// infoPrefix marks handshake responses to the version and identity queries.
+	infoPrefix = "#INFO"
+	// ackPrefix marks a command acknowledgement, followed by the applied state.
+	ackPrefix = "#OK"
+	// nakPrefix marks a rejected command, followed by the reason.
+	nakPrefix = "#ERR"
+	// bootPrefix marks the line the MCU sends when it starts, e.g. after a
+	// watchdog reset. It is not a reply to any command.
+	bootPrefix = "#BOOT"
+	// selfTestPrefix marks the self-test report.
+	selfTestPrefix = "#TEST"
+	// calibrationPrefix marks the reply listing the calibration points stored on the MCU.
+	calibrationPrefix = "#CAL"
+	// versionQuery is sent on connect to request the handshake response.
+	versionQuery = "V?\n"
+	// identityQuery is sent on connect to request firmware version, board and uptime.
+	identityQuery = "I?\n"
+
+	// rateCommand sets the MCU output sample rate in whole Hz.
+	rateCommand = "R%d\n"
+	// averageCommand sets the number of ADC readings the MCU averages per sample.
+	averageCommand = "N%d\n"
+	// intervalCommand sets the MCU's ADC read interval in whole milliseconds.
+	intervalCommand = "T%d\n"
+	// gainCommand sets the gain of the absorber ADC channel, relative to the default input range.
+	gainCommand = "G%d\n"
+	// referenceCommand sets the ADC input range in millivolts, which selects the ADC reference.
+	referenceCommand = "A%d\n"
+	// dutyCommand sets the heater PWM duty cycles in per mille, one
+	// comma-separated value per heater.
+	dutyCommand = "D%s\n"
+	// maxDuty is the dutyCommand value for a fully on heater.
+	maxDuty = 1000
+	// binaryCommand switches the MCU output between text lines (0) and binary frames (1).
+	binaryCommand = "B%d\n"
+	// pauseCommand pauses (1) or resumes (0) the MCU's sample output.
+	pauseCommand = "P%d\n"
+	// calibrationQuery requests the calibration points stored on the MCU.
+	calibrationQuery = "K?\n"
+	// calibrationClear removes all calibration points from the MCU.
+	calibrationClear = "K-\n"
+	// calibrationCommand sets calibration point i to a slope and power, scaled
+	// by calibrationSlopeScale and calibrationPowerScale.
+	calibrationCommand = "K%d,%d,%d\n"
+	// calibrationSlopeScale converts calibration slopes to the MCU's signed
+	// 32-bit integers, covering ±2.147.
+	calibrationSlopeScale = 1e9
+	// calibrationPowerScale converts calibration powers to the MCU's signed
+	// 32-bit integers in µW, covering ±2147 W.
+	calibrationPowerScale = 1e6
+	// maxCalibrationPoints is how many calibration points the MCU stores.
+	maxCalibrationPoints = 8
+	// selfTestCommand runs the MCU self-test.
+	selfTestCommand = "X\n"
+	// saveCommand saves the averaging, ADC interval and calibration points to the MCU's flash.
+	saveCommand = "S\n"
+
+	// ackProtocolVersion is the first protocol version that acknowledges commands.
+	ackProtocolVersion = 2
+	// rateProtocolVersion is the first protocol version that accepts rateCommand.
+	rateProtocolVersion = 3
+	// binaryProtocolVersion is the first protocol version that accepts binaryCommand.
+	binaryProtocolVersion = 5
+	// averageProtocolVersion is the first protocol version that accepts averageCommand.
+	averageProtocolVersion = 6
+	// dutyProtocolVersion is the first protocol version that accepts dutyCommand.
+	dutyProtocolVersion = 7
+	// intervalProtocolVersion is the first protocol version that accepts intervalCommand.
+	intervalProtocolVersion = 9
+	// settingsProtocolVersion is the first protocol version that stores calibration points and saves settings.
+	settingsProtocolVersion = 11
+	// pauseProtocolVersion is the first protocol version that accepts pauseCommand.
+	pauseProtocolVersion = 13
+	// selfTestProtocolVersion is the first protocol version that accepts selfTestCommand.
+	selfTestProtocolVersion = 14
+	// adcProtocolVersion is the first protocol version that accepts gainCommand and referenceCommand.
+	adcProtocolVersion = 15
+	// heaterCountProtocolVersion is the first protocol version that reports its number of heaters.
+	heaterCountProtocolVersion = 17
+	// calibrationProtocolVersion is the first protocol version that stores signed calibration points with powers in µW.
+	calibrationProtocolVersion = 19
+
+	// DefaultHeaters is the number of heaters of firmware that does not
+	// report one.
+	DefaultHeaters = 3
+)
+
+// Info describes the connected device's capabilities as reported by the firmware.
+type Info struct {
+	ProtocolVersion int           // Wire protocol version (0 if the firmware did not answer)
+	Channels        int           // Number of ADC channels reported per sample
+	SampleRate      float64       // Output sample rate in Hz
+	Averaging       int           // ADC readings averaged per sample (0 if not reported)
+	ADCInterval     time.Duration // Time between ADC readings (0 if not reported)
+	ADCGain         int           // Absorber channel gain relative to the default input range (0 if not reported)
+	ADCReference    float64       // ADC input range in V (0 if not reported)
+	Heaters         int           // Number of heaters (0 if not reported, see HeaterCount)
+
+	FirmwareVersion string        // Firmware version string (empty if unknown)
+	Board           string        // Board name the firmware was built for
+	Serial          string        // Serial number telling heads apart, from the firmware or the USB port (empty if unknown)
+	Uptime          time.Duration // Time since the MCU booted
+}
+
+// HeaterCount returns the number of heaters of the device: as reported, or
+// DefaultHeaters for firmware that does not report it.
+func (i Info) HeaterCount() int {
+	if i.Heaters > 0 {
+		return i.Heaters
+	}
+	return DefaultHeaters
+}
+
+// String formats the info as a "#INFO" line, the same format the firmware reports.
+// Used as a metadata header in recordings; empty fields are omitted.
+func (i Info) String() string {
+	var b strings.Builder
+	b.WriteString(infoPrefix)
+	if i.ProtocolVersion > 0 {
+		fmt.Fprintf(&b, " proto=%d", i.ProtocolVersion)
+	}
+	if i.Channels > 0 {
+		fmt.Fprintf(&b, " channels=%d", i.Channels)
+	}
+	if i.SampleRate > 0 {
+		fmt.Fprintf(&b, " rate=%s", strconv.FormatFloat(i.SampleRate, 'f', -1, 64))
+	}
+	if i.Averaging > 0 {
+		fmt.Fprintf(&b, " avg=%d", i.Averaging)
+	}
+	if i.ADCInterval > 0 {
+		fmt.Fprintf(&b, " interval=%d", i.ADCInterval.Milliseconds())
+	}
+	if i.ADCGain > 0 {
+		fmt.Fprintf(&b, " gain=%d", i.ADCGain)
+	}
+	if i.ADCReference > 0 {
+		fmt.Fprintf(&b, " ref=%d", int(math.Round(i.ADCReference*1000)))
+	}
+	if i.Heaters > 0 {
+		fmt.Fprintf(&b, " heaters=%d", i.Heaters)
+	}
+	if i.FirmwareVersion != "" {
+		fmt.Fprintf(&b, " fw=%s", i.FirmwareVersion)
+	}
+	if i.Board != "" {
+		fmt.Fprintf(&b, " board=%s", i.Board)
+	}
+	if i.Serial != "" {
+		fmt.Fprintf(&b, " serial=%s", i.Serial)
+	}
+	if i.Uptime > 0 {
+		fmt.Fprintf(&b, " uptime=%d", i.Uptime.Milliseconds())
+	}
+	return b.String()
+}
+
+// parseInfo parses a handshake response line into info, leaving fields that
+// are not present in the line untouched, so several responses can be merged.
+// Formats: "#INFO proto=1 channels=2 rate=50 avg=20 interval=1 gain=1 ref=3300 heaters=3" and
+// "#INFO fw=0.2.0 board=xiao serial=e6614c311b4b2a2f uptime=1234" (interval and uptime in milliseconds, ref in millivolts).
+// Unknown keys are ignored so newer firmware can report additional fields.
+func parseInfo(line string, info *Info) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != infoPrefix {
+		return fmt.Errorf("not an info response: %q", line)
+	}
+
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "proto":
+			info.ProtocolVersion, err = strconv.Atoi(value)
+		case "channels":
+			info.Channels, err = strconv.Atoi(value)
+		case "rate":
+			info.SampleRate, err = strconv.ParseFloat(value, 64)
+		case "avg":
+			info.Averaging, err = strconv.Atoi(value)
+		case "interval":
+			var ms int64
+			ms, err = strconv.ParseInt(value, 10, 64)
+			info.ADCInterval = time.Duration(ms) * time.Millisecond
+		case "gain":
+			info.ADCGain, err = strconv.Atoi(value)
+		case "ref":
+			var mv int
+			mv, err = strconv.Atoi(value)
+			info.ADCReference = float64(mv) / 1000
+		case "heaters":
+			info.Heaters, err = strconv.Atoi(value)
+		case "fw":
+			info.FirmwareVersion = value
+		case "board":
+			info.Board = value
+		case "serial":
+			info.Serial = value
+		case "uptime":
+			var ms int64
+			ms, err = strconv.ParseInt(value, 10, 64)
+			info.Uptime = time.Duration(ms) * time.Millisecond
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q: %w", key, value, err)
+		}
+	}
+
+	return nil
+}
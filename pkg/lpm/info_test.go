@@ -0,0 +1,106 @@
+package lpm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    Info
+		wantErr bool
+	}{
+		{
+			name: "full response",
+			line: "#INFO proto=1 channels=2 rate=50",
+			want: Info{ProtocolVersion: 1, Channels: 2, SampleRate: 50},
+		},
+		{
+			name: "unknown keys ignored",
+			line: "#INFO proto=2 future=yes channels=3 rate=12.5",
+			want: Info{ProtocolVersion: 2, Channels: 3, SampleRate: 12.5},
+		},
+		{
+			name: "averaging",
+			line: "#INFO proto=9 channels=2 rate=50.0 avg=20 interval=1",
+			want: Info{ProtocolVersion: 9, Channels: 2, SampleRate: 50, Averaging: 20, ADCInterval: time.Millisecond},
+		},
+		{
+			name: "ADC settings",
+			line: "#INFO proto=15 channels=3 rate=50.0 avg=20 interval=1 gain=16 ref=2000",
+			want: Info{ProtocolVersion: 15, Channels: 3, SampleRate: 50, Averaging: 20, ADCInterval: time.Millisecond, ADCGain: 16, ADCReference: 2},
+		},
+		{
+			name: "heater count",
+			line: "#INFO proto=17 channels=2 rate=50.0 heaters=5",
+			want: Info{ProtocolVersion: 17, Channels: 2, SampleRate: 50, Heaters: 5},
+		},
+		{
+			name: "identity response",
+			line: "#INFO fw=0.2.0 board=xiao uptime=1500",
+			want: Info{FirmwareVersion: "0.2.0", Board: "xiao", Uptime: 1500 * time.Millisecond},
+		},
+		{
+			name: "serial number",
+			line: "#INFO fw=0.3.0 board=pico serial=e6614c311b4b2a2f uptime=10",
+			want: Info{FirmwareVersion: "0.3.0", Board: "pico", Serial: "e6614c311b4b2a2f", Uptime: 10 * time.Millisecond},
+		},
+		{
+			name:    "invalid number",
+			line:    "#INFO proto=x",
+			wantErr: true,
+		},
+		{
+			name:    "not an info line",
+			line:    "#OK",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Info
+			err := parseInfo(tt.line, &got)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseInfo_MergesResponses(t *testing.T) {
+	var info Info
+	require.NoError(t, parseInfo("#INFO proto=1 channels=2 rate=50", &info))
+	require.NoError(t, parseInfo("#INFO fw=0.2.0 board=pico uptime=10", &info))
+
+	assert.Equal(t, Info{
+		ProtocolVersion: 1,
+		Channels:        2,
+		SampleRate:      50,
+		FirmwareVersion: "0.2.0",
+		Board:           "pico",
+		Uptime:          10 * time.Millisecond,
+	}, info)
+}
+
+func TestInfo_String_RoundTrip(t *testing.T) {
+	in := Info{ProtocolVersion: 1, Channels: 2, SampleRate: 12.5, Averaging: 40, ADCInterval: 2 * time.Millisecond, ADCGain: 4, ADCReference: 3.3, Heaters: 2, FirmwareVersion: "0.2.0", Board: "xiao", Serial: "A1B2", Uptime: 3 * time.Second}
+	assert.Equal(t, "#INFO proto=1 channels=2 rate=12.5 avg=40 interval=2 gain=4 ref=3300 heaters=2 fw=0.2.0 board=xiao serial=A1B2 uptime=3000", in.String())
+
+	var out Info
+	require.NoError(t, parseInfo(in.String(), &out))
+	assert.Equal(t, in, out)
+}
+
+func TestInfo_HeaterCount(t *testing.T) {
+	assert.Equal(t, DefaultHeaters, Info{ProtocolVersion: 16}.HeaterCount())
+	assert.Equal(t, 5, Info{ProtocolVersion: 17, Heaters: 5}.HeaterCount())
+}
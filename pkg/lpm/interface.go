@@ -1,12 +1,48 @@
 package lpm
 
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotConnected is returned by ReadSample before the device was ever connected.
+var ErrNotConnected = errors.New("not connected")
+
+// ErrHeaterCount is returned by SetHeaters and SetHeaterDuty when given a
+// state or duty for other than each heater of the device.
+var ErrHeaterCount = errors.New("wrong number of heaters")
+
 // Device defines the interface for LPM devices (real or mocked).
+//
+// A connection lives until its context is cancelled or Close is called, after
+// which the Samples channel is closed. Connect is ConnectContext with a
+// background context.
+//
+// Samples can be consumed either from the Samples channel or one at a time
+// with ReadSample, which returns io.EOF once the connection has shut down and
+// all buffered samples are read. Use one or the other, not both.
 type Device interface {
 	Connect() error
+	ConnectContext(ctx context.Context) error
 	Close() error
 	Samples() <-chan RawSample
-	SetHeaters(heater1, heater2, heater3 bool) error
+	ReadSample(ctx context.Context) (RawSample, error)
+	SetHeaters(states ...bool) error       // One state per heater, see Info.HeaterCount
+	SetHeaterDuty(duties ...float64) error // One duty per heater, from 0 to 1
+	SetSampleRate(hz float64) error
+	SetStreaming(enabled bool) error
 	IsConnected() bool
+	Info() Info
+	Stats() Stats
+}
+
+// RawSource is implemented by devices that can pass on the bytes received
+// from their link, before decoding.
+type RawSource interface {
+	// OnRaw sets the handler for the bytes received; see Decoder.OnRaw.
+	// Returns false if the device has no link whose bytes it can pass on.
+	OnRaw(fn func(data []byte)) bool
 }
 
 // Ensure Device implements DeviceInterface.
@@ -14,3 +50,17 @@ var _ Device = (*Serial)(nil)
 
 // Ensure MockedDevice implements DeviceInterface.
 var _ Device = (*Mock)(nil)
+
+// readSample receives the next sample from samples, blocking until one
+// arrives, the channel is closed (io.EOF) or ctx is done.
+func readSample(ctx context.Context, samples <-chan RawSample) (RawSample, error) {
+	select {
+	case sample, ok := <-samples:
+		if !ok {
+			return RawSample{}, io.EOF
+		}
+		return sample, nil
+	case <-ctx.Done():
+		return RawSample{}, ctx.Err()
+	}
+}
@@ -0,0 +1,271 @@
+package lpm
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// JitterOptions configures the impairments a Jitter device applies.
+type JitterOptions struct {
+	Delay         time.Duration // Fixed delay added to every sample
+	Jitter        time.Duration // Maximum random deviation from Delay, in both directions
+	DropChance    float64       // Probability (0-1) that a sample is dropped
+	DuplicateRate float64       // Probability (0-1) that a sample is delivered twice
+	Seed          int64         // Random seed, so impaired runs are reproducible
+}
+
+// jittered is a sample waiting for its release time.
+type jittered struct {
+	sample RawSample
+	at     time.Time
+}
+
+// Jitter wraps a Device and impairs its sample stream: samples are delayed
+// and jittered, and occasionally dropped or duplicated. It exercises the
+// pipeline's robustness in tests and demos without touching hardware.
+// Sample order is preserved; a sample is never released before the previous one.
+// Samples wait in an unbounded queue, so any delay at any rate is held
+// without backing up into the inner device.
+// Dropped samples are reported in Stats as Lost, like samples lost on the link.
+type Jitter struct {
+	inner Device
+	opts  JitterOptions
+	rng   *rand.Rand // Only used by the forward goroutine
+
+	samples chan RawSample
+	wake    chan struct{} // Signals the release goroutine that the queue's head or state changed
+	done    chan struct{}
+	lost    atomic.Uint64
+	dropped atomic.Uint64
+
+	mu      sync.Mutex
+	queue   []jittered // Samples waiting for release, in release order
+	ended   bool       // The inner stream has ended; no more samples are queued
+	stopped bool       // Closed: the queued samples are released without waiting
+}
+
+// Ensure Jitter implements Device.
+var _ Device = (*Jitter)(nil)
+
+// NewJitter creates a new Jitter around inner.
+func NewJitter(inner Device, opts JitterOptions) *Jitter {
+	if opts.Jitter > opts.Delay {
+		// Negative delays are not possible; clamp to keep the jitter symmetric
+		opts.Jitter = opts.Delay
+	}
+
+	return &Jitter{
+		inner:   inner,
+		opts:    opts,
+		rng:     rand.New(rand.NewSource(opts.Seed)),
+		samples: make(chan RawSample, DefaultBufferSize),
+	}
+}
+
+// Connect connects the inner device without a deadline.
+func (j *Jitter) Connect() error {
+	return j.ConnectContext(context.Background())
+}
+
+// ConnectContext connects the inner device with ctx and starts impairing the stream.
+// Cancelling ctx shuts down the inner device, which ends the stream.
+func (j *Jitter) ConnectContext(ctx context.Context) error {
+	if err := j.inner.ConnectContext(ctx); err != nil {
+		return err
+	}
+
+	// The previous connection closed the samples channel
+	if j.done != nil {
+		j.samples = make(chan RawSample, DefaultBufferSize)
+	}
+	j.mu.Lock()
+	j.queue, j.ended, j.stopped = nil, false, false
+	j.mu.Unlock()
+	j.wake = make(chan struct{}, 1)
+	j.done = make(chan struct{})
+	go j.forward()
+	go j.release()
+
+	return nil
+}
+
+// Close closes the inner device and delivers the delayed samples without
+// waiting for their release times.
+func (j *Jitter) Close() error {
+	err := j.inner.Close()
+	if j.done != nil {
+		j.mu.Lock()
+		j.stopped = true
+		j.mu.Unlock()
+		j.signal()
+		<-j.done
+	}
+	return err
+}
+
+// Samples returns the channel for reading samples.
+func (j *Jitter) Samples() <-chan RawSample {
+	return j.samples
+}
+
+// ReadSample returns the next sample. Returns io.EOF once the stream has ended
+// and ErrNotConnected if the device was never connected.
+func (j *Jitter) ReadSample(ctx context.Context) (RawSample, error) {
+	if j.done == nil {
+		return RawSample{}, ErrNotConnected
+	}
+	return readSample(ctx, j.samples)
+}
+
+// SetHeaters forwards the heater command to the inner device.
+func (j *Jitter) SetHeaters(states ...bool) error {
+	return j.inner.SetHeaters(states...)
+}
+
+// SetHeaterDuty forwards the heater duties to the inner device.
+func (j *Jitter) SetHeaterDuty(duties ...float64) error {
+	return j.inner.SetHeaterDuty(duties...)
+}
+
+// SetSampleRate forwards the sample rate to the inner device.
+func (j *Jitter) SetSampleRate(hz float64) error {
+	return j.inner.SetSampleRate(hz)
+}
+
+// SetStreaming forwards pausing or resuming the sample output to the inner device.
+func (j *Jitter) SetStreaming(enabled bool) error {
+	return j.inner.SetStreaming(enabled)
+}
+
+// OnRaw passes the handler for the received bytes on to the inner device,
+// if it is a RawSource. The bytes are passed on as received, unimpaired.
+func (j *Jitter) OnRaw(fn func(data []byte)) bool {
+	src, ok := j.inner.(RawSource)
+	return ok && src.OnRaw(fn)
+}
+
+// IsConnected returns whether the inner device is connected.
+func (j *Jitter) IsConnected() bool {
+	return j.inner.IsConnected()
+}
+
+// Info returns the inner device's info.
+func (j *Jitter) Info() Info {
+	return j.inner.Info()
+}
+
+// Stats returns the inner device's link counters, including samples
+// dropped on purpose (as Lost) and because this wrapper's channel was full.
+func (j *Jitter) Stats() Stats {
+	stats := j.inner.Stats()
+	stats.Lost += j.lost.Load()
+	stats.Dropped += j.dropped.Load()
+	return stats
+}
+
+// forward schedules samples from the inner device for release, dropping and
+// duplicating them at random, until the inner channel closes.
+func (j *Jitter) forward() {
+	defer func() {
+		j.mu.Lock()
+		j.ended = true
+		j.mu.Unlock()
+		j.signal()
+	}()
+
+	var last time.Time
+	for sample := range j.inner.Samples() {
+		if j.opts.DropChance > 0 && j.rng.Float64() < j.opts.DropChance {
+			j.lost.Add(1)
+			continue
+		}
+
+		copies := 1
+		if j.opts.DuplicateRate > 0 && j.rng.Float64() < j.opts.DuplicateRate {
+			copies = 2
+		}
+
+		at := time.Now().Add(j.delay())
+		if at.Before(last) {
+			at = last
+		}
+		last = at
+
+		j.mu.Lock()
+		// Only a new head changes when the next sample is due
+		idle := len(j.queue) == 0
+		for range copies {
+			j.queue = append(j.queue, jittered{sample: sample, at: at})
+		}
+		j.mu.Unlock()
+		if idle {
+			j.signal()
+		}
+	}
+}
+
+// signal wakes the release goroutine, if it is not woken already.
+func (j *Jitter) signal() {
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// delay returns Delay plus a random deviation within ±Jitter.
+func (j *Jitter) delay() time.Duration {
+	if j.opts.Jitter <= 0 {
+		return j.opts.Delay
+	}
+	return j.opts.Delay + time.Duration(j.rng.Int63n(int64(2*j.opts.Jitter)+1)) - j.opts.Jitter
+}
+
+// release delivers queued samples at their release time, or at once after
+// Close. The output channel is closed once the stream has ended and the
+// queue is drained.
+func (j *Jitter) release() {
+	defer close(j.done)
+	defer close(j.samples)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		j.mu.Lock()
+		if len(j.queue) == 0 {
+			ended := j.ended
+			j.mu.Unlock()
+			if ended {
+				return
+			}
+			<-j.wake
+			continue
+		}
+		next, stopped := j.queue[0], j.stopped
+		j.mu.Unlock()
+
+		if wait := time.Until(next.at); wait > 0 && !stopped {
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-j.wake:
+				continue // Closed while waiting
+			}
+		}
+
+		j.mu.Lock()
+		j.queue[0] = jittered{}
+		j.queue = j.queue[1:]
+		j.mu.Unlock()
+
+		select {
+		case j.samples <- next.sample:
+		default:
+			j.dropped.Add(1)
+			log.Printf("Jitter samples channel full, dropping sample")
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package lpm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collect reads samples until the channel closes.
+func collect(ch <-chan RawSample) []RawSample {
+	var samples []RawSample
+	for s := range ch {
+		samples = append(samples, s)
+	}
+	return samples
+}
+
+func TestJitter_DelaysInOrder(t *testing.T) {
+	inner := newFakeDevice()
+	j := NewJitter(inner, JitterOptions{Delay: 20 * time.Millisecond, Jitter: 15 * time.Millisecond, Seed: 1})
+	require.NoError(t, j.Connect())
+
+	start := time.Now()
+	for i := range 20 {
+		inner.samples <- RawSample{Sequence: uint32(i + 1)}
+	}
+
+	first := <-j.Samples()
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond, "samples are delayed")
+
+	require.NoError(t, j.Close())
+	samples := append([]RawSample{first}, collect(j.Samples())...)
+
+	require.Len(t, samples, 20)
+	for i, s := range samples {
+		assert.Equal(t, uint32(i+1), s.Sequence, "order is preserved")
+	}
+}
+
+func TestJitter_LongDelay(t *testing.T) {
+	inner := newFakeDevice()
+	j := NewJitter(inner, JitterOptions{Delay: time.Hour})
+	require.NoError(t, j.Connect())
+
+	// More samples than any buffer holds are queued, not backed up
+	const n = 3 * DefaultBufferSize
+	sent := make(chan struct{})
+	go func() {
+		for i := range n {
+			inner.samples <- RawSample{Sequence: uint32(i + 1)}
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("the inner device is blocked")
+	}
+
+	received := make(chan []RawSample)
+	go func() { received <- collect(j.Samples()) }()
+	start := time.Now()
+	require.NoError(t, j.Close())
+	assert.Less(t, time.Since(start), time.Second, "Close does not wait for the delay")
+
+	samples := <-received
+	assert.Equal(t, n, len(samples)+int(j.Stats().Dropped), "the queued samples are delivered on close")
+	for i := 1; i < len(samples); i++ {
+		assert.Less(t, samples[i-1].Sequence, samples[i].Sequence)
+	}
+}
+
+func TestJitter_DropAndDuplicate(t *testing.T) {
+	inner := newFakeDevice()
+	j := NewJitter(inner, JitterOptions{DropChance: 0.2, DuplicateRate: 0.2, Seed: 42})
+	require.NoError(t, j.Connect())
+
+	const n = 50
+	go func() {
+		for i := range n {
+			inner.samples <- RawSample{Sequence: uint32(i + 1)}
+		}
+		inner.Close()
+	}()
+
+	samples := collect(j.Samples())
+
+	seen := make(map[uint32]int)
+	for _, s := range samples {
+		seen[s.Sequence]++
+	}
+	duplicates := len(samples) - len(seen)
+	lost := n - len(seen)
+
+	assert.Positive(t, duplicates)
+	assert.Positive(t, lost)
+	assert.Equal(t, uint64(lost), j.Stats().Lost)
+}
+
+func TestJitter_Reproducible(t *testing.T) {
+	run := func() []RawSample {
+		inner := newFakeDevice()
+		j := NewJitter(inner, JitterOptions{DropChance: 0.3, DuplicateRate: 0.3, Seed: 7})
+		require.NoError(t, j.Connect())
+		for i := range 30 {
+			inner.samples <- RawSample{Sequence: uint32(i + 1)}
+		}
+		inner.Close()
+		return collect(j.Samples())
+	}
+
+	assert.Equal(t, run(), run())
+}
+
+func TestNewJitter_ClampsJitter(t *testing.T) {
+	j := NewJitter(newFakeDevice(), JitterOptions{Delay: 10 * time.Millisecond, Jitter: time.Second})
+	assert.Equal(t, 10*time.Millisecond, j.opts.Jitter)
+}
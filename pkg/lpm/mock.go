@@ -4,33 +4,75 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/rand"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/itohio/golpm/pkg/config"
 )
 
+// Thermal model fallbacks for configs that leave them unset.
+const (
+	defaultResponsivity     = 0.001 // V per mW
+	defaultBodyTimeConstant = 10 * time.Second
+)
+
+// TruthSample is the simulated ground truth behind a generated sample.
+type TruthSample struct {
+	Timestamp   time.Time // Timestamp of the generated sample
+	Sequence    uint32    // Sequence number of the generated sample
+	LaserPower  float64   // Absorbed laser power (mW)
+	HeaterPower float64   // Heater power (mW)
+}
+
 // Mock simulates an LPM device for testing and development.
 type Mock struct {
 	cfg *config.MockConfig
 
 	samples   chan RawSample
+	truth     chan TruthSample
 	mu        sync.RWMutex
-	ctx       context.Context
-	cancel    context.CancelFunc
+	cancel    context.CancelFunc // Cancels the current connection
+	done      chan struct{}      // Closed when the current connection has shut down
 	connected bool
 
-	// Heater states
-	heater1 bool
-	heater2 bool
-	heater3 bool
+	// Heater PWM duties, one per heater, nil while all are off; a heater is
+	// on while its duty is above zero
+	duty []float64
+
+	paused bool // Sample output paused by SetStreaming; the simulation keeps running
 
 	// Simulation state
+	interval    time.Duration // Sample interval, initially cfg.SampleRate
+	tick        time.Duration // Actual interval before the sample being generated, jittered
 	startTime   time.Time
 	lastLaserOn time.Time
 	laserActive bool
-	temperature float64 // Simulated temperature (V)
-	voltage     float64 // Simulated voltage (V)
+	temperature float64 // Simulated sensor temperature (V)
+	body        float64 // Simulated absorber body temperature (V)
+	walk        float64 // Random walk part of the ambient drift (V)
+	walkRng     *rand.Rand
+	voltage     float64     // Simulated voltage (V)
+	sequence    uint32      // Sequence number of the last generated sample
+	lastTruth   TruthSample // Ground truth of the last generated sample
+
+	// Scenario playback; scenarioTime advances one interval per sample
+	scenario     *Scenario
+	pattern      *Scenario // MockConfig.LaserPattern, played when no scenario is set
+	scenarioTime time.Duration
+
+	// Replay of a recorded session; the simulated thermals overlay the recording
+	replay    []RawSample
+	replayPos int
+
+	faults *mockFaults // Fault injection of the current connection, nil if disabled
+
+	// Manual laser pulse fired by TriggerLaser, in connection time like elapsed
+	triggerPower float64
+	triggerUntil time.Duration
+
+	stats linkStats
 }
 
 // Ensure MockedDevice implements DeviceInterface.
@@ -46,22 +88,41 @@ func NewMock(cfg *config.MockConfig) *Mock {
 			LaserDuration: 10 * time.Second,
 			LaserPeriod:   20 * time.Second,
 			SampleRate:    20 * time.Millisecond, // 50 samples per second
+
+			Responsivity:       defaultResponsivity,
+			BodyTimeConstant:   defaultBodyTimeConstant,
+			SensorTimeConstant: 300 * time.Millisecond,
 		}
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-
 	return &Mock{
 		cfg:       cfg,
+		interval:  cfg.SampleRate,
 		samples:   make(chan RawSample, DefaultBufferSize),
-		ctx:       ctx,
-		cancel:    cancel,
+		truth:     make(chan TruthSample, DefaultBufferSize),
 		connected: false,
 	}
 }
 
-// Connect simulates connecting to the device.
+// SetScenario replaces the periodic laser with a scripted timeline,
+// starting from the next Connect. A nil scenario restores the configured
+// MockConfig.Scenario, or the periodic laser if none is configured.
+func (m *Mock) SetScenario(s *Scenario) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scenario = s
+}
+
+// Connect simulates connecting to the device without a deadline.
+// It is equivalent to ConnectContext(context.Background()).
 func (m *Mock) Connect() error {
+	return m.ConnectContext(context.Background())
+}
+
+// ConnectContext simulates connecting to the device. Samples are generated
+// until ctx is cancelled or Close is called; the generator then marks the
+// device disconnected and closes the samples channel.
+func (m *Mock) ConnectContext(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -69,41 +130,195 @@ func (m *Mock) Connect() error {
 		return fmt.Errorf("already connected")
 	}
 
+	if m.scenario == nil && m.cfg.Scenario != "" {
+		s, err := LoadScenario(m.cfg.Scenario)
+		if err != nil {
+			return err
+		}
+		m.scenario = s
+	}
+	if m.replay == nil && m.cfg.ReplayFile != "" {
+		replay, err := ReadRecording(m.cfg.ReplayFile)
+		if err != nil {
+			return err
+		}
+		m.replay = replay
+	}
+	m.pattern = nil
+	if len(m.cfg.LaserPattern) > 0 {
+		pattern, err := patternScenario(m.cfg)
+		if err != nil {
+			return err
+		}
+		m.pattern = pattern
+	}
+
+	// The previous connection closed its samples and truth channels
+	if m.done != nil {
+		m.samples = make(chan RawSample, DefaultBufferSize)
+		m.truth = make(chan TruthSample, DefaultBufferSize)
+	}
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.done = make(chan struct{})
 	m.connected = true
+	m.stats.connects.Add(1)
+	m.interval = m.cfg.SampleRate
 	m.startTime = time.Now()
 	m.lastLaserOn = m.startTime
 	m.temperature = m.cfg.Bias
+	m.body = m.cfg.Bias
 	m.voltage = 5 // Initial voltage (will have noise added)
+	m.scenarioTime = 0
+	m.tick = 0
+	m.replayPos = 0
+	m.walk = 0
+	m.walkRng = rand.New(rand.NewSource(m.cfg.DriftSeed))
+	if m.replay != nil {
+		// The simulated response is added to the recorded baseline
+		m.temperature, m.body = 0, 0
+		if interval := RecordingInterval(m.replay); interval > 0 {
+			m.interval = interval
+		}
+	}
+	m.faults = newMockFaults(m.cfg)
+	m.triggerPower, m.triggerUntil = 0, 0
+	m.paused = false
 
 	// Start generating samples
-	go m.generateSamples()
+	go m.generateSamples(ctx, m.samples, m.truth, m.done, m.faults, newMockTiming(m.cfg))
 
 	return nil
 }
 
-// Close stops the mocked device.
+// Close cancels the connection and waits until it has shut down.
 func (m *Mock) Close() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.mu.RLock()
+	cancel, done := m.cancel, m.done
+	m.mu.RUnlock()
 
-	if !m.connected {
+	if cancel == nil {
 		return nil
 	}
 
-	m.cancel()
-	m.connected = false
-	close(m.samples)
+	cancel()
+	<-done
 
 	return nil
 }
 
-// Samples returns the channel for reading samples.
+// Samples returns the channel for reading samples of the current connection.
 func (m *Mock) Samples() <-chan RawSample {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.samples
 }
 
-// SetHeaters sets the heater states (simulated).
-func (m *Mock) SetHeaters(heater1, heater2, heater3 bool) error {
+// Truth returns the channel carrying the ground truth of every generated
+// sample of the current connection, including samples lost to injected
+// faults. Truth samples are dropped while the channel is full, so it only
+// needs to be read when wanted. Closed when the connection shuts down.
+// In replay mode the recording's own laser is unknown; only pulses fired
+// with TriggerLaser are reported.
+func (m *Mock) Truth() <-chan TruthSample {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.truth
+}
+
+// ReadSample returns the next generated sample.
+// Returns io.EOF once the connection has shut down and ErrNotConnected
+// if the device was never connected.
+func (m *Mock) ReadSample(ctx context.Context) (RawSample, error) {
+	m.mu.RLock()
+	samples, done := m.samples, m.done
+	m.mu.RUnlock()
+
+	if done == nil {
+		return RawSample{}, ErrNotConnected
+	}
+	return readSample(ctx, samples)
+}
+
+// SetHeaters sets the heater states (simulated), one per heater of the
+// mock (see MockConfig.HeaterPower).
+func (m *Mock) SetHeaters(states ...bool) error {
+	duty := make([]float64, len(states))
+	for i, on := range states {
+		if on {
+			duty[i] = 1
+		}
+	}
+	return m.SetHeaterDuty(duty...)
+}
+
+// SetHeaterDuty sets the heater PWM duty cycles (simulated), one per heater
+// of the mock. Each heater contributes its full power scaled by its duty.
+func (m *Mock) SetHeaterDuty(duties ...float64) error {
+	if n := len(m.heaterPower()); len(duties) != n {
+		return fmt.Errorf("%w: %d heater duties for %d heaters", ErrHeaterCount, len(duties), n)
+	}
+	for i, d := range duties {
+		if d < 0 || d > 1 || math.IsNaN(d) {
+			return fmt.Errorf("invalid duty %v for heater %d", d, i+1)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.connected {
+		return fmt.Errorf("not connected")
+	}
+
+	m.duty = slices.Clone(duties)
+
+	return nil
+}
+
+// SetSampleRate changes the simulated output rate. The configured
+// SampleRate is left untouched and applies again on the next Connect.
+func (m *Mock) SetSampleRate(hz float64) error {
+	if hz <= 0 {
+		return fmt.Errorf("invalid sample rate %v Hz", hz)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.connected {
+		return fmt.Errorf("not connected")
+	}
+
+	m.interval = time.Duration(float64(time.Second) / hz)
+
+	return nil
+}
+
+// SetStreaming pauses or resumes the sample output. While paused the
+// simulation keeps running, like the MCU keeps sampling, so heaters switched
+// meanwhile show their effect on resume.
+func (m *Mock) SetStreaming(enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.connected {
+		return fmt.Errorf("not connected")
+	}
+
+	m.paused = !enabled
+
+	return nil
+}
+
+// TriggerLaser fires a simulated laser pulse of power (mW) for duration,
+// starting now. The pulse adds to the periodic or scripted laser; a new
+// trigger replaces a pulse that is still running.
+func (m *Mock) TriggerLaser(power float64, duration time.Duration) error {
+	if power < 0 || duration <= 0 {
+		return fmt.Errorf("invalid laser pulse %v mW for %v", power, duration)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -111,9 +326,13 @@ func (m *Mock) SetHeaters(heater1, heater2, heater3 bool) error {
 		return fmt.Errorf("not connected")
 	}
 
-	m.heater1 = heater1
-	m.heater2 = heater2
-	m.heater3 = heater3
+	now := time.Since(m.startTime)
+	if m.replay == nil && (m.scenario != nil || m.pattern != nil) {
+		// Scripted playback runs on the sample clock
+		now = m.scenarioTime
+	}
+	m.triggerPower = power
+	m.triggerUntil = now + duration
 
 	return nil
 }
@@ -125,26 +344,142 @@ func (m *Mock) IsConnected() bool {
 	return m.connected
 }
 
+// Info returns simulated device capabilities matching the mock configuration.
+func (m *Mock) Info() Info {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	info := Info{
+		ProtocolVersion: 1,
+		Channels:        2,
+		SampleRate:      1 / m.interval.Seconds(),
+		Heaters:         len(m.heaterPower()),
+		FirmwareVersion: "mock",
+		Board:           "mock",
+	}
+	if m.connected {
+		info.Uptime = time.Since(m.startTime)
+	}
+	return info
+}
+
+// Stats returns the simulated link counters. No bytes are transferred,
+// so only sample counters are populated. With fault injection enabled,
+// ParseErrors and Lost count the injected faults the decoder detected.
+func (m *Mock) Stats() Stats {
+	stats := m.stats.snapshot()
+
+	m.mu.RLock()
+	faults := m.faults
+	m.mu.RUnlock()
+	if faults != nil {
+		parseStats := faults.decoder.Stats()
+		stats.ParseErrors += parseStats.Errors()
+		stats.Lost += parseStats.Lost
+	}
+	return stats
+}
+
 // generateSamples generates simulated samples.
-func (m *Mock) generateSamples() {
-	ticker := time.NewTicker(m.cfg.SampleRate)
+// The generator owns the connection's shutdown: on exit it marks the device
+// disconnected and closes the samples channel and done.
+func (m *Mock) generateSamples(ctx context.Context, samples chan RawSample, truth chan TruthSample, done chan struct{}, faults *mockFaults, timing *mockTiming) {
+	defer func() {
+		m.mu.Lock()
+		m.connected = false
+		if faults != nil {
+			parseStats := faults.decoder.Stats()
+			m.stats.parseErrors.Add(parseStats.Errors())
+			m.stats.lost.Add(parseStats.Lost)
+			m.faults = nil
+		}
+		m.mu.Unlock()
+
+		close(samples)
+		close(truth)
+		close(done)
+	}()
+
+	m.mu.RLock()
+	interval := m.interval
+	m.mu.RUnlock()
+
+	tick := interval // Interval until the next sample
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-m.ctx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			// Follow sample rate changes
+			m.mu.Lock()
+			if m.interval != interval {
+				interval = m.interval
+				tick = interval
+				ticker.Reset(interval)
+			}
+			m.tick = tick
+			m.mu.Unlock()
+
+			if timing != nil {
+				tick = timing.interval(interval)
+				ticker.Reset(tick)
+			}
+
 			sample := m.generateSample()
+			if sample.Sequence == 0 {
+				// Paused
+				continue
+			}
 			select {
-			case m.samples <- sample:
-			case <-m.ctx.Done():
-				return
+			case truth <- m.lastTruth:
 			default:
-				// Channel full, skip
 			}
+
+			if faults == nil {
+				if !m.deliver(ctx, samples, timing, sample) {
+					return
+				}
+				continue
+			}
+			for _, sample := range faults.apply(sample) {
+				if !m.deliver(ctx, samples, timing, sample) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends a sample, holding it back while timing bunches samples into
+// a burst. Returns false if ctx was cancelled.
+func (m *Mock) deliver(ctx context.Context, samples chan RawSample, timing *mockTiming, sample RawSample) bool {
+	if timing == nil {
+		return m.send(ctx, samples, sample)
+	}
+	for _, sample := range timing.hold(sample) {
+		if !m.send(ctx, samples, sample) {
+			return false
 		}
 	}
+	return true
+}
+
+// send delivers a sample without blocking, dropping it if the channel is full.
+// Returns false if ctx was cancelled.
+func (m *Mock) send(ctx context.Context, samples chan RawSample, sample RawSample) bool {
+	m.stats.parsed.Add(1)
+	select {
+	case samples <- sample:
+	case <-ctx.Done():
+		return false
+	default:
+		// Channel full, skip
+		m.stats.dropped.Add(1)
+	}
+	return true
 }
 
 // generateSample generates a single simulated sample.
@@ -153,64 +488,106 @@ func (m *Mock) generateSample() RawSample {
 	now := time.Now()
 	elapsed := now.Sub(m.startTime)
 	laserElapsed := now.Sub(m.lastLaserOn)
-	heater1 := m.heater1
-	heater2 := m.heater2
-	heater3 := m.heater3
+	duty := m.duty
+	paused := m.paused
+	interval := m.interval
+	if m.tick > 0 {
+		interval = m.tick
+	}
+	scenario := m.scenario
+	if scenario == nil {
+		scenario = m.pattern
+	}
+	replay := m.replay
+	triggerPower, triggerUntil := m.triggerPower, m.triggerUntil
 	m.mu.RUnlock()
 
-	// Check if laser should be on
-	// Laser cycles: on for LaserDuration, off for (LaserPeriod - LaserDuration)
-	// Reset timer when period completes
-	if laserElapsed >= m.cfg.LaserPeriod {
+	bias := m.cfg.Bias
+	noiseLevel := m.cfg.NoiseLevel
+	laserPower := 0.0
+
+	if replay != nil {
+		// The recording brings its own baseline, noise and laser pulses
+		bias, noiseLevel = 0, 0
+	} else if scenario != nil {
+		// Scripted timeline on the sample clock, so runs are reproducible
 		m.mu.Lock()
-		m.lastLaserOn = now
+		m.scenarioTime += interval
+		elapsed = m.scenarioTime
 		m.mu.Unlock()
-		laserElapsed = 0 // Reset for new cycle
+		now = m.startTime.Add(elapsed)
+
+		laserPower = scenario.LaserPower(elapsed)
+		noiseLevel = scenario.NoiseLevel(elapsed, noiseLevel)
+		bias += scenario.BiasOffset(elapsed)
+	} else {
+		// Check if laser should be on
+		// Laser cycles: on for LaserDuration, off for (LaserPeriod - LaserDuration)
+		// Reset timer when period completes
+		if laserElapsed >= m.cfg.LaserPeriod {
+			m.mu.Lock()
+			m.lastLaserOn = now
+			m.mu.Unlock()
+			laserElapsed = 0 // Reset for new cycle
+		}
+
+		// Laser is on during the first LaserDuration of each period
+		if laserElapsed < m.cfg.LaserDuration {
+			laserPower = m.cfg.LaserPower
+		}
 	}
 
-	// Laser is on during the first LaserDuration of each period
-	laserActive := laserElapsed < m.cfg.LaserDuration
+	if replay == nil {
+		bias += m.ambientDrift(elapsed, interval)
+	}
+
+	if elapsed < triggerUntil {
+		laserPower += triggerPower
+	}
 
 	m.mu.Lock()
-	m.laserActive = laserActive
+	m.laserActive = laserPower > 0
 	m.mu.Unlock()
 
 	// Simulate temperature response
 	// Heating from laser or heaters
-	heaterPower := m.calculateHeaterPower(heater1, heater2, heater3)
-	laserPower := 0.0
-	if laserActive {
-		laserPower = m.cfg.LaserPower
+	heaterPower := m.dutyHeaterPower(duty)
+	heaters := make([]bool, len(m.heaterPower()))
+	for i, d := range duty {
+		heaters[i] = d > 0
 	}
 
-	// Thermal response: exponential approach to steady state
-	// Each heater adds its power contribution to temperature
-	// Simplified model: T = T0 + (P/k) * (1 - exp(-t/tau))
-	// For simulation, use simpler linear ramp with thermal lag
-	totalPower := heaterPower + laserPower
-	targetTemp := m.cfg.Bias + totalPower*0.001 // 0.001 V per mW
-	thermalTimeConstant := 2.0                  // seconds
-
-	// Update temperature with thermal lag
-	dt := m.cfg.SampleRate.Seconds()
-	alpha := dt / thermalTimeConstant
-	m.temperature = m.temperature + alpha*(targetTemp-m.temperature)
+	m.stepThermal(bias, heaterPower+laserPower, interval)
 
-	// Add noise
+	// Add noise to the reading only; it is not heat
 	noise := (math.Sin(float64(elapsed.Nanoseconds())*0.001) +
 		math.Cos(float64(elapsed.Nanoseconds())*0.0013)) *
-		m.cfg.NoiseLevel * 0.5
-	m.temperature += noise
+		noiseLevel * 0.5
+	reading := m.temperature + noise
 
 	// Simulate voltage (constant reference voltage with noise)
 	// Voltage is not affected by heater state
 	voltageNoise := (math.Sin(float64(elapsed.Nanoseconds())*0.0007) +
 		math.Cos(float64(elapsed.Nanoseconds())*0.0009)) *
-		m.cfg.NoiseLevel * 0.1
+		noiseLevel * 0.1
 	m.voltage = 2.5 + voltageNoise // Constant ~2.5V with small noise
 
+	if replay != nil {
+		// Overlay the simulated response on the recorded sample, looping at the end
+		recorded := replay[m.replayPos%len(replay)]
+		m.replayPos++
+		reading += adcVolts(recorded.Reading)
+		m.voltage = adcVolts(recorded.Voltage)
+		if len(recorded.Heaters) > len(heaters) {
+			heaters = append(heaters, make([]bool, len(recorded.Heaters)-len(heaters))...)
+		}
+		for i, on := range recorded.Heaters {
+			heaters[i] = heaters[i] || on
+		}
+	}
+
 	// Convert to ADC values (16-bit, 0-65535, 3.3V reference)
-	readingVal := (m.temperature / 3.3) * 65535
+	readingVal := (reading / 3.3) * 65535
 	if readingVal < 0 {
 		readingVal = 0
 	} else if readingVal > 65535 {
@@ -226,29 +603,102 @@ func (m *Mock) generateSample() RawSample {
 	}
 	voltageADC := uint16(voltageVal)
 
+	// Numbered like protocol v4 firmware, which skips 0 on wrap. Samples
+	// taken while paused are not output, so they are not numbered either.
+	sequence := uint32(0)
+	if !paused {
+		m.sequence++
+		if m.sequence == 0 {
+			m.sequence = 1
+		}
+		sequence = m.sequence
+	}
+
+	m.lastTruth = TruthSample{
+		Timestamp:   now,
+		Sequence:    sequence,
+		LaserPower:  laserPower,
+		HeaterPower: heaterPower,
+	}
+
 	return RawSample{
 		Timestamp: now,
 		Reading:   readingADC,
 		Voltage:   voltageADC,
-		Heater1:   heater1,
-		Heater2:   heater2,
-		Heater3:   heater3,
+		Heaters:   heaters,
+		Sequence:  sequence,
 	}
 }
 
-// calculateHeaterPower calculates simulated heater power based on heater states.
-// This is a simplified model - in reality, power depends on voltage and resistance.
-func (m *Mock) calculateHeaterPower(heater1, heater2, heater3 bool) float64 {
-	power := 0.0
-	// Simplified: assume each heater contributes fixed power when on
-	if heater1 {
-		power += 10.0 // ~10 mW
+// ambientDrift returns the configured baseline drift at elapsed and
+// advances the random walk by dt.
+func (m *Mock) ambientDrift(elapsed, dt time.Duration) float64 {
+	drift := 0.0
+	if m.cfg.DriftAmplitude != 0 && m.cfg.DriftPeriod > 0 {
+		drift = m.cfg.DriftAmplitude * math.Sin(2*math.Pi*elapsed.Seconds()/m.cfg.DriftPeriod.Seconds())
+	}
+	if m.cfg.RandomWalk > 0 {
+		m.walk += m.walkRng.NormFloat64() * m.cfg.RandomWalk * math.Sqrt(dt.Seconds())
+	}
+	return drift + m.walk
+}
+
+// stepThermal advances the thermal model by dt with power (mW) absorbed.
+//
+// The absorber body has heat capacity C and thermal resistance R to ambient:
+//
+//	C dTb/dt = P - (Tb - Tamb) / R
+//
+// with R = Responsivity and R*C = BodyTimeConstant, so the body settles at
+// Tamb + P*Responsivity and the initial slope is P/C. The sensor follows the
+// body with a first-order lag:
+//
+//	dTs/dt = (Tb - Ts) / SensorTimeConstant
+//
+// Both stages use exact exponential steps, so the model is stable at any
+// sample rate.
+func (m *Mock) stepThermal(ambient, power float64, dt time.Duration) {
+	responsivity := m.cfg.Responsivity
+	if responsivity == 0 {
+		responsivity = defaultResponsivity
+	}
+	bodyTau := m.cfg.BodyTimeConstant
+	if bodyTau <= 0 {
+		bodyTau = defaultBodyTimeConstant
 	}
-	if heater2 {
-		power += 50.0 // ~50 mW
+
+	target := ambient + power*responsivity
+	m.body = target + (m.body-target)*math.Exp(-dt.Seconds()/bodyTau.Seconds())
+
+	if m.cfg.SensorTimeConstant <= 0 {
+		m.temperature = m.body
+		return
+	}
+	m.temperature = m.body + (m.temperature-m.body)*math.Exp(-dt.Seconds()/m.cfg.SensorTimeConstant.Seconds())
+}
+
+// mockHeaterPower is the simulated power of each heater when fully on (mW),
+// unless configured by MockConfig.HeaterPower.
+var mockHeaterPower = []float64{10, 50, 100}
+
+// heaterPower returns the simulated power of each heater when fully on (mW),
+// one per heater of the mock.
+func (m *Mock) heaterPower() []float64 {
+	if len(m.cfg.HeaterPower) > 0 {
+		return m.cfg.HeaterPower
 	}
-	if heater3 {
-		power += 100.0 // ~100 mW
+	return mockHeaterPower
+}
+
+// dutyHeaterPower calculates simulated heater power for PWM duty cycles.
+// The PWM period is far shorter than the thermal time constants, so the
+// heaters act as their average power.
+func (m *Mock) dutyHeaterPower(duty []float64) float64 {
+	power := 0.0
+	for i, p := range m.heaterPower() {
+		if i < len(duty) {
+			power += duty[i] * p
+		}
 	}
 	return power
 }
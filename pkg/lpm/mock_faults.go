@@ -0,0 +1,115 @@
+package lpm
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+)
+
+// defaultFreezeDuration applies when FreezeRate is set without FreezeDuration.
+const defaultFreezeDuration = time.Second
+
+// mockFaults injects the faults configured in MockConfig into the mock's
+// sample stream. Samples are sent over a simulated wire: each one is
+// formatted as a protocol line and decoded again by a Decoder, so garbled
+// lines, sequence gaps and duplicates go through the real parser and gap
+// detection, and are counted in the same ParseStats.
+type mockFaults struct {
+	cfg *config.MockConfig
+	rng *rand.Rand
+
+	wire    bytes.Buffer
+	decoder *Decoder
+
+	frozen      RawSample // Sample whose readings are repeated while frozen
+	frozenUntil time.Time
+}
+
+// newMockFaults returns nil if cfg enables no faults.
+func newMockFaults(cfg *config.MockConfig) *mockFaults {
+	if cfg.MalformedRate <= 0 && cfg.SaturationRate <= 0 && cfg.DuplicateRate <= 0 &&
+		cfg.DropRate <= 0 && cfg.FreezeRate <= 0 {
+		return nil
+	}
+
+	f := &mockFaults{
+		cfg: cfg,
+		rng: rand.New(rand.NewSource(cfg.FaultSeed)),
+	}
+	f.decoder = NewDecoder(&f.wire)
+	return f
+}
+
+// chance reports true with probability p.
+func (f *mockFaults) chance(p float64) bool {
+	return p > 0 && f.rng.Float64() < p
+}
+
+// apply injects faults into sample and returns the samples that survive the
+// simulated wire: none if it was dropped or garbled, two if duplicated.
+func (f *mockFaults) apply(sample RawSample) []RawSample {
+	// Sensor faults
+	if sample.Timestamp.Before(f.frozenUntil) {
+		sample.Reading, sample.Voltage = f.frozen.Reading, f.frozen.Voltage
+	} else if f.chance(f.cfg.FreezeRate) {
+		duration := f.cfg.FreezeDuration
+		if duration <= 0 {
+			duration = defaultFreezeDuration
+		}
+		f.frozen = sample
+		f.frozenUntil = sample.Timestamp.Add(duration)
+	}
+	if f.chance(f.cfg.SaturationRate) {
+		sample.Reading = 65535
+		if f.rng.Intn(2) == 0 {
+			sample.Reading = 0
+		}
+	}
+
+	// Link faults; a dropped sample still used up its sequence number
+	if f.chance(f.cfg.DropRate) {
+		return nil
+	}
+	copies := 1
+	if f.chance(f.cfg.DuplicateRate) {
+		copies = 2
+	}
+
+	for range copies {
+		line := formatLine(sample)
+		if f.chance(f.cfg.MalformedRate) {
+			line = f.garble(line)
+		}
+		f.wire.WriteString(line)
+	}
+	return f.receive()
+}
+
+// garble corrupts line so that the parser rejects it: either the line is
+// cut short after the reading, or a byte of the reading is replaced.
+func (f *mockFaults) garble(line string) string {
+	first := strings.IndexByte(line, ',')
+	second := first + 1 + strings.IndexByte(line[first+1:], ',')
+
+	if f.rng.Intn(2) == 0 {
+		return line[:second] + "\n"
+	}
+	b := []byte(line)
+	b[first+1+f.rng.Intn(second-first-1)] = '?'
+	return string(b)
+}
+
+// receive decodes everything written to the wire.
+func (f *mockFaults) receive() []RawSample {
+	var samples []RawSample
+	for {
+		sample, err := f.decoder.Decode()
+		if err != nil {
+			return samples
+		}
+		samples = append(samples, sample)
+	}
+}
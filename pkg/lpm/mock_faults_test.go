@@ -0,0 +1,128 @@
+package lpm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func faultSample(seq uint32) RawSample {
+	return RawSample{
+		Timestamp: time.UnixMicro(1_700_000_000_000_000 + int64(seq)*20_000),
+		Reading:   2048,
+		Voltage:   1024,
+		Heaters:   []bool{false, true, false},
+		Sequence:  seq,
+	}
+}
+
+func TestNewMockFaults_Disabled(t *testing.T) {
+	assert.Nil(t, newMockFaults(&config.MockConfig{FreezeDuration: time.Second, FaultSeed: 1}))
+}
+
+func TestMockFaults_PassThrough(t *testing.T) {
+	f := newMockFaults(&config.MockConfig{MalformedRate: 1e-12})
+
+	for seq := uint32(1); seq <= 3; seq++ {
+		out := f.apply(faultSample(seq))
+		require.Len(t, out, 1)
+		assert.Equal(t, faultSample(seq), out[0])
+	}
+	assert.Zero(t, f.decoder.Stats().Errors())
+}
+
+func TestMockFaults_Malformed(t *testing.T) {
+	f := newMockFaults(&config.MockConfig{MalformedRate: 1})
+
+	for seq := uint32(1); seq <= 20; seq++ {
+		assert.Empty(t, f.apply(faultSample(seq)))
+	}
+	assert.Equal(t, uint64(20), f.decoder.Stats().BadLines)
+}
+
+func TestMockFaults_DropLeavesGap(t *testing.T) {
+	f := newMockFaults(&config.MockConfig{DropRate: 1})
+	require.Len(t, f.apply(faultSample(1)), 0)
+
+	f.cfg = &config.MockConfig{}
+	require.Len(t, f.apply(faultSample(2)), 1)
+	require.Len(t, f.apply(faultSample(3)), 1)
+
+	f.cfg = &config.MockConfig{DropRate: 1}
+	f.apply(faultSample(4))
+	f.apply(faultSample(5))
+
+	f.cfg = &config.MockConfig{}
+	out := f.apply(faultSample(6))
+	require.Len(t, out, 1)
+	assert.Equal(t, uint32(2), out[0].Lost)
+	assert.Equal(t, uint64(2), f.decoder.Stats().Lost)
+}
+
+func TestMockFaults_Duplicate(t *testing.T) {
+	f := newMockFaults(&config.MockConfig{DuplicateRate: 1})
+
+	out := f.apply(faultSample(1))
+	require.Len(t, out, 2)
+	assert.Equal(t, out[0], out[1])
+}
+
+func TestMockFaults_Saturation(t *testing.T) {
+	f := newMockFaults(&config.MockConfig{SaturationRate: 1})
+
+	for seq := uint32(1); seq <= 20; seq++ {
+		out := f.apply(faultSample(seq))
+		require.Len(t, out, 1)
+		assert.Contains(t, []uint16{0, 65535}, out[0].Reading)
+	}
+}
+
+func TestMockFaults_Freeze(t *testing.T) {
+	f := newMockFaults(&config.MockConfig{FreezeRate: 1, FreezeDuration: 50 * time.Millisecond})
+
+	first := f.apply(faultSample(1))
+	require.Len(t, first, 1)
+
+	// Frozen for 50ms: readings repeat while timestamps and sequence advance
+	for seq := uint32(2); seq <= 3; seq++ {
+		s := faultSample(seq)
+		s.Reading = 4000 + uint16(seq)
+		out := f.apply(s)
+		require.Len(t, out, 1)
+		assert.Equal(t, first[0].Reading, out[0].Reading)
+		assert.Equal(t, seq, out[0].Sequence)
+	}
+
+	s := faultSample(4)
+	s.Reading = 4004
+	f.cfg.FreezeRate = 0
+	out := f.apply(s)
+	require.Len(t, out, 1)
+	assert.Equal(t, uint16(4004), out[0].Reading)
+}
+
+func TestMock_Faults(t *testing.T) {
+	dev := NewMock(&config.MockConfig{
+		SampleRate:    time.Millisecond,
+		MalformedRate: 0.2,
+		DropRate:      0.2,
+		FaultSeed:     42,
+	})
+	require.NoError(t, dev.Connect())
+
+	received := 0
+	for received < 100 {
+		_, err := dev.ReadSample(context.Background())
+		require.NoError(t, err)
+		received++
+	}
+	require.NoError(t, dev.Close())
+
+	stats := dev.Stats()
+	assert.Greater(t, stats.ParseErrors, uint64(0))
+	assert.Greater(t, stats.Lost, uint64(0))
+}
@@ -1,6 +1,7 @@
 package lpm
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -56,3 +57,28 @@ func TestMock_GracefulShutdown(t *testing.T) {
 	assert.False(t, ok, "Channel should be closed")
 }
 
+
+// TestMock_ConnectContext_Cancel tests that cancelling the connection context
+// shuts the device down the same way Close does.
+func TestMock_ConnectContext_Cancel(t *testing.T) {
+	mock := NewMock(&config.MockConfig{SampleRate: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, mock.ConnectContext(ctx))
+	samples := mock.Samples()
+	<-samples
+
+	cancel()
+	select {
+	case <-mock.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Mock did not shut down after cancel")
+	}
+	assert.False(t, mock.IsConnected())
+
+	// Reconnect gets a fresh channel
+	assert.NoError(t, mock.Connect())
+	defer mock.Close()
+	_, ok := <-mock.Samples()
+	assert.True(t, ok, "Reconnected mock should produce samples")
+}
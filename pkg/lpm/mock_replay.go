@@ -0,0 +1,63 @@
+package lpm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ReadRecording reads a raw recording, as written by Recorder, e.g. for
+// playback by the Mock. Metadata lines and malformed lines are skipped by the
+// Decoder; the heater count of '#INFO' lines unpacks the binary frames
+// following them.
+func ReadRecording(filename string) ([]RawSample, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer f.Close()
+
+	var samples []RawSample
+	decoder := NewDecoder(f)
+	decoder.OnResponse(func(line string) {
+		var info Info
+		if parseInfo(line, &info) == nil && info.Heaters > 0 {
+			decoder.SetHeaterCount(info.Heaters)
+		}
+	})
+	for {
+		sample, err := decoder.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read replay file: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("replay file %s contains no samples", filename)
+	}
+	return samples, nil
+}
+
+// RecordingInterval returns the mean sample interval of a recording,
+// or 0 if it cannot be determined.
+func RecordingInterval(samples []RawSample) time.Duration {
+	if len(samples) < 2 {
+		return 0
+	}
+	span := samples[len(samples)-1].Timestamp.Sub(samples[0].Timestamp)
+	if span <= 0 {
+		return 0
+	}
+	return span / time.Duration(len(samples)-1)
+}
+
+// adcVolts converts a 16-bit ADC value to volts (3.3V reference).
+func adcVolts(adc uint16) float64 {
+	return float64(adc) / 65535 * 3.3
+}
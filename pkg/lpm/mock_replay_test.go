@@ -0,0 +1,117 @@
+package lpm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRecording writes n samples 5ms apart in the Recorder format.
+func writeRecording(t *testing.T, n int) (string, []RawSample) {
+	t.Helper()
+
+	var b strings.Builder
+	b.WriteString(Info{ProtocolVersion: 4, Board: "xiao"}.String() + "\n")
+	samples := make([]RawSample, n)
+	for i := range samples {
+		samples[i] = RawSample{
+			Timestamp: time.UnixMicro(1_700_000_000_000_000 + int64(i)*5000),
+			Reading:   uint16(20000 + 10*i),
+			Voltage:   31000,
+			Heaters:   []bool{false, false, i >= n-2},
+			Sequence:  uint32(i + 1),
+		}
+		b.WriteString(formatLine(samples[i]))
+	}
+
+	path := filepath.Join(t.TempDir(), "session.csv")
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0o644))
+	return path, samples
+}
+
+func TestLoadReplay(t *testing.T) {
+	path, want := writeRecording(t, 10)
+
+	samples, err := ReadRecording(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, samples)
+	assert.Equal(t, 5*time.Millisecond, RecordingInterval(samples))
+
+	_, err = ReadRecording(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+
+	empty := filepath.Join(t.TempDir(), "empty.csv")
+	require.NoError(t, os.WriteFile(empty, []byte("#INFO proto=4\n"), 0o644))
+	_, err = ReadRecording(empty)
+	assert.Error(t, err)
+}
+
+func TestLoadReplay_Binary(t *testing.T) {
+	heaters := []bool{true, false, false, false, true}
+	var b strings.Builder
+	b.WriteString(Info{ProtocolVersion: 17, Heaters: len(heaters)}.String() + "\n")
+	for i := range 3 {
+		b.Write(encodeFrame(RawSample{Reading: uint16(100 + i), Voltage: 31000, Heaters: heaters, Sequence: uint32(i + 1)}))
+	}
+	path := filepath.Join(t.TempDir(), "session.lpm")
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0o644))
+
+	samples, err := ReadRecording(path)
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	for _, s := range samples {
+		assert.Equal(t, heaters, s.Heaters, "unpacked with the recorded heater count")
+	}
+	assert.Equal(t, len(heaters), NewReplay(samples).Info().Heaters)
+}
+
+func TestMock_Replay(t *testing.T) {
+	path, recorded := writeRecording(t, 20)
+
+	dev := NewMock(&config.MockConfig{
+		SampleRate: time.Second, // Replaced by the recording's rate
+		ReplayFile: path,
+		LaserPower: 1000, LaserPeriod: time.Millisecond, LaserDuration: time.Millisecond,
+		BodyTimeConstant: 100 * time.Millisecond,
+	})
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+	assert.InDelta(t, 200, dev.Info().SampleRate, 0.01)
+
+	read := func() RawSample {
+		sample, err := dev.ReadSample(context.Background())
+		require.NoError(t, err)
+		return sample
+	}
+
+	// Without heaters the recording plays back unchanged, and loops at the end
+	for i := range 2 * len(recorded) {
+		want := recorded[i%len(recorded)]
+		got := read()
+		assert.InDelta(t, want.Reading, got.Reading, 1, "sample %d", i)
+		assert.InDelta(t, want.Voltage, got.Voltage, 1, "sample %d", i)
+		assert.Equal(t, want.Heaters, got.Heaters, "sample %d", i)
+	}
+
+	// Simulated heaters overlay their response on the recording
+	require.NoError(t, dev.SetHeaters(false, true, false))
+	for range 10 {
+		read()
+	}
+	got := read()
+	assert.True(t, got.Heaters[1])
+	assert.Greater(t, int(got.Reading), int(recorded[10].Reading)+100)
+}
+
+func TestMock_Replay_LoadError(t *testing.T) {
+	dev := NewMock(&config.MockConfig{ReplayFile: filepath.Join(t.TempDir(), "missing.csv")})
+	assert.Error(t, dev.Connect())
+	assert.False(t, dev.IsConnected())
+}
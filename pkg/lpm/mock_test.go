@@ -1,95 +1,17 @@
 package lpm
 
 import (
+	"context"
+	"io"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/itohio/golpm/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestMockedDevice_calculateHeaterPower(t *testing.T) {
-	cfg := &config.MockConfig{
-		Bias:          0.0,
-		NoiseLevel:    0.001,
-		LaserPower:    40.0,
-		LaserDuration: 2 * time.Second,
-		LaserPeriod:   20 * time.Second,
-		SampleRate:    100 * time.Millisecond,
-	}
-	dev := NewMock(cfg)
-
-	tests := []struct {
-		name           string
-		heater1, heater2, heater3 bool
-		wantPower      float64
-	}{
-		{
-			name:      "all off",
-			heater1:   false,
-			heater2:   false,
-			heater3:   false,
-			wantPower: 0.0,
-		},
-		{
-			name:      "only heater1 on",
-			heater1:   true,
-			heater2:   false,
-			heater3:   false,
-			wantPower: 10.0,
-		},
-		{
-			name:      "only heater2 on",
-			heater1:   false,
-			heater2:   true,
-			heater3:   false,
-			wantPower: 50.0,
-		},
-		{
-			name:      "only heater3 on",
-			heater1:   false,
-			heater2:   false,
-			heater3:   true,
-			wantPower: 100.0,
-		},
-		{
-			name:      "heater1 and heater2 on",
-			heater1:   true,
-			heater2:   true,
-			heater3:   false,
-			wantPower: 60.0,
-		},
-		{
-			name:      "heater1 and heater3 on",
-			heater1:   true,
-			heater2:   false,
-			heater3:   true,
-			wantPower: 110.0,
-		},
-		{
-			name:      "heater2 and heater3 on",
-			heater1:   false,
-			heater2:   true,
-			heater3:   true,
-			wantPower: 150.0,
-		},
-		{
-			name:      "all heaters on",
-			heater1:   true,
-			heater2:   true,
-			heater3:   true,
-			wantPower: 160.0,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			power := dev.calculateHeaterPower(tt.heater1, tt.heater2, tt.heater3)
-			assert.Equal(t, tt.wantPower, power)
-		})
-	}
-}
-
 func TestNewMock(t *testing.T) {
 	cfg := &config.MockConfig{
 		Bias:          0.5,
@@ -139,22 +61,101 @@ func TestMockedDevice_SetHeaters(t *testing.T) {
 	// Now should work
 	err = dev.SetHeaters(true, false, true)
 	assert.NoError(t, err)
-	assert.True(t, dev.heater1)
-	assert.False(t, dev.heater2)
-	assert.True(t, dev.heater3)
+	assert.Equal(t, []float64{1, 0, 1}, dev.duty)
 
 	// Test all combinations
 	err = dev.SetHeaters(false, false, false)
 	assert.NoError(t, err)
-	assert.False(t, dev.heater1)
-	assert.False(t, dev.heater2)
-	assert.False(t, dev.heater3)
+	assert.Equal(t, []float64{0, 0, 0}, dev.duty)
 
 	err = dev.SetHeaters(true, true, true)
 	assert.NoError(t, err)
-	assert.True(t, dev.heater1)
-	assert.True(t, dev.heater2)
-	assert.True(t, dev.heater3)
+	assert.Equal(t, []float64{1, 1, 1}, dev.duty)
+
+	// One state per heater
+	assert.ErrorIs(t, dev.SetHeaters(true, true), ErrHeaterCount)
+}
+
+func TestMockedDevice_SetHeaterDuty(t *testing.T) {
+	dev := NewMock(nil)
+	assert.ErrorContains(t, dev.SetHeaterDuty(0.5, 0, 0), "not connected")
+
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	require.NoError(t, dev.SetHeaterDuty(0.5, 0, 0.25))
+	assert.Equal(t, []float64{0.5, 0, 0.25}, dev.duty)
+	assert.InDelta(t, 30.0, dev.dutyHeaterPower(dev.duty), 1e-9)
+
+	// Switching heaters on runs them at full duty
+	require.NoError(t, dev.SetHeaters(true, true, false))
+	assert.InDelta(t, 60.0, dev.dutyHeaterPower(dev.duty), 1e-9)
+
+	assert.Error(t, dev.SetHeaterDuty(1.5, 0, 0))
+	assert.Error(t, dev.SetHeaterDuty(0, -0.1, 0))
+	assert.ErrorIs(t, dev.SetHeaterDuty(0.5, 0, 0, 0), ErrHeaterCount)
+}
+
+func TestMock_HeaterPower(t *testing.T) {
+	dev := NewMock(&config.MockConfig{HeaterPower: []float64{20, 40}, SampleRate: time.Millisecond})
+	assert.Equal(t, 2, dev.Info().Heaters)
+	assert.Equal(t, 60.0, dev.dutyHeaterPower([]float64{1, 1}))
+
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	require.NoError(t, dev.SetHeaters(false, true))
+	assert.ErrorIs(t, dev.SetHeaters(false, true, false), ErrHeaterCount)
+	sample, err := dev.ReadSample(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, sample.Heaters, 2)
+}
+
+func TestMockedDevice_SetStreaming(t *testing.T) {
+	dev := NewMock(nil)
+	assert.ErrorContains(t, dev.SetStreaming(false), "not connected")
+
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	last, err := dev.ReadSample(ctx)
+	require.NoError(t, err)
+
+	// Nothing is output while paused
+	require.NoError(t, dev.SetStreaming(false))
+	time.Sleep(2 * dev.cfg.SampleRate)
+	for len(dev.Samples()) > 0 {
+		last = <-dev.Samples()
+	}
+	time.Sleep(5 * dev.cfg.SampleRate)
+	assert.Empty(t, dev.Samples())
+
+	// Samples generated while paused are not numbered, so none count as lost
+	require.NoError(t, dev.SetStreaming(true))
+	next, err := dev.ReadSample(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, last.Sequence+1, next.Sequence)
+}
+
+func TestMockedDevice_SetSampleRate(t *testing.T) {
+	dev := NewMock(nil)
+
+	err := dev.SetSampleRate(10)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not connected")
+
+	err = dev.Connect()
+	assert.NoError(t, err)
+	defer dev.Close()
+	assert.InDelta(t, 50.0, dev.Info().SampleRate, 0.001)
+
+	assert.NoError(t, dev.SetSampleRate(10))
+	assert.InDelta(t, 10.0, dev.Info().SampleRate, 0.001)
+	assert.Equal(t, 20*time.Millisecond, dev.cfg.SampleRate, "Config should be left untouched")
+
+	assert.Error(t, dev.SetSampleRate(-1))
 }
 
 func TestMockedDevice_Connect_AlreadyConnected(t *testing.T) {
@@ -187,6 +188,28 @@ func TestMockedDevice_Close_Connected(t *testing.T) {
 	assert.False(t, dev.IsConnected())
 }
 
+func TestMockedDevice_ReadSample(t *testing.T) {
+	dev := NewMock(&config.MockConfig{SampleRate: time.Millisecond, LaserPeriod: time.Second})
+
+	_, err := dev.ReadSample(context.Background())
+	assert.ErrorIs(t, err, ErrNotConnected)
+
+	require.NoError(t, dev.Connect())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	first, err := dev.ReadSample(ctx)
+	require.NoError(t, err)
+	second, err := dev.ReadSample(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first.Sequence+1, second.Sequence)
+
+	require.NoError(t, dev.Close())
+	for err == nil {
+		_, err = dev.ReadSample(ctx)
+	}
+	assert.ErrorIs(t, err, io.EOF)
+}
+
 func TestMockedDevice_generateSample_ADCConversion(t *testing.T) {
 	// Test ADC conversion logic directly without full simulation
 	// This tests the calculation: (voltage / 3.3) * 4095
@@ -218,6 +241,19 @@ func TestMockedDevice_generateSample_ADCConversion(t *testing.T) {
 	}
 }
 
+func TestMockedDevice_generateSample_Sequence(t *testing.T) {
+	m := NewMock(nil)
+	m.startTime = time.Now()
+	m.lastLaserOn = m.startTime
+
+	for want := uint32(1); want <= 3; want++ {
+		assert.Equal(t, want, m.generateSample().Sequence)
+	}
+
+	m.sequence = 0xFFFFFFFF
+	assert.Equal(t, uint32(1), m.generateSample().Sequence, "0 is skipped on wrap")
+}
+
 func TestMockedDevice_generateSample_ADCClamping(t *testing.T) {
 	// Test ADC clamping logic directly
 	testCases := []struct {
@@ -275,3 +311,134 @@ func TestMockedDevice_ThermalTargetCalculation(t *testing.T) {
 	}
 }
 
+
+func TestMock_stepThermal(t *testing.T) {
+	dev := NewMock(&config.MockConfig{
+		Responsivity:       0.002,
+		BodyTimeConstant:   10 * time.Second,
+		SensorTimeConstant: time.Second,
+	})
+	const dt = 10 * time.Millisecond
+
+	// Initial body slope is P/C = P*R/tau
+	dev.stepThermal(0, 100, dt)
+	assert.InDelta(t, 100*0.002/10*dt.Seconds(), dev.body, 1e-6)
+	assert.Less(t, dev.temperature, dev.body, "sensor lags the body")
+
+	// Settles at ambient + P*R after many time constants
+	for range 20000 {
+		dev.stepThermal(0.5, 100, dt)
+	}
+	assert.InDelta(t, 0.7, dev.body, 1e-6)
+	assert.InDelta(t, 0.7, dev.temperature, 1e-6)
+
+	// Cools back to ambient with the power off; the sensor trails the body
+	dev.stepThermal(0.5, 0, time.Second)
+	assert.Less(t, dev.body, 0.7)
+	assert.Greater(t, dev.temperature, dev.body)
+}
+
+func TestMock_stepThermal_NoSensorLag(t *testing.T) {
+	dev := NewMock(&config.MockConfig{})
+
+	dev.stepThermal(0, 40, time.Second)
+	assert.Equal(t, dev.body, dev.temperature)
+	assert.InDelta(t, 0.04*(1-math.Exp(-0.1)), dev.body, 1e-9, "falls back to default responsivity and body time constant")
+}
+
+func TestMock_TriggerLaser(t *testing.T) {
+	dev := NewMock(&config.MockConfig{SampleRate: 5 * time.Millisecond})
+	assert.Error(t, dev.TriggerLaser(40, time.Second), "not connected")
+
+	// An empty scenario keeps the periodic laser off and runs on the sample clock
+	dev.SetScenario(&Scenario{})
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	assert.Error(t, dev.TriggerLaser(-1, time.Second))
+	assert.Error(t, dev.TriggerLaser(40, 0))
+
+	read := func() RawSample {
+		sample, err := dev.ReadSample(context.Background())
+		require.NoError(t, err)
+		return sample
+	}
+
+	laserActive := func() bool {
+		dev.mu.RLock()
+		defer dev.mu.RUnlock()
+		return dev.laserActive
+	}
+
+	before := read()
+	require.NoError(t, dev.TriggerLaser(1000, 100*time.Millisecond))
+	for range 5 {
+		read()
+	}
+	during := read()
+	assert.Greater(t, during.Reading, before.Reading)
+	assert.True(t, laserActive())
+
+	for range 40 {
+		read()
+	}
+	assert.False(t, laserActive(), "pulse ended")
+}
+
+func TestMock_ambientDrift(t *testing.T) {
+	dev := NewMock(&config.MockConfig{DriftAmplitude: 0.01, DriftPeriod: 40 * time.Second})
+
+	assert.InDelta(t, 0, dev.ambientDrift(0, time.Second), 1e-12)
+	assert.InDelta(t, 0.01, dev.ambientDrift(10*time.Second, time.Second), 1e-12)
+	assert.InDelta(t, -0.01, dev.ambientDrift(30*time.Second, time.Second), 1e-12)
+}
+
+func TestMock_ambientDrift_RandomWalk(t *testing.T) {
+	walk := func(seed int64) []float64 {
+		dev := NewMock(&config.MockConfig{RandomWalk: 0.001, DriftSeed: seed, SampleRate: time.Second})
+		require.NoError(t, dev.Connect())
+		require.NoError(t, dev.Close())
+
+		steps := make([]float64, 1000)
+		for i := range steps {
+			steps[i] = dev.ambientDrift(time.Duration(i)*time.Second, time.Second)
+		}
+		return steps
+	}
+
+	a, b := walk(7), walk(7)
+	assert.Equal(t, a, b, "same seed, same walk")
+	assert.NotEqual(t, a, walk(8))
+
+	// After 1000s the walk has a standard deviation of 0.001*√1000 ≈ 0.03 V
+	assert.NotZero(t, a[len(a)-1])
+	assert.Less(t, math.Abs(a[len(a)-1]), 0.2)
+}
+
+func TestMock_Truth(t *testing.T) {
+	dev := NewMock(&config.MockConfig{SampleRate: 5 * time.Millisecond})
+	dev.SetScenario(&Scenario{Pulses: []ScenarioPulse{{Start: 50 * time.Millisecond, Duration: 50 * time.Millisecond, Power: 40}}})
+	require.NoError(t, dev.Connect())
+
+	for i := 1; i <= 30; i++ {
+		sample, err := dev.ReadSample(context.Background())
+		require.NoError(t, err)
+		truth := <-dev.Truth()
+
+		assert.Equal(t, sample.Sequence, truth.Sequence)
+		assert.Equal(t, sample.Timestamp, truth.Timestamp)
+		assert.Zero(t, truth.HeaterPower)
+
+		// Sample i is generated at i*5ms on the sample clock
+		want := 0.0
+		if i >= 10 && i < 20 {
+			want = 40
+		}
+		assert.Equal(t, want, truth.LaserPower, "sample %d", i)
+	}
+
+	truth := dev.Truth()
+	require.NoError(t, dev.Close())
+	for range truth {
+	}
+}
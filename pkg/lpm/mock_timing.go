@@ -0,0 +1,63 @@
+package lpm
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+)
+
+// defaultBurstSize applies when BurstRate is set without BurstSize.
+const defaultBurstSize = 8
+
+// mockTiming makes the mock's sample timing realistic: each sample interval
+// jitters around the nominal rate, and delivery occasionally stalls so that
+// several samples arrive at once, like USB serial packets. Device timestamps
+// keep the actual sample times; only delivery is bunched.
+type mockTiming struct {
+	cfg   *config.MockConfig
+	rng   *rand.Rand
+	burst []RawSample // Samples held back in the current burst
+}
+
+// newMockTiming returns nil if cfg enables neither jitter nor bursts.
+func newMockTiming(cfg *config.MockConfig) *mockTiming {
+	if cfg.RateJitter <= 0 && cfg.BurstRate <= 0 {
+		return nil
+	}
+	return &mockTiming{
+		cfg: cfg,
+		rng: rand.New(rand.NewSource(cfg.TimingSeed)),
+	}
+}
+
+// interval returns the jittered interval until the next sample.
+func (t *mockTiming) interval(nominal time.Duration) time.Duration {
+	jitter := min(t.cfg.RateJitter, 1)
+	if jitter <= 0 {
+		return nominal
+	}
+	next := time.Duration(float64(nominal) * (1 + jitter*(2*t.rng.Float64()-1)))
+	return max(next, time.Microsecond)
+}
+
+// hold returns the samples to deliver now: none while a burst is building,
+// the whole burst once it is complete, and otherwise just sample.
+func (t *mockTiming) hold(sample RawSample) []RawSample {
+	if len(t.burst) == 0 && (t.cfg.BurstRate <= 0 || t.rng.Float64() >= t.cfg.BurstRate) {
+		return []RawSample{sample}
+	}
+
+	size := t.cfg.BurstSize
+	if size <= 0 {
+		size = defaultBurstSize
+	}
+	t.burst = append(t.burst, sample)
+	if len(t.burst) < size {
+		return nil
+	}
+
+	burst := t.burst
+	t.burst = nil
+	return burst
+}
@@ -0,0 +1,79 @@
+package lpm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMockTiming_Disabled(t *testing.T) {
+	assert.Nil(t, newMockTiming(&config.MockConfig{BurstSize: 4, TimingSeed: 1}))
+}
+
+func TestMockTiming_Interval(t *testing.T) {
+	intervals := func(seed int64) []time.Duration {
+		timing := newMockTiming(&config.MockConfig{RateJitter: 0.2, TimingSeed: seed})
+		out := make([]time.Duration, 100)
+		for i := range out {
+			out[i] = timing.interval(10 * time.Millisecond)
+		}
+		return out
+	}
+
+	a := intervals(3)
+	assert.Equal(t, a, intervals(3), "same seed, same timing")
+	for _, interval := range a {
+		assert.GreaterOrEqual(t, interval, 8*time.Millisecond)
+		assert.LessOrEqual(t, interval, 12*time.Millisecond)
+	}
+	assert.NotEqual(t, a[0], a[1])
+}
+
+func TestMockTiming_Hold(t *testing.T) {
+	timing := newMockTiming(&config.MockConfig{BurstRate: 1, BurstSize: 3})
+
+	assert.Empty(t, timing.hold(faultSample(1)))
+	assert.Empty(t, timing.hold(faultSample(2)))
+	burst := timing.hold(faultSample(3))
+	require.Len(t, burst, 3)
+	for i, sample := range burst {
+		assert.Equal(t, uint32(i+1), sample.Sequence)
+	}
+
+	// Without bursts every sample is delivered immediately
+	timing.cfg = &config.MockConfig{RateJitter: 0.1}
+	assert.Len(t, timing.hold(faultSample(4)), 1)
+}
+
+func TestMock_Bursts(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	dev := NewMock(&config.MockConfig{
+		SampleRate: interval,
+		BurstRate:  1,
+		BurstSize:  4,
+	})
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	samples := make([]RawSample, 8)
+	arrivals := make([]time.Time, len(samples))
+	for i := range samples {
+		sample, err := dev.ReadSample(context.Background())
+		require.NoError(t, err)
+		samples[i], arrivals[i] = sample, time.Now()
+	}
+
+	bunched := 0
+	for i := 1; i < len(samples); i++ {
+		// Device timestamps keep the sample times
+		assert.Greater(t, samples[i].Timestamp.Sub(samples[i-1].Timestamp), interval/2)
+		if arrivals[i].Sub(arrivals[i-1]) < interval/4 {
+			bunched++
+		}
+	}
+	assert.GreaterOrEqual(t, bunched, 5, "samples arrive in bursts of 4")
+}
@@ -0,0 +1,153 @@
+package lpm
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"go.bug.st/serial"
+)
+
+// Flow control modes for SerialConfig.FlowControl.
+const (
+	FlowControlNone   = "none"
+	FlowControlRTSCTS = "rtscts"
+)
+
+// ctsPollInterval is how often a command write re-checks CTS under RTS/CTS flow control.
+const ctsPollInterval = 5 * time.Millisecond
+
+// NewFromConfig creates a new Serial device using the port and line settings
+// from cfg. Returns an error for unsupported line settings.
+// A port with BLEPortPrefix connects over BLE using DefaultBLEDialer;
+// line settings do not apply there.
+func NewFromConfig(cfg *config.SerialConfig, bufSize int) (*Serial, error) {
+	if address, ok := isBLEPort(cfg.Port); ok {
+		if DefaultBLEDialer == nil {
+			return nil, ErrBLEUnsupported
+		}
+		return NewBLE(address, DefaultBLEDialer, bufSize), nil
+	}
+
+	mode, err := serialMode(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	d := New(cfg.Port, mode.BaudRate, bufSize)
+	d.mode = mode
+	d.rtsCTS = cfg.FlowControl == FlowControlRTSCTS
+	d.binary = cfg.Binary
+	d.averaging = cfg.Averaging
+	d.adcInterval = cfg.ADCInterval
+	d.adcGain = cfg.ADCGain
+	d.adcReference = cfg.ADCReference
+	return d, nil
+}
+
+// Negotiate requests the configured sample rate from d and records the
+// negotiated rate and ADC settings in cfg, so sample-based windows and count
+// conversions resolve against what the device actually delivers. A
+// configured averaging count, already applied by a serial device on connect,
+// takes precedence; a mocked device always takes the rate.
+func Negotiate(d Device, cfg *config.SerialConfig, mocked bool) {
+	if cfg.SampleRate > 0 && (mocked || cfg.Averaging == 0) {
+		if err := d.SetSampleRate(cfg.SampleRate); err != nil {
+			log.Printf("Failed to set sample rate: %v", err)
+		}
+	}
+	info := d.Info()
+	cfg.NegotiatedRate = info.SampleRate
+	cfg.NegotiatedGain = info.ADCGain
+	cfg.NegotiatedReference = info.ADCReference
+}
+
+// serialMode converts the line settings from cfg to a serial.Mode.
+// Zero values select 8N1 at DefaultBaudRate with the driver's default DTR/RTS.
+func serialMode(cfg *config.SerialConfig) (*serial.Mode, error) {
+	mode := &serial.Mode{
+		BaudRate: cfg.BaudRate,
+		DataBits: cfg.DataBits,
+	}
+	if mode.BaudRate == 0 {
+		mode.BaudRate = DefaultBaudRate
+	}
+	if mode.DataBits == 0 {
+		mode.DataBits = 8
+	}
+	if mode.DataBits < 5 || mode.DataBits > 8 {
+		return nil, fmt.Errorf("invalid data bits %d: must be 5-8", mode.DataBits)
+	}
+
+	switch cfg.Parity {
+	case "", "none":
+		mode.Parity = serial.NoParity
+	case "odd":
+		mode.Parity = serial.OddParity
+	case "even":
+		mode.Parity = serial.EvenParity
+	case "mark":
+		mode.Parity = serial.MarkParity
+	case "space":
+		mode.Parity = serial.SpaceParity
+	default:
+		return nil, fmt.Errorf("invalid parity %q: must be none, odd, even, mark or space", cfg.Parity)
+	}
+
+	switch cfg.StopBits {
+	case "", "1":
+		mode.StopBits = serial.OneStopBit
+	case "1.5":
+		mode.StopBits = serial.OnePointFiveStopBits
+	case "2":
+		mode.StopBits = serial.TwoStopBits
+	default:
+		return nil, fmt.Errorf("invalid stop bits %q: must be 1, 1.5 or 2", cfg.StopBits)
+	}
+
+	switch cfg.FlowControl {
+	case "", FlowControlNone, FlowControlRTSCTS:
+	default:
+		return nil, fmt.Errorf("invalid flow control %q: must be %s or %s", cfg.FlowControl, FlowControlNone, FlowControlRTSCTS)
+	}
+
+	// Only override the driver default (both asserted) when configured.
+	// Some boards reset when DTR toggles, so it can be held low.
+	if cfg.DTR != nil || cfg.RTS != nil || cfg.FlowControl == FlowControlRTSCTS {
+		bits := &serial.ModemOutputBits{DTR: true, RTS: true}
+		if cfg.DTR != nil {
+			bits.DTR = *cfg.DTR
+		}
+		if cfg.RTS != nil {
+			bits.RTS = *cfg.RTS
+		}
+		// RTS signals the MCU that the host is ready to receive
+		if cfg.FlowControl == FlowControlRTSCTS {
+			bits.RTS = true
+		}
+		mode.InitialStatusBits = bits
+	}
+
+	return mode, nil
+}
+
+// waitCTS blocks until the MCU asserts CTS or the timeout expires.
+// go.bug.st/serial has no hardware flow control, so under RTS/CTS the host
+// holds back command writes until the MCU is ready to receive.
+func waitCTS(port serial.Port, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		bits, err := port.GetModemStatusBits()
+		if err != nil {
+			return fmt.Errorf("failed to read modem status: %w", err)
+		}
+		if bits.CTS {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("CTS not asserted within %v", timeout)
+		}
+		time.Sleep(ctsPollInterval)
+	}
+}
@@ -0,0 +1,98 @@
+package lpm
+
+import (
+	"testing"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.bug.st/serial"
+)
+
+func TestSerialMode(t *testing.T) {
+	off := false
+	tests := []struct {
+		name    string
+		cfg     config.SerialConfig
+		want    serial.Mode
+		wantErr bool
+	}{
+		{
+			name: "zero values select 115200 8N1",
+			cfg:  config.SerialConfig{},
+			want: serial.Mode{BaudRate: DefaultBaudRate, DataBits: 8},
+		},
+		{
+			name: "7E2",
+			cfg:  config.SerialConfig{BaudRate: 9600, DataBits: 7, Parity: "even", StopBits: "2"},
+			want: serial.Mode{BaudRate: 9600, DataBits: 7, Parity: serial.EvenParity, StopBits: serial.TwoStopBits},
+		},
+		{
+			name: "DTR held low",
+			cfg:  config.SerialConfig{DTR: &off},
+			want: serial.Mode{BaudRate: DefaultBaudRate, DataBits: 8, InitialStatusBits: &serial.ModemOutputBits{DTR: false, RTS: true}},
+		},
+		{
+			name: "RTS/CTS forces RTS",
+			cfg:  config.SerialConfig{FlowControl: FlowControlRTSCTS, RTS: &off},
+			want: serial.Mode{BaudRate: DefaultBaudRate, DataBits: 8, InitialStatusBits: &serial.ModemOutputBits{DTR: true, RTS: true}},
+		},
+		{name: "invalid data bits", cfg: config.SerialConfig{DataBits: 9}, wantErr: true},
+		{name: "invalid parity", cfg: config.SerialConfig{Parity: "sometimes"}, wantErr: true},
+		{name: "invalid stop bits", cfg: config.SerialConfig{StopBits: "3"}, wantErr: true},
+		{name: "invalid flow control", cfg: config.SerialConfig{FlowControl: "xonxoff"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serialMode(&tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func TestNewFromConfig_PassesMode(t *testing.T) {
+	dev, err := NewFromConfig(&config.SerialConfig{Port: "fake", BaudRate: 57600, Parity: "odd"}, 0)
+	require.NoError(t, err)
+
+	var opened *serial.Mode
+	dev.openPort = func(name string, mode *serial.Mode) (serial.Port, error) {
+		opened = mode
+		return newFakePort(nil), nil
+	}
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	require.NotNil(t, opened)
+	assert.Equal(t, 57600, opened.BaudRate)
+	assert.Equal(t, serial.OddParity, opened.Parity)
+}
+
+func TestSerial_RTSCTS_WaitsForCTS(t *testing.T) {
+	port := newFakePort(nil)
+	dev, err := NewFromConfig(&config.SerialConfig{Port: "fake", FlowControl: FlowControlRTSCTS}, 0)
+	require.NoError(t, err)
+	dev.openPort = func(string, *serial.Mode) (serial.Port, error) { return port, nil }
+
+	port.setCTS(true) // Let the handshake through
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	port.setCTS(false)
+	assert.ErrorContains(t, dev.SetHeaters(true, false, false), "CTS")
+	assert.NotContains(t, port.commands(), "100\n")
+
+	port.setCTS(true)
+	require.NoError(t, dev.SetHeaters(true, false, false))
+	assert.Contains(t, port.commands(), "100\n")
+}
+
+func TestNewFromConfig_InvalidSettings(t *testing.T) {
+	_, err := NewFromConfig(&config.SerialConfig{Port: "fake", StopBits: "3"}, 0)
+	assert.Error(t, err)
+}
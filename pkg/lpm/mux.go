@@ -0,0 +1,257 @@
+package lpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMuxHoldoff is how long a Mux holds a sample back while waiting for
+// the other devices, before releasing it out of order rather than stalling.
+const DefaultMuxHoldoff = 100 * time.Millisecond
+
+// muxInput is a sample received from one of the Mux devices.
+type muxInput struct {
+	id      int
+	sample  RawSample
+	arrived time.Time
+	closed  bool // The device's stream ended
+}
+
+// Mux merges the sample streams of several devices, e.g. a reference detector
+// plus the thermal head, into one stream ordered by sample timestamp. Each
+// sample's DeviceID is set to the index of its device in NewMux.
+//
+// A sample is released once every connected device has a sample pending, so
+// the earliest one is known. When a device falls silent, samples are released
+// after the holdoff anyway; ordering across devices is then best effort.
+//
+// Commands (heaters, sample rate) and Info go to the first device, the
+// primary head; pausing the stream applies to all devices.
+type Mux struct {
+	devices []Device
+	holdoff time.Duration
+
+	samples chan RawSample
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+// Ensure Mux implements Device.
+var _ Device = (*Mux)(nil)
+
+// NewMux creates a new Mux over devices. At least one device is required.
+func NewMux(devices ...Device) *Mux {
+	return &Mux{
+		devices: devices,
+		holdoff: DefaultMuxHoldoff,
+		samples: make(chan RawSample, DefaultBufferSize*max(len(devices), 1)),
+	}
+}
+
+// Connect connects all devices without a deadline.
+func (m *Mux) Connect() error {
+	return m.ConnectContext(context.Background())
+}
+
+// ConnectContext connects all devices with ctx and starts merging their streams.
+// If any device fails to connect, the ones already connected are closed again.
+func (m *Mux) ConnectContext(ctx context.Context) error {
+	if len(m.devices) == 0 {
+		return fmt.Errorf("mux has no devices")
+	}
+
+	for i, dev := range m.devices {
+		if err := dev.ConnectContext(ctx); err != nil {
+			for _, connected := range m.devices[:i] {
+				connected.Close()
+			}
+			return fmt.Errorf("failed to connect device %d: %w", i, err)
+		}
+	}
+
+	// The previous connection closed the samples channel
+	if m.done != nil {
+		m.samples = make(chan RawSample, cap(m.samples))
+	}
+	m.done = make(chan struct{})
+
+	inputs := make(chan muxInput, cap(m.samples))
+	for i, dev := range m.devices {
+		go m.receive(i, dev.Samples(), inputs)
+	}
+	go m.merge(inputs)
+
+	return nil
+}
+
+// Close closes all devices and waits for the merged stream to drain.
+func (m *Mux) Close() error {
+	var errs []error
+	for i, dev := range m.devices {
+		if err := dev.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("device %d: %w", i, err))
+		}
+	}
+	if m.done != nil {
+		<-m.done
+	}
+	return errors.Join(errs...)
+}
+
+// Samples returns the channel for reading the merged samples.
+func (m *Mux) Samples() <-chan RawSample {
+	return m.samples
+}
+
+// ReadSample returns the next merged sample. Returns io.EOF once all streams
+// have ended and ErrNotConnected if the device was never connected.
+func (m *Mux) ReadSample(ctx context.Context) (RawSample, error) {
+	if m.done == nil {
+		return RawSample{}, ErrNotConnected
+	}
+	return readSample(ctx, m.samples)
+}
+
+// SetHeaters forwards the heater command to the primary device.
+func (m *Mux) SetHeaters(states ...bool) error {
+	return m.devices[0].SetHeaters(states...)
+}
+
+// SetHeaterDuty forwards the heater duties to the primary device.
+func (m *Mux) SetHeaterDuty(duties ...float64) error {
+	return m.devices[0].SetHeaterDuty(duties...)
+}
+
+// SetSampleRate forwards the sample rate to the primary device.
+func (m *Mux) SetSampleRate(hz float64) error {
+	return m.devices[0].SetSampleRate(hz)
+}
+
+// SetStreaming pauses or resumes the sample output of all devices, so the
+// merged stream pauses as a whole.
+func (m *Mux) SetStreaming(enabled bool) error {
+	var errs []error
+	for i, dev := range m.devices {
+		if err := dev.SetStreaming(enabled); err != nil {
+			errs = append(errs, fmt.Errorf("device %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsConnected returns whether all devices are connected.
+func (m *Mux) IsConnected() bool {
+	for _, dev := range m.devices {
+		if !dev.IsConnected() {
+			return false
+		}
+	}
+	return len(m.devices) > 0
+}
+
+// Info returns the primary device's info.
+func (m *Mux) Info() Info {
+	return m.devices[0].Info()
+}
+
+// Stats returns the sum of all devices' link counters, including samples
+// dropped by the Mux itself.
+func (m *Mux) Stats() Stats {
+	var stats Stats
+	for _, dev := range m.devices {
+		s := dev.Stats()
+		stats.BytesRead += s.BytesRead
+		stats.Parsed += s.Parsed
+		stats.ParseErrors += s.ParseErrors
+		stats.Dropped += s.Dropped
+		stats.Lost += s.Lost
+		stats.Reconnects += s.Reconnects
+		stats.Retries += s.Retries
+		stats.CommandFailures += s.CommandFailures
+	}
+	stats.Dropped += m.dropped.Load()
+	return stats
+}
+
+// receive tags the samples of device id and passes them to the merger.
+func (m *Mux) receive(id int, samples <-chan RawSample, inputs chan<- muxInput) {
+	for sample := range samples {
+		sample.DeviceID = id
+		inputs <- muxInput{id: id, sample: sample, arrived: time.Now()}
+	}
+	inputs <- muxInput{id: id, closed: true}
+}
+
+// merge releases pending samples in timestamp order until all streams have ended.
+func (m *Mux) merge(inputs <-chan muxInput) {
+	defer close(m.done)
+	defer close(m.samples)
+
+	pending := make([][]muxInput, len(m.devices))
+	closed := make([]bool, len(m.devices))
+	open := len(m.devices)
+
+	ticker := time.NewTicker(m.holdoff / 2)
+	defer ticker.Stop()
+
+	for open > 0 {
+		select {
+		case in := <-inputs:
+			if in.closed {
+				closed[in.id] = true
+				open--
+			} else {
+				pending[in.id] = append(pending[in.id], in)
+			}
+		case <-ticker.C:
+		}
+
+		for m.releaseNext(pending, closed, false) {
+		}
+	}
+
+	// All streams ended: flush what is left in order
+	for m.releaseNext(pending, closed, true) {
+	}
+}
+
+// releaseNext sends the pending sample with the earliest timestamp if it is
+// safe to do so: every open device has a sample pending, a sample has waited
+// longer than the holdoff, or flush is set. Returns whether a sample was released.
+func (m *Mux) releaseNext(pending [][]muxInput, closed []bool, flush bool) bool {
+	next := -1
+	complete := true
+	expired := false
+	for id, queue := range pending {
+		if len(queue) == 0 {
+			if !closed[id] {
+				complete = false
+			}
+			continue
+		}
+		if time.Since(queue[0].arrived) >= m.holdoff {
+			expired = true
+		}
+		if next < 0 || queue[0].sample.Timestamp.Before(pending[next][0].sample.Timestamp) {
+			next = id
+		}
+	}
+	if next < 0 || !(complete || expired || flush) {
+		return false
+	}
+
+	sample := pending[next][0].sample
+	pending[next] = pending[next][1:]
+
+	select {
+	case m.samples <- sample:
+	default:
+		m.dropped.Add(1)
+		log.Printf("Mux samples channel full, dropping sample")
+	}
+	return true
+}
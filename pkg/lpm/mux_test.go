@@ -0,0 +1,67 @@
+package lpm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMux_MergesInTimestampOrder(t *testing.T) {
+	head, ref := newFakeDevice(), newFakeDevice()
+	mux := NewMux(head, ref)
+	require.NoError(t, mux.Connect())
+	assert.True(t, mux.IsConnected())
+
+	// The reference detector delivers its samples late
+	for _, us := range []int64{1, 3, 5} {
+		head.samples <- RawSample{Timestamp: time.UnixMicro(us)}
+	}
+	for _, us := range []int64{2, 4, 6} {
+		ref.samples <- RawSample{Timestamp: time.UnixMicro(us)}
+	}
+
+	require.NoError(t, mux.Close())
+	samples := collect(mux.Samples())
+
+	require.Len(t, samples, 6)
+	for i, s := range samples {
+		assert.Equal(t, int64(i+1), s.Timestamp.UnixMicro())
+		assert.Equal(t, i%2, s.DeviceID)
+	}
+}
+
+func TestMux_SilentDeviceDoesNotStall(t *testing.T) {
+	head, ref := newFakeDevice(), newFakeDevice()
+	mux := NewMux(head, ref)
+	mux.holdoff = 20 * time.Millisecond
+	require.NoError(t, mux.Connect())
+	defer mux.Close()
+
+	head.samples <- RawSample{Reading: 42}
+
+	select {
+	case s := <-mux.Samples():
+		assert.Equal(t, uint16(42), s.Reading)
+	case <-time.After(time.Second):
+		t.Fatal("sample was held back by the silent device")
+	}
+}
+
+func TestMux_CommandsGoToPrimary(t *testing.T) {
+	head, ref := newFakeDevice(), newFakeDevice()
+	mux := NewMux(head, ref)
+
+	require.NoError(t, mux.SetHeaters(true, false, true))
+	require.NoError(t, mux.SetSampleRate(25))
+
+	assert.Equal(t, []bool{true, false, true}, head.heaters)
+	assert.Equal(t, 25.0, head.rate)
+	assert.Nil(t, ref.heaters)
+	assert.Equal(t, Stats{Parsed: 2}, mux.Stats())
+}
+
+func TestMux_NoDevices(t *testing.T) {
+	assert.Error(t, NewMux().Connect())
+}
@@ -0,0 +1,111 @@
+package lpm
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// fakePort is an in-memory serial.Port emulating the MCU.
+// Commands written by the host are answered by the respond function;
+// samples are pushed with emit.
+type fakePort struct {
+	r *io.PipeReader
+	w *io.PipeWriter
+
+	mu      sync.Mutex
+	written []string
+	respond func(cmd string) string
+	modem   serial.ModemStatusBits
+}
+
+func newFakePort(respond func(cmd string) string) *fakePort {
+	r, w := io.Pipe()
+	return &fakePort{r: r, w: w, respond: respond}
+}
+
+// firmwareV3 answers like protocol version 3 firmware that acknowledges heater
+// and sample rate commands.
+func firmwareV3(cmd string) string {
+	switch cmd {
+	case "V?\n":
+		return "#INFO proto=3 channels=2 rate=50.0\n"
+	case "I?\n":
+		return "#INFO fw=0.2.0 board=xiao uptime=1000\n"
+	}
+	if strings.HasPrefix(cmd, "R") {
+		hz, err := strconv.Atoi(strings.TrimSpace(cmd[1:]))
+		if err != nil || hz <= 0 {
+			return "#ERR rate\n"
+		}
+		return fmt.Sprintf("#OK rate=%.1f\n", 1000/float64(1000/hz)) // quantized to whole 1ms readings
+	}
+	if len(cmd) == 4 {
+		return "#OK " + cmd[:3] + "\n"
+	}
+	return ""
+}
+
+// connectFake connects a Serial device to a fake port.
+func connectFake(port *fakePort) (*Serial, error) {
+	dev := New("fake", 0, 0)
+	dev.openPort = func(string, *serial.Mode) (serial.Port, error) { return port, nil }
+	return dev, dev.Connect()
+}
+
+func (p *fakePort) emit(s string) {
+	p.w.Write([]byte(s))
+}
+
+func (p *fakePort) commands() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.written...)
+}
+
+func (p *fakePort) Read(b []byte) (int, error) { return p.r.Read(b) }
+
+func (p *fakePort) Write(b []byte) (int, error) {
+	cmd := string(b)
+	p.mu.Lock()
+	p.written = append(p.written, cmd)
+	p.mu.Unlock()
+
+	if p.respond != nil {
+		if resp := p.respond(cmd); resp != "" {
+			go p.emit(resp)
+		}
+	}
+	return len(b), nil
+}
+
+func (p *fakePort) Close() error {
+	p.w.Close()
+	return nil
+}
+
+func (p *fakePort) SetMode(*serial.Mode) error { return nil }
+func (p *fakePort) Drain() error               { return nil }
+func (p *fakePort) ResetInputBuffer() error    { return nil }
+func (p *fakePort) ResetOutputBuffer() error   { return nil }
+func (p *fakePort) SetDTR(bool) error          { return nil }
+func (p *fakePort) SetRTS(bool) error          { return nil }
+func (p *fakePort) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	bits := p.modem
+	return &bits, nil
+}
+
+func (p *fakePort) setCTS(cts bool) {
+	p.mu.Lock()
+	p.modem.CTS = cts
+	p.mu.Unlock()
+}
+func (p *fakePort) SetReadTimeout(time.Duration) error { return nil }
+func (p *fakePort) Break(time.Duration) error          { return nil }
@@ -0,0 +1,248 @@
+package lpm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// Recorder wraps a Device and transparently passes samples through while
+// recording the raw stream to a writer. When the inner device is a RawSource,
+// the bytes received are written as they arrived, so malformed lines, frames
+// failing their CRC, responses and binary frames are kept as well. Other
+// devices have no link, so their samples are written in the MCU line format.
+// Writes are unbuffered, one per message, so everything received up to a
+// crash is already on disk when the writer is an *os.File.
+type Recorder struct {
+	inner Device
+	raw   bool // The inner device passes on the bytes received
+
+	samples chan RawSample
+	mu      sync.Mutex
+	w       io.Writer
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+// Ensure Recorder implements Device.
+var _ Device = (*Recorder)(nil)
+
+// NewRecorder creates a new Recorder around inner that writes raw lines to w.
+// A nil writer creates a paused recorder; use SetWriter to start recording.
+func NewRecorder(inner Device, w io.Writer) *Recorder {
+	r := &Recorder{
+		inner:   inner,
+		samples: make(chan RawSample, DefaultBufferSize),
+		w:       w,
+	}
+	if src, ok := inner.(RawSource); ok {
+		r.raw = src.OnRaw(r.write)
+	}
+	if !r.raw {
+		log.Printf("Recorder: %T does not pass on the bytes received, recording samples in the line format", inner)
+	}
+	return r
+}
+
+// Connect connects the inner device without a deadline.
+func (r *Recorder) Connect() error {
+	return r.ConnectContext(context.Background())
+}
+
+// ConnectContext connects the inner device with ctx and starts forwarding samples.
+// Cancelling ctx shuts down the inner device, which ends the stream.
+func (r *Recorder) ConnectContext(ctx context.Context) error {
+	if err := r.inner.ConnectContext(ctx); err != nil {
+		return err
+	}
+
+	// The previous connection closed the samples channel
+	if r.done != nil {
+		r.samples = make(chan RawSample, DefaultBufferSize)
+	}
+	r.done = make(chan struct{})
+	go r.forward()
+
+	return nil
+}
+
+// Close closes the inner device and waits for pending samples to be recorded.
+func (r *Recorder) Close() error {
+	err := r.inner.Close()
+	if r.done != nil {
+		<-r.done
+	}
+	return err
+}
+
+// Samples returns the channel for reading samples.
+func (r *Recorder) Samples() <-chan RawSample {
+	return r.samples
+}
+
+// ReadSample returns the next sample. Returns io.EOF once the stream has ended
+// and ErrNotConnected if the device was never connected.
+func (r *Recorder) ReadSample(ctx context.Context) (RawSample, error) {
+	if r.done == nil {
+		return RawSample{}, ErrNotConnected
+	}
+	return readSample(ctx, r.samples)
+}
+
+// SetHeaters forwards the heater command to the inner device.
+func (r *Recorder) SetHeaters(states ...bool) error {
+	return r.inner.SetHeaters(states...)
+}
+
+// SetHeaterDuty forwards the heater duties to the inner device.
+func (r *Recorder) SetHeaterDuty(duties ...float64) error {
+	return r.inner.SetHeaterDuty(duties...)
+}
+
+// SetStreaming forwards pausing or resuming the sample output to the inner device.
+func (r *Recorder) SetStreaming(enabled bool) error {
+	return r.inner.SetStreaming(enabled)
+}
+
+// SetSampleRate forwards the sample rate to the inner device. While recording,
+// the updated device Info is written as a new '#INFO' metadata line so a
+// replay can follow the rate change.
+func (r *Recorder) SetSampleRate(hz float64) error {
+	if err := r.inner.SetSampleRate(hz); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.w != nil {
+		r.writeInfo()
+	}
+	return nil
+}
+
+// IsConnected returns whether the inner device is connected.
+func (r *Recorder) IsConnected() bool {
+	return r.inner.IsConnected()
+}
+
+// Info returns the inner device's info.
+func (r *Recorder) Info() Info {
+	return r.inner.Info()
+}
+
+// Stats returns the inner device's link counters, including samples
+// dropped by this wrapper.
+func (r *Recorder) Stats() Stats {
+	stats := r.inner.Stats()
+	stats.Dropped += r.dropped.Load()
+	return stats
+}
+
+// SetWriter replaces the recording destination. A nil writer pauses recording.
+// A new writer starts with the device Info as a '#INFO' metadata line, which
+// the parser skips as a response on replay.
+// The previous writer is not closed; that is the caller's responsibility.
+func (r *Recorder) SetWriter(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.w = w
+	if w != nil && r.inner.IsConnected() {
+		r.writeInfo()
+	}
+}
+
+// writeInfo writes the device Info as a metadata line. Must be called with mu held.
+func (r *Recorder) writeInfo() {
+	if _, err := io.WriteString(r.w, r.inner.Info().String()+"\n"); err != nil {
+		log.Printf("Recorder write failed, recording paused: %v", err)
+		r.w = nil
+	}
+}
+
+// IsRecording returns whether samples are currently being written.
+func (r *Recorder) IsRecording() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.w != nil
+}
+
+// forward copies samples from the inner device, recording each one before
+// passing it on unless the raw stream is recorded. The output channel is
+// closed when the inner channel closes.
+func (r *Recorder) forward() {
+	defer close(r.done)
+	defer close(r.samples)
+
+	for sample := range r.inner.Samples() {
+		if !r.raw {
+			r.record(sample)
+		}
+
+		select {
+		case r.samples <- sample:
+		default:
+			r.dropped.Add(1)
+			log.Printf("Recorder samples channel full, dropping sample")
+		}
+	}
+}
+
+// record writes a single sample line.
+func (r *Recorder) record(sample RawSample) {
+	r.write([]byte(formatLine(sample)))
+}
+
+// write writes data while recording. On write failure recording is paused
+// so a full disk does not produce an error per sample.
+func (r *Recorder) write(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.w == nil {
+		return
+	}
+
+	if _, err := r.w.Write(data); err != nil {
+		log.Printf("Recorder write failed, recording paused: %v", err)
+		r.w = nil
+	}
+}
+
+// formatLine formats a RawSample in the MCU line format understood by parseLine.
+// Format: unix_micros,reading,voltage,heater1heater2heater3[,sequence[,ambient[,reading2]]]\n
+// Optional fields are only written when the sample, or a later field, has them.
+func formatLine(s RawSample) string {
+	heaters := s.Heaters
+	if len(heaters) == 0 {
+		heaters = make([]bool, DefaultHeaters) // The heater field cannot be empty
+	}
+	line := fmt.Sprintf("%d,%d,%d,%s", s.Timestamp.UnixMicro(), s.Reading, s.Voltage,
+		formatHeaters(heaters))
+	if s.Sequence != 0 || s.Ambient != 0 || s.HasReading2 {
+		line += fmt.Sprintf(",%d", s.Sequence)
+	}
+	if s.Ambient != 0 || s.HasReading2 {
+		line += fmt.Sprintf(",%d", s.Ambient)
+	}
+	if s.HasReading2 {
+		line += fmt.Sprintf(",%d", s.Reading2)
+	}
+	return line + "\n"
+}
+
+// formatHeaters formats heater states as the string of one digit per heater
+// used on the wire, e.g. "100".
+func formatHeaters(states []bool) string {
+	b := make([]byte, len(states))
+	for i, on := range states {
+		b[i] = '0'
+		if on {
+			b[i] = '1'
+		}
+	}
+	return string(b)
+}
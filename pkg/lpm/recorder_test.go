@@ -0,0 +1,178 @@
+package lpm
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.bug.st/serial"
+)
+
+// fakeDevice is a minimal Device whose samples are pushed by the test.
+type fakeDevice struct {
+	samples   chan RawSample
+	connected bool
+	heaters   []bool
+	duty      []float64
+	rate      float64
+	paused    bool
+}
+
+func newFakeDevice() *fakeDevice {
+	return &fakeDevice{samples: make(chan RawSample, DefaultBufferSize)}
+}
+
+func (f *fakeDevice) Connect() error { f.connected = true; return nil }
+func (f *fakeDevice) ConnectContext(ctx context.Context) error {
+	return f.Connect()
+}
+func (f *fakeDevice) Close() error              { f.connected = false; close(f.samples); return nil }
+func (f *fakeDevice) Samples() <-chan RawSample { return f.samples }
+func (f *fakeDevice) IsConnected() bool         { return f.connected }
+func (f *fakeDevice) Info() Info                { return Info{ProtocolVersion: 1, Channels: 2} }
+func (f *fakeDevice) Stats() Stats              { return Stats{Parsed: 1} }
+func (f *fakeDevice) SetHeaters(states ...bool) error {
+	f.heaters = states
+	return nil
+}
+func (f *fakeDevice) SetHeaterDuty(duties ...float64) error {
+	f.duty = duties
+	return nil
+}
+func (f *fakeDevice) SetSampleRate(hz float64) error {
+	f.rate = hz
+	return nil
+}
+func (f *fakeDevice) SetStreaming(enabled bool) error {
+	f.paused = !enabled
+	return nil
+}
+func (f *fakeDevice) ReadSample(ctx context.Context) (RawSample, error) {
+	return readSample(ctx, f.samples)
+}
+
+func TestFormatLine_RoundTrip(t *testing.T) {
+	in := RawSample{
+		Timestamp: time.UnixMicro(1234567890123),
+		Reading:   2048,
+		Voltage:   1024,
+		Heaters:   []bool{true, false, true},
+	}
+
+	line := formatLine(in)
+	assert.Equal(t, "1234567890123,2048,1024,101\n", line)
+
+	out, err := parseLine(strings.TrimSpace(line))
+	require.NoError(t, err)
+	assert.Equal(t, in.Timestamp.UnixNano(), out.Timestamp.UnixNano())
+	assert.Equal(t, in.Reading, out.Reading)
+	assert.Equal(t, in.Voltage, out.Voltage)
+	assert.Equal(t, in.Heaters, out.Heaters)
+}
+
+func TestFormatLine_Sequence(t *testing.T) {
+	in := RawSample{Timestamp: time.UnixMicro(1234567890123), Reading: 2048, Voltage: 1024, Sequence: 42}
+
+	line := formatLine(in)
+	assert.Equal(t, "1234567890123,2048,1024,000,42\n", line)
+
+	out, err := parseLine(strings.TrimSpace(line))
+	require.NoError(t, err)
+	assert.Equal(t, in.Sequence, out.Sequence)
+}
+
+func TestFormatLine_Ambient(t *testing.T) {
+	in := RawSample{Timestamp: time.UnixMicro(1234567890123), Reading: 2048, Voltage: 1024, Heaters: []bool{false, false, false}, Sequence: 42, Ambient: 30000}
+
+	line := formatLine(in)
+	assert.Equal(t, "1234567890123,2048,1024,000,42,30000\n", line)
+
+	out, err := parseLine(strings.TrimSpace(line))
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+}
+
+func TestFormatLine_Reading2(t *testing.T) {
+	in := RawSample{Timestamp: time.UnixMicro(1234567890123), Reading: 2048, Voltage: 1024, Heaters: []bool{false, false, false}, Reading2: 2100, HasReading2: true}
+
+	line := formatLine(in)
+	assert.Equal(t, "1234567890123,2048,1024,000,0,0,2100\n", line)
+
+	out, err := parseLine(strings.TrimSpace(line))
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+}
+
+func TestRecorder_PassesThroughAndRecords(t *testing.T) {
+	inner := newFakeDevice()
+	var buf bytes.Buffer
+	rec := NewRecorder(inner, &buf)
+
+	require.NoError(t, rec.Connect())
+	assert.True(t, rec.IsRecording())
+
+	inner.samples <- RawSample{Timestamp: time.UnixMicro(1), Reading: 10, Voltage: 20}
+	got := <-rec.Samples()
+	assert.Equal(t, uint16(10), got.Reading)
+
+	rec.SetWriter(nil)
+	assert.False(t, rec.IsRecording())
+	inner.samples <- RawSample{Timestamp: time.UnixMicro(2), Reading: 11, Voltage: 21}
+	<-rec.Samples()
+
+	require.NoError(t, rec.Close())
+	_, ok := <-rec.Samples()
+	assert.False(t, ok, "Channel should be closed")
+
+	assert.Equal(t, "1,10,20,000\n", buf.String())
+}
+
+func TestRecorder_RecordsRawStream(t *testing.T) {
+	wrappers := map[string]func(Device) Device{
+		"watchdog": func(d Device) Device { return NewWatchdog(d, 0, nil) },
+		"jitter":   func(d Device) Device { return NewJitter(NewWatchdog(d, 0, nil), JitterOptions{}) },
+	}
+	for name, wrap := range wrappers {
+		t.Run(name, func(t *testing.T) {
+			port := newFakePort(firmwareV3)
+			dev := New("fake", 0, 0)
+			dev.openPort = func(string, *serial.Mode) (serial.Port, error) { return port, nil }
+			var buf bytes.Buffer
+			rec := NewRecorder(wrap(dev), &buf)
+			require.True(t, rec.raw)
+			require.NoError(t, rec.Connect())
+
+			frame := encodeFrame(RawSample{Timestamp: time.UnixMicro(42), Reading: 7, Voltage: 8})
+			bad := bytes.Clone(frame)
+			bad[len(bad)-1] ^= 0xFF
+			stream := "1000,2048,1024,101\n" +
+				"1000,garbled\n" +
+				"#OK 101\n" +
+				string(frame) + string(bad) +
+				"1001,2049,1024,101\n"
+			port.emit(stream)
+			require.NoError(t, rec.Close())
+
+			// The handshake's responses come first
+			assert.True(t, strings.HasPrefix(buf.String(), "#INFO proto=3"), buf.String())
+			assert.True(t, strings.HasSuffix(buf.String(), stream), "everything received is recorded as it arrived")
+		})
+	}
+}
+
+func TestRecorder_SetHeatersForwarded(t *testing.T) {
+	inner := newFakeDevice()
+	rec := NewRecorder(inner, nil)
+
+	require.NoError(t, rec.SetHeaters(false, true, false))
+	assert.Equal(t, []bool{false, true, false}, inner.heaters)
+}
+
+func TestRecorder_StatsIncludeInner(t *testing.T) {
+	rec := NewRecorder(newFakeDevice(), nil)
+	assert.Equal(t, Stats{Parsed: 1}, rec.Stats())
+}
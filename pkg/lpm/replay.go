@@ -0,0 +1,370 @@
+package lpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// replayMaxSpeed is the fastest playback speed, limited so the samples
+// channel and the meter keep up.
+const replayMaxSpeed = 100
+
+// ErrReplay is returned by the commands a recording cannot carry out, such
+// as switching heaters.
+var ErrReplay = errors.New("not supported while replaying a recording")
+
+// Replay plays a raw recording back as a Device, at the recorded pace
+// scaled by a speed factor, so the whole measurement chain runs on
+// historical data. Playback can be paused, resumed and moved to any point
+// of the recording; it pauses at the end. Delivered samples keep the
+// recording's time scale, so slopes and powers match a live measurement,
+// but are shifted after a seek so timestamps and sequence numbers keep
+// increasing.
+type Replay struct {
+	recording []RawSample
+
+	samples   chan RawSample
+	mu        sync.Mutex
+	cancel    context.CancelFunc // Cancels the current connection
+	done      chan struct{}      // Closed when the current connection has shut down
+	wake      chan struct{}      // Signals the player that the position, speed or pause changed
+	connected bool
+	startTime time.Time
+
+	pos       int           // Index of the next sample to deliver
+	speed     float64       // Playback speed, 1 = as recorded
+	paused    bool          // Paused by the user or at the end of the recording
+	offset    time.Duration // Added to recorded timestamps
+	seqOffset uint32        // Added to recorded sequence numbers
+	last      RawSample     // Last sample delivered, zero before the first
+	changes   uint64        // Counts changes of position, speed and pause
+
+	stats linkStats
+}
+
+// Ensure Replay implements Device.
+var _ Device = (*Replay)(nil)
+
+// NewReplay creates a device playing recording, as read by ReadRecording,
+// at recorded speed from its start.
+func NewReplay(recording []RawSample) *Replay {
+	return &Replay{
+		recording: recording,
+		samples:   make(chan RawSample, DefaultBufferSize),
+		speed:     1,
+	}
+}
+
+// Connect starts the playback without a deadline.
+// It is equivalent to ConnectContext(context.Background()).
+func (r *Replay) Connect() error {
+	return r.ConnectContext(context.Background())
+}
+
+// ConnectContext starts the playback from the current position. Samples
+// are delivered until ctx is cancelled or Close is called; the player then
+// marks the device disconnected and closes the samples channel.
+func (r *Replay) ConnectContext(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.connected {
+		return fmt.Errorf("already connected")
+	}
+	if len(r.recording) == 0 {
+		return fmt.Errorf("recording contains no samples")
+	}
+
+	// The previous connection closed its samples channel
+	if r.done != nil {
+		r.samples = make(chan RawSample, DefaultBufferSize)
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	r.wake = make(chan struct{}, 1)
+	r.connected = true
+	r.stats.connects.Add(1)
+	r.startTime = time.Now()
+
+	go r.play(ctx, r.samples, r.wake, r.done)
+
+	return nil
+}
+
+// Close stops the playback and waits until it has shut down.
+func (r *Replay) Close() error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	<-done
+
+	return nil
+}
+
+// Samples returns the channel for reading samples of the current connection.
+func (r *Replay) Samples() <-chan RawSample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.samples
+}
+
+// ReadSample returns the next sample played back.
+// Returns io.EOF once the connection has shut down and ErrNotConnected
+// if the device was never connected.
+func (r *Replay) ReadSample(ctx context.Context) (RawSample, error) {
+	r.mu.Lock()
+	samples, done := r.samples, r.done
+	r.mu.Unlock()
+
+	if done == nil {
+		return RawSample{}, ErrNotConnected
+	}
+	return readSample(ctx, samples)
+}
+
+// SetHeaters returns ErrReplay: the recording's heaters cannot be switched.
+func (r *Replay) SetHeaters(states ...bool) error {
+	return ErrReplay
+}
+
+// SetHeaterDuty returns ErrReplay: the recording's heaters cannot be switched.
+func (r *Replay) SetHeaterDuty(duties ...float64) error {
+	return ErrReplay
+}
+
+// SetSampleRate returns ErrReplay: the rate is the recording's. Use
+// SetSpeed to play it faster or slower.
+func (r *Replay) SetSampleRate(hz float64) error {
+	return ErrReplay
+}
+
+// SetStreaming pauses or resumes the playback.
+func (r *Replay) SetStreaming(enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.connected {
+		return fmt.Errorf("not connected")
+	}
+	if enabled && r.pos >= len(r.recording) {
+		// Resuming at the end plays the recording again
+		r.moveTo(0)
+	}
+	r.paused = !enabled
+	r.signal()
+
+	return nil
+}
+
+// Paused returns whether the playback is paused, by SetStreaming or at the
+// end of the recording.
+func (r *Replay) Paused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.paused
+}
+
+// SetSpeed sets the playback speed: 1 plays as recorded, 2 twice as fast.
+func (r *Replay) SetSpeed(speed float64) error {
+	if !(speed > 0 && speed <= replayMaxSpeed) {
+		return fmt.Errorf("invalid replay speed %v, must be above 0 and at most %d", speed, replayMaxSpeed)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.speed = speed
+	r.signal()
+
+	return nil
+}
+
+// Speed returns the playback speed.
+func (r *Replay) Speed() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.speed
+}
+
+// Seek moves the playback to t from the start of the recording, clamped to
+// the recording.
+func (r *Replay) Seek(t time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := r.recording[0].Timestamp
+	pos := 0
+	for pos < len(r.recording)-1 && r.recording[pos].Timestamp.Sub(start) < t {
+		pos++
+	}
+	r.moveTo(pos)
+	r.signal()
+}
+
+// Position returns the recording time of the next sample and the length
+// of the recording.
+func (r *Replay) Position() (position, length time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := r.recording[0].Timestamp
+	length = r.recording[len(r.recording)-1].Timestamp.Sub(start)
+	if r.pos >= len(r.recording) {
+		return length, length
+	}
+	return r.recording[r.pos].Timestamp.Sub(start), length
+}
+
+// IsConnected returns whether the playback is running.
+func (r *Replay) IsConnected() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.connected
+}
+
+// Info describes the recording as a device: its mean sample rate and the
+// heaters of its first sample.
+func (r *Replay) Info() Info {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info := Info{
+		Channels:        1,
+		FirmwareVersion: "replay",
+		Board:           "replay",
+	}
+	if len(r.recording) > 0 {
+		info.Heaters = len(r.recording[0].Heaters)
+	}
+	if interval := RecordingInterval(r.recording); interval > 0 {
+		info.SampleRate = 1 / interval.Seconds()
+	}
+	if r.connected {
+		info.Uptime = time.Since(r.startTime)
+	}
+	return info
+}
+
+// Stats returns the playback counters: samples delivered and dropped.
+func (r *Replay) Stats() Stats {
+	return r.stats.snapshot()
+}
+
+// moveTo makes pos the next sample to deliver, shifting the following
+// timestamps and sequence numbers to continue from the last one delivered.
+// Call it with mu held.
+func (r *Replay) moveTo(pos int) {
+	r.pos = pos
+	if r.last.Timestamp.IsZero() {
+		return
+	}
+	next := r.recording[pos]
+	interval := RecordingInterval(r.recording)
+	r.offset = r.last.Timestamp.Add(interval).Sub(next.Timestamp)
+	if r.last.Sequence != 0 && next.Sequence != 0 {
+		r.seqOffset = r.last.Sequence + 1 - next.Sequence
+	}
+}
+
+// signal wakes the player up to apply a change. Call it with mu held.
+func (r *Replay) signal() {
+	r.changes++
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the next sample to deliver, how long to wait before
+// delivering it and the changes so far, or false while paused.
+func (r *Replay) next() (RawSample, time.Duration, uint64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pos >= len(r.recording) {
+		r.paused = true
+	}
+	if r.paused {
+		return RawSample{}, 0, r.changes, false
+	}
+
+	s := r.recording[r.pos]
+	s.Timestamp = s.Timestamp.Add(r.offset)
+	if s.Sequence != 0 {
+		s.Sequence += r.seqOffset
+	}
+	var wait time.Duration
+	if !r.last.Timestamp.IsZero() {
+		wait = time.Duration(float64(s.Timestamp.Sub(r.last.Timestamp)) / r.speed)
+	}
+	return s, max(wait, 0), r.changes, true
+}
+
+// play delivers the recording's samples at their pace until ctx is
+// cancelled. The player owns the connection's shutdown: on exit it marks
+// the device disconnected and closes the samples channel and done.
+func (r *Replay) play(ctx context.Context, samples chan RawSample, wake chan struct{}, done chan struct{}) {
+	defer func() {
+		r.mu.Lock()
+		r.connected = false
+		r.mu.Unlock()
+
+		close(samples)
+		close(done)
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for {
+		s, wait, changes, ok := r.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-wake:
+			}
+			continue
+		}
+
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+			// Position, speed or pause changed: look again
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		r.mu.Lock()
+		if r.changes != changes {
+			// Changed while the timer fired
+			r.mu.Unlock()
+			continue
+		}
+		r.pos++
+		r.last = s
+		r.mu.Unlock()
+
+		r.stats.parsed.Add(1)
+		select {
+		case samples <- s:
+		case <-ctx.Done():
+			return
+		default:
+			r.stats.dropped.Add(1)
+		}
+	}
+}
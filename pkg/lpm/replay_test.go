@@ -0,0 +1,68 @@
+package lpm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readReplay reads n samples from r, failing after a second.
+func readReplay(t *testing.T, r *Replay, n int) []RawSample {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	samples := make([]RawSample, n)
+	for i := range samples {
+		s, err := r.ReadSample(ctx)
+		require.NoError(t, err)
+		samples[i] = s
+	}
+	return samples
+}
+
+func TestReplay(t *testing.T) {
+	_, recording := writeRecording(t, 10)
+	r := NewReplay(recording)
+	require.NoError(t, r.SetSpeed(10))
+	assert.Error(t, r.SetSpeed(0))
+	assert.InDelta(t, 200, r.Info().SampleRate, 1e-9)
+	assert.ErrorIs(t, r.SetHeaters(true, false, false), ErrReplay)
+
+	require.NoError(t, r.Connect())
+	defer r.Close()
+
+	got := readReplay(t, r, 10)
+	assert.Equal(t, recording, got, "played as recorded")
+	require.Eventually(t, r.Paused, time.Second, time.Millisecond, "pauses at the end")
+	position, length := r.Position()
+	assert.Equal(t, 45*time.Millisecond, length)
+	assert.Equal(t, length, position)
+
+	// Seeking back continues the timestamps and sequence numbers
+	r.Seek(20 * time.Millisecond)
+	position, _ = r.Position()
+	assert.Equal(t, 20*time.Millisecond, position)
+	require.NoError(t, r.SetStreaming(true))
+	again := readReplay(t, r, 2)
+	assert.Equal(t, recording[4].Reading, again[0].Reading)
+	assert.Equal(t, recording[9].Timestamp.Add(5*time.Millisecond), again[0].Timestamp)
+	assert.Equal(t, uint32(11), again[0].Sequence)
+	assert.Equal(t, uint32(12), again[1].Sequence)
+
+	// Paused playback delivers nothing
+	require.NoError(t, r.SetStreaming(false))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	for {
+		if _, err := r.ReadSample(ctx); err != nil {
+			break // Drained what was sent before pausing
+		}
+	}
+	assert.True(t, r.Paused())
+
+	require.NoError(t, r.Close())
+	assert.False(t, r.IsConnected())
+}
@@ -0,0 +1,188 @@
+package lpm
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a scripted timeline for the Mock: laser pulses, ambient drift
+// and noise changes at fixed offsets from connect. Scenario time advances by
+// one sample interval per sample, so a scenario replays identically
+// regardless of host load.
+//
+// Example:
+//
+//	loop: 60s
+//	pulses:
+//	  - {start: 5s, duration: 10s, power: 40}
+//	  - {start: 30s, duration: 2s, power: 150}
+//	drift:
+//	  - {start: 0s, value: 0.0001}  # bias drift in V/s
+//	noise:
+//	  - {start: 45s, value: 0.005}  # noise level in V
+type Scenario struct {
+	Loop   time.Duration   `yaml:"loop"`   // Restart the timeline after this long (0 = play once)
+	Pulses []ScenarioPulse `yaml:"pulses"` // Laser pulses; overlapping pulses add up
+	Drift  []ScenarioStep  `yaml:"drift"`  // Ambient drift rate of the bias in V/s, from Start on
+	Noise  []ScenarioStep  `yaml:"noise"`  // Noise level in V, from Start on (default: MockConfig.NoiseLevel)
+}
+
+// ScenarioPulse is a laser pulse in a Scenario.
+type ScenarioPulse struct {
+	Start    time.Duration `yaml:"start"`
+	Duration time.Duration `yaml:"duration"`
+	Power    float64       `yaml:"power"` // Laser power (mW)
+}
+
+// ScenarioStep sets a value from Start until the next step.
+type ScenarioStep struct {
+	Start time.Duration `yaml:"start"`
+	Value float64       `yaml:"value"`
+}
+
+// LoadScenario reads a scenario from a YAML file.
+func LoadScenario(filename string) (*Scenario, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+
+	s, err := ParseScenario(data)
+	if err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", filename, err)
+	}
+	return s, nil
+}
+
+// ParseScenario parses and validates a YAML scenario.
+func ParseScenario(data []byte) (*Scenario, error) {
+	var s Scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// validate checks the timeline and sorts the steps by start time.
+func (s *Scenario) validate() error {
+	if s.Loop < 0 {
+		return fmt.Errorf("invalid loop %v", s.Loop)
+	}
+	for i, p := range s.Pulses {
+		if p.Start < 0 || p.Duration <= 0 {
+			return fmt.Errorf("pulse %d: invalid start %v or duration %v", i, p.Start, p.Duration)
+		}
+		if p.Power < 0 {
+			return fmt.Errorf("pulse %d: invalid power %v", i, p.Power)
+		}
+	}
+	for i, step := range s.Noise {
+		if step.Value < 0 {
+			return fmt.Errorf("noise step %d: invalid level %v", i, step.Value)
+		}
+	}
+	for _, steps := range [][]ScenarioStep{s.Drift, s.Noise} {
+		for i, step := range steps {
+			if step.Start < 0 {
+				return fmt.Errorf("step %d: invalid start %v", i, step.Start)
+			}
+		}
+		sort.SliceStable(steps, func(i, j int) bool { return steps[i].Start < steps[j].Start })
+	}
+	return nil
+}
+
+// at maps t onto the timeline, wrapping it when the scenario loops.
+func (s *Scenario) at(t time.Duration) time.Duration {
+	if s.Loop > 0 {
+		return t % s.Loop
+	}
+	return t
+}
+
+// LaserPower returns the total laser power (mW) at t.
+func (s *Scenario) LaserPower(t time.Duration) float64 {
+	t = s.at(t)
+	power := 0.0
+	for _, p := range s.Pulses {
+		if t >= p.Start && t < p.Start+p.Duration {
+			power += p.Power
+		}
+	}
+	return power
+}
+
+// NoiseLevel returns the noise level (V) at t, or def before the first step.
+func (s *Scenario) NoiseLevel(t time.Duration, def float64) float64 {
+	t = s.at(t)
+	level := def
+	for _, step := range s.Noise {
+		if step.Start > t {
+			break
+		}
+		level = step.Value
+	}
+	return level
+}
+
+// BiasOffset returns the bias offset (V) accumulated by the drift steps up to t.
+// Drift keeps accumulating across loops, like a slowly warming room.
+func (s *Scenario) BiasOffset(t time.Duration) float64 {
+	if len(s.Drift) == 0 {
+		return 0
+	}
+	if s.Loop > 0 {
+		loops := float64(t / s.Loop)
+		return loops*s.driftUntil(s.Loop) + s.driftUntil(t%s.Loop)
+	}
+	return s.driftUntil(t)
+}
+
+// driftUntil integrates the piecewise constant drift rate from 0 to t.
+func (s *Scenario) driftUntil(t time.Duration) float64 {
+	offset := 0.0
+	for i, step := range s.Drift {
+		if step.Start >= t {
+			break
+		}
+		end := t
+		if i+1 < len(s.Drift) && s.Drift[i+1].Start < t {
+			end = s.Drift[i+1].Start
+		}
+		offset += step.Value * (end - step.Start).Seconds()
+	}
+	return offset
+}
+
+// patternScenario converts a repeating laser pattern into a looping Scenario.
+// Zero durations fall back to the configured LaserDuration and LaserPeriod.
+func patternScenario(cfg *config.MockConfig) (*Scenario, error) {
+	s := &Scenario{}
+	for i, p := range cfg.LaserPattern {
+		if p.Duration == 0 {
+			p.Duration = cfg.LaserDuration
+		}
+		if p.Period == 0 {
+			p.Period = cfg.LaserPeriod
+		}
+		if p.Period < p.Duration {
+			return nil, fmt.Errorf("laser pattern step %d: period %v shorter than pulse %v", i, p.Period, p.Duration)
+		}
+
+		s.Pulses = append(s.Pulses, ScenarioPulse{Start: s.Loop, Duration: p.Duration, Power: p.Power})
+		s.Loop += p.Period
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("laser pattern: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,206 @@
+package lpm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScenario = `
+loop: 10s
+pulses:
+  - {start: 1s, duration: 2s, power: 40}
+  - {start: 2s, duration: 2s, power: 100}
+drift:
+  - {start: 5s, value: -0.01}
+  - {start: 0s, value: 0.01}
+noise:
+  - {start: 4s, value: 0.005}
+`
+
+func TestParseScenario(t *testing.T) {
+	s, err := ParseScenario([]byte(testScenario))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		at    time.Duration
+		power float64
+		noise float64
+		bias  float64
+	}{
+		{name: "start", at: 0, power: 0, noise: 0.001, bias: 0},
+		{name: "first pulse", at: 1500 * time.Millisecond, power: 40, noise: 0.001, bias: 0.015},
+		{name: "overlap", at: 2500 * time.Millisecond, power: 140, noise: 0.001, bias: 0.025},
+		{name: "second pulse", at: 3500 * time.Millisecond, power: 100, noise: 0.001, bias: 0.035},
+		{name: "noise step", at: 4 * time.Second, power: 0, noise: 0.005, bias: 0.04},
+		{name: "drift reversed", at: 7 * time.Second, power: 0, noise: 0.005, bias: 0.03},
+		{name: "second loop", at: 11500 * time.Millisecond, power: 40, noise: 0.001, bias: 0.015},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.power, s.LaserPower(tt.at))
+			assert.Equal(t, tt.noise, s.NoiseLevel(tt.at, 0.001))
+			assert.InDelta(t, tt.bias, s.BiasOffset(tt.at), 1e-9)
+		})
+	}
+}
+
+func TestParseScenario_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"syntax":         "pulses: [",
+		"negative loop":  "loop: -1s",
+		"zero duration":  "pulses: [{start: 1s, duration: 0s, power: 10}]",
+		"negative power": "pulses: [{start: 1s, duration: 1s, power: -10}]",
+		"negative noise": "noise: [{start: 1s, value: -0.1}]",
+		"negative start": "drift: [{start: -1s, value: 0.1}]",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseScenario([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testScenario), 0o644))
+
+	s, err := LoadScenario(path)
+	require.NoError(t, err)
+	assert.Len(t, s.Pulses, 2)
+	assert.Equal(t, 10*time.Second, s.Loop)
+
+	_, err = LoadScenario(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestMock_Scenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`pulses: [{start: 50ms, duration: 100ms, power: 1000}]`), 0o644))
+
+	run := func() []RawSample {
+		dev := NewMock(&config.MockConfig{
+			SampleRate: 5 * time.Millisecond,
+			Scenario:   path,
+		})
+		require.NoError(t, dev.Connect())
+		defer dev.Close()
+
+		var samples []RawSample
+		for len(samples) < 40 {
+			sample, err := dev.ReadSample(context.Background())
+			require.NoError(t, err)
+			samples = append(samples, sample)
+		}
+		return samples
+	}
+
+	first, second := run(), run()
+	for i := range first {
+		// Timestamps follow the sample clock, 5ms apart
+		assert.Equal(t, first[i].Timestamp.Sub(first[0].Timestamp), time.Duration(i)*5*time.Millisecond)
+		assert.Equal(t, first[i].Reading, second[i].Reading, "sample %d", i)
+	}
+
+	// Flat before the pulse, rising during it
+	assert.Equal(t, first[0].Reading, first[8].Reading)
+	assert.Greater(t, first[30].Reading, first[10].Reading)
+}
+
+func TestMock_Scenario_LoadError(t *testing.T) {
+	dev := NewMock(&config.MockConfig{
+		SampleRate: 5 * time.Millisecond,
+		Scenario:   filepath.Join(t.TempDir(), "missing.yaml"),
+	})
+	assert.Error(t, dev.Connect())
+	assert.False(t, dev.IsConnected())
+}
+
+func TestPatternScenario(t *testing.T) {
+	cfg := &config.MockConfig{
+		LaserDuration: time.Second,
+		LaserPeriod:   4 * time.Second,
+		LaserPattern: []config.MockPulse{
+			{Power: 10},
+			{Power: 20, Duration: 2 * time.Second},
+			{Power: 40, Period: 2 * time.Second},
+			{Power: 80, Duration: 3 * time.Second, Period: 5 * time.Second},
+		},
+	}
+
+	s, err := patternScenario(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Second, s.Loop)
+
+	tests := []struct {
+		at    time.Duration
+		power float64
+	}{
+		{500 * time.Millisecond, 10},
+		{2 * time.Second, 0},
+		{5500 * time.Millisecond, 20},
+		{6500 * time.Millisecond, 0},
+		{8500 * time.Millisecond, 40},
+		{9500 * time.Millisecond, 0},
+		{12500 * time.Millisecond, 80},
+		{14 * time.Second, 0},
+		{15500 * time.Millisecond, 10}, // Repeats
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.power, s.LaserPower(tt.at), "at %v", tt.at)
+	}
+}
+
+func TestPatternScenario_Invalid(t *testing.T) {
+	_, err := patternScenario(&config.MockConfig{
+		LaserPattern: []config.MockPulse{{Power: 10, Duration: 2 * time.Second, Period: time.Second}},
+	})
+	assert.Error(t, err)
+
+	_, err = patternScenario(&config.MockConfig{
+		LaserPattern: []config.MockPulse{{Power: -10, Duration: time.Second, Period: time.Second}},
+	})
+	assert.Error(t, err)
+
+	dev := NewMock(&config.MockConfig{
+		SampleRate:   5 * time.Millisecond,
+		LaserPattern: []config.MockPulse{{Power: 10}},
+	})
+	assert.Error(t, dev.Connect(), "zero pulse duration")
+}
+
+func TestMock_LaserPattern(t *testing.T) {
+	dev := NewMock(&config.MockConfig{
+		SampleRate: 5 * time.Millisecond,
+		LaserPattern: []config.MockPulse{
+			{Power: 500, Duration: 50 * time.Millisecond, Period: 100 * time.Millisecond},
+			{Power: 2000, Duration: 50 * time.Millisecond, Period: 100 * time.Millisecond},
+		},
+		BodyTimeConstant: 100 * time.Millisecond,
+	})
+	require.NoError(t, dev.Connect())
+	defer dev.Close()
+
+	readings := make([]uint16, 40)
+	for i := range readings {
+		sample, err := dev.ReadSample(context.Background())
+		require.NoError(t, err)
+		readings[i] = sample.Reading
+	}
+
+	// Each pulse heats during its first 50ms; the stronger one heats more
+	rise1 := int(readings[9]) - int(readings[0])
+	rise2 := int(readings[29]) - int(readings[19])
+	assert.Greater(t, rise1, 0)
+	assert.Greater(t, rise2, 3*rise1)
+}
@@ -0,0 +1,91 @@
+package lpm
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// SelfTestCheck is the result of one self-test check.
+type SelfTestCheck struct {
+	Name   string // Checked channel: "supply", "absorber", "ambient", "heater1".."heater3"
+	Value  int    // ADC reading, or the supply drop in ADC counts for heaters
+	Passed bool
+}
+
+// SelfTestReport lists the checks of a self-test in the order the firmware ran them.
+type SelfTestReport []SelfTestCheck
+
+// Passed reports whether every check passed.
+func (r SelfTestReport) Passed() bool {
+	for _, c := range r {
+		if !c.Passed {
+			return false
+		}
+	}
+	return len(r) > 0
+}
+
+// SelfTest runs the MCU self-test: the ADC inputs are checked for being
+// stuck at a rail, and each heater is switched on alone while the supply
+// sag is measured. No samples are output during the test, which takes about
+// a second; the heaters are back at their duties afterwards.
+// Requires protocol version 14 or later.
+func (d *Serial) SelfTest() (SelfTestReport, error) {
+	if v := d.Info().ProtocolVersion; v < selfTestProtocolVersion {
+		return nil, fmt.Errorf("self-test requires protocol v%d, device reports v%d", selfTestProtocolVersion, v)
+	}
+
+	// Not retried: a lost report would run the heaters through the test again
+	reply, err := d.submit(&command{line: selfTestCommand, timeout: SelfTestTimeout, match: matchSelfTest})
+	if err != nil {
+		return nil, fmt.Errorf("self-test: %w", err)
+	}
+
+	report, err := parseSelfTest(reply)
+	if err != nil {
+		return nil, fmt.Errorf("self-test: %w", err)
+	}
+
+	log.Printf("Self-test on %s: %s", d.port, strings.TrimSpace(strings.TrimPrefix(reply, selfTestPrefix)))
+
+	return report, nil
+}
+
+// matchSelfTest accepts "#TEST" replies and turns "#ERR" replies into errors.
+func matchSelfTest(line string) (bool, error) {
+	switch {
+	case strings.HasPrefix(line, selfTestPrefix):
+		return true, nil
+	case strings.HasPrefix(line, nakPrefix):
+		return matchAck(line)
+	}
+	return false, nil
+}
+
+// parseSelfTest parses a "#TEST supply=52000,ok heater1=12,fail ..." report.
+func parseSelfTest(line string) (SelfTestReport, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != selfTestPrefix {
+		return nil, fmt.Errorf("not a self-test report: %q", line)
+	}
+
+	report := make(SelfTestReport, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		name, result, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid self-test check %q", field)
+		}
+		value, verdict, ok := strings.Cut(result, ",")
+		if !ok || (verdict != "ok" && verdict != "fail") {
+			return nil, fmt.Errorf("invalid self-test check %q", field)
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: %w", name, value, err)
+		}
+		report = append(report, SelfTestCheck{Name: name, Value: v, Passed: verdict == "ok"})
+	}
+	return report, nil
+}
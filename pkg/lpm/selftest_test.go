@@ -0,0 +1,61 @@
+package lpm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const selfTestReply = "#TEST supply=40000,ok absorber=32000,ok ambient=30000,ok heater1=5,ok heater2=20,ok heater3=9000,fail"
+
+// firmwareV14 answers like protocol version 14 firmware with a self-test
+// that finds a shorted third heater.
+func firmwareV14(cmd string) string {
+	switch cmd {
+	case "V?\n":
+		return "#INFO proto=14 channels=3 rate=50.0 avg=20 interval=1\n"
+	case "X\n":
+		return selfTestReply + "\n"
+	}
+	return firmwareV13(cmd)
+}
+
+func TestSerial_SelfTest(t *testing.T) {
+	port := newFakePort(firmwareV14)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	report, err := dev.SelfTest()
+	require.NoError(t, err)
+	require.Len(t, report, 6)
+	assert.Equal(t, SelfTestCheck{Name: "supply", Value: 40000, Passed: true}, report[0])
+	assert.Equal(t, SelfTestCheck{Name: "heater3", Value: 9000, Passed: false}, report[5])
+	assert.False(t, report.Passed())
+	assert.True(t, report[:5].Passed())
+}
+
+func TestSerial_SelfTest_Unsupported(t *testing.T) {
+	port := newFakePort(firmwareV13)
+	dev, err := connectFake(port)
+	require.NoError(t, err)
+	defer dev.Close()
+
+	_, err = dev.SelfTest()
+	assert.ErrorContains(t, err, "requires protocol")
+	assert.NotContains(t, port.commands(), "X\n")
+}
+
+func TestParseSelfTest(t *testing.T) {
+	report, err := parseSelfTest(selfTestReply)
+	require.NoError(t, err)
+	assert.Len(t, report, 6)
+
+	for _, line := range []string{"#OK", "#TEST supply", "#TEST supply=1", "#TEST supply=1,maybe", "#TEST supply=x,ok"} {
+		_, err := parseSelfTest(line)
+		assert.Error(t, err, line)
+	}
+
+	assert.False(t, SelfTestReport{}.Passed(), "An empty report did not test anything")
+}
@@ -0,0 +1,65 @@
+package lpm
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Stats holds link counters for a device, cumulative over the device's lifetime.
+type Stats struct {
+	BytesRead   uint64 // Bytes received from the link
+	Parsed      uint64 // Samples parsed successfully (text lines or binary frames)
+	ParseErrors uint64 // Malformed lines and corrupted frames
+	Dropped     uint64 // Samples dropped because a samples channel was full
+	Lost        uint64 // Samples the MCU sent that never arrived, from sequence gaps
+	Reconnects  uint64 // Successful connects of this device after its first one
+	Resets      uint64 // MCU restarts during a connection, announced by the firmware
+
+	Retries         uint64 // Commands resent after a timeout or write error
+	CommandFailures uint64 // Commands that failed after all retries
+}
+
+// linkStats holds the live counters behind Stats, safe for concurrent use.
+type linkStats struct {
+	bytesRead   atomic.Uint64
+	parsed      atomic.Uint64
+	parseErrors atomic.Uint64
+	dropped     atomic.Uint64
+	lost        atomic.Uint64
+	connects    atomic.Uint64
+	resets      atomic.Uint64
+
+	retries         atomic.Uint64
+	commandFailures atomic.Uint64
+}
+
+// snapshot returns the current counter values.
+func (s *linkStats) snapshot() Stats {
+	stats := Stats{
+		BytesRead:   s.bytesRead.Load(),
+		Parsed:      s.parsed.Load(),
+		ParseErrors: s.parseErrors.Load(),
+		Dropped:     s.dropped.Load(),
+		Lost:        s.lost.Load(),
+		Resets:      s.resets.Load(),
+
+		Retries:         s.retries.Load(),
+		CommandFailures: s.commandFailures.Load(),
+	}
+	if connects := s.connects.Load(); connects > 1 {
+		stats.Reconnects = connects - 1
+	}
+	return stats
+}
+
+// countingReader counts bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Uint64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(uint64(n))
+	return n, err
+}
@@ -0,0 +1,242 @@
+package lpm
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStaleTimeout is how long a Watchdog waits for a sample before
+// flagging the device as unhealthy.
+const DefaultStaleTimeout = 2 * time.Second
+
+// Watchdog wraps a Device and monitors its sample stream. The MCU streams
+// samples continuously, so every valid sample acts as a heartbeat; when none
+// arrives within the timeout (e.g. the firmware hangs) the device is flagged
+// as unhealthy and the callback is notified. It recovers on the next sample.
+type Watchdog struct {
+	inner    Device
+	timeout  time.Duration
+	onChange func(healthy bool)
+
+	samples    chan RawSample
+	mu         sync.Mutex
+	healthy    bool
+	paused     bool // Sample output paused on purpose, not stale
+	lastSample time.Time
+	done       chan struct{}
+	dropped    atomic.Uint64
+}
+
+// Ensure Watchdog implements Device.
+var _ Device = (*Watchdog)(nil)
+
+// NewWatchdog creates a new Watchdog around inner. A zero timeout uses
+// DefaultStaleTimeout. onChange is called from the watchdog goroutines
+// whenever the health state changes and may be nil.
+func NewWatchdog(inner Device, timeout time.Duration, onChange func(healthy bool)) *Watchdog {
+	if timeout <= 0 {
+		timeout = DefaultStaleTimeout
+	}
+
+	return &Watchdog{
+		inner:    inner,
+		timeout:  timeout,
+		onChange: onChange,
+		samples:  make(chan RawSample, DefaultBufferSize),
+	}
+}
+
+// Connect connects the inner device without a deadline.
+func (w *Watchdog) Connect() error {
+	return w.ConnectContext(context.Background())
+}
+
+// ConnectContext connects the inner device with ctx and starts monitoring the stream.
+// Cancelling ctx shuts down the inner device, which ends the stream.
+// The device is considered healthy until the first timeout expires.
+func (w *Watchdog) ConnectContext(ctx context.Context) error {
+	if err := w.inner.ConnectContext(ctx); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.healthy = true
+	w.paused = false
+	w.lastSample = time.Now()
+	w.mu.Unlock()
+
+	// The previous connection closed the samples channel
+	if w.done != nil {
+		w.samples = make(chan RawSample, DefaultBufferSize)
+	}
+	w.done = make(chan struct{})
+	go w.forward()
+	go w.monitor()
+
+	return nil
+}
+
+// Close closes the inner device and waits for the stream to drain.
+func (w *Watchdog) Close() error {
+	err := w.inner.Close()
+	if w.done != nil {
+		<-w.done
+	}
+	return err
+}
+
+// Samples returns the channel for reading samples.
+func (w *Watchdog) Samples() <-chan RawSample {
+	return w.samples
+}
+
+// ReadSample returns the next sample. Returns io.EOF once the stream has ended
+// and ErrNotConnected if the device was never connected.
+func (w *Watchdog) ReadSample(ctx context.Context) (RawSample, error) {
+	if w.done == nil {
+		return RawSample{}, ErrNotConnected
+	}
+	return readSample(ctx, w.samples)
+}
+
+// SetHeaters forwards the heater command to the inner device.
+func (w *Watchdog) SetHeaters(states ...bool) error {
+	return w.inner.SetHeaters(states...)
+}
+
+// SetHeaterDuty forwards the heater duties to the inner device.
+func (w *Watchdog) SetHeaterDuty(duties ...float64) error {
+	return w.inner.SetHeaterDuty(duties...)
+}
+
+// SetSampleRate forwards the sample rate to the inner device.
+func (w *Watchdog) SetSampleRate(hz float64) error {
+	return w.inner.SetSampleRate(hz)
+}
+
+// SetStreaming forwards pausing or resuming the sample output to the inner
+// device. A paused stream is not flagged as stale; the timeout starts over
+// on resume.
+func (w *Watchdog) SetStreaming(enabled bool) error {
+	if err := w.inner.SetStreaming(enabled); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.paused = !enabled
+	w.lastSample = time.Now()
+	w.mu.Unlock()
+
+	return nil
+}
+
+// OnRaw passes the handler for the received bytes on to the inner device,
+// if it is a RawSource.
+func (w *Watchdog) OnRaw(fn func(data []byte)) bool {
+	src, ok := w.inner.(RawSource)
+	return ok && src.OnRaw(fn)
+}
+
+// IsConnected returns whether the inner device is connected.
+func (w *Watchdog) IsConnected() bool {
+	return w.inner.IsConnected()
+}
+
+// Info returns the inner device's info.
+func (w *Watchdog) Info() Info {
+	return w.inner.Info()
+}
+
+// Stats returns the inner device's link counters, including samples
+// dropped by this wrapper.
+func (w *Watchdog) Stats() Stats {
+	stats := w.inner.Stats()
+	stats.Dropped += w.dropped.Load()
+	return stats
+}
+
+// Healthy returns whether a sample arrived within the timeout.
+func (w *Watchdog) Healthy() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.healthy
+}
+
+// LastSample returns when the last sample was received.
+func (w *Watchdog) LastSample() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastSample
+}
+
+// forward copies samples from the inner device, recording each as a heartbeat.
+// The output channel is closed when the inner channel closes.
+func (w *Watchdog) forward() {
+	defer close(w.done)
+	defer close(w.samples)
+
+	for sample := range w.inner.Samples() {
+		w.heartbeat()
+
+		select {
+		case w.samples <- sample:
+		default:
+			w.dropped.Add(1)
+			log.Printf("Watchdog samples channel full, dropping sample")
+		}
+	}
+}
+
+// heartbeat records a received sample and recovers from the unhealthy state.
+func (w *Watchdog) heartbeat() {
+	w.mu.Lock()
+	w.lastSample = time.Now()
+	recovered := !w.healthy
+	w.healthy = true
+	w.mu.Unlock()
+
+	if recovered {
+		log.Printf("Device stream recovered")
+		w.notify(true)
+	}
+}
+
+// monitor periodically checks for a stale stream until the stream closes.
+func (w *Watchdog) monitor() {
+	ticker := time.NewTicker(w.timeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// check flags the device as unhealthy if the last sample is older than the timeout.
+func (w *Watchdog) check() {
+	w.mu.Lock()
+	since := time.Since(w.lastSample)
+	stale := w.healthy && !w.paused && since > w.timeout
+	if stale {
+		w.healthy = false
+	}
+	w.mu.Unlock()
+
+	if stale {
+		log.Printf("No samples for %v, device stream is stale", since.Truncate(time.Millisecond))
+		w.notify(false)
+	}
+}
+
+func (w *Watchdog) notify(healthy bool) {
+	if w.onChange != nil {
+		w.onChange(healthy)
+	}
+}
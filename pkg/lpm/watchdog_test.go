@@ -0,0 +1,86 @@
+package lpm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchdog_StaleAndRecover(t *testing.T) {
+	inner := newFakeDevice()
+	changes := make(chan bool, 4)
+	wd := NewWatchdog(inner, 40*time.Millisecond, func(healthy bool) { changes <- healthy })
+
+	require.NoError(t, wd.Connect())
+	assert.True(t, wd.Healthy())
+
+	// No samples: the stream goes stale
+	select {
+	case healthy := <-changes:
+		assert.False(t, healthy)
+	case <-time.After(time.Second):
+		t.Fatal("stale stream was not detected")
+	}
+	assert.False(t, wd.Healthy())
+
+	// A sample recovers the device and is passed through
+	inner.samples <- RawSample{Reading: 42}
+	got := <-wd.Samples()
+	assert.Equal(t, uint16(42), got.Reading)
+
+	select {
+	case healthy := <-changes:
+		assert.True(t, healthy)
+	case <-time.After(time.Second):
+		t.Fatal("recovery was not reported")
+	}
+	assert.True(t, wd.Healthy())
+
+	require.NoError(t, wd.Close())
+	_, ok := <-wd.Samples()
+	assert.False(t, ok, "Channel should be closed")
+}
+
+func TestWatchdog_StaysHealthyWithSamples(t *testing.T) {
+	inner := newFakeDevice()
+	wd := NewWatchdog(inner, 50*time.Millisecond, func(healthy bool) {
+		t.Errorf("unexpected health change to %v", healthy)
+	})
+	require.NoError(t, wd.Connect())
+
+	for range 10 {
+		inner.samples <- RawSample{}
+		<-wd.Samples()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, wd.Healthy())
+
+	require.NoError(t, wd.Close())
+}
+
+func TestWatchdog_PausedIsNotStale(t *testing.T) {
+	inner := newFakeDevice()
+	wd := NewWatchdog(inner, 40*time.Millisecond, func(healthy bool) {
+		t.Errorf("unexpected health change to %v", healthy)
+	})
+	require.NoError(t, wd.Connect())
+
+	require.NoError(t, wd.SetStreaming(false))
+	assert.True(t, inner.paused)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, wd.Healthy())
+
+	// The timeout starts over on resume
+	require.NoError(t, wd.SetStreaming(true))
+	assert.False(t, inner.paused)
+	assert.True(t, wd.Healthy())
+
+	require.NoError(t, wd.Close())
+}
+
+func TestNewWatchdog_DefaultTimeout(t *testing.T) {
+	wd := NewWatchdog(newFakeDevice(), 0, nil)
+	assert.Equal(t, DefaultStaleTimeout, wd.timeout)
+}
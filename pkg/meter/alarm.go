@@ -0,0 +1,108 @@
+package meter
+
+import (
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/lpm"
+)
+
+// AlarmKind identifies the condition of an alarm.
+type AlarmKind int
+
+const (
+	AlarmPowerAbove   AlarmKind = iota // Measured power above the limit
+	AlarmPowerBelow                    // Measured power below the limit
+	AlarmNoSamples                     // No samples received
+	AlarmHeaterStuck                   // A heater stayed on
+	AlarmHeaterCutoff                  // Heaters turned off after staying on too long
+)
+
+// Alarm slots: one per condition, the heater alarm once per heater.
+const (
+	alarmSlotNoSamples = int(AlarmNoSamples)
+	alarmSlotHeater    = int(AlarmHeaterStuck)
+	alarmSlots         = alarmSlotHeater + lpm.MaxHeaters
+)
+
+// AlarmStatus is the state of the measurement the alarms are checked against.
+type AlarmStatus struct {
+	Measuring bool    // Samples are expected: a device is connected and not paused
+	Received  uint64  // Samples received from the device so far
+	Power     float64 // Power of the pulse being measured (W), 0 if none
+	Heaters   []bool  // Heater states, at most lpm.MaxHeaters
+}
+
+// Alarm is a raised alarm.
+type Alarm struct {
+	Kind    AlarmKind
+	Message string
+}
+
+// AlarmMonitor raises the alarms of an AlarmConfig once their condition has
+// held for the configured time. An alarm is raised once, and again only after
+// its condition cleared. The zero value is ready to use; it is not safe for
+// concurrent use.
+type AlarmMonitor struct {
+	since  [alarmSlots]time.Time // When each condition began, zero while it does not hold
+	raised [alarmSlots]bool
+
+	received   uint64    // Samples received at the last check
+	receivedAt time.Time // When the sample count last changed
+}
+
+// Check checks the alarms of cfg against s at now and returns the alarms
+// raised. Call it periodically; conditions are only seen at the checks.
+// While not measuring, all conditions clear.
+func (m *AlarmMonitor) Check(cfg config.AlarmConfig, now time.Time, s AlarmStatus) []Alarm {
+	if !s.Measuring {
+		*m = AlarmMonitor{}
+		return nil
+	}
+
+	var alarms []Alarm
+	power := s.Power * 1000
+	if m.hold(int(AlarmPowerAbove), cfg.PowerAboveMW > 0 && power > cfg.PowerAboveMW, now, cfg.PowerDuration) {
+		alarms = append(alarms, Alarm{AlarmPowerAbove,
+			i18n.Tf("Power %.3f mW above %.3f mW for %s", power, cfg.PowerAboveMW, cfg.PowerDuration)})
+	}
+	if m.hold(int(AlarmPowerBelow), cfg.PowerBelowMW > 0 && power < cfg.PowerBelowMW, now, cfg.PowerDuration) {
+		alarms = append(alarms, Alarm{AlarmPowerBelow,
+			i18n.Tf("Power %.3f mW below %.3f mW for %s", power, cfg.PowerBelowMW, cfg.PowerDuration)})
+	}
+
+	if m.receivedAt.IsZero() || s.Received != m.received {
+		m.received, m.receivedAt = s.Received, now
+	}
+	if m.hold(alarmSlotNoSamples, cfg.NoSamples > 0 && now.Sub(m.receivedAt) >= cfg.NoSamples, now, 0) {
+		alarms = append(alarms, Alarm{AlarmNoSamples,
+			i18n.Tf("No samples received for %s", cfg.NoSamples)})
+	}
+
+	for i, on := range s.Heaters[:min(len(s.Heaters), lpm.MaxHeaters)] {
+		if m.hold(alarmSlotHeater+i, cfg.HeaterStuckOn > 0 && on, now, cfg.HeaterStuckOn) {
+			alarms = append(alarms, Alarm{AlarmHeaterStuck,
+				i18n.Tf("Heater %d on for %s", i+1, cfg.HeaterStuckOn)})
+		}
+	}
+	return alarms
+}
+
+// hold tracks whether the condition of slot holds and reports whether it
+// raises its alarm now: it has held for delay and was not raised yet.
+func (m *AlarmMonitor) hold(slot int, holds bool, now time.Time, delay time.Duration) bool {
+	if !holds {
+		m.since[slot] = time.Time{}
+		m.raised[slot] = false
+		return false
+	}
+	if m.since[slot].IsZero() {
+		m.since[slot] = now
+	}
+	if m.raised[slot] || now.Sub(m.since[slot]) < delay {
+		return false
+	}
+	m.raised[slot] = true
+	return true
+}
@@ -0,0 +1,79 @@
+package meter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlarmMonitor_Power(t *testing.T) {
+	cfg := config.AlarmConfig{PowerAboveMW: 50, PowerBelowMW: 5, PowerDuration: 3 * time.Second}
+	var m AlarmMonitor
+	t0 := time.Now()
+	status := AlarmStatus{Measuring: true, Power: 0.060}
+
+	// Raised once the power stayed above the limit for the duration, once
+	assert.Empty(t, m.Check(cfg, t0, status))
+	assert.Empty(t, m.Check(cfg, t0.Add(2*time.Second), status))
+	alarms := m.Check(cfg, t0.Add(3*time.Second), status)
+	require.Len(t, alarms, 1)
+	assert.Equal(t, AlarmPowerAbove, alarms[0].Kind)
+	assert.Equal(t, "Power 60.000 mW above 50.000 mW for 3s", alarms[0].Message)
+	assert.Empty(t, m.Check(cfg, t0.Add(4*time.Second), status))
+
+	// Clearing re-arms it
+	status.Power = 0.020
+	assert.Empty(t, m.Check(cfg, t0.Add(5*time.Second), status))
+	status.Power = 0.060
+	assert.Empty(t, m.Check(cfg, t0.Add(6*time.Second), status))
+	assert.Len(t, m.Check(cfg, t0.Add(9*time.Second), status), 1)
+
+	// No pulse counts as no power
+	status.Power = 0
+	m.Check(cfg, t0.Add(10*time.Second), status)
+	alarms = m.Check(cfg, t0.Add(13*time.Second), status)
+	require.Len(t, alarms, 1)
+	assert.Equal(t, AlarmPowerBelow, alarms[0].Kind)
+}
+
+func TestAlarmMonitor_NoSamples(t *testing.T) {
+	cfg := config.AlarmConfig{NoSamples: 5 * time.Second}
+	var m AlarmMonitor
+	t0 := time.Now()
+
+	assert.Empty(t, m.Check(cfg, t0, AlarmStatus{Measuring: true, Received: 100}))
+	assert.Empty(t, m.Check(cfg, t0.Add(4*time.Second), AlarmStatus{Measuring: true, Received: 300}))
+	assert.Empty(t, m.Check(cfg, t0.Add(8*time.Second), AlarmStatus{Measuring: true, Received: 300}))
+	alarms := m.Check(cfg, t0.Add(9*time.Second), AlarmStatus{Measuring: true, Received: 300})
+	require.Len(t, alarms, 1)
+	assert.Equal(t, AlarmNoSamples, alarms[0].Kind)
+
+	// Pausing clears all conditions
+	assert.Empty(t, m.Check(cfg, t0.Add(20*time.Second), AlarmStatus{Received: 300}))
+	assert.Empty(t, m.Check(cfg, t0.Add(21*time.Second), AlarmStatus{Measuring: true, Received: 300}))
+}
+
+func TestAlarmMonitor_HeaterStuck(t *testing.T) {
+	cfg := config.AlarmConfig{HeaterStuckOn: time.Minute}
+	var m AlarmMonitor
+	t0 := time.Now()
+
+	assert.Empty(t, m.Check(cfg, t0, AlarmStatus{Measuring: true, Heaters: []bool{false, true, false}}))
+	assert.Empty(t, m.Check(cfg, t0.Add(30*time.Second), AlarmStatus{Measuring: true, Heaters: []bool{true, true, false}}))
+	alarms := m.Check(cfg, t0.Add(time.Minute), AlarmStatus{Measuring: true, Heaters: []bool{true, true, false}})
+	require.Len(t, alarms, 1)
+	assert.Equal(t, "Heater 2 on for 1m0s", alarms[0].Message)
+
+	// Any heater of the device
+	on := []bool{false, false, false, false, true}
+	assert.Empty(t, m.Check(cfg, t0.Add(2*time.Minute), AlarmStatus{Measuring: true, Heaters: on}))
+	alarms = m.Check(cfg, t0.Add(3*time.Minute), AlarmStatus{Measuring: true, Heaters: on})
+	require.Len(t, alarms, 1)
+	assert.Equal(t, "Heater 5 on for 1m0s", alarms[0].Message)
+
+	// Disabled alarms are never raised
+	assert.Empty(t, m.Check(config.AlarmConfig{}, t0.Add(time.Hour), AlarmStatus{Measuring: true, Power: 1, Heaters: []bool{true, true, true}}))
+}
@@ -0,0 +1,170 @@
+package meter
+
+import (
+	"errors"
+	"math"
+
+	"github.com/itohio/golpm/pkg/config"
+)
+
+// ErrTooFewCalibrationPoints is returned by FitCalibration for fewer than
+// two points.
+var ErrTooFewCalibrationPoints = errors.New("need at least 2 calibration points to fit a polynomial")
+
+// FitCalibration fits the power polynomial to calibration points:
+// Power = c0 + c1*slope + c2*slope² + c3*slope³. The degree is reduced when
+// there are fewer than four points; the coefficients are always four, padded
+// with zeros, as MeasurementConfig.PowerPolynomial expects. Returns the
+// coefficients and the R² of the fit.
+func FitCalibration(points []config.CalibrationPoint) ([]float64, float64, error) {
+	n := len(points)
+	if n < 2 {
+		return nil, 0, ErrTooFewCalibrationPoints
+	}
+
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i, point := range points {
+		x[i] = point.Slope
+		y[i] = point.Power
+	}
+
+	coeffs, rSquared := fitPolynomial(x, y, min(3, n-1))
+	for len(coeffs) < 4 {
+		coeffs = append(coeffs, 0.0)
+	}
+	return coeffs, rSquared, nil
+}
+
+// fitPolynomial fits a polynomial of given degree to (x, y) data points.
+// Returns coefficients [c0, c1, c2, ...] and R² value.
+// Uses least squares method with normal equations.
+func fitPolynomial(x, y []float64, degree int) ([]float64, float64) {
+	n := len(x)
+	if n != len(y) {
+		panic("x and y must have the same length")
+	}
+	if n < degree+1 {
+		panic("need at least degree+1 points")
+	}
+
+	// Build Vandermonde matrix and solve normal equations
+	// X = [1, x, x², x³, ...]
+	// We need to solve: X^T * X * c = X^T * y
+	// This gives us the least squares solution
+
+	// Build X^T * X (symmetric matrix)
+	size := degree + 1
+	XTX := make([][]float64, size)
+	for i := range XTX {
+		XTX[i] = make([]float64, size)
+	}
+
+	for i := range size {
+		for j := range size {
+			sum := 0.0
+			for k := range n {
+				sum += math.Pow(x[k], float64(i+j))
+			}
+			XTX[i][j] = sum
+		}
+	}
+
+	// Build X^T * y
+	XTy := make([]float64, size)
+	for i := range size {
+		sum := 0.0
+		for k := range n {
+			sum += math.Pow(x[k], float64(i)) * y[k]
+		}
+		XTy[i] = sum
+	}
+
+	// Solve linear system using Gaussian elimination
+	coeffs := solveLinearSystem(XTX, XTy)
+
+	// Calculate R²
+	// R² = 1 - (SS_res / SS_tot)
+	// SS_res = sum of squared residuals
+	// SS_tot = total sum of squares
+
+	// Calculate mean of y
+	meanY := 0.0
+	for _, yi := range y {
+		meanY += yi
+	}
+	meanY /= float64(n)
+
+	// Calculate SS_tot and SS_res
+	ssTot := 0.0
+	ssRes := 0.0
+	for i := range n {
+		// Predicted value
+		yPred := 0.0
+		for j, c := range coeffs {
+			yPred += c * math.Pow(x[i], float64(j))
+		}
+
+		ssTot += math.Pow(y[i]-meanY, 2)
+		ssRes += math.Pow(y[i]-yPred, 2)
+	}
+
+	rSquared := 1.0
+	if ssTot > 0 {
+		rSquared = 1.0 - (ssRes / ssTot)
+	}
+
+	return coeffs, rSquared
+}
+
+// solveLinearSystem solves Ax = b using Gaussian elimination with partial pivoting.
+func solveLinearSystem(A [][]float64, b []float64) []float64 {
+	n := len(b)
+
+	// Create augmented matrix [A|b]
+	aug := make([][]float64, n)
+	for i := range n {
+		aug[i] = make([]float64, n+1)
+		copy(aug[i], A[i])
+		aug[i][n] = b[i]
+	}
+
+	// Forward elimination with partial pivoting
+	for i := range n {
+		// Find pivot
+		maxRow := i
+		for k := i + 1; k < n; k++ {
+			if math.Abs(aug[k][i]) > math.Abs(aug[maxRow][i]) {
+				maxRow = k
+			}
+		}
+
+		// Swap rows
+		aug[i], aug[maxRow] = aug[maxRow], aug[i]
+
+		// Make all rows below this one 0 in current column
+		for k := i + 1; k < n; k++ {
+			if aug[i][i] == 0 {
+				continue
+			}
+			factor := aug[k][i] / aug[i][i]
+			for j := i; j <= n; j++ {
+				aug[k][j] -= factor * aug[i][j]
+			}
+		}
+	}
+
+	// Back substitution
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		x[i] = aug[i][n]
+		for j := i + 1; j < n; j++ {
+			x[i] -= aug[i][j] * x[j]
+		}
+		if aug[i][i] != 0 {
+			x[i] /= aug[i][i]
+		}
+	}
+
+	return x
+}
@@ -1,8 +1,10 @@
-package main
+package meter
 
 import (
 	"testing"
 
+	"github.com/itohio/golpm/pkg/config"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -103,3 +105,21 @@ func TestSolveLinearSystem_3x3(t *testing.T) {
 	assert.InDelta(t, 3.0, x[1], 0.0001)
 	assert.InDelta(t, -1.0, x[2], 0.0001)
 }
+
+func TestFitCalibration(t *testing.T) {
+	_, _, err := FitCalibration([]config.CalibrationPoint{{Slope: 0.001, Power: 10}})
+	assert.ErrorIs(t, err, ErrTooFewCalibrationPoints)
+
+	// Two points fit a line, padded to four coefficients
+	coeffs, rSquared, err := FitCalibration([]config.CalibrationPoint{
+		{Slope: 0.001, Power: 10},
+		{Slope: 0.003, Power: 30},
+	})
+	require.NoError(t, err)
+	require.Len(t, coeffs, 4)
+	assert.InDelta(t, 0.0, coeffs[0], 1e-6)
+	assert.InDelta(t, 10000.0, coeffs[1], 1e-3)
+	assert.Zero(t, coeffs[2])
+	assert.Zero(t, coeffs[3])
+	assert.InDelta(t, 1.0, rSquared, 1e-9)
+}
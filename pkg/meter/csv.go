@@ -0,0 +1,138 @@
+package meter
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// csvTimeFormat is the ISO 8601 timestamp format of the CSV files.
+const csvTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// WriteSamplesCSV writes samples with their derivatives to w as CSV with a
+// header row, in SI units. derivatives are as returned by Samples and
+// Derivatives: derivatives[i] lies between samples i and i+1, so it is
+// written with sample i+1 and the first sample has none. Values a device
+// does not measure, NaN, are left empty. There is a column for each heater
+// the samples report.
+func WriteSamplesCSV(w io.Writer, samples []sample.Sample, derivatives []float64) error {
+	heaters := 0
+	for _, s := range samples {
+		heaters = max(heaters, len(s.Heaters))
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(samplesCSVHeader(heaters))
+	for i, s := range samples {
+		derivative := math.NaN()
+		if i > 0 && i-1 < len(derivatives) {
+			derivative = derivatives[i-1]
+		}
+		cw.Write(sampleCSVRow(s, derivative, heaters))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WritePulsesCSV writes pulses to w as CSV with a header row, in SI units:
+// the detected and fitted ranges and the fit results.
+func WritePulsesCSV(w io.Writer, pulses []Pulse) error {
+	cw := csv.NewWriter(w)
+	cw.Write(pulsesCSVHeader)
+	for _, p := range pulses {
+		cw.Write(pulseCSVRow(p))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// samplesCSVHeader returns the header row of samples CSV files with the
+// given number of heater columns.
+func samplesCSVHeader(heaters int) []string {
+	header := []string{"time", "reading_v", "derivative_v_s", "voltage_v", "heater_power_w"}
+	for i := range heaters {
+		header = append(header, "heater"+strconv.Itoa(i+1))
+	}
+	return append(header, "ambient_c", "reading2_v")
+}
+
+// sampleCSVRow returns the row of s with its derivative, NaN if it has none,
+// and the given number of heater columns; heaters s does not report are off.
+func sampleCSVRow(s sample.Sample, derivative float64, heaters int) []string {
+	row := []string{
+		s.Timestamp.Format(csvTimeFormat),
+		formatCSVFloat(s.Reading),
+		formatCSVFloat(derivative),
+		formatCSVFloat(s.Voltage),
+		formatCSVFloat(s.HeaterPower),
+	}
+	for i := range heaters {
+		row = append(row, formatCSVBool(i < len(s.Heaters) && s.Heaters[i]))
+	}
+	return append(row, formatCSVFloat(s.Ambient), formatCSVFloat(s.Reading2))
+}
+
+// pulsesCSVHeader is the header row of pulses CSV files.
+var pulsesCSVHeader = []string{"id", "state", "detect_start", "detect_end", "fit_start", "fit_end",
+	"power_w", "slope_v_s", "heater_power_w", "r_squared", "stddev_v_s"}
+
+// pulseCSVRow returns the row of p.
+func pulseCSVRow(p Pulse) []string {
+	return []string{
+		strconv.Itoa(p.ID),
+		p.State.String(),
+		formatCSVTime(p.DetectStartTime),
+		formatCSVTime(p.DetectEndTime),
+		formatCSVTime(p.StartTime),
+		formatCSVTime(p.EndTime),
+		formatCSVFloat(p.AvgPower),
+		formatCSVFloat(p.AvgSlope),
+		formatCSVFloat(p.AvgHeaterPower),
+		formatCSVFloat(p.RSquared),
+		formatCSVFloat(p.StdDev),
+	}
+}
+
+// formatCSVFloat formats v without loss, empty for NaN.
+func formatCSVFloat(v float64) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatCSVBool formats b as 1 or 0.
+func formatCSVBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// formatCSVTime formats t, empty if unset.
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(csvTimeFormat)
+}
+
+// WriteSessionStatsCSV writes s to w as CSV of name and value rows with a
+// header row, in SI units.
+func WriteSessionStatsCSV(w io.Writer, s SessionStats) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"statistic", "value"})
+	cw.Write([]string{"pulses", strconv.Itoa(s.Pulses)})
+	cw.Write([]string{"mean_power_w", formatCSVFloat(s.MeanPower)})
+	cw.Write([]string{"stddev_power_w", formatCSVFloat(s.StdDevPower)})
+	cw.Write([]string{"max_power_w", formatCSVFloat(s.MaxPower)})
+	cw.Write([]string{"energy_j", formatCSVFloat(s.Energy)})
+	cw.Write([]string{"duration_s", formatCSVFloat(s.Duration.Seconds())})
+	cw.Write([]string{"drift_v", formatCSVFloat(s.Drift)})
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,89 @@
+package meter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSamplesCSV(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	samples := []sample.Sample{
+		{Timestamp: t0, Reading: 0.001, Voltage: 5, Ambient: math.NaN(), Reading2: math.NaN()},
+		{Timestamp: t0.Add(10 * time.Millisecond), Reading: 0.002, Voltage: 5, HeaterPower: 0.05, Heaters: []bool{true, false, true}, Ambient: 21.5, Reading2: math.NaN()},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteSamplesCSV(&out, samples, []float64{0.1}))
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"time", "reading_v", "derivative_v_s", "voltage_v", "heater_power_w",
+		"heater1", "heater2", "heater3", "ambient_c", "reading2_v"}, rows[0])
+	assert.Equal(t, []string{"2024-05-01T14:03:07.000000Z", "0.001", "", "5", "0", "0", "0", "0", "", ""}, rows[1])
+	assert.Equal(t, []string{"2024-05-01T14:03:07.010000Z", "0.002", "0.1", "5", "0.05", "1", "0", "1", "21.5", ""}, rows[2])
+}
+
+func TestWriteSamplesCSV_HeaterColumns(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	samples := []sample.Sample{
+		{Timestamp: t0, Heaters: []bool{true}, Ambient: math.NaN(), Reading2: math.NaN()},
+		{Timestamp: t0, Heaters: []bool{false, false, false, false, true}, Ambient: math.NaN(), Reading2: math.NaN()},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteSamplesCSV(&out, samples, nil))
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"heater1", "heater2", "heater3", "heater4", "heater5", "ambient_c"}, rows[0][5:11])
+	assert.Equal(t, []string{"1", "0", "0", "0", "0"}, rows[1][5:10], "missing heaters are off")
+	assert.Equal(t, []string{"0", "0", "0", "0", "1"}, rows[2][5:10])
+}
+
+func TestWritePulsesCSV(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	pulses := []Pulse{
+		{ID: 3, State: PulseStateFinalized, DetectStartTime: t0, DetectEndTime: t0.Add(3 * time.Second),
+			StartTime: t0.Add(time.Second), EndTime: t0.Add(2 * time.Second),
+			AvgPower: 0.0125, AvgSlope: 0.002, RSquared: 0.99, StdDev: 0.0001},
+		{ID: 4, State: PulseStateUpdating, DetectStartTime: t0.Add(5 * time.Second)},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WritePulsesCSV(&out, pulses))
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"id", "state", "detect_start", "detect_end", "fit_start", "fit_end",
+		"power_w", "slope_v_s", "heater_power_w", "r_squared", "stddev_v_s"}, rows[0])
+	assert.Equal(t, []string{"3", "finalized", "2024-05-01T14:03:07.000000Z", "2024-05-01T14:03:10.000000Z",
+		"2024-05-01T14:03:08.000000Z", "2024-05-01T14:03:09.000000Z", "0.0125", "0.002", "0", "0.99", "0.0001"}, rows[1])
+	assert.Equal(t, []string{"4", "updating", "2024-05-01T14:03:12.000000Z", "", "", "", "0", "0", "0", "0", "0"}, rows[2])
+}
+
+func TestWriteSessionStatsCSV(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteSessionStatsCSV(&out, SessionStats{
+		Pulses: 2, MeanPower: 0.01, StdDevPower: 0.001, MaxPower: 0.011, Energy: 0.02,
+		Duration: 90 * time.Second, Drift: math.NaN(),
+	}))
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"statistic", "value"},
+		{"pulses", "2"},
+		{"mean_power_w", "0.01"},
+		{"stddev_power_w", "0.001"},
+		{"max_power_w", "0.011"},
+		{"energy_j", "0.02"},
+		{"duration_s", "90"},
+		{"drift_v", ""},
+	}, rows)
+}
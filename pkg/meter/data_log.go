@@ -0,0 +1,195 @@
+package meter
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// DataLog streams converted samples and pulse events to CSV files while
+// measuring, so long unattended runs are captured. Samples are written to
+// <base>_001.csv, <base>_002.csv, ..., starting a new file once one reaches
+// the size limit; finalized pulses are written to <base>_pulses.csv. The
+// files use the columns of WriteSamplesCSV and WritePulsesCSV, and every row
+// is flushed as it is written, so a crash loses nothing logged before it.
+// The samples files have a column for each heater of the device; when it
+// changes, e.g. on reconnecting to another head, SetHeaterCount starts a new
+// samples file. It is safe for concurrent use.
+type DataLog struct {
+	base    string
+	maxSize int64
+	heaters int // Heater columns of the samples files
+
+	mu        sync.Mutex
+	samples   *countingFile
+	samplesW  *csv.Writer
+	pulses    *countingFile
+	pulsesW   *csv.Writer
+	part      int       // Number of the current samples file
+	first     time.Time // Timestamp of the first sample logged
+	last      time.Time // Timestamp of the last sample logged
+	lastPulse time.Time // Detection start of the last pulse logged
+
+	closedSize int64 // Bytes in the samples files rotated out
+	closed     bool
+}
+
+// DataLogStats describes what a DataLog has written so far.
+type DataLogStats struct {
+	Path     string        // Current samples file
+	Files    int           // Samples files written
+	Size     int64         // Bytes in all files
+	Duration time.Duration // Time between the first and last sample logged
+}
+
+// NewDataLog creates the first samples file and the pulses file of a log
+// named base, a path without extension, with a column for each of heaters.
+// Samples files are rotated once they reach maxSize bytes; 0 disables
+// rotation.
+func NewDataLog(base string, maxSize int64, heaters int) (*DataLog, error) {
+	l := &DataLog{base: base, maxSize: maxSize, heaters: heaters}
+	f, err := os.OpenFile(base+"_pulses.csv", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l.pulses = &countingFile{File: f}
+	l.pulsesW = csv.NewWriter(l.pulses)
+	if err := writeCSVRow(l.pulsesW, pulsesCSVHeader); err != nil {
+		l.pulses.Close()
+		return nil, err
+	}
+	if err := l.rotate(); err != nil {
+		l.pulses.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// LogSample writes s with its derivative, rotating the samples file if it
+// is full.
+func (l *DataLog) LogSample(s sample.Sample) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return os.ErrClosed
+	}
+	if l.maxSize > 0 && l.samples.n >= l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	if l.first.IsZero() {
+		l.first = s.Timestamp
+	}
+	l.last = s.Timestamp
+	return writeCSVRow(l.samplesW, sampleCSVRow(s, s.Change, l.heaters))
+}
+
+// SetHeaterCount sets the number of heater columns. A different number
+// than the current samples file has starts a new file with a matching
+// header, so no file mixes rows of different widths.
+func (l *DataLog) SetHeaterCount(heaters int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return os.ErrClosed
+	}
+	if heaters == l.heaters {
+		return nil
+	}
+	l.heaters = heaters
+	return l.rotate()
+}
+
+// LogPulses writes the finalized pulses not logged yet. Pass every meter
+// update's pulses; pulses are recognized by their detection start, so IDs
+// starting over with a new meter do not matter.
+func (l *DataLog) LogPulses(pulses []Pulse) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return os.ErrClosed
+	}
+	for _, p := range pulses {
+		if p.State != PulseStateFinalized || !p.DetectStartTime.After(l.lastPulse) {
+			continue
+		}
+		if err := writeCSVRow(l.pulsesW, pulseCSVRow(p)); err != nil {
+			return err
+		}
+		l.lastPulse = p.DetectStartTime
+	}
+	return nil
+}
+
+// Stats returns what has been written so far.
+func (l *DataLog) Stats() DataLogStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return DataLogStats{
+		Path:     l.samplesPath(l.part),
+		Files:    l.part,
+		Size:     l.closedSize + l.samples.n + l.pulses.n,
+		Duration: l.last.Sub(l.first),
+	}
+}
+
+// Close closes the files. Logging afterwards fails with os.ErrClosed.
+func (l *DataLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	return errors.Join(l.samples.Close(), l.pulses.Close())
+}
+
+// rotate starts the next samples file and closes the current one, if any.
+func (l *DataLog) rotate() error {
+	f, err := os.OpenFile(l.samplesPath(l.part+1), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if l.samples != nil {
+		if err := l.samples.Close(); err != nil {
+			f.Close()
+			return err
+		}
+		l.closedSize += l.samples.n
+	}
+	l.part++
+	l.samples = &countingFile{File: f}
+	l.samplesW = csv.NewWriter(l.samples)
+	return writeCSVRow(l.samplesW, samplesCSVHeader(l.heaters))
+}
+
+// samplesPath returns the path of samples file number part.
+func (l *DataLog) samplesPath(part int) string {
+	return fmt.Sprintf("%s_%03d.csv", l.base, part)
+}
+
+// writeCSVRow writes row with w and flushes it to the file.
+func writeCSVRow(w *csv.Writer, row []string) error {
+	w.Write(row)
+	w.Flush()
+	return w.Error()
+}
+
+// countingFile is a file counting the bytes written to it.
+type countingFile struct {
+	*os.File
+	n int64
+}
+
+// Write writes p to the file.
+func (f *countingFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	f.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,111 @@
+package meter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readCSVFile reads all rows of a CSV file.
+func readCSVFile(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	return rows
+}
+
+func TestDataLog_Rotation(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "run")
+	l, err := NewDataLog(base, 300, 3)
+	require.NoError(t, err)
+
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	for i := range 10 {
+		require.NoError(t, l.LogSample(sample.Sample{Timestamp: t0.Add(time.Duration(i) * time.Second), Reading: 0.001, Change: 0.01}))
+	}
+	stats := l.Stats()
+	require.NoError(t, l.Close())
+	assert.ErrorIs(t, l.LogSample(sample.Sample{}), os.ErrClosed)
+
+	assert.Equal(t, 9*time.Second, stats.Duration)
+	assert.Greater(t, stats.Files, 1, "samples rotated")
+	assert.Equal(t, fmt.Sprintf("%s_%03d.csv", base, stats.Files), stats.Path)
+
+	// Every file starts with the header and together they hold all samples
+	var size int64
+	samples := 0
+	for part := 1; part <= stats.Files; part++ {
+		path := fmt.Sprintf("%s_%03d.csv", base, part)
+		rows := readCSVFile(t, path)
+		assert.Equal(t, samplesCSVHeader(3), rows[0], path)
+		samples += len(rows) - 1
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		size += info.Size()
+	}
+	assert.Equal(t, 10, samples)
+	assert.Equal(t, "0.01", readCSVFile(t, base+"_001.csv")[1][2], "derivative from Change")
+
+	info, err := os.Stat(base + "_pulses.csv")
+	require.NoError(t, err)
+	assert.Equal(t, size+info.Size(), stats.Size)
+}
+
+func TestDataLog_SetHeaterCount(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "run")
+	l, err := NewDataLog(base, 0, 3)
+	require.NoError(t, err)
+
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	require.NoError(t, l.LogSample(sample.Sample{Timestamp: t0, Heaters: []bool{true, false, true}}))
+	require.NoError(t, l.SetHeaterCount(3))
+	assert.Equal(t, 1, l.Stats().Files, "same heaters, same file")
+
+	// Reconnected to a head with more heaters
+	require.NoError(t, l.SetHeaterCount(5))
+	require.NoError(t, l.LogSample(sample.Sample{Timestamp: t0.Add(time.Second), Heaters: []bool{false, false, false, false, true}}))
+	assert.Equal(t, 2, l.Stats().Files)
+	require.NoError(t, l.Close())
+	assert.ErrorIs(t, l.SetHeaterCount(2), os.ErrClosed)
+
+	rows := readCSVFile(t, base+"_001.csv")
+	require.Len(t, rows, 2)
+	assert.Equal(t, samplesCSVHeader(3), rows[0])
+	rows = readCSVFile(t, base+"_002.csv")
+	require.Len(t, rows, 2)
+	assert.Equal(t, samplesCSVHeader(5), rows[0])
+	assert.Len(t, rows[1], len(rows[0]))
+}
+
+func TestDataLog_Pulses(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "run")
+	l, err := NewDataLog(base, 0, 3)
+	require.NoError(t, err)
+
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	first := Pulse{ID: 1, State: PulseStateFinalized, DetectStartTime: t0, AvgPower: 0.01}
+	second := Pulse{ID: 2, State: PulseStateUpdating, DetectStartTime: t0.Add(5 * time.Second)}
+	require.NoError(t, l.LogPulses([]Pulse{first, second}))
+	require.NoError(t, l.LogPulses([]Pulse{first, second}))
+
+	// A new meter numbers its pulses from 1 again
+	second.ID, second.State = 1, PulseStateFinalized
+	require.NoError(t, l.LogPulses([]Pulse{second}))
+	require.NoError(t, l.Close())
+
+	rows := readCSVFile(t, base+"_pulses.csv")
+	require.Len(t, rows, 3)
+	assert.Equal(t, pulsesCSVHeader, rows[0])
+	assert.Equal(t, "2024-05-01T14:03:07.000000Z", rows[1][2])
+	assert.Equal(t, "2024-05-01T14:03:12.000000Z", rows[2][2])
+}
@@ -74,33 +74,49 @@ type Meter struct {
 // New creates a new PowerMeter instance.
 // Returns concrete type (*Meter) following Go best practices.
 func New(cfg *config.Config) *Meter {
-	minPulseDuration := time.Duration(cfg.Measurement.MinPulseDuration * float64(time.Second))
-	lineFitMinDuration := time.Duration(cfg.Measurement.PulseLineFitMinDuration * float64(time.Second))
-
-	// Use MinPulseDuration for line fitting if PulseLineFitMinDuration is not set or is larger
-	if lineFitMinDuration == 0 || lineFitMinDuration > minPulseDuration {
-		lineFitMinDuration = minPulseDuration
-	}
-
 	m := &Meter{
-		cfg:                   cfg,
 		samples:               make([]sample.Sample, 0),
 		derivatives:           make([]float64, 0),
 		pulses:                make([]Pulse, 0),
 		callbacks:             make([]func(samples []sample.Sample, derivatives []float64, pulses []Pulse), 0),
-		windowDuration:        time.Duration(cfg.Measurement.WindowSeconds * float64(time.Second)),
-		threshold:             cfg.Measurement.PulseThresholdMVS / 1000.0, // Convert mV/s to V/s
-		minPulseDuration:      minPulseDuration,
-		lineFitMinDuration:    lineFitMinDuration,
-		lineFitRangeMVS:       cfg.Measurement.PulseLineFitRangeMVS,
 		absorbanceCoefficient: cfg.Measurement.AbsorbanceCoefficient,
 		powerPolynomial:       cfg.Measurement.PowerPolynomial,
 		shutdown:              false,
 	}
+	m.configure(cfg)
 
 	return m
 }
 
+// Reconfigure applies the window and pulse detection settings of cfg while
+// the meter runs, keeping the buffered samples and pulses. Pulses detected
+// from now on use the new settings; a shorter window drops older samples
+// with the next sample.
+func (m *Meter) Reconfigure(cfg *config.Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.configure(cfg)
+}
+
+// configure sets the window and pulse detection settings from cfg.
+func (m *Meter) configure(cfg *config.Config) {
+	minPulseDuration := cfg.MinPulseWindow()
+	lineFitMinDuration := time.Duration(cfg.Measurement.PulseLineFitMinDuration * float64(time.Second))
+
+	// Use MinPulseDuration for line fitting if PulseLineFitMinDuration is not set or is larger
+	if lineFitMinDuration == 0 || lineFitMinDuration > minPulseDuration {
+		lineFitMinDuration = minPulseDuration
+	}
+
+	m.cfg = cfg
+	m.windowDuration = time.Duration(cfg.Measurement.WindowSeconds * float64(time.Second))
+	m.threshold = cfg.Measurement.PulseThresholdMVS / 1000.0 // Convert mV/s to V/s
+	m.minPulseDuration = minPulseDuration
+	m.lineFitMinDuration = lineFitMinDuration
+	m.lineFitRangeMVS = cfg.Measurement.PulseLineFitRangeMVS
+}
+
 // ProcessSamples processes samples from the input channel in a goroutine.
 // When the input channel closes, it sets shutdown flag to prevent further callbacks.
 func (m *Meter) ProcessSamples(input <-chan sample.Sample) {
@@ -106,6 +106,29 @@ func TestProcessSample_WindowRemoval(t *testing.T) {
 	assert.LessOrEqual(t, len(samples), 2)
 }
 
+func TestReconfigure(t *testing.T) {
+	cfg := config.Default()
+	cfg.Measurement.WindowSeconds = 10.0
+	m := New(cfg)
+
+	now := time.Now()
+	samples := createSamplesWithChange(now, []float64{1.0, 1.1, 1.2, 1.3}, 2.0, 0.0, time.Second)
+	for _, s := range samples[:3] {
+		m.processSample(s)
+	}
+
+	updated := *cfg
+	updated.Measurement.WindowSeconds = 1.5
+	updated.Measurement.PulseThresholdMVS = 250.0
+	m.Reconfigure(&updated)
+	assert.Len(t, m.Samples(), 3, "Buffered samples are kept")
+	assert.InDelta(t, 0.25, m.threshold, 1e-9)
+
+	// The shorter window applies with the next sample
+	m.processSample(samples[3])
+	assert.Len(t, m.Samples(), 2)
+}
+
 // DEPRECATED: Old pulse detection test - now using pulse_behavior_test.go
 func _TestProcessSample_PulseDetection(t *testing.T) {
 	cfg := config.Default()
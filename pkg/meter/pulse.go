@@ -1,6 +1,7 @@
 package meter
 
 import (
+	"fmt"
 	"log"
 	"math"
 	"time"
@@ -17,6 +18,19 @@ const (
 	PulseStateFinalized                   // Finalized: complete, locked in, no longer tracking, only rendered
 )
 
+// String returns the state's name, e.g. "finalized".
+func (s PulseState) String() string {
+	switch s {
+	case PulseStateFitting:
+		return "fitting"
+	case PulseStateUpdating:
+		return "updating"
+	case PulseStateFinalized:
+		return "finalized"
+	}
+	return fmt.Sprintf("PulseState(%d)", int(s))
+}
+
 // Pulse represents a detected heating pulse with self-contained state management.
 type Pulse struct {
 	// Identification
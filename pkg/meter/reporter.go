@@ -0,0 +1,114 @@
+package meter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// Reporter prints each pulse once the meter has finalized it and, every
+// interval of sample time, the latest reading, as text or JSON lines. Register
+// its Update method with OnUpdate.
+type Reporter struct {
+	w        io.Writer
+	json     bool
+	interval time.Duration
+
+	mu          sync.Mutex
+	lastPulse   int       // ID of the newest pulse printed
+	lastReading time.Time // Sample time of the last reading printed
+}
+
+// NewReporter creates a Reporter writing to w, JSON lines if json is set.
+// An interval of 0 reports pulses only.
+func NewReporter(w io.Writer, json bool, interval time.Duration) *Reporter {
+	return &Reporter{w: w, json: json, interval: interval}
+}
+
+// reportedPulse is a measured pulse as printed in JSON.
+type reportedPulse struct {
+	Type        string    `json:"type"` // "pulse"
+	ID          int       `json:"id"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Duration    float64   `json:"duration_s"`
+	Power       float64   `json:"power_w"`
+	Slope       float64   `json:"slope_v_s"`
+	HeaterPower float64   `json:"heater_power_w"`
+	RSquared    float64   `json:"r_squared"`
+}
+
+// reportedReading is a reading as printed in JSON.
+type reportedReading struct {
+	Type        string    `json:"type"` // "reading"
+	Time        time.Time `json:"time"`
+	Reading     float64   `json:"reading_v"`
+	Derivative  float64   `json:"derivative_v_s"`
+	HeaterPower float64   `json:"heater_power_w"`
+}
+
+// Update is the meter callback.
+func (r *Reporter) Update(samples []sample.Sample, derivatives []float64, pulses []Pulse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range pulses {
+		if p.State != PulseStateFinalized || p.ID <= r.lastPulse {
+			continue
+		}
+		r.lastPulse = p.ID
+		r.print(reportedPulse{
+			Type:        "pulse",
+			ID:          p.ID,
+			Start:       p.StartTime,
+			End:         p.EndTime,
+			Duration:    p.EndTime.Sub(p.StartTime).Seconds(),
+			Power:       p.AvgPower,
+			Slope:       p.AvgSlope,
+			HeaterPower: p.AvgHeaterPower,
+			RSquared:    p.RSquared,
+		})
+	}
+
+	if r.interval <= 0 || len(samples) == 0 {
+		return
+	}
+	last := samples[len(samples)-1]
+	if last.Timestamp.Sub(r.lastReading) < r.interval {
+		return
+	}
+	r.lastReading = last.Timestamp
+	reading := reportedReading{Type: "reading", Time: last.Timestamp, Reading: last.Reading, HeaterPower: last.HeaterPower}
+	if len(derivatives) > 0 {
+		reading.Derivative = derivatives[len(derivatives)-1]
+	}
+	r.print(reading)
+}
+
+// print writes v as a line of JSON or text.
+func (r *Reporter) print(v any) {
+	if r.json {
+		if err := json.NewEncoder(r.w).Encode(v); err != nil {
+			log.Printf("Failed to write output: %v", err)
+		}
+		return
+	}
+
+	var line string
+	switch v := v.(type) {
+	case reportedPulse:
+		line = fmt.Sprintf("%s pulse #%d %.3f mW over %.2fs (slope %.3f mV/s, heater %.3f mW, R² %.3f)",
+			v.End.Format("15:04:05.000"), v.ID, v.Power*1000, v.Duration, v.Slope*1000, v.HeaterPower*1000, v.RSquared)
+	case reportedReading:
+		line = fmt.Sprintf("%s reading %.3f mV, %.3f mV/s, heater %.3f mW",
+			v.Time.Format("15:04:05.000"), v.Reading*1000, v.Derivative*1000, v.HeaterPower*1000)
+	}
+	if _, err := fmt.Fprintln(r.w, line); err != nil {
+		log.Printf("Failed to write output: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package meter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReporter_Text(t *testing.T) {
+	var out bytes.Buffer
+	r := NewReporter(&out, false, 0)
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.Local)
+	pulse := Pulse{ID: 2, State: PulseStateUpdating, StartTime: t0, EndTime: t0.Add(2 * time.Second), AvgPower: 0.0125, RSquared: 0.99}
+
+	// Pulses are printed once, when finalized
+	r.Update(nil, nil, []Pulse{pulse})
+	assert.Empty(t, out.String(), "pulse still updating")
+	pulse.State = PulseStateFinalized
+	r.Update(nil, nil, []Pulse{pulse})
+	r.Update(nil, nil, []Pulse{pulse})
+	assert.Equal(t, "14:03:09.000 pulse #2 12.500 mW over 2.00s (slope 0.000 mV/s, heater 0.000 mW, R² 0.990)\n", out.String())
+}
+
+func TestReporter_JSON(t *testing.T) {
+	var out bytes.Buffer
+	r := NewReporter(&out, true, time.Second)
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	samples := []sample.Sample{
+		{Timestamp: t0, Reading: 0.001},
+		{Timestamp: t0.Add(500 * time.Millisecond), Reading: 0.002, HeaterPower: 0.05},
+	}
+
+	// Readings every interval of sample time
+	r.Update(samples[:1], nil, nil)
+	r.Update(samples, []float64{0.002}, nil)
+	r.Update(append(samples, sample.Sample{Timestamp: t0.Add(time.Second), Reading: 0.003}), []float64{0.002, 0.002}, []Pulse{
+		{ID: 1, State: PulseStateFinalized, StartTime: t0, EndTime: t0.Add(time.Second), AvgPower: 0.01},
+	})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	var first, pulse map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "reading", first["type"])
+	assert.Equal(t, 0.001, first["reading_v"])
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &pulse))
+	assert.Equal(t, "pulse", pulse["type"])
+	assert.Equal(t, 0.01, pulse["power_w"])
+	assert.Equal(t, 1.0, pulse["duration_s"])
+	assert.Contains(t, lines[2], `"time":"2024-05-01T14:03:08Z"`)
+}
@@ -0,0 +1,117 @@
+package meter
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// SessionStats summarizes a measurement session. Powers are in W, energy in
+// J and drift in V. Values that are not known yet are NaN.
+type SessionStats struct {
+	Pulses      int           // Finalized pulses measured
+	MeanPower   float64       // Mean pulse power
+	StdDevPower float64       // Standard deviation of the pulse powers
+	MaxPower    float64       // Highest pulse power
+	Energy      float64       // Total energy of all pulses: power over the time the laser was on
+	Duration    time.Duration // Time from the first to the latest sample
+	Drift       float64       // Reading change from the first to the latest sample
+}
+
+// ComputeSessionStats summarizes the samples and the finalized pulses of a
+// session, e.g. one opened from a file.
+func ComputeSessionStats(samples []sample.Sample, pulses []Pulse) SessionStats {
+	var t SessionTracker
+	t.Update(samples, nil, pulses)
+	return t.Stats()
+}
+
+// SessionTracker accumulates the statistics of a session from meter updates.
+// Pulses are counted once they are finalized and kept after they leave the
+// meter's window, and the duration and drift count from the first sample
+// seen. Register its Update method with OnUpdate.
+type SessionTracker struct {
+	mu        sync.Mutex
+	lastID    int     // ID of the newest pulse counted
+	pulses    int     // Pulses counted
+	powerSum  float64 // Sum of the pulse powers
+	powerSum2 float64 // Sum of the squared pulse powers
+	maxPower  float64
+	energy    float64
+	first     sample.Sample // First sample seen, zero before
+	last      sample.Sample // Latest sample seen
+}
+
+// Update is the meter callback.
+func (t *SessionTracker) Update(samples []sample.Sample, derivatives []float64, pulses []Pulse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, p := range pulses {
+		if p.State != PulseStateFinalized || p.ID <= t.lastID {
+			continue
+		}
+		t.lastID = p.ID
+		if t.pulses == 0 || p.AvgPower > t.maxPower {
+			t.maxPower = p.AvgPower
+		}
+		t.pulses++
+		t.powerSum += p.AvgPower
+		t.powerSum2 += p.AvgPower * p.AvgPower
+		t.energy += p.AvgPower * PulseDuration(p).Seconds()
+	}
+
+	if len(samples) == 0 {
+		return
+	}
+	if t.first.Timestamp.IsZero() {
+		t.first = samples[0]
+	}
+	t.last = samples[len(samples)-1]
+}
+
+// Reset starts a new session.
+func (t *SessionTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastID, t.pulses = 0, 0
+	t.powerSum, t.powerSum2, t.maxPower, t.energy = 0, 0, 0, 0
+	t.first, t.last = sample.Sample{}, sample.Sample{}
+}
+
+// Stats returns the statistics so far.
+func (t *SessionTracker) Stats() SessionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := SessionStats{
+		Pulses:      t.pulses,
+		MeanPower:   math.NaN(),
+		StdDevPower: math.NaN(),
+		MaxPower:    math.NaN(),
+		Energy:      t.energy,
+		Drift:       math.NaN(),
+	}
+	if t.pulses > 0 {
+		n := float64(t.pulses)
+		s.MeanPower = t.powerSum / n
+		s.StdDevPower = math.Sqrt(max(t.powerSum2/n-s.MeanPower*s.MeanPower, 0))
+		s.MaxPower = t.maxPower
+	}
+	if !t.first.Timestamp.IsZero() {
+		s.Duration = t.last.Timestamp.Sub(t.first.Timestamp)
+		s.Drift = t.last.Reading - t.first.Reading
+	}
+	return s
+}
+
+// PulseDuration returns how long the laser was on during p: the detected
+// range, or the fitted one if the detection has not ended.
+func PulseDuration(p Pulse) time.Duration {
+	if !p.DetectEndTime.IsZero() && p.DetectEndTime.After(p.DetectStartTime) {
+		return p.DetectEndTime.Sub(p.DetectStartTime)
+	}
+	return p.EndTime.Sub(p.StartTime)
+}
@@ -0,0 +1,47 @@
+package meter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionTracker(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	var tracker SessionTracker
+
+	stats := tracker.Stats()
+	assert.Zero(t, stats.Pulses)
+	assert.True(t, math.IsNaN(stats.MeanPower), "no pulses yet")
+	assert.True(t, math.IsNaN(stats.Drift), "no samples yet")
+
+	pulse := func(id int, power float64, state PulseState) Pulse {
+		start := t0.Add(time.Duration(id) * time.Second)
+		return Pulse{ID: id, State: state, AvgPower: power, DetectStartTime: start, DetectEndTime: start.Add(2 * time.Second)}
+	}
+	tracker.Update([]sample.Sample{{Timestamp: t0, Reading: 0.010}, {Timestamp: t0.Add(time.Second), Reading: 0.011}}, nil,
+		[]Pulse{pulse(1, 0.010, PulseStateFinalized), pulse(2, 0.020, PulseStateUpdating)})
+	// The meter's window moved on: pulse 1 left it, pulse 2 is finalized
+	tracker.Update([]sample.Sample{{Timestamp: t0.Add(10 * time.Second), Reading: 0.012}}, nil,
+		[]Pulse{pulse(2, 0.030, PulseStateFinalized)})
+
+	stats = tracker.Stats()
+	assert.Equal(t, 2, stats.Pulses)
+	assert.InDelta(t, 0.020, stats.MeanPower, 1e-12)
+	assert.InDelta(t, 0.010, stats.StdDevPower, 1e-12)
+	assert.Equal(t, 0.030, stats.MaxPower)
+	assert.InDelta(t, 0.080, stats.Energy, 1e-12, "power over the detected durations")
+	assert.Equal(t, 10*time.Second, stats.Duration)
+	assert.InDelta(t, 0.002, stats.Drift, 1e-12)
+
+	// Pulses are counted once
+	tracker.Update(nil, nil, []Pulse{pulse(2, 0.030, PulseStateFinalized)})
+	assert.Equal(t, 2, tracker.Stats().Pulses)
+
+	tracker.Reset()
+	assert.Zero(t, tracker.Stats().Pulses)
+	assert.Zero(t, tracker.Stats().Duration)
+}
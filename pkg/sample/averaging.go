@@ -77,26 +77,31 @@ func averageAndConvertSamples(samples []lpm.RawSample, cfg *config.Config) (Samp
 		return Sample{}, nil
 	}
 
-	var sumReading, sumVoltage uint32
+	var sumReading, sumVoltage, sumAmbient, sumReading2 uint32
 	lastSample := samples[len(samples)-1]
 
 	for _, s := range samples {
 		sumReading += uint32(s.Reading)
 		sumVoltage += uint32(s.Voltage)
+		sumAmbient += uint32(s.Ambient)
+		sumReading2 += uint32(s.Reading2)
 	}
 
 	n := float64(len(samples))
 	avgReadingADC := uint16((float64(sumReading) / n) + 0.5) // Round to nearest
 	avgVoltageADC := uint16((float64(sumVoltage) / n) + 0.5)
+	avgAmbientADC := uint16((float64(sumAmbient) / n) + 0.5)
+	avgReading2ADC := uint16((float64(sumReading2) / n) + 0.5)
 
 	// Create averaged RawSample and convert
 	avgRaw := lpm.RawSample{
-		Timestamp: lastSample.Timestamp,
-		Reading:   avgReadingADC,
-		Voltage:   avgVoltageADC,
-		Heater1:   lastSample.Heater1, // Use most recent heater states
-		Heater2:   lastSample.Heater2,
-		Heater3:   lastSample.Heater3,
+		Timestamp:   lastSample.Timestamp,
+		Reading:     avgReadingADC,
+		Voltage:     avgVoltageADC,
+		Heaters:     lastSample.Heaters, // Use most recent heater states
+		Ambient:     avgAmbientADC,
+		Reading2:    avgReading2ADC,
+		HasReading2: lastSample.HasReading2,
 	}
 
 	return convertSample(avgRaw, cfg)
@@ -179,11 +184,12 @@ func averageConvertedSamples(samples []Sample, fields FieldFlags) Sample {
 		fields = FieldReading | FieldVoltage | FieldHeaterPower
 	}
 
-	var sumReading, sumChange, sumVoltage, sumPower float64
+	var sumReading, sumReading2, sumChange, sumVoltage, sumPower float64
 	lastSample := samples[len(samples)-1]
 
 	for _, s := range samples {
 		sumReading += s.Reading
+		sumReading2 += s.Reading2
 		sumChange += s.Change
 		sumVoltage += s.Voltage
 		sumPower += s.HeaterPower
@@ -192,13 +198,18 @@ func averageConvertedSamples(samples []Sample, fields FieldFlags) Sample {
 	n := float64(len(samples))
 	result := Sample{
 		Timestamp: lastSample.Timestamp,
+		Ambient:   lastSample.Ambient, // Changes slowly, never averaged
+		Heaters:   lastSample.Heaters,
 	}
 
-	// Only average specified fields, copy others from last sample
+	// Only average specified fields, copy others from last sample.
+	// The second absorber follows the first.
 	if HasField(fields, FieldReading) {
 		result.Reading = sumReading / n
+		result.Reading2 = sumReading2 / n
 	} else {
 		result.Reading = lastSample.Reading
+		result.Reading2 = lastSample.Reading2
 	}
 	if HasField(fields, FieldChange) {
 		result.Change = sumChange / n
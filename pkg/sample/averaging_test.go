@@ -25,9 +25,7 @@ func TestNewAveragingConverter_BasicAveraging(t *testing.T) {
 			Timestamp: now.Add(time.Duration(i) * time.Millisecond),
 			Reading:   uint16(1000 + i*100),
 			Voltage:   uint16(2000 + i*100),
-			Heater1:   i%2 == 0,
-			Heater2:   false,
-			Heater3:   false,
+			Heaters:   []bool{i%2 == 0, false, false},
 		}
 	}
 
@@ -68,9 +66,7 @@ func TestNewAveragingConverter_WindowSize(t *testing.T) {
 			Timestamp: now.Add(time.Duration(i) * time.Millisecond),
 			Reading:   constValue,
 			Voltage:   constValue,
-			Heater1:   false,
-			Heater2:   false,
-			Heater3:   false,
+			Heaters:   []bool{false, false, false},
 		}
 	}
 
@@ -112,9 +108,7 @@ func TestNewAveragingConverter_InvalidWindowSize(t *testing.T) {
 		Timestamp: now,
 		Reading:   2047,
 		Voltage:   2047,
-		Heater1:   false,
-		Heater2:   false,
-		Heater3:   false,
+		Heaters:   []bool{false, false, false},
 	}
 
 	time.Sleep(150 * time.Millisecond)
@@ -150,9 +144,7 @@ func TestAverageAndConvertSamples(t *testing.T) {
 					Timestamp: now,
 					Reading:   2047,
 					Voltage:   2047,
-					Heater1:   false,
-					Heater2:   false,
-					Heater3:   false,
+					Heaters:   []bool{false, false, false},
 				},
 			},
 			wantErr: false,
@@ -164,25 +156,19 @@ func TestAverageAndConvertSamples(t *testing.T) {
 					Timestamp: now,
 					Reading:   1000,
 					Voltage:   2000,
-					Heater1:   false,
-					Heater2:   false,
-					Heater3:   false,
+					Heaters:   []bool{false, false, false},
 				},
 				{
 					Timestamp: now.Add(time.Millisecond),
 					Reading:   1100,
 					Voltage:   2100,
-					Heater1:   true,
-					Heater2:   false,
-					Heater3:   false,
+					Heaters:   []bool{true, false, false},
 				},
 				{
 					Timestamp: now.Add(2 * time.Millisecond),
 					Reading:   1200,
 					Voltage:   2200,
-					Heater1:   true,
-					Heater2:   true,
-					Heater3:   false,
+					Heaters:   []bool{true, true, false},
 				},
 			},
 			wantErr: false,
@@ -207,9 +193,7 @@ func TestAverageAndConvertSamples(t *testing.T) {
 							cfg.VoltageDivider.R1,
 							cfg.VoltageDivider.R2,
 						),
-						last.Heater1,
-						last.Heater2,
-						last.Heater3,
+						last.Heaters,
 						cfg.Heaters,
 					)
 					// Power should match (approximately, due to averaging)
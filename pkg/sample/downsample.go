@@ -2,6 +2,8 @@ package sample
 
 import (
 	"log"
+	"math"
+	"slices"
 	"time"
 )
 
@@ -93,11 +95,12 @@ func averageWindow(samples []Sample) Sample {
 		return Sample{}
 	}
 
-	var sumReading, sumChange, sumVoltage float64
+	var sumReading, sumReading2, sumChange, sumVoltage float64
 	lastSample := samples[len(samples)-1]
 
 	for _, s := range samples {
 		sumReading += s.Reading
+		sumReading2 += s.Reading2
 		sumChange += s.Change
 		sumVoltage += s.Voltage
 		// HeaterPower is never filtered/averaged - use latest value
@@ -110,6 +113,9 @@ func averageWindow(samples []Sample) Sample {
 		Change:      sumChange / n,
 		Voltage:     sumVoltage / n,
 		HeaterPower: lastSample.HeaterPower, // Use latest value (never filtered)
+		Ambient:     lastSample.Ambient,     // Changes slowly, use latest value
+		Reading2:    sumReading2 / n,
+		Heaters:     lastSample.Heaters,
 	}
 }
 
@@ -157,11 +163,12 @@ func DownsampleSamples(dst []Sample, samples []Sample, maxPoints int) []Sample {
 
 		// Average all samples in this window (except HeaterPower - use latest value)
 		if startIdx < endIdx {
-			var sumReading, sumChange, sumVoltage float64
+			var sumReading, sumReading2, sumChange, sumVoltage float64
 			windowSize := endIdx - startIdx
 			lastSampleInWindow := samples[endIdx-1]
 			for j := startIdx; j < endIdx; j++ {
 				sumReading += samples[j].Reading
+				sumReading2 += samples[j].Reading2
 				sumChange += samples[j].Change
 				sumVoltage += samples[j].Voltage
 				// HeaterPower is never filtered/averaged - use latest value
@@ -173,6 +180,9 @@ func DownsampleSamples(dst []Sample, samples []Sample, maxPoints int) []Sample {
 				Change:      sumChange / n,
 				Voltage:     sumVoltage / n,
 				HeaterPower: lastSampleInWindow.HeaterPower, // Use latest value (never filtered)
+				Ambient:     lastSampleInWindow.Ambient,     // Changes slowly, use latest value
+				Reading2:    sumReading2 / n,
+				Heaters:     lastSampleInWindow.Heaters,
 			}
 			dst = append(dst, avg)
 		}
@@ -219,3 +229,141 @@ func DownsampleDerivatives(dst []float64, derivatives []float64, maxPoints int)
 
 	return dst
 }
+
+// DownsampleWindows splits n points into about maxPoints consecutive windows
+// and returns the end index (exclusive) of each window. Every index in keep
+// gets a window of its own, so it survives downsampling unchanged; the rest
+// of the budget is spread over the gaps between kept indices. Samples and
+// derivatives downsampled with the same windows stay aligned.
+// Destination-based: reuses dst if it has sufficient capacity.
+func DownsampleWindows(dst []int, n, maxPoints int, keep []int) []int {
+	dst = dst[:0]
+	if n <= maxPoints {
+		for i := 1; i <= n; i++ {
+			dst = append(dst, i)
+		}
+		return dst
+	}
+
+	kept := make([]int, 0, len(keep))
+	for _, k := range keep {
+		if k >= 0 && k < n {
+			kept = append(kept, k)
+		}
+	}
+	slices.Sort(kept)
+	kept = slices.Compact(kept)
+
+	free := n - len(kept)
+	budget := max(maxPoints-len(kept), 1)
+	start := 0
+	split := func(end int) {
+		length := end - start
+		if length <= 0 {
+			return
+		}
+		windows := min(max(int(math.Round(float64(length)*float64(budget)/float64(free))), 1), length)
+		for j := 1; j <= windows; j++ {
+			dst = append(dst, start+length*j/windows)
+		}
+	}
+	for _, k := range kept {
+		split(k)
+		dst = append(dst, k+1)
+		start = k + 1
+	}
+	split(n)
+	return dst
+}
+
+// DownsampleSamplesWindows averages samples over the windows returned by
+// DownsampleWindows, like DownsampleSamples. Single-sample windows copy the
+// sample unchanged.
+func DownsampleSamplesWindows(dst []Sample, samples []Sample, ends []int) []Sample {
+	dst = dst[:0]
+	start := 0
+	for _, end := range ends {
+		end = min(end, len(samples))
+		if start >= end {
+			break
+		}
+		if end-start == 1 {
+			dst = append(dst, samples[start])
+		} else {
+			dst = append(dst, averageWindow(samples[start:end]))
+		}
+		start = end
+	}
+	return dst
+}
+
+// DownsampleDerivativesWindows averages derivatives over the sample windows
+// returned by DownsampleWindows. derivatives[i] lies between samples i and
+// i+1, so a window ending at the last sample has one derivative fewer.
+func DownsampleDerivativesWindows(dst []float64, derivatives []float64, ends []int) []float64 {
+	dst = dst[:0]
+	start := 0
+	for _, end := range ends {
+		end = min(end, len(derivatives))
+		if start >= end {
+			break
+		}
+		var sum float64
+		for _, d := range derivatives[start:end] {
+			sum += d
+		}
+		dst = append(dst, sum/float64(end-start))
+		start = end
+	}
+	return dst
+}
+
+// Envelope is the range of values within a downsampling window.
+type Envelope struct {
+	Min, Max float64
+}
+
+// DownsampleSamplesEnvelope returns the range of the readings within each of
+// the windows returned by DownsampleWindows, aligned with the averages of
+// DownsampleSamplesWindows. It shows the noise and brief spikes that
+// averaging hides.
+func DownsampleSamplesEnvelope(dst []Envelope, samples []Sample, ends []int) []Envelope {
+	dst = dst[:0]
+	start := 0
+	for _, end := range ends {
+		end = min(end, len(samples))
+		if start >= end {
+			break
+		}
+		e := Envelope{Min: samples[start].Reading, Max: samples[start].Reading}
+		for _, s := range samples[start+1 : end] {
+			e.Min = min(e.Min, s.Reading)
+			e.Max = max(e.Max, s.Reading)
+		}
+		dst = append(dst, e)
+		start = end
+	}
+	return dst
+}
+
+// DownsampleDerivativesEnvelope returns the range of the derivatives within
+// each of the sample windows returned by DownsampleWindows, aligned with
+// DownsampleDerivativesWindows.
+func DownsampleDerivativesEnvelope(dst []Envelope, derivatives []float64, ends []int) []Envelope {
+	dst = dst[:0]
+	start := 0
+	for _, end := range ends {
+		end = min(end, len(derivatives))
+		if start >= end {
+			break
+		}
+		e := Envelope{Min: derivatives[start], Max: derivatives[start]}
+		for _, d := range derivatives[start+1 : end] {
+			e.Min = min(e.Min, d)
+			e.Max = max(e.Max, d)
+		}
+		dst = append(dst, e)
+		start = end
+	}
+	return dst
+}
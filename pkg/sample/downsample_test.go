@@ -197,3 +197,69 @@ func TestDownsampleDerivatives_ExactMaxPoints(t *testing.T) {
 	}
 }
 
+
+func TestDownsampleWindows_KeepsIndices(t *testing.T) {
+	ends := DownsampleWindows(nil, 100, 10, []int{37, 5, 37, 200})
+
+	require.NotEmpty(t, ends)
+	assert.Equal(t, 100, ends[len(ends)-1], "windows cover all points")
+	assert.LessOrEqual(t, len(ends), 12)
+	assert.Contains(t, ends, 38, "37 has a window of its own")
+	assert.Contains(t, ends, 37)
+	assert.Contains(t, ends, 6, "5 has a window of its own")
+	assert.Contains(t, ends, 5)
+	for i := 1; i < len(ends); i++ {
+		assert.Greater(t, ends[i], ends[i-1], "windows are consecutive and non-empty")
+	}
+}
+
+func TestDownsampleWindows_NoDownsampling(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, DownsampleWindows(nil, 3, 10, []int{1}))
+	assert.Empty(t, DownsampleWindows(nil, 0, 10, nil))
+}
+
+func TestDownsampleSamplesWindows(t *testing.T) {
+	now := time.Now()
+	samples := make([]Sample, 100)
+	derivatives := make([]float64, 99)
+	for i := range samples {
+		samples[i] = Sample{Timestamp: now.Add(time.Duration(i) * time.Millisecond), Reading: float64(i)}
+		if i < len(derivatives) {
+			derivatives[i] = float64(i)
+		}
+	}
+	samples[37].Reading = 1000 // Spike that averaging would flatten
+
+	ends := DownsampleWindows(nil, len(samples), 10, []int{37})
+	result := DownsampleSamplesWindows(nil, samples, ends)
+	require.Len(t, result, len(ends))
+	assert.Contains(t, result, samples[37], "kept sample is unchanged")
+
+	derivs := DownsampleDerivativesWindows(nil, derivatives, ends)
+	assert.GreaterOrEqual(t, len(derivs), len(result)-1)
+	assert.LessOrEqual(t, len(derivs), len(result))
+	for i, end := range ends {
+		if end == 38 {
+			assert.Equal(t, 37.0, derivs[i], "derivative leaving the kept sample")
+		}
+	}
+}
+
+func TestDownsampleSamplesEnvelope(t *testing.T) {
+	samples := make([]Sample, 6)
+	for i, r := range []float64{1, 5, 2, 2, -3, 2} {
+		samples[i].Reading = r
+	}
+
+	envelope := DownsampleSamplesEnvelope(nil, samples, []int{3, 4, 6})
+	assert.Equal(t, []Envelope{{Min: 1, Max: 5}, {Min: 2, Max: 2}, {Min: -3, Max: 2}}, envelope)
+}
+
+func TestDownsampleDerivativesEnvelope(t *testing.T) {
+	// One derivative fewer than samples: the last window is one shorter
+	derivatives := []float64{0.1, -0.2, 0.3, 0.05}
+	envelope := DownsampleDerivativesEnvelope(nil, derivatives, []int{2, 5})
+	require.Len(t, envelope, 2)
+	assert.Equal(t, Envelope{Min: -0.2, Max: 0.1}, envelope[0])
+	assert.Equal(t, Envelope{Min: 0.05, Max: 0.3}, envelope[1])
+}
@@ -0,0 +1,230 @@
+package sample
+
+import (
+	"log"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+)
+
+// Pipeline is a running chain of the converters that turn raw device samples
+// into the filtered samples with derivatives the meter measures. Its settings
+// can be changed while it runs, see Reconfigure.
+type Pipeline struct {
+	cfg      atomic.Pointer[config.Config] // Settings snapshot read by the base converter
+	reconfig chan *config.Config           // Settings for the filter stages
+	done     chan struct{}                 // Closed when the raw samples are drained
+	out      <-chan Sample
+}
+
+// NewPipeline chains the converters that turn raw device samples into the
+// filtered samples with derivatives the meter measures. With statistics,
+// signal statistics are collected on the unfiltered samples.
+func NewPipeline(cfg *config.Config, statistics bool, raw <-chan lpm.RawSample) <-chan Sample {
+	return RunPipeline(cfg, statistics, raw).Samples()
+}
+
+// RunPipeline starts a Pipeline converting raw; see NewPipeline.
+func RunPipeline(cfg *config.Config, statistics bool, raw <-chan lpm.RawSample) *Pipeline {
+	p := &Pipeline{
+		reconfig: make(chan *config.Config),
+		done:     make(chan struct{}),
+	}
+	p.cfg.Store(snapshot(cfg))
+
+	// Chain converters:
+	// 1. Base conversion from raw samples
+	// 2. Statistics collection (if enabled) - collects stats on raw converted samples
+	// 3. Filter stages, rebuilt when their settings change (see newFilterChain)
+	// Increase buffer size to prevent channel full errors
+	baseStream := newConverter(p.cfg.Load, 500)(raw)
+
+	// Apply statistics collection (if enabled)
+	// This must be done BEFORE any filtering to capture raw signal characteristics
+	var statsStream <-chan Sample
+	if statistics {
+		// Use the configured smoothing alpha for EMA comparison
+		log.Printf("Statistics Smoothing alpha: %f", cfg.Measurement.SmoothingAlpha)
+		statsStream = NewStatisticsConverter(cfg.Measurement.SmoothingAlpha, 500)(baseStream)
+	} else {
+		statsStream = baseStream
+	}
+
+	// Feed the filter chain; on new filter settings close its input, so it
+	// drains, and feed a new chain. Chains are merged in order.
+	chains := make(chan (<-chan Sample), 1)
+	filters := newFilterSettings(cfg)
+	go func() {
+		defer close(p.done)
+		defer close(chains)
+
+		in := make(chan Sample, 500)
+		chains <- newFilterChain(filters, in)
+		defer func() { close(in) }()
+		for {
+			select {
+			case s, ok := <-statsStream:
+				if !ok {
+					return
+				}
+				in <- s
+			case cfg := <-p.reconfig:
+				if f := newFilterSettings(cfg); f != filters {
+					filters = f
+					close(in)
+					in = make(chan Sample, 500)
+					chains <- newFilterChain(filters, in)
+				}
+			}
+		}
+	}()
+
+	out := make(chan Sample, 500)
+	go func() {
+		defer close(out)
+		for chain := range chains {
+			for s := range chain {
+				out <- s
+			}
+		}
+	}()
+	p.out = out
+
+	return p
+}
+
+// Samples returns the converted samples, closed once the raw samples are
+// drained.
+func (p *Pipeline) Samples() <-chan Sample {
+	return p.out
+}
+
+// Reconfigure applies cfg to the running pipeline: voltage divider, heater
+// and ADC settings from the next sample on. The filter stages are rebuilt
+// if their settings changed, restarting their smoothing; samples already
+// in them are still delivered in order.
+func (p *Pipeline) Reconfigure(cfg *config.Config) {
+	cfg = snapshot(cfg)
+	p.cfg.Store(cfg)
+	select {
+	case p.reconfig <- cfg:
+	case <-p.done:
+	}
+}
+
+// snapshot copies the settings of cfg the converters use, so they can be
+// read while cfg is edited.
+func snapshot(cfg *config.Config) *config.Config {
+	c := *cfg
+	c.Heaters = slices.Clone(cfg.Heaters)
+	return &c
+}
+
+// filterSettings are the settings of the filter stages of a pipeline.
+type filterSettings struct {
+	spikeWindow        time.Duration
+	smoothingAlpha     float64
+	downsampleRate     time.Duration
+	changeFilterType   string
+	changeFilterAlpha  float64
+	changeFilterWindow time.Duration
+}
+
+// newFilterSettings returns the filter settings configured in cfg.
+func newFilterSettings(cfg *config.Config) filterSettings {
+	f := filterSettings{
+		spikeWindow:        cfg.SpikeFilterWindow(),
+		smoothingAlpha:     cfg.Measurement.SmoothingAlpha,
+		changeFilterType:   cfg.Measurement.ChangeFilterType,
+		changeFilterAlpha:  cfg.Measurement.ChangeFilterAlpha,
+		changeFilterWindow: cfg.ChangeFilterWindow(),
+	}
+	if cfg.Measurement.DownsampleRate != nil {
+		f.downsampleRate = *cfg.Measurement.DownsampleRate
+	}
+	return f
+}
+
+// newFilterChain chains the filter stages of a pipeline:
+//  1. Median filter on main fields to remove spikes (hardware-induced spikes when heaters turn on)
+//  2. EMA smoothing on all fields except Change (if smoothing enabled)
+//  3. Downsampling to target sample rate (if enabled)
+//  4. Differentiation to calculate Change field from Reading
+//  5. Filter on Change field (EMA/MA/MM - configurable)
+func newFilterChain(f filterSettings, in <-chan Sample) <-chan Sample {
+	// Apply median filter to remove spikes from main signal (Reading, Voltage)
+	// This filters out hardware-induced spikes when heaters turn on
+	// Note: HeaterPower is never filtered - it's only calculated
+	mainFields := FieldReading | FieldVoltage
+	var spikeFilteredStream <-chan Sample
+	if f.spikeWindow > 0 {
+		spikeFilteredStream = NewMMFilter(f.spikeWindow, mainFields, 500)(in)
+	} else {
+		// Spike filtering disabled, use input directly
+		spikeFilteredStream = in
+	}
+
+	// Apply EMA smoothing on all fields except Change and HeaterPower (if smoothing enabled)
+	// Note: HeaterPower is never filtered - it's only calculated
+	var smoothedStream <-chan Sample
+	if f.smoothingAlpha > 0 {
+		// Apply EMA to Reading and Voltage (Change will be calculated later, HeaterPower is never filtered)
+		smoothedStream = NewEMAFilter(f.smoothingAlpha, mainFields, 500)(spikeFilteredStream)
+	} else {
+		// No smoothing, use spike-filtered stream directly
+		smoothedStream = spikeFilteredStream
+	}
+
+	// Apply downsampling to target sample rate (if enabled)
+	var downsampledStream <-chan Sample
+	if f.downsampleRate > 0 {
+		downsampledStream = NewDownsamplingConverter(f.downsampleRate, 500)(smoothedStream)
+	} else {
+		// No downsampling, use smoothed stream directly
+		downsampledStream = smoothedStream
+	}
+
+	// Always apply differentiation to calculate Change field from Reading
+	diffStream := NewDifferentiationConverter(500)(downsampledStream)
+
+	// Apply filter on Change field (configurable: EMA, MA, or MM)
+	var samplesStream <-chan Sample
+	changeFields := FieldChange
+	filterType := f.changeFilterType
+	if filterType == "" {
+		filterType = "ema" // Default
+	}
+
+	switch filterType {
+	case "ema", "EMA":
+		if f.changeFilterAlpha > 0 {
+			samplesStream = NewEMAFilter(f.changeFilterAlpha, changeFields, 500)(diffStream)
+		} else {
+			samplesStream = diffStream // No filtering if alpha is 0
+		}
+	case "ma", "MA":
+		windowDuration := f.changeFilterWindow
+		if windowDuration <= 0 {
+			windowDuration = 200 * time.Millisecond // Default: 200ms
+		}
+		samplesStream = NewMAFilter(windowDuration, changeFields, 500)(diffStream)
+	case "mm", "MM":
+		windowDuration := f.changeFilterWindow
+		if windowDuration <= 0 {
+			windowDuration = 200 * time.Millisecond // Default: 200ms
+		}
+		samplesStream = NewMMFilter(windowDuration, changeFields, 500)(diffStream)
+	default:
+		// Unknown filter type, use EMA as fallback
+		if f.changeFilterAlpha > 0 {
+			samplesStream = NewEMAFilter(f.changeFilterAlpha, changeFields, 500)(diffStream)
+		} else {
+			samplesStream = diffStream
+		}
+	}
+
+	return samplesStream
+}
@@ -0,0 +1,74 @@
+package sample
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/lpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unfilteredConfig returns a configuration with every filter stage disabled.
+func unfilteredConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Measurement.SpikeFilterWindowSize = 0
+	cfg.Measurement.SpikeFilterSamples = 0
+	cfg.Measurement.SmoothingAlpha = 0
+	cfg.Measurement.DownsampleRate = nil
+	cfg.Measurement.ChangeFilterType = "ema"
+	cfg.Measurement.ChangeFilterAlpha = 0
+	return cfg
+}
+
+func TestPipeline_ReconfigureDivider(t *testing.T) {
+	cfg := unfilteredConfig()
+	raw := make(chan lpm.RawSample)
+	p := RunPipeline(cfg, false, raw)
+
+	now := time.Now()
+	raw <- lpm.RawSample{Timestamp: now, Voltage: 30000}
+	before := <-p.Samples()
+
+	// Edits take effect on Reconfigure only
+	cfg.VoltageDivider.R1 = cfg.VoltageDivider.R2
+	raw <- lpm.RawSample{Timestamp: now.Add(time.Second), Voltage: 30000}
+	assert.Equal(t, before.Voltage, (<-p.Samples()).Voltage)
+
+	p.Reconfigure(cfg)
+	raw <- lpm.RawSample{Timestamp: now.Add(2 * time.Second), Voltage: 30000}
+	after := <-p.Samples()
+	assert.InDelta(t, 2*adcToVoltage(30000, cfg.ADCReference()), after.Voltage, 1e-9)
+
+	close(raw)
+	_, ok := <-p.Samples()
+	assert.False(t, ok, "Output channel should be closed")
+	p.Reconfigure(cfg) // Returns once drained
+}
+
+func TestPipeline_ReconfigureFilters(t *testing.T) {
+	cfg := unfilteredConfig()
+	raw := make(chan lpm.RawSample, 10)
+	p := RunPipeline(cfg, false, raw)
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		raw <- lpm.RawSample{Timestamp: now.Add(time.Duration(i) * time.Second), Reading: uint16(1000 * i)}
+	}
+	cfg.Measurement.SmoothingAlpha = 0.5
+	p.Reconfigure(cfg)
+	for i := 3; i < 6; i++ {
+		raw <- lpm.RawSample{Timestamp: now.Add(time.Duration(i) * time.Second), Reading: uint16(1000 * i)}
+	}
+	close(raw)
+
+	var samples []Sample
+	for s := range p.Samples() {
+		samples = append(samples, s)
+	}
+	require.Len(t, samples, 6, "Samples in the old filter chain are delivered")
+	for i, s := range samples {
+		assert.Equal(t, now.Add(time.Duration(i)*time.Second), s.Timestamp, "Sample %d out of order", i)
+	}
+}
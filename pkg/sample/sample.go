@@ -2,6 +2,7 @@ package sample
 
 import (
 	"log"
+	"math"
 	"time"
 
 	"github.com/itohio/golpm/pkg/config"
@@ -31,6 +32,9 @@ type Sample struct {
 	Change      float64 // Change from previous reading (V) - calculated by differentiation filter
 	Voltage     float64 // Voltage measurement (V)
 	HeaterPower float64 // Total heater power (W)
+	Ambient     float64 // Ambient temperature (°C), NaN if the device has no ambient sensor
+	Reading2    float64 // Second absorber differential voltage (V), NaN if the device has one absorber
+	Heaters     []bool  // Heater on/off states reported with the reading, heater 1 first
 }
 
 // Converter is a function type that converts RawSample channel to Sample channel.
@@ -38,6 +42,12 @@ type Converter func(in <-chan lpm.RawSample) <-chan Sample
 
 // NewConverter creates a converter function that transforms RawSample to Sample.
 func NewConverter(cfg *config.Config, bufSize int) Converter {
+	return newConverter(func() *config.Config { return cfg }, bufSize)
+}
+
+// newConverter creates a converter reading its configuration from settings
+// for every sample, so the configuration can be swapped while it runs.
+func newConverter(settings func() *config.Config, bufSize int) Converter {
 	if bufSize <= 0 {
 		bufSize = 100
 	}
@@ -49,7 +59,7 @@ func NewConverter(cfg *config.Config, bufSize int) Converter {
 			defer close(out)
 
 			for raw := range in {
-				sample, err := convertSample(raw, cfg)
+				sample, err := convertSample(raw, settings())
 				if err != nil {
 					log.Printf("Failed to convert sample: %v", err)
 					continue
@@ -68,16 +78,26 @@ func NewConverter(cfg *config.Config, bufSize int) Converter {
 }
 
 // convertSample converts a RawSample to Sample using configuration.
+// Counts are converted at the ADC input range and absorber gain negotiated
+// with the device.
 func convertSample(raw lpm.RawSample, cfg *config.Config) (Sample, error) {
-	// Convert reading (temperature differential) from ADC to voltage
-	readingVoltage := adcToVoltage(raw.Reading, cfg.VoltageDivider.VRef)
+	ref := cfg.ADCReference()
+
+	// Convert reading (temperature differential) from ADC to voltage at the input
+	readingVoltage := adcToVoltage(raw.Reading, ref) / cfg.ReadingGain()
+
+	// The second absorber shares the first one's gain
+	reading2Voltage := math.NaN()
+	if raw.HasReading2 {
+		reading2Voltage = adcToVoltage(raw.Reading2, ref) / cfg.ReadingGain()
+	}
 
 	// Convert voltage measurement from ADC to voltage (after divider)
-	voltageMeasured := adcToVoltage(raw.Voltage, cfg.VoltageDivider.VRef)
+	voltageMeasured := adcToVoltage(raw.Voltage, ref)
 	voltageActual := voltageDivider(voltageMeasured, cfg.VoltageDivider.R1, cfg.VoltageDivider.R2)
 
 	// Calculate heater power
-	heaterPower := calculateHeaterPower(voltageActual, raw.Heater1, raw.Heater2, raw.Heater3, cfg.Heaters)
+	heaterPower := calculateHeaterPower(voltageActual, raw.Heaters, cfg.Heaters)
 
 	return Sample{
 		Timestamp:   raw.Timestamp,
@@ -85,6 +105,9 @@ func convertSample(raw lpm.RawSample, cfg *config.Config) (Sample, error) {
 		Change:      0.0, // Will be calculated by differentiation filter
 		Voltage:     voltageActual,
 		HeaterPower: heaterPower,
+		Ambient:     ambientTemperature(raw.Ambient, ref/cfg.VoltageDivider.VRef, cfg.Ambient),
+		Reading2:    reading2Voltage,
+		Heaters:     raw.Heaters,
 	}, nil
 }
 
@@ -94,6 +117,26 @@ func adcToVoltage(adc uint16, vref float64) float64 {
 	return (float64(adc) / 65535.0) * vref
 }
 
+// ambientTemperature converts the ambient NTC divider reading to °C using the
+// beta equation. scale is the ADC input range relative to the divider's
+// supply, 1 when the ADC uses the supply as reference. Returns NaN for a zero
+// reading (no sensor) and for readings outside the divider's range.
+func ambientTemperature(adc uint16, scale float64, cfg config.AmbientConfig) float64 {
+	if adc == 0 || adc == 65535 || cfg.R25 <= 0 || cfg.Beta <= 0 || cfg.RSeries <= 0 {
+		return math.NaN()
+	}
+
+	// V = VRef * R / (R + RSeries), so R = RSeries * V / (VRef - V); VRef cancels
+	ratio := float64(adc) / 65535.0 * scale
+	if ratio >= 1 || math.IsNaN(ratio) {
+		return math.NaN()
+	}
+	r := cfg.RSeries * ratio / (1 - ratio)
+
+	const t25 = 298.15 // 25 °C in K
+	return 1/(1/t25+math.Log(r/cfg.R25)/cfg.Beta) - 273.15
+}
+
 // voltageDivider calculates the input voltage from the measured output voltage.
 // Formula: V_in = V_out * ((R1 + R2) / R2)
 func voltageDivider(vout float64, r1, r2 float64) float64 {
@@ -101,30 +144,14 @@ func voltageDivider(vout float64, r1, r2 float64) float64 {
 }
 
 // calculateHeaterPower calculates the total power from all active heaters.
-func calculateHeaterPower(voltage float64, heater1, heater2, heater3 bool, heaters []config.HeaterConfig) float64 {
-	if len(heaters) < 3 {
-		return 0.0
-	}
-
+// Heaters without a configured resistance contribute nothing.
+func calculateHeaterPower(voltage float64, states []bool, heaters []config.HeaterConfig) float64 {
 	var totalPower float64
 
 	// P = V² / R for each active heater
-	if heater1 {
-		if heaters[0].Resistance > 0 {
-			power := (voltage * voltage) / heaters[0].Resistance
-			totalPower += power
-		}
-	}
-	if heater2 {
-		if heaters[1].Resistance > 0 {
-			power := (voltage * voltage) / heaters[1].Resistance
-			totalPower += power
-		}
-	}
-	if heater3 {
-		if heaters[2].Resistance > 0 {
-			power := (voltage * voltage) / heaters[2].Resistance
-			totalPower += power
+	for i, on := range states {
+		if on && i < len(heaters) && heaters[i].Resistance > 0 {
+			totalPower += (voltage * voltage) / heaters[i].Resistance
 		}
 	}
 
@@ -1,6 +1,7 @@
 package sample
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -57,6 +58,29 @@ func TestADCToVoltage(t *testing.T) {
 	}
 }
 
+func TestAmbientTemperature(t *testing.T) {
+	cfg := config.Default().Ambient
+
+	tests := []struct {
+		name string
+		adc  uint16
+		want float64
+	}{
+		{name: "R25", adc: 32768, want: 25.0},
+		{name: "twice R25 is colder", adc: 43690, want: 10.18},
+		{name: "half R25 is warmer", adc: 21845, want: 41.46},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, ambientTemperature(tt.adc, 1, cfg), 0.01)
+		})
+	}
+
+	assert.True(t, math.IsNaN(ambientTemperature(0, 1, cfg)), "no sensor")
+	assert.True(t, math.IsNaN(ambientTemperature(65535, 1, cfg)), "open NTC")
+	assert.True(t, math.IsNaN(ambientTemperature(32768, 1, config.AmbientConfig{})), "not configured")
+}
+
 func TestVoltageDivider(t *testing.T) {
 	tests := []struct {
 		name string
@@ -108,81 +132,70 @@ func TestCalculateHeaterPower(t *testing.T) {
 	tests := []struct {
 		name    string
 		voltage float64
-		heater1 bool
-		heater2 bool
-		heater3 bool
+		states  []bool
 		want    float64
 		heaters []config.HeaterConfig
 	}{
 		{
 			name:    "no heaters",
 			voltage: 5.0,
-			heater1: false,
-			heater2: false,
-			heater3: false,
+			states:  []bool{false, false, false},
 			want:    0.0,
 			heaters: cfg.Heaters,
 		},
 		{
 			name:    "heater 1 only",
 			voltage: 5.0,
-			heater1: true,
-			heater2: false,
-			heater3: false,
+			states:  []bool{true, false, false},
 			want:    25.0 / 2300.0, // V²/R = 25/2300 ≈ 0.01087
 			heaters: cfg.Heaters,
 		},
 		{
 			name:    "heater 2 only",
 			voltage: 5.0,
-			heater1: false,
-			heater2: true,
-			heater3: false,
+			states:  []bool{false, true, false},
 			want:    25.0 / 500.0, // V²/R = 25/500 = 0.05
 			heaters: cfg.Heaters,
 		},
 		{
 			name:    "heater 3 only",
 			voltage: 5.0,
-			heater1: false,
-			heater2: false,
-			heater3: true,
+			states:  []bool{false, false, true},
 			want:    25.0 / 200.0, // V²/R = 25/200 = 0.125
 			heaters: cfg.Heaters,
 		},
 		{
 			name:    "all heaters",
 			voltage: 5.0,
-			heater1: true,
-			heater2: true,
-			heater3: true,
+			states:  []bool{true, true, true},
 			want:    (25.0 / 2300.0) + (25.0 / 500.0) + (25.0 / 200.0),
 			heaters: cfg.Heaters,
 		},
 		{
 			name:    "heaters 1 and 2",
 			voltage: 5.0,
-			heater1: true,
-			heater2: true,
-			heater3: false,
+			states:  []bool{true, true, false},
 			want:    (25.0 / 2300.0) + (25.0 / 500.0),
 			heaters: cfg.Heaters,
 		},
 		{
-			name:    "insufficient heaters config",
+			name:    "heater without configuration",
 			voltage: 5.0,
-			heater1: true,
-			heater2: false,
-			heater3: false,
-			want:    0.0,
+			states:  []bool{true, true, false},
+			want:    25.0 / 2300.0,                             // Heater 2 has no resistance configured
 			heaters: []config.HeaterConfig{{Resistance: 2300}}, // Only 1 heater
 		},
+		{
+			name:    "five heaters",
+			voltage: 5.0,
+			states:  []bool{false, false, false, true, true},
+			want:    (25.0 / 100.0) + (25.0 / 50.0),
+			heaters: []config.HeaterConfig{{Resistance: 2300}, {Resistance: 500}, {Resistance: 200}, {Resistance: 100}, {Resistance: 50}},
+		},
 		{
 			name:    "zero resistance",
 			voltage: 5.0,
-			heater1: true,
-			heater2: false,
-			heater3: false,
+			states:  []bool{true, false, false},
 			want:    0.0,
 			heaters: []config.HeaterConfig{
 				{Resistance: 0}, // Zero resistance
@@ -194,9 +207,9 @@ func TestCalculateHeaterPower(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := calculateHeaterPower(tt.voltage, tt.heater1, tt.heater2, tt.heater3, tt.heaters)
-			assert.InDelta(t, tt.want, got, 0.0001, "calculateHeaterPower(%f, %v, %v, %v, ...) = %f, want %f",
-				tt.voltage, tt.heater1, tt.heater2, tt.heater3, got, tt.want)
+			got := calculateHeaterPower(tt.voltage, tt.states, tt.heaters)
+			assert.InDelta(t, tt.want, got, 0.0001, "calculateHeaterPower(%f, %v, ...) = %f, want %f",
+				tt.voltage, tt.states, got, tt.want)
 		})
 	}
 }
@@ -216,15 +229,14 @@ func TestConvertSample(t *testing.T) {
 				Timestamp: now,
 				Reading:   0,
 				Voltage:   0,
-				Heater1:   false,
-				Heater2:   false,
-				Heater3:   false,
+				Heaters:   []bool{false, false, false},
 			},
 			want: Sample{
 				Timestamp:   now,
 				Reading:     0.0,
 				Voltage:     0.0,
 				HeaterPower: 0.0,
+				Heaters:     []bool{false, false, false},
 			},
 		},
 		{
@@ -233,15 +245,14 @@ func TestConvertSample(t *testing.T) {
 				Timestamp: now,
 				Reading:   65535,
 				Voltage:   65535,
-				Heater1:   false,
-				Heater2:   false,
-				Heater3:   false,
+				Heaters:   []bool{false, false, false},
 			},
 			want: Sample{
 				Timestamp:   now,
 				Reading:     3.3, // VRef
 				Voltage:     6.6, // After divider: 3.3V * (R1+R2)/R2 = 3.3 * 2 = 6.6V
 				HeaterPower: 0.0,
+				Heaters:     []bool{false, false, false},
 			},
 		},
 		{
@@ -250,15 +261,14 @@ func TestConvertSample(t *testing.T) {
 				Timestamp: now,
 				Reading:   32767,
 				Voltage:   32767,
-				Heater1:   true,
-				Heater2:   false,
-				Heater3:   false,
+				Heaters:   []bool{true, false, false},
 			},
 			want: Sample{
 				Timestamp:   now,
 				Reading:     1.65,                 // Approximately
 				Voltage:     3.3,                  // After divider: 1.65V * 2 = 3.3V
 				HeaterPower: (3.3 * 3.3) / 2300.0, // V²/R
+				Heaters:     []bool{true, false, false},
 			},
 		},
 	}
@@ -271,10 +281,41 @@ func TestConvertSample(t *testing.T) {
 			assert.InDelta(t, tt.want.Reading, got.Reading, 0.01)
 			assert.InDelta(t, tt.want.Voltage, got.Voltage, 0.01)
 			assert.InDelta(t, tt.want.HeaterPower, got.HeaterPower, 0.001)
+			assert.Equal(t, tt.want.Heaters, got.Heaters)
 		})
 	}
 }
 
+func TestConvertSample_NegotiatedADC(t *testing.T) {
+	cfg := config.Default()
+	cfg.Serial.NegotiatedGain = 16
+	cfg.Serial.NegotiatedReference = 2.0
+
+	got, err := convertSample(lpm.RawSample{Reading: 65535, Voltage: 32767, Ambient: 32768}, cfg)
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0/16, got.Reading, 1e-6, "Full scale at the negotiated range and gain")
+	assert.InDelta(t, 2.0, got.Voltage, 1e-3, "Half the 2V range through the 1:1 divider")
+
+	// The NTC divider runs from the 3.3V supply, so the same count is a lower ratio
+	cfg.Serial.NegotiatedGain, cfg.Serial.NegotiatedReference = 0, 0
+	want, err := convertSample(lpm.RawSample{Ambient: 19859}, cfg)
+	require.NoError(t, err)
+	assert.InDelta(t, want.Ambient, got.Ambient, 0.05)
+}
+
+func TestConvertSample_Reading2(t *testing.T) {
+	cfg := config.Default()
+
+	got, err := convertSample(lpm.RawSample{Reading: 32767, Reading2: 65535, HasReading2: true}, cfg)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.65, got.Reading, 0.01)
+	assert.InDelta(t, 3.3, got.Reading2, 0.01)
+
+	got, err = convertSample(lpm.RawSample{Reading: 32767}, cfg)
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(got.Reading2), "single absorber")
+}
+
 func TestNewConverter_ChannelProcessing(t *testing.T) {
 	cfg := config.Default()
 	converter := NewConverter(cfg, 10)
@@ -289,9 +330,7 @@ func TestNewConverter_ChannelProcessing(t *testing.T) {
 			Timestamp: now.Add(time.Duration(i) * time.Second),
 			Reading:   uint16(2047 + i*100),
 			Voltage:   uint16(2047 + i*100),
-			Heater1:   i%2 == 0,
-			Heater2:   false,
-			Heater3:   false,
+			Heaters:   []bool{i%2 == 0, false, false},
 		}
 	}
 
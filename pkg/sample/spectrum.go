@@ -0,0 +1,103 @@
+package sample
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Spectrum is the amplitude spectrum of a signal.
+type Spectrum struct {
+	BinWidth  float64   // Frequency step between bins (Hz)
+	Amplitude []float64 // Peak amplitude per bin, from 0 Hz up to the Nyquist frequency
+}
+
+// Frequency returns the frequency of bin i in Hz.
+func (s Spectrum) Frequency(i int) float64 {
+	return float64(i) * s.BinWidth
+}
+
+// Peak returns the bin with the largest amplitude above 0 Hz, or -1 if the
+// spectrum has no such bin.
+func (s Spectrum) Peak() int {
+	peak := -1
+	for i := 1; i < len(s.Amplitude); i++ {
+		if peak < 0 || s.Amplitude[i] > s.Amplitude[peak] {
+			peak = i
+		}
+	}
+	return peak
+}
+
+// ComputeSpectrum returns the amplitude spectrum of the sample readings.
+// The samples are assumed evenly spaced at their mean interval; the mean is
+// removed and a Hann window applied before a zero-padded FFT. Frequencies
+// above half the sample rate alias, so e.g. mains hum only shows at its own
+// frequency when sampling faster than 100-120 Hz.
+func ComputeSpectrum(samples []Sample) Spectrum {
+	n := len(samples)
+	if n < 4 {
+		return Spectrum{}
+	}
+	interval := samples[n-1].Timestamp.Sub(samples[0].Timestamp).Seconds() / float64(n-1)
+	if interval <= 0 {
+		return Spectrum{}
+	}
+
+	var mean float64
+	for _, s := range samples {
+		mean += s.Reading
+	}
+	mean /= float64(n)
+
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	data := make([]complex128, size)
+	var windowSum float64
+	for i, s := range samples {
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+		windowSum += w
+		data[i] = complex((s.Reading-mean)*w, 0)
+	}
+	fft(data)
+
+	// Scale so a sine of amplitude A shows as A in its bin
+	amplitude := make([]float64, size/2+1)
+	for i := range amplitude {
+		amplitude[i] = 2 * cmplx.Abs(data[i]) / windowSum
+	}
+	return Spectrum{
+		BinWidth:  1 / (interval * float64(size)),
+		Amplitude: amplitude,
+	}
+}
+
+// fft transforms x in place. len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+
+	// Bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package sample
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeSpectrum_Sine(t *testing.T) {
+	// 1 mV at 12.5 Hz on a 0.5 V baseline, sampled at 100 Hz
+	const rate, freq, amp = 100.0, 12.5, 0.001
+	t0 := time.Unix(1_700_000_000, 0)
+	samples := make([]Sample, 512)
+	for i := range samples {
+		ts := float64(i) / rate
+		samples[i] = Sample{
+			Timestamp: t0.Add(time.Duration(ts * float64(time.Second))),
+			Reading:   0.5 + amp*math.Sin(2*math.Pi*freq*ts),
+		}
+	}
+
+	spec := ComputeSpectrum(samples)
+	require.Len(t, spec.Amplitude, 257)
+	assert.InDelta(t, rate/512, spec.BinWidth, 1e-9)
+
+	peak := spec.Peak()
+	assert.InDelta(t, freq, spec.Frequency(peak), spec.BinWidth)
+	assert.InDelta(t, amp, spec.Amplitude[peak], amp*0.05)
+	assert.Less(t, spec.Amplitude[0], amp*0.01, "mean removed")
+}
+
+func TestComputeSpectrum_TooShort(t *testing.T) {
+	spec := ComputeSpectrum([]Sample{{}, {}})
+	assert.Empty(t, spec.Amplitude)
+	assert.Equal(t, -1, spec.Peak())
+}
@@ -0,0 +1,81 @@
+package scope
+
+import (
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// crosshairReadout is the sample under the mouse pointer.
+type crosshairReadout struct {
+	sample     sample.Sample
+	derivative float64
+	hasSlope   bool // derivative is set; a single sample has none
+}
+
+// MouseIn shows the crosshair.
+func (s *ScopeWidget) MouseIn(ev *desktop.MouseEvent) {
+	s.setPointer(ev.Position, true)
+}
+
+// MouseMoved moves the crosshair to the pointer.
+func (s *ScopeWidget) MouseMoved(ev *desktop.MouseEvent) {
+	s.setPointer(ev.Position, true)
+}
+
+// MouseOut hides the crosshair.
+func (s *ScopeWidget) MouseOut() {
+	s.setPointer(fyne.Position{}, false)
+}
+
+func (s *ScopeWidget) setPointer(pos fyne.Position, hovering bool) {
+	s.mu.Lock()
+	s.pointer, s.hovering = pos, hovering
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// readout returns the sample nearest to the pointer, if the pointer is over
+// the plot and the view has data. Must be called with mu held.
+func (s *ScopeWidget) readout() (crosshairReadout, bool) {
+	size := s.Size()
+	if !s.hovering || s.pointer.X < marginLeft || s.pointer.X > size.Width-marginRight ||
+		s.pointer.Y < marginTop || s.pointer.Y > size.Height-marginBottom {
+		return crosshairReadout{}, false
+	}
+
+	i := nearestSample(s.samples, s.timeAt(s.pointer.X))
+	if i < 0 || s.samples[i].Timestamp.Before(s.xMin) || s.samples[i].Timestamp.After(s.xMax) {
+		return crosshairReadout{}, false
+	}
+
+	r := crosshairReadout{sample: s.samples[i]}
+	// derivatives[i] lies between samples i and i+1; the last sample uses the one before
+	if len(s.derivatives) > 0 {
+		r.derivative = s.derivatives[min(i, len(s.derivatives)-1)]
+		r.hasSlope = true
+	}
+	return r, true
+}
+
+// nearestSample returns the index of the sample closest to t, or -1 if
+// there are none. samples must be in time order.
+func nearestSample(samples []sample.Sample, t time.Time) int {
+	if len(samples) == 0 {
+		return -1
+	}
+	i := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].Timestamp.Before(t)
+	})
+	switch {
+	case i == len(samples):
+		return i - 1
+	case i > 0 && t.Sub(samples[i-1].Timestamp) < samples[i].Timestamp.Sub(t):
+		return i - 1
+	}
+	return i
+}
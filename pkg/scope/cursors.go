@@ -0,0 +1,95 @@
+package scope
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// cursorMeasurement is what lies between the two measurement cursors.
+type cursorMeasurement struct {
+	dt        time.Duration // Time from the first to the second cursor
+	dv        float64       // Reading change (V)
+	meanPower float64       // Mean heater power between the cursors (W)
+}
+
+// Tapped opens the details of a pulse when tapped between its start and
+// end lines. Elsewhere it places the measurement cursors at the sample under
+// the pointer: the first tap places cursor 1, the second cursor 2, and later
+// taps move the nearer cursor.
+func (s *ScopeWidget) Tapped(ev *fyne.PointEvent) {
+	s.mu.Lock()
+	size := s.Size()
+	i := -1
+	if ev.Position.X >= marginLeft && ev.Position.X <= size.Width-marginRight {
+		t := s.timeAt(ev.Position.X)
+		if p := pulseAt(s.pulses, t); p >= 0 {
+			pulse := s.pulses[p]
+			s.mu.Unlock()
+			s.showPulseDetails(pulse, ev.AbsolutePosition)
+			return
+		}
+		i = nearestSample(s.samples, t)
+	}
+	if i < 0 {
+		s.mu.Unlock()
+		return
+	}
+	s.cursors = placeCursor(s.cursors, s.samples[i].Timestamp)
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// TappedSecondary removes the measurement cursors.
+func (s *ScopeWidget) TappedSecondary(*fyne.PointEvent) {
+	s.mu.Lock()
+	s.cursors = [2]time.Time{}
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// placeCursor places a cursor at t: the first free one, or the one nearer
+// to t if both are placed.
+func placeCursor(cursors [2]time.Time, t time.Time) [2]time.Time {
+	switch {
+	case cursors[0].IsZero():
+		cursors[0] = t
+	case cursors[1].IsZero():
+		cursors[1] = t
+	case absDuration(t.Sub(cursors[0])) <= absDuration(t.Sub(cursors[1])):
+		cursors[0] = t
+	default:
+		cursors[1] = t
+	}
+	return cursors
+}
+
+// measureCursors returns the reading change and mean heater power between
+// the cursors a and b, using the samples nearest to them. ok is false if a
+// cursor has scrolled out of the buffer.
+func measureCursors(samples []sample.Sample, a, b time.Time) (m cursorMeasurement, ok bool) {
+	if len(samples) == 0 || a.Before(samples[0].Timestamp) || b.Before(samples[0].Timestamp) {
+		return cursorMeasurement{}, false
+	}
+	ia, ib := nearestSample(samples, a), nearestSample(samples, b)
+
+	m.dt = samples[ib].Timestamp.Sub(samples[ia].Timestamp)
+	m.dv = samples[ib].Reading - samples[ia].Reading
+
+	from, to := min(ia, ib), max(ia, ib)
+	for _, s := range samples[from : to+1] {
+		m.meanPower += s.HeaterPower
+	}
+	m.meanPower /= float64(to - from + 1)
+	return m, true
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,97 @@
+package scope
+
+import (
+	"sort"
+	"time"
+
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// history collects the meter's rolling window into a longer record, so the
+// graph can be scrolled back to data the meter has already dropped.
+//
+// The slices are only ever appended to or resliced, and pulses are replaced
+// by a new slice, so data handed out earlier, e.g. to a frozen display,
+// never changes.
+type history struct {
+	samples     []sample.Sample
+	derivatives []float64 // derivatives[i] lies between samples i and i+1
+	pulses      []meter.Pulse
+}
+
+// merge adds an update of the meter's window to the history and drops data
+// older than length before the newest sample. Samples already in the history
+// are skipped. A window that ends before the history, e.g. from a replayed
+// recording, restarts it. With length 0 the history is the window.
+func (h *history) merge(samples []sample.Sample, derivatives []float64, pulses []meter.Pulse, length time.Duration) {
+	if length <= 0 || len(h.samples) == 0 {
+		h.samples, h.derivatives, h.pulses = samples, derivatives, pulses
+		return
+	}
+	if len(samples) == 0 {
+		return
+	}
+
+	last := h.samples[len(h.samples)-1].Timestamp
+	if samples[len(samples)-1].Timestamp.Before(last) {
+		h.samples, h.derivatives, h.pulses = samples, derivatives, pulses
+		return
+	}
+	from := sort.Search(len(samples), func(i int) bool {
+		return samples[i].Timestamp.After(last)
+	})
+
+	if from < len(samples) {
+		// Keep the derivatives aligned: the one between the newest sample
+		// of the history and the first new one is unknown across a gap.
+		if from == 0 {
+			h.derivatives = append(h.derivatives, 0)
+		} else {
+			h.derivatives = append(h.derivatives, derivatives[min(from-1, len(derivatives)):]...)
+		}
+		h.samples = append(h.samples, samples[from:]...)
+	}
+	h.mergePulses(pulses)
+	h.trim(length)
+}
+
+// mergePulses adds new pulses and replaces those with the same ID, whose
+// fit may have been refined since.
+func (h *history) mergePulses(pulses []meter.Pulse) {
+	merged := make([]meter.Pulse, len(h.pulses), len(h.pulses)+len(pulses))
+	copy(merged, h.pulses)
+	for _, p := range pulses {
+		i := sort.Search(len(merged), func(i int) bool {
+			return merged[i].ID >= p.ID
+		})
+		if i < len(merged) && merged[i].ID == p.ID {
+			merged[i] = p
+			continue
+		}
+		merged = append(merged, meter.Pulse{})
+		copy(merged[i+1:], merged[i:])
+		merged[i] = p
+	}
+	h.pulses = merged
+}
+
+// trim drops the samples older than length before the newest one and, like
+// the meter, the pulses detected before the oldest sample left.
+func (h *history) trim(length time.Duration) {
+	oldest := h.samples[len(h.samples)-1].Timestamp.Add(-length)
+	n := sort.Search(len(h.samples), func(i int) bool {
+		return !h.samples[i].Timestamp.Before(oldest)
+	})
+	if n > 0 {
+		h.samples = h.samples[n:]
+		h.derivatives = h.derivatives[min(n, len(h.derivatives)):]
+	}
+
+	first := h.samples[0].Timestamp
+	keep := 0
+	for keep < len(h.pulses) && h.pulses[keep].DetectEndTime.Before(first) {
+		keep++
+	}
+	h.pulses = h.pulses[keep:]
+}
@@ -0,0 +1,213 @@
+package scope
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+const (
+	// liveAveragePulses is the number of newest pulses in the running average.
+	liveAveragePulses = 10
+
+	// liveQuietWindow is how far back the baseline and noise look for
+	// samples outside pulses.
+	liveQuietWindow = 2 * time.Second
+
+	// liveInterval limits how often the numbers change, so they stay
+	// readable.
+	liveInterval = 250 * time.Millisecond
+
+	// liveWidth is the fixed panel width, so the layout does not jump when
+	// the numbers change length.
+	liveWidth = 240
+)
+
+// liveValues are the numbers shown by a LiveReadout. NaN marks a value that
+// is not known yet.
+type liveValues struct {
+	lastPower    float64 // Power of the newest pulse (W)
+	averagePower float64 // Mean power of the newest liveAveragePulses pulses (W)
+	averageCount int     // Pulses in the average
+	baseline     float64 // Mean reading outside pulses (V)
+	noise        float64 // RMS of the derivative around its mean outside pulses (V/s)
+	heaterPower  float64 // Latest heater power (W)
+}
+
+// computeLiveValues derives the readout from the meter's data. The baseline
+// and noise use the samples of the last liveQuietWindow that are not part of
+// a pulse.
+func computeLiveValues(samples []sample.Sample, derivatives []float64, pulses []meter.Pulse, active *meter.Pulse) liveValues {
+	v := liveValues{
+		lastPower:    math.NaN(),
+		averagePower: math.NaN(),
+		baseline:     math.NaN(),
+		noise:        math.NaN(),
+		heaterPower:  math.NaN(),
+	}
+
+	if len(pulses) > 0 {
+		v.lastPower = pulses[len(pulses)-1].AvgPower
+		recent := pulses[max(len(pulses)-liveAveragePulses, 0):]
+		var sum float64
+		for _, p := range recent {
+			sum += p.AvgPower
+		}
+		v.averagePower = sum / float64(len(recent))
+		v.averageCount = len(recent)
+	}
+	if len(samples) == 0 {
+		return v
+	}
+	v.heaterPower = samples[len(samples)-1].HeaterPower
+
+	from := samples[len(samples)-1].Timestamp.Add(-liveQuietWindow)
+	inPulse := func(t time.Time) bool {
+		if active != nil && !t.Before(active.DetectStartTime) {
+			return true
+		}
+		for _, p := range pulses {
+			end := p.DetectEndTime
+			if end.IsZero() || end.Before(p.EndTime) {
+				end = p.EndTime
+			}
+			if !t.Before(p.DetectStartTime) && !t.After(end) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var readingSum, derivSum, derivSum2 float64
+	var readings, derivs int
+	for i := len(samples) - 1; i >= 0 && !samples[i].Timestamp.Before(from); i-- {
+		if inPulse(samples[i].Timestamp) {
+			continue
+		}
+		readingSum += samples[i].Reading
+		readings++
+		// derivatives[i] lies between samples i and i+1
+		if i < len(derivatives) {
+			derivSum += derivatives[i]
+			derivSum2 += derivatives[i] * derivatives[i]
+			derivs++
+		}
+	}
+	if readings > 0 {
+		v.baseline = readingSum / float64(readings)
+	}
+	if derivs > 1 {
+		mean := derivSum / float64(derivs)
+		v.noise = math.Sqrt(max(derivSum2/float64(derivs)-mean*mean, 0))
+	}
+	return v
+}
+
+// LiveReadout is a panel of large numbers to sit beside the scope: the power
+// of the last pulse, the running average, the baseline, the noise and the
+// heater power, readable from across the lab.
+type LiveReadout struct {
+	widget.BaseWidget
+
+	lastUpdate time.Time
+	shown      *liveValues // Values shown, nil before the first update
+	lastPower  *canvas.Text
+	average    *canvas.Text
+	averageFor *widget.Label
+	baseline   *canvas.Text
+	noise      *canvas.Text
+	heater     *canvas.Text
+	content    fyne.CanvasObject
+}
+
+// NewLiveReadout creates a readout panel showing no values.
+func NewLiveReadout() *LiveReadout {
+	big := func(c color.Color, size float32) *canvas.Text {
+		t := canvas.NewText("—", c)
+		t.TextSize = size
+		t.TextStyle = fyne.TextStyle{Bold: true, Monospace: true}
+		t.Alignment = fyne.TextAlignTrailing
+		return t
+	}
+	caption := func(s string) *widget.Label {
+		return widget.NewLabelWithStyle(s, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+
+	w := &LiveReadout{
+		lastPower:  big(readingColor, 36),
+		average:    big(readingColor, 32),
+		averageFor: caption(i18n.T("Average")),
+		baseline:   big(labelColor, 24),
+		noise:      big(labelColor, 24),
+		heater:     big(heaterPowerColor, 24),
+	}
+	w.content = container.NewVBox(
+		caption(i18n.T("Last Pulse")), w.lastPower,
+		w.averageFor, w.average,
+		widget.NewSeparator(),
+		caption(i18n.T("Baseline")), w.baseline,
+		caption(i18n.T("Noise RMS")), w.noise,
+		caption(i18n.T("Heater")), w.heater,
+	)
+	w.ExtendBaseWidget(w)
+	return w
+}
+
+// Update recomputes the numbers from the meter's data, at most every
+// liveInterval. Hidden panels skip the update. Call it on the main thread.
+func (w *LiveReadout) Update(samples []sample.Sample, derivatives []float64, pulses []meter.Pulse, active *meter.Pulse) {
+	if !w.Visible() || time.Since(w.lastUpdate) < liveInterval {
+		return
+	}
+	w.lastUpdate = time.Now()
+	w.show(computeLiveValues(samples, derivatives, pulses, active))
+}
+
+// Refresh shows the values again, e.g. in new display units.
+func (w *LiveReadout) Refresh() {
+	if w.shown != nil {
+		w.show(*w.shown)
+	}
+	w.BaseWidget.Refresh()
+}
+
+// show updates the texts to v.
+func (w *LiveReadout) show(v liveValues) {
+	w.shown = &v
+	set := func(t *canvas.Text, value float64, format func(float64) string) {
+		s := "—"
+		if !math.IsNaN(value) {
+			s = format(value)
+		}
+		if t.Text != s {
+			t.Text = s
+			t.Refresh()
+		}
+	}
+	set(w.lastPower, v.lastPower, formatPower)
+	set(w.average, v.averagePower, formatPower)
+	set(w.baseline, v.baseline, formatVoltageMV)
+	set(w.noise, v.noise, formatDerivative)
+	set(w.heater, v.heaterPower, formatPower)
+
+	caption := i18n.T("Average")
+	if v.averageCount > 0 {
+		caption = i18n.Tf("Average of %s", formatInt(int64(v.averageCount)))
+	}
+	w.averageFor.SetText(caption)
+}
+
+// CreateRenderer creates the widget renderer.
+func (w *LiveReadout) CreateRenderer() fyne.WidgetRenderer {
+	width := canvas.NewRectangle(color.Transparent)
+	width.SetMinSize(fyne.NewSize(liveWidth, 0))
+	return widget.NewSimpleRenderer(container.NewPadded(container.NewStack(width, w.content)))
+}
@@ -0,0 +1,45 @@
+package scope
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Marker is a named point on the timeline placed by the user, e.g. "aligned
+// beam" or "changed filter", to correlate the data with experiment steps.
+type Marker struct {
+	Time  time.Time
+	Label string
+}
+
+// AddMarker places a marker named label at measurement cursor 1 if it is
+// placed, so past events can be marked after scrolling back, and otherwise
+// at the newest sample, or now without data. Markers stay for the session,
+// also after their data has left the history.
+func (s *ScopeWidget) AddMarker(label string) Marker {
+	s.mu.Lock()
+	m := Marker{Time: time.Now(), Label: strings.TrimSpace(label)}
+	switch {
+	case !s.cursors[0].IsZero():
+		m.Time = s.cursors[0]
+	case len(s.history.samples) > 0:
+		m.Time = s.history.samples[len(s.history.samples)-1].Timestamp
+	}
+	i := sort.Search(len(s.markers), func(i int) bool {
+		return s.markers[i].Time.After(m.Time)
+	})
+	s.markers = slices.Insert(s.markers, i, m)
+	s.mu.Unlock()
+
+	s.Refresh()
+	return m
+}
+
+// Markers returns the markers in time order.
+func (s *ScopeWidget) Markers() []Marker {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.markers)
+}
@@ -0,0 +1,87 @@
+package scope
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+)
+
+// pulseAt returns the index of the pulse whose start and end lines enclose
+// t, preferring the newest, or -1 if none does.
+func pulseAt(pulses []meter.Pulse, t time.Time) int {
+	for i := len(pulses) - 1; i >= 0; i-- {
+		p := pulses[i]
+		if !t.Before(p.StartTime) && !t.After(p.EndTime) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pulseEnergy returns the energy of the pulse in J: its power over the time
+// the laser was on.
+func pulseEnergy(p meter.Pulse) float64 {
+	return p.AvgPower * meter.PulseDuration(p).Seconds()
+}
+
+// pulseFit rates the fit of the pulse: "noisy" if the derivatives spread
+// more than the configured threshold, otherwise "good".
+func pulseFit(p meter.Pulse) string {
+	if p.StdDevThreshold > 0 && p.StdDev > p.StdDevThreshold {
+		return "noisy"
+	}
+	return "good"
+}
+
+// pulseDetails returns the label and value rows shown for a pulse.
+func pulseDetails(p meter.Pulse) [][2]string {
+	duration := meter.PulseDuration(p)
+	return [][2]string{
+		{i18n.T("Power"), formatPower(p.AvgPower)},
+		{i18n.T("Energy"), formatEnergy(pulseEnergy(p))},
+		{i18n.T("Duration"), formatDuration(duration)},
+		{i18n.T("Fit window"), formatDuration(p.EndTime.Sub(p.StartTime))},
+		{i18n.T("Slope"), formatDerivative(p.AvgSlope) + " ±" + formatDerivative(p.StdDev)},
+		{i18n.T("Heater"), formatPower(p.AvgHeaterPower)},
+		{i18n.T("Start"), p.DetectStartTime.Format("15:04:05.000")},
+		{i18n.T("Confidence"), fmt.Sprintf("R² %.3f, %s", p.RSquared, i18n.T(pulseFit(p)))},
+	}
+}
+
+// showPulseDetails opens a popover with the details of p at the absolute
+// position pos. Its Cursors button places the measurement cursors on the
+// pulse's start and end lines.
+func (s *ScopeWidget) showPulseDetails(p meter.Pulse, pos fyne.Position) {
+	c := fyne.CurrentApp().Driver().CanvasForObject(s)
+	if c == nil {
+		return
+	}
+
+	form := widget.NewForm()
+	for _, row := range pulseDetails(p) {
+		form.Append(row[0], widget.NewLabel(row[1]))
+	}
+
+	var popup *widget.PopUp
+	cursorsBtn := widget.NewButton(i18n.T("Cursors"), func() {
+		popup.Hide()
+		s.mu.Lock()
+		s.cursors = [2]time.Time{p.StartTime, p.EndTime}
+		s.mu.Unlock()
+		s.Refresh()
+	})
+	closeBtn := widget.NewButton(i18n.T("Close"), func() { popup.Hide() })
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(i18n.Tf("Pulse #%d", p.ID), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		form,
+		container.NewHBox(cursorsBtn, closeBtn),
+	)
+	popup = widget.NewPopUp(content, c)
+	popup.ShowAtPosition(pos)
+}
@@ -0,0 +1,200 @@
+package scope
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/units"
+)
+
+// pulseLogWidth is the panel width, wide enough for all columns.
+const pulseLogWidth = 460
+
+// pulseLogColumns are the column headers and widths of the pulse table.
+var pulseLogColumns = []struct {
+	title string
+	width float32
+}{
+	{"#", 40},
+	{"Time", 80},
+	{"Duration", 80},
+	{"Power", 90},
+	{"Energy", 80},
+	{"R²", 60},
+}
+
+// PulseLog is a panel listing every pulse measured in the session, newest
+// first, so pulses stay readable after they scroll out of the scope's
+// window. The list can be copied to the clipboard or exported as CSV.
+type PulseLog struct {
+	widget.BaseWidget
+
+	// OnExport is called with the list as CSV when the CSV button is
+	// pressed, to save it.
+	OnExport func(csv string)
+
+	pulses []meter.Pulse // Finalized pulses in measurement order
+	lastID int           // ID of the newest pulse logged
+	table  *widget.Table
+	count  *widget.Label
+}
+
+// NewPulseLog creates an empty pulse log.
+func NewPulseLog() *PulseLog {
+	w := &PulseLog{count: widget.NewLabel("")}
+	w.table = widget.NewTable(
+		func() (int, int) { return len(w.pulses), len(pulseLogColumns) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(w.cell(id.Row, id.Col))
+		},
+	)
+	w.table.ShowHeaderRow = true
+	w.table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	w.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		o.(*widget.Label).SetText(i18n.T(pulseLogColumns[id.Col].title))
+	}
+	for i, c := range pulseLogColumns {
+		w.table.SetColumnWidth(i, c.width)
+	}
+	w.updateCount()
+	w.ExtendBaseWidget(w)
+	return w
+}
+
+// Update logs the pulses the meter has finalized since the last update.
+// Call it on the main thread with every meter update, also while the panel
+// is hidden.
+func (w *PulseLog) Update(pulses []meter.Pulse) {
+	added := false
+	for _, p := range pulses {
+		if p.State != meter.PulseStateFinalized || p.ID <= w.lastID {
+			continue
+		}
+		w.pulses = append(w.pulses, p)
+		w.lastID = p.ID
+		added = true
+	}
+	if added {
+		w.updateCount()
+		if w.Visible() {
+			w.table.Refresh()
+		}
+	}
+}
+
+// Restart prepares the log for a new meter, whose pulse IDs start over.
+// The pulses logged so far are kept.
+func (w *PulseLog) Restart() {
+	w.lastID = 0
+}
+
+// Clear removes all pulses from the log.
+func (w *PulseLog) Clear() {
+	w.pulses = nil
+	w.updateCount()
+	w.table.Refresh()
+}
+
+// CSV returns the log in measurement order as CSV with a header row, with
+// power and energy in the display units, e.g. power_mw and energy_mj.
+func (w *PulseLog) CSV() string {
+	_, powerUnit := units.Power(0)
+	_, energyUnit := units.Energy(0)
+	decimals := 3
+	if powerUnit == units.Watt {
+		decimals = 6
+	}
+
+	var b strings.Builder
+	cw := csv.NewWriter(&b)
+	cw.Write([]string{"n", "start", "duration_s", "power_" + strings.ToLower(string(powerUnit)),
+		"energy_" + strings.ToLower(energyUnit), "r_squared", "fit"})
+	for i, p := range w.pulses {
+		power, _ := units.Power(p.AvgPower)
+		energy, _ := units.Energy(pulseEnergy(p))
+		cw.Write([]string{
+			strconv.Itoa(i + 1),
+			p.DetectStartTime.Format("2006-01-02 15:04:05.000"),
+			strconv.FormatFloat(meter.PulseDuration(p).Seconds(), 'f', 3, 64),
+			formatCSVValue(power, decimals),
+			formatCSVValue(energy, decimals),
+			strconv.FormatFloat(p.RSquared, 'f', 4, 64),
+			pulseFit(p),
+		})
+	}
+	cw.Flush()
+	return b.String()
+}
+
+// formatCSVValue formats v with decimals, empty if it is infinite, as the
+// dBm of no power.
+func formatCSVValue(v float64, decimals int) string {
+	if math.IsInf(v, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+// cell returns the text of a table cell. Row 0 is the newest pulse.
+func (w *PulseLog) cell(row, col int) string {
+	n := len(w.pulses) - row
+	if n < 1 {
+		return ""
+	}
+	p := w.pulses[n-1]
+	switch col {
+	case 0:
+		return strconv.Itoa(n)
+	case 1:
+		return p.DetectStartTime.Format("15:04:05")
+	case 2:
+		return formatDuration(meter.PulseDuration(p))
+	case 3:
+		return formatPower(p.AvgPower)
+	case 4:
+		return formatEnergy(pulseEnergy(p))
+	case 5:
+		return fmt.Sprintf("%.3f", p.RSquared)
+	}
+	return ""
+}
+
+// updateCount shows the number of pulses in the title.
+func (w *PulseLog) updateCount() {
+	w.count.SetText(i18n.Tf("Pulses (%d)", len(w.pulses)))
+}
+
+// copy puts the log on the clipboard as CSV.
+func (w *PulseLog) copy() {
+	fyne.CurrentApp().Clipboard().SetContent(w.CSV())
+}
+
+// CreateRenderer creates the widget renderer.
+func (w *PulseLog) CreateRenderer() fyne.WidgetRenderer {
+	copyBtn := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), w.copy)
+	exportBtn := widget.NewButtonWithIcon("CSV", theme.DocumentSaveIcon(), func() {
+		if w.OnExport != nil {
+			w.OnExport(w.CSV())
+		}
+	})
+	clearBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), w.Clear)
+
+	width := canvas.NewRectangle(color.Transparent)
+	width.SetMinSize(fyne.NewSize(pulseLogWidth, 0))
+	header := container.NewBorder(nil, nil, w.count, container.NewHBox(copyBtn, exportBtn, clearBtn))
+	return widget.NewSimpleRenderer(container.NewPadded(container.NewStack(width, container.NewBorder(header, nil, nil, nil, w.table))))
+}
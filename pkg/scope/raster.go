@@ -0,0 +1,209 @@
+package scope
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+// The raster backend draws all traces of a frame into one image instead of
+// creating a canvas.Line per segment, which is much cheaper with thousands
+// of points and lets the lines be anti-aliased.
+
+// rasterPath is a polyline in widget coordinates, or a band filled between
+// points and lower when lower is set. The color's alpha is its opacity.
+type rasterPath struct {
+	points []fyne.Position
+	lower  []fyne.Position
+	color  color.RGBA
+	width  float32
+}
+
+// traceRaster is the image layer holding the traces of the current frame.
+type traceRaster struct {
+	raster *canvas.Raster
+
+	mu    sync.Mutex
+	paths []rasterPath // Drawn by the raster, set by the renderer
+	size  fyne.Size    // Widget size the paths were laid out for
+	img   *image.RGBA  // Reused between frames of the same pixel size
+	mask  *image.Alpha // Coverage of the path being drawn
+}
+
+// newTraceRaster creates an empty trace layer.
+func newTraceRaster() *traceRaster {
+	t := &traceRaster{}
+	t.raster = canvas.NewRaster(t.generate)
+	return t
+}
+
+// update replaces the paths and redraws the layer over size.
+func (t *traceRaster) update(paths []rasterPath, size fyne.Size) {
+	t.mu.Lock()
+	t.paths, t.size = paths, size
+	t.mu.Unlock()
+
+	t.raster.Resize(size)
+	t.raster.Move(fyne.NewPos(0, 0))
+	t.raster.Refresh()
+}
+
+// generate draws the paths into a w x h pixel image.
+func (t *traceRaster) generate(w, h int) image.Image {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.img == nil || t.img.Rect.Dx() != w || t.img.Rect.Dy() != h {
+		t.img = image.NewRGBA(image.Rect(0, 0, w, h))
+		t.mask = image.NewAlpha(t.img.Rect)
+	} else {
+		clear(t.img.Pix)
+	}
+	if t.size.Width <= 0 || t.size.Height <= 0 {
+		return t.img
+	}
+
+	scale := float64(w) / float64(t.size.Width)
+	for _, p := range t.paths {
+		drawPath(t.img, t.mask, p, scale)
+	}
+	return t.img
+}
+
+// drawPath draws an anti-aliased polyline scaled to pixels. Coverage is
+// collected in mask first, so overlapping segment ends at the joints are
+// not blended twice.
+func drawPath(img *image.RGBA, mask *image.Alpha, p rasterPath, scale float64) {
+	if len(p.points) < 2 {
+		return
+	}
+	halfWidth := max(float64(p.width)*scale, 1) / 2
+
+	// Pixel bounds of the path, grown by the line width
+	bbox := image.Rectangle{}
+	for _, points := range [][]fyne.Position{p.points, p.lower} {
+		for _, pt := range points {
+			px := image.Pt(int(float64(pt.X)*scale), int(float64(pt.Y)*scale))
+			bbox = bbox.Union(image.Rectangle{Min: px, Max: px.Add(image.Pt(1, 1))})
+		}
+	}
+	reach := int(math.Ceil(halfWidth)) + 1
+	bbox = bbox.Inset(-reach).Intersect(img.Rect)
+	if bbox.Empty() {
+		return
+	}
+
+	for y := bbox.Min.Y; y < bbox.Max.Y; y++ {
+		clear(mask.Pix[mask.PixOffset(bbox.Min.X, y):mask.PixOffset(bbox.Max.X, y)])
+	}
+	if p.lower != nil {
+		coverBand(mask, bbox, p.points, p.lower, scale)
+	} else {
+		for i := 1; i < len(p.points); i++ {
+			a, b := p.points[i-1], p.points[i]
+			coverSegment(mask, bbox,
+				float64(a.X)*scale, float64(a.Y)*scale,
+				float64(b.X)*scale, float64(b.Y)*scale,
+				halfWidth)
+		}
+	}
+
+	// Composite the color over the image through the mask
+	c := p.color
+	for y := bbox.Min.Y; y < bbox.Max.Y; y++ {
+		for x := bbox.Min.X; x < bbox.Max.X; x++ {
+			coverage := mask.Pix[mask.PixOffset(x, y)]
+			if coverage == 0 {
+				continue
+			}
+			a := float64(c.A) / 255 * float64(coverage) / 255
+			inv := 1 - a
+			i := img.PixOffset(x, y)
+			pix := img.Pix[i : i+4 : i+4]
+			pix[0] = uint8(float64(c.R)*a + float64(pix[0])*inv)
+			pix[1] = uint8(float64(c.G)*a + float64(pix[1])*inv)
+			pix[2] = uint8(float64(c.B)*a + float64(pix[2])*inv)
+			pix[3] = uint8(255*a + float64(pix[3])*inv)
+		}
+	}
+}
+
+// coverSegment raises mask to the coverage of a segment from (x0,y0) to
+// (x1,y1) with round caps, limited to bbox. Coverage falls off over one
+// pixel at the edges.
+func coverSegment(mask *image.Alpha, bbox image.Rectangle, x0, y0, x1, y1, halfWidth float64) {
+	reach := halfWidth + 1
+	minX := max(int(math.Floor(min(x0, x1)-reach)), bbox.Min.X)
+	maxX := min(int(math.Ceil(max(x0, x1)+reach)), bbox.Max.X-1)
+	minY := max(int(math.Floor(min(y0, y1)-reach)), bbox.Min.Y)
+	maxY := min(int(math.Ceil(max(y0, y1)+reach)), bbox.Max.Y-1)
+
+	dx, dy := x1-x0, y1-y0
+	lengthSq := dx*dx + dy*dy
+	for y := minY; y <= maxY; y++ {
+		py := float64(y) + 0.5
+		for x := minX; x <= maxX; x++ {
+			px := float64(x) + 0.5
+
+			// Distance from the pixel centre to the segment
+			u := 0.0
+			if lengthSq > 0 {
+				u = min(max(((px-x0)*dx+(py-y0)*dy)/lengthSq, 0), 1)
+			}
+			ex, ey := px-(x0+u*dx), py-(y0+u*dy)
+			coverage := min(halfWidth+0.5-math.Sqrt(ex*ex+ey*ey), 1)
+			if coverage <= 0 {
+				continue
+			}
+			i := mask.PixOffset(x, y)
+			mask.Pix[i] = max(mask.Pix[i], uint8(coverage*255))
+		}
+	}
+}
+
+// coverBand sets mask to the coverage of the area between the upper and
+// lower polylines, which share their X coordinates, limited to bbox. Each
+// pixel column is covered between the bounds interpolated at its centre.
+func coverBand(mask *image.Alpha, bbox image.Rectangle, upper, lower []fyne.Position, scale float64) {
+	n := min(len(upper), len(lower))
+	seg := 1
+	for x := bbox.Min.X; x < bbox.Max.X; x++ {
+		px := float64(x) + 0.5
+		for seg < n-1 && float64(upper[seg].X)*scale < px {
+			seg++
+		}
+		x0, x1 := float64(upper[seg-1].X)*scale, float64(upper[seg].X)*scale
+		if px < x0 || px > x1 {
+			continue
+		}
+		u := 0.0
+		if x1 > x0 {
+			u = (px - x0) / (x1 - x0)
+		}
+		top := lerp(float64(upper[seg-1].Y), float64(upper[seg].Y), u) * scale
+		bottom := lerp(float64(lower[seg-1].Y), float64(lower[seg].Y), u) * scale
+		if top > bottom {
+			top, bottom = bottom, top
+		}
+
+		minY := max(int(math.Floor(top)), bbox.Min.Y)
+		maxY := min(int(math.Ceil(bottom)), bbox.Max.Y)
+		for y := minY; y < maxY; y++ {
+			coverage := min(bottom, float64(y+1)) - max(top, float64(y))
+			if coverage <= 0 {
+				continue
+			}
+			i := mask.PixOffset(x, y)
+			mask.Pix[i] = max(mask.Pix[i], uint8(min(coverage, 1)*255))
+		}
+	}
+}
+
+// lerp interpolates linearly from a to b.
+func lerp(a, b, u float64) float64 {
+	return a + (b-a)*u
+}
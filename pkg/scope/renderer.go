@@ -1,15 +1,25 @@
 package scope
 
 import (
-	"fmt"
 	"image/color"
 	"math"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
 	"github.com/itohio/golpm/pkg/meter"
 	"github.com/itohio/golpm/pkg/sample"
+	"github.com/itohio/golpm/pkg/units"
+)
+
+// Plot margins - more space on the sides for the Y-axis labels
+const (
+	marginLeft   = float32(60.0)
+	marginRight  = float32(60.0)
+	marginTop    = float32(20.0)
+	marginBottom = float32(40.0)
 )
 
 // scopeRenderer renders the scope widget.
@@ -34,6 +44,15 @@ type scopeRenderer struct {
 	heaterVoltLabel    *canvas.Text
 	timestampDiffLabel *canvas.Text
 
+	// Returns to the live view, shown while zoomed or panned
+	liveBtn *widget.Button
+
+	// Raster backend: traces of the frame are collected in paths and drawn
+	// into one image layer instead of a canvas.Line per segment
+	useRaster bool
+	traces    *traceRaster
+	paths     []rasterPath
+
 	// Grid lines
 	gridLines []*canvas.Line
 	gridTexts []*canvas.Text
@@ -69,15 +88,43 @@ func (r *scopeRenderer) Refresh() {
 	r.scope.mu.RLock()
 	samples := r.scope.displaySamples
 	derivatives := r.scope.displayDerivatives
+	readingEnvelope := r.scope.readingEnvelope
+	derivativeEnvelope := r.scope.derivativeEnvelope
+	heaterLane := r.scope.cfg.Scope.HeaterLane && len(r.scope.samples) > 0
+	heaterSpans := r.scope.heaterSpans
 	pulses := r.scope.pulses
 	activePulse := r.scope.activePulse // May be nil
 	heaterPower := r.scope.heaterPower
+	truth := r.scope.truth
 	sampleYMin := r.scope.sampleYMin
 	sampleYMax := r.scope.sampleYMax
 	derivativeYMin := r.scope.derivativeYMin
 	derivativeYMax := r.scope.derivativeYMax
 	xMin := r.scope.xMin
 	xMax := r.scope.xMax
+	live := r.scope.view.live()
+	frozen := r.scope.frozen
+	triggerStatus := r.scope.trigger.status()
+	triggerAt, triggerShown := r.scope.triggerMarker()
+	rolling := r.scope.trigger.mode == TriggerRoll
+	var sweepAt time.Time
+	if r.scope.sweeping() && len(r.scope.samples) > 0 {
+		sweepAt = r.scope.samples[len(r.scope.samples)-1].Timestamp
+	}
+	styles := newTraceStyles(r.scope.cfg.Scope)
+	theme := newScopeTheme(r.scope.cfg.Scope)
+	split := r.scope.cfg.Scope.Layout == "split" && styles.derivative.show
+	r.useRaster = r.scope.cfg.Scope.Renderer != "lines"
+	readout, showReadout := r.scope.readout()
+	cursors := r.scope.cursors
+	markers := r.scope.markers
+	var (
+		measurement cursorMeasurement
+		measured    bool
+	)
+	if !cursors[0].IsZero() && !cursors[1].IsZero() {
+		measurement, measured = measureCursors(r.scope.samples, cursors[0], cursors[1])
+	}
 	// Get heater voltage from latest sample (if available)
 	var heaterVoltage float64
 	if len(samples) > 0 {
@@ -106,33 +153,82 @@ func (r *scopeRenderer) Refresh() {
 	r.heaterLabel = nil
 	r.heaterVoltLabel = nil
 	r.timestampDiffLabel = nil
-
-	// Calculate margins - need more space on right for derivative axis labels
-	marginLeft := float32(60.0)
-	marginRight := float32(60.0) // Increased for right Y-axis labels
-	marginTop := float32(20.0)
-	marginBottom := float32(40.0)
+	r.paths = nil // Owned by the trace layer once handed over
+	if r.useRaster {
+		defer func() { r.traces.update(r.paths, size) }()
+	}
 
 	plotWidth := size.Width - marginLeft - marginRight
 	plotHeight := size.Height - marginTop - marginBottom
 	plotX := marginLeft
 	plotY := marginTop
 
+	// The split layout draws the derivative in a sub-plot below the reading,
+	// sharing the time axis, instead of over it
+	mainY, mainHeight := plotY, plotHeight
+	derivY, derivHeight := plotY, plotHeight
+	if split {
+		derivHeight = plotHeight * subPlotFraction
+		mainHeight = plotHeight - derivHeight - subPlotGap
+		derivY = plotY + mainHeight + subPlotGap
+	}
+
 	// Draw grid with dual Y-axes
-	r.drawGrid(plotX, plotY, plotWidth, plotHeight, sampleYMin, sampleYMax, derivativeYMin, derivativeYMax, xMin, xMax)
+	if r.grid.FillColor != theme.background {
+		r.grid.FillColor = theme.background
+		r.grid.Refresh()
+	}
+	if split {
+		r.drawSplitGrid(plotX, mainY, mainHeight, derivY, derivHeight, plotWidth, sampleYMin, sampleYMax, derivativeYMin, derivativeYMax, xMin, xMax, styles, theme)
+	} else {
+		r.drawGrid(plotX, plotY, plotWidth, plotHeight, sampleYMin, sampleYMax, derivativeYMin, derivativeYMax, xMin, xMax, styles, theme)
+	}
+
+	// Heater states below the time axis, lined up with the thermal response
+	if heaterLane {
+		r.drawHeaterLane(plotX, plotY, plotWidth, plotHeight, heaterSpans, xMin, xMax, styles.heaterPower, theme)
+	}
 
-	// Draw samples (orange line) using left Y-axis - USE THE SAME METHOD
+	// Heater voltage and power have no axis; each is scaled to its own range,
+	// listed in the legend, and drawn below the reading and derivative
+	var legend []legendEntry
 	if len(samples) > 1 {
-		samplePoints := make([]dataPoint, len(samples))
-		for i, s := range samples {
-			samplePoints[i] = dataPoint{time: s.Timestamp, value: s.Reading}
+		for _, trace := range []struct {
+			name   string
+			style  traceStyle
+			value  func(sample.Sample) float64
+			format func(float64) string
+		}{
+			{i18n.T("voltage"), styles.voltage, func(s sample.Sample) float64 { return s.Voltage }, formatVoltage},
+			{i18n.T("heater"), styles.heaterPower, func(s sample.Sample) float64 { return s.HeaterPower }, formatPower},
+		} {
+			if !trace.style.show {
+				continue
+			}
+			points := samplePoints(samples, trace.value)
+			yMin, yMax := pointsRange(points)
+			r.drawCurve(plotX, mainY, plotWidth, mainHeight, points, yMin, yMax, xMin, xMax, trace.style.color, trace.style.width)
+			legend = append(legend, legendEntry{
+				text:  trace.name + " " + trace.format(yMin) + " – " + trace.format(yMax),
+				color: trace.style.color,
+			})
 		}
-		r.drawCurve(plotX, plotY, plotWidth, plotHeight, samplePoints, sampleYMin, sampleYMax, xMin, xMax,
-			color.RGBA{R: 255, G: 165, B: 0, A: 255}, 1.5) // Orange
 	}
 
-	// Draw derivatives (light blue, thinner line) using right Y-axis - USE THE SAME METHOD
-	if len(derivatives) > 0 && len(samples) > 1 {
+	// Draw samples using left Y-axis - USE THE SAME METHOD, over the range
+	// of the readings merged into each point
+	if len(samples) > 1 && styles.reading.show {
+		if len(readingEnvelope) == len(samples) {
+			upper, lower := envelopePoints(readingEnvelope, func(i int) time.Time { return samples[i].Timestamp })
+			r.drawBand(plotX, mainY, plotWidth, mainHeight, upper, lower, sampleYMin, sampleYMax, xMin, xMax, styles.reading.color)
+		}
+		points := samplePoints(samples, func(s sample.Sample) float64 { return s.Reading })
+		r.drawCurve(plotX, mainY, plotWidth, mainHeight, points, sampleYMin, sampleYMax, xMin, xMax,
+			styles.reading.color, styles.reading.width)
+	}
+
+	// Draw derivatives using right Y-axis - USE THE SAME METHOD
+	if len(derivatives) > 0 && len(samples) > 1 && styles.derivative.show {
 		derivativePoints := make([]dataPoint, 0, len(derivatives))
 		for i, deriv := range derivatives {
 			if i+1 >= len(samples) {
@@ -142,89 +238,160 @@ func (r *scopeRenderer) Refresh() {
 			midTime := samples[i].Timestamp.Add(samples[i+1].Timestamp.Sub(samples[i].Timestamp) / 2)
 			derivativePoints = append(derivativePoints, dataPoint{time: midTime, value: deriv})
 		}
-		r.drawCurve(plotX, plotY, plotWidth, plotHeight, derivativePoints, derivativeYMin, derivativeYMax, xMin, xMax,
-			color.RGBA{R: 100, G: 200, B: 255, A: 255}, 1.0) // Light blue, thinner
+		if len(derivativeEnvelope) >= len(derivativePoints) {
+			upper, lower := envelopePoints(derivativeEnvelope[:len(derivativePoints)], func(i int) time.Time { return derivativePoints[i].time })
+			r.drawBand(plotX, derivY, plotWidth, derivHeight, upper, lower, derivativeYMin, derivativeYMax, xMin, xMax, styles.derivative.color)
+		}
+		r.drawCurve(plotX, derivY, plotWidth, derivHeight, derivativePoints, derivativeYMin, derivativeYMax, xMin, xMax,
+			styles.derivative.color, styles.derivative.width)
 	}
+	r.drawLegend(plotX, mainY, mainHeight, legend)
 
 	// Draw pulses (dark blue vertical lines)
-	r.drawPulses(plotX, plotY, plotWidth, plotHeight, pulses, samples, xMin, xMax)
+	if theme.pulseMarkers.show {
+		r.drawPulses(plotX, plotY, plotWidth, plotHeight, pulses, samples, xMin, xMax, theme.pulseMarkers)
+	}
 
 	// Draw fitted lines for pulses (use derivative Y-axis)
-	r.drawFittedLines(plotX, plotY, plotWidth, plotHeight, pulses, samples, derivatives, derivativeYMin, derivativeYMax, xMin, xMax)
+	r.drawFittedLines(plotX, derivY, plotWidth, derivHeight, pulses, samples, derivatives, derivativeYMin, derivativeYMax, xMin, xMax)
 
 	// Draw power labels (use derivative Y-axis for positioning - labels go on fitted line)
-	r.drawPowerLabels(plotX, plotY, plotWidth, plotHeight, pulses, samples, derivativeYMin, derivativeYMax, xMin, xMax)
+	r.drawPowerLabels(plotX, derivY, plotWidth, derivHeight, pulses, samples, derivativeYMin, derivativeYMax, xMin, xMax)
 
 	// Draw active pulse (Fitting or Updating) in gray/dashed to show what's being tracked
 	if activePulse != nil {
-		r.drawActivePulse(plotX, plotY, plotWidth, plotHeight, activePulse, samples, derivatives, derivativeYMin, derivativeYMax, xMin, xMax)
+		r.drawActivePulse(plotX, derivY, plotWidth, derivHeight, activePulse, samples, derivatives, derivativeYMin, derivativeYMax, xMin, xMax)
+	}
+
+	// Draw ground truth of a simulated device with the error of each pulse
+	if len(truth) > 0 {
+		r.drawTruth(plotX, mainY, plotWidth, mainHeight, truth, pulses, xMin, xMax)
 	}
 
 	// Draw heater power and voltage indicator (use sample Y-axis)
 	if heaterPower > 0 {
-		r.drawHeaterPower(plotX, plotY, plotWidth, plotHeight, heaterPower, heaterVoltage, sampleYMin, sampleYMax)
+		r.drawHeaterPower(plotX, mainY, plotWidth, mainHeight, heaterPower, heaterVoltage, sampleYMin, sampleYMax)
 	}
 
 	// Draw timestamp difference between 10 samples
-	r.drawTimestampDiff(plotX, plotY, plotWidth, plotHeight, timestampDiff)
+	r.drawTimestampDiff(plotX, plotY, plotWidth, plotHeight, timestampDiff, theme.label)
+
+	// Markers of the experiment steps
+	r.drawMarkers(plotX, plotY, plotWidth, plotHeight, markers, xMin, xMax)
+
+	// Measurement cursors with the deltas between them
+	r.drawCursors(plotX, plotY, plotWidth, plotHeight, cursors, measurement, measured, xMin, xMax)
+
+	if frozen {
+		r.drawFrozen(plotX, plotY, plotWidth)
+	}
+
+	// Write position of the sweep
+	if !sweepAt.IsZero() {
+		r.drawSweepCursor(plotX, plotY, plotWidth, plotHeight, sweepAt, xMin, xMax)
+	}
+
+	// Trigger point of the capture on display and the trigger state
+	if triggerShown {
+		r.drawTriggerMarker(plotX, plotY, plotWidth, plotHeight, triggerAt, xMin, xMax)
+	}
+	if triggerStatus != "" {
+		r.drawTriggerStatus(plotX, plotY, plotWidth, triggerStatus)
+	}
+
+	// Crosshair through the sample under the mouse pointer
+	if showReadout {
+		r.drawCrosshair(plotX, mainY, plotWidth, mainHeight, readout, sampleYMin, sampleYMax, xMin, xMax)
+	}
+
+	// Live button on top of everything else while the view is detached;
+	// triggered captures are detached by design
+	if !live && rolling {
+		btnSize := r.liveBtn.MinSize()
+		r.liveBtn.Resize(btnSize)
+		r.liveBtn.Move(fyne.NewPos(plotX+plotWidth-btnSize.Width-5, plotY+plotHeight-btnSize.Height-5))
+		r.objects = append(r.objects, r.liveBtn)
+	}
 }
 
 // drawGrid draws the oscilloscope-style grid with dual Y-axes.
 // Left Y-axis: samples (voltage in mV)
 // Right Y-axis: derivatives (rate of change in mV/s)
 // Uses the SAME method for calculating labels for both axes.
-func (r *scopeRenderer) drawGrid(plotX, plotY, plotWidth, plotHeight float32, sampleYMin, sampleYMax, derivativeYMin, derivativeYMax float64, xMin, xMax time.Time) {
-	// Horizontal grid lines (shared by both axes)
-	numHLines := 8
-	for i := range numHLines + 1 {
-		y := plotY + float32(i)*plotHeight/float32(numHLines)
-		line := canvas.NewLine(color.RGBA{R: 40, G: 40, B: 40, A: 255})
+func (r *scopeRenderer) drawGrid(plotX, plotY, plotWidth, plotHeight float32, sampleYMin, sampleYMax, derivativeYMin, derivativeYMax float64, xMin, xMax time.Time, styles traceStyles, theme scopeTheme) {
+	reading := &axisLabels{min: sampleYMin, max: sampleYMax, color: styles.reading.color, format: formatVoltageMV}
+	derivative := &axisLabels{min: derivativeYMin, max: derivativeYMax, color: styles.derivative.color, format: formatDerivative}
+	r.drawHGrid(plotX, plotY, plotWidth, plotHeight, theme.hDivisions, reading, derivative, theme)
+	r.drawVGrid(plotX, plotY, plotWidth, plotHeight, xMin, xMax, true, theme)
+}
+
+// axisLabels are the tick labels of a Y-axis.
+type axisLabels struct {
+	min, max float64
+	color    color.RGBA
+	format   func(float64) string
+}
+
+// drawHGrid draws divisions horizontal grid lines, labelled with the left
+// and right Y-axes unless nil.
+func (r *scopeRenderer) drawHGrid(plotX, plotY, plotWidth, plotHeight float32, divisions int, left, right *axisLabels, theme scopeTheme) {
+	for i := range divisions + 1 {
+		y := plotY + float32(i)*plotHeight/float32(divisions)
+		line := canvas.NewLine(theme.grid)
 		line.Position1 = fyne.NewPos(plotX, y)
 		line.Position2 = fyne.NewPos(plotX+plotWidth, y)
-		line.StrokeWidth = 1
+		line.StrokeWidth = theme.gridWidth
 		r.gridLines = append(r.gridLines, line)
 		r.objects = append(r.objects, line)
 
-		// Left Y-axis label (samples - voltage in mV)
-		// Calculate evenly-spaced tick between min and max
-		sampleValue := calculateAxisLabel(sampleYMin, sampleYMax, numHLines, i)
-		sampleText := canvas.NewText(formatVoltageMV(sampleValue), color.RGBA{R: 255, G: 165, B: 0, A: 255}) // Orange for samples
-		sampleText.TextSize = 10
-		sampleText.Alignment = fyne.TextAlignTrailing
-		sampleText.Move(fyne.NewPos(plotX-5, y-6))
-		r.gridTexts = append(r.gridTexts, sampleText)
-		r.objects = append(r.objects, sampleText)
-
-		// Right Y-axis label (derivatives - rate in mV/s)
-		// Calculate evenly-spaced tick between min and max using the SAME method
-		derivativeValue := calculateAxisLabel(derivativeYMin, derivativeYMax, numHLines, i)
-		derivativeText := canvas.NewText(formatDerivative(derivativeValue), color.RGBA{R: 100, G: 200, B: 255, A: 255}) // Light blue for derivatives
-		derivativeText.TextSize = 10
-		derivativeText.Alignment = fyne.TextAlignLeading
-		derivativeText.Move(fyne.NewPos(plotX+plotWidth+5, y-6))
-		r.gridTexts = append(r.gridTexts, derivativeText)
-		r.objects = append(r.objects, derivativeText)
-	}
-
-	// Vertical grid lines (time)
-	numVLines := 10
+		// Evenly-spaced ticks between min and max
+		if left != nil {
+			text := canvas.NewText(left.format(calculateAxisLabel(left.min, left.max, divisions, i)), left.color)
+			text.TextSize = 10
+			text.Alignment = fyne.TextAlignTrailing
+			text.Move(fyne.NewPos(plotX-5, y-6))
+			r.gridTexts = append(r.gridTexts, text)
+			r.objects = append(r.objects, text)
+		}
+		if right != nil {
+			text := canvas.NewText(right.format(calculateAxisLabel(right.min, right.max, divisions, i)), right.color)
+			text.TextSize = 10
+			text.Alignment = fyne.TextAlignLeading
+			text.Move(fyne.NewPos(plotX+plotWidth+5, y-6))
+			r.gridTexts = append(r.gridTexts, text)
+			r.objects = append(r.objects, text)
+		}
+	}
+}
+
+// drawVGrid draws the vertical grid lines of the time axis, with their time
+// labels below the plot if labels is set.
+func (r *scopeRenderer) drawVGrid(plotX, plotY, plotWidth, plotHeight float32, xMin, xMax time.Time, labels bool, theme scopeTheme) {
+	numVLines := theme.vDivisions
 	for i := range numVLines + 1 {
 		x := plotX + float32(i)*plotWidth/float32(numVLines)
-		line := canvas.NewLine(color.RGBA{R: 40, G: 40, B: 40, A: 255})
+		line := canvas.NewLine(theme.grid)
 		line.Position1 = fyne.NewPos(x, plotY)
 		line.Position2 = fyne.NewPos(x, plotY+plotHeight)
-		line.StrokeWidth = 1
+		line.StrokeWidth = theme.gridWidth
 		r.gridLines = append(r.gridLines, line)
 		r.objects = append(r.objects, line)
+		if !labels {
+			continue
+		}
 
 		// X-axis label
 		timeRange := xMax.Sub(xMin)
 		timeOffset := time.Duration(float64(i) * float64(timeRange) / float64(numVLines))
 		timeVal := xMin.Add(timeOffset)
-		text := canvas.NewText(formatTime(timeVal.Sub(xMin)), color.RGBA{R: 150, G: 150, B: 150, A: 255})
+		label := formatTime(timeOffset)
+		if r.scope.cfg.Scope.TimeAxis == "clock" {
+			label = formatClock(timeVal, timeRange/time.Duration(numVLines))
+		}
+		text := canvas.NewText(label, theme.label)
 		text.TextSize = 10
-		text.Alignment = fyne.TextAlignCenter
-		text.Move(fyne.NewPos(x-20, plotY+plotHeight+5))
+		labelWidth := fyne.MeasureText(label, text.TextSize, text.TextStyle).Width
+		text.Move(fyne.NewPos(x-labelWidth/2, plotY+plotHeight+5))
 		r.gridTexts = append(r.gridTexts, text)
 		r.objects = append(r.objects, text)
 	}
@@ -242,7 +409,62 @@ func (r *scopeRenderer) drawCurve(plotX, plotY, plotWidth, plotHeight float32, p
 		return
 	}
 
-	// Calculate positions for all points
+	positions := plotPositions(plotX, plotY, plotWidth, plotHeight, points, yMin, yMax, xMin, xMax)
+
+	// Queue the curve for the trace layer, which takes this place in the
+	// drawing order when the first curve of the frame is queued
+	if r.useRaster {
+		r.queuePath(rasterPath{points: positions, color: color, width: strokeWidth})
+		return
+	}
+
+	// Draw connected line segments
+	for i := range len(positions) - 1 {
+		line := canvas.NewLine(color)
+		line.Position1 = positions[i]
+		line.Position2 = positions[i+1]
+		line.StrokeWidth = strokeWidth
+		r.objects = append(r.objects, line)
+	}
+}
+
+// drawBand fills the area between the upper and lower points, e.g. the
+// envelope of a downsampled trace, in a translucent c.
+func (r *scopeRenderer) drawBand(plotX, plotY, plotWidth, plotHeight float32, upper, lower []dataPoint, yMin, yMax float64, xMin, xMax time.Time, c color.RGBA) {
+	if len(upper) < 2 || len(lower) != len(upper) {
+		return
+	}
+	top := plotPositions(plotX, plotY, plotWidth, plotHeight, upper, yMin, yMax, xMin, xMax)
+	bottom := plotPositions(plotX, plotY, plotWidth, plotHeight, lower, yMin, yMax, xMin, xMax)
+	c.A = envelopeAlpha
+
+	if r.useRaster {
+		r.queuePath(rasterPath{points: top, lower: bottom, color: c})
+		return
+	}
+
+	// One rectangle per window, from the previous window's end to its own
+	fill := color.NRGBA{R: c.R, G: c.G, B: c.B, A: c.A}
+	for i := 1; i < len(top); i++ {
+		rect := canvas.NewRectangle(fill)
+		y0, y1 := min(top[i].Y, bottom[i].Y), max(top[i].Y, bottom[i].Y)
+		rect.Move(fyne.NewPos(top[i-1].X, y0))
+		rect.Resize(fyne.NewSize(top[i].X-top[i-1].X, max(y1-y0, 1)))
+		r.objects = append(r.objects, rect)
+	}
+}
+
+// queuePath queues a path for the trace layer, which takes this place in
+// the drawing order when the first path of the frame is queued.
+func (r *scopeRenderer) queuePath(p rasterPath) {
+	if r.paths == nil {
+		r.objects = append(r.objects, r.traces.raster)
+	}
+	r.paths = append(r.paths, p)
+}
+
+// plotPositions maps data points to widget coordinates.
+func plotPositions(plotX, plotY, plotWidth, plotHeight float32, points []dataPoint, yMin, yMax float64, xMin, xMax time.Time) []fyne.Position {
 	positions := make([]fyne.Position, 0, len(points))
 	timeRange := xMax.Sub(xMin).Seconds()
 	yRange := yMax - yMin
@@ -260,19 +482,24 @@ func (r *scopeRenderer) drawCurve(plotX, plotY, plotWidth, plotHeight float32, p
 		}
 		positions = append(positions, fyne.NewPos(x, y))
 	}
+	return positions
+}
 
-	// Draw connected line segments
-	for i := range len(positions) - 1 {
-		line := canvas.NewLine(color)
-		line.Position1 = positions[i]
-		line.Position2 = positions[i+1]
-		line.StrokeWidth = strokeWidth
-		r.objects = append(r.objects, line)
-	}
+// envelopePoints returns the upper and lower bounds of envelope as points
+// at the times of the windows.
+func envelopePoints(envelope []sample.Envelope, at func(i int) time.Time) (upper, lower []dataPoint) {
+	upper = make([]dataPoint, len(envelope))
+	lower = make([]dataPoint, len(envelope))
+	for i, e := range envelope {
+		t := at(i)
+		upper[i] = dataPoint{time: t, value: e.Max}
+		lower[i] = dataPoint{time: t, value: e.Min}
+	}
+	return upper, lower
 }
 
 // drawPulses draws vertical lines for detected pulses (dark blue).
-func (r *scopeRenderer) drawPulses(plotX, plotY, plotWidth, plotHeight float32, pulses []meter.Pulse, samples []sample.Sample, xMin, xMax time.Time) {
+func (r *scopeRenderer) drawPulses(plotX, plotY, plotWidth, plotHeight float32, pulses []meter.Pulse, samples []sample.Sample, xMin, xMax time.Time, style traceStyle) {
 	if len(samples) == 0 {
 		return
 	}
@@ -292,10 +519,10 @@ func (r *scopeRenderer) drawPulses(plotX, plotY, plotWidth, plotHeight float32,
 		// Draw start line (only if within visible range)
 		if !startTime.Before(xMin) && !startTime.After(xMax) {
 			xStart := plotX + float32(startTime.Sub(xMin).Seconds()/timeRange)*plotWidth
-			lineStart := canvas.NewLine(color.RGBA{R: 0, G: 100, B: 200, A: 255}) // Dark blue
+			lineStart := canvas.NewLine(style.color)
 			lineStart.Position1 = fyne.NewPos(xStart, plotY)
 			lineStart.Position2 = fyne.NewPos(xStart, plotY+plotHeight)
-			lineStart.StrokeWidth = 1
+			lineStart.StrokeWidth = style.width
 			r.pulseLines = append(r.pulseLines, lineStart)
 			r.objects = append(r.objects, lineStart)
 		}
@@ -303,10 +530,10 @@ func (r *scopeRenderer) drawPulses(plotX, plotY, plotWidth, plotHeight float32,
 		// Draw end line (only if within visible range)
 		if !endTime.Before(xMin) && !endTime.After(xMax) {
 			xEnd := plotX + float32(endTime.Sub(xMin).Seconds()/timeRange)*plotWidth
-			lineEnd := canvas.NewLine(color.RGBA{R: 0, G: 100, B: 200, A: 255}) // Dark blue
+			lineEnd := canvas.NewLine(style.color)
 			lineEnd.Position1 = fyne.NewPos(xEnd, plotY)
 			lineEnd.Position2 = fyne.NewPos(xEnd, plotY+plotHeight)
-			lineEnd.StrokeWidth = 1
+			lineEnd.StrokeWidth = style.width
 			r.pulseLines = append(r.pulseLines, lineEnd)
 			r.objects = append(r.objects, lineEnd)
 		}
@@ -523,7 +750,7 @@ func (r *scopeRenderer) drawPowerLabels(plotX, plotY, plotWidth, plotHeight floa
 
 		// Show ±1σ StdDev near the derivative line (just below it)
 		if pulse.StdDev > 0 {
-			stdDevText := canvas.NewText(fmt.Sprintf("±%.3f mV/s", pulse.StdDev*1000), color.RGBA{R: 150, G: 150, B: 150, A: 200})
+			stdDevText := canvas.NewText("±"+formatDerivative(pulse.StdDev), color.RGBA{R: 150, G: 150, B: 150, A: 200})
 			stdDevText.TextSize = 9
 			stdDevText.Alignment = fyne.TextAlignCenter
 			stdDevText.Move(fyne.NewPos(x-40, yLine+5)) // Just below the fitted line
@@ -553,7 +780,7 @@ func (r *scopeRenderer) drawHeaterPower(plotX, plotY, plotWidth, plotHeight floa
 }
 
 // drawTimestampDiff draws the timestamp difference between 10 samples.
-func (r *scopeRenderer) drawTimestampDiff(plotX, plotY, plotWidth, plotHeight float32, timestampDiff time.Duration) {
+func (r *scopeRenderer) drawTimestampDiff(plotX, plotY, plotWidth, plotHeight float32, timestampDiff time.Duration, labelColor color.RGBA) {
 	if timestampDiff == 0 {
 		return
 	}
@@ -566,7 +793,7 @@ func (r *scopeRenderer) drawTimestampDiff(plotX, plotY, plotWidth, plotHeight fl
 		diffText = formatDurationMs(timestampDiff)
 	}
 
-	text := canvas.NewText("Δt(10): "+diffText, color.RGBA{R: 150, G: 150, B: 150, A: 255}) // Gray
+	text := canvas.NewText("Δt(10): "+diffText, labelColor)
 	text.TextSize = 10
 	text.Alignment = fyne.TextAlignLeading
 	// Position in top-right corner
@@ -575,6 +802,46 @@ func (r *scopeRenderer) drawTimestampDiff(plotX, plotY, plotWidth, plotHeight fl
 	r.objects = append(r.objects, text)
 }
 
+// legendEntry is one line of the trace legend.
+type legendEntry struct {
+	text  string
+	color color.RGBA
+}
+
+// drawLegend lists the traces scaled to their own range at the bottom left
+// of the plot, above the cursor labels.
+func (r *scopeRenderer) drawLegend(plotX, plotY, plotHeight float32, entries []legendEntry) {
+	for i, entry := range entries {
+		text := canvas.NewText(entry.text, entry.color)
+		text.TextSize = 10
+		text.Move(fyne.NewPos(plotX+5, plotY+plotHeight-30-float32(len(entries)-1-i)*14))
+		r.objects = append(r.objects, text)
+	}
+}
+
+// drawFrozen marks the display as frozen below the Δt(10) label.
+func (r *scopeRenderer) drawFrozen(plotX, plotY, plotWidth float32) {
+	text := canvas.NewText(i18n.T("FROZEN"), color.RGBA{R: 255, G: 80, B: 80, A: 255}) // Red
+	text.TextSize = 12
+	text.TextStyle = fyne.TextStyle{Bold: true}
+	text.Move(fyne.NewPos(plotX+plotWidth-120, plotY+26))
+	r.objects = append(r.objects, text)
+}
+
+// drawSweepCursor marks the newest sample of a sweep with a vertical line.
+func (r *scopeRenderer) drawSweepCursor(plotX, plotY, plotWidth, plotHeight float32, at, xMin, xMax time.Time) {
+	timeRange := xMax.Sub(xMin).Seconds()
+	if timeRange <= 0 {
+		return
+	}
+	x := plotX + float32(at.Sub(xMin).Seconds()/timeRange)*plotWidth
+	line := canvas.NewLine(color.RGBA{R: 200, G: 200, B: 200, A: 100})
+	line.Position1 = fyne.NewPos(x, plotY)
+	line.Position2 = fyne.NewPos(x, plotY+plotHeight)
+	line.StrokeWidth = 1
+	r.objects = append(r.objects, line)
+}
+
 // Objects returns all canvas objects for rendering.
 func (r *scopeRenderer) Objects() []fyne.CanvasObject {
 	return r.objects
@@ -595,30 +862,35 @@ func formatVoltage(v float64) string {
 }
 
 func formatVoltageMV(v float64) string {
-	// Format voltage as mV (millivolts)
-	// Convert from V to mV by multiplying by 1000
-	vMV := v * 1000
-	if math.Abs(vMV) < 0.001 {
+	// Format a reading in the display unit, mV by default
+	value, unit := units.Reading(v)
+	if unit == units.Volt {
+		return formatFloat(value, 6) + "V"
+	}
+	if math.Abs(value) < 0.001 {
 		return "0.000mV"
 	}
-	return formatFloat(vMV, 3) + "mV"
+	return formatFloat(value, 3) + "mV"
 }
 
 func formatDerivative(d float64) string {
-	// Format derivative as mV/s (millivolts per second)
-	// Convert from V/s to mV/s by multiplying by 1000
+	// Format derivative in the reading's display unit per second, mV/s by default
 	dMV := d * 1000
+	value, unit := units.Slope(d)
 	if math.Abs(dMV) < 0.000001 {
-		return "0.000mV/s"
+		return "0.000" + unit
 	}
-	// Use appropriate precision based on magnitude
+	// Use appropriate precision based on magnitude, in mV/s
+	decimals := 3
 	if math.Abs(dMV) < 0.001 {
-		return formatFloat(dMV, 6) + "mV/s"
+		decimals = 6
 	} else if math.Abs(dMV) < 1.0 {
-		return formatFloat(dMV, 4) + "mV/s"
-	} else {
-		return formatFloat(dMV, 3) + "mV/s"
+		decimals = 4
 	}
+	if units.Selected().Reading == units.Volt {
+		decimals += 3 // Same digits in V/s
+	}
+	return formatFloat(value, decimals) + unit
 }
 
 func formatTime(d time.Duration) string {
@@ -628,10 +900,35 @@ func formatTime(d time.Duration) string {
 	return formatFloat(d.Seconds(), 1) + "s"
 }
 
+// formatClock formats t as the local time of day, with enough fractional
+// digits to tell apart labels step apart.
+func formatClock(t time.Time, step time.Duration) string {
+	switch {
+	case step >= time.Second:
+		return t.Format("15:04:05")
+	case step >= 100*time.Millisecond:
+		return t.Format("15:04:05.0")
+	default:
+		return t.Format("15:04:05.000")
+	}
+}
+
 func formatPower(powerW float64) string {
-	// Convert W to mW for display (power is stored internally in W)
-	powerMW := powerW * 1000.0
-	return formatFloat(powerMW, 2) + " mW"
+	// Convert W to the display unit, mW by default (power is stored internally in W)
+	value, unit := units.Power(powerW)
+	switch {
+	case math.IsInf(value, -1):
+		return "-∞ " + string(unit)
+	case unit == units.Watt:
+		return formatFloat(value, 5) + " W"
+	}
+	return formatFloat(value, 2) + " " + string(unit)
+}
+
+func formatEnergy(energyJ float64) string {
+	// Convert J to the energy unit matching the power unit, mJ by default
+	value, unit := units.Energy(energyJ)
+	return formatFloat(value, 3) + " " + unit
 }
 
 func formatDuration(d time.Duration) string {
@@ -7,6 +7,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"github.com/itohio/golpm/pkg/i18n"
 	"github.com/itohio/golpm/pkg/meter"
 	"github.com/itohio/golpm/pkg/sample"
 )
@@ -36,12 +37,12 @@ func (r *scopeRenderer) drawActivePulse(
 		// Fitting: very light gray, still being evaluated
 		lineColor = color.RGBA{R: 150, G: 150, B: 150, A: 180}
 		labelColor = color.RGBA{R: 150, G: 150, B: 150, A: 255}
-		stateLabel = "FITTING"
+		stateLabel = i18n.T("FITTING")
 	} else if activePulse.IsUpdating() {
 		// Updating: darker gray, official pulse
 		lineColor = color.RGBA{R: 120, G: 120, B: 120, A: 220}
 		labelColor = color.RGBA{R: 120, G: 120, B: 120, A: 255}
-		stateLabel = "UPDATING"
+		stateLabel = i18n.T("UPDATING")
 	} else {
 		// Finalized or other - shouldn't happen, but handle gracefully
 		return
@@ -189,8 +190,8 @@ func (r *scopeRenderer) drawActivePulse(
 	labelText := fmt.Sprintf("%s (%.1fs)", stateLabel, duration)
 	
 	// Always show mean and stdDev (even if 0 or negative)
-	labelText += fmt.Sprintf("\n%.3f mV/s", activePulse.AvgSlope*1000)
-	labelText += fmt.Sprintf("\nσ=%.3f mV/s", activePulse.StdDev*1000)
+	labelText += "\n" + formatDerivative(activePulse.AvgSlope)
+	labelText += "\nσ=" + formatDerivative(activePulse.StdDev)
 
 	label := canvas.NewText(labelText, labelColor)
 	label.TextSize = 12
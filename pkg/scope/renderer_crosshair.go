@@ -0,0 +1,73 @@
+package scope
+
+import (
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// crosshairColor is used for the crosshair lines and readout text.
+var crosshairColor = color.RGBA{R: 200, G: 200, B: 200, A: 160}
+
+// drawCrosshair draws a crosshair through the sample under the pointer and a
+// readout box with its time, reading, derivative and heater power.
+func (r *scopeRenderer) drawCrosshair(plotX, plotY, plotWidth, plotHeight float32, readout crosshairReadout, yMin, yMax float64, xMin, xMax time.Time) {
+	timeRange := xMax.Sub(xMin).Seconds()
+	yRange := yMax - yMin
+	if timeRange <= 0 || yRange == 0 {
+		return
+	}
+
+	s := readout.sample
+	x := plotX + float32(s.Timestamp.Sub(xMin).Seconds()/timeRange)*plotWidth
+	y := plotY + plotHeight - float32((s.Reading-yMin)/yRange)*plotHeight
+	y = min(max(y, plotY), plotY+plotHeight)
+
+	vertical := canvas.NewLine(crosshairColor)
+	vertical.Position1 = fyne.NewPos(x, plotY)
+	vertical.Position2 = fyne.NewPos(x, plotY+plotHeight)
+	vertical.StrokeWidth = 1
+	horizontal := canvas.NewLine(crosshairColor)
+	horizontal.Position1 = fyne.NewPos(plotX, y)
+	horizontal.Position2 = fyne.NewPos(plotX+plotWidth, y)
+	horizontal.StrokeWidth = 1
+	r.objects = append(r.objects, vertical, horizontal)
+
+	lines := []string{
+		s.Timestamp.Format("15:04:05.000") + "  +" + formatTime(s.Timestamp.Sub(xMin)),
+		i18n.T("reading") + " " + formatVoltageMV(s.Reading),
+	}
+	if readout.hasSlope {
+		lines = append(lines, i18n.T("slope")+" "+formatDerivative(readout.derivative))
+	}
+	lines = append(lines, i18n.T("heater")+" "+formatPower(s.HeaterPower))
+
+	// Readout box next to the crosshair, flipped to stay inside the plot
+	const (
+		lineHeight = float32(14)
+		boxWidth   = float32(150)
+	)
+	boxHeight := lineHeight*float32(len(lines)) + 6
+	boxX := x + 10
+	if boxX+boxWidth > plotX+plotWidth {
+		boxX = x - 10 - boxWidth
+	}
+	boxY := min(max(y-boxHeight-10, plotY), plotY+plotHeight-boxHeight)
+
+	box := canvas.NewRectangle(color.RGBA{R: 0, G: 0, B: 0, A: 200})
+	box.StrokeColor = crosshairColor
+	box.StrokeWidth = 1
+	box.Move(fyne.NewPos(boxX, boxY))
+	box.Resize(fyne.NewSize(boxWidth, boxHeight))
+	r.objects = append(r.objects, box)
+
+	for i, line := range lines {
+		text := canvas.NewText(line, color.RGBA{R: 230, G: 230, B: 230, A: 255})
+		text.TextSize = 10
+		text.Move(fyne.NewPos(boxX+5, boxY+3+float32(i)*lineHeight))
+		r.objects = append(r.objects, text)
+	}
+}
@@ -0,0 +1,57 @@
+package scope
+
+import (
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"github.com/itohio/golpm/pkg/i18n"
+)
+
+// cursorColors are the colors of measurement cursors 1 and 2.
+var cursorColors = [2]color.RGBA{
+	{R: 255, G: 230, B: 0, A: 255}, // Yellow
+	{R: 0, G: 230, B: 180, A: 255}, // Teal
+}
+
+// drawCursors draws the placed measurement cursors and, with both placed, a
+// readout of Δt, ΔV, the slope and the mean heater power between them at
+// the top of the plot.
+func (r *scopeRenderer) drawCursors(plotX, plotY, plotWidth, plotHeight float32, cursors [2]time.Time, measurement cursorMeasurement, measured bool, xMin, xMax time.Time) {
+	timeRange := xMax.Sub(xMin).Seconds()
+	if timeRange <= 0 {
+		return
+	}
+
+	for i, t := range cursors {
+		if t.IsZero() || t.Before(xMin) || t.After(xMax) {
+			continue
+		}
+		x := plotX + float32(t.Sub(xMin).Seconds()/timeRange)*plotWidth
+		line := canvas.NewLine(cursorColors[i])
+		line.Position1 = fyne.NewPos(x, plotY)
+		line.Position2 = fyne.NewPos(x, plotY+plotHeight)
+		line.StrokeWidth = 1
+		label := canvas.NewText(formatInt(int64(i+1)), cursorColors[i])
+		label.TextSize = 10
+		label.Move(fyne.NewPos(x+3, plotY+plotHeight-14))
+		r.objects = append(r.objects, line, label)
+	}
+
+	if !measured {
+		return
+	}
+
+	text := "Δt " + formatDuration(measurement.dt) +
+		"   ΔV " + formatVoltageMV(measurement.dv) +
+		"   " + i18n.T("heater") + " " + formatPower(measurement.meanPower)
+	if measurement.dt != 0 {
+		text += "   " + formatDerivative(measurement.dv/measurement.dt.Seconds())
+	}
+	readout := canvas.NewText(text, cursorColors[0])
+	readout.TextSize = 12
+	width := fyne.MeasureText(text, readout.TextSize, readout.TextStyle).Width
+	readout.Move(fyne.NewPos(plotX+(plotWidth-width)/2, plotY+2))
+	r.objects = append(r.objects, readout)
+}
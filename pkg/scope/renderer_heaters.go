@@ -0,0 +1,100 @@
+package scope
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// Heater lane geometry below the time axis labels
+const (
+	heaterLaneOffset = float32(20) // From the bottom of the plot to the first row
+	heaterRowHeight  = float32(3)
+	heaterRowPitch   = float32(5)
+)
+
+// timeSpan is a time interval [start, end).
+type timeSpan struct {
+	start, end time.Time
+}
+
+// heaterSpans returns the intervals each heater was on in samples, one entry
+// per heater the samples report. A heater still on at the last sample stays
+// on until its timestamp.
+func heaterSpans(samples []sample.Sample) [][]timeSpan {
+	heaters := 0
+	for _, s := range samples {
+		heaters = max(heaters, len(s.Heaters))
+	}
+	spans := make([][]timeSpan, heaters)
+	on := make([]time.Time, heaters)
+	for _, s := range samples {
+		for h, heating := range s.Heaters {
+			switch {
+			case heating && on[h].IsZero():
+				on[h] = s.Timestamp
+			case !heating && !on[h].IsZero():
+				spans[h] = append(spans[h], timeSpan{on[h], s.Timestamp})
+				on[h] = time.Time{}
+			}
+		}
+	}
+	if len(samples) > 0 {
+		last := samples[len(samples)-1].Timestamp
+		for h := range on {
+			if !on[h].IsZero() {
+				spans[h] = append(spans[h], timeSpan{on[h], last})
+			}
+		}
+	}
+	return spans
+}
+
+// drawHeaterLane draws a digital timeline of the heaters below the time axis:
+// a thin baseline per heater, filled where it was on. The rows are packed
+// closer when there are too many heaters for the bottom margin.
+func (r *scopeRenderer) drawHeaterLane(plotX, plotY, plotWidth, plotHeight float32, spans [][]timeSpan, xMin, xMax time.Time, style traceStyle, theme scopeTheme) {
+	timeRange := xMax.Sub(xMin).Seconds()
+	if timeRange <= 0 {
+		return
+	}
+	xOf := func(t time.Time) float32 {
+		return plotX + float32(min(max(t.Sub(xMin).Seconds()/timeRange, 0), 1))*plotWidth
+	}
+
+	top := plotY + plotHeight + heaterLaneOffset
+	pitch, height := heaterRowPitch, heaterRowHeight
+	if n := float32(len(spans)); n*pitch > marginBottom-heaterLaneOffset {
+		pitch = (marginBottom - heaterLaneOffset) / n
+		height = max(pitch-1, 1)
+	}
+	for h := range spans {
+		y := top + float32(h)*pitch
+
+		baseline := canvas.NewLine(theme.grid)
+		baseline.Position1 = fyne.NewPos(plotX, y+height/2)
+		baseline.Position2 = fyne.NewPos(plotX+plotWidth, y+height/2)
+		baseline.StrokeWidth = 1
+		r.objects = append(r.objects, baseline)
+
+		for _, span := range spans[h] {
+			if span.end.Before(xMin) || span.start.After(xMax) {
+				continue
+			}
+			x0, x1 := xOf(span.start), xOf(span.end)
+			rect := canvas.NewRectangle(style.color)
+			rect.Move(fyne.NewPos(x0, y))
+			rect.Resize(fyne.NewSize(max(x1-x0, 1), height))
+			r.objects = append(r.objects, rect)
+		}
+	}
+
+	label := canvas.NewText(i18n.T("heaters"), theme.label)
+	label.TextSize = 8
+	label.Alignment = fyne.TextAlignTrailing
+	label.Move(fyne.NewPos(plotX-5, top))
+	r.objects = append(r.objects, label)
+}
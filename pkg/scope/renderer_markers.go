@@ -0,0 +1,45 @@
+package scope
+
+import (
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+// markerColor is the color of user-placed markers.
+var markerColor = color.RGBA{R: 120, G: 230, B: 120, A: 255} // Green
+
+// markerRows is the number of rows the marker labels alternate between, so
+// the labels of nearby markers overlap less.
+const markerRows = 3
+
+// drawMarkers draws a vertical line at each marker in view with its label
+// near the top of the plot.
+func (r *scopeRenderer) drawMarkers(plotX, plotY, plotWidth, plotHeight float32, markers []Marker, xMin, xMax time.Time) {
+	timeRange := xMax.Sub(xMin).Seconds()
+	if timeRange <= 0 {
+		return
+	}
+
+	for i, m := range markers {
+		if m.Time.Before(xMin) || m.Time.After(xMax) {
+			continue
+		}
+		x := plotX + float32(m.Time.Sub(xMin).Seconds()/timeRange)*plotWidth
+		line := canvas.NewLine(markerColor)
+		line.Position1 = fyne.NewPos(x, plotY)
+		line.Position2 = fyne.NewPos(x, plotY+plotHeight)
+		line.StrokeWidth = 1
+		r.objects = append(r.objects, line)
+
+		if m.Label == "" {
+			continue
+		}
+		label := canvas.NewText(m.Label, markerColor)
+		label.TextSize = 10
+		label.Move(fyne.NewPos(x+3, plotY+18+float32(i%markerRows)*12))
+		r.objects = append(r.objects, label)
+	}
+}
@@ -0,0 +1,27 @@
+package scope
+
+import (
+	"math"
+	"time"
+)
+
+// Split layout geometry: the derivative sub-plot below the reading
+const (
+	subPlotFraction = 0.3          // Of the plot height
+	subPlotGap      = float32(8.0) // Between the reading and the sub-plot
+)
+
+// drawSplitGrid draws the grids of the split layout: the reading plot with
+// its axis on the left, and below it the derivative sub-plot with its axis
+// on the right and the time labels.
+func (r *scopeRenderer) drawSplitGrid(plotX, mainY, mainHeight, derivY, derivHeight, plotWidth float32, sampleYMin, sampleYMax, derivativeYMin, derivativeYMax float64, xMin, xMax time.Time, styles traceStyles, theme scopeTheme) {
+	reading := &axisLabels{min: sampleYMin, max: sampleYMax, color: styles.reading.color, format: formatVoltageMV}
+	r.drawHGrid(plotX, mainY, plotWidth, mainHeight, theme.hDivisions, reading, nil, theme)
+	r.drawVGrid(plotX, mainY, plotWidth, mainHeight, xMin, xMax, false, theme)
+
+	// About the same line spacing as the reading plot
+	divisions := max(int(math.Round(float64(theme.hDivisions)*subPlotFraction/(1-subPlotFraction))), 1)
+	derivative := &axisLabels{min: derivativeYMin, max: derivativeYMax, color: styles.derivative.color, format: formatDerivative}
+	r.drawHGrid(plotX, derivY, plotWidth, derivHeight, divisions, nil, derivative, theme)
+	r.drawVGrid(plotX, derivY, plotWidth, derivHeight, xMin, xMax, true, theme)
+}
@@ -0,0 +1,46 @@
+package scope
+
+import (
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+// triggerColor marks the trigger point and state.
+var triggerColor = color.RGBA{R: 255, G: 220, B: 0, A: 255} // Yellow
+
+// drawTriggerMarker draws a dashed vertical line at the trigger
+// time of the capture on display, with a "T" at the top.
+func (r *scopeRenderer) drawTriggerMarker(plotX, plotY, plotWidth, plotHeight float32, at, xMin, xMax time.Time) {
+	timeRange := xMax.Sub(xMin).Seconds()
+	if timeRange <= 0 || at.Before(xMin) || at.After(xMax) {
+		return
+	}
+	x := plotX + float32(at.Sub(xMin).Seconds()/timeRange)*plotWidth
+
+	const dash = float32(6)
+	for y := plotY; y < plotY+plotHeight; y += 2 * dash {
+		line := canvas.NewLine(triggerColor)
+		line.Position1 = fyne.NewPos(x, y)
+		line.Position2 = fyne.NewPos(x, min(y+dash, plotY+plotHeight))
+		line.StrokeWidth = 1
+		r.objects = append(r.objects, line)
+	}
+
+	label := canvas.NewText("T", triggerColor)
+	label.TextSize = 12
+	label.TextStyle = fyne.TextStyle{Bold: true}
+	label.Move(fyne.NewPos(x+3, plotY+2))
+	r.objects = append(r.objects, label)
+}
+
+// drawTriggerStatus shows the trigger state below the FROZEN label.
+func (r *scopeRenderer) drawTriggerStatus(plotX, plotY, plotWidth float32, status string) {
+	text := canvas.NewText(status, triggerColor)
+	text.TextSize = 12
+	text.TextStyle = fyne.TextStyle{Bold: true}
+	text.Move(fyne.NewPos(plotX+plotWidth-120, plotY+42))
+	r.objects = append(r.objects, text)
+}
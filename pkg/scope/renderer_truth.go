@@ -0,0 +1,101 @@
+package scope
+
+import (
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+)
+
+// truthColor is used for the ground truth overlay (magenta).
+var truthColor = color.RGBA{R: 220, G: 80, B: 220, A: 255}
+
+// drawTruth draws the ground truth laser power as a step trace in the bottom
+// fifth of the plot, scaled to its visible maximum, and labels each pulse
+// with the true power and the relative error of the measured power.
+func (r *scopeRenderer) drawTruth(plotX, plotY, plotWidth, plotHeight float32, truth []TruthPoint, pulses []meter.Pulse, xMin, xMax time.Time) {
+	timeRange := xMax.Sub(xMin).Seconds()
+	if timeRange <= 0 {
+		return
+	}
+
+	maxPower := 0.0
+	for _, p := range truth {
+		if !p.Time.Before(xMin) && !p.Time.After(xMax) {
+			maxPower = max(maxPower, p.Power)
+		}
+	}
+
+	// Step trace: hold each power until the next point
+	if maxPower > 0 {
+		traceHeight := plotHeight / 5
+		points := make([]dataPoint, 0, 2*len(truth))
+		for i, p := range truth {
+			if p.Time.Before(xMin) || p.Time.After(xMax) {
+				continue
+			}
+			if i > 0 && len(points) > 0 {
+				points = append(points, dataPoint{time: p.Time, value: truth[i-1].Power})
+			}
+			points = append(points, dataPoint{time: p.Time, value: p.Power})
+		}
+		r.drawCurve(plotX, plotY+plotHeight-traceHeight, plotWidth, traceHeight, points, 0, maxPower, xMin, xMax, truthColor, 1.0)
+	}
+
+	for _, pulse := range pulses {
+		if pulse.EndTime.Before(xMin) || pulse.StartTime.After(xMax) {
+			continue
+		}
+		power, ok := meanTruthPower(truth, pulse.StartTime, pulse.EndTime)
+		if !ok || power <= 0 {
+			continue
+		}
+
+		centerTime := pulse.StartTime.Add(pulse.EndTime.Sub(pulse.StartTime) / 2)
+		x := plotX + float32(centerTime.Sub(xMin).Seconds()/timeRange)*plotWidth
+
+		text := i18n.Tf("truth %s (%+.1f%%)", formatPower(power), (pulse.AvgPower-power)/power*100)
+		label := canvas.NewText(text, truthColor)
+		label.TextSize = 10
+		label.Alignment = fyne.TextAlignCenter
+		label.Move(fyne.NewPos(x-40, plotY+plotHeight-plotHeight/5-15))
+		r.powerLabels = append(r.powerLabels, label)
+		r.objects = append(r.objects, label)
+	}
+}
+
+// meanTruthPower returns the mean true power over [start, end], holding
+// each point's power until the next one. ok is false if no point covers
+// the interval.
+func meanTruthPower(truth []TruthPoint, start, end time.Time) (power float64, ok bool) {
+	var (
+		energy  float64
+		covered time.Duration
+	)
+	for i, p := range truth {
+		from := p.Time
+		if from.After(end) {
+			break
+		}
+		to := end
+		if i+1 < len(truth) && truth[i+1].Time.Before(end) {
+			to = truth[i+1].Time
+		}
+		if from.Before(start) {
+			from = start
+		}
+		if !to.After(from) {
+			continue
+		}
+		energy += p.Power * to.Sub(from).Seconds()
+		covered += to.Sub(from)
+	}
+
+	if covered <= 0 {
+		return 0, false
+	}
+	return energy / covered.Seconds(), true
+}
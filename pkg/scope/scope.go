@@ -1,7 +1,8 @@
 package scope
 
 import (
-	"image/color"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -9,10 +10,15 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/widget"
 	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/i18n"
 	"github.com/itohio/golpm/pkg/meter"
 	"github.com/itohio/golpm/pkg/sample"
 )
 
+// defaultMaxDisplayPoints limits the points drawn per trace unless the
+// configuration sets another limit.
+const defaultMaxDisplayPoints = 1000
+
 // ScopeWidget is a custom Fyne widget that displays oscilloscope-style measurement graphs.
 type ScopeWidget struct {
 	widget.BaseWidget
@@ -26,18 +32,61 @@ type ScopeWidget struct {
 	pulses      []meter.Pulse
 	activePulse *meter.Pulse // Currently tracked pulse (Fitting or Updating), drawn in gray
 	heaterPower float64
+	truth       []TruthPoint // Known laser power from a simulated device, drawn as a debug overlay
+
+	// Longer record of the updates, shown when rolling
+	history history
 
 	// Display buffers (reused for downsampling)
 	displaySamples     []sample.Sample
 	displayDerivatives []float64
+	displayWindows     []int
+
+	// Range of the readings and derivatives within each display window,
+	// empty unless downsampling merged samples
+	readingEnvelope    []sample.Envelope
+	derivativeEnvelope []sample.Envelope
+
+	// Intervals each heater was on within the visible time range
+	heaterSpans [][]timeSpan
 
 	// Auto-scaling - separate Y-axes for samples and derivatives
 	sampleYMin, sampleYMax         float64 // Y-axis range for samples (left axis)
 	derivativeYMin, derivativeYMax float64 // Y-axis range for derivatives (right axis)
 	xMin, xMax                     time.Time
 
-	// Display settings
-	maxDisplayPoints int
+	// Time range chosen by zooming and panning
+	view viewState
+
+	// Positions of the fingers on a touch screen
+	touches []fyne.Position
+
+	// Mouse pointer position for the crosshair
+	pointer  fyne.Position
+	hovering bool
+
+	// Measurement cursors placed by tapping, zero if not placed
+	cursors [2]time.Time
+
+	// Named markers placed by the user, in time order
+	markers []Marker
+
+	// While frozen, updates are held in pending and the display keeps its data
+	frozen  bool
+	pending *scopeData
+
+	// Trigger mode and capture state; rolls continuously by default
+	trigger triggerState
+
+	// Redraws for new data are paced by the throttle; stale marks data
+	// that has not been drawn yet
+	throttle refreshThrottle
+	stale    bool
+
+	// OnViewChanged, if set, is called on the main thread after every
+	// refresh with the samples in view, e.g. to update a SpectrumWidget.
+	// The slice must not be modified.
+	OnViewChanged func(samples []sample.Sample)
 }
 
 // New creates a new ScopeWidget instance.
@@ -48,9 +97,8 @@ func New(cfg *config.Config) *ScopeWidget {
 		derivatives:        make([]float64, 0),
 		pulses:             make([]meter.Pulse, 0),
 		heaterPower:        0.0,
-		displaySamples:     make([]sample.Sample, 0, 1000),
-		displayDerivatives: make([]float64, 0, 1000),
-		maxDisplayPoints:   1000, // Limit points for efficient rendering
+		displaySamples:     make([]sample.Sample, 0, defaultMaxDisplayPoints),
+		displayDerivatives: make([]float64, 0, defaultMaxDisplayPoints),
 	}
 	s.ExtendBaseWidget(s)
 	// Trigger initial refresh to display empty scope
@@ -58,34 +106,299 @@ func New(cfg *config.Config) *ScopeWidget {
 	return s
 }
 
+// maxDisplayPoints returns how many points the traces are downsampled to
+// for drawing.
+func (s *ScopeWidget) maxDisplayPoints() int {
+	if s.cfg.Scope.MaxDisplayPoints > 0 {
+		return s.cfg.Scope.MaxDisplayPoints
+	}
+	return defaultMaxDisplayPoints
+}
+
+// scopeData is an update held while the display is frozen.
+type scopeData struct {
+	samples     []sample.Sample
+	derivatives []float64
+	pulses      []meter.Pulse
+	activePulse *meter.Pulse
+	heaterPower float64
+	truth       []TruthPoint
+}
+
 // UpdateData updates the widget with new measurement data.
 // This should be called from the measurement callback using fyne.Do().
+// Redraws are throttled to what the machine keeps up with, at most about
+// 60 per second; the newest data is always drawn eventually.
+// Updates are collected into the history, which can be scrolled back beyond
+// the meter's window; while the display is frozen, it keeps its data.
 func (s *ScopeWidget) UpdateData(samples []sample.Sample, derivatives []float64, pulses []meter.Pulse, activePulse *meter.Pulse, heaterPower float64) {
 	s.mu.Lock()
 
-	// Downsample for display (reuse buffers)
-	s.displaySamples = sample.DownsampleSamples(s.displaySamples, samples, s.maxDisplayPoints)
-	s.displayDerivatives = sample.DownsampleDerivatives(s.displayDerivatives, derivatives, s.maxDisplayPoints)
+	s.history.merge(samples, derivatives, pulses, s.cfg.Scope.History)
+
+	if s.frozen {
+		if s.pending == nil {
+			s.pending = &scopeData{truth: s.truth}
+		}
+		s.pending.samples, s.pending.derivatives, s.pending.pulses = samples, derivatives, pulses
+		s.pending.activePulse, s.pending.heaterPower = activePulse, heaterPower
+		s.mu.Unlock()
+		return
+	}
 
-	// Store full data
-	s.samples = samples
-	s.derivatives = derivatives
-	s.pulses = pulses
-	s.activePulse = activePulse // May be nil if no active tracking
-	s.heaterPower = heaterPower
+	if s.trigger.mode != TriggerRoll {
+		s.updateTrigger(&scopeData{
+			samples: samples, derivatives: derivatives, pulses: pulses,
+			activePulse: activePulse, heaterPower: heaterPower, truth: s.truth,
+		})
+	} else {
+		// Store full data
+		s.showHistory()
+		s.activePulse = activePulse // May be nil if no active tracking
+		s.heaterPower = heaterPower
+	}
 
-	// Calculate auto-scaling
-	s.updateAutoScale()
+	// Redraw now, or later if redraws are falling behind
+	wait := s.throttled()
+	s.mu.Unlock()
+	if !wait {
+		s.redraw()
+	}
+}
+
+// TruthPoint is the known laser power at an instant, reported by a
+// simulated device. Power is in W, like meter.Pulse.AvgPower.
+type TruthPoint struct {
+	Time  time.Time
+	Power float64
+}
+
+// UpdateTruth sets the ground truth drawn over the graph, so the measurement
+// error of each pulse can be read off directly. Points must be in time order;
+// nil hides the overlay. Like UpdateData, call it on the main thread.
+func (s *ScopeWidget) UpdateTruth(points []TruthPoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.frozen {
+		if s.pending == nil {
+			s.pending = &scopeData{samples: s.samples, derivatives: s.derivatives, pulses: s.pulses,
+				activePulse: s.activePulse, heaterPower: s.heaterPower}
+		}
+		s.pending.truth = points
+		return
+	}
+	s.truth = points
+}
+
+// SetFrozen freezes or resumes the display. While frozen the scope keeps
+// showing its data and can still be zoomed and panned, but ignores updates;
+// acquisition continues elsewhere. Resuming shows the latest update and
+// returns to the live view.
+func (s *ScopeWidget) SetFrozen(frozen bool) {
+	s.mu.Lock()
+	if frozen == s.frozen {
+		s.mu.Unlock()
+		return
+	}
+	s.frozen = frozen
+	if !frozen {
+		p := s.pending
+		s.pending = nil
+		if s.trigger.mode != TriggerRoll {
+			if p != nil {
+				s.truth = p.truth
+				s.updateTrigger(p)
+				s.updateView()
+			}
+		} else {
+			if p != nil {
+				s.showHistory()
+				s.activePulse, s.heaterPower, s.truth = p.activePulse, p.heaterPower, p.truth
+			}
+			s.view = viewState{}
+			s.updateView()
+		}
+	}
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// showHistory shows the data collected in the history. Must be called with
+// mu held.
+func (s *ScopeWidget) showHistory() {
+	s.samples, s.derivatives, s.pulses = s.history.samples, s.history.derivatives, s.history.pulses
+}
+
+// ClearHistory forgets the data of earlier updates, e.g. when a new meter
+// starts counting pulses again. The display keeps its data until the next
+// update.
+func (s *ScopeWidget) ClearHistory() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = history{}
+}
 
+// History returns the data collected in the history, e.g. to save the
+// session. The slices must not be modified.
+func (s *ScopeWidget) History() ([]sample.Sample, []float64, []meter.Pulse) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.history.samples, s.history.derivatives, s.history.pulses
+}
+
+// LoadHistory replaces the history and the markers with recorded data, e.g.
+// a saved session, and freezes the display on all of it. The data must be in
+// the form UpdateData receives and must not be modified afterwards.
+// Resuming the display shows new updates as they arrive.
+func (s *ScopeWidget) LoadHistory(samples []sample.Sample, derivatives []float64, pulses []meter.Pulse, markers []Marker) {
+	s.mu.Lock()
+	s.history = history{samples: samples, derivatives: derivatives, pulses: pulses}
+	s.markers = slices.Clone(markers)
+	s.frozen = true
+	s.pending = nil
+	s.showHistory()
+	s.activePulse, s.heaterPower, s.truth = nil, 0, nil
+	s.cursors = [2]time.Time{}
+	s.view = viewState{}
+	if len(samples) > 1 {
+		s.view.span = samples[len(samples)-1].Timestamp.Sub(samples[0].Timestamp)
+	}
+	s.updateView()
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// Frozen reports whether the display is frozen.
+func (s *ScopeWidget) Frozen() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.frozen
+}
+
+// Refresh redraws the widget and reports the samples in view to
+// OnViewChanged.
+func (s *ScopeWidget) Refresh() {
+	s.BaseWidget.Refresh()
+	if s.OnViewChanged != nil {
+		s.OnViewChanged(s.visibleSamples())
+	}
+}
+
+// visibleSamples returns the samples between xMin and xMax.
+func (s *ScopeWidget) visibleSamples() []sample.Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	from, to := visibleRange(s.samples, s.xMin, s.xMax)
+	return s.samples[from:to]
+}
+
+// pulseKeepIndices returns the indices of samples that downsampling must
+// keep so pulses are drawn true: the detection and fit edges of every pulse,
+// and the lowest and highest reading within each. samples must be in time
+// order.
+func pulseKeepIndices(samples []sample.Sample, pulses []meter.Pulse, active *meter.Pulse) []int {
+	if len(samples) == 0 {
+		return nil
+	}
+	at := func(t time.Time) int {
+		return sort.Search(len(samples), func(i int) bool {
+			return !samples[i].Timestamp.Before(t)
+		})
+	}
+
+	var keep []int
+	add := func(p meter.Pulse) {
+		end := p.DetectEndTime
+		if end.IsZero() {
+			end = samples[len(samples)-1].Timestamp
+		}
+		if end.Before(samples[0].Timestamp) || p.DetectStartTime.After(samples[len(samples)-1].Timestamp) {
+			return
+		}
+		for _, t := range []time.Time{p.DetectStartTime, end, p.StartTime, p.EndTime} {
+			if i := at(t); !t.IsZero() && i < len(samples) {
+				keep = append(keep, i)
+			}
+		}
+
+		from, to := at(p.DetectStartTime), min(at(end)+1, len(samples))
+		if from >= to {
+			return
+		}
+		lo, hi := from, from
+		for i := from; i < to; i++ {
+			if samples[i].Reading < samples[lo].Reading {
+				lo = i
+			}
+			if samples[i].Reading > samples[hi].Reading {
+				hi = i
+			}
+		}
+		keep = append(keep, lo, hi)
+	}
+	for _, p := range pulses {
+		add(p)
+	}
+	if active != nil {
+		add(*active)
+	}
+	return keep
+}
+
+// sweeping reports whether the live view sweeps instead of rolling.
+// Zooming, panning and triggered captures always show a rolling window.
+func (s *ScopeWidget) sweeping() bool {
+	return s.cfg.Scope.Display == "sweep" && s.view == viewState{}
+}
+
+// Rescale recomputes the Y-axes, e.g. after the fixed ranges in the scope
+// configuration changed.
+func (s *ScopeWidget) Rescale() {
+	s.mu.Lock()
+	s.updateView()
 	s.mu.Unlock()
 
-	// Refresh the widget (must be outside lock to avoid potential deadlock)
-	// This triggers the renderer's Refresh() method which rebuilds all canvas objects
 	s.Refresh()
+}
 
-	// Explicitly refresh as a canvas object to ensure Fyne invalidates and repaints
-	// This is critical - without this, Fyne may only repaint on user interaction
-	canvas.Refresh(s)
+// updateView selects and downsamples the samples in the visible time range
+// and rescales the Y-axes to them. Must be called with mu held.
+func (s *ScopeWidget) updateView() {
+	samples, derivatives := s.samples, s.derivatives
+	if len(samples) > 0 {
+		minSpan := time.Duration(s.cfg.Measurement.WindowSeconds) * time.Second
+		if minSpan > 0 && s.sweeping() {
+			s.xMin, s.xMax = sweepWindow(samples[len(samples)-1].Timestamp, minSpan)
+		} else {
+			s.xMin, s.xMax = s.view.window(samples[0].Timestamp, samples[len(samples)-1].Timestamp, minSpan)
+		}
+
+		// derivatives[i] lies between samples i and i+1
+		from, to := visibleRange(samples, s.xMin, s.xMax)
+		samples = samples[from:to]
+		derivatives = derivatives[min(from, len(derivatives)):min(max(to-1, from), len(derivatives))]
+	}
+
+	if s.cfg.Scope.HeaterLane {
+		s.heaterSpans = heaterSpans(samples)
+	}
+
+	// Downsample for display (reuse buffers), keeping pulse edges and extrema
+	keep := pulseKeepIndices(samples, s.pulses, s.activePulse)
+	s.displayWindows = sample.DownsampleWindows(s.displayWindows, len(samples), s.maxDisplayPoints(), keep)
+	s.displaySamples = sample.DownsampleSamplesWindows(s.displaySamples, samples, s.displayWindows)
+	s.displayDerivatives = sample.DownsampleDerivativesWindows(s.displayDerivatives, derivatives, s.displayWindows)
+	s.readingEnvelope, s.derivativeEnvelope = s.readingEnvelope[:0], s.derivativeEnvelope[:0]
+	if s.cfg.Scope.Envelope && len(s.displaySamples) < len(samples) {
+		s.readingEnvelope = sample.DownsampleSamplesEnvelope(s.readingEnvelope, samples, s.displayWindows)
+		s.derivativeEnvelope = sample.DownsampleDerivativesEnvelope(s.derivativeEnvelope, derivatives, s.displayWindows)
+	}
+
+	// Calculate auto-scaling
+	s.updateAutoScale()
 }
 
 // updateAutoScale calculates Y-axis ranges from current data.
@@ -96,15 +409,18 @@ func (s *ScopeWidget) updateAutoScale() {
 		s.sampleYMax = 1.0
 		s.derivativeYMin = -0.001 // Minimum range: -1 mV/s
 		s.derivativeYMax = 0.001  // Minimum range: 1 mV/s
-		s.xMin = time.Now()
-		s.xMax = time.Now().Add(10 * time.Second)
+		if len(s.samples) == 0 {
+			s.xMin = time.Now()
+			s.xMax = time.Now().Add(10 * time.Second)
+		}
+		s.applyFixedRanges()
 		return
 	}
 
 	// Calculate ranges using the same unified method for both samples and derivatives
 	// IMPORTANT: Convert to mV before scaling, then convert back to V
 	// This ensures proper snapping (e.g., 457-501 mV → 450-510 mV, not 0.4-0.6 V)
-	sampleValues := extractSampleValues(s.displaySamples)
+	sampleValues := appendEnvelope(extractSampleValues(s.displaySamples), s.readingEnvelope)
 	if len(sampleValues) > 0 {
 		// Convert to mV for scaling
 		sampleValuesMV := make([]float64, len(sampleValues))
@@ -131,8 +447,9 @@ func (s *ScopeWidget) updateAutoScale() {
 
 	if len(s.displayDerivatives) > 0 {
 		// Convert to mV/s for scaling
-		derivativeValuesMV := make([]float64, len(s.displayDerivatives))
-		for i, v := range s.displayDerivatives {
+		derivatives := appendEnvelope(slices.Clone(s.displayDerivatives), s.derivativeEnvelope)
+		derivativeValuesMV := make([]float64, len(derivatives))
+		for i, v := range derivatives {
 			derivativeValuesMV[i] = v * 1000.0 // V/s to mV/s
 		}
 
@@ -168,23 +485,33 @@ func (s *ScopeWidget) updateAutoScale() {
 		s.derivativeYMax = 0.001
 	}
 
-	// Time range
-	if len(s.displaySamples) > 0 {
-		s.xMin = s.displaySamples[0].Timestamp
-		s.xMax = s.displaySamples[len(s.displaySamples)-1].Timestamp
-		// Ensure minimum window
-		if s.xMax.Sub(s.xMin) < time.Duration(s.cfg.Measurement.WindowSeconds)*time.Second {
-			s.xMax = s.xMin.Add(time.Duration(s.cfg.Measurement.WindowSeconds) * time.Second)
-		}
+	s.applyFixedRanges()
+}
+
+// applyFixedRanges replaces the auto-scaled Y ranges with the fixed ranges
+// from the scope configuration, given in mV and mV/s.
+func (s *ScopeWidget) applyFixedRanges() {
+	if s.cfg == nil {
+		return
+	}
+	if r := s.cfg.Scope.ReadingRange; r.Fixed() {
+		s.sampleYMin = r.Min / 1000.0
+		s.sampleYMax = r.Max / 1000.0
+	}
+	if r := s.cfg.Scope.DerivativeRange; r.Fixed() {
+		s.derivativeYMin = r.Min / 1000.0
+		s.derivativeYMax = r.Max / 1000.0
 	}
 }
 
 // CreateRenderer creates the widget renderer.
 func (s *ScopeWidget) CreateRenderer() fyne.WidgetRenderer {
-	grid := canvas.NewRectangle(color.RGBA{R: 20, G: 20, B: 20, A: 255}) // Dark background
+	grid := canvas.NewRectangle(newScopeTheme(s.cfg.Scope).background)
 	return &scopeRenderer{
 		scope:    s,
 		grid:     grid,
+		liveBtn:  widget.NewButton(i18n.T("Live"), s.SetLive),
+		traces:   newTraceRaster(),
 		objects:  []fyne.CanvasObject{grid},
 		lastSize: fyne.Size{Width: 0, Height: 0},
 	}
@@ -199,6 +526,15 @@ func extractSampleValues(samples []sample.Sample) []float64 {
 	return values
 }
 
+// appendEnvelope appends the bounds of the envelope to values, so the
+// auto-scaled axis fits the band around the trace too.
+func appendEnvelope(values []float64, envelope []sample.Envelope) []float64 {
+	for _, e := range envelope {
+		values = append(values, e.Min, e.Max)
+	}
+	return values
+}
+
 // calculateRangeFromValues calculates min/max from a slice of values and rounds to nice range.
 // Uses the SAME method for all value types (samples, derivatives, etc.).
 // Scaling method:
@@ -1,7 +1,24 @@
 package scope
 
 import (
+	"image"
+	"image/color"
+	"maps"
+	"math"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/mobile"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/meter"
+	"github.com/itohio/golpm/pkg/sample"
+	"github.com/itohio/golpm/pkg/units"
 )
 
 func TestSnapToMultiples(t *testing.T) {
@@ -75,3 +92,1307 @@ func TestSnapToMultiples(t *testing.T) {
 		})
 	}
 }
+
+func TestMeanTruthPower(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+
+	// 0 W until 100ms, 40 mW until 300ms, then off
+	truth := []TruthPoint{
+		{Time: at(0), Power: 0},
+		{Time: at(100), Power: 0.04},
+		{Time: at(200), Power: 0.04},
+		{Time: at(300), Power: 0},
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       float64
+		wantOK     bool
+	}{
+		{"inside pulse", at(120), at(280), 0.04, true},
+		{"whole pulse", at(100), at(300), 0.04, true},
+		{"half covered", at(0), at(200), 0.02, true},
+		{"after last point holds its power", at(300), at(500), 0, true},
+		{"before first point", at(-200), at(-100), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := meanTruthPower(truth, tt.start, tt.end)
+			if ok != tt.wantOK {
+				t.Fatalf("meanTruthPower() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("meanTruthPower() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewState(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	at := func(s float64) time.Time { return t0.Add(time.Duration(s * float64(time.Second))) }
+	first, last := at(0), at(60)
+	full := last.Sub(first)
+
+	// Live view shows the newest window of data, from the start until there
+	// is more
+	var v viewState
+	if xMin, xMax := v.window(first, at(5), 10*time.Second); !xMin.Equal(first) || !xMax.Equal(at(10)) {
+		t.Errorf("short buffer window = %v..%v, want 0s..10s", xMin.Sub(t0), xMax.Sub(t0))
+	}
+	if xMin, xMax := v.window(first, last, 10*time.Second); !xMin.Equal(at(50)) || !xMax.Equal(last) {
+		t.Errorf("long history window = %v..%v, want 50s..60s", xMin.Sub(t0), xMax.Sub(t0))
+	}
+
+	// Zooming out stops at the whole history
+	if z := v.zoom(100, last, at(50), last, full); !z.live() || z.span != full {
+		t.Errorf("zoomed out to %v, live %v, want %v", z.span, z.live(), full)
+	}
+
+	// Zooming in while live keeps following the newest data
+	v = v.zoom(0.5, at(10), first, last, full)
+	if !v.live() {
+		t.Fatal("zoom left the live view")
+	}
+	xMin, xMax := v.window(first, last, 0)
+	if !xMin.Equal(at(30)) || !xMax.Equal(last) {
+		t.Errorf("live zoom window = %v..%v, want 30s..60s", xMin.Sub(t0), xMax.Sub(t0))
+	}
+
+	// Dragging right by a third of the plot shows 10s older data
+	v = v.pan(10*time.Second, xMin, xMax, last)
+	if v.live() {
+		t.Fatal("pan did not leave the live view")
+	}
+	xMin, xMax = v.window(first, last, 0)
+	if !xMin.Equal(at(20)) || !xMax.Equal(at(50)) {
+		t.Errorf("panned window = %v..%v, want 20s..50s", xMin.Sub(t0), xMax.Sub(t0))
+	}
+
+	// Zooming around a point keeps it in place
+	v = v.zoom(0.5, at(35), xMin, xMax, full)
+	xMin, xMax = v.window(first, last, 0)
+	if !xMin.Equal(at(27.5)) || !xMax.Equal(at(42.5)) {
+		t.Errorf("anchored zoom window = %v..%v, want 27.5s..42.5s", xMin.Sub(t0), xMax.Sub(t0))
+	}
+
+	// Zoom is limited
+	for range 50 {
+		v = v.zoom(0.5, at(35), xMin, xMax, full)
+		xMin, xMax = v.window(first, last, 0)
+	}
+	if xMax.Sub(xMin) != minViewSpan {
+		t.Errorf("zoomed span = %v, want %v", xMax.Sub(xMin), minViewSpan)
+	}
+
+	// Panning past the newest data returns to the live view
+	v = v.pan(-time.Minute, xMin, xMax, last)
+	if !v.live() {
+		t.Error("pan past the newest data did not return to the live view")
+	}
+}
+
+func TestHistory_Merge(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	window := func(from, to int) ([]sample.Sample, []float64) {
+		samples := make([]sample.Sample, 0, to-from)
+		derivatives := make([]float64, 0, to-from)
+		for i := from; i < to; i++ {
+			samples = append(samples, sample.Sample{Timestamp: t0.Add(time.Duration(i) * time.Second), Reading: float64(i)})
+			if i+1 < to {
+				derivatives = append(derivatives, float64(i))
+			}
+		}
+		return samples, derivatives
+	}
+
+	var h history
+	samples, derivatives := window(0, 10)
+	h.merge(samples, derivatives, []meter.Pulse{{ID: 1, AvgPower: 1, DetectEndTime: t0.Add(2 * time.Second)}}, time.Minute)
+
+	// The meter's window moves on: overlapping samples are skipped and a
+	// refined pulse replaces the earlier fit
+	samples, derivatives = window(5, 15)
+	h.merge(samples, derivatives, []meter.Pulse{
+		{ID: 1, AvgPower: 2, DetectEndTime: t0.Add(2 * time.Second)},
+		{ID: 2, AvgPower: 3, DetectEndTime: t0.Add(12 * time.Second)},
+	}, time.Minute)
+	if len(h.samples) != 15 || len(h.derivatives) != 14 {
+		t.Fatalf("%d samples and %d derivatives, want 15 and 14", len(h.samples), len(h.derivatives))
+	}
+	for i, d := range h.derivatives {
+		if d != float64(i) || h.samples[i].Reading != float64(i) {
+			t.Fatalf("sample %d = %v, derivative %v, want %d", i, h.samples[i].Reading, d, i)
+		}
+	}
+	if len(h.pulses) != 2 || h.pulses[0].AvgPower != 2 {
+		t.Errorf("pulses = %+v, want the refined first pulse and the second", h.pulses)
+	}
+
+	// Data older than the history length is dropped, with its pulses
+	samples, derivatives = window(14, 20)
+	h.merge(samples, derivatives, nil, 10*time.Second)
+	if first := h.samples[0].Timestamp.Sub(t0); first != 9*time.Second || len(h.derivatives) != len(h.samples)-1 {
+		t.Errorf("history starts at %v with %d derivatives for %d samples, want 9s", first, len(h.derivatives), len(h.samples))
+	}
+	if len(h.pulses) != 1 || h.pulses[0].ID != 2 {
+		t.Errorf("pulses = %+v, want only the second", h.pulses)
+	}
+
+	// A window from before the history restarts it
+	samples, derivatives = window(0, 3)
+	h.merge(samples, derivatives, nil, time.Minute)
+	if len(h.samples) != 3 || len(h.pulses) != 0 {
+		t.Errorf("%d samples and %d pulses after a restart, want 3 and 0", len(h.samples), len(h.pulses))
+	}
+}
+
+func TestScopeWidget_History(t *testing.T) {
+	test.NewTempApp(t)
+	t0 := time.Unix(1_700_000_000, 0)
+	window := func(from, to int) []sample.Sample {
+		samples := make([]sample.Sample, 0, to-from)
+		for i := from; i < to; i++ {
+			samples = append(samples, sample.Sample{Timestamp: t0.Add(time.Duration(i) * 100 * time.Millisecond)})
+		}
+		return samples
+	}
+
+	cfg := config.Default()
+	cfg.Measurement.WindowSeconds = 5
+	s := New(cfg)
+	s.Resize(fyne.NewSize(600, 300))
+	pulse := meter.Pulse{ID: 1, DetectStartTime: t0.Add(time.Second), DetectEndTime: t0.Add(2 * time.Second)}
+	s.UpdateData(window(0, 50), make([]float64, 49), []meter.Pulse{pulse}, nil, 0)
+
+	// Frozen while the meter's window moves past the pulse
+	s.SetFrozen(true)
+	for from := 10; from <= 150; from += 10 {
+		s.UpdateData(window(from, from+50), make([]float64, 49), nil, nil, 0)
+	}
+	s.SetFrozen(false)
+	if xMin, xMax := s.xMin.Sub(t0), s.xMax.Sub(t0); xMin != 14900*time.Millisecond || xMax != 19900*time.Millisecond {
+		t.Errorf("live window = %v..%v, want the newest 5s", xMin, xMax)
+	}
+
+	// Scrolling back reaches the pulse the meter has dropped
+	s.Dragged(&fyne.DragEvent{Dragged: fyne.Delta{DX: 3 * s.plotWidth()}})
+	if xMin := s.xMin.Sub(t0); xMin != -100*time.Millisecond {
+		t.Errorf("scrolled back to %v, want -100ms", xMin)
+	}
+	if len(s.samples) != 200 || len(s.pulses) != 1 {
+		t.Errorf("%d samples and %d pulses in the history, want 200 and 1", len(s.samples), len(s.pulses))
+	}
+}
+
+func TestScopeWidget_Markers(t *testing.T) {
+	test.NewTempApp(t)
+	t0 := time.Unix(1_700_000_000, 0)
+	samples := make([]sample.Sample, 10)
+	for i := range samples {
+		samples[i].Timestamp = t0.Add(time.Duration(i) * time.Second)
+	}
+	s := New(config.Default())
+	s.UpdateData(samples, make([]float64, 9), nil, nil, 0)
+
+	// At the newest sample, or at cursor 1 to mark a past event
+	if m := s.AddMarker(" changed filter "); !m.Time.Equal(samples[9].Timestamp) || m.Label != "changed filter" {
+		t.Errorf("marker = %+v, want \"changed filter\" at the newest sample", m)
+	}
+	s.cursors[0] = samples[3].Timestamp
+	s.AddMarker("aligned beam")
+
+	markers := s.Markers()
+	if len(markers) != 2 || markers[0].Label != "aligned beam" || !markers[0].Time.Equal(samples[3].Timestamp) {
+		t.Errorf("markers = %+v, want aligned beam at 3s first", markers)
+	}
+
+	// Markers outlive the history
+	s.ClearHistory()
+	if len(s.Markers()) != 2 {
+		t.Error("markers cleared with the history")
+	}
+}
+
+func TestScopeWidget_LoadHistory(t *testing.T) {
+	test.NewTempApp(t)
+	t0 := time.Unix(1_700_000_000, 0)
+	samples := make([]sample.Sample, 100)
+	for i := range samples {
+		samples[i].Timestamp = t0.Add(time.Duration(i) * 100 * time.Millisecond)
+	}
+	pulses := []meter.Pulse{{ID: 1, State: meter.PulseStateFinalized, DetectStartTime: t0.Add(time.Second), DetectEndTime: t0.Add(2 * time.Second)}}
+
+	cfg := config.Default()
+	cfg.Measurement.WindowSeconds = 5
+	s := New(cfg)
+	s.Resize(fyne.NewSize(600, 300))
+	s.AddMarker("live")
+	s.LoadHistory(samples, make([]float64, 99), pulses, []Marker{{Time: t0.Add(3 * time.Second), Label: "saved"}})
+
+	// Frozen on all of the recorded data, with its markers
+	if !s.Frozen() {
+		t.Error("display not frozen on the loaded data")
+	}
+	if xMin, xMax := s.xMin.Sub(t0), s.xMax.Sub(t0); xMin != 0 || xMax != 9900*time.Millisecond {
+		t.Errorf("view = %v..%v, want all 9.9s", xMin, xMax)
+	}
+	if markers := s.Markers(); len(markers) != 1 || markers[0].Label != "saved" {
+		t.Errorf("markers = %+v, want the saved one", markers)
+	}
+	if got, _, p := s.History(); len(got) != 100 || len(p) != 1 {
+		t.Errorf("history has %d samples and %d pulses, want 100 and 1", len(got), len(p))
+	}
+}
+
+func TestVisibleRange(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	samples := make([]sample.Sample, 10)
+	for i := range samples {
+		samples[i].Timestamp = t0.Add(time.Duration(i) * time.Second)
+	}
+
+	from, to := visibleRange(samples, t0.Add(2500*time.Millisecond), t0.Add(6*time.Second))
+	if from != 3 || to != 7 {
+		t.Errorf("visibleRange() = %d..%d, want 3..7", from, to)
+	}
+	from, to = visibleRange(samples, t0.Add(-time.Hour), t0.Add(-time.Minute))
+	if from != to {
+		t.Errorf("visibleRange() before the data = %d..%d, want empty", from, to)
+	}
+}
+
+func TestNearestSample(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	samples := make([]sample.Sample, 5)
+	for i := range samples {
+		samples[i].Timestamp = t0.Add(time.Duration(i) * time.Second)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Duration
+		want int
+	}{
+		{"exact", 2 * time.Second, 2},
+		{"closer to earlier", 2400 * time.Millisecond, 2},
+		{"closer to later", 2600 * time.Millisecond, 3},
+		{"before first", -time.Second, 0},
+		{"after last", time.Minute, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nearestSample(samples, t0.Add(tt.at)); got != tt.want {
+				t.Errorf("nearestSample() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if got := nearestSample(nil, t0); got != -1 {
+		t.Errorf("nearestSample(nil) = %d, want -1", got)
+	}
+}
+
+func TestPlaceCursor(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	at := func(s int) time.Time { return t0.Add(time.Duration(s) * time.Second) }
+
+	var cursors [2]time.Time
+	cursors = placeCursor(cursors, at(10))
+	cursors = placeCursor(cursors, at(20))
+	if !cursors[0].Equal(at(10)) || !cursors[1].Equal(at(20)) {
+		t.Fatalf("placed cursors = %v, want 10s and 20s", cursors)
+	}
+
+	// Later taps move the nearer cursor
+	cursors = placeCursor(cursors, at(18))
+	if !cursors[0].Equal(at(10)) || !cursors[1].Equal(at(18)) {
+		t.Errorf("moved cursors = %v, want 10s and 18s", cursors)
+	}
+	cursors = placeCursor(cursors, at(5))
+	if !cursors[0].Equal(at(5)) {
+		t.Errorf("moved cursor 1 = %v, want 5s", cursors[0])
+	}
+}
+
+func TestMeasureCursors(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	samples := make([]sample.Sample, 11)
+	for i := range samples {
+		samples[i] = sample.Sample{
+			Timestamp: t0.Add(time.Duration(i) * 100 * time.Millisecond),
+			Reading:   float64(i) * 0.001,
+		}
+		if i >= 5 {
+			samples[i].HeaterPower = 0.01
+		}
+	}
+
+	// Cursors in either order, between samples
+	m, ok := measureCursors(samples, t0.Add(1020*time.Millisecond), t0.Add(190*time.Millisecond))
+	if !ok {
+		t.Fatal("measureCursors() not ok")
+	}
+	if m.dt != -800*time.Millisecond {
+		t.Errorf("dt = %v, want -800ms", m.dt)
+	}
+	if math.Abs(m.dv-(-0.008)) > 1e-12 {
+		t.Errorf("dv = %v, want -0.008", m.dv)
+	}
+	// Samples 2..10, of which 5..10 have the heater on
+	if math.Abs(m.meanPower-0.01*6/9) > 1e-12 {
+		t.Errorf("meanPower = %v, want %v", m.meanPower, 0.01*6/9)
+	}
+
+	if _, ok := measureCursors(samples, t0.Add(-time.Second), t0); ok {
+		t.Error("measureCursors() ok for a cursor before the buffer")
+	}
+}
+
+func TestScopeWidget_Frozen(t *testing.T) {
+	test.NewTempApp(t)
+	s := New(config.Default())
+
+	t0 := time.Unix(1_700_000_000, 0)
+	older := []sample.Sample{{Timestamp: t0}, {Timestamp: t0.Add(time.Second)}}
+	newer := append(older, sample.Sample{Timestamp: t0.Add(2 * time.Second)})
+
+	s.UpdateData(older, nil, nil, nil, 0)
+	s.SetFrozen(true)
+	s.view = viewState{span: time.Second, end: t0.Add(time.Second)}
+	s.UpdateData(newer, nil, nil, nil, 0.5)
+	s.UpdateTruth([]TruthPoint{{Time: t0}})
+
+	if !s.Frozen() {
+		t.Fatal("Frozen() = false after SetFrozen(true)")
+	}
+	if len(s.samples) != len(older) || s.heaterPower != 0 || s.truth != nil {
+		t.Errorf("frozen scope took an update: %d samples, heater power %v", len(s.samples), s.heaterPower)
+	}
+
+	// Resuming shows the latest update in the live view
+	s.SetFrozen(false)
+	if len(s.samples) != len(newer) || s.heaterPower != 0.5 || len(s.truth) != 1 {
+		t.Errorf("resumed scope has %d samples, heater power %v, %d truth points", len(s.samples), s.heaterPower, len(s.truth))
+	}
+	if !s.IsLive() {
+		t.Error("resumed scope is not live")
+	}
+}
+
+// samplesUntil returns samples every 100 ms from t0 to t0+d.
+func samplesUntil(t0 time.Time, d time.Duration) []sample.Sample {
+	var samples []sample.Sample
+	for dt := time.Duration(0); dt <= d; dt += 100 * time.Millisecond {
+		samples = append(samples, sample.Sample{Timestamp: t0.Add(dt)})
+	}
+	return samples
+}
+
+func TestScopeWidget_TriggerSingle(t *testing.T) {
+	test.NewTempApp(t)
+	cfg := config.Default()
+	cfg.Measurement.WindowSeconds = 4 // 1s before the trigger, 3s after
+	s := New(cfg)
+	s.SetTriggerMode(TriggerSingle)
+
+	t0 := time.Unix(1_700_000_000, 0)
+	pulse := meter.Pulse{ID: 1, DetectStartTime: t0.Add(2 * time.Second)}
+
+	s.UpdateData(samplesUntil(t0, 2*time.Second), nil, nil, nil, 0)
+	if got := s.trigger.status(); got != "ARMED" {
+		t.Errorf("status = %q before a pulse, want ARMED", got)
+	}
+
+	// The display waits for the data after the trigger
+	s.UpdateData(samplesUntil(t0, 4*time.Second), nil, nil, &pulse, 0)
+	if got := s.trigger.status(); got != "TRIGGERED" {
+		t.Errorf("status = %q during the capture, want TRIGGERED", got)
+	}
+	if len(s.samples) != 0 {
+		t.Errorf("display shows %d samples before the capture completed", len(s.samples))
+	}
+
+	s.UpdateData(samplesUntil(t0, 5*time.Second), nil, []meter.Pulse{pulse}, nil, 0)
+	s.flush() // Without waiting for the throttle
+	if got := s.trigger.status(); got != "STOPPED" {
+		t.Errorf("status = %q after a single capture, want STOPPED", got)
+	}
+	if !s.xMin.Equal(t0.Add(time.Second)) || !s.xMax.Equal(t0.Add(5*time.Second)) {
+		t.Errorf("capture window = %v..%v, want 1s..5s", s.xMin.Sub(t0), s.xMax.Sub(t0))
+	}
+	if at, ok := s.triggerMarker(); !ok || !at.Equal(pulse.DetectStartTime) {
+		t.Errorf("trigger marker = %v, %v", at, ok)
+	}
+
+	// Stopped: later pulses leave the capture alone, and re-arming skips them
+	next := meter.Pulse{ID: 2, DetectStartTime: t0.Add(6 * time.Second)}
+	s.UpdateData(samplesUntil(t0, 10*time.Second), nil, []meter.Pulse{pulse, next}, nil, 0)
+	if len(s.samples) != 51 {
+		t.Errorf("stopped display changed to %d samples", len(s.samples))
+	}
+	s.Arm()
+	s.UpdateData(samplesUntil(t0, 20*time.Second), nil, []meter.Pulse{pulse, next}, nil, 0)
+	if got := s.trigger.status(); got != "ARMED" {
+		t.Errorf("status = %q after re-arming, want ARMED", got)
+	}
+}
+
+func TestScopeWidget_TriggerAutoRolls(t *testing.T) {
+	test.NewTempApp(t)
+	cfg := config.Default()
+	cfg.Measurement.WindowSeconds = 4
+	s := New(cfg)
+	s.SetTriggerMode(TriggerAuto)
+
+	t0 := time.Unix(1_700_000_000, 0)
+	s.UpdateData(samplesUntil(t0, 5*time.Second), nil, nil, nil, 0)
+	if len(s.samples) != 0 {
+		t.Errorf("auto display changed to %d samples before a window passed", len(s.samples))
+	}
+
+	// No pulse for a whole window: show the newest data
+	s.UpdateData(samplesUntil(t0, 10*time.Second), nil, nil, nil, 0)
+	if len(s.samples) != 101 || !s.IsLive() {
+		t.Errorf("auto display has %d samples, live %v; want it rolling", len(s.samples), s.IsLive())
+	}
+
+	// Back to rolling shows every update
+	s.SetTriggerMode(TriggerRoll)
+	s.UpdateData(samplesUntil(t0, 11*time.Second), nil, nil, nil, 0)
+	if len(s.samples) != 111 {
+		t.Errorf("rolling display has %d samples", len(s.samples))
+	}
+}
+
+func TestSpectrumWidget_FollowsScopeView(t *testing.T) {
+	test.NewTempApp(t)
+	s := New(config.Default())
+	spectrum := NewSpectrum()
+	spectrum.Hide()
+	s.OnViewChanged = spectrum.Update
+
+	t0 := time.Unix(1_700_000_000, 0)
+	s.UpdateData(samplesUntil(t0, 10*time.Second), nil, nil, nil, 0)
+	if len(spectrum.spectrum.Amplitude) != 0 {
+		t.Error("hidden spectrum was computed")
+	}
+
+	spectrum.Show()
+	s.Refresh()
+	if len(spectrum.spectrum.Amplitude) != 65 { // 101 samples, padded to 128
+		t.Errorf("spectrum has %d bins, want 65", len(spectrum.spectrum.Amplitude))
+	}
+}
+
+func TestScopeWidget_Sweep(t *testing.T) {
+	test.NewTempApp(t)
+	cfg := config.Default()
+	cfg.Scope.Display = "sweep"
+	s := New(cfg)
+
+	t0 := time.Unix(1_700_000_000, 0) // A multiple of the 10s window
+	s.UpdateData(samplesUntil(t0, 9*time.Second), nil, nil, nil, 0)
+	if !s.xMin.Equal(t0) || !s.xMax.Equal(t0.Add(10*time.Second)) {
+		t.Errorf("first sweep = %v..%v, want 0s..10s", s.xMin.Sub(t0), s.xMax.Sub(t0))
+	}
+
+	// Past the right edge the trace restarts at the left
+	s.UpdateData(samplesUntil(t0, 13*time.Second), nil, nil, nil, 0)
+	s.flush() // Without waiting for the throttle
+	if !s.xMin.Equal(t0.Add(10*time.Second)) || !s.xMax.Equal(t0.Add(20*time.Second)) {
+		t.Errorf("second sweep = %v..%v, want 10s..20s", s.xMin.Sub(t0), s.xMax.Sub(t0))
+	}
+	if n := len(s.visibleSamples()); n != 31 {
+		t.Errorf("second sweep shows %d samples, want 31", n)
+	}
+
+	// Zooming shows a rolling window
+	s.view = viewState{span: 5 * time.Second}
+	s.updateView()
+	if s.sweeping() || !s.xMax.Equal(t0.Add(13*time.Second)) {
+		t.Errorf("zoomed view = %v..%v, want it rolling", s.xMin.Sub(t0), s.xMax.Sub(t0))
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 14, 3, 7, 123_456_789, time.Local)
+	tests := []struct {
+		step time.Duration
+		want string
+	}{
+		{time.Second, "14:03:07"},
+		{200 * time.Millisecond, "14:03:07.1"},
+		{20 * time.Millisecond, "14:03:07.123"},
+	}
+	for _, tt := range tests {
+		if got := formatClock(ts, tt.step); got != tt.want {
+			t.Errorf("formatClock(step %v) = %q, want %q", tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestPinch(t *testing.T) {
+	fixed := fyne.NewPos(100, 50)
+
+	// Spreading the fingers zooms in around their midpoint
+	factor, anchorX, centerDX := pinch(fixed, fyne.NewPos(200, 50), fyne.NewPos(300, 60))
+	if factor != 0.5 || anchorX != 200 || centerDX != 50 {
+		t.Errorf("spread = %v, %v, %v, want 0.5, 200, 50", factor, anchorX, centerDX)
+	}
+
+	// Fingers too close together only pan
+	factor, _, centerDX = pinch(fixed, fyne.NewPos(110, 50), fyne.NewPos(130, 50))
+	if factor != 1 || centerDX != 10 {
+		t.Errorf("close fingers = %v, %v, want 1, 10", factor, centerDX)
+	}
+}
+
+func TestNearestTouch(t *testing.T) {
+	touches := []fyne.Position{{X: 10, Y: 10}, {X: 200, Y: 10}}
+	if i := nearestTouch(touches, fyne.NewPos(190, 30)); i != 1 {
+		t.Errorf("nearestTouch = %d, want 1", i)
+	}
+	if i := nearestTouch(nil, fyne.NewPos(0, 0)); i != -1 {
+		t.Errorf("nearestTouch(nil) = %d, want -1", i)
+	}
+}
+
+func TestScopeWidget_PinchZoom(t *testing.T) {
+	test.NewTempApp(t)
+	s := New(config.Default())
+	s.Resize(fyne.NewSize(920, 400)) // 800 px plot
+	t0 := time.Unix(1_700_000_000, 0)
+	s.UpdateData(samplesUntil(t0, 20*time.Second), nil, nil, nil, 0)
+	s.SetFrozen(true)
+	span := s.xMax.Sub(s.xMin)
+
+	// Spread two fingers to twice their distance: half the span
+	s.TouchDown(&mobile.TouchEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(300, 100)}})
+	s.TouchDown(&mobile.TouchEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(500, 100)}})
+	s.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(700, 100)}, Dragged: fyne.Delta{DX: 200}})
+	if got := s.xMax.Sub(s.xMin); got != span/2 {
+		t.Errorf("span after pinch = %v, want %v", got, span/2)
+	}
+
+	// With one finger lifted, dragging pans again
+	s.TouchUp(&mobile.TouchEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(700, 100)}})
+	if len(s.touches) != 1 || s.touches[0] != fyne.NewPos(300, 100) {
+		t.Errorf("touches after lift = %v, want [{300 100}]", s.touches)
+	}
+}
+
+func TestDrawPath(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	mask := image.NewAlpha(img.Rect)
+	half := color.RGBA{R: 128, A: 128} // Premultiplied red at 50%
+
+	// Two segments joined at (10, 5): the joint must not be drawn twice
+	drawPath(img, mask, rasterPath{
+		points: []fyne.Position{{X: 2, Y: 5}, {X: 10, Y: 5}, {X: 18, Y: 5}},
+		color:  half,
+		width:  2,
+	}, 1)
+
+	if got := img.RGBAAt(6, 4); got.A < 120 || got.A > 130 {
+		t.Errorf("pixel on the line = %v, want about half opaque", got)
+	}
+	if got, want := img.RGBAAt(10, 4), img.RGBAAt(6, 4); got != want {
+		t.Errorf("joint pixel = %v, want %v like the rest of the line", got, want)
+	}
+	if got := img.RGBAAt(6, 0); got.A != 0 {
+		t.Errorf("pixel away from the line = %v, want transparent", got)
+	}
+}
+
+func TestHeaterSpans(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	at := func(i int) time.Time { return t0.Add(time.Duration(i) * time.Second) }
+	states := [][]bool{
+		{false, false, false},
+		{true, false, false},
+		{true, true, false},
+		{false, true, false},
+		{false, true, false},
+	}
+	samples := make([]sample.Sample, len(states))
+	for i, h := range states {
+		samples[i] = sample.Sample{Timestamp: at(i), Heaters: h}
+	}
+
+	spans := heaterSpans(samples)
+	want := [][]timeSpan{
+		{{at(1), at(3)}},
+		{{at(2), at(4)}}, // Still on at the last sample
+		nil,
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("spans of %d heaters, want %d", len(spans), len(want))
+	}
+	for h := range want {
+		if !slices.Equal(spans[h], want[h]) {
+			t.Errorf("heater %d spans = %v, want %v", h+1, spans[h], want[h])
+		}
+	}
+}
+
+func TestComputeLiveValues(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	at := func(i int) time.Time { return t0.Add(time.Duration(i) * 100 * time.Millisecond) }
+
+	// 3 s of samples: quiet at 0.1 V with a ±1 mV/s derivative, except for
+	// a pulse at 0.5 V between samples 20 and 24
+	samples := make([]sample.Sample, 30)
+	derivatives := make([]float64, 29)
+	for i := range samples {
+		samples[i] = sample.Sample{Timestamp: at(i), Reading: 0.1, HeaterPower: 0.002}
+		if i >= 20 && i <= 24 {
+			samples[i].Reading = 0.5
+		}
+	}
+	for i := range derivatives {
+		derivatives[i] = 0.001
+		if i%2 == 1 {
+			derivatives[i] = -0.001
+		}
+	}
+	pulses := make([]meter.Pulse, 12)
+	for i := range pulses {
+		pulses[i].AvgPower = 0.010 // 10 mW
+	}
+	pulses[11] = meter.Pulse{AvgPower: 0.021, DetectStartTime: at(20), StartTime: at(20), EndTime: at(24)}
+
+	v := computeLiveValues(samples, derivatives, pulses, nil)
+	if v.lastPower != 0.021 || v.averageCount != 10 || math.Abs(v.averagePower-0.0111) > 1e-9 {
+		t.Errorf("pulse powers = %v, %v of %d, want 0.021, 0.0111 of 10", v.lastPower, v.averagePower, v.averageCount)
+	}
+	if math.Abs(v.baseline-0.1) > 1e-9 {
+		t.Errorf("baseline = %v, want 0.1 without the pulse", v.baseline)
+	}
+	if math.Abs(v.noise-0.001) > 1e-4 {
+		t.Errorf("noise = %v, want about 0.001", v.noise)
+	}
+	if v.heaterPower != 0.002 {
+		t.Errorf("heater power = %v, want 0.002", v.heaterPower)
+	}
+
+	v = computeLiveValues(nil, nil, nil, nil)
+	if !math.IsNaN(v.lastPower) || !math.IsNaN(v.baseline) || !math.IsNaN(v.heaterPower) {
+		t.Errorf("values without data = %+v, want NaN", v)
+	}
+}
+
+func TestLiveReadout_Show(t *testing.T) {
+	test.NewTempApp(t)
+	w := NewLiveReadout()
+	w.show(liveValues{
+		lastPower:    0.0125,
+		averagePower: 0.012,
+		averageCount: 3,
+		baseline:     math.NaN(),
+		noise:        math.NaN(),
+		heaterPower:  0,
+	})
+	if w.lastPower.Text != "12.50 mW" || w.baseline.Text != "—" {
+		t.Errorf("texts = %q, %q, want 12.50 mW and a dash", w.lastPower.Text, w.baseline.Text)
+	}
+	if w.averageFor.Text != "Average of 3" {
+		t.Errorf("average caption = %q", w.averageFor.Text)
+	}
+
+	// New display units show on refresh
+	t.Cleanup(func() { units.Set("mW", "mV") })
+	units.Set("W", "mV")
+	fyne.CurrentApp().Settings().SetTheme(theme.DefaultTheme()) // With the bold monospace font
+	w.Refresh()
+	if w.lastPower.Text != "0.01250 W" {
+		t.Errorf("text after refresh = %q, want 0.01250 W", w.lastPower.Text)
+	}
+}
+
+func TestParseTriggerMode(t *testing.T) {
+	if m, ok := ParseTriggerMode("single"); !ok || m != TriggerSingle {
+		t.Errorf("ParseTriggerMode(single) = %v, %v", m, ok)
+	}
+	if m, ok := ParseTriggerMode("sideways"); ok || m != TriggerRoll {
+		t.Errorf("ParseTriggerMode(sideways) = %v, %v, want Roll, false", m, ok)
+	}
+}
+
+func TestScopeWidget_Views(t *testing.T) {
+	test.NewTempApp(t)
+	s := New(config.Default())
+	t0 := time.Unix(1_700_000_000, 0)
+	s.UpdateData(samplesUntil(t0, 20*time.Second), nil, nil, nil, 0)
+
+	cw := config.ScopeView{
+		Name:            "CW",
+		Span:            5 * time.Second,
+		Traces:          config.TraceVisibility{Reading: true, HeaterPower: true},
+		DerivativeRange: config.AxisRange{Min: -10, Max: 10},
+		Trigger:         "auto",
+	}
+	s.ApplyView(cw)
+	if s.TriggerMode() != TriggerAuto || s.cfg.Scope.Derivative.Show || !s.cfg.Scope.HeaterPower.Show {
+		t.Errorf("after applying: trigger %v, derivative shown %v, heater shown %v",
+			s.TriggerMode(), s.cfg.Scope.Derivative.Show, s.cfg.Scope.HeaterPower.Show)
+	}
+	if s.derivativeYMax != 0.010 {
+		t.Errorf("derivative axis max = %v, want the locked 10 mV/s", s.derivativeYMax)
+	}
+	if got := s.CurrentView("CW"); got != cw {
+		t.Errorf("CurrentView = %+v, want %+v", got, cw)
+	}
+}
+
+func TestDrawPath_Band(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	mask := image.NewAlpha(img.Rect)
+
+	drawPath(img, mask, rasterPath{
+		points: []fyne.Position{{X: 2, Y: 2}, {X: 18, Y: 2}},
+		lower:  []fyne.Position{{X: 2, Y: 6}, {X: 18, Y: 6}},
+		color:  color.RGBA{G: 255, A: envelopeAlpha},
+	}, 1)
+
+	if got := img.RGBAAt(10, 4); got.A != envelopeAlpha {
+		t.Errorf("pixel inside the band = %v, want alpha %d", got, envelopeAlpha)
+	}
+	for _, pt := range []image.Point{{10, 8}, {0, 4}} {
+		if got := img.RGBAAt(pt.X, pt.Y); got.A != 0 {
+			t.Errorf("pixel %v outside the band = %v, want transparent", pt, got)
+		}
+	}
+}
+
+func TestScopeWidget_Envelope(t *testing.T) {
+	test.NewTempApp(t)
+	t0 := time.Unix(1_700_000_000, 0)
+	samples := make([]sample.Sample, 5000)
+	for i := range samples {
+		samples[i] = sample.Sample{Timestamp: t0.Add(time.Duration(i) * 2 * time.Millisecond), Reading: 0.1}
+	}
+	samples[2501].Reading = 0.5 // A spike averaged away by downsampling
+
+	cfg := config.Default()
+	cfg.Measurement.WindowSeconds = 0 // Show the whole buffer
+	s := New(cfg)
+	s.UpdateData(samples, make([]float64, len(samples)-1), nil, nil, 0)
+
+	if len(s.readingEnvelope) != len(s.displaySamples) || len(s.derivativeEnvelope) == 0 {
+		t.Fatalf("envelopes %d and %d for %d points", len(s.readingEnvelope), len(s.derivativeEnvelope), len(s.displaySamples))
+	}
+	var peak float64
+	for _, e := range s.readingEnvelope {
+		peak = max(peak, e.Max)
+	}
+	if peak != 0.5 || s.sampleYMax < 0.5 {
+		t.Errorf("envelope peak %v, axis max %v, want the 0.5 spike on screen", peak, s.sampleYMax)
+	}
+
+	// Without downsampling there is nothing to show
+	s.ClearHistory()
+	s.UpdateData(samples[:500], make([]float64, 499), nil, nil, 0)
+	s.flush() // Without waiting for the throttle
+	if len(s.readingEnvelope) != 0 {
+		t.Errorf("envelope of %d points without downsampling", len(s.readingEnvelope))
+	}
+}
+
+func TestScopeRenderer_Raster(t *testing.T) {
+	test.NewTempApp(t)
+	t0 := time.Unix(1_700_000_000, 0)
+	for _, tt := range []struct {
+		renderer   string
+		wantRaster bool
+	}{
+		{"raster", true},
+		{"lines", false},
+	} {
+		cfg := config.Default()
+		cfg.Scope.Renderer = tt.renderer
+		s := New(cfg)
+		s.Resize(fyne.NewSize(800, 400))
+		s.UpdateData(samplesUntil(t0, 5*time.Second), nil, nil, nil, 0)
+
+		var rasters, lines int
+		for _, o := range test.TempWidgetRenderer(t, s).Objects() {
+			switch o.(type) {
+			case *canvas.Raster:
+				rasters++
+			case *canvas.Line:
+				lines++
+			}
+		}
+		if tt.wantRaster && (rasters != 1 || lines > 100) {
+			t.Errorf("%s: %d rasters and %d lines, want the traces in one raster", tt.renderer, rasters, lines)
+		}
+		if !tt.wantRaster && (rasters != 0 || lines < 50) {
+			t.Errorf("%s: %d rasters and %d lines, want a line per segment", tt.renderer, rasters, lines)
+		}
+	}
+}
+
+func TestRefreshThrottle(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	var th refreshThrottle
+	if w := th.wait(t0); w != 0 {
+		t.Errorf("first redraw waits %v", w)
+	}
+
+	// Fast redraws run at about 60 FPS
+	th.record(t0, time.Millisecond, 0)
+	if th.interval != minRefreshInterval || th.wait(t0.Add(10*time.Millisecond)) != 6*time.Millisecond {
+		t.Errorf("interval %v after a fast redraw, want %v", th.interval, minRefreshInterval)
+	}
+
+	// Slow redraws back off to keep the main thread free, within limits
+	for range 20 {
+		th.record(t0, 50*time.Millisecond, 0)
+	}
+	if th.interval < 190*time.Millisecond || th.interval > 200*time.Millisecond {
+		t.Errorf("interval %v after 50ms redraws, want about 200ms", th.interval)
+	}
+	for range 20 {
+		th.record(t0, time.Second, 0)
+	}
+	if th.interval != maxRefreshInterval {
+		t.Errorf("interval %v after 1s redraws, want %v", th.interval, maxRefreshInterval)
+	}
+
+	// And recover when rendering speeds up
+	for range 40 {
+		th.record(t0, time.Millisecond, 0)
+	}
+	if th.interval != minRefreshInterval {
+		t.Errorf("interval %v after fast redraws again, want %v", th.interval, minRefreshInterval)
+	}
+
+	// A configured interval slows the fastest redraws down
+	th.record(t0, time.Millisecond, 40*time.Millisecond)
+	if th.interval != 40*time.Millisecond {
+		t.Errorf("interval %v with a 40ms refresh interval, want 40ms", th.interval)
+	}
+}
+
+func TestRefreshThrottle_Performance(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	var th refreshThrottle
+	if p := th.performance(t0); p.FPS != 0 {
+		t.Errorf("FPS %v before any redraw", p.FPS)
+	}
+
+	// 21 redraws 50ms apart are 20 frames over a second
+	for i := range 21 {
+		th.record(t0.Add(time.Duration(i)*50*time.Millisecond), 2*time.Millisecond, 0)
+	}
+	p := th.performance(t0.Add(time.Second))
+	if p.FPS != 20 || p.Redraw != 2*time.Millisecond || p.Interval != minRefreshInterval {
+		t.Errorf("performance %+v, want 20 FPS, 2ms redraws, %v interval", p, minRefreshInterval)
+	}
+	if p := th.performance(t0.Add(5 * time.Second)); p.FPS != 0 {
+		t.Errorf("FPS %v after redraws stopped, want 0", p.FPS)
+	}
+}
+
+func TestScopeWidget_Throttle(t *testing.T) {
+	test.NewTempApp(t)
+	t0 := time.Unix(1_700_000_000, 0)
+	s := New(config.Default())
+	s.UpdateData(samplesUntil(t0, time.Second), nil, nil, nil, 0)
+	drawn := s.xMax
+
+	// An update right after a redraw waits for the next one
+	s.UpdateData(samplesUntil(t0, 20*time.Second), nil, nil, nil, 0)
+	s.mu.RLock()
+	stale, scheduled, xMax := s.stale, s.throttle.scheduled, s.xMax
+	s.mu.RUnlock()
+	if !stale || !scheduled || !xMax.Equal(drawn) {
+		t.Fatalf("stale %v, scheduled %v, drawn to %v: want the update held back", stale, scheduled, xMax.Sub(t0))
+	}
+
+	// The scheduled redraw draws the newest data
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.RLock()
+		stale, xMax = s.stale, s.xMax
+		s.mu.RUnlock()
+		if !stale || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if stale || !xMax.Equal(t0.Add(20*time.Second)) {
+		t.Errorf("stale %v, drawn to %v after the throttle, want 20s", stale, xMax.Sub(t0))
+	}
+}
+
+func TestScopeRenderer_SplitLayout(t *testing.T) {
+	test.NewTempApp(t)
+	t0 := time.Unix(1_700_000_000, 0)
+	labelsAt := func(layout string) (reading, derivative []float32) {
+		cfg := config.Default()
+		cfg.Scope.Layout = layout
+		s := New(cfg)
+		s.Resize(fyne.NewSize(800, 400))
+		s.UpdateData(samplesUntil(t0, 5*time.Second), nil, nil, nil, 0)
+
+		r := test.TempWidgetRenderer(t, s).(*scopeRenderer)
+		for _, text := range r.gridTexts {
+			switch text.Color {
+			case readingColor:
+				reading = append(reading, text.Position().Y)
+			case derivativeColor:
+				derivative = append(derivative, text.Position().Y)
+			}
+		}
+		return reading, derivative
+	}
+
+	// Overlaid, both axes label the same grid lines
+	reading, derivative := labelsAt("overlay")
+	if !slices.Equal(reading, derivative) || len(reading) != 9 {
+		t.Errorf("overlay labels at %v and %v, want the same 9", reading, derivative)
+	}
+
+	// Split, the derivative axis labels the sub-plot below the reading:
+	// plot 20..360, the reading 230 high, a gap of 8 and the sub-plot
+	reading, derivative = labelsAt("split")
+	if len(reading) != 9 || slices.Max(reading) != 20+230-6 {
+		t.Errorf("split reading labels at %v, want 9 down to 244", reading)
+	}
+	if len(derivative) != 4 || derivative[0] != 258-6 || slices.Max(derivative) != 360-6 {
+		t.Errorf("split derivative labels at %v, want 4 from 252 to 354", derivative)
+	}
+}
+
+func TestPulseAt(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	at := func(s int) time.Time { return t0.Add(time.Duration(s) * time.Second) }
+	pulses := []meter.Pulse{
+		{ID: 1, StartTime: at(10), EndTime: at(20)},
+		{ID: 2, StartTime: at(30), EndTime: at(40)},
+	}
+
+	for _, tt := range []struct {
+		t    time.Time
+		want int
+	}{
+		{at(5), -1},
+		{at(10), 0},
+		{at(15), 0},
+		{at(25), -1},
+		{at(40), 1},
+		{at(41), -1},
+	} {
+		if got := pulseAt(pulses, tt.t); got != tt.want {
+			t.Errorf("pulseAt(%v) = %d, want %d", tt.t.Sub(t0), got, tt.want)
+		}
+	}
+}
+
+func TestPulseDetails(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	p := meter.Pulse{
+		ID:              3,
+		DetectStartTime: t0,
+		DetectEndTime:   t0.Add(2 * time.Second),
+		StartTime:       t0.Add(500 * time.Millisecond),
+		EndTime:         t0.Add(1500 * time.Millisecond),
+		AvgPower:        0.05,
+		AvgSlope:        0.002,
+		StdDev:          0.0001,
+		StdDevThreshold: 0.0002,
+		RSquared:        0.987,
+	}
+
+	got := map[string]string{}
+	for _, row := range pulseDetails(p) {
+		got[row[0]] = row[1]
+	}
+	want := map[string]string{
+		"Power":      "50.00 mW",
+		"Energy":     "100.000 mJ", // 50 mW for the 2 s detected duration
+		"Duration":   "2.000s",
+		"Fit window": "1.000s",
+		"Confidence": "R² 0.987, good",
+	}
+	for label, value := range want {
+		if got[label] != value {
+			t.Errorf("%s = %q, want %q", label, got[label], value)
+		}
+	}
+}
+
+func TestPulseLog(t *testing.T) {
+	test.NewTempApp(t)
+	w := NewPulseLog()
+	t0 := time.Date(2024, 5, 1, 14, 3, 7, 0, time.UTC)
+	pulse := func(id int, state meter.PulseState, power float64) meter.Pulse {
+		start := t0.Add(time.Duration(id) * 10 * time.Second)
+		return meter.Pulse{ID: id, State: state, DetectStartTime: start, DetectEndTime: start.Add(2 * time.Second), AvgPower: power, RSquared: 0.99}
+	}
+
+	// Pulses are logged once, when finalized, and kept after leaving the window
+	w.Update([]meter.Pulse{pulse(1, meter.PulseStateFinalized, 0.01), pulse(2, meter.PulseStateUpdating, 0.02)})
+	w.Update([]meter.Pulse{pulse(2, meter.PulseStateFinalized, 0.02)})
+	w.Update([]meter.Pulse{pulse(2, meter.PulseStateFinalized, 0.02)})
+	if len(w.pulses) != 2 {
+		t.Fatalf("logged %d pulses, want 2", len(w.pulses))
+	}
+
+	// A new meter numbers its pulses from 1 again
+	w.Restart()
+	w.Update([]meter.Pulse{pulse(1, meter.PulseStateFinalized, 0.03)})
+	if len(w.pulses) != 3 || w.count.Text != "Pulses (3)" {
+		t.Fatalf("logged %d pulses (%q) after restart, want 3", len(w.pulses), w.count.Text)
+	}
+
+	// Newest first in the table
+	if got := w.cell(0, 0) + " " + w.cell(0, 3) + " " + w.cell(0, 4); got != "3 30.00 mW 60.000 mJ" {
+		t.Errorf("first row = %q, want the newest pulse", got)
+	}
+
+	want := "n,start,duration_s,power_mw,energy_mj,r_squared,fit\n" +
+		"1,2024-05-01 14:03:17.000,2.000,10.000,20.000,0.9900,good\n"
+	if csv := w.CSV(); !strings.HasPrefix(csv, want) || strings.Count(csv, "\n") != 4 {
+		t.Errorf("CSV = %q, want header and 3 rows starting %q", csv, want)
+	}
+
+	w.Clear()
+	if len(w.pulses) != 0 || w.count.Text != "Pulses (0)" {
+		t.Errorf("cleared log has %d pulses", len(w.pulses))
+	}
+}
+
+func TestFormat_DisplayUnits(t *testing.T) {
+	t.Cleanup(func() { units.Set("mW", "mV") })
+
+	units.Set("W", "V")
+	for got, want := range map[string]string{
+		formatPower(0.0125):     "0.01250 W",
+		formatEnergy(0.5):       "0.500 J",
+		formatVoltageMV(0.0125): "0.012500V",
+		formatDerivative(0.002): "0.002000V/s",
+	} {
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	units.Set("dBm", "mV")
+	for got, want := range map[string]string{
+		formatPower(0.1):        "20.00 dBm",
+		formatPower(0):          "-∞ dBm",
+		formatEnergy(0.5):       "500.000 mJ",
+		formatVoltageMV(0.0125): "12.500mV",
+		formatDerivative(0.002): "2.000mV/s",
+	} {
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	w := NewPulseLog()
+	w.pulses = []meter.Pulse{{ID: 1, AvgPower: 0.01, RSquared: 0.99}}
+	want := "n,start,duration_s,power_dbm,energy_mj,r_squared,fit\n"
+	if csv := w.CSV(); !strings.HasPrefix(csv, want) || !strings.Contains(csv, ",10.000,") {
+		t.Errorf("CSV = %q, want power in dBm", csv)
+	}
+}
+
+func TestScopeWidget_TapPulse(t *testing.T) {
+	test.NewTempApp(t)
+	s := New(config.Default())
+	w := test.NewTempWindow(t, s)
+	w.Resize(fyne.NewSize(800, 400))
+
+	t0 := time.Unix(1_700_000_000, 0)
+	pulse := meter.Pulse{ID: 1, StartTime: t0.Add(4 * time.Second), EndTime: t0.Add(6 * time.Second)}
+	s.UpdateData(samplesUntil(t0, 10*time.Second), nil, []meter.Pulse{pulse}, nil, 0)
+
+	// The middle of the plot is inside the pulse: details, no cursor
+	middle := fyne.NewPos(marginLeft+s.plotWidth()/2, 100)
+	s.Tapped(&fyne.PointEvent{Position: middle, AbsolutePosition: middle})
+	if n := len(w.Canvas().Overlays().List()); n != 1 {
+		t.Errorf("%d overlays after tapping a pulse, want the details popover", n)
+	}
+	if !s.cursors[0].IsZero() {
+		t.Error("tapping a pulse placed a cursor")
+	}
+
+	// Outside the pulse the tap places a cursor
+	left := fyne.NewPos(marginLeft+10, 100)
+	s.Tapped(&fyne.PointEvent{Position: left, AbsolutePosition: left})
+	if s.cursors[0].IsZero() {
+		t.Error("tapping outside a pulse placed no cursor")
+	}
+}
+
+func TestPulseKeepIndices(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	samples := samplesUntil(t0, 10*time.Second) // 101 samples, 100 ms apart
+	samples[45].Reading = -1
+	samples[55].Reading = 1
+	pulse := meter.Pulse{
+		DetectStartTime: t0.Add(4 * time.Second),
+		DetectEndTime:   t0.Add(6 * time.Second),
+		StartTime:       t0.Add(4500 * time.Millisecond),
+		EndTime:         t0.Add(5500 * time.Millisecond),
+	}
+	outside := meter.Pulse{DetectStartTime: t0.Add(time.Minute), DetectEndTime: t0.Add(2 * time.Minute)}
+
+	// Edges at 40, 45, 55 and 60; the extrema at 45 and 55 coincide with
+	// the fit edges
+	got := map[int]bool{}
+	for _, i := range pulseKeepIndices(samples, []meter.Pulse{pulse, outside}, nil) {
+		got[i] = true
+	}
+	want := map[int]bool{40: true, 45: true, 55: true, 60: true}
+	if !maps.Equal(got, want) {
+		t.Errorf("kept indices = %v, want %v", got, want)
+	}
+}
+
+func TestScopeWidget_MaxDisplayPoints(t *testing.T) {
+	test.NewTempApp(t)
+	cfg := config.Default()
+	s := New(cfg)
+
+	cfg.Scope.MaxDisplayPoints = 0
+	if got := s.maxDisplayPoints(); got != defaultMaxDisplayPoints {
+		t.Errorf("maxDisplayPoints() = %d unset, want %d", got, defaultMaxDisplayPoints)
+	}
+
+	cfg.Scope.MaxDisplayPoints = 50
+	s.UpdateData(samplesUntil(time.Unix(1_700_000_000, 0), 10*time.Second), nil, nil, nil, 0)
+	if n := len(s.displaySamples); n == 0 || n > 50 {
+		t.Errorf("%d display samples, want 1 to 50", n)
+	}
+}
+
+func TestScopeWidget_DownsampleKeepsPulseEdges(t *testing.T) {
+	test.NewTempApp(t)
+	s := New(config.Default())
+	s.cfg.Scope.MaxDisplayPoints = 20
+
+	t0 := time.Unix(1_700_000_000, 0)
+	samples := samplesUntil(t0, 10*time.Second)
+	pulse := meter.Pulse{
+		DetectStartTime: samples[33].Timestamp,
+		DetectEndTime:   samples[71].Timestamp,
+		StartTime:       samples[33].Timestamp,
+		EndTime:         samples[71].Timestamp,
+	}
+	s.UpdateData(samples, nil, []meter.Pulse{pulse}, nil, 0)
+
+	shown := map[time.Time]bool{}
+	for _, ds := range s.displaySamples {
+		shown[ds.Timestamp] = true
+	}
+	if !shown[pulse.StartTime] || !shown[pulse.EndTime] {
+		t.Errorf("pulse edges missing from the %d display samples", len(s.displaySamples))
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   color.RGBA
+		wantOK bool
+	}{
+		{"#FFA500", color.RGBA{R: 255, G: 165, B: 0, A: 255}, true},
+		{"#64c8ff80", color.RGBA{R: 100, G: 200, B: 255, A: 128}, true},
+		{"FFA500", color.RGBA{}, false},
+		{"#FFA5", color.RGBA{}, false},
+		{"#GGA500", color.RGBA{}, false},
+		{"", color.RGBA{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseColor(tt.in)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("parseColor(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestNewTraceStyles(t *testing.T) {
+	cfg := config.Default().Scope
+	cfg.Voltage = config.TraceConfig{Show: true, Color: "bad"}
+
+	styles := newTraceStyles(cfg)
+	if !styles.reading.show || styles.reading.color != readingColor || styles.reading.width != 1.5 {
+		t.Errorf("reading style = %+v, want the default orange at 1.5", styles.reading)
+	}
+	if styles.heaterPower.show {
+		t.Error("heater power shown by default")
+	}
+	if !styles.voltage.show || styles.voltage.color != voltageColor || styles.voltage.width != 1 {
+		t.Errorf("voltage style = %+v, want the fallback color and width", styles.voltage)
+	}
+}
+
+func TestNewScopeTheme(t *testing.T) {
+	theme := newScopeTheme(config.Default().Scope)
+	if theme.background != backgroundColor || theme.grid != gridColor || theme.label != labelColor {
+		t.Errorf("default theme colors = %v, %v, %v", theme.background, theme.grid, theme.label)
+	}
+	if theme.hDivisions != 8 || theme.vDivisions != 10 || !theme.pulseMarkers.show {
+		t.Errorf("default theme = %+v", theme)
+	}
+
+	cfg := config.Default().Scope
+	cfg.Background = "#000000"
+	cfg.Grid = config.GridConfig{HDivisions: 4, Color: "bad", Width: 2}
+	theme = newScopeTheme(cfg)
+	if theme.background != (color.RGBA{A: 255}) {
+		t.Errorf("background = %v, want black", theme.background)
+	}
+	if theme.hDivisions != 4 || theme.vDivisions != 10 || theme.gridWidth != 2 || theme.grid != gridColor {
+		t.Errorf("theme = %+v, want 4 divisions, default 10, width 2 and the fallback color", theme)
+	}
+}
+
+func TestUpdateAutoScale_IndependentAxes(t *testing.T) {
+	s := &ScopeWidget{cfg: config.Default()}
+	t0 := time.Unix(1_700_000_000, 0)
+
+	// Readings around 0.5 V and slopes of a few mV/s must not squash each other
+	s.displaySamples = []sample.Sample{
+		{Timestamp: t0, Reading: 0.458},
+		{Timestamp: t0.Add(time.Second), Reading: 0.462},
+	}
+	s.displayDerivatives = []float64{0.004}
+	s.updateAutoScale()
+
+	if math.Abs(s.sampleYMin-0.45) > 1e-9 || math.Abs(s.sampleYMax-0.47) > 1e-9 {
+		t.Errorf("sample axis = %v..%v V, want 0.45..0.47", s.sampleYMin, s.sampleYMax)
+	}
+	if math.Abs(s.derivativeYMin-(-0.001)) > 1e-9 || math.Abs(s.derivativeYMax-0.005) > 1e-9 {
+		t.Errorf("derivative axis = %v..%v V/s, want -0.001..0.005", s.derivativeYMin, s.derivativeYMax)
+	}
+}
+
+func TestUpdateAutoScale_FixedRanges(t *testing.T) {
+	cfg := config.Default()
+	cfg.Scope.ReadingRange = config.AxisRange{Min: -10, Max: 10}
+	s := &ScopeWidget{cfg: cfg}
+	t0 := time.Unix(1_700_000_000, 0)
+
+	s.displaySamples = []sample.Sample{
+		{Timestamp: t0, Reading: 0.458},
+		{Timestamp: t0.Add(time.Second), Reading: 0.462},
+	}
+	s.displayDerivatives = []float64{0.004}
+	s.updateAutoScale()
+
+	if math.Abs(s.sampleYMin-(-0.01)) > 1e-9 || math.Abs(s.sampleYMax-0.01) > 1e-9 {
+		t.Errorf("sample axis = %v..%v V, want the fixed -0.01..0.01", s.sampleYMin, s.sampleYMax)
+	}
+	if math.Abs(s.derivativeYMin-(-0.001)) > 1e-9 || math.Abs(s.derivativeYMax-0.005) > 1e-9 {
+		t.Errorf("derivative axis = %v..%v V/s, want auto-scaled -0.001..0.005", s.derivativeYMin, s.derivativeYMax)
+	}
+}
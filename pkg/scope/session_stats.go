@@ -0,0 +1,97 @@
+package scope
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+)
+
+// sessionStatsInterval limits how often the statistics change, so they stay
+// readable.
+const sessionStatsInterval = time.Second
+
+// SessionStatsRows returns the label and value rows describing s in the
+// display units, for the panel and for exports.
+func SessionStatsRows(s meter.SessionStats) [][2]string {
+	value := func(v float64, format func(float64) string) string {
+		if math.IsNaN(v) {
+			return "—"
+		}
+		return format(v)
+	}
+	return [][2]string{
+		{i18n.T("Pulses"), formatInt(int64(s.Pulses))},
+		{i18n.T("Mean Power"), value(s.MeanPower, formatPower)},
+		{i18n.T("Power σ"), value(s.StdDevPower, formatPower)},
+		{i18n.T("Max Power"), value(s.MaxPower, formatPower)},
+		{i18n.T("Total Energy"), formatEnergy(s.Energy)},
+		{i18n.T("Duration"), s.Duration.Round(time.Second).String()},
+		{i18n.T("Drift"), value(s.Drift, formatVoltageMV)},
+	}
+}
+
+// SessionStatsPanel is a panel summarizing the session beside the scope:
+// the number of pulses, their mean, spread and highest power, the total
+// energy, how long the session runs and how far the reading drifted.
+type SessionStatsPanel struct {
+	widget.BaseWidget
+
+	lastUpdate time.Time
+	stats      meter.SessionStats
+	form       *widget.Form
+	values     []*widget.Label
+}
+
+// NewSessionStatsPanel creates a panel of an empty session.
+func NewSessionStatsPanel() *SessionStatsPanel {
+	w := &SessionStatsPanel{form: widget.NewForm()}
+	for _, row := range SessionStatsRows(meter.ComputeSessionStats(nil, nil)) {
+		value := widget.NewLabelWithStyle("", fyne.TextAlignTrailing, fyne.TextStyle{Monospace: true})
+		w.values = append(w.values, value)
+		w.form.Append(row[0], value)
+	}
+	w.SetStats(meter.ComputeSessionStats(nil, nil))
+	w.ExtendBaseWidget(w)
+	return w
+}
+
+// Update shows s, at most every sessionStatsInterval. Hidden panels skip
+// the update. Call it on the main thread.
+func (w *SessionStatsPanel) Update(s meter.SessionStats) {
+	if !w.Visible() || time.Since(w.lastUpdate) < sessionStatsInterval {
+		return
+	}
+	w.SetStats(s)
+}
+
+// SetStats shows s now, e.g. of an opened session. Call it on the main
+// thread.
+func (w *SessionStatsPanel) SetStats(s meter.SessionStats) {
+	w.lastUpdate = time.Now()
+	w.stats = s
+	for i, row := range SessionStatsRows(s) {
+		w.values[i].SetText(row[1])
+	}
+}
+
+// Refresh shows the statistics again, e.g. in new display units.
+func (w *SessionStatsPanel) Refresh() {
+	w.SetStats(w.stats)
+	w.BaseWidget.Refresh()
+}
+
+// CreateRenderer creates the widget renderer.
+func (w *SessionStatsPanel) CreateRenderer() fyne.WidgetRenderer {
+	width := canvas.NewRectangle(color.Transparent)
+	width.SetMinSize(fyne.NewSize(liveWidth, 0))
+	title := widget.NewLabelWithStyle(i18n.T("Session"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	return widget.NewSimpleRenderer(container.NewPadded(container.NewStack(width,
+		container.NewBorder(container.NewVBox(widget.NewSeparator(), title), nil, nil, nil, w.form))))
+}
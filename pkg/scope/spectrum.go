@@ -0,0 +1,179 @@
+package scope
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// spectrumRangeDB is the amplitude range shown below the top of the plot.
+const spectrumRangeDB = 80.0
+
+// spectrumColor is used for the spectrum trace and its peak label.
+var spectrumColor = color.RGBA{R: 120, G: 220, B: 120, A: 255}
+
+// SpectrumWidget shows the amplitude spectrum of the readings in view on a
+// ScopeWidget, to spot periodic noise such as mains hum, chopper or fan
+// frequencies. Amplitudes are drawn in dB relative to 1 µV.
+type SpectrumWidget struct {
+	widget.BaseWidget
+
+	mu       sync.RWMutex
+	spectrum sample.Spectrum
+}
+
+// NewSpectrum creates an empty spectrum panel.
+func NewSpectrum() *SpectrumWidget {
+	w := &SpectrumWidget{}
+	w.ExtendBaseWidget(w)
+	return w
+}
+
+// Update computes the spectrum of samples and redraws. Hidden panels skip
+// the computation. Call it on the main thread.
+func (w *SpectrumWidget) Update(samples []sample.Sample) {
+	if !w.Visible() {
+		return
+	}
+	spectrum := sample.ComputeSpectrum(samples)
+
+	w.mu.Lock()
+	w.spectrum = spectrum
+	w.mu.Unlock()
+
+	w.Refresh()
+}
+
+// CreateRenderer creates the widget renderer.
+func (w *SpectrumWidget) CreateRenderer() fyne.WidgetRenderer {
+	return &spectrumRenderer{
+		spectrum:   w,
+		background: canvas.NewRectangle(color.RGBA{R: 20, G: 20, B: 20, A: 255}),
+	}
+}
+
+// spectrumRenderer renders the spectrum widget.
+type spectrumRenderer struct {
+	spectrum   *SpectrumWidget
+	background *canvas.Rectangle
+	objects    []fyne.CanvasObject
+}
+
+// MinSize returns the minimum size of the widget.
+func (r *spectrumRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(400, 150)
+}
+
+// Layout arranges the widget components.
+func (r *spectrumRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+	r.Refresh()
+}
+
+// Refresh rebuilds the plot from the current spectrum.
+func (r *spectrumRenderer) Refresh() {
+	r.spectrum.mu.RLock()
+	spectrum := r.spectrum.spectrum
+	r.spectrum.mu.RUnlock()
+
+	r.objects = []fyne.CanvasObject{r.background}
+	size := r.spectrum.Size()
+	plotX, plotY := marginLeft, marginTop
+	plotWidth := size.Width - marginLeft - marginRight
+	plotHeight := size.Height - marginTop - marginBottom
+	if plotWidth <= 0 || plotHeight <= 0 {
+		return
+	}
+
+	if len(spectrum.Amplitude) < 2 {
+		r.addText(i18n.T("No data for the spectrum"), plotX+10, plotY+10, color.RGBA{R: 150, G: 150, B: 150, A: 255}, 12)
+		return
+	}
+
+	levels := make([]float64, len(spectrum.Amplitude))
+	top := math.Inf(-1)
+	for i, a := range spectrum.Amplitude {
+		levels[i] = 20 * math.Log10(max(a, 1e-12)/1e-6)
+		if i > 0 {
+			top = max(top, levels[i])
+		}
+	}
+	top = math.Ceil(top/10) * 10
+	bottom := top - spectrumRangeDB
+	maxFreq := spectrum.Frequency(len(levels) - 1)
+
+	r.drawAxes(plotX, plotY, plotWidth, plotHeight, bottom, top, maxFreq)
+
+	// Skip the 0 Hz bin: the mean is removed, so it only shows window leakage
+	xOf := func(i int) float32 {
+		return plotX + float32(spectrum.Frequency(i)/maxFreq)*plotWidth
+	}
+	yOf := func(db float64) float32 {
+		frac := (min(max(db, bottom), top) - bottom) / (top - bottom)
+		return plotY + plotHeight - float32(frac)*plotHeight
+	}
+	for i := 2; i < len(levels); i++ {
+		line := canvas.NewLine(spectrumColor)
+		line.Position1 = fyne.NewPos(xOf(i-1), yOf(levels[i-1]))
+		line.Position2 = fyne.NewPos(xOf(i), yOf(levels[i]))
+		line.StrokeWidth = 1
+		r.objects = append(r.objects, line)
+	}
+
+	if peak := spectrum.Peak(); peak > 0 {
+		label := i18n.Tf("peak %.2f Hz, %s", spectrum.Frequency(peak), formatVoltageMV(spectrum.Amplitude[peak]))
+		r.addText(label, plotX+plotWidth-200, plotY+5, spectrumColor, 12)
+	}
+}
+
+// drawAxes draws the dB grid on the left and the frequency axis below.
+func (r *spectrumRenderer) drawAxes(plotX, plotY, plotWidth, plotHeight float32, bottom, top, maxFreq float64) {
+	gridColor := color.RGBA{R: 40, G: 40, B: 40, A: 255}
+	labelColor := color.RGBA{R: 150, G: 150, B: 150, A: 255}
+
+	const hLines = 4
+	for i := range hLines + 1 {
+		y := plotY + float32(i)*plotHeight/hLines
+		line := canvas.NewLine(gridColor)
+		line.Position1 = fyne.NewPos(plotX, y)
+		line.Position2 = fyne.NewPos(plotX+plotWidth, y)
+		r.objects = append(r.objects, line)
+
+		db := top - float64(i)*(top-bottom)/hLines
+		r.addText(fmt.Sprintf("%.0f dBµV", db), 2, y-7, labelColor, 10)
+	}
+
+	const vLines = 5
+	for i := range vLines + 1 {
+		x := plotX + float32(i)*plotWidth/vLines
+		line := canvas.NewLine(gridColor)
+		line.Position1 = fyne.NewPos(x, plotY)
+		line.Position2 = fyne.NewPos(x, plotY+plotHeight)
+		r.objects = append(r.objects, line)
+
+		r.addText(fmt.Sprintf("%.1f Hz", maxFreq*float64(i)/vLines), x-20, plotY+plotHeight+5, labelColor, 10)
+	}
+}
+
+// addText adds a text label at x, y.
+func (r *spectrumRenderer) addText(s string, x, y float32, c color.RGBA, size float32) {
+	text := canvas.NewText(s, c)
+	text.TextSize = size
+	text.Move(fyne.NewPos(x, y))
+	r.objects = append(r.objects, text)
+}
+
+// Objects returns all canvas objects for rendering.
+func (r *spectrumRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+// Destroy cleans up renderer resources.
+func (r *spectrumRenderer) Destroy() {}
@@ -0,0 +1,57 @@
+package scope
+
+import (
+	"image/color"
+
+	"github.com/itohio/golpm/pkg/config"
+)
+
+// Fallback theme colors for missing or invalid configured colors.
+var (
+	backgroundColor  = color.RGBA{R: 20, G: 20, B: 20, A: 255}    // Dark background
+	gridColor        = color.RGBA{R: 40, G: 40, B: 40, A: 255}    // Dark gray
+	labelColor       = color.RGBA{R: 150, G: 150, B: 150, A: 255} // Gray
+	pulseMarkerColor = color.RGBA{R: 0, G: 100, B: 200, A: 255}   // Dark blue
+)
+
+// scopeTheme is the resolved appearance of the plot around the traces.
+type scopeTheme struct {
+	background   color.RGBA
+	grid         color.RGBA
+	gridWidth    float32
+	label        color.RGBA
+	hDivisions   int
+	vDivisions   int
+	pulseMarkers traceStyle
+}
+
+func newScopeTheme(cfg config.ScopeConfig) scopeTheme {
+	t := scopeTheme{
+		background:   backgroundColor,
+		grid:         gridColor,
+		gridWidth:    1,
+		label:        labelColor,
+		hDivisions:   8,
+		vDivisions:   10,
+		pulseMarkers: newTraceStyle(cfg.PulseMarkers, pulseMarkerColor, 1.0),
+	}
+	if c, ok := parseColor(cfg.Background); ok {
+		t.background = c
+	}
+	if c, ok := parseColor(cfg.Grid.Color); ok {
+		t.grid = c
+	}
+	if c, ok := parseColor(cfg.Grid.LabelColor); ok {
+		t.label = c
+	}
+	if cfg.Grid.Width > 0 {
+		t.gridWidth = cfg.Grid.Width
+	}
+	if cfg.Grid.HDivisions > 0 {
+		t.hDivisions = cfg.Grid.HDivisions
+	}
+	if cfg.Grid.VDivisions > 0 {
+		t.vDivisions = cfg.Grid.VDivisions
+	}
+	return t
+}
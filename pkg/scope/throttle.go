@@ -0,0 +1,142 @@
+package scope
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+const (
+	// minRefreshInterval caps the redraw rate at about 60 FPS unless the
+	// configuration sets another interval.
+	minRefreshInterval = 16 * time.Millisecond
+
+	// maxRefreshInterval keeps the scope moving on the slowest machines.
+	maxRefreshInterval = 500 * time.Millisecond
+
+	// refreshLoad is the share of the main thread the redraws may take.
+	refreshLoad = 0.25
+
+	// fpsWindow is the time over which the redraw rate is counted.
+	fpsWindow = time.Second
+)
+
+// refreshThrottle limits how often the scope redraws for new data. The
+// interval adapts to how long the redraws take, so a slow machine redraws
+// less often instead of falling behind and freezing the UI.
+type refreshThrottle struct {
+	interval  time.Duration // Minimum time from one redraw to the next
+	last      time.Time     // Start of the last redraw
+	cost      time.Duration // Smoothed duration of a redraw
+	scheduled bool          // A deferred redraw is pending
+
+	frames      int       // Redraws since windowStart
+	windowStart time.Time // Start of the current FPS count
+	fps         float64   // Redraws per second in the last complete count
+}
+
+// wait returns how long after now the next redraw is due, 0 if it is.
+func (t *refreshThrottle) wait(now time.Time) time.Duration {
+	return max(t.last.Add(t.interval).Sub(now), 0)
+}
+
+// record adapts the interval to a redraw that started at start and took
+// elapsed, keeping at least minInterval between redraws (0 =
+// minRefreshInterval).
+func (t *refreshThrottle) record(start time.Time, elapsed, minInterval time.Duration) {
+	t.last = start
+	if t.cost == 0 {
+		t.cost = elapsed
+	} else {
+		t.cost += (elapsed - t.cost) / 4
+	}
+	if minInterval <= 0 {
+		minInterval = minRefreshInterval
+	}
+	interval := time.Duration(float64(t.cost) / refreshLoad)
+	t.interval = min(max(interval, minInterval), max(maxRefreshInterval, minInterval))
+
+	t.frames++
+	if t.windowStart.IsZero() {
+		t.windowStart = start
+	} else if window := start.Sub(t.windowStart); window >= fpsWindow {
+		t.fps = float64(t.frames-1) / window.Seconds()
+		t.frames, t.windowStart = 1, start
+	}
+}
+
+// Performance is how the scope keeps up with its updates.
+type Performance struct {
+	FPS      float64       // Redraws per second over the last second
+	Redraw   time.Duration // Smoothed time a redraw takes on the main thread
+	Interval time.Duration // Current minimum time between redraws
+}
+
+// performance returns the throttle's figures at now. The rate drops to 0
+// when nothing was redrawn for a count.
+func (t *refreshThrottle) performance(now time.Time) Performance {
+	p := Performance{FPS: t.fps, Redraw: t.cost, Interval: t.interval}
+	if t.last.IsZero() || now.Sub(t.last) >= 2*fpsWindow {
+		p.FPS = 0
+	}
+	return p
+}
+
+// Performance returns the achieved redraw rate and the cost of a redraw,
+// e.g. for a performance overlay.
+func (s *ScopeWidget) Performance() Performance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.throttle.performance(time.Now())
+}
+
+// throttled marks the view as stale and reports whether its redraw has to
+// wait for the throttle, in which case a redraw is scheduled. Must be called
+// with mu held.
+func (s *ScopeWidget) throttled() bool {
+	s.stale = true
+	wait := s.throttle.wait(time.Now())
+	if wait == 0 {
+		return false
+	}
+	if !s.throttle.scheduled {
+		s.throttle.scheduled = true
+		time.AfterFunc(wait, func() { fyne.Do(s.flush) })
+	}
+	return true
+}
+
+// flush redraws the data held back by the throttle.
+func (s *ScopeWidget) flush() {
+	s.mu.Lock()
+	s.throttle.scheduled = false
+	stale := s.stale
+	s.mu.Unlock()
+
+	if stale {
+		s.redraw()
+	}
+}
+
+// redraw selects the data in view and redraws it, timing both for the
+// throttle. Call it on the main thread.
+func (s *ScopeWidget) redraw() {
+	start := time.Now()
+	s.mu.Lock()
+	s.updateView()
+	s.stale = false
+	s.mu.Unlock()
+
+	// Refresh the widget (must be outside lock to avoid potential deadlock)
+	// This triggers the renderer's Refresh() method which rebuilds all canvas objects
+	s.Refresh()
+
+	// Explicitly refresh as a canvas object to ensure Fyne invalidates and repaints
+	// This is critical - without this, Fyne may only repaint on user interaction
+	canvas.Refresh(s)
+
+	s.mu.Lock()
+	s.throttle.record(start, time.Since(start), s.cfg.Scope.RefreshInterval)
+	s.mu.Unlock()
+}
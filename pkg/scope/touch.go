@@ -0,0 +1,96 @@
+package scope
+
+import (
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/mobile"
+)
+
+// Fyne reports each finger on a touch screen with TouchDown and TouchUp,
+// but the moves of all fingers arrive as Dragged events without saying
+// which finger moved. The scope keeps the last position of each finger and
+// matches every move to the nearest one; with two fingers down, moves pinch
+// to zoom and pan by the movement of the point between the fingers.
+//
+// Desktop drivers report no touches: there, touchpads pan with a horizontal
+// two-finger scroll and zoom with a vertical one, like a mouse wheel.
+
+// minPinchSpan is the horizontal finger distance below which a pinch does
+// not zoom, as the zoom factor would be mostly noise.
+const minPinchSpan = 20
+
+// TouchDown starts tracking a finger.
+func (s *ScopeWidget) TouchDown(ev *mobile.TouchEvent) {
+	s.mu.Lock()
+	s.touches = append(s.touches, ev.Position)
+	s.mu.Unlock()
+}
+
+// TouchUp stops tracking the finger nearest to the event.
+func (s *ScopeWidget) TouchUp(ev *mobile.TouchEvent) {
+	s.mu.Lock()
+	if i := nearestTouch(s.touches, ev.Position); i >= 0 {
+		s.touches = append(s.touches[:i], s.touches[i+1:]...)
+	}
+	s.mu.Unlock()
+}
+
+// TouchCancel stops tracking the finger nearest to the event.
+func (s *ScopeWidget) TouchCancel(ev *mobile.TouchEvent) {
+	s.TouchUp(ev)
+}
+
+// pinchDragged applies a move of one of two fingers as a pinch and pan,
+// and reports whether two fingers are down. Must be called with mu held.
+func (s *ScopeWidget) pinchDragged(ev *fyne.DragEvent) bool {
+	if len(s.touches) < 2 {
+		return false
+	}
+	prev := ev.Position.Subtract(ev.Dragged)
+	moved := nearestTouch(s.touches[:2], prev)
+	fixed := s.touches[1-moved]
+	s.touches[moved] = ev.Position
+
+	width := s.plotWidth()
+	if len(s.samples) < 2 || width <= 0 {
+		return true
+	}
+	factor, anchorX, centerDX := pinch(fixed, prev, ev.Position)
+	full := s.samples[len(s.samples)-1].Timestamp.Sub(s.samples[0].Timestamp)
+	if factor != 1 {
+		s.view = s.view.zoom(factor, s.timeAt(anchorX), s.xMin, s.xMax, full)
+		s.updateView()
+	}
+	shift := time.Duration(float64(centerDX) / float64(width) * float64(s.xMax.Sub(s.xMin)))
+	s.view = s.view.pan(shift, s.xMin, s.xMax, s.samples[len(s.samples)-1].Timestamp)
+	s.updateView()
+	return true
+}
+
+// pinch returns the time axis zoom factor when one finger moves from prev
+// to next while the other stays at fixed, the horizontal position to zoom
+// around, and how far the point between the fingers moved horizontally.
+// Spreading the fingers zooms in.
+func pinch(fixed, prev, next fyne.Position) (factor float64, anchorX, centerDX float32) {
+	before := math.Abs(float64(prev.X - fixed.X))
+	after := math.Abs(float64(next.X - fixed.X))
+	factor = 1
+	if before >= minPinchSpan && after >= minPinchSpan {
+		factor = before / after
+	}
+	return factor, (fixed.X + next.X) / 2, (next.X - prev.X) / 2
+}
+
+// nearestTouch returns the index of the finger nearest to pos, or -1.
+func nearestTouch(touches []fyne.Position, pos fyne.Position) int {
+	best, bestDist := -1, float32(math.MaxFloat32)
+	for i, t := range touches {
+		dx, dy := t.X-pos.X, t.Y-pos.Y
+		if d := dx*dx + dy*dy; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,88 @@
+package scope
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/itohio/golpm/pkg/config"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+// traceStyle is the resolved appearance of a trace.
+type traceStyle struct {
+	show  bool
+	color color.RGBA
+	width float32
+}
+
+// Fallback trace colors for missing or invalid configured colors.
+var (
+	readingColor     = color.RGBA{R: 255, G: 165, B: 0, A: 255}   // Orange
+	derivativeColor  = color.RGBA{R: 100, G: 200, B: 255, A: 255} // Light blue
+	voltageColor     = color.RGBA{R: 180, G: 180, B: 200, A: 255} // Gray
+	heaterPowerColor = color.RGBA{R: 255, G: 80, B: 80, A: 255}   // Red
+)
+
+// envelopeAlpha is the opacity of the min/max band behind a downsampled
+// trace.
+const envelopeAlpha = 80
+
+// traceStyles resolves the configured traces of the scope.
+type traceStyles struct {
+	reading, derivative, voltage, heaterPower traceStyle
+}
+
+func newTraceStyles(cfg config.ScopeConfig) traceStyles {
+	return traceStyles{
+		reading:     newTraceStyle(cfg.Reading, readingColor, 1.5),
+		derivative:  newTraceStyle(cfg.Derivative, derivativeColor, 1.0),
+		voltage:     newTraceStyle(cfg.Voltage, voltageColor, 1.0),
+		heaterPower: newTraceStyle(cfg.HeaterPower, heaterPowerColor, 1.0),
+	}
+}
+
+func newTraceStyle(cfg config.TraceConfig, fallback color.RGBA, width float32) traceStyle {
+	style := traceStyle{show: cfg.Show, color: fallback, width: width}
+	if c, ok := parseColor(cfg.Color); ok {
+		style.color = c
+	}
+	if cfg.Width > 0 {
+		style.width = cfg.Width
+	}
+	return style
+}
+
+// parseColor parses "#RRGGBB" or "#RRGGBBAA".
+func parseColor(s string) (color.RGBA, bool) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok || (len(hex) != 6 && len(hex) != 8) {
+		return color.RGBA{}, false
+	}
+	if len(hex) == 6 {
+		hex += "FF"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, true
+}
+
+// samplePoints extracts one field of the samples as a curve.
+func samplePoints(samples []sample.Sample, value func(sample.Sample) float64) []dataPoint {
+	points := make([]dataPoint, len(samples))
+	for i, s := range samples {
+		points[i] = dataPoint{time: s.Timestamp, value: value(s)}
+	}
+	return points
+}
+
+// pointsRange returns the snapped value range of points, like the axes.
+func pointsRange(points []dataPoint) (float64, float64) {
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.value
+	}
+	return calculateRangeFromValues(values)
+}
@@ -0,0 +1,188 @@
+package scope
+
+import (
+	"time"
+
+	"github.com/itohio/golpm/pkg/i18n"
+	"github.com/itohio/golpm/pkg/meter"
+)
+
+// TriggerMode selects how the scope follows incoming data.
+type TriggerMode int
+
+const (
+	// TriggerRoll scrolls continuously with the newest data.
+	TriggerRoll TriggerMode = iota
+	// TriggerAuto captures a window around each detected pulse, and rolls
+	// when no pulse arrives for a whole window.
+	TriggerAuto
+	// TriggerNormal captures a window around each detected pulse and holds
+	// the last capture until the next one.
+	TriggerNormal
+	// TriggerSingle captures the next detected pulse, then stops until
+	// re-armed.
+	TriggerSingle
+)
+
+// String returns the mode name shown in the UI.
+func (m TriggerMode) String() string {
+	switch m {
+	case TriggerAuto:
+		return "Auto"
+	case TriggerNormal:
+		return "Normal"
+	case TriggerSingle:
+		return "Single"
+	default:
+		return "Roll"
+	}
+}
+
+// TriggerModes lists all modes in UI order.
+var TriggerModes = []TriggerMode{TriggerRoll, TriggerAuto, TriggerNormal, TriggerSingle}
+
+// preTriggerFraction is the share of the capture window before the trigger.
+const preTriggerFraction = 0.25
+
+// triggerState tracks captures around pulse detections.
+type triggerState struct {
+	mode    TriggerMode
+	armed   bool      // Waiting for the next pulse
+	at      time.Time // Detection time of the pulse being captured, zero if none
+	lastID  int       // ID of the last pulse that triggered, so each pulse triggers once
+	shown   time.Time // Trigger time of the capture on display, zero if rolling
+	settled time.Time // Newest sample time when last armed or captured, for Auto
+	latest  *scopeData
+}
+
+// status returns the indicator text, empty while rolling.
+func (t *triggerState) status() string {
+	switch {
+	case t.mode == TriggerRoll:
+		return ""
+	case !t.at.IsZero():
+		return i18n.T("TRIGGERED")
+	case t.armed && t.mode == TriggerAuto && t.shown.IsZero():
+		return i18n.T("AUTO")
+	case t.armed:
+		return i18n.T("ARMED")
+	default:
+		return i18n.T("STOPPED")
+	}
+}
+
+// newestPulse returns the newest pulse, tracked or completed, or nil.
+// Pulse IDs increase, starting at 1.
+func newestPulse(pulses []meter.Pulse, active *meter.Pulse) *meter.Pulse {
+	newest := active
+	if n := len(pulses); n > 0 && (newest == nil || pulses[n-1].ID > newest.ID) {
+		newest = &pulses[n-1]
+	}
+	return newest
+}
+
+// newestPulseID returns the ID of the newest pulse received, 0 if none,
+// so that pulses already seen do not trigger. Must be called with mu held.
+func (s *ScopeWidget) newestPulseID() int {
+	pulses, active := s.pulses, s.activePulse
+	if d := s.trigger.latest; d != nil {
+		pulses, active = d.pulses, d.activePulse
+	}
+	if p := newestPulse(pulses, active); p != nil {
+		return p.ID
+	}
+	return 0
+}
+
+// captureWindow returns the pre- and post-trigger parts of a window.
+func captureWindow(window time.Duration) (pre, post time.Duration) {
+	pre = time.Duration(float64(window) * preTriggerFraction)
+	return pre, window - pre
+}
+
+// SetTriggerMode selects the trigger mode and arms the trigger. Rolling
+// shows the newest data again.
+func (s *ScopeWidget) SetTriggerMode(mode TriggerMode) {
+	s.mu.Lock()
+	latest := s.trigger.latest
+	if latest != nil && mode == TriggerRoll {
+		s.showData(latest)
+		s.showHistory()
+		latest = nil
+	}
+	s.trigger = triggerState{mode: mode, armed: mode != TriggerRoll, lastID: s.newestPulseID(), latest: latest}
+	s.view = viewState{}
+	s.updateView()
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// TriggerMode returns the selected trigger mode.
+func (s *ScopeWidget) TriggerMode() TriggerMode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.trigger.mode
+}
+
+// Arm re-arms the trigger, e.g. after a single capture. Pulses detected
+// before arming do not trigger.
+func (s *ScopeWidget) Arm() {
+	s.mu.Lock()
+	if s.trigger.mode != TriggerRoll {
+		s.trigger.armed = true
+		s.trigger.at = time.Time{}
+		s.trigger.settled = time.Time{}
+		s.trigger.lastID = s.newestPulseID()
+	}
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// updateTrigger feeds an update through the trigger. The data shown only
+// changes when a capture completes, or while Auto rolls; the caller updates
+// the view. Must be called with mu held.
+func (s *ScopeWidget) updateTrigger(d *scopeData) {
+	t := &s.trigger
+	t.latest = d
+	if len(d.samples) == 0 {
+		return
+	}
+	newest := d.samples[len(d.samples)-1].Timestamp
+	if t.settled.IsZero() {
+		t.settled = newest
+	}
+	pre, post := captureWindow(time.Duration(s.cfg.Measurement.WindowSeconds) * time.Second)
+
+	if t.armed && t.at.IsZero() {
+		if p := newestPulse(d.pulses, d.activePulse); p != nil && p.ID > t.lastID {
+			t.at, t.lastID = p.DetectStartTime, p.ID
+		}
+	}
+
+	switch {
+	case !t.at.IsZero() && !newest.Before(t.at.Add(post)):
+		// Capture complete: show the window around the trigger
+		s.showData(d)
+		s.view = viewState{span: pre + post, end: t.at.Add(post)}
+		t.shown, t.at, t.settled = t.at, time.Time{}, newest
+		t.armed = t.mode != TriggerSingle
+	case t.mode == TriggerAuto && t.at.IsZero() && newest.Sub(t.settled) > pre+post:
+		// No pulse for a whole window: roll until the next one
+		s.showData(d)
+		s.view = viewState{}
+		t.shown = time.Time{}
+	}
+}
+
+// showData replaces the displayed data. Must be called with mu held.
+func (s *ScopeWidget) showData(d *scopeData) {
+	s.samples, s.derivatives, s.pulses = d.samples, d.derivatives, d.pulses
+	s.activePulse, s.heaterPower = d.activePulse, d.heaterPower
+}
+
+// triggerMarker returns the trigger time of the capture on display.
+func (s *ScopeWidget) triggerMarker() (time.Time, bool) {
+	return s.trigger.shown, !s.trigger.shown.IsZero()
+}
@@ -0,0 +1,195 @@
+package scope
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/itohio/golpm/pkg/sample"
+)
+
+const (
+	// minViewSpan limits zooming in on the time axis.
+	minViewSpan = 100 * time.Millisecond
+
+	// zoomStep is the span factor per mouse wheel notch.
+	zoomStep = 1.25
+)
+
+// viewState is the time range chosen by zooming and panning. The zero value
+// is the live view: the newest data, as long as the meter's window.
+type viewState struct {
+	span time.Duration // Visible time span, 0 for the live window
+	end  time.Time     // Right edge, zero to follow the newest data
+}
+
+// live reports whether the view follows the newest data.
+func (v viewState) live() bool {
+	return v.end.IsZero()
+}
+
+// window returns the visible time range for data from first to last.
+// The live window shows the newest minSpan of data, starting at first until
+// there is more; with minSpan 0 it shows all of it.
+func (v viewState) window(first, last time.Time, minSpan time.Duration) (xMin, xMax time.Time) {
+	if v.span == 0 && v.live() {
+		switch {
+		case minSpan <= 0:
+			return first, last
+		case last.Sub(first) < minSpan:
+			return first, first.Add(minSpan)
+		default:
+			return last.Add(-minSpan), last
+		}
+	}
+
+	xMax = last
+	if !v.live() {
+		xMax = v.end
+	}
+	span := v.span
+	if span == 0 {
+		span = minSpan
+		if minSpan <= 0 {
+			span = last.Sub(first)
+		}
+	}
+	return xMax.Add(-span), xMax
+}
+
+// sweepWindow returns the sweep of length span that holds last. Sweeps
+// start at multiples of span, so the trace is drawn left to right and
+// restarts at the left edge when it reaches the right one.
+func sweepWindow(last time.Time, span time.Duration) (xMin, xMax time.Time) {
+	xMin = last.Truncate(span)
+	return xMin, xMin.Add(span)
+}
+
+// zoom scales the visible range xMin..xMax by factor around anchor, which
+// keeps its position on screen. The live view keeps following the newest
+// data instead. Zooming out stops at the whole history of length full.
+func (v viewState) zoom(factor float64, anchor, xMin, xMax time.Time, full time.Duration) viewState {
+	current := xMax.Sub(xMin)
+	span := max(time.Duration(float64(current)*factor), minViewSpan)
+	if factor > 1 {
+		span = max(min(span, full), current)
+	}
+
+	if v.live() {
+		return viewState{span: span}
+	}
+	offset := time.Duration(float64(anchor.Sub(xMin)) / float64(current) * float64(span))
+	return viewState{span: span, end: anchor.Add(span - offset)}
+}
+
+// pan moves the visible range xMin..xMax back in time by shift. Panning
+// past the newest data at last returns to the live view.
+func (v viewState) pan(shift time.Duration, xMin, xMax, last time.Time) viewState {
+	end := xMax.Add(-shift)
+	span := xMax.Sub(xMin)
+	if !end.Before(last) {
+		return viewState{span: v.span}
+	}
+	return viewState{span: span, end: end}
+}
+
+// visibleRange returns the index range of samples within xMin..xMax.
+// samples must be in time order.
+func visibleRange(samples []sample.Sample, xMin, xMax time.Time) (int, int) {
+	from := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].Timestamp.Before(xMin)
+	})
+	to := sort.Search(len(samples), func(i int) bool {
+		return samples[i].Timestamp.After(xMax)
+	})
+	return from, to
+}
+
+// Scrolled zooms the time axis with the mouse wheel, around the pointer.
+// Mostly horizontal scrolling, e.g. a two-finger swipe on a touchpad, pans.
+func (s *ScopeWidget) Scrolled(ev *fyne.ScrollEvent) {
+	if math.Abs(float64(ev.Scrolled.DX)) > math.Abs(float64(ev.Scrolled.DY)) {
+		s.Dragged(&fyne.DragEvent{PointEvent: ev.PointEvent, Dragged: fyne.Delta{DX: ev.Scrolled.DX}})
+		return
+	}
+	if ev.Scrolled.DY == 0 {
+		return
+	}
+	factor := zoomStep
+	if ev.Scrolled.DY > 0 {
+		factor = 1 / zoomStep
+	}
+
+	s.mu.Lock()
+	if len(s.samples) < 2 {
+		s.mu.Unlock()
+		return
+	}
+	anchor := s.timeAt(ev.Position.X)
+	full := s.samples[len(s.samples)-1].Timestamp.Sub(s.samples[0].Timestamp)
+	s.view = s.view.zoom(factor, anchor, s.xMin, s.xMax, full)
+	s.updateView()
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// Dragged pans the time axis; dragging right shows older data. With two
+// fingers on a touch screen it pinches and pans instead.
+func (s *ScopeWidget) Dragged(ev *fyne.DragEvent) {
+	s.mu.Lock()
+	if s.pinchDragged(ev) {
+		s.mu.Unlock()
+		s.Refresh()
+		return
+	}
+	width := s.plotWidth()
+	if len(s.samples) < 2 || width <= 0 {
+		s.mu.Unlock()
+		return
+	}
+	shift := time.Duration(float64(ev.Dragged.DX) / float64(width) * float64(s.xMax.Sub(s.xMin)))
+	s.view = s.view.pan(shift, s.xMin, s.xMax, s.samples[len(s.samples)-1].Timestamp)
+	s.updateView()
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// DragEnd implements fyne.Draggable.
+func (s *ScopeWidget) DragEnd() {}
+
+// IsLive reports whether the scope follows the newest data.
+func (s *ScopeWidget) IsLive() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.view.live()
+}
+
+// SetLive resets zoom and pan, so the scope shows the meter's window and
+// follows the newest data again.
+func (s *ScopeWidget) SetLive() {
+	s.mu.Lock()
+	s.view = viewState{}
+	s.updateView()
+	s.mu.Unlock()
+
+	s.Refresh()
+}
+
+// timeAt returns the time under the horizontal widget position x.
+func (s *ScopeWidget) timeAt(x float32) time.Time {
+	width := s.plotWidth()
+	if width <= 0 {
+		return s.xMax
+	}
+	frac := float64((x - marginLeft) / width)
+	frac = min(max(frac, 0), 1)
+	return s.xMin.Add(time.Duration(frac * float64(s.xMax.Sub(s.xMin))))
+}
+
+// plotWidth returns the width of the plot area.
+func (s *ScopeWidget) plotWidth() float32 {
+	return s.Size().Width - marginLeft - marginRight
+}
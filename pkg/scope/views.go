@@ -0,0 +1,65 @@
+package scope
+
+import (
+	"strings"
+
+	"github.com/itohio/golpm/pkg/config"
+)
+
+// ParseTriggerMode returns the trigger mode named s, ignoring case, or
+// false if there is none.
+func ParseTriggerMode(s string) (TriggerMode, bool) {
+	for _, m := range TriggerModes {
+		if strings.EqualFold(m.String(), s) {
+			return m, true
+		}
+	}
+	return TriggerRoll, false
+}
+
+// CurrentView returns the zoom, trace visibility, axis locks and trigger
+// mode of the scope as a view called name. The panned position is not
+// part of a view: views follow the newest data.
+func (s *ScopeWidget) CurrentView(name string) config.ScopeView {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sc := s.cfg.Scope
+	return config.ScopeView{
+		Name: name,
+		Span: s.view.span,
+		Traces: config.TraceVisibility{
+			Reading:     sc.Reading.Show,
+			Derivative:  sc.Derivative.Show,
+			Voltage:     sc.Voltage.Show,
+			HeaterPower: sc.HeaterPower.Show,
+		},
+		ReadingRange:    sc.ReadingRange,
+		DerivativeRange: sc.DerivativeRange,
+		Trigger:         strings.ToLower(s.trigger.mode.String()),
+	}
+}
+
+// ApplyView switches the scope to v. Trace visibility and axis locks are
+// written to the scope configuration. Unknown trigger modes roll.
+func (s *ScopeWidget) ApplyView(v config.ScopeView) {
+	mode, _ := ParseTriggerMode(v.Trigger)
+	s.SetTriggerMode(mode)
+
+	s.mu.Lock()
+	sc := &s.cfg.Scope
+	sc.Reading.Show = v.Traces.Reading
+	sc.Derivative.Show = v.Traces.Derivative
+	sc.Voltage.Show = v.Traces.Voltage
+	sc.HeaterPower.Show = v.Traces.HeaterPower
+	sc.ReadingRange = v.ReadingRange
+	sc.DerivativeRange = v.DerivativeRange
+	s.view = viewState{}
+	if v.Span > 0 {
+		s.view.span = max(v.Span, minViewSpan)
+	}
+	s.updateView()
+	s.mu.Unlock()
+
+	s.Refresh()
+}
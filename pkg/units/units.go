@@ -0,0 +1,113 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// PowerUnit is a unit power is displayed in. Measured values are held in
+// SI units and converted for display by Power, Energy, Reading and Slope.
+type PowerUnit string
+
+// Power units.
+const (
+	MilliWatt PowerUnit = "mW"
+	Watt      PowerUnit = "W"
+	DBm       PowerUnit = "dBm" // Decibels relative to 1 mW
+)
+
+// PowerUnits are the units power can be displayed in, the default first.
+var PowerUnits = []PowerUnit{MilliWatt, Watt, DBm}
+
+// VoltageUnit is a unit readings are displayed in.
+type VoltageUnit string
+
+// Voltage units.
+const (
+	MilliVolt VoltageUnit = "mV"
+	Volt      VoltageUnit = "V"
+)
+
+// VoltageUnits are the units readings can be displayed in, the default first.
+var VoltageUnits = []VoltageUnit{MilliVolt, Volt}
+
+// Units are the units values are displayed in.
+type Units struct {
+	Power   PowerUnit
+	Reading VoltageUnit
+}
+
+// Default are the units displayed unless others are selected.
+var Default = Units{Power: MilliWatt, Reading: MilliVolt}
+
+// selected holds the selected units, nil for Default.
+var selected atomic.Pointer[Units]
+
+// Set selects the units of power and readings, e.g. "W" and "V". An unknown
+// unit keeps its default and is reported as an error.
+func Set(power, reading string) error {
+	u := Default
+	var err error
+	switch p := PowerUnit(power); p {
+	case MilliWatt, Watt, DBm:
+		u.Power = p
+	default:
+		err = fmt.Errorf("unknown power unit %q", power)
+	}
+	switch r := VoltageUnit(reading); r {
+	case MilliVolt, Volt:
+		u.Reading = r
+	default:
+		err = fmt.Errorf("unknown reading unit %q", reading)
+	}
+	selected.Store(&u)
+	return err
+}
+
+// Selected returns the selected units.
+func Selected() Units {
+	if u := selected.Load(); u != nil {
+		return *u
+	}
+	return Default
+}
+
+// Power converts w in W to the selected power unit. No power is -Inf dBm.
+func Power(w float64) (float64, PowerUnit) {
+	switch u := Selected().Power; u {
+	case Watt:
+		return w, u
+	case DBm:
+		if w <= 0 {
+			return math.Inf(-1), u
+		}
+		return 10 * math.Log10(w*1000), u
+	default:
+		return w * 1000, MilliWatt
+	}
+}
+
+// Energy converts j in J to the energy unit matching the selected power
+// unit: J for W, else mJ.
+func Energy(j float64) (float64, string) {
+	if Selected().Power == Watt {
+		return j, "J"
+	}
+	return j * 1000, "mJ"
+}
+
+// Reading converts v in V to the selected reading unit.
+func Reading(v float64) (float64, VoltageUnit) {
+	if u := Selected().Reading; u == Volt {
+		return v, u
+	}
+	return v * 1000, MilliVolt
+}
+
+// Slope converts a slope in V/s to the selected reading unit per second,
+// returning the value and its unit, e.g. "mV/s".
+func Slope(vps float64) (float64, string) {
+	v, u := Reading(vps)
+	return v, string(u) + "/s"
+}
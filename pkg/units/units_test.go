@@ -0,0 +1,53 @@
+package units
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+	t.Cleanup(func() { Set("mW", "mV") })
+
+	assert.Equal(t, Default, Selected())
+	v, u := Power(0.0125)
+	assert.InDelta(t, 12.5, v, 1e-9)
+	assert.Equal(t, MilliWatt, u)
+
+	assert.NoError(t, Set("W", "V"))
+	assert.Equal(t, Units{Power: Watt, Reading: Volt}, Selected())
+	v, u = Power(0.0125)
+	assert.Equal(t, 0.0125, v)
+	assert.Equal(t, Watt, u)
+	e, eu := Energy(0.5)
+	assert.Equal(t, 0.5, e)
+	assert.Equal(t, "J", eu)
+	r, ru := Reading(0.002)
+	assert.Equal(t, 0.002, r)
+	assert.Equal(t, Volt, ru)
+	_, su := Slope(0.001)
+	assert.Equal(t, "V/s", su)
+
+	assert.Error(t, Set("hp", "V"))
+	assert.Equal(t, Units{Power: MilliWatt, Reading: Volt}, Selected(), "unknown units keep the default")
+}
+
+func TestPower_DBm(t *testing.T) {
+	t.Cleanup(func() { Set("mW", "mV") })
+	Set("dBm", "mV")
+
+	v, u := Power(0.001)
+	assert.Equal(t, DBm, u)
+	assert.InDelta(t, 0, v, 1e-9, "1 mW is 0 dBm")
+	v, _ = Power(0.1)
+	assert.InDelta(t, 20, v, 1e-9)
+	v, _ = Power(0)
+	assert.True(t, math.IsInf(v, -1))
+	e, eu := Energy(0.5)
+	assert.Equal(t, 500.0, e)
+	assert.Equal(t, "mJ", eu)
+	s, su := Slope(0.001)
+	assert.InDelta(t, 1, s, 1e-9)
+	assert.Equal(t, "mV/s", su)
+}